@@ -12,19 +12,35 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	"github.com/sirupsen/logrus"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 
 	"lyss-ai-platform/eino-service/internal/client"
 	"lyss-ai-platform/eino-service/internal/config"
 	"lyss-ai-platform/eino-service/internal/handlers"
 	"lyss-ai-platform/eino-service/internal/workflows"
+	"lyss-ai-platform/eino-service/pkg/audit"
+	"lyss-ai-platform/eino-service/pkg/bodylimit"
 	"lyss-ai-platform/eino-service/pkg/credential"
+	"lyss-ai-platform/eino-service/pkg/featureflag"
 	"lyss-ai-platform/eino-service/pkg/health"
+	"lyss-ai-platform/eino-service/pkg/idempotency"
+	"lyss-ai-platform/eino-service/pkg/prompts"
+	"lyss-ai-platform/eino-service/pkg/prompttrace"
+	"lyss-ai-platform/eino-service/pkg/redact"
+	"lyss-ai-platform/eino-service/pkg/responsecache"
+	"lyss-ai-platform/eino-service/pkg/streambuffer"
+	"lyss-ai-platform/eino-service/pkg/tracing"
 )
 
 func main() {
+	// 记录进程启动时间，用于存活检查和指标中的运行时长
+	health.MarkStarted(time.Now())
+
 	// 初始化日志
 	logger := logrus.New()
-	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetFormatter(redact.NewFormatter(&logrus.JSONFormatter{}))
 	logger.SetLevel(logrus.InfoLevel)
 
 	logger.Info("启动EINO服务...")
@@ -49,6 +65,12 @@ func main() {
 		"tenant_service":  cfg.Services.TenantService.BaseURL,
 	}).Info("配置加载成功")
 
+	// 初始化链路追踪
+	shutdownTracing, err := tracing.Init(context.Background(), &cfg.Tracing, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("链路追踪初始化失败")
+	}
+
 	// 初始化Redis客户端
 	redisClient := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port),
@@ -60,13 +82,19 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := redisClient.Ping(ctx).Err(); err != nil {
-		logger.WithError(err).Fatal("Redis连接失败")
+	// Redis只用于统计、配额和缓存，连接失败时降级运行而不是退出
+	redisErr := redisClient.Ping(ctx).Err()
+	if redisErr != nil {
+		logger.WithError(redisErr).Warn("Redis连接失败，服务降级运行")
+	} else {
+		logger.Info("Redis连接成功")
 	}
-	logger.Info("Redis连接成功")
+
+	// 初始化共享的HTTP连接池
+	transport := client.NewTransport(&cfg.Services.HTTPClient)
 
 	// 初始化租户服务客户端
-	tenantClient := client.NewTenantClient(&cfg.Services.TenantService, logger)
+	tenantClient := client.NewTenantClient(&cfg.Services.TenantService, transport, logger)
 
 	// 测试租户服务连接
 	if err := tenantClient.HealthCheck(ctx); err != nil {
@@ -79,8 +107,15 @@ func main() {
 		tenantClient,
 		redisClient,
 		&cfg.Credential,
+		cfg.Workflows.DefaultStrategy,
 		logger,
 	)
+	credentialManager.SetHTTPClient(&http.Client{Transport: transport})
+
+	// 启动时Redis不可用，暂停Redis写入，由健康检查报告 redis: degraded
+	if redisErr != nil {
+		credentialManager.MarkRedisUnavailable(redisErr)
+	}
 
 	// 启动凭证管理器
 	if err := credentialManager.Start(); err != nil {
@@ -99,10 +134,74 @@ func main() {
 	// 初始化工作流管理器
 	workflowManager := workflows.NewWorkflowManager(
 		credentialManager,
+		redisClient,
+		transport,
 		logger,
 		cfg,
 	)
 
+	// 开启时缓存确定性请求的响应
+	if cfg.Workflows.ResponseCacheEnabled {
+		workflowManager.SetResponseCache(responsecache.NewStore(redisClient, cfg.Workflows.ResponseCacheTTL))
+		logger.WithField("ttl", cfg.Workflows.ResponseCacheTTL.String()).Info("响应缓存已启用")
+	}
+
+	// 租户级功能开关：配置默认值，租户服务按租户覆盖
+	workflowManager.SetFeatureFlags(featureflag.NewService(
+		tenantClient,
+		redisClient,
+		cfg.Features.Defaults,
+		cfg.Features.CacheTTL,
+		logger,
+	))
+
+	// 开启时按采样比例或调试头记录完整的提示词与回答
+	var promptTraces *prompttrace.Recorder
+	if cfg.Logging.PromptSampleRate > 0 || cfg.Logging.PromptTraceHeader {
+		promptTraces = prompttrace.NewRecorder(
+			prompttrace.NewSampler(cfg.Logging.PromptSampleRate),
+			prompttrace.NewStore(redisClient, cfg.Logging.PromptTraceTTL, cfg.Logging.PromptTraceMaxEntries),
+			cfg.Logging.PromptTraceHeader,
+			logger,
+		)
+		workflowManager.SetPromptTraceRecorder(promptTraces)
+		logger.WithFields(logrus.Fields{
+			"sample_rate":  cfg.Logging.PromptSampleRate,
+			"allow_header": cfg.Logging.PromptTraceHeader,
+		}).Info("提示词记录已启用")
+	}
+
+	// 开启凭证使用审计或提示词预设库时连接数据库
+	var db *gorm.DB
+	if cfg.Database.UsageAuditEnabled || cfg.Database.PromptsEnabled {
+		db, err = gorm.Open(postgres.Open(cfg.Database.DSN()), &gorm.Config{
+			Logger: gormlogger.Default.LogMode(gormlogger.Warn),
+		})
+		if err != nil {
+			logger.WithError(err).Fatal("数据库连接失败")
+		}
+	}
+
+	var usageAudit *audit.Store
+	if cfg.Database.UsageAuditEnabled {
+		usageAudit = audit.NewStore(db, logger)
+		if err := usageAudit.Migrate(); err != nil {
+			logger.WithError(err).Fatal("凭证使用审计表初始化失败")
+		}
+		workflowManager.SetUsageAuditStore(usageAudit)
+		logger.Info("凭证使用审计已启用")
+	}
+
+	var promptStore *prompts.Store
+	if cfg.Database.PromptsEnabled {
+		promptStore = prompts.NewStore(db, logger)
+		if err := promptStore.Migrate(); err != nil {
+			logger.WithError(err).Fatal("提示词预设表初始化失败")
+		}
+		workflowManager.SetPromptStore(promptStore)
+		logger.Info("提示词预设库已启用")
+	}
+
 	// 初始化工作流管理器
 	if err := workflowManager.Initialize(); err != nil {
 		logger.WithError(err).Fatal("工作流管理器初始化失败")
@@ -124,6 +223,8 @@ func main() {
 
 	// 添加基本中间件
 	router.Use(gin.Recovery())
+	router.Use(tracing.Middleware())
+	router.Use(bodylimit.Middleware(cfg.Server.MaxBodyBytes, logger))
 	router.Use(func(c *gin.Context) {
 		c.Set("start_time", time.Now().UnixMilli())
 		c.Next()
@@ -138,14 +239,62 @@ func main() {
 		logger,
 	)
 
+	idempotencyStore := idempotency.NewStore(
+		redisClient,
+		cfg.Workflows.IdempotencyTTL,
+		cfg.Workflows.ExecutionTimeout,
+		logger,
+	)
+
 	workflowHandler := handlers.NewWorkflowHandler(
 		workflowManager,
+		idempotencyStore,
+		cfg.Workflows.StreamKeepaliveInterval,
+		logger,
+	)
+
+	// 开启时缓冲流式事件，支持客户端携带 Last-Event-ID 断线续传
+	if cfg.Workflows.StreamResumeTTL > 0 {
+		workflowHandler.SetStreamBuffer(streambuffer.NewStore(redisClient, cfg.Workflows.StreamResumeTTL, cfg.Workflows.StreamResumeMaxEvents), cfg.Workflows.StreamResumeGrace)
+		logger.WithField("ttl", cfg.Workflows.StreamResumeTTL.String()).Info("流式断线续传已启用")
+	}
+
+	modelHandler := handlers.NewModelHandler(
+		credentialManager,
+		logger,
+	)
+
+	promptHandler := handlers.NewPromptHandler(
+		promptStore,
+		logger,
+	)
+
+	usageHandler := handlers.NewUsageHandler(
+		usageAudit,
+		audit.NewPricing(cfg.Models.Pricing),
+		logger,
+	)
+
+	credentialHandler := handlers.NewCredentialHandler(
+		credentialManager,
+		cfg.Server.InternalToken,
+		logger,
+	)
+
+	promptTraceHandler := handlers.NewPromptTraceHandler(
+		promptTraces,
+		cfg.Server.InternalToken,
 		logger,
 	)
 
 	// 注册路由
 	healthHandler.RegisterRoutes(router)
 	workflowHandler.RegisterRoutes(router)
+	modelHandler.RegisterRoutes(router)
+	usageHandler.RegisterRoutes(router)
+	promptHandler.RegisterRoutes(router)
+	credentialHandler.RegisterRoutes(router)
+	promptTraceHandler.RegisterRoutes(router)
 
 	// 创建HTTP服务器
 	srv := &http.Server{
@@ -154,7 +303,7 @@ func main() {
 		ReadTimeout:    cfg.Server.ReadTimeout,
 		WriteTimeout:   cfg.Server.WriteTimeout,
 		IdleTimeout:    cfg.Server.IdleTimeout,
-		MaxHeaderBytes: 1 << 20, // 1MB
+		MaxHeaderBytes: cfg.Server.MaxHeaderBytes,
 	}
 
 	// 启动服务器
@@ -176,6 +325,9 @@ func main() {
 
 	logger.Info("收到关闭信号，开始优雅关闭...")
 
+	// 排空工作流执行：拒绝新的执行，等待运行中的执行完成，超时后取消
+	workflowManager.Shutdown()
+
 	// 优雅关闭
 	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -185,12 +337,14 @@ func main() {
 		logger.WithError(err).Error("HTTP服务器关闭失败")
 	}
 
-	// 关闭工作流管理器
-	workflowManager.Shutdown()
-
 	// 关闭凭证管理器
 	credentialManager.Stop()
 
+	// 导出剩余的追踪数据
+	if err := shutdownTracing(ctx); err != nil {
+		logger.WithError(err).Error("链路追踪关闭失败")
+	}
+
 	// 关闭Redis连接
 	if err := redisClient.Close(); err != nil {
 		logger.WithError(err).Error("Redis连接关闭失败")
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,6 +12,10 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/pkg/redact"
+	"lyss-ai-platform/eino-service/pkg/requestid"
+	"lyss-ai-platform/eino-service/pkg/tracing"
 )
 
 // DeepSeekClient DeepSeek API 客户端
@@ -19,13 +24,22 @@ type DeepSeekClient struct {
 	baseURL    string
 	httpClient *http.Client
 	logger     *logrus.Logger
+
+	// streamTimeouts 流式请求的首字节与空闲超时
+	streamTimeouts StreamTimeouts
+
+	// extraHeaders 凭证配置的额外请求头，不能覆盖鉴权等受保护的请求头
+	extraHeaders map[string]string
+
+	// maxSSELineSize 流式响应单行上限，0表示使用 DefaultMaxSSELineSize
+	maxSSELineSize int
 }
 
 // DeepSeekRequest 聊天请求结构
 type DeepSeekRequest struct {
 	Model       string                 `json:"model"`
 	Messages    []DeepSeekMessage      `json:"messages"`
-	Temperature float64                `json:"temperature,omitempty"`
+	Temperature *float64               `json:"temperature,omitempty"` // 为空时使用供应商默认值，显式的0会被发送
 	MaxTokens   int                    `json:"max_tokens,omitempty"`
 	Stream      bool                   `json:"stream,omitempty"`
 	Stop        []string               `json:"stop,omitempty"`
@@ -34,6 +48,25 @@ type DeepSeekRequest struct {
 	N           int                    `json:"n,omitempty"`
 	User        string                 `json:"user,omitempty"`
 	Extra       map[string]interface{} `json:"extra,omitempty"`
+
+	FrequencyPenalty float64 `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float64 `json:"presence_penalty,omitempty"`
+	Seed             *int    `json:"seed,omitempty"`
+
+	ResponseFormat *DeepSeekResponseFormat `json:"response_format,omitempty"`
+}
+
+// temperatureValue 用于日志输出，未设置时为nil
+func (r *DeepSeekRequest) temperatureValue() interface{} {
+	if r.Temperature == nil {
+		return nil
+	}
+	return *r.Temperature
+}
+
+// DeepSeekResponseFormat 输出格式，DeepSeek 支持 text 与 json_object
+type DeepSeekResponseFormat struct {
+	Type string `json:"type"`
 }
 
 // DeepSeekMessage 消息结构
@@ -97,24 +130,48 @@ type DeepSeekStreamResponse struct {
 }
 
 // NewDeepSeekClient 创建DeepSeek客户端
-func NewDeepSeekClient(apiKey, baseURL string, logger *logrus.Logger) *DeepSeekClient {
+// httpClient 为共享连接池的客户端，为nil时使用独立的默认客户端（同样记录链路追踪）
+func NewDeepSeekClient(apiKey, baseURL string, httpClient *http.Client, logger *logrus.Logger) *DeepSeekClient {
 	if baseURL == "" {
 		baseURL = "https://api.deepseek.com"
 	}
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Transport: &tracing.Transport{},
+			Timeout:   60 * time.Second,
+		}
+	}
 
 	return &DeepSeekClient{
-		apiKey:  apiKey,
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
-		},
-		logger: logger,
+		apiKey:     apiKey,
+		baseURL:    baseURL,
+		httpClient: httpClient,
+		logger:     logger,
 	}
 }
 
+// SetExtraHeaders 设置随每个请求发送的额外请求头，受保护的请求头会被忽略
+func (c *DeepSeekClient) SetExtraHeaders(headers map[string]string) {
+	c.extraHeaders = headers
+}
+
+// SetStreamTimeouts 设置流式请求的首字节与空闲超时
+// 流式请求不受 httpClient 整体超时限制，长时间但持续输出的生成不会被中断
+func (c *DeepSeekClient) SetStreamTimeouts(timeouts StreamTimeouts) {
+	c.streamTimeouts = timeouts
+}
+
+// SetMaxSSELineSize 设置流式响应的单行上限，超过上限的行仍会完整读取并记录警告
+func (c *DeepSeekClient) SetMaxSSELineSize(size int) {
+	c.maxSSELineSize = size
+}
+
 // ChatCompletion 发送聊天请求
 func (c *DeepSeekClient) ChatCompletion(ctx context.Context, req *DeepSeekRequest) (*DeepSeekResponse, error) {
 	startTime := time.Now()
+
+	// 非流式接口按完整JSON解析响应，不能请求事件流
+	req.Stream = false
 	
 	// 构建请求URL
 	url := fmt.Sprintf("%s/chat/completions", c.baseURL)
@@ -133,16 +190,20 @@ func (c *DeepSeekClient) ChatCompletion(ctx context.Context, req *DeepSeekReques
 		return nil, fmt.Errorf("创建请求失败: %w", err)
 	}
 
-	// 设置请求头
+	// 设置请求头，固定请求头在额外请求头之后设置
+	applyExtraHeaders(httpReq, c.extraHeaders)
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
 	httpReq.Header.Set("User-Agent", "Lyss-EINO-Service/1.0.0")
+	requestid.SetHeader(httpReq)
 
 	c.logger.WithFields(logrus.Fields{
-		"url":           url,
+		"request_id":    requestid.FromContext(ctx),
+		"url":           redact.String(url),
+		"headers":       redact.Headers(httpReq.Header),
 		"model":         req.Model,
 		"messages":      len(req.Messages),
-		"temperature":   req.Temperature,
+		"temperature":   req.temperatureValue(),
 		"max_tokens":    req.MaxTokens,
 		"stream":        req.Stream,
 	}).Info("发送DeepSeek聊天请求")
@@ -165,6 +226,7 @@ func (c *DeepSeekClient) ChatCompletion(ctx context.Context, req *DeepSeekReques
 	// 记录响应时间
 	duration := time.Since(startTime)
 	c.logger.WithFields(logrus.Fields{
+		"request_id":      requestid.FromContext(ctx),
 		"status_code":     resp.StatusCode,
 		"response_time_ms": duration.Milliseconds(),
 		"response_size":   len(respBody),
@@ -177,26 +239,37 @@ func (c *DeepSeekClient) ChatCompletion(ctx context.Context, req *DeepSeekReques
 			c.logger.WithFields(logrus.Fields{
 				"status_code":   resp.StatusCode,
 				"error_type":    errorResp.Error.Type,
-				"error_message": errorResp.Error.Message,
+				"error_message": redact.String(errorResp.Error.Message),
 				"error_code":    errorResp.Error.Code,
 			}).Error("DeepSeek API返回错误")
-			return nil, fmt.Errorf("DeepSeek API错误 [%s]: %s", errorResp.Error.Type, errorResp.Error.Message)
+			return nil, NewProviderError("deepseek", resp.StatusCode, deepSeekErrorCode(errorResp.Error), errorResp.Error.Message)
 		}
 		
 		c.logger.WithFields(logrus.Fields{
 			"status_code": resp.StatusCode,
-			"response":    string(respBody),
+			"response":    redact.String(string(respBody)),
 		}).Error("DeepSeek HTTP错误")
-		return nil, fmt.Errorf("HTTP错误: %d", resp.StatusCode)
+		return nil, NewProviderError("deepseek", resp.StatusCode, "", http.StatusText(resp.StatusCode))
 	}
 
 	// 解析成功响应
 	var deepSeekResp DeepSeekResponse
 	if err := json.Unmarshal(respBody, &deepSeekResp); err != nil {
-		c.logger.WithError(err).WithField("response", string(respBody)).Error("解析DeepSeek响应失败")
+		c.logger.WithError(err).WithField("response", redact.String(string(respBody))).Error("解析DeepSeek响应失败")
 		return nil, fmt.Errorf("解析响应失败: %w", err)
 	}
 
+	// 校验回复内容，格式异常的响应不交给调用方索引
+	if err := checkChoices(&deepSeekResp, resp.StatusCode); err != nil {
+		c.logger.WithFields(logrus.Fields{
+			"request_id":  requestid.FromContext(ctx),
+			"status_code": resp.StatusCode,
+			"response":    redact.String(string(respBody)),
+			"operation":   "empty_provider_response",
+		}).Error("DeepSeek响应缺少回复内容")
+		return nil, err
+	}
+
 	// 记录成功响应
 	c.logger.WithFields(logrus.Fields{
 		"response_id":    deepSeekResp.ID,
@@ -211,6 +284,7 @@ func (c *DeepSeekClient) ChatCompletion(ctx context.Context, req *DeepSeekReques
 }
 
 // ChatCompletionStream 发送流式聊天请求
+// 读取中断（包括空闲超时）时在关闭通道前发送一条带 Error 的响应
 func (c *DeepSeekClient) ChatCompletionStream(ctx context.Context, req *DeepSeekRequest) (<-chan *DeepSeekStreamResponse, error) {
 	// 确保流式请求
 	req.Stream = true
@@ -232,23 +306,27 @@ func (c *DeepSeekClient) ChatCompletionStream(ctx context.Context, req *DeepSeek
 		return nil, fmt.Errorf("创建请求失败: %w", err)
 	}
 
-	// 设置请求头
+	// 设置请求头，固定请求头在额外请求头之后设置
+	applyExtraHeaders(httpReq, c.extraHeaders)
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
 	httpReq.Header.Set("User-Agent", "Lyss-EINO-Service/1.0.0")
+	requestid.SetHeader(httpReq)
 	httpReq.Header.Set("Accept", "text/event-stream")
 
 	c.logger.WithFields(logrus.Fields{
-		"url":           url,
+		"request_id":    requestid.FromContext(ctx),
+		"url":           redact.String(url),
+		"headers":       redact.Headers(httpReq.Header),
 		"model":         req.Model,
 		"messages":      len(req.Messages),
-		"temperature":   req.Temperature,
+		"temperature":   req.temperatureValue(),
 		"max_tokens":    req.MaxTokens,
 		"stream":        true,
 	}).Info("发送DeepSeek流式聊天请求")
 
 	// 发送请求
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := doStreamRequest(c.httpClient, httpReq, c.streamTimeouts)
 	if err != nil {
 		c.logger.WithError(err).Error("发送DeepSeek流式请求失败")
 		return nil, fmt.Errorf("请求失败: %w", err)
@@ -260,9 +338,9 @@ func (c *DeepSeekClient) ChatCompletionStream(ctx context.Context, req *DeepSeek
 		respBody, _ := io.ReadAll(resp.Body)
 		c.logger.WithFields(logrus.Fields{
 			"status_code": resp.StatusCode,
-			"response":    string(respBody),
+			"response":    redact.String(string(respBody)),
 		}).Error("DeepSeek流式请求HTTP错误")
-		return nil, fmt.Errorf("HTTP错误: %d", resp.StatusCode)
+		return nil, NewProviderError("deepseek", resp.StatusCode, "", http.StatusText(resp.StatusCode))
 	}
 
 	// 创建响应通道
@@ -281,7 +359,7 @@ func (c *DeepSeekClient) ChatCompletionStream(ctx context.Context, req *DeepSeek
 
 // processStreamResponse 处理流式响应
 func (c *DeepSeekClient) processStreamResponse(ctx context.Context, body io.ReadCloser, responseChan chan<- *DeepSeekStreamResponse) {
-	scanner := NewSSEScanner(body)
+	scanner := NewSSEScannerWithLimit(body, c.maxSSELineSize)
 	
 	for scanner.Scan() {
 		select {
@@ -292,6 +370,12 @@ func (c *DeepSeekClient) processStreamResponse(ctx context.Context, body io.Read
 		}
 
 		line := scanner.Text()
+		if err := scanner.LineErr(); err != nil {
+			c.logger.WithFields(logrus.Fields{
+				"operation": "sse_line_overflow",
+				"error":     err.Error(),
+			}).Warn("DeepSeek流式响应单行超过上限，已完整读取")
+		}
 		
 		// 跳过空行和注释
 		if line == "" || strings.HasPrefix(line, ":") {
@@ -311,7 +395,7 @@ func (c *DeepSeekClient) processStreamResponse(ctx context.Context, body io.Read
 			// 解析JSON数据
 			var streamResp DeepSeekStreamResponse
 			if err := json.Unmarshal([]byte(data), &streamResp); err != nil {
-				c.logger.WithError(err).WithField("data", data).Error("解析DeepSeek流式响应失败")
+				c.logger.WithError(err).WithField("data", redact.String(data)).Error("解析DeepSeek流式响应失败")
 				continue
 			}
 
@@ -319,7 +403,7 @@ func (c *DeepSeekClient) processStreamResponse(ctx context.Context, body io.Read
 			if streamResp.Error != nil {
 				c.logger.WithFields(logrus.Fields{
 					"error_type":    streamResp.Error.Type,
-					"error_message": streamResp.Error.Message,
+					"error_message": redact.String(streamResp.Error.Message),
 					"error_code":    streamResp.Error.Code,
 				}).Error("DeepSeek流式响应错误")
 				continue
@@ -336,24 +420,59 @@ func (c *DeepSeekClient) processStreamResponse(ctx context.Context, body io.Read
 
 	if err := scanner.Err(); err != nil {
 		c.logger.WithError(err).Error("读取DeepSeek流式响应出错")
+
+		errorType := "stream_error"
+		if errors.Is(err, ErrStreamIdleTimeout) {
+			errorType = "timeout"
+		}
+		select {
+		case responseChan <- &DeepSeekStreamResponse{Error: &DeepSeekError{Message: err.Error(), Type: errorType}}:
+		case <-ctx.Done():
+		}
 	}
 }
 
+// checkChoices 校验非流式响应至少包含一个带消息的选择项
+func checkChoices(resp *DeepSeekResponse, statusCode int) error {
+	if len(resp.Choices) == 0 {
+		return &EmptyResponseError{Provider: "deepseek", StatusCode: statusCode, Reason: "choices 为空"}
+	}
+	if resp.Choices[0].Message == nil {
+		return &EmptyResponseError{Provider: "deepseek", StatusCode: statusCode, Reason: "message 为空"}
+	}
+	return nil
+}
+
+// deepSeekErrorCode 提取错误码，未返回 code 时使用错误类型
+func deepSeekErrorCode(e *DeepSeekError) string {
+	if e.Code != "" {
+		return e.Code
+	}
+	return e.Type
+}
+
 // TestConnection 测试连接
 func (c *DeepSeekClient) TestConnection(ctx context.Context) error {
+	return c.TestModel(ctx, c.GetDefaultModel())
+}
+
+// TestModel 使用指定模型发起最小补全请求，验证凭证可以实际调用
+// 兼容OpenAI接口格式的供应商均可使用
+func (c *DeepSeekClient) TestModel(ctx context.Context, model string) error {
 	// 创建简单的测试请求
 	req := &DeepSeekRequest{
-		Model: "deepseek-chat",
+		Model: model,
 		Messages: []DeepSeekMessage{
 			{
 				Role:    "user",
 				Content: "Hello, this is a connection test.",
 			},
 		},
-		MaxTokens:   10,
-		Temperature: 0.1,
-		Stream:      false,
+		MaxTokens: 10,
+		Stream:    false,
 	}
+	temperature := 0.1
+	req.Temperature = &temperature
 
 	// 发送测试请求
 	resp, err := c.ChatCompletion(ctx, req)
@@ -362,12 +481,6 @@ func (c *DeepSeekClient) TestConnection(ctx context.Context) error {
 		return fmt.Errorf("连接测试失败: %w", err)
 	}
 
-	// 检查响应
-	if len(resp.Choices) == 0 {
-		c.logger.Error("DeepSeek测试响应为空")
-		return fmt.Errorf("测试响应为空")
-	}
-
 	c.logger.WithFields(logrus.Fields{
 		"response_id":   resp.ID,
 		"model":         resp.Model,
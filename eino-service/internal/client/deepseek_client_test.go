@@ -0,0 +1,97 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestDeepSeekRequestTemperature(t *testing.T) {
+	zero := 0.0
+	warm := 0.7
+
+	tests := []struct {
+		name        string
+		temperature *float64
+		want        string
+	}{
+		{name: "unset", temperature: nil, want: ""},
+		{name: "explicit zero", temperature: &zero, want: `"temperature":0`},
+		{name: "explicit value", temperature: &warm, want: `"temperature":0.7`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(&DeepSeekRequest{Model: "deepseek-chat", Temperature: tt.temperature})
+			if err != nil {
+				t.Fatalf("序列化失败: %v", err)
+			}
+			if tt.want == "" {
+				if strings.Contains(string(body), "temperature") {
+					t.Fatalf("请求体 %s 不应包含 temperature", body)
+				}
+				return
+			}
+			if !strings.Contains(string(body), tt.want) {
+				t.Fatalf("请求体 %s 应包含 %s", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeepSeekClientLogsAreRedacted(t *testing.T) {
+	const apiKey = "sk-abcdef0123456789abcdef"
+
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		stream bool
+	}{
+		{name: "鉴权失败响应回显密钥", status: http.StatusUnauthorized, body: `{"error":{"message":"Incorrect API key provided: ` + apiKey + `"}}`},
+		{name: "成功响应", status: http.StatusOK, body: `{"id":"1","choices":[{"index":0,"message":{"role":"assistant","content":"hi"}}]}`},
+		{name: "流式请求鉴权失败", status: http.StatusUnauthorized, body: `{"api_key":"` + apiKey + `"}`, stream: true},
+		{name: "流式错误事件回显密钥", status: http.StatusOK, body: "data: {\"error\":{\"message\":\"bad key " + apiKey + "\"}}\n\ndata: [DONE]\n\n", stream: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			var buf bytes.Buffer
+			logger := logrus.New()
+			logger.SetOutput(&buf)
+			logger.SetLevel(logrus.DebugLevel)
+			logger.SetFormatter(&logrus.JSONFormatter{})
+
+			deepSeekClient := NewDeepSeekClient(apiKey, server.URL+"?api_key="+apiKey, http.DefaultClient, logger)
+			req := &DeepSeekRequest{Model: "deepseek-chat", Messages: []DeepSeekMessage{{Role: "user", Content: "hi"}}}
+			if tt.stream {
+				if stream, err := deepSeekClient.ChatCompletionStream(context.Background(), req); err == nil {
+					for range stream {
+					}
+				}
+			} else {
+				deepSeekClient.ChatCompletion(context.Background(), req)
+			}
+
+			output := buf.String()
+			if output == "" {
+				t.Fatal("期望输出请求日志")
+			}
+			if strings.Contains(output, apiKey) {
+				t.Fatalf("日志包含API密钥: %s", output)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// emptyResponseBodies 格式异常但状态码为200的供应商响应
+var emptyResponseBodies = []struct {
+	name       string
+	body       string
+	wantReason string
+}{
+	{name: "choices为空数组", body: `{"id":"1","choices":[]}`, wantReason: "choices 为空"},
+	{name: "缺少choices", body: `{"id":"1"}`, wantReason: "choices 为空"},
+	{name: "choices为null", body: `{"id":"1","choices":null}`, wantReason: "choices 为空"},
+	{name: "message为null", body: `{"id":"1","choices":[{"index":0,"message":null,"finish_reason":"stop"}]}`, wantReason: "message 为空"},
+	{name: "缺少message", body: `{"id":"1","choices":[{"index":0}]}`, wantReason: "message 为空"},
+}
+
+func TestChatCompletionEmptyResponse(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, tt := range emptyResponseBodies {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, tt.body)
+			}))
+			t.Cleanup(server.Close)
+
+			deepseek := NewDeepSeekClient("sk-test", server.URL, http.DefaultClient, logger)
+			resp, err := deepseek.ChatCompletion(context.Background(), &DeepSeekRequest{
+				Model:    "deepseek-chat",
+				Messages: []DeepSeekMessage{{Role: "user", Content: "你好"}},
+			})
+			if resp != nil {
+				t.Fatalf("响应 = %+v，期望 nil", resp)
+			}
+			if !errors.Is(err, ErrEmptyProviderResponse) {
+				t.Fatalf("错误 = %v，期望 ErrEmptyProviderResponse", err)
+			}
+			var emptyErr *EmptyResponseError
+			if !errors.As(err, &emptyErr) {
+				t.Fatalf("错误 = %v，期望 EmptyResponseError", err)
+			}
+			if emptyErr.Provider != "deepseek" || emptyErr.StatusCode != http.StatusOK || emptyErr.Reason != tt.wantReason {
+				t.Fatalf("错误详情 = %+v，期望 deepseek、HTTP 200、%s", emptyErr, tt.wantReason)
+			}
+			if !strings.Contains(err.Error(), "HTTP 200") {
+				t.Fatalf("错误信息 %q 应包含供应商原始状态码", err.Error())
+			}
+		})
+	}
+}
+
+func TestEmptyResponseErrorMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *EmptyResponseError
+		want string
+	}{
+		{name: "包含状态码", err: &EmptyResponseError{Provider: "deepseek", StatusCode: 200, Reason: "choices 为空"}, want: "供应商响应为空: deepseek choices 为空（HTTP 200）"},
+		{name: "无状态码", err: &EmptyResponseError{Provider: "openai", Reason: "message 为空"}, want: "供应商响应为空: openai message 为空"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Fatalf("Error() = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	openai "github.com/meguminnnnnnnnn/go-openai"
+	"google.golang.org/genai"
+)
+
+// ProviderError 供应商调用失败的统一错误类型
+// Retriable 在创建时按状态码确定，调用方据此决定是否换用其他凭证重试
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	Code       string // 供应商返回的错误码或错误类型，可能为空
+	Message    string
+	Retriable  bool
+	Err        error // 供应商SDK返回的原始错误，可能为nil
+}
+
+// NewProviderError 根据供应商响应创建错误，5xx 和 429 视为可重试
+func NewProviderError(provider string, statusCode int, code, message string) *ProviderError {
+	return &ProviderError{
+		Provider:   provider,
+		StatusCode: statusCode,
+		Code:       code,
+		Message:    message,
+		Retriable:  isRetriableStatus(statusCode),
+	}
+}
+
+// Error 实现 error 接口
+func (e *ProviderError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s API错误 [%s]: %s（HTTP %d）", e.Provider, e.Code, e.Message, e.StatusCode)
+	}
+	return fmt.Sprintf("%s API错误: %s（HTTP %d）", e.Provider, e.Message, e.StatusCode)
+}
+
+// Unwrap 返回供应商SDK的原始错误
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// AuthError 是否为鉴权失败（401/403），通常意味着密钥已吊销或过期
+func (e *ProviderError) AuthError() bool {
+	return isAuthStatus(e.StatusCode)
+}
+
+// AsProviderError 将EINO模型组件返回的错误转换为 ProviderError，保留原始错误
+// 无法识别出HTTP状态码的错误（网络错误、超时等）原样返回
+func AsProviderError(provider string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return err
+	}
+
+	var converted *ProviderError
+	var openaiErr *openai.APIError
+	var genaiErr genai.APIError
+	switch {
+	case errors.As(err, &openaiErr) && openaiErr.HTTPStatusCode > 0:
+		code := openaiErr.Type
+		if openaiErr.Code != nil {
+			code = fmt.Sprint(openaiErr.Code)
+		}
+		converted = NewProviderError(provider, openaiErr.HTTPStatusCode, code, openaiErr.Message)
+	case errors.As(err, &genaiErr) && genaiErr.Code > 0:
+		converted = NewProviderError(provider, genaiErr.Code, genaiErr.Status, genaiErr.Message)
+	default:
+		match := statusCodePattern.FindStringSubmatch(err.Error())
+		if match == nil {
+			return err
+		}
+		statusCode, _ := strconv.Atoi(match[1])
+		converted = NewProviderError(provider, statusCode, "", err.Error())
+	}
+
+	converted.Err = err
+	return converted
+}
+
+// ErrEmptyProviderResponse 供应商返回成功状态但响应中没有可用的回复内容
+var ErrEmptyProviderResponse = errors.New("供应商响应为空")
+
+// EmptyResponseError 供应商空响应详情，StatusCode 为供应商原始HTTP状态码，无法获取时为0
+type EmptyResponseError struct {
+	Provider   string
+	StatusCode int
+	Reason     string
+}
+
+// Error 实现 error 接口
+func (e *EmptyResponseError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("%s: %s %s", ErrEmptyProviderResponse.Error(), e.Provider, e.Reason)
+	}
+	return fmt.Sprintf("%s: %s %s（HTTP %d）", ErrEmptyProviderResponse.Error(), e.Provider, e.Reason, e.StatusCode)
+}
+
+// Unwrap 支持 errors.Is(err, ErrEmptyProviderResponse)
+func (e *EmptyResponseError) Unwrap() error {
+	return ErrEmptyProviderResponse
+}
+
+// statusCodePattern 匹配EINO模型组件错误信息中的HTTP状态码
+var statusCodePattern = regexp.MustCompile(`status code: (\d{3})`)
+
+// IsRetriableError 判断供应商调用错误是否值得换用其他凭证重试
+// 5xx、429、超时和网络错误视为可重试；参数错误、鉴权失败等4xx错误换凭证也无法解决
+func IsRetriableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.Retriable
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+
+	if match := statusCodePattern.FindStringSubmatch(err.Error()); match != nil {
+		statusCode, _ := strconv.Atoi(match[1])
+		return isRetriableStatus(statusCode)
+	}
+
+	return false
+}
+
+// IsAuthError 判断供应商调用错误是否为鉴权失败（401/403），通常意味着密钥已吊销或过期
+func IsAuthError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.AuthError()
+	}
+
+	if match := statusCodePattern.FindStringSubmatch(err.Error()); match != nil {
+		statusCode, _ := strconv.Atoi(match[1])
+		return isAuthStatus(statusCode)
+	}
+
+	return false
+}
+
+// isAuthStatus 判断HTTP状态码是否表示鉴权失败
+func isAuthStatus(statusCode int) bool {
+	return statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden
+}
+
+// isRetriableStatus 判断HTTP状态码是否可重试
+func isRetriableStatus(statusCode int) bool {
+	return statusCode >= http.StatusInternalServerError || statusCode == http.StatusTooManyRequests
+}
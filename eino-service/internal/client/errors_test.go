@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	openai "github.com/meguminnnnnnnnn/go-openai"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genai"
+)
+
+func TestDeepSeekClientProviderError(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		wantCode      string
+		wantRetriable bool
+		wantAuth      bool
+	}{
+		{name: "429可重试", status: http.StatusTooManyRequests, body: `{"error":{"message":"rate limited","type":"rate_limit_error"}}`, wantCode: "rate_limit_error", wantRetriable: true},
+		{name: "401鉴权失败", status: http.StatusUnauthorized, body: `{"error":{"message":"invalid api key","type":"authentication_error","code":"invalid_api_key"}}`, wantCode: "invalid_api_key", wantAuth: true},
+		{name: "403鉴权失败", status: http.StatusForbidden, body: `forbidden`, wantAuth: true},
+		{name: "400不可重试", status: http.StatusBadRequest, body: `{"error":{"message":"bad param","type":"invalid_request_error"}}`, wantCode: "invalid_request_error"},
+		{name: "500可重试", status: http.StatusInternalServerError, body: `internal error`, wantRetriable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			t.Cleanup(server.Close)
+
+			deepseek := NewDeepSeekClient("sk-test", server.URL, http.DefaultClient, logger)
+			req := &DeepSeekRequest{Model: "deepseek-chat", Messages: []DeepSeekMessage{{Role: "user", Content: "你好"}}}
+			_, err := deepseek.ChatCompletion(context.Background(), req)
+			_, streamErr := deepseek.ChatCompletionStream(context.Background(), req)
+
+			for _, err := range []error{err, streamErr} {
+				var providerErr *ProviderError
+				if !errors.As(err, &providerErr) {
+					t.Fatalf("错误 = %v，期望 ProviderError", err)
+				}
+				if providerErr.Provider != "deepseek" || providerErr.StatusCode != tt.status {
+					t.Fatalf("错误详情 = %+v，期望 deepseek、HTTP %d", providerErr, tt.status)
+				}
+				if providerErr.Retriable != tt.wantRetriable || IsRetriableError(err) != tt.wantRetriable {
+					t.Fatalf("Retriable = %v，期望 %v", providerErr.Retriable, tt.wantRetriable)
+				}
+				if providerErr.AuthError() != tt.wantAuth || IsAuthError(err) != tt.wantAuth {
+					t.Fatalf("AuthError = %v，期望 %v", providerErr.AuthError(), tt.wantAuth)
+				}
+			}
+
+			// 只有非流式请求会解析错误响应体
+			var providerErr *ProviderError
+			errors.As(err, &providerErr)
+			if providerErr.Code != tt.wantCode {
+				t.Fatalf("Code = %q，期望 %q", providerErr.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestAsProviderError(t *testing.T) {
+	openaiErr := &openai.APIError{Code: "rate_limit_exceeded", Message: "slow down", HTTPStatusCode: http.StatusTooManyRequests}
+	genaiErr := genai.APIError{Code: http.StatusUnauthorized, Status: "UNAUTHENTICATED", Message: "API key not valid"}
+	existing := NewProviderError("deepseek", http.StatusBadRequest, "", "bad request")
+
+	tests := []struct {
+		name          string
+		err           error
+		wantStatus    int
+		wantCode      string
+		wantRetriable bool
+		wantAuth      bool
+	}{
+		{name: "go-openai错误", err: fmt.Errorf("生成失败: %w", openaiErr), wantStatus: 429, wantCode: "rate_limit_exceeded", wantRetriable: true},
+		{name: "genai错误", err: genaiErr, wantStatus: 401, wantCode: "UNAUTHENTICATED", wantAuth: true},
+		{name: "错误信息中的状态码", err: errors.New("error, status code: 400, message: bad request"), wantStatus: 400},
+		{name: "已是ProviderError", err: existing, wantStatus: 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := AsProviderError("openai", tt.err)
+			var providerErr *ProviderError
+			if !errors.As(err, &providerErr) {
+				t.Fatalf("错误 = %v，期望 ProviderError", err)
+			}
+			if providerErr.StatusCode != tt.wantStatus || providerErr.Code != tt.wantCode {
+				t.Fatalf("错误详情 = %+v，期望 HTTP %d、%q", providerErr, tt.wantStatus, tt.wantCode)
+			}
+			if providerErr.Retriable != tt.wantRetriable || providerErr.AuthError() != tt.wantAuth {
+				t.Fatalf("Retriable/AuthError = %v/%v，期望 %v/%v", providerErr.Retriable, providerErr.AuthError(), tt.wantRetriable, tt.wantAuth)
+			}
+			// genai.APIError 含切片字段不可比较，errors.Is 无法匹配，按值比较原始错误
+			if tt.err != existing && !reflect.DeepEqual(providerErr.Err, tt.err) {
+				t.Fatalf("原始错误 = %v，期望保留 %v", providerErr.Err, tt.err)
+			}
+		})
+	}
+
+	t.Run("无法识别状态码时原样返回", func(t *testing.T) {
+		networkErr := &url.Error{Op: "Post", URL: "https://api.example.com", Err: errors.New("connection refused")}
+		if err := AsProviderError("openai", networkErr); err != networkErr {
+			t.Fatalf("错误 = %v，期望原样返回", err)
+		}
+		if AsProviderError("openai", nil) != nil {
+			t.Fatal("nil 错误应返回 nil")
+		}
+	})
+}
+
+func TestIsRetriableErrorWithoutStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "超时", err: fmt.Errorf("调用失败: %w", context.DeadlineExceeded), want: true},
+		{name: "网络错误", err: &url.Error{Op: "Post", URL: "https://api.example.com", Err: errors.New("connection reset")}, want: true},
+		{name: "未知错误", err: errors.New("unexpected"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetriableError(tt.err); got != tt.want {
+				t.Fatalf("IsRetriableError = %v，期望 %v", got, tt.want)
+			}
+			if IsAuthError(tt.err) {
+				t.Fatal("非状态码错误不应视为鉴权失败")
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+
+	"lyss-ai-platform/eino-service/internal/models"
+)
+
+// protectedHeaders 不允许通过凭证额外请求头覆盖的请求头，包括各供应商的鉴权头和由HTTP客户端维护的头
+var protectedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Api-Key":             true,
+	"X-Api-Key":           true,
+	"X-Goog-Api-Key":      true,
+	"Host":                true,
+	"Content-Length":      true,
+	"Content-Type":        true,
+}
+
+// IsProtectedHeader 判断请求头是否受保护，不区分大小写
+func IsProtectedHeader(name string) bool {
+	return protectedHeaders[http.CanonicalHeaderKey(strings.TrimSpace(name))]
+}
+
+// ExtraHeaders 读取凭证 model_configs.extra_headers 中配置的额外请求头（如组织ID、beta 标记）
+// 忽略受保护的请求头和非字符串值，未配置时返回nil
+func ExtraHeaders(cred *models.SupplierCredential) map[string]string {
+	configured, ok := cred.ModelConfigs["extra_headers"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	headers := make(map[string]string, len(configured))
+	for name, value := range configured {
+		text, ok := value.(string)
+		if !ok || strings.TrimSpace(name) == "" || IsProtectedHeader(name) {
+			continue
+		}
+		headers[http.CanonicalHeaderKey(strings.TrimSpace(name))] = text
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
+
+// applyExtraHeaders 将额外请求头写入请求，跳过受保护的请求头
+func applyExtraHeaders(req *http.Request, headers map[string]string) {
+	for name, value := range headers {
+		if IsProtectedHeader(name) {
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+}
+
+// HeaderTransport 为出站请求附加额外请求头的传输层，用于无法直接设置请求头的模型组件
+type HeaderTransport struct {
+	Base    http.RoundTripper
+	Headers map[string]string
+}
+
+// RoundTrip 实现 http.RoundTripper 接口
+// 按 RoundTripper 约定不修改原请求，复制后写入额外请求头
+func (t *HeaderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if len(t.Headers) == 0 {
+		return base.RoundTrip(req)
+	}
+
+	outbound := req.Clone(req.Context())
+	applyExtraHeaders(outbound, t.Headers)
+	return base.RoundTrip(outbound)
+}
+
+// WithExtraHeaders 返回附加额外请求头的HTTP客户端
+// headers 为空时原样返回 httpClient（可能为nil，由调用方使用默认客户端）
+func WithExtraHeaders(httpClient *http.Client, headers map[string]string) *http.Client {
+	if len(headers) == 0 {
+		return httpClient
+	}
+
+	withHeaders := &http.Client{}
+	if httpClient != nil {
+		*withHeaders = *httpClient
+	}
+	withHeaders.Transport = &HeaderTransport{
+		Base:    withHeaders.Transport,
+		Headers: headers,
+	}
+	return withHeaders
+}
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/internal/models"
+)
+
+func TestExtraHeaders(t *testing.T) {
+	tests := []struct {
+		name         string
+		modelConfigs map[string]interface{}
+		want         map[string]string
+	}{
+		{name: "未配置", modelConfigs: map[string]interface{}{}, want: nil},
+		{name: "格式错误", modelConfigs: map[string]interface{}{"extra_headers": "X-Org: 1"}, want: nil},
+		{
+			name: "规范化请求头名称",
+			modelConfigs: map[string]interface{}{"extra_headers": map[string]interface{}{
+				"openai-organization": "org-1",
+				" anthropic-beta ":    "tools-2024",
+			}},
+			want: map[string]string{"Openai-Organization": "org-1", "Anthropic-Beta": "tools-2024"},
+		},
+		{
+			name: "忽略受保护请求头与非字符串值",
+			modelConfigs: map[string]interface{}{"extra_headers": map[string]interface{}{
+				"authorization":  "Bearer evil",
+				"X-API-KEY":      "evil",
+				"x-goog-api-key": "evil",
+				"Content-Type":   "text/plain",
+				"X-Retry":        3,
+				"X-Org":          "org-1",
+			}},
+			want: map[string]string{"X-Org": "org-1"},
+		},
+		{
+			name:         "全部被忽略时返回nil",
+			modelConfigs: map[string]interface{}{"extra_headers": map[string]interface{}{"Authorization": "Bearer evil"}},
+			want:         nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtraHeaders(&models.SupplierCredential{ModelConfigs: tt.modelConfigs})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ExtraHeaders = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// headerRecorder 记录收到的请求头并返回固定响应
+type headerRecorder struct {
+	mutex   sync.Mutex
+	headers http.Header
+}
+
+func (h *headerRecorder) handler(body string, stream bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.mutex.Lock()
+		h.headers = r.Header.Clone()
+		h.mutex.Unlock()
+		if stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		io.WriteString(w, body)
+	}
+}
+
+func (h *headerRecorder) received() http.Header {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.headers
+}
+
+func TestDeepSeekClientExtraHeaders(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	// 直接设置未经 ExtraHeaders 过滤的请求头，验证发送时仍不能覆盖受保护的请求头
+	extra := map[string]string{
+		"X-Org":          "org-1",
+		"Anthropic-Beta": "tools-2024",
+		"Authorization":  "Bearer evil",
+		"Content-Type":   "text/plain",
+	}
+
+	tests := []struct {
+		name   string
+		stream bool
+		body   string
+	}{
+		{name: "非流式请求", body: `{"id":"1","choices":[{"index":0,"message":{"role":"assistant","content":"hi"}}]}`},
+		{name: "流式请求", stream: true, body: "data: {\"id\":\"1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hi\"}}]}\n\ndata: [DONE]\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &headerRecorder{}
+			server := httptest.NewServer(recorder.handler(tt.body, tt.stream))
+			t.Cleanup(server.Close)
+
+			deepseek := NewDeepSeekClient("sk-test", server.URL, http.DefaultClient, logger)
+			deepseek.SetExtraHeaders(extra)
+			req := &DeepSeekRequest{
+				Model:    "deepseek-chat",
+				Messages: []DeepSeekMessage{{Role: "user", Content: "你好"}},
+			}
+
+			if tt.stream {
+				chunks, err := deepseek.ChatCompletionStream(context.Background(), req)
+				if err != nil {
+					t.Fatalf("ChatCompletionStream: %v", err)
+				}
+				for range chunks {
+				}
+			} else if _, err := deepseek.ChatCompletion(context.Background(), req); err != nil {
+				t.Fatalf("ChatCompletion: %v", err)
+			}
+
+			headers := recorder.received()
+			if headers.Get("X-Org") != "org-1" || headers.Get("Anthropic-Beta") != "tools-2024" {
+				t.Fatalf("请求头 = %v，期望包含额外请求头", headers)
+			}
+			if got := headers.Get("Authorization"); got != "Bearer sk-test" {
+				t.Fatalf("Authorization = %q，期望凭证自身的密钥", got)
+			}
+			if got := headers.Get("Content-Type"); got != "application/json" {
+				t.Fatalf("Content-Type = %q，期望 application/json", got)
+			}
+		})
+	}
+}
+
+func TestWithExtraHeaders(t *testing.T) {
+	recorder := &headerRecorder{}
+	server := httptest.NewServer(recorder.handler("ok", false))
+	t.Cleanup(server.Close)
+
+	httpClient := WithExtraHeaders(http.DefaultClient, map[string]string{
+		"X-Org":         "org-1",
+		"Authorization": "Bearer evil",
+	})
+	if httpClient == http.DefaultClient {
+		t.Fatal("配置了额外请求头时应返回新的客户端")
+	}
+	if WithExtraHeaders(http.DefaultClient, nil) != http.DefaultClient {
+		t.Fatal("未配置额外请求头时应原样返回客户端")
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, nil)
+	req.Header.Set("Authorization", "Bearer sk-test")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+
+	headers := recorder.received()
+	if headers.Get("X-Org") != "org-1" {
+		t.Fatalf("请求头 = %v，期望包含 X-Org", headers)
+	}
+	if got := headers.Get("Authorization"); got != "Bearer sk-test" {
+		t.Fatalf("Authorization = %q，期望模型组件设置的密钥", got)
+	}
+	if req.Header.Get("X-Org") != "" {
+		t.Fatal("传输层不应修改原请求")
+	}
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genai"
+)
+
+// geminiModels Gemini 内置模型列表
+var geminiModels = []string{
+	"gemini-2.0-flash",
+	"gemini-1.5-pro",
+	"gemini-1.5-flash",
+}
+
+// GeminiClient Google Gemini 客户端
+// 封装 genai 客户端，供 EINO Gemini 模型组件使用
+type GeminiClient struct {
+	client *genai.Client
+	logger *logrus.Logger
+}
+
+// NewGeminiClient 创建Gemini客户端
+// baseURL 为空时使用 Gemini API 默认端点；为 Vertex AI 端点（*aiplatform.googleapis.com）时以 API Key 方式访问 Vertex AI
+// httpClient 为共享连接池的客户端，为nil时使用 genai 默认客户端
+func NewGeminiClient(ctx context.Context, apiKey, baseURL string, httpClient *http.Client, logger *logrus.Logger) (*GeminiClient, error) {
+	config := &genai.ClientConfig{
+		APIKey:     apiKey,
+		Backend:    genai.BackendGeminiAPI,
+		HTTPClient: httpClient,
+	}
+	if baseURL != "" {
+		config.HTTPOptions.BaseURL = baseURL
+		if strings.Contains(baseURL, "aiplatform.googleapis.com") {
+			config.Backend = genai.BackendVertexAI
+		}
+	}
+
+	client, err := genai.NewClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("创建Gemini客户端失败: %w", err)
+	}
+
+	return &GeminiClient{
+		client: client,
+		logger: logger,
+	}, nil
+}
+
+// GenAI 获取底层 genai 客户端
+func (c *GeminiClient) GenAI() *genai.Client {
+	return c.client
+}
+
+// GetModels 获取可用模型列表
+func (c *GeminiClient) GetModels(ctx context.Context) ([]string, error) {
+	models := append([]string(nil), geminiModels...)
+
+	c.logger.WithField("models", models).Info("返回Gemini模型列表")
+	return models, nil
+}
+
+// ValidateModel 验证模型名称
+func (c *GeminiClient) ValidateModel(model string) bool {
+	for _, name := range geminiModels {
+		if name == model {
+			return true
+		}
+	}
+	return false
+}
+
+// TestModel 发起最小的 generateContent 请求测试模型可用性
+func (c *GeminiClient) TestModel(ctx context.Context, model string) error {
+	resp, err := c.client.Models.GenerateContent(ctx, model, genai.Text("Hello, this is a connection test."), &genai.GenerateContentConfig{
+		MaxOutputTokens: 10,
+	})
+	if err != nil {
+		c.logger.WithError(err).Error("Gemini连接测试失败")
+		return fmt.Errorf("连接测试失败: %w", err)
+	}
+
+	fields := logrus.Fields{"model": model}
+	if resp.UsageMetadata != nil {
+		fields["total_tokens"] = resp.UsageMetadata.TotalTokenCount
+	}
+	c.logger.WithFields(fields).Info("Gemini连接测试成功")
+	return nil
+}
+
+// GetDefaultModel 获取默认模型
+func (c *GeminiClient) GetDefaultModel() string {
+	return "gemini-1.5-flash"
+}
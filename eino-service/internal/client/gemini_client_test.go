@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genai"
+)
+
+func TestNewGeminiClientBackend(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	tests := []struct {
+		name        string
+		baseURL     string
+		wantBackend genai.Backend
+	}{
+		{name: "默认端点", wantBackend: genai.BackendGeminiAPI},
+		{name: "自定义Gemini端点", baseURL: "https://gemini-proxy.example.com/", wantBackend: genai.BackendGeminiAPI},
+		{name: "Vertex AI端点", baseURL: "https://us-central1-aiplatform.googleapis.com/", wantBackend: genai.BackendVertexAI},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			geminiClient, err := NewGeminiClient(context.Background(), "test-key", tt.baseURL, nil, logger)
+			if err != nil {
+				t.Fatalf("NewGeminiClient: %v", err)
+			}
+			config := geminiClient.GenAI().ClientConfig()
+			if config.Backend != tt.wantBackend {
+				t.Fatalf("backend = %v，期望 %v", config.Backend, tt.wantBackend)
+			}
+			if tt.baseURL != "" && config.HTTPOptions.BaseURL != tt.baseURL {
+				t.Fatalf("base url = %q，期望 %q", config.HTTPOptions.BaseURL, tt.baseURL)
+			}
+		})
+	}
+}
+
+func TestGeminiClientModels(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	geminiClient, err := NewGeminiClient(context.Background(), "test-key", "", nil, logger)
+	if err != nil {
+		t.Fatalf("NewGeminiClient: %v", err)
+	}
+
+	models, _ := geminiClient.GetModels(context.Background())
+	for _, model := range models {
+		if !geminiClient.ValidateModel(model) {
+			t.Fatalf("GetModels 返回的模型 %s 未通过校验", model)
+		}
+	}
+
+	tests := []struct {
+		model string
+		want  bool
+	}{
+		{model: "gemini-2.0-flash", want: true},
+		{model: "gemini-1.5-pro", want: true},
+		{model: geminiClient.GetDefaultModel(), want: true},
+		{model: "deepseek-chat", want: false},
+		{model: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			if got := geminiClient.ValidateModel(tt.model); got != tt.want {
+				t.Fatalf("ValidateModel(%q) = %v，期望 %v", tt.model, got, tt.want)
+			}
+		})
+	}
+}
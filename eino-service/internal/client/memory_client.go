@@ -0,0 +1,87 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/internal/config"
+	"lyss-ai-platform/eino-service/internal/models"
+)
+
+// MemoryClient 记忆服务客户端
+type MemoryClient struct {
+	baseURL    string
+	httpClient *http.Client
+	logger     *logrus.Logger
+}
+
+// NewMemoryClient 创建新的记忆服务客户端
+func NewMemoryClient(config *config.MemoryServiceConfig, transport http.RoundTripper, logger *logrus.Logger) *MemoryClient {
+	return &MemoryClient{
+		baseURL: config.BaseURL,
+		httpClient: &http.Client{
+			Transport: transport,
+			Timeout:   config.Timeout,
+		},
+		logger: logger,
+	}
+}
+
+// SearchMemories 检索与查询相关的用户记忆
+func (c *MemoryClient) SearchMemories(ctx context.Context, tenantID, userID, requestID string, searchRequest *models.MemorySearchRequest) ([]*models.Memory, error) {
+	url := fmt.Sprintf("%s/api/v1/memory/search", c.baseURL)
+
+	reqBody, err := json.Marshal(searchRequest)
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tenant-ID", tenantID)
+	req.Header.Set("X-User-ID", userID)
+	req.Header.Set("X-Request-ID", requestID)
+
+	c.logger.WithFields(logrus.Fields{
+		"tenant_id":  tenantID,
+		"user_id":    userID,
+		"request_id": requestID,
+		"limit":      searchRequest.Limit,
+	}).Debug("检索用户记忆")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP状态码错误: %d", resp.StatusCode)
+	}
+
+	var apiResponse models.ApiResponse[models.MemorySearchResult]
+	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	if !apiResponse.Success {
+		return nil, fmt.Errorf("API请求失败: %s", apiResponse.Message)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"tenant_id":  tenantID,
+		"user_id":    userID,
+		"request_id": requestID,
+		"count":      len(apiResponse.Data.Memories),
+	}).Debug("检索用户记忆成功")
+
+	return apiResponse.Data.Memories, nil
+}
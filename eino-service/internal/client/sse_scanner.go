@@ -2,38 +2,154 @@ package client
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
 	"io"
 )
 
+// DefaultMaxSSELineSize SSE扫描器默认的单行上限
+const DefaultMaxSSELineSize = 1024 * 1024
+
+// sseInitialBufferSize SSE扫描器的初始缓冲区大小
+const sseInitialBufferSize = 64 * 1024
+
+// ErrSSELineTooLong SSE单行超过扫描器的行上限
+var ErrSSELineTooLong = errors.New("SSE单行超过上限")
+
+// SSELineTooLongError 超长行的详情，该行已按行读取器完整读出，调用方可以记录后继续处理
+type SSELineTooLongError struct {
+	Size  int // 该行的实际字节数
+	Limit int // 扫描器的行上限
+}
+
+// Error 实现 error 接口
+func (e *SSELineTooLongError) Error() string {
+	return fmt.Sprintf("%s: %d 字节（上限 %d 字节）", ErrSSELineTooLong.Error(), e.Size, e.Limit)
+}
+
+// Unwrap 支持 errors.Is(err, ErrSSELineTooLong) 判断
+func (e *SSELineTooLongError) Unwrap() error {
+	return ErrSSELineTooLong
+}
+
 // SSEScanner Server-Sent Events 扫描器
+// 行长度不超过上限时使用 bufio.Scanner 逐行读取；超过上限时改用 bufio.Reader 读出该行剩余部分，
+// 不截断响应，并通过 LineErr 返回 ErrSSELineTooLong
 type SSEScanner struct {
-	scanner *bufio.Scanner
+	reader      *bufio.Reader
+	scanner     *bufio.Scanner
+	maxLineSize int
+
+	// overflow 当前超长行的前缀，由 split 在缓冲区写满时设置
+	overflow []byte
+	line     string
+	lineErr  error
+	err      error
 }
 
-// NewSSEScanner 创建SSE扫描器
+// NewSSEScanner 创建SSE扫描器，单行上限为 DefaultMaxSSELineSize
 func NewSSEScanner(r io.Reader) *SSEScanner {
-	scanner := bufio.NewScanner(r)
-	
+	return NewSSEScannerWithLimit(r, DefaultMaxSSELineSize)
+}
+
+// NewSSEScannerWithLimit 创建指定单行上限的SSE扫描器，maxLineSize 不大于0时使用 DefaultMaxSSELineSize
+func NewSSEScannerWithLimit(r io.Reader, maxLineSize int) *SSEScanner {
+	if maxLineSize <= 0 {
+		maxLineSize = DefaultMaxSSELineSize
+	}
+
+	s := &SSEScanner{
+		reader:      bufio.NewReader(r),
+		maxLineSize: maxLineSize,
+	}
+	s.scanner = bufio.NewScanner(s.reader)
+
 	// 设置缓冲区大小以处理大响应
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
-	
-	return &SSEScanner{
-		scanner: scanner,
+	buf := make([]byte, 0, min(sseInitialBufferSize, maxLineSize))
+	s.scanner.Buffer(buf, maxLineSize)
+	s.scanner.Split(s.split)
+
+	return s
+}
+
+// split 按行切分，缓冲区写满仍未遇到换行时交出已读部分，由 Scan 改用行读取器读完该行
+func (s *SSEScanner) split(data []byte, atEOF bool) (int, []byte, error) {
+	advance, token, err := bufio.ScanLines(data, atEOF)
+	if advance > 0 || token != nil || err != nil {
+		return advance, token, err
+	}
+	if len(data) >= s.maxLineSize {
+		s.overflow = append([]byte(nil), data...)
+		return len(data), data, nil
 	}
+	return 0, nil, nil
 }
 
 // Scan 扫描下一行
 func (s *SSEScanner) Scan() bool {
-	return s.scanner.Scan()
+	s.line = ""
+	s.lineErr = nil
+	if s.err != nil {
+		return false
+	}
+
+	if !s.scanner.Scan() {
+		return false
+	}
+	if s.overflow == nil {
+		s.line = s.scanner.Text()
+		return true
+	}
+
+	line, err := s.readLongLine(s.overflow)
+	s.overflow = nil
+	if err != nil {
+		s.err = fmt.Errorf("读取超长SSE行失败: %w", err)
+		return false
+	}
+	s.line = line
+	if len(line) > s.maxLineSize {
+		s.lineErr = &SSELineTooLongError{Size: len(line), Limit: s.maxLineSize}
+	}
+	return true
+}
+
+// readLongLine 使用行读取器读完超长行的剩余部分，行长度不受限制
+func (s *SSEScanner) readLongLine(prefix []byte) (string, error) {
+	var line bytes.Buffer
+	line.Write(prefix)
+	for {
+		chunk, err := s.reader.ReadSlice('\n')
+		line.Write(chunk)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		break
+	}
+
+	data := bytes.TrimSuffix(line.Bytes(), []byte("\n"))
+	data = bytes.TrimSuffix(data, []byte("\r"))
+	return string(data), nil
 }
 
 // Text 获取当前行文本
 func (s *SSEScanner) Text() string {
-	return s.scanner.Text()
+	return s.line
+}
+
+// LineErr 当前行超过上限时返回 *SSELineTooLongError，该行内容仍完整可用，扫描可以继续
+func (s *SSEScanner) LineErr() error {
+	return s.lineErr
 }
 
 // Err 获取扫描错误
 func (s *SSEScanner) Err() error {
+	if s.err != nil {
+		return s.err
+	}
 	return s.scanner.Err()
-}
\ No newline at end of file
+}
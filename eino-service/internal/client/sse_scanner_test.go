@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/sirupsen/logrus"
+)
+
+// newTestLogger 创建丢弃输出的日志记录器
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// scanAll 读取全部行及每行的超长错误
+func scanAll(t *testing.T, scanner *SSEScanner) ([]string, []error) {
+	t.Helper()
+	var lines []string
+	var lineErrs []error
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		lineErrs = append(lineErrs, scanner.LineErr())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	return lines, lineErrs
+}
+
+func TestSSEScannerOversizedDataLine(t *testing.T) {
+	big := strings.Repeat("x", 2*DefaultMaxSSELineSize)
+	input := "data: a\r\n\ndata: " + big + "\r\ndata: b\n: keepalive\ndata: " + big
+
+	tests := []struct {
+		name   string
+		reader func() io.Reader
+	}{
+		{name: "完整读取", reader: func() io.Reader { return strings.NewReader(input) }},
+		{name: "分段读取", reader: func() io.Reader { return iotest.HalfReader(strings.NewReader(input)) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, lineErrs := scanAll(t, NewSSEScanner(tt.reader()))
+
+			want := []string{"data: a", "", "data: " + big, "data: b", ": keepalive", "data: " + big}
+			if len(lines) != len(want) {
+				t.Fatalf("读取 %d 行，期望 %d 行", len(lines), len(want))
+			}
+			for i := range want {
+				if lines[i] != want[i] {
+					t.Fatalf("第 %d 行长度 %d，期望 %d", i, len(lines[i]), len(want[i]))
+				}
+			}
+
+			for _, i := range []int{2, 5} {
+				var tooLong *SSELineTooLongError
+				if !errors.As(lineErrs[i], &tooLong) || !errors.Is(lineErrs[i], ErrSSELineTooLong) {
+					t.Fatalf("第 %d 行应返回 ErrSSELineTooLong，实际 %v", i, lineErrs[i])
+				}
+				if tooLong.Size != len(want[i]) || tooLong.Limit != DefaultMaxSSELineSize {
+					t.Fatalf("超长详情 = %+v", tooLong)
+				}
+			}
+			for _, i := range []int{0, 1, 3, 4} {
+				if lineErrs[i] != nil {
+					t.Fatalf("第 %d 行不应返回错误: %v", i, lineErrs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSSEScannerLimitBoundary(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantLine string
+		tooLong  bool
+	}{
+		{name: "低于上限", input: "abc\n", wantLine: "abc"},
+		{name: "等于上限", input: "abcd\n", wantLine: "abcd"},
+		{name: "超过上限", input: "abcde\n", wantLine: "abcde", tooLong: true},
+		{name: "超过上限且无换行结尾", input: "abcdefgh", wantLine: "abcdefgh", tooLong: true},
+		{name: "逐字节读取", input: "abcdefgh\r\n", wantLine: "abcdefgh", tooLong: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := io.Reader(strings.NewReader(tt.input))
+			if tt.name == "逐字节读取" {
+				reader = iotest.OneByteReader(reader)
+			}
+			lines, lineErrs := scanAll(t, NewSSEScannerWithLimit(reader, 4))
+			if len(lines) != 1 || lines[0] != tt.wantLine {
+				t.Fatalf("lines = %q，期望 [%q]", lines, tt.wantLine)
+			}
+			if got := errors.Is(lineErrs[0], ErrSSELineTooLong); got != tt.tooLong {
+				t.Fatalf("超长 = %v，期望 %v", got, tt.tooLong)
+			}
+		})
+	}
+}
+
+func TestDeepSeekStreamKeepsOversizedChunk(t *testing.T) {
+	content := strings.Repeat("y", 2*DefaultMaxSSELineSize)
+	body := `data: {"choices":[{"index":0,"delta":{"role":"assistant","content":"` + content + `"}}]}` + "\n\n" +
+		`data: {"choices":[{"index":0,"delta":{"role":"assistant","content":"!"}}]}` + "\n\ndata: [DONE]\n"
+
+	deepSeekClient := NewDeepSeekClient("sk-test", "", nil, newTestLogger())
+	responseChan := make(chan *DeepSeekStreamResponse, 10)
+	deepSeekClient.processStreamResponse(context.Background(), io.NopCloser(strings.NewReader(body)), responseChan)
+	close(responseChan)
+
+	var received []string
+	for resp := range responseChan {
+		if resp.Error != nil {
+			t.Fatalf("不应返回流错误: %s", resp.Error.Message)
+		}
+		received = append(received, resp.Choices[0].Delta.Content)
+	}
+	if len(received) != 2 || received[0] != content || received[1] != "!" {
+		t.Fatalf("收到 %d 个增量，超长增量未完整保留", len(received))
+	}
+}
+
+func TestDeepSeekClientMaxSSELineSize(t *testing.T) {
+	body := "data: " + strings.Repeat("z", 64) + "\n"
+	deepSeekClient := NewDeepSeekClient("sk-test", "", nil, newTestLogger())
+	deepSeekClient.SetMaxSSELineSize(16)
+
+	scanner := NewSSEScannerWithLimit(strings.NewReader(body), deepSeekClient.maxSSELineSize)
+	_, lineErrs := scanAll(t, scanner)
+	var tooLong *SSELineTooLongError
+	if !errors.As(lineErrs[0], &tooLong) || tooLong.Limit != 16 {
+		t.Fatalf("应使用配置的单行上限，实际 %v", lineErrs[0])
+	}
+}
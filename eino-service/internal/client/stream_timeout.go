@@ -0,0 +1,175 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrFirstByteTimeout 供应商在首字节超时内未返回响应头
+var ErrFirstByteTimeout = errors.New("等待供应商响应超时")
+
+// ErrStreamIdleTimeout 流式响应在空闲超时内未收到新数据
+var ErrStreamIdleTimeout = errors.New("流式响应空闲超时")
+
+// StreamTimeouts 流式请求的超时设置，取值为0表示不限制
+// 流式请求不使用 http.Client.Timeout，整体时长只受请求 context 约束
+type StreamTimeouts struct {
+	FirstByte time.Duration // 发出请求到收到响应头的最长时间
+	Idle      time.Duration // 相邻两次收到数据的最长间隔，每收到数据重新计时
+}
+
+// timeoutError 超时错误，实现 net.Error 以便按超时处理（可重试）
+type timeoutError struct {
+	cause error
+	after time.Duration
+}
+
+// Error 实现 error 接口
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("%s（%s）", e.cause.Error(), e.after)
+}
+
+// Unwrap 支持 errors.Is(err, ErrStreamIdleTimeout) 等判断
+func (e *timeoutError) Unwrap() error {
+	return e.cause
+}
+
+// Timeout 实现 net.Error
+func (e *timeoutError) Timeout() bool {
+	return true
+}
+
+// Temporary 实现 net.Error
+func (e *timeoutError) Temporary() bool {
+	return true
+}
+
+// StreamWatchdog 流式调用的超时看门狗
+// 开始时按首字节超时计时，收到数据后改按空闲超时计时并在每次收到数据时重新计时，超时后取消其 context
+type StreamWatchdog struct {
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	timeouts StreamTimeouts
+
+	mutex          sync.Mutex
+	firstByteTimer *time.Timer
+	idleTimer      *time.Timer
+	stopped        bool
+}
+
+// NewStreamWatchdog 创建超时看门狗，流式调用须使用返回的 context
+func NewStreamWatchdog(parent context.Context, timeouts StreamTimeouts) (context.Context, *StreamWatchdog) {
+	ctx, cancel := context.WithCancelCause(parent)
+	w := &StreamWatchdog{
+		ctx:      ctx,
+		cancel:   cancel,
+		timeouts: timeouts,
+	}
+	if timeouts.FirstByte > 0 {
+		w.firstByteTimer = time.AfterFunc(timeouts.FirstByte, func() {
+			cancel(&timeoutError{cause: ErrFirstByteTimeout, after: timeouts.FirstByte})
+		})
+	}
+	return ctx, w
+}
+
+// Received 记录收到数据，停止首字节计时并重新开始空闲计时
+func (w *StreamWatchdog) Received() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.stopped {
+		return
+	}
+	if w.firstByteTimer != nil {
+		w.firstByteTimer.Stop()
+		w.firstByteTimer = nil
+	}
+	if w.timeouts.Idle <= 0 {
+		return
+	}
+	if w.idleTimer == nil {
+		idle := w.timeouts.Idle
+		w.idleTimer = time.AfterFunc(idle, func() {
+			w.cancel(&timeoutError{cause: ErrStreamIdleTimeout, after: idle})
+		})
+		return
+	}
+	w.idleTimer.Reset(w.timeouts.Idle)
+}
+
+// Err 看门狗超时导致的失败返回超时错误，其余错误原样返回
+func (w *StreamWatchdog) Err(err error) error {
+	if err == nil {
+		return nil
+	}
+	if cause := context.Cause(w.ctx); errors.Is(cause, ErrFirstByteTimeout) || errors.Is(cause, ErrStreamIdleTimeout) {
+		return cause
+	}
+	return err
+}
+
+// Stop 停止计时并释放 context
+func (w *StreamWatchdog) Stop() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.stopped {
+		return
+	}
+	w.stopped = true
+	if w.firstByteTimer != nil {
+		w.firstByteTimer.Stop()
+	}
+	if w.idleTimer != nil {
+		w.idleTimer.Stop()
+	}
+	w.cancel(nil)
+}
+
+// doStreamRequest 发送流式请求
+// 忽略 httpClient 的整体超时，首字节超时只约束等待响应头的阶段，返回的响应体在空闲超时内无数据时中断连接
+func doStreamRequest(httpClient *http.Client, req *http.Request, timeouts StreamTimeouts) (*http.Response, error) {
+	streamClient := *httpClient
+	streamClient.Timeout = 0
+
+	ctx, watchdog := NewStreamWatchdog(req.Context(), timeouts)
+	resp, err := streamClient.Do(req.WithContext(ctx))
+	if err != nil {
+		watchdog.Stop()
+		return nil, watchdog.Err(err)
+	}
+
+	watchdog.Received()
+	resp.Body = &watchdogBody{body: resp.Body, watchdog: watchdog}
+	return resp, nil
+}
+
+// watchdogBody 受看门狗约束的响应体，每次读到数据重新计时
+type watchdogBody struct {
+	body     io.ReadCloser
+	watchdog *StreamWatchdog
+}
+
+// Read 读取响应体，超时中断时返回超时错误
+func (b *watchdogBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if n > 0 {
+		b.watchdog.Received()
+	}
+	if err != nil && err != io.EOF {
+		err = b.watchdog.Err(err)
+	}
+	return n, err
+}
+
+// Close 关闭响应体并停止看门狗
+func (b *watchdogBody) Close() error {
+	b.watchdog.Stop()
+	return b.body.Close()
+}
@@ -0,0 +1,159 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// newPacedStreamServer 启动流式供应商替身：等待 firstByte 后返回响应头，之后每隔 interval 发送一个数据块，
+// 发送 chunks 个数据块后若 stall 为 true 则停止发送直到连接断开，否则发送 [DONE]
+func newPacedStreamServer(t *testing.T, firstByte, interval time.Duration, chunks int, stall bool) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(firstByte):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+
+		for i := 0; i < chunks; i++ {
+			select {
+			case <-time.After(interval):
+			case <-r.Context().Done():
+				return
+			}
+			fmt.Fprintf(w, "data: {\"id\":\"1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"%d\"}}]}\n\n", i)
+			w.(http.Flusher).Flush()
+		}
+		if stall {
+			<-r.Context().Done()
+			return
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestChatCompletionStreamTimeouts(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	tests := []struct {
+		name       string
+		firstByte  time.Duration
+		interval   time.Duration
+		chunks     int
+		stall      bool
+		wantErr    error
+		wantChunks int
+		wantError  string // 流结束前最后一条错误事件的类型
+	}{
+		{
+			// 整体时长超过 http.Client.Timeout，但每次间隔都在空闲超时内
+			name:       "缓慢但持续的流正常完成",
+			interval:   40 * time.Millisecond,
+			chunks:     6,
+			wantChunks: 6,
+		},
+		{
+			name:       "停滞的流因空闲超时中断",
+			interval:   10 * time.Millisecond,
+			chunks:     2,
+			stall:      true,
+			wantChunks: 2,
+			wantError:  "timeout",
+		},
+		{
+			name:      "首字节超时",
+			firstByte: 500 * time.Millisecond,
+			wantErr:   ErrFirstByteTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newPacedStreamServer(t, tt.firstByte, tt.interval, tt.chunks, tt.stall)
+			deepseek := NewDeepSeekClient("sk-test", server.URL, &http.Client{Timeout: 100 * time.Millisecond}, logger)
+			deepseek.SetStreamTimeouts(StreamTimeouts{FirstByte: 100 * time.Millisecond, Idle: 150 * time.Millisecond})
+
+			start := time.Now()
+			stream, err := deepseek.ChatCompletionStream(context.Background(), &DeepSeekRequest{Model: "deepseek-chat"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("错误 = %v，期望 %v", err, tt.wantErr)
+				}
+				var netErr net.Error
+				if !errors.As(err, &netErr) || !netErr.Timeout() {
+					t.Fatalf("错误 = %v，期望可按超时处理的 net.Error", err)
+				}
+				if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+					t.Fatalf("耗时 %v，首字节超时未生效", elapsed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ChatCompletionStream: %v", err)
+			}
+
+			received, errorType := 0, ""
+			for chunk := range stream {
+				if chunk.Error != nil {
+					errorType = chunk.Error.Type
+					continue
+				}
+				received++
+			}
+			if received != tt.wantChunks {
+				t.Fatalf("收到 %d 个数据块，期望 %d", received, tt.wantChunks)
+			}
+			if errorType != tt.wantError {
+				t.Fatalf("错误事件类型 = %q，期望 %q", errorType, tt.wantError)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("耗时 %v，流未按预期结束", elapsed)
+			}
+		})
+	}
+}
+
+func TestDoStreamRequestIdleTimeoutError(t *testing.T) {
+	tests := []struct {
+		name    string
+		stall   bool
+		wantErr error
+	}{
+		{name: "正常结束", wantErr: nil},
+		{name: "读取停滞返回空闲超时", stall: true, wantErr: ErrStreamIdleTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newPacedStreamServer(t, 0, 10*time.Millisecond, 1, tt.stall)
+			req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+			resp, err := doStreamRequest(http.DefaultClient, req, StreamTimeouts{Idle: 100 * time.Millisecond})
+			if err != nil {
+				t.Fatalf("doStreamRequest: %v", err)
+			}
+			defer resp.Body.Close()
+
+			_, err = io.ReadAll(resp.Body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("读取错误 = %v，期望 %v", err, tt.wantErr)
+			}
+		})
+	}
+}
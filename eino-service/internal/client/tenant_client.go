@@ -13,6 +13,8 @@ import (
 
 	"lyss-ai-platform/eino-service/internal/config"
 	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/pkg/redact"
+	"lyss-ai-platform/eino-service/pkg/requestid"
 )
 
 // TenantClient 租户服务客户端
@@ -23,11 +25,12 @@ type TenantClient struct {
 }
 
 // NewTenantClient 创建新的租户服务客户端
-func NewTenantClient(config *config.TenantServiceConfig, logger *logrus.Logger) *TenantClient {
+func NewTenantClient(config *config.TenantServiceConfig, transport http.RoundTripper, logger *logrus.Logger) *TenantClient {
 	return &TenantClient{
 		baseURL: config.BaseURL,
 		httpClient: &http.Client{
-			Timeout: config.Timeout,
+			Transport: transport,
+			Timeout:   config.Timeout,
 		},
 		logger: logger,
 	}
@@ -53,7 +56,7 @@ func (c *TenantClient) GetAvailableCredentials(tenantID string, selector *models
 	
 	c.logger.WithFields(logrus.Fields{
 		"tenant_id": tenantID,
-		"url":       requestURL,
+		"url":       redact.String(requestURL),
 	}).Debug("获取可用凭证列表")
 	
 	resp, err := c.httpClient.Get(requestURL)
@@ -125,6 +128,40 @@ func (c *TenantClient) TestCredential(credentialID string, testRequest *models.C
 	return apiResponse.Data.Success, nil
 }
 
+// ReportInvalidCredential 通知租户服务凭证鉴权失败，由租户服务标记该凭证
+func (c *TenantClient) ReportInvalidCredential(ctx context.Context, credentialID, reason string) error {
+	url := fmt.Sprintf("%s/internal/suppliers/%s/invalidate", c.baseURL, credentialID)
+
+	reqBody, err := json.Marshal(map[string]string{
+		"reason": reason,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	c.logger.WithFields(logrus.Fields{
+		"credential_id": credentialID,
+	}).Debug("通知租户服务凭证失效")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP状态码错误: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
 // GetActiveTenants 获取活跃租户列表
 func (c *TenantClient) GetActiveTenants() ([]string, error) {
 	url := fmt.Sprintf("%s/internal/tenants/active", c.baseURL)
@@ -156,16 +193,22 @@ func (c *TenantClient) GetActiveTenants() ([]string, error) {
 }
 
 // GetToolConfig 获取工具配置
-func (c *TenantClient) GetToolConfig(tenantID, workflowName, toolName string) (*models.ToolConfig, error) {
+func (c *TenantClient) GetToolConfig(ctx context.Context, tenantID, workflowName, toolName string) (*models.ToolConfig, error) {
 	url := fmt.Sprintf("%s/internal/tool-configs/%s/%s/%s", c.baseURL, tenantID, workflowName, toolName)
 	
 	c.logger.WithFields(logrus.Fields{
+		"request_id":    requestid.FromContext(ctx),
 		"tenant_id":     tenantID,
 		"workflow_name": workflowName,
 		"tool_name":     toolName,
 	}).Debug("获取工具配置")
 	
-	resp, err := c.httpClient.Get(url)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP请求失败: %w", err)
 	}
@@ -185,6 +228,7 @@ func (c *TenantClient) GetToolConfig(tenantID, workflowName, toolName string) (*
 	}
 	
 	c.logger.WithFields(logrus.Fields{
+		"request_id":    requestid.FromContext(ctx),
 		"tenant_id":     tenantID,
 		"workflow_name": workflowName,
 		"tool_name":     toolName,
@@ -194,6 +238,42 @@ func (c *TenantClient) GetToolConfig(tenantID, workflowName, toolName string) (*
 	return &apiResponse.Data, nil
 }
 
+// GetFeatureFlags 获取租户级功能开关覆盖，未覆盖的开关不在结果中
+func (c *TenantClient) GetFeatureFlags(ctx context.Context, tenantID string) (map[string]bool, error) {
+	url := fmt.Sprintf("%s/internal/tenants/%s/features", c.baseURL, tenantID)
+	
+	c.logger.WithFields(logrus.Fields{
+		"request_id": requestid.FromContext(ctx),
+		"tenant_id":  tenantID,
+	}).Debug("获取租户功能开关")
+	
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP状态码错误: %d", resp.StatusCode)
+	}
+	
+	var apiResponse models.ApiResponse[map[string]bool]
+	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	
+	if !apiResponse.Success {
+		return nil, fmt.Errorf("API请求失败: %s", apiResponse.Message)
+	}
+	
+	return apiResponse.Data, nil
+}
+
 // HealthCheck 健康检查
 func (c *TenantClient) HealthCheck(ctx context.Context) error {
 	url := fmt.Sprintf("%s/health", c.baseURL)
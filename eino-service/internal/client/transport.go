@@ -0,0 +1,33 @@
+package client
+
+import (
+	"net"
+	"net/http"
+
+	"lyss-ai-platform/eino-service/internal/config"
+	"lyss-ai-platform/eino-service/pkg/requestid"
+	"lyss-ai-platform/eino-service/pkg/tracing"
+)
+
+// NewTransport 创建所有出站客户端共享的HTTP传输层
+// 复用同一个连接池，避免每个客户端各自建立连接造成的端口消耗
+// 请求 context 中携带请求ID时自动附加 X-Request-ID 头，便于关联上游日志
+// 每个出站请求创建客户端 span 并通过 traceparent 头传播追踪上下文
+func NewTransport(config *config.HTTPClientConfig) http.RoundTripper {
+	dialer := &net.Dialer{
+		Timeout:   config.DialTimeout,
+		KeepAlive: config.KeepAlive,
+	}
+
+	return &tracing.Transport{Base: &requestid.Transport{
+		Base: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        config.MaxIdleConns,
+			MaxIdleConnsPerHost: config.MaxIdleConnsPerHost,
+			IdleConnTimeout:     config.IdleConnTimeout,
+			TLSHandshakeTimeout: config.TLSHandshakeTimeout,
+		},
+	}}
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"lyss-ai-platform/eino-service/internal/config"
+)
+
+// newCountingServer 启动统计新建连接数的供应商替身
+func newCountingServer(tb testing.TB, connections *int64) *httptest.Server {
+	tb.Helper()
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1","model":"deepseek-chat","choices":[{"index":0,"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(connections, 1)
+		}
+	}
+	server.Start()
+	tb.Cleanup(server.Close)
+	return server
+}
+
+// newPooledClient 创建使用共享传输层的DeepSeek客户端
+func newPooledClient(baseURL string, transport http.RoundTripper) *DeepSeekClient {
+	return NewDeepSeekClient("sk-test", baseURL, &http.Client{Transport: transport, Timeout: 5 * time.Second}, newTestLogger())
+}
+
+func testHTTPClientConfig() *config.HTTPClientConfig {
+	return &config.HTTPClientConfig{
+		MaxIdleConns:        10,
+		MaxIdleConnsPerHost: 2,
+		IdleConnTimeout:     time.Minute,
+		TLSHandshakeTimeout: time.Second,
+		DialTimeout:         time.Second,
+		KeepAlive:           30 * time.Second,
+	}
+}
+
+func TestTransportReusesConnections(t *testing.T) {
+	var connections int64
+	server := newCountingServer(t, &connections)
+	transport := NewTransport(testHTTPClientConfig())
+
+	// 多个客户端共享同一个传输层，顺序请求复用同一个连接
+	for i := 0; i < 20; i++ {
+		deepSeekClient := newPooledClient(server.URL, transport)
+		if _, err := deepSeekClient.ChatCompletion(context.Background(), &DeepSeekRequest{Model: "deepseek-chat"}); err != nil {
+			t.Fatalf("第 %d 次请求失败: %v", i, err)
+		}
+	}
+
+	if got := atomic.LoadInt64(&connections); got != 1 {
+		t.Fatalf("新建连接数 = %d，期望复用 1 个连接", got)
+	}
+}
+
+func BenchmarkTransportConnectionReuse(b *testing.B) {
+	var connections int64
+	server := newCountingServer(b, &connections)
+	deepSeekClient := newPooledClient(server.URL, NewTransport(testHTTPClientConfig()))
+	req := &DeepSeekRequest{Model: "deepseek-chat"}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := deepSeekClient.ChatCompletion(context.Background(), req); err != nil {
+			b.Fatalf("请求失败: %v", err)
+		}
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(atomic.LoadInt64(&connections)), "conns")
+	if got := atomic.LoadInt64(&connections); got != 1 {
+		b.Fatalf("新建连接数 = %d，期望复用 1 个连接", got)
+	}
+}
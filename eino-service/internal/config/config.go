@@ -16,6 +16,10 @@ type Config struct {
 	Logging      LoggingConfig      `mapstructure:"logging"`
 	Credential   CredentialConfig   `mapstructure:"credential"`
 	Workflows    WorkflowsConfig    `mapstructure:"workflows"`
+	Quota        QuotaConfig        `mapstructure:"quota"`
+	Models       ModelsConfig       `mapstructure:"models"`
+	Tracing      TracingConfig      `mapstructure:"tracing"`
+	Features     FeaturesConfig     `mapstructure:"features"`
 }
 
 // ServerConfig 服务器配置
@@ -25,6 +29,12 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
 	IdleTimeout  time.Duration `mapstructure:"idle_timeout"`
+
+	MaxHeaderBytes int   `mapstructure:"max_header_bytes"` // 请求头大小上限
+	MaxBodyBytes   int64 `mapstructure:"max_body_bytes"`   // 请求体大小上限，超过时返回 413，0表示不限制
+
+	// InternalToken 内部接口（/internal/*）的访问令牌，调用方通过 X-Internal-Token 头携带；为空时内部接口不可用
+	InternalToken string `mapstructure:"internal_token"`
 }
 
 // DatabaseConfig 数据库配置
@@ -35,6 +45,17 @@ type DatabaseConfig struct {
 	Password string `mapstructure:"password"`
 	Database string `mapstructure:"database"`
 	SSLMode  string `mapstructure:"ssl_mode"`
+
+	// UsageAuditEnabled 是否将凭证使用审计记录写入数据库，与 PromptsEnabled 均关闭时服务不连接数据库
+	UsageAuditEnabled bool `mapstructure:"usage_audit_enabled"`
+	// PromptsEnabled 是否启用数据库存储的系统提示词预设库
+	PromptsEnabled bool `mapstructure:"prompts_enabled"`
+}
+
+// DSN 构建PostgreSQL连接串
+func (c *DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		c.Host, c.Port, c.Username, c.Password, c.Database, c.SSLMode)
 }
 
 // RedisConfig Redis配置
@@ -49,6 +70,7 @@ type RedisConfig struct {
 type ServicesConfig struct {
 	TenantService TenantServiceConfig `mapstructure:"tenant_service"`
 	MemoryService MemoryServiceConfig `mapstructure:"memory_service"`
+	HTTPClient    HTTPClientConfig    `mapstructure:"http_client"`
 }
 
 // TenantServiceConfig 租户服务配置
@@ -63,6 +85,22 @@ type MemoryServiceConfig struct {
 	Timeout time.Duration `mapstructure:"timeout"`
 }
 
+// HTTPClientConfig 出站HTTP客户端连接池配置
+type HTTPClientConfig struct {
+	MaxIdleConns        int           `mapstructure:"max_idle_conns"`
+	MaxIdleConnsPerHost int           `mapstructure:"max_idle_conns_per_host"`
+	IdleConnTimeout     time.Duration `mapstructure:"idle_conn_timeout"`
+	TLSHandshakeTimeout time.Duration `mapstructure:"tls_handshake_timeout"`
+	DialTimeout         time.Duration `mapstructure:"dial_timeout"`
+	KeepAlive           time.Duration `mapstructure:"keep_alive"`
+	ProviderTimeout     time.Duration `mapstructure:"provider_timeout"` // 非流式供应商请求的整体超时
+
+	ProviderFirstByteTimeout  time.Duration `mapstructure:"provider_first_byte_timeout"`  // 流式请求等待供应商首个响应的超时
+	ProviderStreamIdleTimeout time.Duration `mapstructure:"provider_stream_idle_timeout"` // 流式请求相邻两次收到数据的最长间隔
+
+	ProviderMaxSSELineBytes int `mapstructure:"provider_max_sse_line_bytes"` // 供应商流式响应单行上限，超过时改用行读取器完整读取
+}
+
 // LoggingConfig 日志配置
 type LoggingConfig struct {
 	Level      string `mapstructure:"level"`
@@ -71,6 +109,24 @@ type LoggingConfig struct {
 	MaxSize    int    `mapstructure:"max_size"`
 	MaxBackups int    `mapstructure:"max_backups"`
 	MaxAge     int    `mapstructure:"max_age"`
+
+	PromptSampleRate      int           `mapstructure:"prompt_sample_rate"`       // 每N个请求记录一次完整提示词与回答，0表示不采样
+	PromptTraceHeader     bool          `mapstructure:"prompt_trace_header"`      // 是否记录携带 X-Debug-Trace 头的请求
+	PromptTraceTTL        time.Duration `mapstructure:"prompt_trace_ttl"`         // 提示词记录在最后一次写入后的保留时间
+	PromptTraceMaxEntries int           `mapstructure:"prompt_trace_max_entries"` // 每个租户保留的最近记录数
+}
+
+// TracingConfig 链路追踪配置
+type TracingConfig struct {
+	Endpoint    string  `mapstructure:"endpoint"`     // OTLP/HTTP 导出地址（如 http://otel-collector:4318），为空时不导出
+	ServiceName string  `mapstructure:"service_name"` // 上报的服务名
+	SampleRatio float64 `mapstructure:"sample_ratio"` // 无上游追踪时的采样比例，有上游时沿用上游的采样决定
+}
+
+// FeaturesConfig 租户级功能开关配置
+type FeaturesConfig struct {
+	Defaults map[string]bool `mapstructure:"defaults"`  // 功能开关默认值，租户服务可按租户覆盖；未声明的开关视为关闭
+	CacheTTL time.Duration   `mapstructure:"cache_ttl"` // 租户覆盖在Redis中的缓存时间，0表示每次请求都查询租户服务
 }
 
 // CredentialConfig 凭证管理配置
@@ -78,6 +134,15 @@ type CredentialConfig struct {
 	CacheTTL           time.Duration `mapstructure:"cache_ttl"`
 	HealthCheckInterval time.Duration `mapstructure:"health_check_interval"`
 	MaxConcurrentTests int           `mapstructure:"max_concurrent_tests"`
+
+	CircuitFailureThreshold int           `mapstructure:"circuit_failure_threshold"`
+	CircuitCooldown         time.Duration `mapstructure:"circuit_cooldown"`
+
+	HealthCheckMode  string        `mapstructure:"health_check_mode"`
+	LiveCheckTimeout time.Duration `mapstructure:"live_check_timeout"`
+
+	MaxConcurrentCalls     int           `mapstructure:"max_concurrent_calls"`     // 单个凭证同时进行的模型调用上限，0表示不限制；凭证 model_configs.max_concurrent_calls 优先
+	ConcurrencyWaitTimeout time.Duration `mapstructure:"concurrency_wait_timeout"` // 凭证调用数已满时等待空位的最长时间，超时后换用备用凭证
 }
 
 // WorkflowsConfig 工作流配置
@@ -85,6 +150,73 @@ type WorkflowsConfig struct {
 	MaxConcurrentExecutions int           `mapstructure:"max_concurrent_executions"`
 	ExecutionTimeout        time.Duration `mapstructure:"execution_timeout"`
 	DefaultStrategy         string        `mapstructure:"default_strategy"`
+	IdempotencyTTL          time.Duration `mapstructure:"idempotency_ttl"`
+	ShutdownGracePeriod     time.Duration `mapstructure:"shutdown_grace_period"`
+	ExecutionRecordTTL      time.Duration `mapstructure:"execution_record_ttl"`
+	MaxProviderFallbacks    int           `mapstructure:"max_provider_fallbacks"`
+	MaxStreamResumes        int           `mapstructure:"max_stream_resumes"`
+
+	MaxMessageBytes  int            `mapstructure:"max_message_bytes"`
+	MaxMessageTokens int            `mapstructure:"max_message_tokens"`
+	ModelTokenLimits map[string]int `mapstructure:"model_token_limits"`
+	MaxImageBytes    int            `mapstructure:"max_image_bytes"`
+
+	MaxBatchSize     int `mapstructure:"max_batch_size"`    // 批量聊天单次请求的最大条数
+	BatchConcurrency int `mapstructure:"batch_concurrency"` // 批量聊天同时执行的最大条数
+
+	StreamKeepaliveInterval time.Duration `mapstructure:"stream_keepalive_interval"` // 流式响应空闲时发送保活注释的间隔，0表示不发送
+	StreamResumeTTL         time.Duration `mapstructure:"stream_resume_ttl"`         // 流式事件缓冲在最后一次写入后的保留时间，0表示关闭断线续传
+	StreamResumeMaxEvents   int           `mapstructure:"stream_resume_max_events"`  // 每次流式执行在缓冲中保留的最近事件数
+	StreamResumeGrace       time.Duration `mapstructure:"stream_resume_grace"`       // 客户端断开后等待续传的时间，期间没有客户端续传则取消执行，0表示断开即取消
+
+	ResponseCacheEnabled bool          `mapstructure:"response_cache_enabled"` // 是否缓存 temperature 为0的确定性请求的响应
+	ResponseCacheTTL     time.Duration `mapstructure:"response_cache_ttl"`     // 缓存响应的保留时间
+
+	ParameterPolicy      string         `mapstructure:"parameter_policy"`        // 生成参数越界时的处理策略：reject 或 clamp
+	MaxOutputTokens      int            `mapstructure:"max_output_tokens"`       // max_tokens 的默认上限，0表示不限制
+	ModelMaxOutputTokens map[string]int `mapstructure:"model_max_output_tokens"` // 按模型覆盖的 max_tokens 上限
+}
+
+// QuotaConfig 租户月度令牌与费用配额配置
+type QuotaConfig struct {
+	MonthlyTokenLimit int64              `mapstructure:"monthly_token_limit"` // 默认月度令牌上限，0表示不限制
+	MonthlyCostLimit  float64            `mapstructure:"monthly_cost_limit"`  // 默认月度费用上限，按 models.pricing 计价，0表示不限制
+	SoftLimitPercent  int                `mapstructure:"soft_limit_percent"`  // 达到该百分比时在响应中提示
+	TenantLimits      map[string]int64   `mapstructure:"tenant_limits"`       // 按租户覆盖的月度令牌上限
+	TenantCostLimits  map[string]float64 `mapstructure:"tenant_cost_limits"`  // 按租户覆盖的月度费用上限
+}
+
+// ModelsConfig 模型配置
+type ModelsConfig struct {
+	Aliases []ModelAliasConfig   `mapstructure:"aliases"`
+	Pricing []ModelPricingConfig `mapstructure:"pricing"` // 用量报表计算费用使用的模型单价
+
+	DefaultModel string                `mapstructure:"default_model"` // 请求未指定模型和供应商时使用的模型
+	Defaults     []ModelDefaultsConfig `mapstructure:"defaults"`      // 按模型配置的默认生成参数
+}
+
+// ModelAliasConfig 模型别名，将客户端使用的名称映射到供应商与具体模型ID
+type ModelAliasConfig struct {
+	Alias    string `mapstructure:"alias"`
+	Provider string `mapstructure:"provider"`
+	Model    string `mapstructure:"model"` // 为空时与别名相同
+
+	Capabilities []string `mapstructure:"capabilities"` // 模型支持的能力，用于按能力选择模型
+}
+
+// ModelDefaultsConfig 模型的默认生成参数，请求未指定时使用
+type ModelDefaultsConfig struct {
+	Model       string   `mapstructure:"model"`       // 具体模型ID
+	Provider    string   `mapstructure:"provider"`    // 请求未指定供应商时使用
+	Temperature *float64 `mapstructure:"temperature"` // 为空时使用0.7
+	MaxTokens   int      `mapstructure:"max_tokens"`  // 为0时使用2048
+}
+
+// ModelPricingConfig 模型单价，按每1000个令牌计价
+type ModelPricingConfig struct {
+	Model           string  `mapstructure:"model"`
+	PromptPer1K     float64 `mapstructure:"prompt_per_1k"`
+	CompletionPer1K float64 `mapstructure:"completion_per_1k"`
 }
 
 // LoadConfig 加载配置
@@ -108,6 +240,11 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("解析配置文件失败: %w", err)
 	}
 	
+	// 校验配置项取值，存在问题时启动失败
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	
 	return &config, nil
 }
 
@@ -119,6 +256,9 @@ func setDefaultValues() {
 	viper.SetDefault("server.read_timeout", "30s")
 	viper.SetDefault("server.write_timeout", "30s")
 	viper.SetDefault("server.idle_timeout", "120s")
+	viper.SetDefault("server.max_header_bytes", 1<<20)
+	viper.SetDefault("server.max_body_bytes", 16<<20)
+	viper.SetDefault("server.internal_token", "")
 	
 	// 数据库默认配置
 	viper.SetDefault("database.host", "localhost")
@@ -127,6 +267,8 @@ func setDefaultValues() {
 	viper.SetDefault("database.password", "lyss_dev_password_2025")
 	viper.SetDefault("database.database", "lyss_platform")
 	viper.SetDefault("database.ssl_mode", "disable")
+	viper.SetDefault("database.usage_audit_enabled", false)
+	viper.SetDefault("database.prompts_enabled", false)
 	
 	// Redis默认配置
 	viper.SetDefault("redis.host", "localhost")
@@ -139,6 +281,16 @@ func setDefaultValues() {
 	viper.SetDefault("services.tenant_service.timeout", "30s")
 	viper.SetDefault("services.memory_service.base_url", "http://localhost:8004")
 	viper.SetDefault("services.memory_service.timeout", "30s")
+	viper.SetDefault("services.http_client.max_idle_conns", 100)
+	viper.SetDefault("services.http_client.max_idle_conns_per_host", 20)
+	viper.SetDefault("services.http_client.idle_conn_timeout", "90s")
+	viper.SetDefault("services.http_client.tls_handshake_timeout", "10s")
+	viper.SetDefault("services.http_client.dial_timeout", "5s")
+	viper.SetDefault("services.http_client.keep_alive", "30s")
+	viper.SetDefault("services.http_client.provider_timeout", "60s")
+	viper.SetDefault("services.http_client.provider_first_byte_timeout", "30s")
+	viper.SetDefault("services.http_client.provider_stream_idle_timeout", "60s")
+	viper.SetDefault("services.http_client.provider_max_sse_line_bytes", 1024*1024)
 	
 	// 日志默认配置
 	viper.SetDefault("logging.level", "info")
@@ -147,14 +299,81 @@ func setDefaultValues() {
 	viper.SetDefault("logging.max_size", 100)
 	viper.SetDefault("logging.max_backups", 3)
 	viper.SetDefault("logging.max_age", 7)
+	viper.SetDefault("logging.prompt_sample_rate", 0)
+	viper.SetDefault("logging.prompt_trace_header", false)
+	viper.SetDefault("logging.prompt_trace_ttl", "24h")
+	viper.SetDefault("logging.prompt_trace_max_entries", 200)
+
+	// 链路追踪默认配置
+	viper.SetDefault("tracing.endpoint", "")
+	viper.SetDefault("tracing.service_name", "eino-service")
+	viper.SetDefault("tracing.sample_ratio", 1.0)
 	
 	// 凭证管理默认配置
 	viper.SetDefault("credential.cache_ttl", "5m")
 	viper.SetDefault("credential.health_check_interval", "2m")
 	viper.SetDefault("credential.max_concurrent_tests", 10)
+	viper.SetDefault("credential.circuit_failure_threshold", 5)
+	viper.SetDefault("credential.circuit_cooldown", "30s")
+	viper.SetDefault("credential.health_check_mode", "connection")
+	viper.SetDefault("credential.live_check_timeout", "15s")
+	viper.SetDefault("credential.max_concurrent_calls", 0)
+	viper.SetDefault("credential.concurrency_wait_timeout", "2s")
 	
 	// 工作流默认配置
 	viper.SetDefault("workflows.max_concurrent_executions", 100)
 	viper.SetDefault("workflows.execution_timeout", "5m")
 	viper.SetDefault("workflows.default_strategy", "first_available")
+	viper.SetDefault("workflows.idempotency_ttl", "24h")
+	viper.SetDefault("workflows.shutdown_grace_period", "20s")
+	viper.SetDefault("workflows.execution_record_ttl", "1h")
+	viper.SetDefault("workflows.max_provider_fallbacks", 1)
+	viper.SetDefault("workflows.max_stream_resumes", 1)
+	viper.SetDefault("workflows.max_message_bytes", 262144)
+	viper.SetDefault("workflows.max_message_tokens", 32000)
+	viper.SetDefault("workflows.max_image_bytes", 5242880)
+	viper.SetDefault("workflows.max_batch_size", 20)
+	viper.SetDefault("workflows.batch_concurrency", 4)
+	viper.SetDefault("workflows.stream_keepalive_interval", "15s")
+	viper.SetDefault("workflows.stream_resume_ttl", "2m")
+	viper.SetDefault("workflows.stream_resume_max_events", 1000)
+	viper.SetDefault("workflows.stream_resume_grace", "30s")
+	viper.SetDefault("workflows.response_cache_enabled", false)
+	viper.SetDefault("workflows.response_cache_ttl", "1h")
+	viper.SetDefault("workflows.parameter_policy", "reject")
+	viper.SetDefault("workflows.max_output_tokens", 32768)
+	
+	// 配额默认配置
+	viper.SetDefault("quota.monthly_token_limit", 0)
+	viper.SetDefault("quota.monthly_cost_limit", 0)
+	viper.SetDefault("quota.soft_limit_percent", 80)
+
+	// 模型默认参数配置
+	viper.SetDefault("models.default_model", "deepseek-chat")
+	viper.SetDefault("models.defaults", []map[string]interface{}{
+		{"model": "deepseek-chat", "provider": "deepseek", "temperature": 0.7, "max_tokens": 2048},
+		{"model": "deepseek-coder", "provider": "deepseek", "temperature": 0.0, "max_tokens": 4096},
+		{"model": "gemini-1.5-flash", "provider": "google", "temperature": 1.0, "max_tokens": 8192},
+		{"model": "gemini-1.5-pro", "provider": "google", "temperature": 1.0, "max_tokens": 8192},
+		{"model": "gemini-2.0-flash", "provider": "google", "temperature": 1.0, "max_tokens": 8192},
+	})
+
+	// 功能开关默认配置
+	viper.SetDefault("features.defaults", map[string]bool{
+		"optimized_rag": true,
+		"json_mode":     true,
+	})
+	viper.SetDefault("features.cache_ttl", "1m")
+
+	// 模型别名默认配置
+	viper.SetDefault("models.aliases", []map[string]interface{}{
+		{"alias": "gpt-4", "provider": "openai", "model": "gpt-4"},
+		{"alias": "gpt-4o", "provider": "openai", "model": "gpt-4o", "capabilities": []string{"vision", "tools", "json_mode"}},
+		{"alias": "gpt-3.5-turbo", "provider": "openai", "model": "gpt-3.5-turbo"},
+		{"alias": "deepseek-chat", "provider": "deepseek", "model": "deepseek-chat", "capabilities": []string{"tools", "json_mode"}},
+		{"alias": "deepseek-coder", "provider": "deepseek", "model": "deepseek-coder"},
+		{"alias": "gemini-1.5-flash", "provider": "google", "model": "gemini-1.5-flash", "capabilities": []string{"vision"}},
+		{"alias": "gemini-1.5-pro", "provider": "google", "model": "gemini-1.5-pro", "capabilities": []string{"vision"}},
+		{"alias": "gemini-2.0-flash", "provider": "google", "model": "gemini-2.0-flash", "capabilities": []string{"vision"}},
+	})
 }
\ No newline at end of file
@@ -0,0 +1,247 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// 可选取值的配置项
+var (
+	validStrategies        = []string{"first_available", "least_used", "round_robin", "weighted", "sticky_by_user"}
+	validHealthCheckModes  = []string{"connection", "live"}
+	validParameterPolicies = []string{"reject", "clamp"}
+	validLogLevels         = []string{"panic", "fatal", "error", "warn", "warning", "info", "debug", "trace"}
+)
+
+// configValidator 收集配置校验错误，每条错误都指明配置项
+type configValidator struct {
+	errs []error
+}
+
+// addf 记录一条配置项错误
+func (v *configValidator) addf(key, format string, args ...interface{}) {
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+}
+
+// required 配置项不能为空
+func (v *configValidator) required(key, value string) {
+	if value == "" {
+		v.addf(key, "不能为空")
+	}
+}
+
+// baseURL 配置项必须是 http/https 地址
+func (v *configValidator) baseURL(key, value string) {
+	if value == "" {
+		v.addf(key, "不能为空")
+		return
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		v.addf(key, "应为 http:// 或 https:// 开头的地址，当前为 %q", value)
+	}
+}
+
+// positive 配置项必须大于0
+func (v *configValidator) positive(key string, value int64) {
+	if value <= 0 {
+		v.addf(key, "必须大于0，当前为 %d", value)
+	}
+}
+
+// nonNegative 配置项不能为负数
+func (v *configValidator) nonNegative(key string, value int64) {
+	if value < 0 {
+		v.addf(key, "不能为负数，当前为 %d", value)
+	}
+}
+
+// positiveDuration 时长必须大于0
+func (v *configValidator) positiveDuration(key string, value time.Duration) {
+	if value <= 0 {
+		v.addf(key, "必须大于0，当前为 %s", value)
+	}
+}
+
+// nonNegativeDuration 时长不能为负数，0表示关闭或不限制
+func (v *configValidator) nonNegativeDuration(key string, value time.Duration) {
+	if value < 0 {
+		v.addf(key, "不能为负数，当前为 %s", value)
+	}
+}
+
+// oneOf 配置项必须是可选值之一，为空时使用默认行为
+func (v *configValidator) oneOf(key, value string, allowed []string) {
+	if value == "" {
+		return
+	}
+	for _, candidate := range allowed {
+		if value == candidate {
+			return
+		}
+	}
+	v.addf(key, "应为 %v 之一，当前为 %q", allowed, value)
+}
+
+// Validate 校验配置项的取值与组合，返回汇总了所有问题的错误
+func (c *Config) Validate() error {
+	v := &configValidator{}
+
+	// 服务器
+	if c.Server.Port <= 0 || c.Server.Port > 65535 {
+		v.addf("server.port", "应在 1-65535 之间，当前为 %d", c.Server.Port)
+	}
+	v.nonNegativeDuration("server.read_timeout", c.Server.ReadTimeout)
+	v.nonNegativeDuration("server.write_timeout", c.Server.WriteTimeout)
+	v.nonNegativeDuration("server.idle_timeout", c.Server.IdleTimeout)
+	v.nonNegative("server.max_header_bytes", int64(c.Server.MaxHeaderBytes))
+	v.nonNegative("server.max_body_bytes", c.Server.MaxBodyBytes)
+
+	// 数据库，仅在需要连接时校验
+	if c.Database.UsageAuditEnabled || c.Database.PromptsEnabled {
+		v.required("database.host", c.Database.Host)
+		v.required("database.database", c.Database.Database)
+		if c.Database.Port <= 0 || c.Database.Port > 65535 {
+			v.addf("database.port", "应在 1-65535 之间，当前为 %d", c.Database.Port)
+		}
+	}
+
+	// Redis
+	v.required("redis.host", c.Redis.Host)
+	if c.Redis.Port <= 0 || c.Redis.Port > 65535 {
+		v.addf("redis.port", "应在 1-65535 之间，当前为 %d", c.Redis.Port)
+	}
+	v.nonNegative("redis.db", int64(c.Redis.DB))
+
+	// 依赖服务
+	v.baseURL("services.tenant_service.base_url", c.Services.TenantService.BaseURL)
+	v.nonNegativeDuration("services.tenant_service.timeout", c.Services.TenantService.Timeout)
+	v.baseURL("services.memory_service.base_url", c.Services.MemoryService.BaseURL)
+	v.nonNegativeDuration("services.memory_service.timeout", c.Services.MemoryService.Timeout)
+	httpClient := c.Services.HTTPClient
+	v.nonNegative("services.http_client.max_idle_conns", int64(httpClient.MaxIdleConns))
+	v.nonNegative("services.http_client.max_idle_conns_per_host", int64(httpClient.MaxIdleConnsPerHost))
+	v.nonNegativeDuration("services.http_client.idle_conn_timeout", httpClient.IdleConnTimeout)
+	v.nonNegativeDuration("services.http_client.tls_handshake_timeout", httpClient.TLSHandshakeTimeout)
+	v.nonNegativeDuration("services.http_client.dial_timeout", httpClient.DialTimeout)
+	v.nonNegativeDuration("services.http_client.keep_alive", httpClient.KeepAlive)
+	v.nonNegativeDuration("services.http_client.provider_timeout", httpClient.ProviderTimeout)
+	v.nonNegativeDuration("services.http_client.provider_first_byte_timeout", httpClient.ProviderFirstByteTimeout)
+	v.nonNegativeDuration("services.http_client.provider_stream_idle_timeout", httpClient.ProviderStreamIdleTimeout)
+	v.nonNegative("services.http_client.provider_max_sse_line_bytes", int64(httpClient.ProviderMaxSSELineBytes))
+
+	// 日志
+	v.oneOf("logging.level", c.Logging.Level, validLogLevels)
+	v.nonNegative("logging.prompt_sample_rate", int64(c.Logging.PromptSampleRate))
+	v.nonNegativeDuration("logging.prompt_trace_ttl", c.Logging.PromptTraceTTL)
+	v.nonNegative("logging.prompt_trace_max_entries", int64(c.Logging.PromptTraceMaxEntries))
+
+	// 凭证管理
+	v.nonNegativeDuration("credential.cache_ttl", c.Credential.CacheTTL)
+	v.positiveDuration("credential.health_check_interval", c.Credential.HealthCheckInterval)
+	v.nonNegative("credential.max_concurrent_tests", int64(c.Credential.MaxConcurrentTests))
+	v.nonNegative("credential.circuit_failure_threshold", int64(c.Credential.CircuitFailureThreshold))
+	v.nonNegativeDuration("credential.circuit_cooldown", c.Credential.CircuitCooldown)
+	v.oneOf("credential.health_check_mode", c.Credential.HealthCheckMode, validHealthCheckModes)
+	v.nonNegativeDuration("credential.live_check_timeout", c.Credential.LiveCheckTimeout)
+	v.nonNegative("credential.max_concurrent_calls", int64(c.Credential.MaxConcurrentCalls))
+	v.nonNegativeDuration("credential.concurrency_wait_timeout", c.Credential.ConcurrencyWaitTimeout)
+
+	// 工作流
+	workflows := c.Workflows
+	v.positive("workflows.max_concurrent_executions", int64(workflows.MaxConcurrentExecutions))
+	v.positiveDuration("workflows.execution_timeout", workflows.ExecutionTimeout)
+	v.oneOf("workflows.default_strategy", workflows.DefaultStrategy, validStrategies)
+	v.nonNegativeDuration("workflows.idempotency_ttl", workflows.IdempotencyTTL)
+	v.nonNegativeDuration("workflows.shutdown_grace_period", workflows.ShutdownGracePeriod)
+	v.nonNegativeDuration("workflows.execution_record_ttl", workflows.ExecutionRecordTTL)
+	v.nonNegative("workflows.max_provider_fallbacks", int64(workflows.MaxProviderFallbacks))
+	v.nonNegative("workflows.max_stream_resumes", int64(workflows.MaxStreamResumes))
+	v.nonNegative("workflows.max_message_bytes", int64(workflows.MaxMessageBytes))
+	v.nonNegative("workflows.max_message_tokens", int64(workflows.MaxMessageTokens))
+	v.nonNegative("workflows.max_image_bytes", int64(workflows.MaxImageBytes))
+	v.positive("workflows.max_batch_size", int64(workflows.MaxBatchSize))
+	v.positive("workflows.batch_concurrency", int64(workflows.BatchConcurrency))
+	v.nonNegativeDuration("workflows.stream_keepalive_interval", workflows.StreamKeepaliveInterval)
+	v.nonNegativeDuration("workflows.stream_resume_ttl", workflows.StreamResumeTTL)
+	v.nonNegative("workflows.stream_resume_max_events", int64(workflows.StreamResumeMaxEvents))
+	v.nonNegativeDuration("workflows.stream_resume_grace", workflows.StreamResumeGrace)
+	if workflows.ResponseCacheEnabled {
+		v.positiveDuration("workflows.response_cache_ttl", workflows.ResponseCacheTTL)
+	}
+	v.oneOf("workflows.parameter_policy", workflows.ParameterPolicy, validParameterPolicies)
+	v.nonNegative("workflows.max_output_tokens", int64(workflows.MaxOutputTokens))
+	for model, limit := range workflows.ModelTokenLimits {
+		v.positive(fmt.Sprintf("workflows.model_token_limits.%s", model), int64(limit))
+	}
+	for model, limit := range workflows.ModelMaxOutputTokens {
+		v.positive(fmt.Sprintf("workflows.model_max_output_tokens.%s", model), int64(limit))
+	}
+
+	// 配额
+	v.nonNegative("quota.monthly_token_limit", c.Quota.MonthlyTokenLimit)
+	if c.Quota.SoftLimitPercent < 0 || c.Quota.SoftLimitPercent > 100 {
+		v.addf("quota.soft_limit_percent", "应在 0-100 之间，当前为 %d", c.Quota.SoftLimitPercent)
+	}
+	for tenantID, limit := range c.Quota.TenantLimits {
+		v.nonNegative(fmt.Sprintf("quota.tenant_limits.%s", tenantID), limit)
+	}
+	if c.Quota.MonthlyCostLimit < 0 {
+		v.addf("quota.monthly_cost_limit", "不能为负数，当前为 %g", c.Quota.MonthlyCostLimit)
+	}
+	for tenantID, limit := range c.Quota.TenantCostLimits {
+		if limit < 0 {
+			v.addf(fmt.Sprintf("quota.tenant_cost_limits.%s", tenantID), "不能为负数，当前为 %g", limit)
+		}
+	}
+
+	// 模型
+	aliases := make(map[string]bool, len(c.Models.Aliases))
+	for i, alias := range c.Models.Aliases {
+		key := fmt.Sprintf("models.aliases[%d]", i)
+		v.required(key+".alias", alias.Alias)
+		v.required(key+".provider", alias.Provider)
+		if alias.Alias != "" && aliases[alias.Alias] {
+			v.addf(key+".alias", "别名 %q 重复", alias.Alias)
+		}
+		aliases[alias.Alias] = true
+	}
+	defaultModelDeclared := false
+	for i, defaults := range c.Models.Defaults {
+		key := fmt.Sprintf("models.defaults[%d]", i)
+		v.required(key+".model", defaults.Model)
+		if defaults.Temperature != nil && (*defaults.Temperature < 0 || *defaults.Temperature > 2) {
+			v.addf(key+".temperature", "应在 0-2 之间，当前为 %g", *defaults.Temperature)
+		}
+		v.nonNegative(key+".max_tokens", int64(defaults.MaxTokens))
+		if defaults.Model == c.Models.DefaultModel {
+			defaultModelDeclared = true
+		}
+	}
+	if c.Models.DefaultModel != "" && len(c.Models.Defaults) > 0 && !defaultModelDeclared {
+		v.addf("models.default_model", "%q 未在 models.defaults 中声明", c.Models.DefaultModel)
+	}
+	for i, price := range c.Models.Pricing {
+		key := fmt.Sprintf("models.pricing[%d]", i)
+		v.required(key+".model", price.Model)
+		if price.PromptPer1K < 0 || price.CompletionPer1K < 0 {
+			v.addf(key, "单价不能为负数")
+		}
+	}
+
+	// 链路追踪与功能开关
+	if c.Tracing.Endpoint != "" {
+		v.baseURL("tracing.endpoint", c.Tracing.Endpoint)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		v.addf("tracing.sample_ratio", "应在 0-1 之间，当前为 %g", c.Tracing.SampleRatio)
+	}
+	v.nonNegativeDuration("features.cache_ttl", c.Features.CacheTTL)
+
+	if len(v.errs) > 0 {
+		return fmt.Errorf("配置校验失败: %w", errors.Join(v.errs...))
+	}
+	return nil
+}
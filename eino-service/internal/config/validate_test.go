@@ -0,0 +1,159 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loadTestConfig 加载仓库自带的配置文件，作为各用例修改的基础
+func loadTestConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg, err := LoadConfig("../../config.yaml")
+	if err != nil {
+		t.Fatalf("加载默认配置失败: %v", err)
+	}
+	return cfg
+}
+
+func TestValidateDefaultConfig(t *testing.T) {
+	if err := loadTestConfig(t).Validate(); err != nil {
+		t.Fatalf("默认配置校验失败: %v", err)
+	}
+}
+
+func TestValidateInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(cfg *Config)
+		wantKeys []string
+	}{
+		{
+			name:     "租户服务地址为空",
+			modify:   func(cfg *Config) { cfg.Services.TenantService.BaseURL = "" },
+			wantKeys: []string{"services.tenant_service.base_url"},
+		},
+		{
+			name:     "租户服务地址缺少协议",
+			modify:   func(cfg *Config) { cfg.Services.TenantService.BaseURL = "localhost:8002" },
+			wantKeys: []string{"services.tenant_service.base_url"},
+		},
+		{
+			name:     "最大并发执行数为0",
+			modify:   func(cfg *Config) { cfg.Workflows.MaxConcurrentExecutions = 0 },
+			wantKeys: []string{"workflows.max_concurrent_executions"},
+		},
+		{
+			name: "超时为负数",
+			modify: func(cfg *Config) {
+				cfg.Server.ReadTimeout = -time.Second
+				cfg.Services.HTTPClient.ProviderTimeout = -time.Second
+			},
+			wantKeys: []string{"server.read_timeout", "services.http_client.provider_timeout"},
+		},
+		{
+			name:     "端口越界",
+			modify:   func(cfg *Config) { cfg.Server.Port = 70000 },
+			wantKeys: []string{"server.port"},
+		},
+		{
+			name: "取值不在可选范围内",
+			modify: func(cfg *Config) {
+				cfg.Workflows.DefaultStrategy = "random"
+				cfg.Credential.HealthCheckMode = "ping"
+				cfg.Workflows.ParameterPolicy = "ignore"
+			},
+			wantKeys: []string{"workflows.default_strategy", "credential.health_check_mode", "workflows.parameter_policy"},
+		},
+		{
+			name: "重复的模型别名",
+			modify: func(cfg *Config) {
+				cfg.Models.Aliases = append(cfg.Models.Aliases, cfg.Models.Aliases[0])
+			},
+			wantKeys: []string{"models.aliases"},
+		},
+		{
+			name:     "采样比例越界",
+			modify:   func(cfg *Config) { cfg.Tracing.SampleRatio = 1.5 },
+			wantKeys: []string{"tracing.sample_ratio"},
+		},
+		{
+			name: "开启响应缓存但TTL为0",
+			modify: func(cfg *Config) {
+				cfg.Workflows.ResponseCacheEnabled = true
+				cfg.Workflows.ResponseCacheTTL = 0
+			},
+			wantKeys: []string{"workflows.response_cache_ttl"},
+		},
+		{
+			name: "费用配额为负数",
+			modify: func(cfg *Config) {
+				cfg.Quota.MonthlyCostLimit = -1
+				cfg.Quota.TenantCostLimits = map[string]float64{"tenant-1": -0.5}
+			},
+			wantKeys: []string{"quota.monthly_cost_limit", "quota.tenant_cost_limits.tenant-1"},
+		},
+		{
+			name: "多个问题汇总返回",
+			modify: func(cfg *Config) {
+				cfg.Services.TenantService.BaseURL = ""
+				cfg.Workflows.MaxConcurrentExecutions = 0
+				cfg.Quota.SoftLimitPercent = 120
+				cfg.Credential.MaxConcurrentTests = -1
+			},
+			wantKeys: []string{
+				"services.tenant_service.base_url",
+				"workflows.max_concurrent_executions",
+				"quota.soft_limit_percent",
+				"credential.max_concurrent_tests",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := loadTestConfig(t)
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if err == nil {
+				t.Fatal("Validate 期望返回错误")
+			}
+			message := err.Error()
+			for _, key := range tt.wantKeys {
+				if !strings.Contains(message, key) {
+					t.Fatalf("错误信息 = %q，期望指明配置项 %s", message, key)
+				}
+			}
+			if lines := strings.Count(message, "\n") + 1; lines != len(tt.wantKeys) {
+				t.Fatalf("错误信息包含 %d 条问题，期望 %d 条: %q", lines, len(tt.wantKeys), message)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFailsFast(t *testing.T) {
+	original, err := os.ReadFile("../../config.yaml")
+	if err != nil {
+		t.Fatalf("读取默认配置失败: %v", err)
+	}
+	invalid := strings.Replace(string(original), "max_concurrent_executions: 100", "max_concurrent_executions: 0", 1)
+	if invalid == string(original) {
+		t.Fatal("默认配置中未找到 max_concurrent_executions")
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(invalid), 0o600); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err == nil || cfg != nil {
+		t.Fatalf("LoadConfig = %v, %v，期望校验失败", cfg, err)
+	}
+	if !strings.Contains(err.Error(), "workflows.max_concurrent_executions") {
+		t.Fatalf("错误信息 = %q，期望指明 workflows.max_concurrent_executions", err.Error())
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"lyss-ai-platform/eino-service/internal/config"
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+// newEchoProvider 启动回显最后一条消息的供应商替身
+// 消息包含“失败”时返回400，消息越靠前响应越慢，使并发执行的完成顺序与请求顺序相反
+func newEchoProvider(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		content := body.Messages[len(body.Messages)-1].Content
+
+		var delay int
+		fmt.Sscanf(content, "第%d条", &delay)
+		time.Sleep(time.Duration(10-delay) * 5 * time.Millisecond)
+
+		if strings.Contains(content, "失败") {
+			http.Error(w, `{"error":{"message":"invalid request"}}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":      "provider-1",
+			"object":  "chat.completion",
+			"model":   body.Model,
+			"choices": []map[string]interface{}{{"index": 0, "message": map[string]string{"role": "assistant", "content": "回复:" + content}, "finish_reason": "stop"}},
+			"usage":   map[string]int{"prompt_tokens": 5, "completion_tokens": 2, "total_tokens": 7},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBatchChatMixedResultsKeepInputOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []string
+	}{
+		{name: "全部成功", messages: []string{"第1条", "第2条", "第3条"}},
+		{name: "部分失败", messages: []string{"第1条", "第2条失败", "第3条", "第4条失败", "第5条"}},
+		{name: "全部失败", messages: []string{"第1条失败", "第2条失败"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			provider := newEchoProvider(t)
+			server.tenantService.SetCredentials(testTenantID, testutil.Credential("deepseek", provider.URL))
+
+			requests := make([]map[string]interface{}, len(tt.messages))
+			for i, message := range tt.messages {
+				requests[i] = map[string]interface{}{"message": message, "model": "deepseek-chat"}
+			}
+			recorder := server.post("/api/v1/chat/batch", map[string]interface{}{"requests": requests})
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("部分失败不应使整批失败，status = %d，body = %s", recorder.Code, recorder.Body.String())
+			}
+
+			var response struct {
+				Data models.BatchChatResponse `json:"data"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("响应不是JSON: %v", err)
+			}
+			batch := response.Data
+			if len(batch.Results) != len(tt.messages) {
+				t.Fatalf("结果数 = %d，期望 %d", len(batch.Results), len(tt.messages))
+			}
+
+			succeeded := 0
+			for i, result := range batch.Results {
+				if result.Index != i {
+					t.Fatalf("第 %d 个结果的 index = %d，结果应按请求顺序返回", i, result.Index)
+				}
+				wantSuccess := !strings.Contains(tt.messages[i], "失败")
+				if result.Success != wantSuccess {
+					t.Fatalf("第 %d 个结果 success = %v，期望 %v，error = %s", i, result.Success, wantSuccess, result.Error)
+				}
+				if !wantSuccess {
+					if result.Error == "" || result.Response != nil {
+						t.Fatalf("失败的结果应只包含错误信息，实际 %+v", result)
+					}
+					continue
+				}
+				succeeded++
+				if result.Response == nil || result.Response.Content != "回复:"+tt.messages[i] {
+					t.Fatalf("第 %d 个结果的回复 = %+v，期望回显 %q", i, result.Response, tt.messages[i])
+				}
+			}
+
+			if batch.Succeeded != succeeded || batch.Failed != len(tt.messages)-succeeded {
+				t.Fatalf("succeeded/failed = %d/%d，期望 %d/%d", batch.Succeeded, batch.Failed, succeeded, len(tt.messages)-succeeded)
+			}
+			wantUsage := models.TokenUsage{PromptTokens: 5 * succeeded, CompletionTokens: 2 * succeeded, TotalTokens: 7 * succeeded}
+			if batch.Usage != wantUsage {
+				t.Fatalf("合计用量 = %+v，期望只统计成功的请求 %+v", batch.Usage, wantUsage)
+			}
+			// config.yaml 中 deepseek-chat 的单价：每条 5 个提示令牌 × 0.00027/1K + 2 个补全令牌 × 0.0011/1K
+			if wantCost := 0.00000355 * float64(succeeded); math.Abs(batch.Cost-wantCost) > 1e-12 {
+				t.Fatalf("合计费用 = %g，期望只统计成功的请求 %g", batch.Cost, wantCost)
+			}
+		})
+	}
+}
+
+func TestBatchChatRejectsInvalidBatches(t *testing.T) {
+	server := newTestServer(t, func(cfg *config.Config) {
+		cfg.Workflows.MaxBatchSize = 2
+	})
+
+	tests := []struct {
+		name     string
+		messages []string
+	}{
+		{name: "空批量"},
+		{name: "超出条数上限", messages: []string{"第1条", "第2条", "第3条"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := make([]map[string]interface{}, len(tt.messages))
+			for i, message := range tt.messages {
+				requests[i] = map[string]interface{}{"message": message}
+			}
+			recorder := server.post("/api/v1/chat/batch", map[string]interface{}{"requests": requests})
+			if recorder.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d，期望 %d，body = %s", recorder.Code, http.StatusBadRequest, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -10,18 +10,22 @@ import (
 
 	"lyss-ai-platform/eino-service/internal/models"
 	"lyss-ai-platform/eino-service/pkg/credential"
+	"lyss-ai-platform/eino-service/pkg/modelalias"
+	"lyss-ai-platform/eino-service/pkg/tokenizer"
 )
 
 // ChatHandler 聊天处理器
 type ChatHandler struct {
 	credentialManager *credential.Manager
+	modelAliases      *modelalias.Registry
 	logger            *logrus.Logger
 }
 
 // NewChatHandler 创建新的聊天处理器
-func NewChatHandler(credentialManager *credential.Manager, logger *logrus.Logger) *ChatHandler {
+func NewChatHandler(credentialManager *credential.Manager, modelAliases *modelalias.Registry, logger *logrus.Logger) *ChatHandler {
 	return &ChatHandler{
 		credentialManager: credentialManager,
+		modelAliases:      modelAliases,
 		logger:            logger,
 	}
 }
@@ -59,8 +63,18 @@ func (h *ChatHandler) SimpleChat(c *gin.Context) {
 	// 模拟处理（实际应该调用EINO工作流）
 	startTime := time.Now()
 	
+	// 解析模型别名
+	resolved, err := h.modelAliases.Resolve(request.Model)
+	if err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "1001", "未知的模型", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	provider := resolved.Provider
+	request.Model = resolved.Model
+
 	// 获取凭证
-	provider := h.getProviderFromModel(request.Model)
 	credential, err := h.credentialManager.GetBestCredentialForModel(tenantID, provider, request.Model)
 	if err != nil {
 		h.logger.WithError(err).WithFields(logrus.Fields{
@@ -81,16 +95,19 @@ func (h *ChatHandler) SimpleChat(c *gin.Context) {
 	h.credentialManager.RecordUsage(credential.ID.String())
 	
 	// 模拟AI响应
+	content := h.generateMockResponse(request.Message, request.Model)
+	promptTokens := tokenizer.CountTokens(request.Model, request.Message)
+	completionTokens := tokenizer.CountTokens(request.Model, content)
 	response := &models.ChatResponse{
 		ID:              uuid.New().String(),
-		Content:         h.generateMockResponse(request.Message, request.Model),
+		Content:         content,
 		Model:           request.Model,
 		WorkflowType:    "simple_chat",
 		ExecutionTimeMs: int(time.Since(startTime).Milliseconds()),
 		Usage: models.TokenUsage{
-			PromptTokens:     len(request.Message) / 4,
-			CompletionTokens: 150,
-			TotalTokens:      len(request.Message)/4 + 150,
+			PromptTokens:     promptTokens,
+			CompletionTokens: completionTokens,
+			TotalTokens:      promptTokens + completionTokens,
 		},
 		Metadata: map[string]interface{}{
 			"credential_id": credential.ID.String(),
@@ -213,16 +230,19 @@ func (h *ChatHandler) RAGChat(c *gin.Context) {
 	// 模拟RAG处理
 	startTime := time.Now()
 	
+	content := "这是一个RAG增强的回答示例。基于检索到的知识，我可以为您提供更准确和丰富的答案。"
+	promptTokens := tokenizer.CountTokens(request.Model, request.Message)
+	completionTokens := tokenizer.CountTokens(request.Model, content)
 	response := &models.ChatResponse{
 		ID:              uuid.New().String(),
-		Content:         "这是一个RAG增强的回答示例。基于检索到的知识，我可以为您提供更准确和丰富的答案。",
+		Content:         content,
 		Model:           request.Model,
 		WorkflowType:    "optimized_rag",
 		ExecutionTimeMs: int(time.Since(startTime).Milliseconds()),
 		Usage: models.TokenUsage{
-			PromptTokens:     len(request.Message) / 4,
-			CompletionTokens: 200,
-			TotalTokens:      len(request.Message)/4 + 200,
+			PromptTokens:     promptTokens,
+			CompletionTokens: completionTokens,
+			TotalTokens:      promptTokens + completionTokens,
 		},
 		Metadata: map[string]interface{}{
 			"workflow_steps": []string{"prompt_optimizer", "memory_retrieval", "core_responder", "web_search", "final_synthesizer"},
@@ -278,20 +298,6 @@ func (h *ChatHandler) GetExecution(c *gin.Context) {
 	h.respondWithSuccess(c, execution, "执行状态查询成功", requestID)
 }
 
-// getProviderFromModel 根据模型名称获取供应商
-func (h *ChatHandler) getProviderFromModel(model string) string {
-	switch {
-	case model == "gpt-4" || model == "gpt-3.5-turbo":
-		return "openai"
-	case model == "claude-3-opus" || model == "claude-3-sonnet":
-		return "anthropic"
-	case model == "deepseek-chat" || model == "deepseek-coder":
-		return "deepseek"
-	default:
-		return "openai" // 默认使用OpenAI
-	}
-}
-
 // generateMockResponse 生成模拟响应
 func (h *ChatHandler) generateMockResponse(message, model string) string {
 	return "感谢您的消息：\"" + message + "\"。我是由 " + model + " 模型驱动的AI助手，通过Lyss EINO服务为您提供服务。这是一个模拟响应，用于演示凭证管理和工作流编排功能。"
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/pkg/credential"
+)
+
+// InternalTokenHeader 内部接口访问令牌使用的HTTP头
+const InternalTokenHeader = "X-Internal-Token"
+
+// CredentialHandler 凭证缓存管理处理器，仅供内部服务调用
+type CredentialHandler struct {
+	credentialManager *credential.Manager
+	internalToken     string
+	logger            *logrus.Logger
+}
+
+// NewCredentialHandler 创建凭证缓存管理处理器，internalToken 为空时内部接口一律拒绝
+func NewCredentialHandler(credentialManager *credential.Manager, internalToken string, logger *logrus.Logger) *CredentialHandler {
+	return &CredentialHandler{
+		credentialManager: credentialManager,
+		internalToken:     internalToken,
+		logger:            logger,
+	}
+}
+
+// RefreshCredentials 重新加载所有活跃租户的凭证
+func (h *CredentialHandler) RefreshCredentials(c *gin.Context) {
+	count, err := h.credentialManager.RefreshCredentials()
+	if err != nil {
+		h.respondWithError(c, http.StatusBadGateway, "重新加载凭证失败", err)
+		return
+	}
+
+	h.respondWithSuccess(c, map[string]interface{}{
+		"refreshed": count,
+	})
+}
+
+// RefreshTenantCredentials 重新加载指定租户的凭证
+func (h *CredentialHandler) RefreshTenantCredentials(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+
+	count, err := h.credentialManager.RefreshTenantCredentials(tenantID)
+	if err != nil {
+		h.respondWithError(c, http.StatusBadGateway, "重新加载租户凭证失败", err)
+		return
+	}
+
+	h.respondWithSuccess(c, map[string]interface{}{
+		"tenant_id": tenantID,
+		"refreshed": count,
+	})
+}
+
+// requireInternalToken 校验内部接口访问令牌
+func (h *CredentialHandler) requireInternalToken() gin.HandlerFunc {
+	return requireInternalToken(h.internalToken, h.logger)
+}
+
+// requireInternalToken 校验内部接口访问令牌，internalToken 为空时一律拒绝
+func requireInternalToken(internalToken string, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if internalToken == "" {
+			abortInternalRequest(c, http.StatusServiceUnavailable, "未配置内部接口访问令牌")
+			return
+		}
+
+		token := c.GetHeader(InternalTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(internalToken)) != 1 {
+			logger.WithFields(logrus.Fields{
+				"request_id": c.GetHeader("X-Request-ID"),
+				"path":       c.Request.URL.Path,
+				"client_ip":  c.ClientIP(),
+				"operation":  "internal_auth_failed",
+			}).Warn("内部接口访问令牌无效")
+			abortInternalRequest(c, http.StatusUnauthorized, "内部接口访问令牌无效")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// abortInternalRequest 拒绝内部接口请求
+func abortInternalRequest(c *gin.Context, statusCode int, message string) {
+	c.AbortWithStatusJSON(statusCode, models.ApiResponse[interface{}]{
+		Success:   false,
+		Data:      nil,
+		Message:   message,
+		RequestID: c.GetHeader("X-Request-ID"),
+		Timestamp: fmt.Sprintf("%d", c.GetInt64("timestamp")),
+	})
+}
+
+// respondWithSuccess 返回成功响应
+func (h *CredentialHandler) respondWithSuccess(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusOK, models.ApiResponse[interface{}]{
+		Success:   true,
+		Data:      data,
+		Message:   "请求成功",
+		RequestID: c.GetHeader("X-Request-ID"),
+		Timestamp: fmt.Sprintf("%d", c.GetInt64("timestamp")),
+	})
+}
+
+// respondWithError 返回错误响应
+func (h *CredentialHandler) respondWithError(c *gin.Context, statusCode int, message string, err error) {
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"request_id": c.GetHeader("X-Request-ID"),
+			"status":     statusCode,
+			"message":    message,
+			"error":      err.Error(),
+			"path":       c.Request.URL.Path,
+			"method":     c.Request.Method,
+		}).Error("请求处理失败")
+	}
+
+	c.JSON(statusCode, models.ApiResponse[interface{}]{
+		Success:   false,
+		Data:      nil,
+		Message:   message,
+		RequestID: c.GetHeader("X-Request-ID"),
+		Timestamp: fmt.Sprintf("%d", c.GetInt64("timestamp")),
+	})
+}
+
+// RegisterRoutes 注册凭证缓存管理路由
+func (h *CredentialHandler) RegisterRoutes(r *gin.Engine) {
+	internal := r.Group("/internal/credentials", h.requireInternalToken())
+	{
+		internal.POST("/refresh", h.RefreshCredentials)
+		internal.POST("/refresh/:tenant_id", h.RefreshTenantCredentials)
+	}
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"lyss-ai-platform/eino-service/internal/config"
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/pkg/credential"
+)
+
+const testInternalToken = "internal-secret"
+
+// newCredentialRouter 创建挂载凭证缓存管理路由的测试路由
+func newCredentialRouter(t *testing.T, internalToken string) (*gin.Engine, *testutil.TenantService, *credential.Manager) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg, err := config.LoadConfig("../../config.yaml")
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	tenantService := testutil.NewTenantService(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+
+	manager := credential.NewManager(tenantService.Client(), redisClient, &cfg.Credential, cfg.Workflows.DefaultStrategy, testutil.Logger())
+	t.Cleanup(manager.Stop)
+
+	router := gin.New()
+	NewCredentialHandler(manager, internalToken, testutil.Logger()).RegisterRoutes(router)
+	return router, tenantService, manager
+}
+
+// refresh 发送凭证刷新请求，token 为空时不携带内部令牌
+func refresh(router *gin.Engine, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	if token != "" {
+		req.Header.Set(InternalTokenHeader, token)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// refreshedCount 解析刷新接口返回的凭证数
+func refreshedCount(t *testing.T, recorder *httptest.ResponseRecorder) int {
+	t.Helper()
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d，body = %s", recorder.Code, recorder.Body.String())
+	}
+	var response models.ApiResponse[map[string]interface{}]
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	count, _ := response.Data["refreshed"].(float64)
+	return int(count)
+}
+
+func TestRefreshCredentialsPicksUpNewCredential(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "全部租户", path: "/internal/credentials/refresh"},
+		{name: "单个租户", path: "/internal/credentials/refresh/" + testTenantID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, tenantService, manager := newCredentialRouter(t, testInternalToken)
+			first := testutil.Credential("deepseek", "")
+			tenantService.SetCredentials(testTenantID, first)
+
+			if got := refreshedCount(t, refresh(router, tt.path, testInternalToken)); got != 1 {
+				t.Fatalf("首次刷新凭证数 = %d，期望 1", got)
+			}
+
+			// 租户服务新增凭证后刷新即可生效
+			tenantService.SetCredentials(testTenantID, first, testutil.Credential("openai", ""))
+			if got := refreshedCount(t, refresh(router, tt.path, testInternalToken)); got != 2 {
+				t.Fatalf("新增后刷新凭证数 = %d，期望 2", got)
+			}
+			if got := manager.GetCredentialStats()["cache_size"]; got != 2 {
+				t.Fatalf("缓存凭证数 = %v，期望 2", got)
+			}
+		})
+	}
+}
+
+func TestRefreshCredentialsRequiresInternalToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		internalToken string
+		token         string
+		wantStatus    int
+	}{
+		{name: "缺少令牌", internalToken: testInternalToken, wantStatus: http.StatusUnauthorized},
+		{name: "令牌错误", internalToken: testInternalToken, token: "wrong", wantStatus: http.StatusUnauthorized},
+		{name: "未配置令牌", token: testInternalToken, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, tenantService, _ := newCredentialRouter(t, tt.internalToken)
+			tenantService.SetCredentials(testTenantID, testutil.Credential("deepseek", ""))
+
+			recorder := refresh(router, "/internal/credentials/refresh", tt.token)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d，期望 %d", recorder.Code, tt.wantStatus)
+			}
+			if got := tenantService.Requests("/suppliers/" + testTenantID + "/available"); got != 0 {
+				t.Fatalf("未授权请求访问了租户服务 %d 次", got)
+			}
+		})
+	}
+}
+
+func TestRefreshCredentialsTenantServiceUnavailable(t *testing.T) {
+	router, tenantService, _ := newCredentialRouter(t, testInternalToken)
+	tenantService.Server.Close()
+
+	recorder := refresh(router, "/internal/credentials/refresh", testInternalToken)
+	if recorder.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d，期望 %d", recorder.Code, http.StatusBadGateway)
+	}
+}
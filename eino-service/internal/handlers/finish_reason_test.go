@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+// finishReasonOf 从各接口的响应中取出结束原因
+func finishReasonOf(t *testing.T, path string, stream bool, body string) interface{} {
+	t.Helper()
+	switch {
+	case path == "/v1/chat/completions" && stream:
+		// 最后一个数据帧为 [DONE]，结束原因在其前一个分块中
+		frames := sseData(t, body)
+		if len(frames) < 2 {
+			t.Fatalf("事件流帧数不足，body = %s", body)
+		}
+		var chunk map[string]interface{}
+		if err := json.Unmarshal([]byte(frames[len(frames)-2]), &chunk); err != nil {
+			t.Fatalf("最后一个分块不是JSON: %v", err)
+		}
+		choices, _ := chunk["choices"].([]interface{})
+		if len(choices) != 1 {
+			t.Fatalf("最后一个分块 choices = %v", chunk["choices"])
+		}
+		return choices[0].(map[string]interface{})["finish_reason"]
+	case path == "/v1/chat/completions":
+		var completion map[string]interface{}
+		if err := json.Unmarshal([]byte(body), &completion); err != nil {
+			t.Fatalf("响应不是JSON: %v", err)
+		}
+		choices, _ := completion["choices"].([]interface{})
+		if len(choices) != 1 {
+			t.Fatalf("choices = %v", completion["choices"])
+		}
+		return choices[0].(map[string]interface{})["finish_reason"]
+	case stream:
+		for _, event := range sseEvents(t, body) {
+			if event.Event != "end" {
+				continue
+			}
+			var data map[string]interface{}
+			if err := json.Unmarshal([]byte(event.Data), &data); err != nil {
+				t.Fatalf("end 事件不是JSON: %v", err)
+			}
+			return data["finish_reason"]
+		}
+		t.Fatalf("事件流缺少 end 事件，body = %s", body)
+	default:
+		var response struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(body), &response); err != nil {
+			t.Fatalf("响应不是JSON: %v", err)
+		}
+		return response.Data["finish_reason"]
+	}
+	return nil
+}
+
+func TestLengthFinishReasonIsSurfaced(t *testing.T) {
+	openAIRequest := func(stream bool) map[string]interface{} {
+		return map[string]interface{}{
+			"model":      "deepseek-chat",
+			"stream":     stream,
+			"max_tokens": 3,
+			"messages":   []map[string]string{{"role": "user", "content": "写一首长诗"}},
+		}
+	}
+	visionRequest := visionChatRequest("写一首长诗")
+	visionRequest["max_tokens"] = 3
+
+	tests := []struct {
+		name         string
+		provider     string
+		path         string
+		stream       bool
+		body         map[string]interface{}
+		finishReason string
+		want         string
+	}{
+		{
+			name:         "工作流接口",
+			provider:     "deepseek",
+			path:         "/api/v1/chat",
+			body:         map[string]interface{}{"message": "写一首长诗", "max_tokens": 3},
+			finishReason: "length",
+			want:         "length",
+		},
+		{
+			name:         "工作流接口流式结束事件",
+			provider:     "openai",
+			path:         "/api/v1/chat",
+			stream:       true,
+			body:         visionRequest,
+			finishReason: "length",
+			want:         "length",
+		},
+		{
+			name:         "OpenAI兼容接口",
+			provider:     "deepseek",
+			path:         "/v1/chat/completions",
+			body:         openAIRequest(false),
+			finishReason: "length",
+			want:         "length",
+		},
+		{
+			name:         "OpenAI兼容接口流式",
+			provider:     "deepseek",
+			path:         "/v1/chat/completions",
+			stream:       true,
+			body:         openAIRequest(true),
+			finishReason: "length",
+			want:         "length",
+		},
+		{
+			name:     "正常结束",
+			provider: "deepseek",
+			path:     "/v1/chat/completions",
+			body:     openAIRequest(false),
+			want:     "stop",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			provider := newProviderStub(t, "床前", "明月")
+			provider.FinishReason = tt.finishReason
+			server.tenantService.SetCredentials(testTenantID, testutil.Credential(tt.provider, provider.Server.URL))
+
+			recorder := server.post(tt.path, tt.body)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d，body = %s", recorder.Code, recorder.Body.String())
+			}
+
+			if got := finishReasonOf(t, tt.path, tt.stream, recorder.Body.String()); got != tt.want {
+				t.Fatalf("finish_reason = %v，期望 %s，body = %s", got, tt.want, recorder.Body.String())
+			}
+
+			requests := provider.Requests()
+			if len(requests) != 1 {
+				t.Fatalf("供应商收到 %d 个请求，期望 1 个", len(requests))
+			}
+			if maxTokens, _ := requests[0]["max_tokens"].(float64); maxTokens != 3 {
+				t.Fatalf("供应商收到的 max_tokens = %v，期望 3", requests[0]["max_tokens"])
+			}
+		})
+	}
+}
@@ -134,10 +134,13 @@ func (h *HealthHandler) ReadinessCheck(c *gin.Context) {
 // LivenessCheck 存活检查
 func (h *HealthHandler) LivenessCheck(c *gin.Context) {
 	// 简单的存活检查，只要服务能响应就认为是存活的
+	uptime := health.Uptime()
 	response := map[string]interface{}{
-		"alive":     true,
-		"timestamp": time.Now().Format(time.RFC3339),
-		"uptime":    "unknown", // 这里应该是服务启动时间
+		"alive":          true,
+		"timestamp":      time.Now().Format(time.RFC3339),
+		"started_at":     health.StartTime().Format(time.RFC3339),
+		"uptime":         uptime.Truncate(time.Second).String(),
+		"uptime_seconds": int64(uptime.Seconds()),
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -174,6 +177,7 @@ func (h *HealthHandler) DetailedHealth(c *gin.Context) {
 				"response_time": result.ResponseTimes["tenant_service"],
 			},
 		},
+		"providers": result.Providers,
 		"credential_manager": map[string]interface{}{
 			"total_credentials":  credentialStats["total_credentials"],
 			"healthy_credentials": credentialStats["healthy_credentials"],
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/pkg/health"
+)
+
+// uptimeFields 存活检查与指标接口中的运行时长字段
+type uptimeFields struct {
+	StartedAt     string `json:"started_at"`
+	Uptime        string `json:"uptime"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+}
+
+func TestUptimeReporting(t *testing.T) {
+	previous := health.StartTime()
+	t.Cleanup(func() { health.MarkStarted(previous) })
+
+	server := newTestServer(t, nil)
+	router := gin.New()
+	NewHealthHandler(nil, nil, nil, testutil.Logger()).RegisterRoutes(router)
+	server.handler.RegisterRoutes(router)
+
+	tests := []struct {
+		name  string
+		path  string
+		parse func(body []byte) (uptimeFields, error)
+	}{
+		{
+			name: "存活检查",
+			path: "/health/liveness",
+			parse: func(body []byte) (uptimeFields, error) {
+				var fields uptimeFields
+				err := json.Unmarshal(body, &fields)
+				return fields, err
+			},
+		},
+		{
+			name: "工作流指标",
+			path: "/api/v1/metrics",
+			parse: func(body []byte) (uptimeFields, error) {
+				var response models.ApiResponse[uptimeFields]
+				err := json.Unmarshal(body, &response)
+				return response.Data, err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			get := func() uptimeFields {
+				t.Helper()
+				recorder := httptest.NewRecorder()
+				router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+				if recorder.Code != http.StatusOK {
+					t.Fatalf("status = %d，body = %s", recorder.Code, recorder.Body.String())
+				}
+				fields, err := tt.parse(recorder.Body.Bytes())
+				if err != nil {
+					t.Fatalf("解析响应失败: %v", err)
+				}
+				return fields
+			}
+
+			started := time.Now().Add(-90 * time.Second)
+			health.MarkStarted(started)
+			first := get()
+			if first.StartedAt != started.Format(time.RFC3339) {
+				t.Fatalf("started_at = %q，期望 %q", first.StartedAt, started.Format(time.RFC3339))
+			}
+			if first.UptimeSeconds < 90 || first.UptimeSeconds > 91 {
+				t.Fatalf("uptime_seconds = %d，期望约 90", first.UptimeSeconds)
+			}
+
+			// 启动时间提前一分钟，模拟运行时长增长
+			health.MarkStarted(started.Add(-time.Minute))
+			if second := get(); second.UptimeSeconds <= first.UptimeSeconds {
+				t.Fatalf("uptime_seconds %d -> %d，期望递增", first.UptimeSeconds, second.UptimeSeconds)
+			}
+		})
+	}
+
+	t.Run("存活检查的可读运行时长", func(t *testing.T) {
+		health.MarkStarted(time.Now().Add(-90 * time.Second))
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health/liveness", nil))
+		var fields uptimeFields
+		json.Unmarshal(recorder.Body.Bytes(), &fields)
+		if fields.Uptime != "1m30s" && fields.Uptime != "1m31s" {
+			t.Fatalf("uptime = %q，期望 1m30s", fields.Uptime)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/internal/workflows"
+)
+
+// prometheusSum 累加 Prometheus 文本中指定指标的值，labels 中的每一项都需出现在该行的标签中
+func prometheusSum(t *testing.T, body, name string, labels ...string) float64 {
+	t.Helper()
+	var sum float64
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, name+"{") && !strings.HasPrefix(line, name+" ") {
+			continue
+		}
+		matched := true
+		for _, label := range labels {
+			if !strings.Contains(line, label) {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		value, err := strconv.ParseFloat(line[strings.LastIndex(line, " ")+1:], 64)
+		if err != nil {
+			t.Fatalf("无法解析指标行 %q: %v", line, err)
+		}
+		sum += value
+	}
+	return sum
+}
+
+func TestMetricsEndpointsAgreeAfterExecute(t *testing.T) {
+	server := newTestServer(t, nil)
+	provider := newProviderStub(t, "你好")
+	server.tenantService.SetCredentials(testTenantID, testutil.Credential("deepseek", provider.Server.URL))
+
+	const executions = 3
+	for i := 0; i < executions; i++ {
+		recorder := server.post("/api/v1/chat", map[string]interface{}{
+			"message": "在吗",
+			"model":   "deepseek-chat",
+		})
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("status = %d，body = %s", recorder.Code, recorder.Body.String())
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("/metrics status = %d", recorder.Code)
+	}
+	text := recorder.Body.String()
+
+	recorder = httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/metrics", nil))
+	var response models.ApiResponse[workflows.WorkflowMetrics]
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("解析 /api/v1/metrics 失败: %v", err)
+	}
+	snapshot := response.Data
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{name: "JSON 执行总数", got: float64(snapshot.TotalExecutions), want: executions},
+		{name: "JSON 成功次数", got: float64(snapshot.SuccessfulExecutions), want: executions},
+		{name: "JSON Token总数", got: float64(snapshot.TotalTokensUsed), want: executions * 10},
+		{name: "executions_total 与 JSON 执行总数一致", got: prometheusSum(t, text, "eino_workflow_executions_total"), want: float64(snapshot.TotalExecutions)},
+		{name: "成功的 executions_total 与 JSON 成功次数一致", got: prometheusSum(t, text, "eino_workflow_executions_total", `status="success"`), want: float64(snapshot.SuccessfulExecutions)},
+		{name: "tokens_total 与 JSON Token总数一致", got: prometheusSum(t, text, "eino_workflow_tokens_total"), want: float64(snapshot.TotalTokensUsed)},
+		{name: "耗时直方图记录每次执行", got: prometheusSum(t, text, "eino_workflow_execution_duration_seconds_count"), want: executions},
+		{name: "provider 标签取实际供应商", got: prometheusSum(t, text, "eino_workflow_executions_total", `provider="deepseek"`), want: executions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Fatalf("%s = %v，期望 %v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+}
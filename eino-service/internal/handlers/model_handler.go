@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/internal/client"
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/pkg/credential"
+)
+
+// 工作流未指定供应商时使用的默认供应商
+const defaultProvider = "openai"
+
+// ModelHandler 模型列表处理器
+type ModelHandler struct {
+	credentialManager *credential.Manager
+	logger            *logrus.Logger
+}
+
+// NewModelHandler 创建模型列表处理器
+func NewModelHandler(credentialManager *credential.Manager, logger *logrus.Logger) *ModelHandler {
+	return &ModelHandler{
+		credentialManager: credentialManager,
+		logger:            logger,
+	}
+}
+
+// ProviderModels 供应商及其可用模型
+type ProviderModels struct {
+	Provider        string   `json:"provider"`
+	Default         bool     `json:"default"`
+	Models          []string `json:"models"`
+	CredentialCount int      `json:"credential_count"`
+}
+
+// ListModels 列出租户可用的供应商和模型
+// 携带 X-API-Version: 2 时按供应商分页返回 models.Page，否则返回 {"providers": [...]}
+func (h *ModelHandler) ListModels(c *gin.Context) {
+	tenantID := c.GetHeader("X-Tenant-ID")
+	if tenantID == "" {
+		h.respondWithError(c, http.StatusBadRequest, "缺少租户信息", nil)
+		return
+	}
+
+	paginated := paginationRequested(c)
+	var params pageParams
+	if paginated {
+		var err error
+		if params, err = parsePageParams(c); err != nil {
+			h.respondWithError(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+	}
+
+	credentials, err := h.credentialManager.ListAvailableCredentials(tenantID)
+	if err != nil {
+		h.respondWithError(c, http.StatusBadGateway, "获取可用凭证失败", err)
+		return
+	}
+
+	providers := h.groupByProvider(credentials)
+
+	h.logger.WithFields(logrus.Fields{
+		"request_id":     c.GetHeader("X-Request-ID"),
+		"tenant_id":      tenantID,
+		"provider_count": len(providers),
+		"operation":      "list_models",
+	}).Info("返回可用模型列表")
+
+	var data interface{} = map[string]interface{}{
+		"providers": providers,
+	}
+	if paginated {
+		data = paginate(providers, params)
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse[interface{}]{
+		Success:   true,
+		Data:      data,
+		Message:   "请求成功",
+		RequestID: c.GetHeader("X-Request-ID"),
+		Timestamp: fmt.Sprintf("%d", c.GetInt64("timestamp")),
+	})
+}
+
+// groupByProvider 按供应商汇总凭证支持的模型
+func (h *ModelHandler) groupByProvider(credentials []*models.SupplierCredential) []*ProviderModels {
+	byProvider := make(map[string]*ProviderModels)
+	seen := make(map[string]map[string]bool)
+
+	for _, cred := range credentials {
+		entry, exists := byProvider[cred.Provider]
+		if !exists {
+			entry = &ProviderModels{Provider: cred.Provider, Models: []string{}}
+			byProvider[cred.Provider] = entry
+			seen[cred.Provider] = make(map[string]bool)
+		}
+		entry.CredentialCount++
+
+		for _, model := range h.credentialModels(cred) {
+			if !seen[cred.Provider][model] {
+				seen[cred.Provider][model] = true
+				entry.Models = append(entry.Models, model)
+			}
+		}
+	}
+
+	providers := make([]*ProviderModels, 0, len(byProvider))
+	for _, entry := range byProvider {
+		sort.Strings(entry.Models)
+		providers = append(providers, entry)
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].Provider < providers[j].Provider
+	})
+
+	// 标记默认供应商：优先使用工作流的默认供应商，否则取第一个
+	if len(providers) > 0 {
+		defaultIndex := 0
+		for i, entry := range providers {
+			if entry.Provider == defaultProvider {
+				defaultIndex = i
+				break
+			}
+		}
+		providers[defaultIndex].Default = true
+	}
+
+	return providers
+}
+
+// credentialModels 获取凭证可用的模型
+// 优先使用凭证 model_configs 中声明的 models/model，DeepSeek 与 Gemini 凭证补充客户端内置的模型列表
+func (h *ModelHandler) credentialModels(cred *models.SupplierCredential) []string {
+	var result []string
+
+	switch configured := cred.ModelConfigs["models"].(type) {
+	case []interface{}:
+		for _, item := range configured {
+			if model, ok := item.(string); ok && model != "" {
+				result = append(result, model)
+			}
+		}
+	case []string:
+		result = append(result, configured...)
+	}
+	if model, ok := cred.ModelConfigs["model"].(string); ok && model != "" {
+		result = append(result, model)
+	}
+
+	switch cred.Provider {
+	case "deepseek":
+		deepSeekClient := client.NewDeepSeekClient(cred.APIKey, cred.BaseURL, nil, h.logger)
+		deepSeekClient.SetExtraHeaders(client.ExtraHeaders(cred))
+		if builtin, err := deepSeekClient.GetModels(context.Background()); err == nil {
+			result = append(result, builtin...)
+		}
+	case "google":
+		geminiClient, err := client.NewGeminiClient(context.Background(), cred.APIKey, cred.BaseURL, client.WithExtraHeaders(nil, client.ExtraHeaders(cred)), h.logger)
+		if err == nil {
+			if builtin, err := geminiClient.GetModels(context.Background()); err == nil {
+				result = append(result, builtin...)
+			}
+		}
+	}
+
+	return result
+}
+
+// respondWithError 返回错误响应
+func (h *ModelHandler) respondWithError(c *gin.Context, statusCode int, message string, err error) {
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"request_id": c.GetHeader("X-Request-ID"),
+			"status":     statusCode,
+			"message":    message,
+			"error":      err.Error(),
+			"path":       c.Request.URL.Path,
+			"method":     c.Request.Method,
+		}).Error("请求处理失败")
+	}
+
+	c.JSON(statusCode, models.ApiResponse[interface{}]{
+		Success:   false,
+		Data:      nil,
+		Message:   message,
+		RequestID: c.GetHeader("X-Request-ID"),
+		Timestamp: fmt.Sprintf("%d", c.GetInt64("timestamp")),
+	})
+}
+
+// RegisterRoutes 注册模型列表路由
+func (h *ModelHandler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/api/v1/models", h.ListModels)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"lyss-ai-platform/eino-service/internal/client"
+	"lyss-ai-platform/eino-service/internal/config"
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/pkg/credential"
+)
+
+// newModelTestRouter 创建连接租户服务替身的模型列表路由
+func newModelTestRouter(t *testing.T) (*gin.Engine, *testutil.TenantService, *credential.Manager) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	tenantService := testutil.NewTenantService(t)
+	manager := credential.NewManager(tenantService.Client(), nil, &config.CredentialConfig{
+		CacheTTL:                time.Minute,
+		CircuitFailureThreshold: 3,
+		CircuitCooldown:         time.Minute,
+	}, credential.StrategyFirstAvailable, testutil.Logger())
+	t.Cleanup(manager.Stop)
+
+	router := gin.New()
+	NewModelHandler(manager, testutil.Logger()).RegisterRoutes(router)
+	return router, tenantService, manager
+}
+
+// listModels 请求模型列表，tenantID 为空时不携带租户头
+func listModels(router *gin.Engine, tenantID string) (*httptest.ResponseRecorder, []ProviderModels) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/models", nil)
+	if tenantID != "" {
+		req.Header.Set("X-Tenant-ID", tenantID)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	var body struct {
+		Data struct {
+			Providers []ProviderModels `json:"providers"`
+		} `json:"data"`
+	}
+	json.Unmarshal(recorder.Body.Bytes(), &body)
+	return recorder, body.Data.Providers
+}
+
+func TestListModelsWithTwoProviders(t *testing.T) {
+	router, tenantService, manager := newModelTestRouter(t)
+
+	deepseekCred := testutil.Credential("deepseek", "http://deepseek.invalid")
+	deepseekCred.ModelConfigs["model"] = "deepseek-reasoner"
+	openaiCred := testutil.Credential("openai", "http://openai.invalid")
+	openaiCred.ModelConfigs["models"] = []interface{}{"gpt-4o", "gpt-4o-mini"}
+	secondOpenAICred := testutil.Credential("openai", "http://openai.invalid")
+	secondOpenAICred.ModelConfigs["model"] = "gpt-4o"
+	revokedCred := testutil.Credential("openai", "http://openai.invalid")
+	revokedCred.ModelConfigs["model"] = "gpt-revoked"
+	tenantService.SetCredentials(testTenantID, deepseekCred, openaiCred, secondOpenAICred, revokedCred)
+
+	// 鉴权失败的凭证被标记为不健康，其模型不应出现在列表中
+	manager.RecordFailure(context.Background(), revokedCred.ID.String(), client.NewProviderError("openai", http.StatusUnauthorized, "", "invalid api key"))
+
+	recorder, providers := listModels(router, testTenantID)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d，body = %s", recorder.Code, recorder.Body.String())
+	}
+
+	want := []ProviderModels{
+		{Provider: "deepseek", Models: []string{"deepseek-chat", "deepseek-coder", "deepseek-reasoner"}, CredentialCount: 1},
+		{Provider: "openai", Default: true, Models: []string{"gpt-4o", "gpt-4o-mini"}, CredentialCount: 2},
+	}
+	if !reflect.DeepEqual(providers, want) {
+		t.Fatalf("providers = %+v，期望 %+v", providers, want)
+	}
+}
+
+func TestListModelsWithoutCredentials(t *testing.T) {
+	router, _, _ := newModelTestRouter(t)
+
+	tests := []struct {
+		name       string
+		tenantID   string
+		wantStatus int
+	}{
+		{name: "没有凭证时返回空列表", tenantID: testTenantID, wantStatus: http.StatusOK},
+		{name: "缺少租户头", tenantID: "", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder, providers := listModels(router, tt.tenantID)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d，期望 %d", recorder.Code, tt.wantStatus)
+			}
+			if len(providers) != 0 {
+				t.Fatalf("providers = %+v，期望为空", providers)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if body := recorder.Body.String(); !json.Valid([]byte(body)) || !containsEmptyProviders(body) {
+					t.Fatalf("body = %s，期望 providers 为空数组", body)
+				}
+			}
+		})
+	}
+}
+
+// containsEmptyProviders 判断响应中的 providers 是空数组而不是 null
+func containsEmptyProviders(body string) bool {
+	var parsed struct {
+		Data struct {
+			Providers json.RawMessage `json:"providers"`
+		} `json:"data"`
+	}
+	json.Unmarshal([]byte(body), &parsed)
+	return string(parsed.Data.Providers) == "[]"
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+func TestChatSelectsModelByCapability(t *testing.T) {
+	server := newTestServer(t, nil)
+	openai := newProviderStub(t, "openai")
+	deepseek := newProviderStub(t, "deepseek")
+	// 租户只有 openai 与 deepseek 的凭证，google 的视觉模型不可选
+	server.tenantService.SetCredentials(testTenantID,
+		testutil.Credential("openai", openai.Server.URL),
+		testutil.Credential("deepseek", deepseek.Server.URL),
+	)
+
+	tests := []struct {
+		name         string
+		model        string
+		requires     []string
+		optimize     string
+		image        bool
+		wantStatus   int
+		wantProvider *providerStub
+		wantModel    string
+	}{
+		{name: "选择最便宜的模型", model: "gpt-4o", optimize: "cost", wantStatus: http.StatusOK, wantProvider: deepseek, wantModel: "deepseek-chat"},
+		{name: "选择支持视觉的模型", model: "deepseek-chat", requires: []string{"vision"}, image: true, wantStatus: http.StatusOK, wantProvider: openai, wantModel: "gpt-4o"},
+		{name: "按成本选择支持视觉的模型", model: "deepseek-chat", requires: []string{"vision"}, optimize: "cost", image: true, wantStatus: http.StatusOK, wantProvider: openai, wantModel: "gpt-4o"},
+		{name: "请求的模型已具备能力", model: "deepseek-chat", requires: []string{"tools"}, wantStatus: http.StatusOK, wantProvider: deepseek, wantModel: "deepseek-chat"},
+		{name: "没有匹配时使用请求的模型", model: "deepseek-chat", requires: []string{"audio"}, wantStatus: http.StatusOK, wantProvider: deepseek, wantModel: "deepseek-chat"},
+		{name: "不支持的优化目标", model: "deepseek-chat", optimize: "speed", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openaiBefore, deepseekBefore := len(openai.Requests()), len(deepseek.Requests())
+			body := map[string]interface{}{
+				"message":  "你好",
+				"model":    tt.model,
+				"requires": tt.requires,
+				"optimize": tt.optimize,
+			}
+			if tt.image {
+				// 包含图片的请求由支持多模态输入的标准EINO聊天工作流处理
+				body["content_parts"] = []map[string]interface{}{
+					{"type": "text", "text": "图片里有什么"},
+					{"type": "image_url", "image_url": map[string]string{"url": "https://example.com/cat.png"}},
+				}
+			}
+			recorder := server.post("/api/v1/chat", body)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d，期望 %d，body = %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+
+			calls := len(openai.Requests()) - openaiBefore + len(deepseek.Requests()) - deepseekBefore
+			if tt.wantProvider == nil {
+				if calls != 0 {
+					t.Fatalf("供应商调用次数 = %d，期望 0", calls)
+				}
+				return
+			}
+			requests := tt.wantProvider.Requests()
+			if calls != 1 || len(requests) == 0 {
+				t.Fatalf("供应商调用次数 = %d，期望由所选供应商处理 1 次", calls)
+			}
+			if got := requests[len(requests)-1]["model"]; got != tt.wantModel {
+				t.Fatalf("供应商收到的模型 = %v，期望 %s", got, tt.wantModel)
+			}
+		})
+	}
+}
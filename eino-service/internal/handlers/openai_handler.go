@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/internal/workflows"
+)
+
+// ChatCompletions OpenAI兼容的聊天补全接口
+func (h *WorkflowHandler) ChatCompletions(c *gin.Context) {
+	var req models.OpenAIChatCompletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondWithOpenAIError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("请求格式错误: %v", err))
+		return
+	}
+
+	// 从请求头获取租户和用户信息
+	tenantID := c.GetHeader("X-Tenant-ID")
+	userID := c.GetHeader("X-User-ID")
+
+	requestID := c.GetHeader("X-Request-ID")
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+
+	workflowReq, err := h.buildOpenAIWorkflowRequest(&req, requestID, tenantID, userID)
+	if err != nil {
+		h.respondWithOpenAIError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	workflowReq.DebugTrace = debugTraceRequested(c)
+
+	h.logger.WithFields(logrus.Fields{
+		"request_id":    requestID,
+		"execution_id":  workflowReq.ExecutionID,
+		"tenant_id":     tenantID,
+		"user_id":       userID,
+		"workflow_type": workflowReq.WorkflowType,
+		"message_count": len(req.Messages),
+		"model":         req.Model,
+		"stream":        req.Stream,
+		"operation":     "openai_chat_completions",
+	}).Info("收到OpenAI兼容聊天请求")
+
+	completionID := "chatcmpl-" + workflowReq.ExecutionID
+	created := time.Now().Unix()
+
+	if req.Stream {
+		h.handleOpenAIStream(c, workflowReq, completionID, created)
+		return
+	}
+
+	response, err := h.workflowManager.ExecuteWorkflow(c.Request.Context(), workflowReq)
+	if err != nil {
+		h.respondWithOpenAIWorkflowError(c, err)
+		return
+	}
+
+	completion := &models.OpenAIChatCompletionResponse{
+		ID:      completionID,
+		Object:  "chat.completion",
+		Created: created,
+		Model:   h.openAIModelName(req.Model, response.Model),
+		Choices: []models.OpenAIChatCompletionChoice{
+			{
+				Index: 0,
+				Message: models.OpenAIChatMessage{
+					Role:    "assistant",
+					Content: response.Content,
+				},
+				FinishReason: openAIFinishReason(response.FinishReason),
+			},
+		},
+	}
+	if response.Usage != nil {
+		completion.Usage = models.OpenAIUsage{
+			PromptTokens:     response.Usage.PromptTokens,
+			CompletionTokens: response.Usage.CompletionTokens,
+			TotalTokens:      response.Usage.TotalTokens,
+		}
+	}
+
+	c.JSON(http.StatusOK, completion)
+}
+
+// buildOpenAIWorkflowRequest 将OpenAI请求映射为工作流请求
+// 最后一条用户消息作为当前消息，之前的对话作为历史，system 消息合并为系统提示
+func (h *WorkflowHandler) buildOpenAIWorkflowRequest(req *models.OpenAIChatCompletionRequest, requestID, tenantID, userID string) (*workflows.WorkflowRequest, error) {
+	lastUserIndex := -1
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == "user" {
+			lastUserIndex = i
+			break
+		}
+	}
+	if lastUserIndex < 0 {
+		return nil, fmt.Errorf("messages 中至少需要一条 user 消息")
+	}
+
+	var systemPrompts []string
+	history := make([]interface{}, 0, lastUserIndex)
+	for _, msg := range req.Messages[:lastUserIndex] {
+		if msg.Role == "system" {
+			systemPrompts = append(systemPrompts, msg.Content)
+			continue
+		}
+		history = append(history, map[string]interface{}{
+			"role":    msg.Role,
+			"content": msg.Content,
+		})
+	}
+
+	configuration := map[string]interface{}{
+		"conversation_history": history,
+	}
+	if len(systemPrompts) > 0 {
+		configuration["system_prompt"] = strings.Join(systemPrompts, "\n\n")
+	}
+
+	modelConfig := map[string]interface{}{
+		"stream": req.Stream,
+	}
+	if req.Model != "" {
+		modelConfig["model"] = req.Model
+	}
+	if req.Temperature != nil {
+		modelConfig["temperature"] = *req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		modelConfig["max_tokens"] = req.MaxTokens
+	}
+	if req.TopP != nil {
+		modelConfig["top_p"] = *req.TopP
+	}
+	if req.Stop != nil {
+		modelConfig["stop"] = req.Stop
+	}
+	if req.FrequencyPenalty != nil {
+		modelConfig["frequency_penalty"] = *req.FrequencyPenalty
+	}
+	if req.PresencePenalty != nil {
+		modelConfig["presence_penalty"] = *req.PresencePenalty
+	}
+
+	workflowReq := &workflows.WorkflowRequest{
+		RequestID:     requestID,
+		ExecutionID:   uuid.New().String(),
+		TenantID:      tenantID,
+		UserID:        userID,
+		WorkflowType:  chatWorkflowType(req.Messages[lastUserIndex].ContentParts),
+		Message:       req.Messages[lastUserIndex].Content,
+		Model:         req.Model,
+		MaxTokens:     req.MaxTokens,
+		ModelConfig:   modelConfig,
+		Configuration: configuration,
+		Stream:        req.Stream,
+		Seed:          req.Seed,
+		ContentParts:  req.Messages[lastUserIndex].ContentParts,
+
+		ResponseFormat: req.ResponseFormat,
+		Temperature:    req.Temperature,
+	}
+
+	return workflowReq, nil
+}
+
+// handleOpenAIStream 以 chat.completion.chunk 格式输出流式响应
+func (h *WorkflowHandler) handleOpenAIStream(c *gin.Context, req *workflows.WorkflowRequest, completionID string, created int64) {
+	// 携带 Last-Event-ID 重连时从事件缓冲续传，不重新执行
+	if h.resumeRequested(c) {
+		h.resumeStream(c, req.TenantID, func() { h.sendOpenAIResync(c) })
+		return
+	}
+
+	ctx, cancel := h.streamContext(c, req.TenantID, req.ExecutionID)
+	defer cancel()
+	responseCh, err := h.workflowManager.ExecuteWorkflowStream(ctx, req)
+	if err != nil {
+		h.respondWithOpenAIWorkflowError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	stream := h.newSSEStream(c, req.TenantID, req.ExecutionID)
+	defer stream.close()
+
+	model := h.openAIModelName(req.Model, "")
+	h.sendOpenAIChunk(stream, completionID, created, model, models.OpenAIChatCompletionDelta{Role: "assistant"}, nil)
+
+	keepalive := newKeepaliveTicker(h.streamKeepaliveInterval)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-keepalive.C():
+			h.sendSSEKeepalive(c)
+			continue
+		case streamResp, ok := <-responseCh:
+			if !ok {
+				return
+			}
+			keepalive.Reset()
+
+			switch streamResp.Type {
+			case workflows.StreamEventChunk:
+				if streamResp.Content == "" {
+					continue
+				}
+				h.sendOpenAIChunk(stream, completionID, created, model, models.OpenAIChatCompletionDelta{Content: streamResp.Content}, nil)
+			case workflows.StreamEventError:
+				h.sendOpenAIStreamError(stream, streamResp.Error)
+				return
+			case workflows.StreamEventEnd:
+				reason, _ := streamResp.Data["finish_reason"].(string)
+				finishReason := openAIFinishReason(reason)
+				h.sendOpenAIChunk(stream, completionID, created, model, models.OpenAIChatCompletionDelta{}, &finishReason)
+				stream.send("data: [DONE]\n\n", true)
+				return
+			}
+		}
+	}
+}
+
+// openAIFinishReason 供应商未返回结束原因时按正常结束处理
+func openAIFinishReason(reason string) string {
+	if reason == "" {
+		return "stop"
+	}
+	return reason
+}
+
+// sendOpenAIChunk 发送单个 chat.completion.chunk
+func (h *WorkflowHandler) sendOpenAIChunk(stream *sseStream, completionID string, created int64, model string, delta models.OpenAIChatCompletionDelta, finishReason *string) {
+	chunk := models.OpenAIChatCompletionChunk{
+		ID:      completionID,
+		Object:  "chat.completion.chunk",
+		Created: created,
+		Model:   model,
+		Choices: []models.OpenAIChatCompletionChunkChoice{
+			{
+				Index:        0,
+				Delta:        delta,
+				FinishReason: finishReason,
+			},
+		},
+	}
+	jsonData, _ := json.Marshal(chunk)
+	stream.send(fmt.Sprintf("data: %s\n\n", string(jsonData)), false)
+}
+
+// sendOpenAIStreamError 在流中发送OpenAI格式的错误，错误帧为流的最后一帧
+func (h *WorkflowHandler) sendOpenAIStreamError(stream *sseStream, message string) {
+	jsonData, _ := json.Marshal(models.OpenAIErrorResponse{
+		Error: models.OpenAIError{
+			Message: message,
+			Type:    "server_error",
+		},
+	})
+	stream.send(fmt.Sprintf("data: %s\n\n", string(jsonData)), true)
+}
+
+// sendOpenAIResync 事件缓冲已过期，以 stream_expired 错误通知客户端重新发起请求
+func (h *WorkflowHandler) sendOpenAIResync(c *gin.Context) {
+	jsonData, _ := json.Marshal(models.OpenAIErrorResponse{
+		Error: models.OpenAIError{
+			Message: "无法从断点续传，请重新发起请求",
+			Type:    "stream_expired",
+		},
+	})
+	c.Writer.WriteString(fmt.Sprintf("data: %s\n\n", string(jsonData)))
+	c.Writer.Flush()
+}
+
+// respondWithOpenAIError 返回OpenAI格式的错误响应
+func (h *WorkflowHandler) respondWithOpenAIError(c *gin.Context, statusCode int, errorType, message string) {
+	h.logger.WithFields(logrus.Fields{
+		"request_id": c.GetHeader("X-Request-ID"),
+		"status":     statusCode,
+		"message":    message,
+		"path":       c.Request.URL.Path,
+		"method":     c.Request.Method,
+	}).Error("OpenAI兼容请求处理失败")
+
+	c.JSON(statusCode, models.OpenAIErrorResponse{
+		Error: models.OpenAIError{
+			Message: message,
+			Type:    errorType,
+		},
+	})
+}
+
+// openAIModelName 确定响应中返回的模型名称
+func (h *WorkflowHandler) openAIModelName(requested, actual string) string {
+	if actual != "" {
+		return actual
+	}
+	if requested != "" {
+		return requested
+	}
+	return "deepseek-chat"
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+// providerStub OpenAI兼容的供应商替身，按请求的 stream 字段返回JSON或事件流，并记录请求体
+type providerStub struct {
+	Server *httptest.Server
+
+	// FinishReason 回答的结束原因，为空时为 stop
+	FinishReason string
+	// Delay 非流式响应及流式响应中每个分块之前的等待时间
+	Delay time.Duration
+
+	mutex    sync.Mutex
+	chunks   []string
+	requests []map[string]interface{}
+	headers  []http.Header
+}
+
+// newProviderStub 启动供应商替身，回答内容为 chunks 依次拼接
+func newProviderStub(t *testing.T, chunks ...string) *providerStub {
+	t.Helper()
+	s := &providerStub{chunks: chunks}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Server.Close)
+	return s
+}
+
+// Headers 返回收到的请求头
+func (s *providerStub) Headers() []http.Header {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]http.Header(nil), s.headers...)
+}
+
+// Requests 返回收到的请求体
+func (s *providerStub) Requests() []map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]map[string]interface{}(nil), s.requests...)
+}
+
+func (s *providerStub) handle(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+	s.mutex.Lock()
+	s.requests = append(s.requests, body)
+	s.headers = append(s.headers, r.Header.Clone())
+	s.mutex.Unlock()
+
+	usage := map[string]int{"prompt_tokens": 7, "completion_tokens": 3, "total_tokens": 10}
+	finishReason := s.FinishReason
+	if finishReason == "" {
+		finishReason = "stop"
+	}
+	if stream, _ := body["stream"].(bool); !stream {
+		time.Sleep(s.Delay)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":      "provider-1",
+			"object":  "chat.completion",
+			"model":   body["model"],
+			"choices": []map[string]interface{}{{"index": 0, "message": map[string]string{"role": "assistant", "content": strings.Join(s.chunks, "")}, "finish_reason": finishReason}},
+			"usage":   usage,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	for i, content := range s.chunks {
+		time.Sleep(s.Delay)
+		choice := map[string]interface{}{"index": 0, "delta": map[string]string{"content": content}}
+		if i == len(s.chunks)-1 {
+			choice["finish_reason"] = finishReason
+		}
+		data, _ := json.Marshal(map[string]interface{}{
+			"id":      "provider-1",
+			"object":  "chat.completion.chunk",
+			"model":   body["model"],
+			"choices": []map[string]interface{}{choice},
+			"usage":   usage,
+		})
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		w.(http.Flusher).Flush()
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+// sseData 解析事件流中的 data 行
+func sseData(t *testing.T, body string) []string {
+	t.Helper()
+	var data []string
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "data: ") {
+			data = append(data, strings.TrimPrefix(line, "data: "))
+		}
+	}
+	return data
+}
+
+// keysOf 返回JSON对象的字段名，按字母排序
+func keysOf(object map[string]interface{}) string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+func TestChatCompletionsMatchesOpenAISchema(t *testing.T) {
+	server := newTestServer(t, nil)
+	provider := newProviderStub(t, "你好", "，世界")
+	server.tenantService.SetCredentials(testTenantID, testutil.Credential("deepseek", provider.Server.URL))
+
+	request := map[string]interface{}{
+		"model": "deepseek-chat",
+		"messages": []map[string]string{
+			{"role": "system", "content": "简洁回答"},
+			{"role": "user", "content": "打个招呼"},
+		},
+	}
+
+	recorder := server.post("/v1/chat/completions", request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d，body = %s", recorder.Code, recorder.Body.String())
+	}
+
+	var completion map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &completion); err != nil {
+		t.Fatalf("响应不是JSON: %v", err)
+	}
+	if keys := keysOf(completion); keys != "choices,created,id,model,object,usage" {
+		t.Fatalf("响应字段 = %s，期望与OpenAI一致", keys)
+	}
+	if id, _ := completion["id"].(string); !strings.HasPrefix(id, "chatcmpl-") {
+		t.Fatalf("id = %v，期望以 chatcmpl- 开头", completion["id"])
+	}
+	if completion["object"] != "chat.completion" || completion["model"] != "deepseek-chat" {
+		t.Fatalf("object/model = %v/%v", completion["object"], completion["model"])
+	}
+	if _, ok := completion["created"].(float64); !ok {
+		t.Fatalf("created = %v，期望为数值", completion["created"])
+	}
+
+	choices, _ := completion["choices"].([]interface{})
+	if len(choices) != 1 {
+		t.Fatalf("choices = %v，期望1个", completion["choices"])
+	}
+	choice := choices[0].(map[string]interface{})
+	if keys := keysOf(choice); keys != "finish_reason,index,message" {
+		t.Fatalf("choice 字段 = %s", keys)
+	}
+	message := choice["message"].(map[string]interface{})
+	if message["role"] != "assistant" || message["content"] != "你好，世界" || choice["finish_reason"] != "stop" {
+		t.Fatalf("choice = %v", choice)
+	}
+
+	usage := completion["usage"].(map[string]interface{})
+	if keys := keysOf(usage); keys != "completion_tokens,prompt_tokens,total_tokens" || usage["total_tokens"] != float64(10) {
+		t.Fatalf("usage = %v", usage)
+	}
+}
+
+func TestChatCompletionsStreamMatchesOpenAISchema(t *testing.T) {
+	server := newTestServer(t, nil)
+	provider := newProviderStub(t, "你好", "，世界")
+	server.tenantService.SetCredentials(testTenantID, testutil.Credential("deepseek", provider.Server.URL))
+
+	recorder := server.post("/v1/chat/completions", map[string]interface{}{
+		"model":    "deepseek-chat",
+		"stream":   true,
+		"messages": []map[string]string{{"role": "user", "content": "打个招呼"}},
+	})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d，body = %s", recorder.Code, recorder.Body.String())
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("Content-Type = %q，期望 text/event-stream", contentType)
+	}
+
+	data := sseData(t, recorder.Body.String())
+	if len(data) < 3 || data[len(data)-1] != "[DONE]" {
+		t.Fatalf("事件流 = %q，期望以 [DONE] 结束", data)
+	}
+
+	var id string
+	var content strings.Builder
+	var finishReasons []interface{}
+	for i, frame := range data[:len(data)-1] {
+		var chunk map[string]interface{}
+		if err := json.Unmarshal([]byte(frame), &chunk); err != nil {
+			t.Fatalf("第 %d 帧不是JSON: %s", i, frame)
+		}
+		if keys := keysOf(chunk); keys != "choices,created,id,model,object" {
+			t.Fatalf("第 %d 帧字段 = %s", i, keys)
+		}
+		if chunk["object"] != "chat.completion.chunk" {
+			t.Fatalf("object = %v，期望 chat.completion.chunk", chunk["object"])
+		}
+		if i == 0 {
+			id, _ = chunk["id"].(string)
+		} else if chunk["id"] != id {
+			t.Fatalf("第 %d 帧 id = %v，期望与首帧一致 %s", i, chunk["id"], id)
+		}
+
+		choice := chunk["choices"].([]interface{})[0].(map[string]interface{})
+		if keys := keysOf(choice); keys != "delta,finish_reason,index" {
+			t.Fatalf("choice 字段 = %s", keys)
+		}
+		delta := choice["delta"].(map[string]interface{})
+		if i == 0 && delta["role"] != "assistant" {
+			t.Fatalf("首帧 delta = %v，期望 role 为 assistant", delta)
+		}
+		if text, ok := delta["content"].(string); ok {
+			content.WriteString(text)
+		}
+		finishReasons = append(finishReasons, choice["finish_reason"])
+	}
+
+	if !strings.HasPrefix(id, "chatcmpl-") {
+		t.Fatalf("id = %q，期望以 chatcmpl- 开头", id)
+	}
+	if content.String() != "你好，世界" {
+		t.Fatalf("拼接内容 = %q，期望 你好，世界", content.String())
+	}
+	for i, reason := range finishReasons {
+		last := i == len(finishReasons)-1
+		if last && reason != "stop" || !last && reason != nil {
+			t.Fatalf("finish_reason = %v，期望仅最后一帧为 stop", finishReasons)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"lyss-ai-platform/eino-service/internal/models"
+)
+
+// APIVersionHeader 客户端选择响应格式版本的HTTP头
+// 取值为 2 时列表接口返回统一的 models.Page 分页结构，未携带时保持原有格式
+const APIVersionHeader = "X-API-Version"
+
+// 分页参数的默认值与上限
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// pageParams 列表接口的分页参数
+type pageParams struct {
+	Page     int
+	PageSize int
+	Offset   int
+}
+
+// paginationRequested 客户端是否要求返回统一分页结构
+func paginationRequested(c *gin.Context) bool {
+	version, err := strconv.Atoi(c.GetHeader(APIVersionHeader))
+	return err == nil && version >= 2
+}
+
+// parsePageParams 解析 page、page_size 与 cursor 查询参数
+// cursor 为上一页返回的 next_cursor，指定时优先于 page；page_size 超过上限时按上限处理
+func parsePageParams(c *gin.Context) (pageParams, error) {
+	params := pageParams{Page: 1, PageSize: defaultPageSize}
+
+	if value := c.Query("page_size"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size <= 0 {
+			return params, fmt.Errorf("page_size 必须为正整数")
+		}
+		params.PageSize = min(size, maxPageSize)
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		offset, err := strconv.Atoi(cursor)
+		if err != nil || offset < 0 {
+			return params, fmt.Errorf("无效的 cursor")
+		}
+		params.Offset = offset
+		params.Page = offset/params.PageSize + 1
+		return params, nil
+	}
+
+	if value := c.Query("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page <= 0 {
+			return params, fmt.Errorf("page 必须为正整数")
+		}
+		params.Page = page
+	}
+	params.Offset = (params.Page - 1) * params.PageSize
+	return params, nil
+}
+
+// paginate 从完整列表中截取当前页
+func paginate[T any](items []T, params pageParams) models.Page[T] {
+	page := models.Page[T]{
+		Items:    []T{},
+		Total:    len(items),
+		Page:     params.Page,
+		PageSize: params.PageSize,
+	}
+	if params.Offset >= len(items) {
+		return page
+	}
+
+	end := min(params.Offset+params.PageSize, len(items))
+	page.Items = items[params.Offset:end]
+	if end < len(items) {
+		page.HasMore = true
+		page.NextCursor = strconv.Itoa(end)
+	}
+	return page
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"lyss-ai-platform/eino-service/internal/config"
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/pkg/credential"
+)
+
+func TestParsePageParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		query   string
+		want    pageParams
+		wantErr bool
+	}{
+		{name: "默认第一页", want: pageParams{Page: 1, PageSize: defaultPageSize}},
+		{name: "指定页码与条数", query: "page=3&page_size=10", want: pageParams{Page: 3, PageSize: 10, Offset: 20}},
+		{name: "条数超过上限", query: "page_size=500", want: pageParams{Page: 1, PageSize: maxPageSize}},
+		{name: "游标优先于页码", query: "page=9&page_size=10&cursor=25", want: pageParams{Page: 3, PageSize: 10, Offset: 25}},
+		{name: "页码为0", query: "page=0", wantErr: true},
+		{name: "条数非数字", query: "page_size=abc", wantErr: true},
+		{name: "游标为负数", query: "cursor=-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+
+			got, err := parsePageParams(c)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parsePageParams(%s) 期望返回错误", tt.query)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("parsePageParams(%s) = %+v, %v，期望 %+v", tt.query, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	tests := []struct {
+		name   string
+		params pageParams
+		want   models.Page[int]
+	}{
+		{
+			name:   "第一页",
+			params: pageParams{Page: 1, PageSize: 2},
+			want:   models.Page[int]{Items: []int{1, 2}, Total: 5, Page: 1, PageSize: 2, HasMore: true, NextCursor: "2"},
+		},
+		{
+			name:   "最后一页",
+			params: pageParams{Page: 3, PageSize: 2, Offset: 4},
+			want:   models.Page[int]{Items: []int{5}, Total: 5, Page: 3, PageSize: 2},
+		},
+		{
+			name:   "恰好取完",
+			params: pageParams{Page: 1, PageSize: 5},
+			want:   models.Page[int]{Items: []int{1, 2, 3, 4, 5}, Total: 5, Page: 1, PageSize: 5},
+		},
+		{
+			name:   "超出范围返回空列表",
+			params: pageParams{Page: 4, PageSize: 2, Offset: 6},
+			want:   models.Page[int]{Items: []int{}, Total: 5, Page: 4, PageSize: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := paginate(items, tt.params); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("paginate = %+v，期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newPaginationRouter 创建同时注册工作流、模型与提示词预设列表接口的路由
+// 测试租户配置了3个供应商的凭证和3个提示词预设
+func newPaginationRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	server := newTestServer(t, nil)
+	server.tenantService.SetCredentials(testTenantID,
+		testutil.Credential("deepseek", "http://deepseek.invalid"),
+		testutil.Credential("openai", "http://openai.invalid"),
+		testutil.Credential("google", "http://google.invalid"),
+	)
+
+	manager := credential.NewManager(server.tenantService.Client(), nil, &config.CredentialConfig{
+		CacheTTL:                time.Minute,
+		CircuitFailureThreshold: 3,
+		CircuitCooldown:         time.Minute,
+	}, credential.StrategyFirstAvailable, testutil.Logger())
+	t.Cleanup(manager.Stop)
+	NewModelHandler(manager, testutil.Logger()).RegisterRoutes(server.router)
+	NewPromptHandler(newTestPromptStore(t), testutil.Logger()).RegisterRoutes(server.router)
+
+	for _, name := range []string{"客服", "翻译", "总结"} {
+		status, response := doPromptRequest(t, server.router, http.MethodPost, "/api/v1/prompts", testTenantID, map[string]string{
+			"name":     name,
+			"template": "你是" + name + "助手。",
+		})
+		if status != http.StatusCreated {
+			t.Fatalf("创建提示词预设 status = %d，message = %s", status, response.Message)
+		}
+	}
+	return server.router
+}
+
+// getList 以测试租户身份请求列表接口，version 为空时不携带版本头
+func getList(t *testing.T, router *gin.Engine, path, version string) (int, json.RawMessage) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("X-Tenant-ID", testTenantID)
+	if version != "" {
+		req.Header.Set(APIVersionHeader, version)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	var response models.ApiResponse[json.RawMessage]
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("响应不是JSON: %v，body = %s", err, recorder.Body.String())
+	}
+	return recorder.Code, response.Data
+}
+
+func TestPageEnvelopeConsistentAcrossEndpoints(t *testing.T) {
+	router := newPaginationRouter(t)
+	envelopeKeys := []string{"has_more", "items", "page", "page_size", "total"}
+
+	tests := []struct {
+		name      string
+		path      string
+		wantTotal int
+		legacyKey string // 不携带版本头时 data 中列表所在的字段，为空表示 data 本身是数组
+	}{
+		{name: "模型列表", path: "/api/v1/models", wantTotal: 3, legacyKey: "providers"},
+		{name: "工作流列表", path: "/api/v1/workflows"},
+		{name: "提示词预设列表", path: "/api/v1/prompts", wantTotal: 3, legacyKey: "prompts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, data := getList(t, router, tt.path, "")
+			if status != http.StatusOK {
+				t.Fatalf("原有格式 status = %d", status)
+			}
+			var legacy []json.RawMessage
+			if tt.legacyKey == "" {
+				if err := json.Unmarshal(data, &legacy); err != nil {
+					t.Fatalf("不携带版本头时 data 应为数组: %s", data)
+				}
+			} else {
+				var wrapped map[string][]json.RawMessage
+				json.Unmarshal(data, &wrapped)
+				if _, ok := wrapped[tt.legacyKey]; !ok {
+					t.Fatalf("不携带版本头时 data 应包含 %s: %s", tt.legacyKey, data)
+				}
+				legacy = wrapped[tt.legacyKey]
+			}
+			wantTotal := tt.wantTotal
+			if wantTotal == 0 {
+				wantTotal = len(legacy)
+			}
+			if len(legacy) != wantTotal || wantTotal < 2 {
+				t.Fatalf("原有格式返回 %d 条，期望 %d 条且至少2条以便分页", len(legacy), wantTotal)
+			}
+
+			// 每页1条，沿 next_cursor 翻页直到取完
+			var collected []json.RawMessage
+			path := tt.path + "?page_size=1"
+			for page := 1; ; page++ {
+				status, data := getList(t, router, path, "2")
+				if status != http.StatusOK {
+					t.Fatalf("第 %d 页 status = %d", page, status)
+				}
+
+				var fields map[string]json.RawMessage
+				json.Unmarshal(data, &fields)
+				keys := make([]string, 0, len(fields))
+				for key := range fields {
+					if key != "next_cursor" {
+						keys = append(keys, key)
+					}
+				}
+				sort.Strings(keys)
+				if !reflect.DeepEqual(keys, envelopeKeys) {
+					t.Fatalf("第 %d 页字段 = %v，期望统一的分页结构 %v", page, keys, envelopeKeys)
+				}
+
+				var envelope models.Page[json.RawMessage]
+				json.Unmarshal(data, &envelope)
+				if envelope.Total != wantTotal || envelope.Page != page || envelope.PageSize != 1 || len(envelope.Items) != 1 {
+					t.Fatalf("第 %d 页 = %+v，期望 total=%d page=%d page_size=1 且含1条", page, envelope, wantTotal, page)
+				}
+				collected = append(collected, envelope.Items...)
+
+				if !envelope.HasMore {
+					if envelope.NextCursor != "" {
+						t.Fatalf("最后一页 next_cursor = %q，期望为空", envelope.NextCursor)
+					}
+					break
+				}
+				path = tt.path + "?page_size=1&cursor=" + envelope.NextCursor
+			}
+
+			if len(collected) != wantTotal {
+				t.Fatalf("翻页共取得 %d 条，期望 %d 条", len(collected), wantTotal)
+			}
+			for i := range legacy {
+				if string(collected[i]) != string(legacy[i]) {
+					t.Fatalf("第 %d 条 = %s，期望与原有格式一致 %s", i+1, collected[i], legacy[i])
+				}
+			}
+
+			if status, _ := getList(t, router, tt.path+"?page_size=0", "2"); status != http.StatusBadRequest {
+				t.Fatalf("非法 page_size status = %d，期望 400", status)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/config"
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+func TestChatParameterPolicy(t *testing.T) {
+	body := map[string]interface{}{
+		"message":      "你好",
+		"model":        "deepseek-chat",
+		"temperature":  2.5,
+		"model_config": map[string]interface{}{"top_p": 1.5},
+	}
+
+	tests := []struct {
+		name            string
+		policy          string
+		wantStatus      int
+		wantInvalid     []string
+		wantCalls       int
+		wantTemperature float64
+	}{
+		{name: "reject 返回400并列出越界参数", policy: "reject", wantStatus: http.StatusBadRequest, wantInvalid: []string{"temperature", "top_p"}},
+		{name: "clamp 修正后继续执行", policy: "clamp", wantStatus: http.StatusOK, wantCalls: 1, wantTemperature: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, func(cfg *config.Config) {
+				cfg.Workflows.ParameterPolicy = tt.policy
+			})
+			stub := newProviderStub(t, "你好")
+			server.tenantService.SetCredentials(testTenantID, testutil.Credential("deepseek", stub.Server.URL))
+
+			recorder := server.post("/api/v1/chat", body)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d，期望 %d，body = %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			requests := stub.Requests()
+			if len(requests) != tt.wantCalls {
+				t.Fatalf("供应商调用次数 = %d，期望 %d", len(requests), tt.wantCalls)
+			}
+
+			if tt.wantStatus == http.StatusBadRequest {
+				var response models.ApiResponse[models.ErrorResponse]
+				if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+					t.Fatalf("解析响应失败: %v", err)
+				}
+				invalid, _ := response.Data.Details["invalid"].(map[string]interface{})
+				for _, name := range tt.wantInvalid {
+					if invalid[name] == nil {
+						t.Fatalf("details.invalid = %v，期望包含 %s", invalid, name)
+					}
+				}
+				return
+			}
+			if got := requests[0]["temperature"]; got != tt.wantTemperature {
+				t.Fatalf("供应商收到的 temperature = %v，期望 %v", got, tt.wantTemperature)
+			}
+		})
+	}
+}
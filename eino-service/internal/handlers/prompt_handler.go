@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/pkg/prompts"
+)
+
+// 提示词预设名称的最大长度（字符数）
+const maxPromptNameLength = 128
+
+// PromptHandler 提示词预设处理器
+type PromptHandler struct {
+	promptStore *prompts.Store
+	logger      *logrus.Logger
+}
+
+// NewPromptHandler 创建提示词预设处理器，promptStore 为 nil 表示未启用提示词预设库
+func NewPromptHandler(promptStore *prompts.Store, logger *logrus.Logger) *PromptHandler {
+	return &PromptHandler{
+		promptStore: promptStore,
+		logger:      logger,
+	}
+}
+
+// PromptRequest 创建或更新提示词预设的请求
+type PromptRequest struct {
+	Name     string `json:"name"`
+	Template string `json:"template"`
+}
+
+// CreatePrompt 创建提示词预设
+func (h *PromptHandler) CreatePrompt(c *gin.Context) {
+	tenantID, ok := h.requireStore(c)
+	if !ok {
+		return
+	}
+
+	request, ok := h.bindPromptRequest(c)
+	if !ok {
+		return
+	}
+
+	preset, err := h.promptStore.Create(c.Request.Context(), tenantID, request.Name, request.Template)
+	if err != nil {
+		h.respondWithStoreError(c, err)
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusCreated, preset)
+}
+
+// ListPrompts 列出租户的提示词预设
+func (h *PromptHandler) ListPrompts(c *gin.Context) {
+	tenantID, ok := h.requireStore(c)
+	if !ok {
+		return
+	}
+
+	presets, err := h.promptStore.List(c.Request.Context(), tenantID)
+	if err != nil {
+		h.respondWithStoreError(c, err)
+		return
+	}
+
+	if paginationRequested(c) {
+		params, err := parsePageParams(c)
+		if err != nil {
+			h.respondWithError(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		h.respondWithSuccess(c, http.StatusOK, paginate(presets, params))
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, map[string]interface{}{
+		"prompts": presets,
+	})
+}
+
+// GetPrompt 获取提示词预设
+func (h *PromptHandler) GetPrompt(c *gin.Context) {
+	tenantID, ok := h.requireStore(c)
+	if !ok {
+		return
+	}
+
+	preset, err := h.promptStore.Get(c.Request.Context(), tenantID, c.Param("id"))
+	if err != nil {
+		h.respondWithStoreError(c, err)
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, preset)
+}
+
+// UpdatePrompt 更新提示词预设
+func (h *PromptHandler) UpdatePrompt(c *gin.Context) {
+	tenantID, ok := h.requireStore(c)
+	if !ok {
+		return
+	}
+
+	request, ok := h.bindPromptRequest(c)
+	if !ok {
+		return
+	}
+
+	preset, err := h.promptStore.Update(c.Request.Context(), tenantID, c.Param("id"), request.Name, request.Template)
+	if err != nil {
+		h.respondWithStoreError(c, err)
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, preset)
+}
+
+// DeletePrompt 删除提示词预设
+func (h *PromptHandler) DeletePrompt(c *gin.Context) {
+	tenantID, ok := h.requireStore(c)
+	if !ok {
+		return
+	}
+
+	if err := h.promptStore.Delete(c.Request.Context(), tenantID, c.Param("id")); err != nil {
+		h.respondWithStoreError(c, err)
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, map[string]interface{}{
+		"id":      c.Param("id"),
+		"deleted": true,
+	})
+}
+
+// requireStore 检查租户信息与提示词预设库是否可用，返回租户ID
+func (h *PromptHandler) requireStore(c *gin.Context) (string, bool) {
+	tenantID := c.GetHeader("X-Tenant-ID")
+	if tenantID == "" {
+		h.respondWithError(c, http.StatusBadRequest, "缺少租户信息", nil)
+		return "", false
+	}
+	if h.promptStore == nil {
+		h.respondWithError(c, http.StatusServiceUnavailable, "未启用提示词预设库", nil)
+		return "", false
+	}
+	return tenantID, true
+}
+
+// bindPromptRequest 解析并校验提示词预设请求
+func (h *PromptHandler) bindPromptRequest(c *gin.Context) (*PromptRequest, bool) {
+	var request PromptRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "请求参数格式错误", err)
+		return nil, false
+	}
+
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" || strings.TrimSpace(request.Template) == "" {
+		h.respondWithError(c, http.StatusBadRequest, "name 和 template 不能为空", nil)
+		return nil, false
+	}
+	if utf8.RuneCountInString(request.Name) > maxPromptNameLength {
+		h.respondWithError(c, http.StatusBadRequest, fmt.Sprintf("name 不能超过 %d 个字符", maxPromptNameLength), nil)
+		return nil, false
+	}
+	if err := prompts.ValidateTemplate(c.Request.Context(), request.Template); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, err.Error(), nil)
+		return nil, false
+	}
+
+	return &request, true
+}
+
+// respondWithStoreError 将存储错误映射为响应状态码
+func (h *PromptHandler) respondWithStoreError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, prompts.ErrPromptNotFound):
+		h.respondWithError(c, http.StatusNotFound, "提示词预设不存在", nil)
+	case errors.Is(err, prompts.ErrPromptNameExists):
+		h.respondWithError(c, http.StatusConflict, err.Error(), nil)
+	default:
+		h.respondWithError(c, http.StatusInternalServerError, "提示词预设操作失败", err)
+	}
+}
+
+// respondWithSuccess 返回成功响应
+func (h *PromptHandler) respondWithSuccess(c *gin.Context, statusCode int, data interface{}) {
+	c.JSON(statusCode, models.ApiResponse[interface{}]{
+		Success:   true,
+		Data:      data,
+		Message:   "请求成功",
+		RequestID: c.GetHeader("X-Request-ID"),
+		Timestamp: fmt.Sprintf("%d", c.GetInt64("timestamp")),
+	})
+}
+
+// respondWithError 返回错误响应
+func (h *PromptHandler) respondWithError(c *gin.Context, statusCode int, message string, err error) {
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"request_id": c.GetHeader("X-Request-ID"),
+			"status":     statusCode,
+			"message":    message,
+			"error":      err.Error(),
+			"path":       c.Request.URL.Path,
+			"method":     c.Request.Method,
+		}).Error("请求处理失败")
+	}
+
+	c.JSON(statusCode, models.ApiResponse[interface{}]{
+		Success:   false,
+		Data:      nil,
+		Message:   message,
+		RequestID: c.GetHeader("X-Request-ID"),
+		Timestamp: fmt.Sprintf("%d", c.GetInt64("timestamp")),
+	})
+}
+
+// RegisterRoutes 注册提示词预设路由
+func (h *PromptHandler) RegisterRoutes(r *gin.Engine) {
+	prompts := r.Group("/api/v1/prompts")
+	{
+		prompts.POST("", h.CreatePrompt)
+		prompts.GET("", h.ListPrompts)
+		prompts.GET("/:id", h.GetPrompt)
+		prompts.PUT("/:id", h.UpdatePrompt)
+		prompts.DELETE("/:id", h.DeletePrompt)
+	}
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/pkg/prompts"
+)
+
+// newTestPromptStore 创建基于内存 SQLite 的提示词预设存储
+func newTestPromptStore(t *testing.T) *prompts.Store {
+	t.Helper()
+	store := prompts.NewStore(newTestDB(t), testutil.Logger())
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("迁移提示词预设表失败: %v", err)
+	}
+	return store
+}
+
+// promptResponse 提示词预设接口的响应
+type promptResponse struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Message string          `json:"message"`
+}
+
+// doPromptRequest 以指定租户身份调用提示词预设接口
+func doPromptRequest(t *testing.T, router *gin.Engine, method, path, tenantID string, body interface{}) (int, promptResponse) {
+	t.Helper()
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if tenantID != "" {
+		req.Header.Set("X-Tenant-ID", tenantID)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	var response promptResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("响应不是JSON: %v，body = %s", err, recorder.Body.String())
+	}
+	return recorder.Code, response
+}
+
+func TestPromptCRUD(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewPromptHandler(newTestPromptStore(t), testutil.Logger()).RegisterRoutes(router)
+
+	status, response := doPromptRequest(t, router, http.MethodPost, "/api/v1/prompts", testTenantID, map[string]string{
+		"name":     "客服",
+		"template": "你是{{company}}的客服。",
+	})
+	if status != http.StatusCreated {
+		t.Fatalf("创建 status = %d，期望 %d，message = %s", status, http.StatusCreated, response.Message)
+	}
+	var created prompts.Prompt
+	json.Unmarshal(response.Data, &created)
+	if created.ID == "" || created.TenantID != testTenantID || created.Name != "客服" {
+		t.Fatalf("创建的预设 = %+v", created)
+	}
+	path := "/api/v1/prompts/" + created.ID
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		tenantID     string
+		body         interface{}
+		wantStatus   int
+		wantTemplate string
+		wantCount    int
+	}{
+		{name: "缺少租户头", method: http.MethodGet, path: "/api/v1/prompts", wantStatus: http.StatusBadRequest},
+		{name: "名称为空", method: http.MethodPost, path: "/api/v1/prompts", tenantID: testTenantID, body: map[string]string{"template": "x"}, wantStatus: http.StatusBadRequest},
+		{name: "模板标签未闭合", method: http.MethodPost, path: "/api/v1/prompts", tenantID: testTenantID, body: map[string]string{"name": "坏模板", "template": "你好{{name"}, wantStatus: http.StatusBadRequest},
+		{name: "同租户重名", method: http.MethodPost, path: "/api/v1/prompts", tenantID: testTenantID, body: map[string]string{"name": "客服", "template": "x"}, wantStatus: http.StatusConflict},
+		{name: "其他租户可使用同名", method: http.MethodPost, path: "/api/v1/prompts", tenantID: "other-tenant", body: map[string]string{"name": "客服", "template": "x"}, wantStatus: http.StatusCreated},
+		{name: "获取", method: http.MethodGet, path: path, tenantID: testTenantID, wantStatus: http.StatusOK, wantTemplate: "你是{{company}}的客服。"},
+		{name: "其他租户不可获取", method: http.MethodGet, path: path, tenantID: "other-tenant", wantStatus: http.StatusNotFound},
+		{name: "列表只包含本租户", method: http.MethodGet, path: "/api/v1/prompts", tenantID: testTenantID, wantStatus: http.StatusOK, wantCount: 1},
+		{name: "其他租户不可更新", method: http.MethodPut, path: path, tenantID: "other-tenant", body: map[string]string{"name": "客服", "template": "y"}, wantStatus: http.StatusNotFound},
+		{name: "更新", method: http.MethodPut, path: path, tenantID: testTenantID, body: map[string]string{"name": "客服", "template": "你是{{company}}的售后客服。"}, wantStatus: http.StatusOK, wantTemplate: "你是{{company}}的售后客服。"},
+		{name: "其他租户不可删除", method: http.MethodDelete, path: path, tenantID: "other-tenant", wantStatus: http.StatusNotFound},
+		{name: "删除", method: http.MethodDelete, path: path, tenantID: testTenantID, wantStatus: http.StatusOK},
+		{name: "删除后不可获取", method: http.MethodGet, path: path, tenantID: testTenantID, wantStatus: http.StatusNotFound},
+	}
+
+	// 各步骤依次作用于同一个存储
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := doPromptRequest(t, router, tt.method, tt.path, tt.tenantID, tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d，期望 %d，message = %s", status, tt.wantStatus, response.Message)
+			}
+			if tt.wantTemplate != "" {
+				var preset prompts.Prompt
+				json.Unmarshal(response.Data, &preset)
+				if preset.Template != tt.wantTemplate {
+					t.Fatalf("template = %q，期望 %q", preset.Template, tt.wantTemplate)
+				}
+			}
+			if tt.wantCount > 0 {
+				var list struct {
+					Prompts []prompts.Prompt `json:"prompts"`
+				}
+				json.Unmarshal(response.Data, &list)
+				if len(list.Prompts) != tt.wantCount {
+					t.Fatalf("预设数 = %d，期望 %d", len(list.Prompts), tt.wantCount)
+				}
+			}
+		})
+	}
+}
+
+func TestPromptStoreDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewPromptHandler(nil, testutil.Logger()).RegisterRoutes(router)
+
+	status, _ := doPromptRequest(t, router, http.MethodGet, "/api/v1/prompts", testTenantID, nil)
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d，期望 %d", status, http.StatusServiceUnavailable)
+	}
+}
+
+func TestChatResolvesPromptPreset(t *testing.T) {
+	server := newTestServer(t, nil)
+	store := newTestPromptStore(t)
+	server.manager.SetPromptStore(store)
+
+	provider := newProviderStub(t, "你好")
+	server.tenantService.SetCredentials(testTenantID, testutil.Credential("deepseek", provider.Server.URL))
+
+	preset, err := store.Create(context.Background(), testTenantID, "客服", "你是{{company}}的客服，用户说：{{message}}")
+	if err != nil {
+		t.Fatalf("创建预设失败: %v", err)
+	}
+	otherPreset, err := store.Create(context.Background(), "other-tenant", "客服", "其他租户")
+	if err != nil {
+		t.Fatalf("创建预设失败: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		promptID     string
+		config       map[string]interface{}
+		wantStatus   int
+		wantSystem   string
+		wantInResult string
+	}{
+		{
+			name:       "渲染预设作为系统消息",
+			promptID:   preset.ID,
+			config:     map[string]interface{}{"company": "Lyss"},
+			wantStatus: http.StatusOK,
+			wantSystem: "你是Lyss的客服，用户说：在吗",
+		},
+		{name: "缺少模板变量", promptID: preset.ID, wantStatus: http.StatusBadRequest, wantInResult: "company"},
+		{name: "不可引用其他租户的预设", promptID: otherPreset.ID, config: map[string]interface{}{"company": "Lyss"}, wantStatus: http.StatusBadRequest, wantInResult: "prompt_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(provider.Requests())
+			recorder := server.post("/api/v1/chat", map[string]interface{}{
+				"message":       "在吗",
+				"model":         "deepseek-chat",
+				"prompt_id":     tt.promptID,
+				"configuration": tt.config,
+			})
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d，期望 %d，body = %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				if !strings.Contains(recorder.Body.String(), tt.wantInResult) {
+					t.Fatalf("body = %s，期望指出 %s", recorder.Body.String(), tt.wantInResult)
+				}
+				if len(provider.Requests()) != before {
+					t.Fatal("预设解析失败时不应调用供应商")
+				}
+				return
+			}
+
+			requests := provider.Requests()
+			if len(requests) != before+1 {
+				t.Fatalf("供应商请求数 = %d，期望 %d", len(requests), before+1)
+			}
+			messages, _ := requests[len(requests)-1]["messages"].([]interface{})
+			if len(messages) == 0 {
+				t.Fatal("供应商请求中没有消息")
+			}
+			first, _ := messages[0].(map[string]interface{})
+			if first["role"] != "system" || first["content"] != tt.wantSystem {
+				t.Fatalf("第一条消息 = %v，期望系统消息 %q", first, tt.wantSystem)
+			}
+		})
+	}
+}
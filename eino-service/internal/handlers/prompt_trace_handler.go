@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/pkg/prompttrace"
+)
+
+// DebugTraceHeader 请求记录完整提示词与回答的HTTP头，取值为 true 或 1，需开启 logging.prompt_trace_header
+const DebugTraceHeader = "X-Debug-Trace"
+
+// 提示词记录列表默认与最大返回条数
+const (
+	defaultPromptTraceLimit = 20
+	maxPromptTraceLimit     = 200
+)
+
+// debugTraceRequested 请求是否携带了调试头
+func debugTraceRequested(c *gin.Context) bool {
+	requested, _ := strconv.ParseBool(c.GetHeader(DebugTraceHeader))
+	return requested
+}
+
+// PromptTraceHandler 采样提示词记录查询处理器，仅供内部调试使用
+type PromptTraceHandler struct {
+	recorder      *prompttrace.Recorder
+	internalToken string
+	logger        *logrus.Logger
+}
+
+// NewPromptTraceHandler 创建提示词记录查询处理器，recorder 为 nil 表示未开启提示词记录
+func NewPromptTraceHandler(recorder *prompttrace.Recorder, internalToken string, logger *logrus.Logger) *PromptTraceHandler {
+	return &PromptTraceHandler{
+		recorder:      recorder,
+		internalToken: internalToken,
+		logger:        logger,
+	}
+}
+
+// ListPromptTraces 按时间倒序列出租户最近的提示词记录，查询参数 limit 默认20，最大200
+func (h *PromptTraceHandler) ListPromptTraces(c *gin.Context) {
+	if h.recorder == nil {
+		h.respondWithError(c, http.StatusServiceUnavailable, "未开启提示词记录", nil)
+		return
+	}
+
+	limit := defaultPromptTraceLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			h.respondWithError(c, http.StatusBadRequest, "limit 必须为正整数", nil)
+			return
+		}
+		limit = min(parsed, maxPromptTraceLimit)
+	}
+
+	tenantID := c.Param("tenant_id")
+	traces, err := h.recorder.List(c.Request.Context(), tenantID, limit)
+	if err != nil {
+		h.respondWithError(c, http.StatusInternalServerError, "读取提示词记录失败", err)
+		return
+	}
+
+	h.respondWithSuccess(c, map[string]interface{}{
+		"tenant_id": tenantID,
+		"traces":    traces,
+	})
+}
+
+// respondWithSuccess 返回成功响应
+func (h *PromptTraceHandler) respondWithSuccess(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusOK, models.ApiResponse[interface{}]{
+		Success:   true,
+		Data:      data,
+		Message:   "请求成功",
+		RequestID: c.GetHeader("X-Request-ID"),
+		Timestamp: fmt.Sprintf("%d", c.GetInt64("timestamp")),
+	})
+}
+
+// respondWithError 返回错误响应
+func (h *PromptTraceHandler) respondWithError(c *gin.Context, statusCode int, message string, err error) {
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"request_id": c.GetHeader("X-Request-ID"),
+			"status":     statusCode,
+			"message":    message,
+			"error":      err.Error(),
+			"path":       c.Request.URL.Path,
+			"method":     c.Request.Method,
+		}).Error("请求处理失败")
+	}
+
+	c.JSON(statusCode, models.ApiResponse[interface{}]{
+		Success:   false,
+		Data:      nil,
+		Message:   message,
+		RequestID: c.GetHeader("X-Request-ID"),
+		Timestamp: fmt.Sprintf("%d", c.GetInt64("timestamp")),
+	})
+}
+
+// RegisterRoutes 注册提示词记录查询路由
+func (h *PromptTraceHandler) RegisterRoutes(r *gin.Engine) {
+	internal := r.Group("/internal/prompt-traces", requireInternalToken(h.internalToken, h.logger))
+	{
+		internal.GET("/:tenant_id", h.ListPromptTraces)
+	}
+}
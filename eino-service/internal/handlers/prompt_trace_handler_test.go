@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/pkg/prompttrace"
+)
+
+const traceAPIKey = "sk-abcdefghijklmnopqrstuvwxyz"
+
+// listPromptTraces 通过内部接口读取测试租户的提示词记录
+func listPromptTraces(t *testing.T, server *testServer) []*prompttrace.Trace {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/internal/prompt-traces/"+testTenantID+"?limit=50", nil)
+	req.Header.Set(InternalTokenHeader, testInternalToken)
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("读取提示词记录 status = %d，body = %s", recorder.Code, recorder.Body.String())
+	}
+
+	var response models.ApiResponse[struct {
+		Traces []*prompttrace.Trace `json:"traces"`
+	}]
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	return response.Data.Traces
+}
+
+func TestChatPromptTraceSampling(t *testing.T) {
+	const requests = 6
+
+	tests := []struct {
+		name         string
+		rate         int
+		allowHeader  bool
+		debugHeader  bool
+		wantTraces   int
+		wantReason   string
+		wantResponse string
+	}{
+		{name: "每2个请求记录1个", rate: 2, wantTraces: requests / 2, wantReason: prompttrace.ReasonSampled},
+		{name: "每3个请求记录1个", rate: 3, wantTraces: requests / 3, wantReason: prompttrace.ReasonSampled},
+		{name: "调试头请求全部记录", allowHeader: true, debugHeader: true, wantTraces: requests, wantReason: prompttrace.ReasonRequested},
+		{name: "未开启调试头时忽略", debugHeader: true, wantTraces: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			stub := newProviderStub(t, "回答 ", traceAPIKey)
+			server.tenantService.SetCredentials(testTenantID, testutil.Credential("deepseek", stub.Server.URL))
+
+			redisClient := redis.NewClient(&redis.Options{Addr: server.redis.Addr()})
+			t.Cleanup(func() { redisClient.Close() })
+			recorder := prompttrace.NewRecorder(
+				prompttrace.NewSampler(tt.rate),
+				prompttrace.NewStore(redisClient, time.Hour, 100),
+				tt.allowHeader,
+				testutil.Logger(),
+			)
+			server.manager.SetPromptTraceRecorder(recorder)
+			NewPromptTraceHandler(recorder, testInternalToken, testutil.Logger()).RegisterRoutes(server.router)
+
+			for i := 0; i < requests; i++ {
+				payload, _ := json.Marshal(map[string]interface{}{
+					"message": "我的密钥是 " + traceAPIKey,
+					"model":   "deepseek-chat",
+				})
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/chat", bytes.NewReader(payload))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("X-Tenant-ID", testTenantID)
+				req.Header.Set("X-User-ID", testUserID)
+				if tt.debugHeader {
+					req.Header.Set(DebugTraceHeader, "true")
+				}
+				response := httptest.NewRecorder()
+				server.router.ServeHTTP(response, req)
+				if response.Code != http.StatusOK {
+					t.Fatalf("status = %d，body = %s", response.Code, response.Body.String())
+				}
+			}
+
+			traces := listPromptTraces(t, server)
+			if len(traces) != tt.wantTraces {
+				t.Fatalf("%d 个请求记录了 %d 条，期望 %d 条", requests, len(traces), tt.wantTraces)
+			}
+			for _, trace := range traces {
+				if trace.Reason != tt.wantReason {
+					t.Fatalf("记录原因 = %s，期望 %s", trace.Reason, tt.wantReason)
+				}
+				if strings.Contains(trace.Message, traceAPIKey) || strings.Contains(trace.Response, traceAPIKey) {
+					t.Fatalf("记录未屏蔽密钥: message=%q response=%q", trace.Message, trace.Response)
+				}
+				if !strings.HasPrefix(trace.Response, "回答 ") || !strings.Contains(trace.Message, "我的密钥是") {
+					t.Fatalf("记录 = %+v，期望保留提示词与回答的非敏感内容", trace)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/pkg/requestid"
+)
+
+func TestRequestIDForwardedToProvider(t *testing.T) {
+	tests := []struct {
+		name      string
+		provider  string
+		path      string
+		body      map[string]interface{}
+		requestID string
+	}{
+		{
+			name:      "简单聊天",
+			provider:  "deepseek",
+			path:      "/api/v1/chat",
+			body:      map[string]interface{}{"message": "你好", "model": "deepseek-chat"},
+			requestID: "req-simple-chat",
+		},
+		{
+			name:      "EINO标准聊天流式",
+			provider:  "openai",
+			path:      "/api/v1/chat",
+			body:      visionChatRequest("图里是什么"),
+			requestID: "req-eino-stream",
+		},
+		{
+			name:      "OpenAI兼容接口",
+			provider:  "deepseek",
+			path:      "/v1/chat/completions",
+			body:      map[string]interface{}{"model": "deepseek-chat", "messages": []map[string]string{{"role": "user", "content": "你好"}}},
+			requestID: "req-openai-compatible",
+		},
+		{
+			name:     "未携带请求ID时使用生成的ID",
+			provider: "deepseek",
+			path:     "/api/v1/chat",
+			body:     map[string]interface{}{"message": "你好", "model": "deepseek-chat"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			provider := newProviderStub(t, "你好")
+			server.tenantService.SetCredentials(testTenantID, testutil.Credential(tt.provider, provider.Server.URL))
+
+			payload, _ := json.Marshal(tt.body)
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewReader(payload))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Tenant-ID", testTenantID)
+			req.Header.Set("X-User-ID", testUserID)
+			if tt.requestID != "" {
+				req.Header.Set(requestid.Header, tt.requestID)
+			}
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, req)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d，body = %s", recorder.Code, recorder.Body.String())
+			}
+
+			want := tt.requestID
+			if want == "" {
+				want = recorder.Header().Get(requestid.Header)
+				if want == "" {
+					t.Fatal("未携带请求ID时响应头应返回生成的请求ID")
+				}
+			}
+
+			headers := provider.Headers()
+			if len(headers) == 0 {
+				t.Fatal("供应商没有收到请求")
+			}
+			for i, header := range headers {
+				if got := header.Get(requestid.Header); got != want {
+					t.Fatalf("第 %d 个出站请求的 %s = %q，期望 %q", i+1, requestid.Header, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/pkg/streambuffer"
+)
+
+// streamBufferWriteTimeout 单次批量写入事件缓冲的超时时间
+const streamBufferWriteTimeout = time.Second
+
+// streamBufferQueueSize 等待写入事件缓冲的最大帧数，写入跟不上时本次流不再缓冲
+const streamBufferQueueSize = 256
+
+// streamResumePollInterval 续传时执行仍在进行，轮询缓冲中新事件的间隔
+const streamResumePollInterval = 200 * time.Millisecond
+
+// sseStream 一次流式响应的SSE输出
+// 每帧带有 <execution_id>:<seq> 格式的单调递增 id，启用事件缓冲时由后台协程批量写入缓冲供断线续传
+type sseStream struct {
+	c           *gin.Context
+	buffer      *streambuffer.Store
+	tenantID    string
+	executionID string
+	seq         int64
+	logger      *logrus.Logger
+
+	pending chan streambuffer.Event
+	flushed chan struct{}
+}
+
+// newSSEStream 创建流式响应输出，调用方在响应结束后调用 close
+func (h *WorkflowHandler) newSSEStream(c *gin.Context, tenantID, executionID string) *sseStream {
+	stream := &sseStream{
+		c:           c,
+		buffer:      h.streamBuffer,
+		tenantID:    tenantID,
+		executionID: executionID,
+		logger:      h.logger,
+	}
+	if stream.buffer != nil {
+		stream.pending = make(chan streambuffer.Event, streamBufferQueueSize)
+		stream.flushed = make(chan struct{})
+		go stream.writeBuffer(stream.pending, stream.flushed)
+	}
+	return stream
+}
+
+// send 发送一帧，final 表示流的最后一帧
+// 帧先写给客户端，再交给后台协程写入缓冲，缓冲写入不阻塞输出
+func (s *sseStream) send(frame string, final bool) {
+	s.seq++
+	event := streambuffer.Event{Seq: s.seq, Frame: frame, Final: final}
+	writeSSEFrame(s.c, s.executionID, event)
+
+	if s.pending == nil {
+		return
+	}
+	select {
+	case s.pending <- event:
+	default:
+		// 丢弃任意一帧都会让缓冲出现缺口，直接停止缓冲，续传的客户端会在缓冲过期后收到重新同步通知
+		s.logger.WithFields(logrus.Fields{
+			"execution_id": s.executionID,
+			"tenant_id":    s.tenantID,
+			"operation":    "stream_buffer_failed",
+		}).Warn("流式事件缓冲写入积压，本次响应不支持断线续传")
+		close(s.pending)
+		s.pending = nil
+	}
+}
+
+// close 停止接收新帧并等待已排队的帧写入缓冲
+func (s *sseStream) close() {
+	if s.pending != nil {
+		close(s.pending)
+		s.pending = nil
+	}
+	if s.flushed != nil {
+		<-s.flushed
+		s.flushed = nil
+	}
+}
+
+// writeBuffer 将排队的帧批量写入事件缓冲，每批包含当前已排队的全部帧
+// 写入失败时记录日志，本次流的后续帧不再写入缓冲
+func (s *sseStream) writeBuffer(pending <-chan streambuffer.Event, flushed chan<- struct{}) {
+	defer close(flushed)
+
+	failed := false
+	for event := range pending {
+		batch := []streambuffer.Event{event}
+	drain:
+		for {
+			select {
+			case next, ok := <-pending:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+		if failed {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), streamBufferWriteTimeout)
+		err := s.buffer.Append(ctx, s.tenantID, s.executionID, batch...)
+		cancel()
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"execution_id": s.executionID,
+				"tenant_id":    s.tenantID,
+				"operation":    "stream_buffer_failed",
+				"error":        err.Error(),
+			}).Warn("写入流式事件缓冲失败，本次响应不支持断线续传")
+			failed = true
+		}
+	}
+}
+
+// writeSSEFrame 写出带 id 的SSE帧
+func writeSSEFrame(c *gin.Context, executionID string, event streambuffer.Event) {
+	c.Writer.WriteString("id: " + streambuffer.FormatID(executionID, event.Seq) + "\n" + event.Frame)
+	c.Writer.Flush()
+}
+
+// SetStreamBuffer 设置流式事件缓冲，设置后流式响应支持携带 Last-Event-ID 断线续传
+// grace 为客户端断开后等待重连续传的时间，期间执行继续并写入缓冲，为0时断开即取消执行
+func (h *WorkflowHandler) SetStreamBuffer(buffer *streambuffer.Store, grace time.Duration) {
+	h.streamBuffer = buffer
+	h.streamResumeGrace = grace
+}
+
+// streamContext 流式执行使用的上下文，调用方在响应结束后调用返回的 cancel
+// 启用事件缓冲时客户端断开不会立即取消执行：等待 streamResumeGrace，
+// 期间有客户端携带 Last-Event-ID 续传则继续执行，否则取消
+func (h *WorkflowHandler) streamContext(c *gin.Context, tenantID, executionID string) (context.Context, context.CancelFunc) {
+	requestCtx := c.Request.Context()
+	if h.streamBuffer == nil || h.streamResumeGrace <= 0 {
+		return context.WithCancel(requestCtx)
+	}
+	ctx, cancel := context.WithCancel(context.WithoutCancel(requestCtx))
+	go h.cancelDetachedStream(ctx, requestCtx, cancel, tenantID, executionID)
+	return ctx, cancel
+}
+
+// cancelDetachedStream 客户端断开后每隔 streamResumeGrace 检查一次续传标记，没有客户端续传时取消执行
+func (h *WorkflowHandler) cancelDetachedStream(ctx, requestCtx context.Context, cancel context.CancelFunc, tenantID, executionID string) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-requestCtx.Done():
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	logger := h.logger.WithFields(logrus.Fields{
+		"execution_id": executionID,
+		"tenant_id":    tenantID,
+		"operation":    "stream_detached",
+	})
+	logger.WithField("grace", h.streamResumeGrace.String()).Info("客户端断开流式连接，等待续传")
+
+	timer := time.NewTimer(h.streamResumeGrace)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		attached, err := h.streamBuffer.Attached(ctx, tenantID, executionID)
+		if err != nil {
+			logger.WithError(err).Warn("读取流式续传标记失败，取消执行")
+			cancel()
+			return
+		}
+		if !attached {
+			logger.Info("等待期内没有客户端续传，取消执行")
+			cancel()
+			return
+		}
+		timer.Reset(h.streamResumeGrace)
+	}
+}
+
+// resumeRequested 请求是否携带 Last-Event-ID 且已启用事件缓冲
+func (h *WorkflowHandler) resumeRequested(c *gin.Context) bool {
+	return h.streamBuffer != nil && c.GetHeader("Last-Event-ID") != ""
+}
+
+// resumeStream 按 Last-Event-ID 从事件缓冲续传流式响应
+// 执行仍在进行时轮询缓冲直到最后一帧；缓冲已过期或 id 无效时调用 resync 通知客户端重新发起请求
+func (h *WorkflowHandler) resumeStream(c *gin.Context, tenantID string, resync func()) {
+	lastEventID := c.GetHeader("Last-Event-ID")
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	logger := h.logger.WithFields(logrus.Fields{
+		"tenant_id":     tenantID,
+		"last_event_id": lastEventID,
+		"operation":     "stream_resume",
+	})
+
+	executionID, seq, err := streambuffer.ParseID(lastEventID)
+	if err != nil {
+		logger.WithError(err).Warn("无法解析 Last-Event-ID，通知客户端重新同步")
+		resync()
+		return
+	}
+	logger.Info("按 Last-Event-ID 续传流式响应")
+
+	ctx := c.Request.Context()
+
+	// 续传期间定期刷新续传标记，原连接的执行据此在等待期后继续运行
+	var attach <-chan time.Time
+	if h.streamResumeGrace > 0 {
+		h.attachStream(ctx, logger, tenantID, executionID)
+		attachTicker := time.NewTicker(h.streamResumeGrace / 2)
+		defer attachTicker.Stop()
+		attach = attachTicker.C
+	}
+
+	keepalive := newKeepaliveTicker(h.streamKeepaliveInterval)
+	defer keepalive.Stop()
+	poll := time.NewTicker(streamResumePollInterval)
+	defer poll.Stop()
+
+	for {
+		events, err := h.streamBuffer.After(ctx, tenantID, executionID, seq)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if !errors.Is(err, streambuffer.ErrExpired) {
+				logger.WithError(err).Warn("读取流式事件缓冲失败，通知客户端重新同步")
+			} else {
+				logger.Info("流式事件缓冲已过期，通知客户端重新同步")
+			}
+			resync()
+			return
+		}
+
+		for _, event := range events {
+			writeSSEFrame(c, executionID, event)
+			seq = event.Seq
+			if event.Final {
+				return
+			}
+		}
+		if len(events) > 0 {
+			keepalive.Reset()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepalive.C():
+			h.sendSSEKeepalive(c)
+		case <-attach:
+			h.attachStream(ctx, logger, tenantID, executionID)
+		case <-poll.C:
+		}
+	}
+}
+
+// attachStream 刷新续传标记，失败时仅记录日志
+func (h *WorkflowHandler) attachStream(ctx context.Context, logger *logrus.Entry, tenantID, executionID string) {
+	if err := h.streamBuffer.Attach(ctx, tenantID, executionID, h.streamResumeGrace); err != nil && ctx.Err() == nil {
+		logger.WithError(err).Warn("刷新流式续传标记失败")
+	}
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/pkg/streambuffer"
+)
+
+// enableStreamBuffer 为测试服务启用基于 miniredis 的事件缓冲
+func enableStreamBuffer(t *testing.T, server *testServer, maxEvents int, grace time.Duration) *streambuffer.Store {
+	t.Helper()
+	redisClient := redis.NewClient(&redis.Options{Addr: server.redis.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+
+	buffer := streambuffer.NewStore(redisClient, time.Minute, maxEvents)
+	server.handler.SetStreamBuffer(buffer, grace)
+	return buffer
+}
+
+// postResume 携带 Last-Event-ID 重新发送请求
+func (s *testServer) postResume(path string, body interface{}, lastEventID string) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tenant-ID", testTenantID)
+	req.Header.Set("X-User-ID", testUserID)
+	req.Header.Set("Last-Event-ID", lastEventID)
+
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// streamChat 发送流式聊天请求并返回解析后的事件
+func streamChat(t *testing.T, server *testServer, chunks ...string) []sseEvent {
+	t.Helper()
+	provider := newProviderStub(t, chunks...)
+	server.tenantService.SetCredentials(testTenantID, testutil.Credential("openai", provider.Server.URL))
+
+	recorder := server.post("/api/v1/chat", visionChatRequest("图里是什么"))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d，body = %s", recorder.Code, recorder.Body.String())
+	}
+	return sseEvents(t, recorder.Body.String())
+}
+
+func TestStreamEventsCarryExecutionIDs(t *testing.T) {
+	server := newTestServer(t, nil)
+	buffer := enableStreamBuffer(t, server, 0, time.Minute)
+
+	events := streamChat(t, server, "一", "二", "三")
+	if len(events) == 0 {
+		t.Fatal("事件流为空")
+	}
+
+	executionID, _, err := streambuffer.ParseID(events[0].ID)
+	if err != nil {
+		t.Fatalf("首个事件 id = %q: %v", events[0].ID, err)
+	}
+	for i, event := range events {
+		if want := streambuffer.FormatID(executionID, int64(i+1)); event.ID != want {
+			t.Fatalf("第 %d 个事件 id = %q，期望 %q", i, event.ID, want)
+		}
+	}
+
+	// 响应结束前已排队的帧全部写入缓冲
+	buffered, err := buffer.After(context.Background(), testTenantID, executionID, 0)
+	if err != nil {
+		t.Fatalf("读取缓冲: %v", err)
+	}
+	if len(buffered) != len(events) || !buffered[len(buffered)-1].Final {
+		t.Fatalf("缓冲事件数 = %d，期望 %d 且最后一帧为终止帧", len(buffered), len(events))
+	}
+}
+
+func TestStreamResumeAfterLastEventID(t *testing.T) {
+	server := newTestServer(t, nil)
+	enableStreamBuffer(t, server, 0, time.Minute)
+
+	events := streamChat(t, server, "一", "二", "三")
+	if len(events) < 4 {
+		t.Fatalf("事件数 = %d，至少需要 4 个", len(events))
+	}
+
+	for _, resumeFrom := range []int{0, 2, len(events) - 2} {
+		recorder := server.postResume("/api/v1/chat", visionChatRequest("图里是什么"), events[resumeFrom].ID)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("status = %d，body = %s", recorder.Code, recorder.Body.String())
+		}
+
+		resumed := sseEvents(t, recorder.Body.String())
+		want := events[resumeFrom+1:]
+		if len(resumed) != len(want) {
+			t.Fatalf("从 %s 续传的事件数 = %d，期望 %d，body = %s", events[resumeFrom].ID, len(resumed), len(want), recorder.Body.String())
+		}
+		for i := range want {
+			if resumed[i] != want[i] {
+				t.Fatalf("续传的第 %d 个事件 = %+v，期望 %+v", i, resumed[i], want[i])
+			}
+		}
+	}
+}
+
+func TestStreamResumeResyncsWhenBufferTrimmed(t *testing.T) {
+	server := newTestServer(t, nil)
+	enableStreamBuffer(t, server, 2, time.Minute)
+
+	events := streamChat(t, server, "一", "二", "三")
+
+	tests := []struct {
+		name        string
+		lastEventID string
+	}{
+		{name: "断点之后的事件已被淘汰", lastEventID: events[0].ID},
+		{name: "未知执行", lastEventID: streambuffer.FormatID("unknown-execution", 1)},
+		{name: "无效的id", lastEventID: "not-an-id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := server.postResume("/api/v1/chat", visionChatRequest("图里是什么"), tt.lastEventID)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d，body = %s", recorder.Code, recorder.Body.String())
+			}
+
+			resumed := sseEvents(t, recorder.Body.String())
+			if len(resumed) != 2 || resumed[0].Event != "resync" || resumed[1].Data != "[DONE]" {
+				t.Fatalf("事件流 = %+v，期望 resync 事件后接 [DONE]", resumed)
+			}
+			var data map[string]interface{}
+			if err := json.Unmarshal([]byte(resumed[0].Data), &data); err != nil {
+				t.Fatalf("resync 事件不是JSON: %v", err)
+			}
+			if data["last_event_id"] != tt.lastEventID {
+				t.Fatalf("last_event_id = %v，期望 %q", data["last_event_id"], tt.lastEventID)
+			}
+		})
+	}
+}
+
+func TestChatCompletionsResumeStreamExpired(t *testing.T) {
+	server := newTestServer(t, nil)
+	enableStreamBuffer(t, server, 2, time.Minute)
+
+	provider := newProviderStub(t, "你好", "，世界")
+	server.tenantService.SetCredentials(testTenantID, testutil.Credential("deepseek", provider.Server.URL))
+	body := map[string]interface{}{
+		"model":    "deepseek-chat",
+		"stream":   true,
+		"messages": []map[string]string{{"role": "user", "content": "打个招呼"}},
+	}
+
+	recorder := server.post("/v1/chat/completions", body)
+	events := sseEvents(t, recorder.Body.String())
+	if len(events) < 3 {
+		t.Fatalf("事件数 = %d，body = %s", len(events), recorder.Body.String())
+	}
+
+	// 缓冲只保留最后两帧，从倒数第三帧续传可以完整回放
+	recorder = server.postResume("/v1/chat/completions", body, events[len(events)-3].ID)
+	if resumed := sseEvents(t, recorder.Body.String()); len(resumed) != 2 || resumed[1].Data != "[DONE]" {
+		t.Fatalf("续传事件流 = %+v，期望回放最后两帧", resumed)
+	}
+
+	recorder = server.postResume("/v1/chat/completions", body, events[0].ID)
+	data := sseData(t, recorder.Body.String())
+	if len(data) != 1 {
+		t.Fatalf("事件流 = %q，期望只有一个错误帧", data)
+	}
+	var response map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(data[0]), &response); err != nil {
+		t.Fatalf("错误帧不是JSON: %v", err)
+	}
+	if response["error"]["type"] != "stream_expired" {
+		t.Fatalf("error.type = %v，期望 stream_expired", response["error"]["type"])
+	}
+	if requests := len(provider.Requests()); requests != 1 {
+		t.Fatalf("供应商收到 %d 个请求，续传不应重新执行", requests)
+	}
+}
+
+func TestStreamContextAfterClientDisconnect(t *testing.T) {
+	const grace = 50 * time.Millisecond
+
+	tests := []struct {
+		name       string
+		grace      time.Duration
+		attach     bool
+		wantCancel bool
+	}{
+		{name: "等待期内没有续传", grace: grace, wantCancel: true},
+		{name: "等待期内有客户端续传", grace: grace, attach: true, wantCancel: false},
+		{name: "未设置等待期", grace: 0, wantCancel: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			buffer := enableStreamBuffer(t, server, 0, tt.grace)
+			if tt.attach {
+				if err := buffer.Attach(context.Background(), testTenantID, "exec-1", time.Minute); err != nil {
+					t.Fatalf("Attach: %v", err)
+				}
+			}
+
+			requestCtx, disconnect := context.WithCancel(context.Background())
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/chat", strings.NewReader("{}")).WithContext(requestCtx)
+
+			ctx, cancel := server.handler.streamContext(c, testTenantID, "exec-1")
+			defer cancel()
+			disconnect()
+
+			select {
+			case <-ctx.Done():
+				if !tt.wantCancel {
+					t.Fatal("有客户端续传时执行被取消")
+				}
+			case <-time.After(4 * grace):
+				if tt.wantCancel {
+					t.Fatal("客户端断开后执行未被取消")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/pkg/tracing"
+)
+
+func TestChatSpanChain(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	server := newTestServer(t, nil)
+	stub := newProviderStub(t, "你好")
+	server.tenantService.SetCredentials(testTenantID, testutil.Credential("deepseek", stub.Server.URL))
+
+	router := gin.New()
+	router.Use(tracing.Middleware())
+	server.handler.RegisterRoutes(router)
+
+	payload, _ := json.Marshal(map[string]interface{}{"message": "你好", "model": "deepseek-chat"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/chat", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tenant-ID", testTenantID)
+	req.Header.Set("X-User-ID", testUserID)
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, req)
+	if response.Code != http.StatusOK {
+		t.Fatalf("status = %d，body = %s", response.Code, response.Body.String())
+	}
+
+	byName := make(map[string]sdktrace.ReadOnlySpan)
+	var providerSpan sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		byName[span.Name()] = span
+		for _, attr := range span.Attributes() {
+			if attr.Key == "url.path" && strings.HasSuffix(attr.Value.AsString(), "/chat/completions") {
+				providerSpan = span
+			}
+		}
+	}
+	handlerSpan, workflowSpan := byName["POST /api/v1/chat"], byName["workflow.execute"]
+	if handlerSpan == nil || workflowSpan == nil || providerSpan == nil {
+		t.Fatalf("span = %v，期望包含处理器、工作流与供应商调用", byName)
+	}
+
+	tests := []struct {
+		name   string
+		child  sdktrace.ReadOnlySpan
+		parent sdktrace.ReadOnlySpan
+	}{
+		{name: "工作流执行属于处理器请求", child: workflowSpan, parent: handlerSpan},
+		{name: "供应商调用属于工作流执行", child: providerSpan, parent: workflowSpan},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.child.Parent().SpanID() != tt.parent.SpanContext().SpanID() {
+				t.Fatalf("%s 的父 span = %v，期望 %s(%v)", tt.child.Name(), tt.child.Parent().SpanID(), tt.parent.Name(), tt.parent.SpanContext().SpanID())
+			}
+			if tt.child.SpanContext().TraceID() != handlerSpan.SpanContext().TraceID() {
+				t.Fatalf("%s 不在同一条追踪中", tt.child.Name())
+			}
+		})
+	}
+
+	attributes := make(map[string]string)
+	for _, attr := range workflowSpan.Attributes() {
+		attributes[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attributes[string(tracing.TenantIDKey)] != testTenantID || attributes[string(tracing.UserIDKey)] != testUserID {
+		t.Fatalf("工作流 span 属性 = %v，期望包含租户与用户", attributes)
+	}
+	if got := stub.Headers()[0].Get("traceparent"); !strings.Contains(got, providerSpan.SpanContext().SpanID().String()) {
+		t.Fatalf("供应商收到的 traceparent = %q，期望携带客户端 span", got)
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/pkg/audit"
+)
+
+// newTestDB 打开内存 SQLite 数据库，单连接保证各查询访问同一个库
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatalf("打开内存数据库失败: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// newTestAuditStore 创建基于内存 SQLite 的审计存储
+func newTestAuditStore(t *testing.T) (*audit.Store, *gorm.DB) {
+	t.Helper()
+	db := newTestDB(t)
+	store := audit.NewStore(db, testutil.Logger())
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("迁移审计表失败: %v", err)
+	}
+	return store, db
+}
+
+func TestSuccessfulChatWritesOneAuditRow(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		body     map[string]interface{}
+		failing  bool
+		wantRows int64
+		wantCost float64
+	}{
+		{
+			name:     "非流式聊天",
+			provider: "deepseek",
+			body:     map[string]interface{}{"message": "你好", "model": "deepseek-chat"},
+			wantRows: 1,
+			// config.yaml 中 deepseek-chat 的单价：7 个提示令牌 × 0.00027/1K + 3 个补全令牌 × 0.0011/1K
+			wantCost: 0.00000519,
+		},
+		{
+			name:     "流式聊天",
+			provider: "openai",
+			body:     visionChatRequest("图里是什么"),
+			wantRows: 1,
+			// config.yaml 中 gpt-4o 的单价：7 个提示令牌 × 0.0025/1K + 3 个补全令牌 × 0.01/1K
+			wantCost: 0.0000475,
+		},
+		{
+			name:     "供应商失败",
+			provider: "deepseek",
+			body:     map[string]interface{}{"message": "你好", "model": "deepseek-chat"},
+			failing:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			store, db := newTestAuditStore(t)
+			server.manager.SetUsageAuditStore(store)
+
+			baseURL := newProviderStub(t, "你好", "！").Server.URL
+			if tt.failing {
+				failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					http.Error(w, `{"error":{"message":"invalid request"}}`, http.StatusBadRequest)
+				}))
+				t.Cleanup(failing.Close)
+				baseURL = failing.URL
+			}
+			credential := testutil.Credential(tt.provider, baseURL)
+			server.tenantService.SetCredentials(testTenantID, credential)
+
+			start := time.Now().Add(-time.Minute)
+			server.post("/api/v1/chat", tt.body)
+
+			var records []audit.UsageRecord
+			if err := db.Find(&records).Error; err != nil {
+				t.Fatalf("查询审计记录失败: %v", err)
+			}
+			if int64(len(records)) != tt.wantRows {
+				t.Fatalf("审计记录数 = %d，期望 %d", len(records), tt.wantRows)
+			}
+			if tt.wantRows == 0 {
+				return
+			}
+
+			record := records[0]
+			if record.TenantID != testTenantID || record.CredentialID != credential.ID.String() || record.Provider != tt.provider {
+				t.Fatalf("审计记录 = %+v，期望租户 %s、凭证 %s、供应商 %s", record, testTenantID, credential.ID, tt.provider)
+			}
+			if record.TotalTokens != 10 || record.RequestID == "" || record.ExecutionID == "" {
+				t.Fatalf("审计记录 = %+v，期望包含令牌用量与请求、执行ID", record)
+			}
+			if math.Abs(record.Cost-tt.wantCost) > 1e-12 {
+				t.Fatalf("审计记录费用 = %g，期望 %g", record.Cost, tt.wantCost)
+			}
+
+			totals, err := store.CredentialTotals(context.Background(), credential.ID.String(), start, time.Now().Add(time.Minute))
+			if err != nil {
+				t.Fatalf("CredentialTotals: %v", err)
+			}
+			if totals.Requests != 1 || totals.TotalTokens != 10 || math.Abs(totals.Cost-tt.wantCost) > 1e-12 {
+				t.Fatalf("凭证用量合计 = %+v，期望 1 次请求、10 个令牌、费用 %g", totals, tt.wantCost)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/pkg/audit"
+)
+
+// 未指定 from 时报表覆盖的天数
+const defaultUsageReportDays = 30
+
+// UsageHandler 租户用量报表处理器
+type UsageHandler struct {
+	usageAudit *audit.Store
+	pricing    audit.Pricing
+	logger     *logrus.Logger
+}
+
+// NewUsageHandler 创建租户用量报表处理器，usageAudit 为 nil 表示未开启凭证使用审计
+func NewUsageHandler(usageAudit *audit.Store, pricing audit.Pricing, logger *logrus.Logger) *UsageHandler {
+	return &UsageHandler{
+		usageAudit: usageAudit,
+		pricing:    pricing,
+		logger:     logger,
+	}
+}
+
+// GetUsageReport 按供应商与模型汇总租户的令牌用量与费用
+// 查询参数：from/to 为 RFC3339 时间或 2006-01-02 日期（to 为日期时包含当天），group_by=day 按天分组，format=csv 返回 CSV
+func (h *UsageHandler) GetUsageReport(c *gin.Context) {
+	tenantID := c.GetHeader("X-Tenant-ID")
+	if tenantID == "" {
+		h.respondWithError(c, http.StatusBadRequest, "缺少租户信息", nil)
+		return
+	}
+	if requested := c.Query("tenant_id"); requested != "" && requested != tenantID {
+		h.respondWithError(c, http.StatusForbidden, "无权查看其他租户的用量", nil)
+		return
+	}
+	if h.usageAudit == nil {
+		h.respondWithError(c, http.StatusServiceUnavailable, "未开启凭证使用审计", nil)
+		return
+	}
+
+	from, to, err := parseUsageRange(c.Query("from"), c.Query("to"), time.Now().UTC())
+	if err != nil {
+		h.respondWithError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	groupBy := c.Query("group_by")
+	if groupBy != "" && groupBy != "day" {
+		h.respondWithError(c, http.StatusBadRequest, "group_by 仅支持 day", nil)
+		return
+	}
+	groupByDay := groupBy == "day"
+
+	groups, err := h.usageAudit.TenantUsage(c.Request.Context(), tenantID, from, to, groupByDay)
+	if err != nil {
+		h.respondWithError(c, http.StatusInternalServerError, "统计租户用量失败", err)
+		return
+	}
+	report := audit.BuildUsageReport(tenantID, from, to, groupByDay, groups, h.pricing)
+
+	h.logger.WithFields(logrus.Fields{
+		"request_id":   c.GetHeader("X-Request-ID"),
+		"tenant_id":    tenantID,
+		"from":         from,
+		"to":           to,
+		"group_by_day": groupByDay,
+		"groups":       len(report.Groups),
+		"operation":    "usage_report",
+	}).Info("返回租户用量报表")
+
+	if c.Query("format") == "csv" {
+		filename := fmt.Sprintf("usage_%s_%s_%s.csv", tenantID, from.Format("20060102"), to.Format("20060102"))
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Status(http.StatusOK)
+		if err := report.WriteCSV(c.Writer); err != nil {
+			h.logger.WithError(err).WithField("tenant_id", tenantID).Error("输出用量报表CSV失败")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse[*audit.UsageReport]{
+		Success:   true,
+		Data:      report,
+		Message:   "请求成功",
+		RequestID: c.GetHeader("X-Request-ID"),
+		Timestamp: fmt.Sprintf("%d", c.GetInt64("timestamp")),
+	})
+}
+
+// parseUsageRange 解析报表时间范围 [from, to)，默认截至当前时间的最近30天
+func parseUsageRange(fromValue, toValue string, now time.Time) (time.Time, time.Time, error) {
+	to := now
+	if toValue != "" {
+		parsed, dateOnly, err := parseUsageTime(toValue)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("to 参数格式无效: %s", toValue)
+		}
+		to = parsed
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+	}
+
+	from := to.AddDate(0, 0, -defaultUsageReportDays)
+	if fromValue != "" {
+		parsed, _, err := parseUsageTime(fromValue)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("from 参数格式无效: %s", fromValue)
+		}
+		from = parsed
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from 必须早于 to")
+	}
+	return from, to, nil
+}
+
+// parseUsageTime 解析 RFC3339 时间或 UTC 日期，dateOnly 表示输入为日期
+func parseUsageTime(value string) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if parsed, err := time.Parse("2006-01-02", value); err == nil {
+		return parsed, true, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return parsed.UTC(), false, nil
+}
+
+// respondWithError 返回错误响应
+func (h *UsageHandler) respondWithError(c *gin.Context, statusCode int, message string, err error) {
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"request_id": c.GetHeader("X-Request-ID"),
+			"status":     statusCode,
+			"message":    message,
+			"error":      err.Error(),
+			"path":       c.Request.URL.Path,
+			"method":     c.Request.Method,
+		}).Error("请求处理失败")
+	}
+
+	c.JSON(statusCode, models.ApiResponse[interface{}]{
+		Success:   false,
+		Data:      nil,
+		Message:   message,
+		RequestID: c.GetHeader("X-Request-ID"),
+		Timestamp: fmt.Sprintf("%d", c.GetInt64("timestamp")),
+	})
+}
+
+// RegisterRoutes 注册用量报表路由
+func (h *UsageHandler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/api/v1/usage", h.GetUsageReport)
+}
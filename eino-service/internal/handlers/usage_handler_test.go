@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/pkg/audit"
+)
+
+func TestGetUsageReport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store, _ := newTestAuditStore(t)
+	createdAt := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	for _, tenantID := range []string{testTenantID, "other-tenant"} {
+		err := store.Record(context.Background(), &audit.UsageRecord{
+			TenantID: tenantID, CredentialID: "credential-1", Provider: "deepseek", Model: "deepseek-chat",
+			PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5, CreatedAt: createdAt,
+		})
+		if err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		store      *audit.Store
+		tenantID   string
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "缺少租户头", store: store, query: "from=2026-03-01&to=2026-03-01", wantStatus: http.StatusBadRequest},
+		{name: "禁止查看其他租户", store: store, tenantID: testTenantID, query: "tenant_id=other-tenant", wantStatus: http.StatusForbidden},
+		{name: "未开启审计", tenantID: testTenantID, wantStatus: http.StatusServiceUnavailable},
+		{name: "时间范围无效", store: store, tenantID: testTenantID, query: "from=2026-03-02&to=2026-03-01", wantStatus: http.StatusBadRequest},
+		{name: "分组方式无效", store: store, tenantID: testTenantID, query: "group_by=week", wantStatus: http.StatusBadRequest},
+		{
+			name: "JSON只包含本租户", store: store, tenantID: testTenantID, query: "from=2026-03-01&to=2026-03-01",
+			wantStatus: http.StatusOK, wantBody: `"requests":1`,
+		},
+		{
+			name: "CSV", store: store, tenantID: testTenantID, query: "from=2026-03-01&to=2026-03-01&format=csv",
+			wantStatus: http.StatusOK,
+			wantBody:   "provider,model,requests,prompt_tokens,completion_tokens,total_tokens,cost\ndeepseek,deepseek-chat,1,3,2,5,0.000000\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			NewUsageHandler(tt.store, audit.NewPricing(nil), testutil.Logger()).RegisterRoutes(router)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/usage?"+tt.query, nil)
+			if tt.tenantID != "" {
+				req.Header.Set("X-Tenant-ID", tt.tenantID)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d，期望 %d，body = %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if !strings.Contains(recorder.Body.String(), tt.wantBody) {
+				t.Fatalf("body = %s，期望包含 %s", recorder.Body.String(), tt.wantBody)
+			}
+			if strings.Contains(tt.query, "format=csv") && !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/csv") {
+				t.Fatalf("Content-Type = %s，期望 text/csv", recorder.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestParseUsageRange(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		wantFrom time.Time
+		wantTo   time.Time
+		wantErr  bool
+	}{
+		{name: "默认最近30天", wantFrom: now.AddDate(0, 0, -30), wantTo: now},
+		{name: "日期包含结束当天", from: "2026-03-01", to: "2026-03-02", wantFrom: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), wantTo: time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{name: "RFC3339转换为UTC", from: "2026-03-01T08:00:00+08:00", to: "2026-03-01T12:00:00Z", wantFrom: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), wantTo: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{name: "格式无效", from: "yesterday", wantErr: true},
+		{name: "from晚于to", from: "2026-03-05", to: "2026-03-01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := parseUsageRange(tt.from, tt.to, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("期望返回错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseUsageRange: %v", err)
+			}
+			if !from.Equal(tt.wantFrom) || !to.Equal(tt.wantTo) {
+				t.Fatalf("范围 = [%v, %v)，期望 [%v, %v)", from, to, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
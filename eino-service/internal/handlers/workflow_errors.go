@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"lyss-ai-platform/eino-service/internal/workflows"
+	"lyss-ai-platform/eino-service/pkg/featureflag"
+	"lyss-ai-platform/eino-service/pkg/modelalias"
+	"lyss-ai-platform/eino-service/pkg/quota"
+)
+
+// statusForWorkflowError 将工作流执行错误映射为HTTP状态码与错误说明
+// 工作流接口与OpenAI兼容接口共用，新增的错误类型只需在此处添加
+func statusForWorkflowError(err error) (int, string) {
+	switch {
+	case errors.Is(err, quota.ErrQuotaExceeded):
+		return http.StatusPaymentRequired, "租户本月配额已用尽"
+	case errors.Is(err, workflows.ErrPayloadTooLarge):
+		return http.StatusRequestEntityTooLarge, "请求内容超出长度限制"
+	case errors.Is(err, workflows.ErrInvalidParameters):
+		return http.StatusBadRequest, "请求参数不符合工作流定义"
+	case errors.Is(err, modelalias.ErrUnknownModel):
+		return http.StatusBadRequest, "未知的模型"
+	case errors.Is(err, featureflag.ErrFeatureDisabled):
+		return http.StatusForbidden, "租户未开启该功能"
+	case errors.Is(err, workflows.ErrConcurrencyLimit):
+		return http.StatusTooManyRequests, "当前执行的工作流过多，请稍后重试"
+	case errors.Is(err, workflows.ErrShuttingDown):
+		return http.StatusServiceUnavailable, "服务正在关闭，请稍后重试"
+	case errors.Is(err, workflows.ErrExecutionTimeout):
+		return http.StatusGatewayTimeout, "工作流执行超时"
+	case errors.Is(err, workflows.ErrClientCanceled):
+		return statusClientClosedRequest, "客户端已取消请求"
+	case errors.Is(err, workflows.ErrExecutionCancelled):
+		return http.StatusConflict, "工作流执行已被取消"
+	default:
+		return http.StatusInternalServerError, "工作流执行失败"
+	}
+}
+
+// openAIErrorType 按状态码确定OpenAI兼容错误的 type
+func openAIErrorType(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusRequestEntityTooLarge:
+		return "invalid_request_error"
+	case http.StatusPaymentRequired:
+		return "insufficient_quota"
+	case http.StatusForbidden:
+		return "permission_error"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case http.StatusGatewayTimeout:
+		return "timeout_error"
+	case http.StatusConflict, statusClientClosedRequest:
+		return "cancelled"
+	default:
+		return "server_error"
+	}
+}
+
+// respondWithWorkflowError 返回工作流执行错误，超限、参数错误与未知模型附带详情
+func (h *WorkflowHandler) respondWithWorkflowError(c *gin.Context, err error) {
+	statusCode, message := statusForWorkflowError(err)
+
+	var tooLarge *workflows.PayloadTooLargeError
+	if errors.As(err, &tooLarge) {
+		h.respondWithPayloadTooLarge(c, statusCode, message, tooLarge)
+		return
+	}
+	var invalid *workflows.InvalidParametersError
+	if errors.As(err, &invalid) {
+		h.respondWithInvalidParameters(c, statusCode, message, invalid)
+		return
+	}
+	var unknown *modelalias.UnknownModelError
+	if errors.As(err, &unknown) {
+		h.respondWithUnknownModel(c, statusCode, message, unknown)
+		return
+	}
+
+	h.respondWithError(c, statusCode, message, err)
+}
+
+// respondWithOpenAIWorkflowError 以OpenAI错误格式返回工作流执行错误
+func (h *WorkflowHandler) respondWithOpenAIWorkflowError(c *gin.Context, err error) {
+	statusCode, message := statusForWorkflowError(err)
+	// 与OpenAI一致，未知模型返回404
+	if errors.Is(err, modelalias.ErrUnknownModel) {
+		statusCode = http.StatusNotFound
+	}
+	if statusCode == http.StatusInternalServerError {
+		h.respondWithOpenAIError(c, statusCode, openAIErrorType(statusCode), fmt.Sprintf("%s: %v", message, err))
+		return
+	}
+	h.respondWithOpenAIError(c, statusCode, openAIErrorType(statusCode), err.Error())
+}
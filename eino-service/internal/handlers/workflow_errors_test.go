@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/workflows"
+	"lyss-ai-platform/eino-service/pkg/featureflag"
+	"lyss-ai-platform/eino-service/pkg/modelalias"
+	"lyss-ai-platform/eino-service/pkg/quota"
+)
+
+func TestStatusForWorkflowError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantType   string
+	}{
+		{name: "配额用尽", err: fmt.Errorf("%w: 已使用 100 / 100", quota.ErrQuotaExceeded), wantStatus: http.StatusPaymentRequired, wantType: "insufficient_quota"},
+		{name: "请求内容超限", err: &workflows.PayloadTooLargeError{Limit: "bytes", Actual: 11, Max: 10}, wantStatus: http.StatusRequestEntityTooLarge, wantType: "invalid_request_error"},
+		{name: "参数不符合定义", err: &workflows.InvalidParametersError{WorkflowType: "simple_chat", Missing: []string{"message"}}, wantStatus: http.StatusBadRequest, wantType: "invalid_request_error"},
+		{name: "未知模型", err: &modelalias.UnknownModelError{Name: "gpt-5", Known: []string{"gpt-4"}}, wantStatus: http.StatusBadRequest, wantType: "invalid_request_error"},
+		{name: "功能未开启", err: fmt.Errorf("%w: json_mode", featureflag.ErrFeatureDisabled), wantStatus: http.StatusForbidden, wantType: "permission_error"},
+		{name: "并发上限", err: workflows.ErrConcurrencyLimit, wantStatus: http.StatusTooManyRequests, wantType: "rate_limit_error"},
+		{name: "包装后的并发上限", err: fmt.Errorf("执行失败: %w", workflows.ErrConcurrencyLimit), wantStatus: http.StatusTooManyRequests, wantType: "rate_limit_error"},
+		{name: "服务关闭", err: workflows.ErrShuttingDown, wantStatus: http.StatusServiceUnavailable, wantType: "server_error"},
+		{name: "执行超时", err: workflows.ErrExecutionTimeout, wantStatus: http.StatusGatewayTimeout, wantType: "timeout_error"},
+		{name: "客户端取消", err: workflows.ErrClientCanceled, wantStatus: statusClientClosedRequest, wantType: "cancelled"},
+		{name: "执行被取消", err: workflows.ErrExecutionCancelled, wantStatus: http.StatusConflict, wantType: "cancelled"},
+		{name: "未知错误", err: errors.New("供应商返回500"), wantStatus: http.StatusInternalServerError, wantType: "server_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, message := statusForWorkflowError(tt.err)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d，期望 %d", status, tt.wantStatus)
+			}
+			if message == "" {
+				t.Fatal("错误说明不应为空")
+			}
+			if got := openAIErrorType(status); got != tt.wantType {
+				t.Fatalf("openAIErrorType = %s，期望 %s", got, tt.wantType)
+			}
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -11,19 +14,37 @@ import (
 
 	"lyss-ai-platform/eino-service/internal/models"
 	"lyss-ai-platform/eino-service/internal/workflows"
+	"lyss-ai-platform/eino-service/pkg/idempotency"
+	"lyss-ai-platform/eino-service/pkg/modelalias"
+	"lyss-ai-platform/eino-service/pkg/streambuffer"
 )
 
+// statusClientClosedRequest 客户端在响应前断开连接时记录的状态码
+const statusClientClosedRequest = 499
+
+// idempotencyWriteTimeout 保存或释放幂等键的超时时间
+const idempotencyWriteTimeout = 2 * time.Second
+
 // WorkflowHandler 工作流处理器
 type WorkflowHandler struct {
-	workflowManager *workflows.WorkflowManager
-	logger          *logrus.Logger
+	workflowManager  *workflows.WorkflowManager
+	idempotencyStore *idempotency.Store
+	streamBuffer     *streambuffer.Store
+	logger           *logrus.Logger
+
+	// streamKeepaliveInterval 流式响应空闲时发送保活注释的间隔，为0时不发送
+	streamKeepaliveInterval time.Duration
+	// streamResumeGrace 启用事件缓冲时客户端断开后等待续传的时间
+	streamResumeGrace time.Duration
 }
 
 // NewWorkflowHandler 创建工作流处理器
-func NewWorkflowHandler(workflowManager *workflows.WorkflowManager, logger *logrus.Logger) *WorkflowHandler {
+func NewWorkflowHandler(workflowManager *workflows.WorkflowManager, idempotencyStore *idempotency.Store, streamKeepaliveInterval time.Duration, logger *logrus.Logger) *WorkflowHandler {
 	return &WorkflowHandler{
-		workflowManager: workflowManager,
-		logger:          logger,
+		workflowManager:         workflowManager,
+		idempotencyStore:        idempotencyStore,
+		logger:                  logger,
+		streamKeepaliveInterval: streamKeepaliveInterval,
 	}
 }
 
@@ -53,29 +74,8 @@ func (h *WorkflowHandler) ExecuteWorkflow(c *gin.Context) {
 	executionID := uuid.New().String()
 
 	// 构建工作流请求
-	workflowReq := &workflows.WorkflowRequest{
-		RequestID:     requestID,
-		ExecutionID:   executionID,
-		TenantID:      tenantID,
-		UserID:        userID,
-		WorkflowType:  "simple_chat", // 默认使用简单聊天工作流
-		Message:       req.Message,
-		ModelConfig:   req.ModelConfig,
-		Configuration: make(map[string]interface{}),
-		Stream:        req.Stream,
-	}
-
-	// 设置模型配置
-	if req.Model != "" {
-		workflowReq.ModelConfig["model"] = req.Model
-	}
-	if req.Temperature != 0 {
-		workflowReq.ModelConfig["temperature"] = req.Temperature
-	}
-	if req.MaxTokens != 0 {
-		workflowReq.ModelConfig["max_tokens"] = req.MaxTokens
-	}
-	workflowReq.ModelConfig["stream"] = req.Stream
+	workflowReq := buildChatWorkflowRequest(&req, requestID, executionID, tenantID, userID)
+	workflowReq.DebugTrace = debugTraceRequested(c)
 
 	// 记录请求
 	h.logger.WithFields(logrus.Fields{
@@ -83,7 +83,7 @@ func (h *WorkflowHandler) ExecuteWorkflow(c *gin.Context) {
 		"execution_id":   executionID,
 		"tenant_id":      tenantID,
 		"user_id":        userID,
-		"workflow_type":  "simple_chat",
+		"workflow_type":  workflowReq.WorkflowType,
 		"message_length": len(req.Message),
 		"model":          req.Model,
 		"stream":         req.Stream,
@@ -96,88 +96,378 @@ func (h *WorkflowHandler) ExecuteWorkflow(c *gin.Context) {
 		return
 	}
 
+	// 处理幂等键：重复请求直接返回首次执行的结果
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if idempotencyKey != "" {
+		cached, claimed, err := h.idempotencyStore.Acquire(c.Request.Context(), tenantID, idempotencyKey)
+		switch {
+		case errors.Is(err, idempotency.ErrInFlightTimeout):
+			h.respondWithError(c, http.StatusConflict, "相同幂等键的请求仍在执行中", err)
+			return
+		case err != nil:
+			// Redis不可用时降级为普通执行
+			h.logger.WithError(err).WithField("idempotency_key", idempotencyKey).Warn("幂等键处理失败，按普通请求执行")
+			idempotencyKey = ""
+		case !claimed:
+			var chatResponse models.ChatResponse
+			if err := json.Unmarshal(cached, &chatResponse); err != nil {
+				h.respondWithError(c, http.StatusInternalServerError, "解析幂等缓存响应失败", err)
+				return
+			}
+			c.Header("Idempotent-Replayed", "true")
+			h.respondWithSuccess(c, &chatResponse)
+			return
+		}
+	}
+
 	// 执行工作流
 	response, err := h.workflowManager.ExecuteWorkflow(c.Request.Context(), workflowReq)
 	if err != nil {
-		h.respondWithError(c, http.StatusInternalServerError, "工作流执行失败", err)
+		if idempotencyKey != "" {
+			writeCtx, cancel := idempotencyWriteContext(c)
+			h.idempotencyStore.Release(writeCtx, tenantID, idempotencyKey)
+			cancel()
+		}
+		h.respondWithWorkflowError(c, err)
 		return
 	}
 
 	// 构建聊天响应
+	chatResponse := buildChatResponse(response, executionID)
+
+	// 保存幂等响应
+	if idempotencyKey != "" {
+		payload, _ := json.Marshal(chatResponse)
+		writeCtx, cancel := idempotencyWriteContext(c)
+		if err := h.idempotencyStore.Complete(writeCtx, tenantID, idempotencyKey, payload); err != nil {
+			h.logger.WithError(err).WithField("idempotency_key", idempotencyKey).Warn("保存幂等响应失败")
+		}
+		cancel()
+	}
+
+	// 返回成功响应
+	h.respondWithSuccess(c, chatResponse)
+}
+
+// idempotencyWriteContext 保存或释放幂等键使用的 context
+// 客户端断开后请求 context 已取消，写入失败会使幂等键停留在执行中状态直到占位过期，重试请求都会返回409，因此不随请求取消
+func idempotencyWriteContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(c.Request.Context()), idempotencyWriteTimeout)
+}
+
+// ExecuteBatch 批量执行相互独立的聊天请求
+// 各条请求并发执行，结果按请求顺序返回，单条失败不影响整个批次；批量请求不支持流式输出
+func (h *WorkflowHandler) ExecuteBatch(c *gin.Context) {
+	var req models.BatchChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "请求格式错误", err)
+		return
+	}
+	if len(req.Requests) == 0 {
+		h.respondWithError(c, http.StatusBadRequest, "批量请求不能为空", nil)
+		return
+	}
+
+	tenantID := c.GetHeader("X-Tenant-ID")
+	userID := c.GetHeader("X-User-ID")
+	if tenantID == "" || userID == "" {
+		h.respondWithError(c, http.StatusBadRequest, "缺少租户或用户信息", nil)
+		return
+	}
+
+	requestID := c.GetHeader("X-Request-ID")
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+
+	workflowReqs := make([]*workflows.WorkflowRequest, len(req.Requests))
+	for i := range req.Requests {
+		req.Requests[i].Stream = false
+		workflowReqs[i] = buildChatWorkflowRequest(&req.Requests[i], requestID, uuid.New().String(), tenantID, userID)
+		workflowReqs[i].DebugTrace = debugTraceRequested(c)
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"request_id": requestID,
+		"tenant_id":  tenantID,
+		"user_id":    userID,
+		"batch_size": len(workflowReqs),
+		"operation":  "workflow_batch_request",
+	}).Info("收到批量聊天请求")
+
+	results, err := h.workflowManager.ExecuteBatch(c.Request.Context(), workflowReqs)
+	if errors.Is(err, workflows.ErrBatchTooLarge) {
+		h.respondWithError(c, http.StatusBadRequest, "批量请求条数超出上限", err)
+		return
+	}
+	if err != nil {
+		h.respondWithError(c, http.StatusInternalServerError, "批量执行失败", err)
+		return
+	}
+
+	batchResponse := &models.BatchChatResponse{
+		Results: make([]models.BatchChatResult, len(results)),
+	}
+	for i, result := range results {
+		item := models.BatchChatResult{Index: result.Index}
+		if result.Err != nil {
+			item.Error = result.Err.Error()
+			batchResponse.Failed++
+		} else {
+			item.Success = true
+			item.Response = buildChatResponse(result.Response, workflowReqs[i].ExecutionID)
+			batchResponse.Usage.PromptTokens += item.Response.Usage.PromptTokens
+			batchResponse.Usage.CompletionTokens += item.Response.Usage.CompletionTokens
+			batchResponse.Usage.TotalTokens += item.Response.Usage.TotalTokens
+			batchResponse.Cost += h.workflowManager.ResponseCost(result.Response)
+			batchResponse.Succeeded++
+		}
+		batchResponse.Results[i] = item
+	}
+
+	h.respondWithSuccess(c, batchResponse)
+}
+
+// buildChatWorkflowRequest 将聊天请求转换为工作流请求
+func buildChatWorkflowRequest(req *models.ChatRequest, requestID, executionID, tenantID, userID string) *workflows.WorkflowRequest {
+	workflowReq := &workflows.WorkflowRequest{
+		RequestID:     requestID,
+		ExecutionID:   executionID,
+		TenantID:      tenantID,
+		UserID:        userID,
+		WorkflowType:  chatWorkflowType(req.ContentParts),
+		Message:       req.Message,
+		Model:         req.Model,
+		Temperature:   req.Temperature,
+		MaxTokens:     req.MaxTokens,
+		ModelConfig:   req.ModelConfig,
+		Configuration: make(map[string]interface{}),
+		Stream:        req.Stream,
+		Seed:          req.Seed,
+		TimeoutMs:     req.TimeoutMs,
+		ContentParts:  req.ContentParts,
+
+		ResponseFormat:  req.ResponseFormat,
+		WorkflowVersion: req.WorkflowVersion,
+
+		Requires: req.Requires,
+		Optimize: req.Optimize,
+
+		CredentialStrategy: req.CredentialStrategy,
+	}
+
+	// 设置模型配置
+	if workflowReq.ModelConfig == nil {
+		workflowReq.ModelConfig = make(map[string]interface{})
+	}
+	if req.Model != "" {
+		workflowReq.ModelConfig["model"] = req.Model
+	}
+	if req.Temperature != nil {
+		workflowReq.ModelConfig["temperature"] = *req.Temperature
+	}
+	if req.MaxTokens != 0 {
+		workflowReq.ModelConfig["max_tokens"] = req.MaxTokens
+	}
+	workflowReq.ModelConfig["stream"] = req.Stream
+
+	// 设置工作流配置与提示词预设
+	for key, value := range req.Configuration {
+		workflowReq.Configuration[key] = value
+	}
+	if req.PromptID != "" {
+		workflowReq.Configuration["prompt_id"] = req.PromptID
+	}
+
+	return workflowReq
+}
+
+// buildChatResponse 将工作流响应转换为聊天响应，未返回供应商响应ID的工作流使用执行ID
+func buildChatResponse(response *workflows.WorkflowResponse, executionID string) *models.ChatResponse {
+	responseID, _ := response.Metadata["response_id"].(string)
+	if responseID == "" {
+		responseID = executionID
+	}
+
 	chatResponse := &models.ChatResponse{
-		ID:              response.Metadata["response_id"].(string),
+		ID:              responseID,
 		Content:         response.Content,
 		Model:           response.Model,
 		WorkflowType:    response.WorkflowType,
 		ExecutionTimeMs: int(response.ExecutionTimeMs),
-		Usage: models.TokenUsage{
+		FinishReason:    response.FinishReason,
+		Metadata:        response.Metadata,
+	}
+	if response.Usage != nil {
+		chatResponse.Usage = models.TokenUsage{
 			PromptTokens:     response.Usage.PromptTokens,
 			CompletionTokens: response.Usage.CompletionTokens,
 			TotalTokens:      response.Usage.TotalTokens,
-		},
-		Metadata:        response.Metadata,
+		}
 	}
+	return chatResponse
+}
 
-	// 返回成功响应
-	h.respondWithSuccess(c, chatResponse)
+// chatWorkflowType 选择聊天请求使用的工作流
+// 默认使用简单聊天工作流；包含图片时使用支持多模态输入的标准EINO聊天工作流
+func chatWorkflowType(parts []models.ContentPart) string {
+	for _, part := range parts {
+		if part.Type == models.ContentPartImageURL {
+			return "eino_standard_chat"
+		}
+	}
+	return "simple_chat"
 }
 
 // handleStreamResponse 处理流式响应
 func (h *WorkflowHandler) handleStreamResponse(c *gin.Context, req *workflows.WorkflowRequest) {
-	// 设置流式响应头
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
-	c.Header("Access-Control-Allow-Origin", "*")
+	// 携带 Last-Event-ID 重连时从事件缓冲续传，不重新执行
+	if h.resumeRequested(c) {
+		c.Header("Access-Control-Allow-Origin", "*")
+		h.resumeStream(c, req.TenantID, func() { h.sendSSEResync(c) })
+		return
+	}
 
 	// 获取流式响应通道
-	responseCh, err := h.workflowManager.ExecuteWorkflowStream(c.Request.Context(), req)
+	ctx, cancel := h.streamContext(c, req.TenantID, req.ExecutionID)
+	defer cancel()
+	responseCh, err := h.workflowManager.ExecuteWorkflowStream(ctx, req)
 	if err != nil {
-		h.sendSSEError(c, err)
+		h.respondWithWorkflowError(c, err)
 		return
 	}
 
-	// 发送流式响应
-	for streamResp := range responseCh {
-		switch streamResp.Type {
-		case "data":
-			h.sendSSEData(c, streamResp.Content)
-		case "error":
-			h.sendSSEError(c, fmt.Errorf(streamResp.Error))
-			return
-		case "done":
-			h.sendSSEDone(c)
-			return
+	// 设置流式响应头
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("Access-Control-Allow-Origin", "*")
+
+	stream := h.newSSEStream(c, req.TenantID, req.ExecutionID)
+	defer stream.close()
+
+	// 发送流式响应，等待下一个事件期间定期发送保活注释
+	keepalive := newKeepaliveTicker(h.streamKeepaliveInterval)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-keepalive.C():
+			h.sendSSEKeepalive(c)
+			continue
+		case streamResp, ok := <-responseCh:
+			if !ok {
+				return
+			}
+			keepalive.Reset()
+
+			switch streamResp.Type {
+			case workflows.StreamEventStart, workflows.StreamEventChunk:
+				h.sendSSEEvent(stream, streamResp.Type, streamResp.Data)
+			case workflows.StreamEventError:
+				h.sendSSEError(stream, errors.New(streamResp.Error))
+				h.sendSSEDone(stream)
+				return
+			case workflows.StreamEventEnd:
+				h.sendSSEEvent(stream, streamResp.Type, streamResp.Data)
+				h.sendSSEDone(stream)
+				return
+			}
 		}
 	}
 }
 
-// sendSSEData 发送SSE数据
-func (h *WorkflowHandler) sendSSEData(c *gin.Context, content string) {
-	c.Writer.WriteString(fmt.Sprintf("data: %s\n\n", content))
-	c.Writer.Flush()
+// sendSSEEvent 发送指定类型的SSE事件
+func (h *WorkflowHandler) sendSSEEvent(stream *sseStream, eventType string, data map[string]any) {
+	if data == nil {
+		data = map[string]any{}
+	}
+	jsonData, _ := json.Marshal(data)
+	stream.send(fmt.Sprintf("event: %s\ndata: %s\n\n", eventType, string(jsonData)), false)
 }
 
-// sendSSEError 发送SSE错误
-func (h *WorkflowHandler) sendSSEError(c *gin.Context, err error) {
+// sendSSEError 发送SSE错误，之后由调用方发送终止帧
+func (h *WorkflowHandler) sendSSEError(stream *sseStream, err error) {
 	errorData := map[string]interface{}{
 		"error": err.Error(),
 	}
 	jsonData, _ := json.Marshal(errorData)
-	c.Writer.WriteString(fmt.Sprintf("event: error\ndata: %s\n\n", string(jsonData)))
+	stream.send(fmt.Sprintf("event: error\ndata: %s\n\n", string(jsonData)), false)
+}
+
+// sendSSEDone 发送SSE终止帧
+func (h *WorkflowHandler) sendSSEDone(stream *sseStream) {
+	stream.send("data: [DONE]\n\n", true)
+}
+
+// sendSSEResync 事件缓冲已过期，无法从 Last-Event-ID 续传，通知客户端重新发起请求
+func (h *WorkflowHandler) sendSSEResync(c *gin.Context) {
+	jsonData, _ := json.Marshal(map[string]interface{}{
+		"last_event_id": c.GetHeader("Last-Event-ID"),
+		"message":       "无法从断点续传，请重新发起请求",
+	})
+	c.Writer.WriteString(fmt.Sprintf("event: resync\ndata: %s\n\n", string(jsonData)))
+	c.Writer.WriteString("data: [DONE]\n\n")
 	c.Writer.Flush()
 }
 
-// sendSSEDone 发送SSE完成信号
-func (h *WorkflowHandler) sendSSEDone(c *gin.Context) {
-	c.Writer.WriteString("event: done\ndata: {}\n\n")
+// sendSSEKeepalive 发送SSE保活注释，客户端会忽略注释行，代理据此保持连接
+func (h *WorkflowHandler) sendSSEKeepalive(c *gin.Context) {
+	c.Writer.WriteString(": keepalive\n\n")
 	c.Writer.Flush()
 }
 
+// keepaliveTicker 流式响应保活定时器，收到事件后重新计时，间隔为0时不触发
+type keepaliveTicker struct {
+	ticker   *time.Ticker
+	interval time.Duration
+}
+
+// newKeepaliveTicker 创建保活定时器
+func newKeepaliveTicker(interval time.Duration) *keepaliveTicker {
+	k := &keepaliveTicker{interval: interval}
+	if interval > 0 {
+		k.ticker = time.NewTicker(interval)
+	}
+	return k
+}
+
+// C 返回触发通道，未启用时返回nil通道
+func (k *keepaliveTicker) C() <-chan time.Time {
+	if k.ticker == nil {
+		return nil
+	}
+	return k.ticker.C
+}
+
+// Reset 收到事件后重新计时
+func (k *keepaliveTicker) Reset() {
+	if k.ticker != nil {
+		k.ticker.Reset(k.interval)
+	}
+}
+
+// Stop 停止定时器
+func (k *keepaliveTicker) Stop() {
+	if k.ticker != nil {
+		k.ticker.Stop()
+	}
+}
+
 // ListWorkflows 列出所有工作流
 func (h *WorkflowHandler) ListWorkflows(c *gin.Context) {
 	workflows := h.workflowManager.ListWorkflows()
-	h.respondWithSuccess(c, workflows)
+	if !paginationRequested(c) {
+		h.respondWithSuccess(c, workflows)
+		return
+	}
+
+	params, err := parsePageParams(c)
+	if err != nil {
+		h.respondWithError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	h.respondWithSuccess(c, paginate(workflows, params))
 }
 
 // GetWorkflowInfo 获取工作流信息
@@ -229,8 +519,9 @@ func (h *WorkflowHandler) CancelExecution(c *gin.Context) {
 	}
 
 	h.respondWithSuccess(c, map[string]interface{}{
-		"execution_id": executionID,
-		"status":       "cancelled",
+		"execution_id":  executionID,
+		"status":        "cancelled",
+		"cancel_reason": workflows.CancelReasonUser,
 	})
 }
 
@@ -253,6 +544,86 @@ func (h *WorkflowHandler) respondWithSuccess(c *gin.Context, data interface{}) {
 	c.JSON(http.StatusOK, response)
 }
 
+// respondWithPayloadTooLarge 返回请求内容超限错误及超限详情
+func (h *WorkflowHandler) respondWithPayloadTooLarge(c *gin.Context, statusCode int, message string, tooLarge *workflows.PayloadTooLargeError) {
+	h.logger.WithFields(logrus.Fields{
+		"request_id": c.GetHeader("X-Request-ID"),
+		"limit":      tooLarge.Limit,
+		"actual":     tooLarge.Actual,
+		"max":        tooLarge.Max,
+		"model":      tooLarge.Model,
+		"operation":  "payload_too_large",
+	}).Warn("请求内容超出长度限制")
+
+	c.JSON(statusCode, models.ApiResponse[interface{}]{
+		Success: false,
+		Data: models.ErrorResponse{
+			Code:    fmt.Sprintf("E%d", statusCode),
+			Message: tooLarge.Error(),
+			Details: map[string]interface{}{
+				"limit":  tooLarge.Limit,
+				"actual": tooLarge.Actual,
+				"max":    tooLarge.Max,
+				"model":  tooLarge.Model,
+			},
+		},
+		Message:   message,
+		RequestID: c.GetHeader("X-Request-ID"),
+		Timestamp: fmt.Sprintf("%d", c.GetInt64("timestamp")),
+	})
+}
+
+// respondWithInvalidParameters 返回请求参数错误及缺失或类型错误的字段
+func (h *WorkflowHandler) respondWithInvalidParameters(c *gin.Context, statusCode int, message string, invalid *workflows.InvalidParametersError) {
+	h.logger.WithFields(logrus.Fields{
+		"request_id":    c.GetHeader("X-Request-ID"),
+		"workflow_type": invalid.WorkflowType,
+		"missing":       invalid.Missing,
+		"invalid":       invalid.Invalid,
+		"operation":     "invalid_parameters",
+	}).Warn("请求参数不符合工作流定义")
+
+	c.JSON(statusCode, models.ApiResponse[interface{}]{
+		Success: false,
+		Data: models.ErrorResponse{
+			Code:    fmt.Sprintf("E%d", statusCode),
+			Message: invalid.Error(),
+			Details: map[string]interface{}{
+				"workflow_type": invalid.WorkflowType,
+				"missing":       invalid.Missing,
+				"invalid":       invalid.Invalid,
+			},
+		},
+		Message:   message,
+		RequestID: c.GetHeader("X-Request-ID"),
+		Timestamp: fmt.Sprintf("%d", c.GetInt64("timestamp")),
+	})
+}
+
+// respondWithUnknownModel 返回未知模型错误及可用模型列表
+func (h *WorkflowHandler) respondWithUnknownModel(c *gin.Context, statusCode int, message string, unknown *modelalias.UnknownModelError) {
+	h.logger.WithFields(logrus.Fields{
+		"request_id": c.GetHeader("X-Request-ID"),
+		"model":      unknown.Name,
+		"operation":  "unknown_model",
+	}).Warn("请求的模型未配置")
+
+	c.JSON(statusCode, models.ApiResponse[interface{}]{
+		Success: false,
+		Data: models.ErrorResponse{
+			Code:    fmt.Sprintf("E%d", statusCode),
+			Message: unknown.Error(),
+			Details: map[string]interface{}{
+				"model": unknown.Name,
+				"known": unknown.Known,
+			},
+		},
+		Message:   message,
+		RequestID: c.GetHeader("X-Request-ID"),
+		Timestamp: fmt.Sprintf("%d", c.GetInt64("timestamp")),
+	})
+}
+
 // respondWithError 返回错误响应
 func (h *WorkflowHandler) respondWithError(c *gin.Context, statusCode int, message string, err error) {
 	errorResponse := models.ErrorResponse{
@@ -323,6 +694,8 @@ func (h *WorkflowHandler) requestIDMiddleware() gin.HandlerFunc {
 		if requestID == "" {
 			requestID = uuid.New().String()
 			c.Header("X-Request-ID", requestID)
+			// 写回请求头，处理器与日志读取到的请求ID与响应头一致
+			c.Request.Header.Set("X-Request-ID", requestID)
 		}
 		
 		c.Set("request_id", requestID)
@@ -340,7 +713,7 @@ func (h *WorkflowHandler) corsMiddleware() gin.HandlerFunc {
 		
 		c.Header("Access-Control-Allow-Origin", origin)
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Tenant-ID, X-User-ID, X-Request-ID")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Tenant-ID, X-User-ID, X-Request-ID, Idempotency-Key")
 		c.Header("Access-Control-Allow-Credentials", "true")
 		c.Header("Access-Control-Max-Age", "86400")
 
@@ -385,6 +758,7 @@ func (h *WorkflowHandler) RegisterRoutes(r *gin.Engine) {
 	{
 		// 聊天接口
 		v1.POST("/chat", h.extractTenantInfo(), h.ExecuteWorkflow)
+		v1.POST("/chat/batch", h.extractTenantInfo(), h.ExecuteBatch)
 		
 		// 工作流管理接口
 		workflows := v1.Group("/workflows")
@@ -403,4 +777,10 @@ func (h *WorkflowHandler) RegisterRoutes(r *gin.Engine) {
 		// 指标接口
 		v1.GET("/metrics", h.GetMetrics)
 	}
+
+	// OpenAI兼容接口
+	r.POST("/v1/chat/completions", h.extractTenantInfo(), h.ChatCompletions)
+
+	// Prometheus指标接口
+	r.GET("/metrics", gin.WrapH(h.workflowManager.MetricsHandler()))
 }
\ No newline at end of file
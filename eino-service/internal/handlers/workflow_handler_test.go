@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"lyss-ai-platform/eino-service/internal/client"
+	"lyss-ai-platform/eino-service/internal/config"
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/internal/workflows"
+	"lyss-ai-platform/eino-service/pkg/credential"
+	"lyss-ai-platform/eino-service/pkg/featureflag"
+	"lyss-ai-platform/eino-service/pkg/idempotency"
+)
+
+const (
+	testTenantID = "11111111-1111-1111-1111-111111111111"
+	testUserID   = "22222222-2222-2222-2222-222222222222"
+)
+
+// testServer 连接租户服务替身与 miniredis 的处理器及路由
+type testServer struct {
+	handler       *WorkflowHandler
+	manager       *workflows.WorkflowManager
+	tenantService *testutil.TenantService
+	redis         *miniredis.Miniredis
+	router        *gin.Engine
+}
+
+// newTestServer 使用 config.yaml 创建完整的处理器，configure 可在创建工作流管理器前调整配置
+func newTestServer(t *testing.T, configure func(cfg *config.Config)) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg, err := config.LoadConfig("../../config.yaml")
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	tenantService := testutil.NewTenantService(t)
+	cfg.Services.TenantService.BaseURL = tenantService.Server.URL
+	if configure != nil {
+		configure(cfg)
+	}
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+
+	logger := testutil.Logger()
+	credentialManager := credential.NewManager(tenantService.Client(), redisClient, &cfg.Credential, cfg.Workflows.DefaultStrategy, logger)
+	t.Cleanup(credentialManager.Stop)
+
+	manager := workflows.NewWorkflowManager(credentialManager, redisClient, client.NewTransport(&cfg.Services.HTTPClient), logger, cfg)
+	if err := manager.Initialize(); err != nil {
+		t.Fatalf("初始化工作流管理器失败: %v", err)
+	}
+	t.Cleanup(manager.Shutdown)
+
+	handler := NewWorkflowHandler(manager, idempotency.NewStore(redisClient, time.Hour, time.Minute, logger), time.Minute, logger)
+	router := gin.New()
+	handler.RegisterRoutes(router)
+
+	return &testServer{
+		handler:       handler,
+		manager:       manager,
+		tenantService: tenantService,
+		redis:         mr,
+		router:        router,
+	}
+}
+
+// post 以测试租户和用户身份发送JSON请求
+func (s *testServer) post(path string, body interface{}) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tenant-ID", testTenantID)
+	req.Header.Set("X-User-ID", testUserID)
+
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestStreamSetupErrorReturnsJSON(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	tests := []struct {
+		name     string
+		path     string
+		body     interface{}
+		wantType string
+	}{
+		{
+			name: "工作流接口",
+			path: "/api/v1/chat",
+			body: map[string]interface{}{"message": "", "stream": true},
+		},
+		{
+			name:     "OpenAI兼容接口",
+			path:     "/v1/chat/completions",
+			body:     map[string]interface{}{"stream": true, "messages": []map[string]string{{"role": "user", "content": ""}}},
+			wantType: "server_error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := server.post(tt.path, tt.body)
+			if recorder.Code != http.StatusInternalServerError {
+				t.Fatalf("status = %d，期望 %d，body = %s", recorder.Code, http.StatusInternalServerError, recorder.Body.String())
+			}
+			if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json; charset=utf-8" {
+				t.Fatalf("Content-Type = %q，启动失败时不应返回事件流", contentType)
+			}
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("响应不是JSON: %v", err)
+			}
+			if tt.wantType != "" {
+				errorBody, _ := body["error"].(map[string]interface{})
+				if errorBody["type"] != tt.wantType {
+					t.Fatalf("error.type = %v，期望 %s", errorBody["type"], tt.wantType)
+				}
+			}
+		})
+	}
+}
+
+func TestIdempotencyWriteContextSurvivesClientDisconnect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	requestCtx, disconnect := context.WithCancel(context.Background())
+	c.Request = httptest.NewRequest("POST", "/api/v1/workflows/execute", nil).WithContext(requestCtx)
+	disconnect()
+
+	writeCtx, cancel := idempotencyWriteContext(c)
+	defer cancel()
+	if err := writeCtx.Err(); err != nil {
+		t.Fatalf("客户端断开后写入 context 不应取消: %v", err)
+	}
+	if _, ok := writeCtx.Deadline(); !ok {
+		t.Fatal("写入 context 应带超时")
+	}
+}
+
+func TestBuildChatWorkflowRequestTemperature(t *testing.T) {
+	zero := 0.0
+	tests := []struct {
+		name       string
+		req        *models.ChatRequest
+		wantInMap  bool
+		wantTopSet bool
+	}{
+		{name: "未传入", req: &models.ChatRequest{Message: "hi"}},
+		{name: "显式0", req: &models.ChatRequest{Message: "hi", Temperature: &zero}, wantInMap: true, wantTopSet: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflowReq := buildChatWorkflowRequest(tt.req, "req-1", "exec-1", "tenant-1", "user-1")
+			value, ok := workflowReq.ModelConfig["temperature"]
+			if ok != tt.wantInMap {
+				t.Fatalf("model_config.temperature 存在 = %v，期望 %v", ok, tt.wantInMap)
+			}
+			if ok && value != 0.0 {
+				t.Fatalf("model_config.temperature = %v，期望 0", value)
+			}
+			if (workflowReq.Temperature != nil) != tt.wantTopSet {
+				t.Fatalf("Temperature = %v", workflowReq.Temperature)
+			}
+		})
+	}
+}
+
+func TestBuildChatWorkflowRequestCredentialStrategy(t *testing.T) {
+	req := &models.ChatRequest{Message: "hi", CredentialStrategy: "sticky_by_user"}
+	workflowReq := buildChatWorkflowRequest(req, "req-1", "exec-1", "tenant-1", "user-1")
+	if workflowReq.CredentialStrategy != "sticky_by_user" {
+		t.Fatalf("CredentialStrategy = %q，期望 sticky_by_user", workflowReq.CredentialStrategy)
+	}
+}
+
+func TestPayloadTooLargeResponse(t *testing.T) {
+	server := newTestServer(t, func(cfg *config.Config) {
+		cfg.Workflows.MaxMessageBytes = 10
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		body       interface{}
+		wantStatus int
+	}{
+		{name: "等于上限", path: "/api/v1/chat", body: map[string]interface{}{"message": "0123456789"}},
+		{name: "超过上限", path: "/api/v1/chat", body: map[string]interface{}{"message": "01234567890"}, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "流式请求超过上限", path: "/api/v1/chat", body: map[string]interface{}{"message": "01234567890", "stream": true}, wantStatus: http.StatusRequestEntityTooLarge},
+		{
+			name:       "OpenAI兼容接口超过上限",
+			path:       "/v1/chat/completions",
+			body:       map[string]interface{}{"messages": []map[string]string{{"role": "user", "content": "01234567890"}}},
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := server.post(tt.path, tt.body)
+			if tt.wantStatus == 0 {
+				if recorder.Code == http.StatusRequestEntityTooLarge {
+					t.Fatalf("等于上限的请求不应返回413: %s", recorder.Body.String())
+				}
+				return
+			}
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d，期望 %d，body = %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if !bytes.Contains(recorder.Body.Bytes(), []byte("11")) {
+				t.Fatalf("响应应包含超限的实际大小: %s", recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestQuotaExceededResponse(t *testing.T) {
+	server := newTestServer(t, func(cfg *config.Config) {
+		cfg.Quota.MonthlyTokenLimit = 100
+	})
+	server.redis.Set("quota:tokens:"+testTenantID+":"+time.Now().UTC().Format("2006-01"), "100")
+
+	tests := []struct {
+		name     string
+		path     string
+		body     interface{}
+		wantType string
+	}{
+		{name: "工作流接口", path: "/api/v1/chat", body: map[string]interface{}{"message": "你好"}},
+		{name: "流式请求", path: "/api/v1/chat", body: map[string]interface{}{"message": "你好", "stream": true}},
+		{
+			name:     "OpenAI兼容接口",
+			path:     "/v1/chat/completions",
+			body:     map[string]interface{}{"messages": []map[string]string{{"role": "user", "content": "你好"}}},
+			wantType: "insufficient_quota",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := server.post(tt.path, tt.body)
+			if recorder.Code != http.StatusPaymentRequired {
+				t.Fatalf("status = %d，期望 402，body = %s", recorder.Code, recorder.Body.String())
+			}
+			if tt.wantType != "" && !bytes.Contains(recorder.Body.Bytes(), []byte(tt.wantType)) {
+				t.Fatalf("响应应包含错误类型 %s: %s", tt.wantType, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestInvalidParametersResponse(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	tests := []struct {
+		name string
+		path string
+		body interface{}
+	}{
+		{name: "工作流接口", path: "/api/v1/chat", body: map[string]interface{}{"message": "你好", "credential_strategy": "random"}},
+		{name: "流式请求", path: "/api/v1/chat", body: map[string]interface{}{"message": "你好", "stream": true, "workflow_version": "9.9.9"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := server.post(tt.path, tt.body)
+			if recorder.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d，期望 400，body = %s", recorder.Code, recorder.Body.String())
+			}
+
+			var body struct {
+				Message string `json:"message"`
+				Data    struct {
+					Details map[string]interface{} `json:"details"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("响应不是JSON: %v", err)
+			}
+			if body.Message != "请求参数不符合工作流定义" || body.Data.Details["invalid"] == nil {
+				t.Fatalf("响应应列出不合法的字段: %s", recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestUnknownModelResponse(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	tests := []struct {
+		name       string
+		path       string
+		body       interface{}
+		wantStatus int
+	}{
+		{name: "工作流接口", path: "/api/v1/chat", body: map[string]interface{}{"message": "你好", "model": "gpt-5"}, wantStatus: http.StatusBadRequest},
+		{name: "流式请求", path: "/api/v1/chat", body: map[string]interface{}{"message": "你好", "model": "gpt-5", "stream": true}, wantStatus: http.StatusBadRequest},
+		{
+			name:       "OpenAI兼容接口返回404",
+			path:       "/v1/chat/completions",
+			body:       map[string]interface{}{"model": "gpt-5", "messages": []map[string]string{{"role": "user", "content": "你好"}}},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "OpenAI兼容流式接口返回404",
+			path:       "/v1/chat/completions",
+			body:       map[string]interface{}{"model": "gpt-5", "stream": true, "messages": []map[string]string{{"role": "user", "content": "你好"}}},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := server.post(tt.path, tt.body)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d，期望 %d，body = %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if !bytes.Contains(recorder.Body.Bytes(), []byte("gpt-4")) {
+				t.Fatalf("错误信息应列出可用模型: %s", recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestFeatureDisabledResponse(t *testing.T) {
+	server := newTestServer(t, nil)
+	server.manager.SetFeatureFlags(featureflag.NewService(
+		server.tenantService.Client(), nil,
+		map[string]bool{featureflag.FlagJSONMode: true, featureflag.FlagOptimizedRAG: true},
+		0, testutil.Logger(),
+	))
+	server.tenantService.SetFeatures(testTenantID, map[string]bool{featureflag.FlagJSONMode: false})
+
+	jsonMode := map[string]string{"type": "json_object"}
+	tests := []struct {
+		name       string
+		path       string
+		body       interface{}
+		wantStatus int
+		wantType   string
+	}{
+		{name: "工作流接口", path: "/api/v1/chat", body: map[string]interface{}{"message": "你好", "response_format": jsonMode}, wantStatus: http.StatusForbidden},
+		{name: "流式请求", path: "/api/v1/chat", body: map[string]interface{}{"message": "你好", "stream": true, "response_format": jsonMode}, wantStatus: http.StatusForbidden},
+		{
+			name:       "OpenAI兼容接口",
+			path:       "/v1/chat/completions",
+			body:       map[string]interface{}{"response_format": jsonMode, "messages": []map[string]string{{"role": "user", "content": "你好"}}},
+			wantStatus: http.StatusForbidden,
+			wantType:   "permission_error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := server.post(tt.path, tt.body)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d，期望 %d，body = %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if tt.wantType != "" && !bytes.Contains(recorder.Body.Bytes(), []byte(tt.wantType)) {
+				t.Fatalf("响应应包含错误类型 %s: %s", tt.wantType, recorder.Body.String())
+			}
+		})
+	}
+}
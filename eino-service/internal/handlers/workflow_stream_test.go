@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+// sseEvent 事件流中的一帧，Event 为空表示仅含 data 的帧
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// sseEvents 按空行切分事件流，解析每帧的 id、event 与 data 行，保活注释不计入
+func sseEvents(t *testing.T, body string) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	var current sseEvent
+	hasData := false
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if hasData {
+				events = append(events, current)
+			}
+			current, hasData = sseEvent{}, false
+		case strings.HasPrefix(line, "id: "):
+			current.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			current.Event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.Data = strings.TrimPrefix(line, "data: ")
+			hasData = true
+		}
+	}
+	return events
+}
+
+// visionChatRequest 带图片内容块的流式聊天请求，路由到逐块输出的标准聊天工作流
+func visionChatRequest(message string) map[string]interface{} {
+	return map[string]interface{}{
+		"message": message,
+		"stream":  true,
+		"model":   "gpt-4o",
+		"content_parts": []map[string]interface{}{
+			{"type": "text", "text": message},
+			{"type": "image_url", "image_url": map[string]string{"url": "https://example.com/cat.png"}},
+		},
+	}
+}
+
+func TestChatStreamForwardsEachChunk(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+	}{
+		{name: "两个增量", chunks: []string{"你好", "，世界"}},
+		{name: "多个增量", chunks: []string{"一", "二", "三", "四"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			provider := newProviderStub(t, tt.chunks...)
+			server.tenantService.SetCredentials(testTenantID, testutil.Credential("openai", provider.Server.URL))
+
+			recorder := server.post("/api/v1/chat", visionChatRequest("图里是什么"))
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d，body = %s", recorder.Code, recorder.Body.String())
+			}
+
+			var deltas []string
+			var accumulated string
+			for _, event := range sseEvents(t, recorder.Body.String()) {
+				if event.Event != "chunk" {
+					continue
+				}
+				var data map[string]interface{}
+				if err := json.Unmarshal([]byte(event.Data), &data); err != nil {
+					t.Fatalf("chunk 事件不是JSON: %v，data = %s", err, event.Data)
+				}
+				delta, _ := data["delta"].(string)
+				deltas = append(deltas, delta)
+				accumulated, _ = data["content"].(string)
+			}
+
+			if len(deltas) != len(tt.chunks) {
+				t.Fatalf("chunk 事件数 = %d，期望 %d，body = %s", len(deltas), len(tt.chunks), recorder.Body.String())
+			}
+			for i, delta := range deltas {
+				if delta != tt.chunks[i] {
+					t.Fatalf("第 %d 个 delta = %q，期望 %q", i, delta, tt.chunks[i])
+				}
+			}
+			if want := strings.Join(tt.chunks, ""); accumulated != want {
+				t.Fatalf("最后一个 chunk 的累计内容 = %q，期望 %q", accumulated, want)
+			}
+		})
+	}
+}
+
+func TestChatStreamWritesAllEventTypes(t *testing.T) {
+	tests := []struct {
+		name       string
+		failing    bool
+		wantEvents []string
+	}{
+		{name: "正常完成", wantEvents: []string{"start", "chunk", "chunk", "end", ""}},
+		{name: "供应商失败", failing: true, wantEvents: []string{"start", "error", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			baseURL := newProviderStub(t, "你好", "，世界").Server.URL
+			if tt.failing {
+				failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					http.Error(w, `{"error":{"message":"upstream unavailable"}}`, http.StatusInternalServerError)
+				}))
+				t.Cleanup(failing.Close)
+				baseURL = failing.URL
+			}
+			server.tenantService.SetCredentials(testTenantID, testutil.Credential("openai", baseURL))
+
+			recorder := server.post("/api/v1/chat", visionChatRequest("图里是什么"))
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d，body = %s", recorder.Code, recorder.Body.String())
+			}
+
+			events := sseEvents(t, recorder.Body.String())
+			var types []string
+			for _, event := range events {
+				types = append(types, event.Event)
+			}
+			if strings.Join(types, ",") != strings.Join(tt.wantEvents, ",") {
+				t.Fatalf("事件类型 = %q，期望 %q，body = %s", types, tt.wantEvents, recorder.Body.String())
+			}
+			if last := events[len(events)-1]; last.Data != "[DONE]" {
+				t.Fatalf("最后一帧 data = %q，期望 [DONE]", last.Data)
+			}
+
+			for _, event := range events[:len(events)-1] {
+				var data map[string]interface{}
+				if err := json.Unmarshal([]byte(event.Data), &data); err != nil {
+					t.Fatalf("%s 事件不是JSON: %v，data = %s", event.Event, err, event.Data)
+				}
+				switch event.Event {
+				case "end":
+					if _, ok := data["usage"].(map[string]interface{}); !ok {
+						t.Fatalf("end 事件缺少 usage，data = %s", event.Data)
+					}
+				case "error":
+					if message, _ := data["error"].(string); message == "" {
+						t.Fatalf("error 事件缺少错误信息，data = %s", event.Data)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSlowStreamSendsKeepaliveComments(t *testing.T) {
+	openAIRequest := map[string]interface{}{
+		"model":    "deepseek-chat",
+		"stream":   true,
+		"messages": []map[string]string{{"role": "user", "content": "慢慢想"}},
+	}
+
+	tests := []struct {
+		name          string
+		provider      string
+		path          string
+		body          map[string]interface{}
+		interval      time.Duration
+		wantKeepalive bool
+	}{
+		{name: "工作流接口", provider: "openai", path: "/api/v1/chat", body: visionChatRequest("慢慢想"), interval: 20 * time.Millisecond, wantKeepalive: true},
+		{name: "OpenAI兼容接口", provider: "deepseek", path: "/v1/chat/completions", body: openAIRequest, interval: 20 * time.Millisecond, wantKeepalive: true},
+		{name: "间隔为0时不发送", provider: "openai", path: "/api/v1/chat", body: visionChatRequest("慢慢想")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			server.handler.streamKeepaliveInterval = tt.interval
+			provider := newProviderStub(t, "想", "好了")
+			provider.Delay = 150 * time.Millisecond
+			server.tenantService.SetCredentials(testTenantID, testutil.Credential(tt.provider, provider.Server.URL))
+
+			recorder := server.post(tt.path, tt.body)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d，body = %s", recorder.Code, recorder.Body.String())
+			}
+
+			// 保活注释必须是独立的帧，不能插入数据帧中间
+			frames := strings.Split(strings.TrimSuffix(recorder.Body.String(), "\n\n"), "\n\n")
+			keepalives, dataFrames := 0, 0
+			for _, frame := range frames {
+				if strings.Contains(frame, ": keepalive") {
+					if frame != ": keepalive" {
+						t.Fatalf("保活注释与数据混在同一帧: %q", frame)
+					}
+					keepalives++
+					continue
+				}
+				dataFrames++
+			}
+
+			if tt.wantKeepalive && keepalives == 0 {
+				t.Fatalf("慢速流中没有保活注释，body = %s", recorder.Body.String())
+			}
+			if !tt.wantKeepalive && keepalives != 0 {
+				t.Fatalf("间隔为0时不应发送保活注释，实际 %d 条", keepalives)
+			}
+			if last := sseData(t, recorder.Body.String()); len(last) == 0 || last[len(last)-1] != "[DONE]" {
+				t.Fatalf("保活注释之后仍应以 [DONE] 结束，body = %s", recorder.Body.String())
+			}
+			if dataFrames < 3 {
+				t.Fatalf("数据帧数 = %d，保活注释不应替代真实事件", dataFrames)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/internal/workflows"
+)
+
+// simpleChatV2 simple_chat 的新版本替身，回答固定内容且不调用供应商
+type simpleChatV2 struct{}
+
+func (simpleChatV2) Execute(ctx context.Context, req *workflows.WorkflowRequest) (*workflows.WorkflowResponse, error) {
+	return &workflows.WorkflowResponse{Success: true, Content: "v2", WorkflowType: "simple_chat", Usage: &workflows.TokenUsage{}}, nil
+}
+
+func (simpleChatV2) ExecuteStream(ctx context.Context, req *workflows.WorkflowRequest) (<-chan *workflows.WorkflowStreamResponse, error) {
+	ch := make(chan *workflows.WorkflowStreamResponse)
+	close(ch)
+	return ch, nil
+}
+
+func (simpleChatV2) GetInfo() *workflows.WorkflowInfo {
+	return &workflows.WorkflowInfo{Name: "simple_chat", Version: "2.0.0"}
+}
+
+func TestChatRoutesByWorkflowVersion(t *testing.T) {
+	server := newTestServer(t, nil)
+	stub := newProviderStub(t, "v1")
+	server.tenantService.SetCredentials(testTenantID, testutil.Credential("deepseek", stub.Server.URL))
+	if err := server.manager.RegisterWorkflow("simple_chat", simpleChatV2{}); err != nil {
+		t.Fatalf("RegisterWorkflow: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		version     string
+		wantStatus  int
+		wantContent string
+		wantVersion string
+		wantCalls   int
+	}{
+		{name: "默认最新版本", wantStatus: http.StatusOK, wantContent: "v2", wantVersion: "2.0.0"},
+		{name: "指定旧版本", version: "1", wantStatus: http.StatusOK, wantContent: "v1", wantVersion: "1.0.0", wantCalls: 1},
+		{name: "指定新版本", version: "2.0.0", wantStatus: http.StatusOK, wantContent: "v2", wantVersion: "2.0.0"},
+		{name: "未注册的版本", version: "3", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(stub.Requests())
+			recorder := server.post("/api/v1/chat", map[string]interface{}{
+				"message":          "你好",
+				"model":            "deepseek-chat",
+				"workflow_version": tt.version,
+			})
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d，期望 %d，body = %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if calls := len(stub.Requests()) - before; calls != tt.wantCalls {
+				t.Fatalf("供应商调用次数 = %d，期望 %d", calls, tt.wantCalls)
+			}
+
+			if tt.wantStatus == http.StatusBadRequest {
+				var response models.ApiResponse[models.ErrorResponse]
+				json.Unmarshal(recorder.Body.Bytes(), &response)
+				invalid, _ := response.Data.Details["invalid"].(map[string]interface{})
+				expected, _ := invalid["workflow_version"].(string)
+				if !strings.Contains(expected, "1.0.0") || !strings.Contains(expected, "2.0.0") {
+					t.Fatalf("details.invalid = %v，期望列出已注册的版本", invalid)
+				}
+				return
+			}
+
+			var response models.ApiResponse[models.ChatResponse]
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			if response.Data.Content != tt.wantContent || response.Data.Metadata["workflow_version"] != tt.wantVersion {
+				t.Fatalf("响应 = %+v，期望 %s 由版本 %s 处理", response.Data, tt.wantContent, tt.wantVersion)
+			}
+		})
+	}
+}
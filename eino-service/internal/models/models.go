@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -58,10 +59,89 @@ type ToolConfig struct {
 type ChatRequest struct {
 	Message     string                 `json:"message"`
 	Model       string                 `json:"model"`
-	Temperature float64                `json:"temperature"`
+	Temperature *float64               `json:"temperature,omitempty"` // 未传入时使用模型默认值，可传入0
 	MaxTokens   int                    `json:"max_tokens"`
 	Stream      bool                   `json:"stream"`
 	ModelConfig map[string]interface{} `json:"model_config"`
+	Seed        *int                   `json:"seed,omitempty"` // 随机种子，配合 temperature=0 获得可复现的输出
+	TimeoutMs   int                    `json:"timeout_ms,omitempty"` // 本次请求的超时时间（毫秒），不超过服务端上限
+
+	ContentParts []ContentPart `json:"content_parts,omitempty"` // 多模态内容（文本与图片），message 仍需包含文本
+
+	PromptID      string                 `json:"prompt_id,omitempty"`     // 引用的系统提示词预设ID
+	Configuration map[string]interface{} `json:"configuration,omitempty"` // 工作流配置，同时作为提示词预设的模板变量
+
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"` // 结构化输出格式，要求模型只输出JSON
+
+	WorkflowVersion string `json:"workflow_version,omitempty"` // 目标工作流版本，为空时使用最新版本
+
+	Requires []string `json:"requires,omitempty"` // 按能力选择模型，如 ["vision"]，没有匹配的模型时使用 model
+	Optimize string   `json:"optimize,omitempty"` // 按能力选择模型的优化目标，cost 表示选择单价最低的模型
+
+	CredentialStrategy string `json:"credential_strategy,omitempty"` // 本次请求的凭证选择策略，为空时使用服务默认策略
+}
+
+// 响应格式类型
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
+// ResponseFormat 结构化输出格式，与OpenAI的 response_format 格式一致
+type ResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+// JSONSchemaFormat json_schema 模式下期望的输出结构
+type JSONSchemaFormat struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Schema      map[string]interface{} `json:"schema"`
+	Strict      bool                   `json:"strict,omitempty"`
+}
+
+// JSONMode 是否要求模型输出JSON，未设置或为 text 时返回false
+func (f *ResponseFormat) JSONMode() bool {
+	return f != nil && (f.Type == ResponseFormatJSONObject || f.Type == ResponseFormatJSONSchema)
+}
+
+// Validate 校验响应格式，json_schema 模式必须提供 schema
+func (f *ResponseFormat) Validate() error {
+	if f == nil {
+		return nil
+	}
+	switch f.Type {
+	case ResponseFormatText, ResponseFormatJSONObject:
+		return nil
+	case ResponseFormatJSONSchema:
+		if f.JSONSchema == nil || len(f.JSONSchema.Schema) == 0 {
+			return fmt.Errorf("json_schema 模式必须提供 json_schema.schema")
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的响应格式 %q，可选 text、json_object、json_schema", f.Type)
+	}
+}
+
+// 内容块类型
+const (
+	ContentPartText     = "text"
+	ContentPartImageURL = "image_url"
+)
+
+// ContentPart 多模态消息内容块，格式与OpenAI的数组内容一致
+type ContentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+// ImageURL 图片地址，支持 http(s) 链接或 base64 data URI
+type ImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
 }
 
 // ChatResponse 聊天响应
@@ -72,9 +152,32 @@ type ChatResponse struct {
 	WorkflowType    string                 `json:"workflow_type"`
 	ExecutionTimeMs int                    `json:"execution_time_ms"`
 	Usage           TokenUsage             `json:"usage"`
+	FinishReason    string                 `json:"finish_reason,omitempty"`
 	Metadata        map[string]interface{} `json:"metadata"`
 }
 
+// BatchChatRequest 批量聊天请求，各条请求相互独立
+type BatchChatRequest struct {
+	Requests []ChatRequest `json:"requests"`
+}
+
+// BatchChatResult 批量聊天中单条请求的结果
+type BatchChatResult struct {
+	Index    int           `json:"index"`
+	Success  bool          `json:"success"`
+	Response *ChatResponse `json:"response,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// BatchChatResponse 批量聊天响应，结果顺序与请求顺序一致
+type BatchChatResponse struct {
+	Results   []BatchChatResult `json:"results"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Usage     TokenUsage        `json:"usage"` // 成功请求的令牌用量合计
+	Cost      float64           `json:"cost"`  // 成功请求按各自模型单价计算的费用合计
+}
+
 // TokenUsage 令牌使用情况
 type TokenUsage struct {
 	PromptTokens     int `json:"prompt_tokens"`
@@ -118,6 +221,16 @@ type ApiResponse[T any] struct {
 	Timestamp string `json:"timestamp"`
 }
 
+// Page 列表接口统一的分页结构
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int    `json:"total"`
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"` // 下一页的游标，作为 cursor 查询参数传入；没有下一页时为空
+}
+
 // ErrorResponse 错误响应
 type ErrorResponse struct {
 	Code    string                 `json:"code"`
@@ -132,4 +245,25 @@ type HealthResponse struct {
 	Version      string            `json:"version"`
 	Dependencies map[string]string `json:"dependencies"`
 	Metrics      map[string]int    `json:"metrics"`
-}
\ No newline at end of file
+}
+// MemorySearchRequest 记忆检索请求
+type MemorySearchRequest struct {
+	Query           string `json:"query"`
+	Limit           int    `json:"limit"`
+	IncludeMetadata bool   `json:"include_metadata"`
+}
+
+// Memory 记忆条目
+type Memory struct {
+	ID             string                 `json:"id"`
+	Content        string                 `json:"content"`
+	RelevanceScore float64                `json:"relevance_score"`
+	CreatedAt      string                 `json:"created_at"`
+	Metadata       map[string]interface{} `json:"metadata"`
+}
+
+// MemorySearchResult 记忆检索结果
+type MemorySearchResult struct {
+	Memories   []*Memory `json:"memories"`
+	TotalFound int       `json:"total_found"`
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// OpenAIChatMessage OpenAI兼容的聊天消息
+// content 可以是字符串或内容块数组，数组形式时 Content 为其中文本块的拼接
+type OpenAIChatMessage struct {
+	Role         string        `json:"role"`
+	Content      string        `json:"content"`
+	ContentParts []ContentPart `json:"-"`
+}
+
+// UnmarshalJSON 解析字符串或数组形式的 content
+func (m *OpenAIChatMessage) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	m.Role = raw.Role
+	m.Content = ""
+	m.ContentParts = nil
+
+	content := strings.TrimSpace(string(raw.Content))
+	switch {
+	case content == "" || content == "null":
+		return nil
+	case strings.HasPrefix(content, "["):
+		if err := json.Unmarshal(raw.Content, &m.ContentParts); err != nil {
+			return fmt.Errorf("content 内容块格式错误: %w", err)
+		}
+		var texts []string
+		for _, part := range m.ContentParts {
+			if part.Type == ContentPartText && part.Text != "" {
+				texts = append(texts, part.Text)
+			}
+		}
+		m.Content = strings.Join(texts, "\n")
+		return nil
+	default:
+		return json.Unmarshal(raw.Content, &m.Content)
+	}
+}
+
+// OpenAIChatCompletionRequest OpenAI兼容的聊天补全请求
+type OpenAIChatCompletionRequest struct {
+	Model            string              `json:"model"`
+	Messages         []OpenAIChatMessage `json:"messages" binding:"required,min=1"`
+	Stream           bool                `json:"stream"`
+	Temperature      *float64            `json:"temperature,omitempty"`
+	TopP             *float64            `json:"top_p,omitempty"`
+	MaxTokens        int                 `json:"max_tokens,omitempty"`
+	Stop             interface{}         `json:"stop,omitempty"`
+	FrequencyPenalty *float64            `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64            `json:"presence_penalty,omitempty"`
+	Seed             *int                `json:"seed,omitempty"`
+	User             string              `json:"user,omitempty"`
+	ResponseFormat   *ResponseFormat     `json:"response_format,omitempty"`
+}
+
+// OpenAIChatCompletionChoice 非流式响应的候选结果
+type OpenAIChatCompletionChoice struct {
+	Index        int               `json:"index"`
+	Message      OpenAIChatMessage `json:"message"`
+	FinishReason string            `json:"finish_reason"`
+}
+
+// OpenAIUsage OpenAI兼容的令牌使用情况
+type OpenAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// OpenAIChatCompletionResponse OpenAI兼容的聊天补全响应
+type OpenAIChatCompletionResponse struct {
+	ID      string                       `json:"id"`
+	Object  string                       `json:"object"`
+	Created int64                        `json:"created"`
+	Model   string                       `json:"model"`
+	Choices []OpenAIChatCompletionChoice `json:"choices"`
+	Usage   OpenAIUsage                  `json:"usage"`
+}
+
+// OpenAIChatCompletionDelta 流式响应的增量内容
+type OpenAIChatCompletionDelta struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// OpenAIChatCompletionChunkChoice 流式响应的候选结果
+type OpenAIChatCompletionChunkChoice struct {
+	Index        int                       `json:"index"`
+	Delta        OpenAIChatCompletionDelta `json:"delta"`
+	FinishReason *string                   `json:"finish_reason"`
+}
+
+// OpenAIChatCompletionChunk OpenAI兼容的流式响应块
+type OpenAIChatCompletionChunk struct {
+	ID      string                            `json:"id"`
+	Object  string                            `json:"object"`
+	Created int64                             `json:"created"`
+	Model   string                            `json:"model"`
+	Choices []OpenAIChatCompletionChunkChoice `json:"choices"`
+}
+
+// OpenAIError OpenAI兼容的错误详情
+type OpenAIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code,omitempty"`
+}
+
+// OpenAIErrorResponse OpenAI兼容的错误响应
+type OpenAIErrorResponse struct {
+	Error OpenAIError `json:"error"`
+}
@@ -0,0 +1,26 @@
+// Package credentialtest 创建测试使用的凭证管理器
+// 凭证包自身的测试依赖 testutil，因此该辅助函数不能放在 testutil 中
+package credentialtest
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"lyss-ai-platform/eino-service/internal/config"
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/pkg/credential"
+)
+
+// NewManager 创建使用租户服务替身与 miniredis 的凭证管理器，测试结束时自动停止
+func NewManager(t *testing.T, tenantService *testutil.TenantService) *credential.Manager {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+
+	manager := credential.NewManager(tenantService.Client(), redisClient, &config.CredentialConfig{}, credential.StrategyFirstAvailable, testutil.Logger())
+	t.Cleanup(manager.Stop)
+	return manager
+}
@@ -0,0 +1,204 @@
+// Package testutil 测试使用的依赖服务替身与辅助函数
+package testutil
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/internal/client"
+	"lyss-ai-platform/eino-service/internal/config"
+	"lyss-ai-platform/eino-service/internal/models"
+)
+
+// Logger 创建丢弃输出的日志记录器
+func Logger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// Credential 创建启用状态的供应商凭证
+func Credential(provider, baseURL string) *models.SupplierCredential {
+	return &models.SupplierCredential{
+		ID:           uuid.New(),
+		Provider:     provider,
+		DisplayName:  provider,
+		APIKey:       "sk-test-" + provider,
+		BaseURL:      baseURL,
+		ModelConfigs: make(map[string]interface{}),
+		IsActive:     true,
+		UpdatedAt:    time.Now(),
+	}
+}
+
+// TenantService 租户服务替身，按租户返回配置的凭证、功能开关与工具配置
+type TenantService struct {
+	Server *httptest.Server
+
+	mutex       sync.Mutex
+	credentials map[string][]*models.SupplierCredential
+	features    map[string]map[string]bool
+	toolConfigs map[string]*models.ToolConfig
+	invalidated []string
+	requests    map[string]int
+	delay       time.Duration
+}
+
+// NewTenantService 启动租户服务替身，测试结束时自动关闭
+func NewTenantService(t *testing.T) *TenantService {
+	t.Helper()
+
+	s := &TenantService{
+		credentials: make(map[string][]*models.SupplierCredential),
+		features:    make(map[string]map[string]bool),
+		toolConfigs: make(map[string]*models.ToolConfig),
+		requests:    make(map[string]int),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Server.Close)
+	return s
+}
+
+// Client 创建指向替身的租户服务客户端
+func (s *TenantService) Client() *client.TenantClient {
+	return client.NewTenantClient(&config.TenantServiceConfig{
+		BaseURL: s.Server.URL,
+		Timeout: 5 * time.Second,
+	}, nil, Logger())
+}
+
+// SetCredentials 设置租户的可用凭证
+func (s *TenantService) SetCredentials(tenantID string, credentials ...*models.SupplierCredential) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.credentials[tenantID] = credentials
+}
+
+// SetFeatures 设置租户的功能开关覆盖
+func (s *TenantService) SetFeatures(tenantID string, features map[string]bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.features[tenantID] = features
+}
+
+// SetToolConfig 设置租户工作流的工具配置
+func (s *TenantService) SetToolConfig(tenantID, workflowName, toolName string, toolConfig *models.ToolConfig) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.toolConfigs[tenantID+"/"+workflowName+"/"+toolName] = toolConfig
+}
+
+// SetDelay 设置每个请求的响应延迟
+func (s *TenantService) SetDelay(delay time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.delay = delay
+}
+
+// Invalidated 返回被上报失效的凭证ID
+func (s *TenantService) Invalidated() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.invalidated...)
+}
+
+// Requests 返回路径后缀匹配的请求次数
+func (s *TenantService) Requests(suffix string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	count := 0
+	for path, n := range s.requests {
+		if strings.HasSuffix(path, suffix) {
+			count += n
+		}
+	}
+	return count
+}
+
+// handle 处理租户服务接口
+func (s *TenantService) handle(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	s.requests[r.URL.Path]++
+	delay := s.delay
+	s.mutex.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 4 && parts[1] == "suppliers" && parts[3] == "available":
+		s.writeData(w, s.available(parts[2], r.URL.Query().Get("providers")))
+	case len(parts) == 4 && parts[1] == "suppliers" && parts[3] == "invalidate":
+		s.mutex.Lock()
+		s.invalidated = append(s.invalidated, parts[2])
+		s.mutex.Unlock()
+		s.writeData(w, nil)
+	case len(parts) == 4 && parts[1] == "suppliers" && parts[3] == "test":
+		s.writeData(w, map[string]bool{"success": true})
+	case len(parts) == 4 && parts[1] == "tenants" && parts[3] == "features":
+		s.mutex.Lock()
+		features := s.features[parts[2]]
+		s.mutex.Unlock()
+		s.writeData(w, features)
+	case len(parts) == 3 && parts[1] == "tenants" && parts[2] == "active":
+		s.mutex.Lock()
+		tenants := make([]string, 0, len(s.credentials))
+		for tenantID := range s.credentials {
+			tenants = append(tenants, tenantID)
+		}
+		s.mutex.Unlock()
+		s.writeData(w, tenants)
+	case len(parts) == 5 && parts[1] == "tool-configs":
+		s.mutex.Lock()
+		toolConfig, ok := s.toolConfigs[strings.Join(parts[2:], "/")]
+		s.mutex.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		s.writeData(w, toolConfig)
+	case r.URL.Path == "/health":
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// available 按供应商过滤租户凭证
+func (s *TenantService) available(tenantID, providers string) []*models.SupplierCredential {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if providers == "" {
+		return s.credentials[tenantID]
+	}
+	wanted := make(map[string]bool)
+	for _, provider := range strings.Split(providers, ",") {
+		wanted[provider] = true
+	}
+	var result []*models.SupplierCredential
+	for _, cred := range s.credentials[tenantID] {
+		if wanted[cred.Provider] {
+			result = append(result, cred)
+		}
+	}
+	return result
+}
+
+// writeData 写出租户服务统一的成功响应
+func (s *TenantService) writeData(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.ApiResponse[interface{}]{Success: true, Data: data})
+}
@@ -0,0 +1,78 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrBatchTooLarge 批量请求条数超出上限
+var ErrBatchTooLarge = errors.New("批量请求条数超出上限")
+
+// BatchItemResult 批量执行中单个请求的结果
+type BatchItemResult struct {
+	Index    int
+	Response *WorkflowResponse
+	Err      error
+}
+
+// ExecuteBatch 并发执行多个相互独立的请求，结果按输入顺序返回
+// 同时执行的条数不超过 batch_concurrency 与全局并发上限，单个请求失败不影响其他请求
+func (wm *WorkflowManager) ExecuteBatch(ctx context.Context, reqs []*WorkflowRequest) ([]BatchItemResult, error) {
+	if maxSize := wm.config.Workflows.MaxBatchSize; maxSize > 0 && len(reqs) > maxSize {
+		return nil, fmt.Errorf("%w: %d 条超过上限 %d", ErrBatchTooLarge, len(reqs), maxSize)
+	}
+
+	concurrency := wm.batchConcurrency()
+	results := make([]BatchItemResult, len(reqs))
+	semaphore := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		go func(index int, req *WorkflowRequest) {
+			defer wg.Done()
+
+			result := BatchItemResult{Index: index}
+			select {
+			case semaphore <- struct{}{}:
+				result.Response, result.Err = wm.ExecuteWorkflow(ctx, req)
+				<-semaphore
+			case <-ctx.Done():
+				result.Err = ctx.Err()
+			}
+			results[index] = result
+		}(i, req)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	wm.logger.WithFields(logrus.Fields{
+		"batch_size":  len(reqs),
+		"concurrency": concurrency,
+		"failed":      failed,
+		"operation":   "workflow_batch_complete",
+	}).Info("批量工作流执行完成")
+
+	return results, nil
+}
+
+// batchConcurrency 批量执行的并发数，不超过全局并发上限
+func (wm *WorkflowManager) batchConcurrency() int {
+	concurrency := wm.config.Workflows.BatchConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if limit := wm.config.Workflows.MaxConcurrentExecutions; limit > 0 && concurrency > limit {
+		concurrency = limit
+	}
+	return concurrency
+}
@@ -0,0 +1,69 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// 执行被取消的原因
+const (
+	CancelReasonUser               = "user"                // 通过取消接口取消，包括其他副本广播的取消请求
+	CancelReasonShutdown           = "shutdown"            // 服务关闭宽限期结束时仍未完成
+	CancelReasonClientDisconnected = "client_disconnected" // 客户端在完成前断开连接
+)
+
+// ErrExecutionCancelled 执行被主动取消
+var ErrExecutionCancelled = errors.New("工作流执行已被取消")
+
+// CancellationError 携带取消原因的取消错误，作为执行上下文的取消 cause 传给工作流
+type CancellationError struct {
+	Reason string
+}
+
+// Error 实现 error 接口
+func (e *CancellationError) Error() string {
+	return fmt.Sprintf("%s（原因：%s）", ErrExecutionCancelled.Error(), e.Reason)
+}
+
+// Unwrap 支持 errors.Is(err, ErrExecutionCancelled)
+func (e *CancellationError) Unwrap() error {
+	return ErrExecutionCancelled
+}
+
+// newExecutionContext 创建执行上下文：可携带原因取消，并带有执行超时
+// cancel 用于按原因中止执行，release 在执行结束后调用以释放资源
+func (e *DefaultWorkflowExecutor) newExecutionContext(ctx context.Context, req *WorkflowRequest) (execCtx context.Context, cancel context.CancelCauseFunc, release context.CancelFunc) {
+	cancelCtx, cancel := context.WithCancelCause(ctx)
+	timeoutCtx, cancelTimeout := context.WithTimeout(cancelCtx, e.timeoutFor(req))
+	return timeoutCtx, cancel, func() {
+		cancelTimeout()
+		cancel(nil)
+	}
+}
+
+// cancellationCause 执行上下文被按原因取消时返回取消错误
+func cancellationCause(ctx context.Context) *CancellationError {
+	var cancelErr *CancellationError
+	if errors.As(context.Cause(ctx), &cancelErr) {
+		return cancelErr
+	}
+	return nil
+}
+
+// statusForError 根据执行结果确定最终状态：completed、timeout、cancelled 或 failed，取消时同时返回原因
+func statusForError(err error) (status, cancelReason string) {
+	var cancelErr *CancellationError
+	switch {
+	case err == nil:
+		return "completed", ""
+	case errors.Is(err, ErrExecutionTimeout):
+		return "timeout", ""
+	case errors.As(err, &cancelErr):
+		return "cancelled", cancelErr.Reason
+	case errors.Is(err, ErrClientCanceled):
+		return "cancelled", CancelReasonClientDisconnected
+	default:
+		return "failed", ""
+	}
+}
@@ -0,0 +1,163 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/internal/testutil/credentialtest"
+)
+
+// hangingProvider 收到请求后一直挂起直到请求被中止的供应商替身
+type hangingProvider struct {
+	*httptest.Server
+	started chan struct{}
+	aborted chan struct{}
+	once    sync.Once
+}
+
+func newHangingProvider(t *testing.T) *hangingProvider {
+	t.Helper()
+	p := &hangingProvider{started: make(chan struct{}), aborted: make(chan struct{})}
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 读完请求体后服务端才会检测到客户端断开
+		io.Copy(io.Discard, r.Body)
+		p.once.Do(func() { close(p.started) })
+		select {
+		case <-r.Context().Done():
+			close(p.aborted)
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(p.Server.Close)
+	return p
+}
+
+func TestCancelExecutionAbortsProviderCall(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream bool
+	}{
+		{name: "阻塞执行"},
+		{name: "流式执行", stream: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newHangingProvider(t)
+			tenantService := testutil.NewTenantService(t)
+			tenantService.SetCredentials("tenant-1", testutil.Credential("openai", provider.URL))
+
+			registry := NewDefaultWorkflowRegistry(testutil.Logger())
+			workflow := NewEINOStandardChatWorkflow(credentialtest.NewManager(t, tenantService), 0, 0, testutil.Logger())
+			if err := registry.RegisterWorkflow("eino_standard_chat", workflow); err != nil {
+				t.Fatalf("RegisterWorkflow: %v", err)
+			}
+			executor := NewDefaultWorkflowExecutor(registry, testutil.Logger(), 10, time.Minute, NewMetricsCollector())
+			// 执行结束后从执行记录存储查询最终状态
+			redisClient, _ := newTestRedis(t)
+			executor.SetExecutionStore(NewExecutionStore(redisClient, time.Hour, testutil.Logger()))
+
+			req := &WorkflowRequest{
+				RequestID:    "req-1",
+				ExecutionID:  "exec-1",
+				TenantID:     "tenant-1",
+				UserID:       "user-1",
+				WorkflowType: "eino_standard_chat",
+				Message:      "写一篇很长的文章",
+				Stream:       tt.stream,
+				ModelConfig:  map[string]interface{}{"provider": "openai", "model": "gpt-4o-mini"},
+			}
+
+			done := make(chan error, 1)
+			go func() {
+				if !tt.stream {
+					_, err := executor.Execute(context.Background(), req)
+					done <- err
+					return
+				}
+				stream, err := executor.ExecuteStream(context.Background(), req)
+				if err != nil {
+					done <- err
+					return
+				}
+				last := drain(stream)
+				if last == nil || last.Type != StreamEventError {
+					done <- fmt.Errorf("流式执行结束事件 = %+v，期望 error", last)
+					return
+				}
+				done <- nil
+			}()
+
+			select {
+			case <-provider.started:
+			case <-time.After(2 * time.Second):
+				t.Fatal("供应商未收到请求")
+			}
+			start := time.Now()
+			if err := executor.CancelExecution("exec-1"); err != nil {
+				t.Fatalf("CancelExecution: %v", err)
+			}
+
+			select {
+			case <-provider.aborted:
+			case <-time.After(time.Second):
+				t.Fatal("取消后供应商请求未被中止")
+			}
+			select {
+			case err := <-done:
+				if !tt.stream && !errors.Is(err, ErrExecutionCancelled) {
+					t.Fatalf("Execute 错误 = %v，期望 ErrExecutionCancelled", err)
+				}
+				if tt.stream && err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("取消后执行未结束")
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("取消到结束耗时 %v", elapsed)
+			}
+
+			status, err := executor.GetExecutionStatus("exec-1")
+			if err != nil {
+				t.Fatalf("GetExecutionStatus: %v", err)
+			}
+			if status.Status != "cancelled" || status.CancelReason != CancelReasonUser {
+				t.Fatalf("执行状态 = %s/%s，期望 cancelled/%s", status.Status, status.CancelReason, CancelReasonUser)
+			}
+		})
+	}
+}
+
+func TestStatusForError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus string
+		wantReason string
+	}{
+		{name: "成功", err: nil, wantStatus: "completed"},
+		{name: "超时", err: fmt.Errorf("%w: deadline", ErrExecutionTimeout), wantStatus: "timeout"},
+		{name: "用户取消", err: fmt.Errorf("%w: canceled", &CancellationError{Reason: CancelReasonUser}), wantStatus: "cancelled", wantReason: CancelReasonUser},
+		{name: "服务关闭", err: &CancellationError{Reason: CancelReasonShutdown}, wantStatus: "cancelled", wantReason: CancelReasonShutdown},
+		{name: "客户端断开", err: fmt.Errorf("%w: canceled", ErrClientCanceled), wantStatus: "cancelled", wantReason: CancelReasonClientDisconnected},
+		{name: "其他错误", err: errors.New("upstream failure"), wantStatus: "failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, reason := statusForError(tt.err)
+			if status != tt.wantStatus || reason != tt.wantReason {
+				t.Fatalf("statusForError = %s/%s，期望 %s/%s", status, reason, tt.wantStatus, tt.wantReason)
+			}
+		})
+	}
+}
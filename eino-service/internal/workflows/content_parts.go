@@ -0,0 +1,126 @@
+package workflows
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+
+	"lyss-ai-platform/eino-service/internal/models"
+)
+
+// visionProviders 支持图片输入的供应商，其他供应商仅发送文本
+var visionProviders = map[string]bool{
+	"openai": true,
+	"ark":    true,
+}
+
+// allowedImageMimeTypes 允许的 base64 图片类型
+var allowedImageMimeTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// validateContentParts 校验内容块类型、图片地址格式以及 base64 图片的类型和大小
+func validateContentParts(parts []models.ContentPart, maxImageBytes int) error {
+	for i, part := range parts {
+		switch part.Type {
+		case models.ContentPartText:
+		case models.ContentPartImageURL:
+			if part.ImageURL == nil || part.ImageURL.URL == "" {
+				return fmt.Errorf("内容块 %d 缺少图片地址", i)
+			}
+			if err := validateImageURL(part.ImageURL.URL, maxImageBytes); err != nil {
+				return fmt.Errorf("内容块 %d 图片无效: %w", i, err)
+			}
+		default:
+			return fmt.Errorf("内容块 %d 类型 %s 不受支持", i, part.Type)
+		}
+	}
+	return nil
+}
+
+// validateImageURL 校验图片地址，data URI 需为允许的图片类型且不超过大小限制
+func validateImageURL(url string, maxImageBytes int) error {
+	if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+		return nil
+	}
+	if !strings.HasPrefix(url, "data:") {
+		return fmt.Errorf("仅支持 http(s) 链接或 base64 data URI")
+	}
+
+	header, payload, found := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+	mimeType, encoding, _ := strings.Cut(header, ";")
+	if !found || encoding != "base64" {
+		return fmt.Errorf("data URI 必须使用 base64 编码")
+	}
+	if !allowedImageMimeTypes[mimeType] {
+		return fmt.Errorf("不支持的图片类型 %s", mimeType)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return fmt.Errorf("base64 解码失败: %w", err)
+	}
+	if maxImageBytes > 0 && len(data) > maxImageBytes {
+		return &PayloadTooLargeError{Limit: "image_bytes", Actual: len(data), Max: maxImageBytes}
+	}
+	return nil
+}
+
+// hasImageParts 判断内容块中是否包含图片
+func hasImageParts(parts []models.ContentPart) bool {
+	for _, part := range parts {
+		if part.Type == models.ContentPartImageURL {
+			return true
+		}
+	}
+	return false
+}
+
+// buildUserMessage 构建当前用户消息
+// 供应商支持视觉时使用多模态内容，否则仅发送文本
+func buildUserMessage(req *WorkflowRequest, provider string) *schema.Message {
+	if !hasImageParts(req.ContentParts) || !visionProviders[provider] {
+		return &schema.Message{
+			Role:    schema.User,
+			Content: req.Message,
+		}
+	}
+
+	multiContent := make([]schema.ChatMessagePart, 0, len(req.ContentParts))
+	for _, part := range req.ContentParts {
+		switch part.Type {
+		case models.ContentPartText:
+			multiContent = append(multiContent, schema.ChatMessagePart{
+				Type: schema.ChatMessagePartTypeText,
+				Text: part.Text,
+			})
+		case models.ContentPartImageURL:
+			multiContent = append(multiContent, schema.ChatMessagePart{
+				Type: schema.ChatMessagePartTypeImageURL,
+				ImageURL: &schema.ChatMessageImageURL{
+					URL:    part.ImageURL.URL,
+					Detail: schema.ImageURLDetail(part.ImageURL.Detail),
+				},
+			})
+		}
+	}
+
+	return &schema.Message{
+		Role:         schema.User,
+		MultiContent: multiContent,
+	}
+}
+
+// applyContentPartsMetadata 供应商不支持图片输入时在响应元数据中说明已退化为纯文本
+func applyContentPartsMetadata(metadata map[string]interface{}, provider string, parts []models.ContentPart) {
+	if !hasImageParts(parts) || visionProviders[provider] {
+		return
+	}
+	metadata["images_ignored"] = true
+	metadata["images_note"] = fmt.Sprintf("供应商 %s 不支持图片输入，仅发送了文本内容", provider)
+}
@@ -0,0 +1,149 @@
+package workflows
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"lyss-ai-platform/eino-service/internal/models"
+)
+
+// imagePart 创建图片内容块
+func imagePart(url string) models.ContentPart {
+	return models.ContentPart{Type: models.ContentPartImageURL, ImageURL: &models.ImageURL{URL: url}}
+}
+
+// dataURI 创建指定类型与字节数的 base64 data URI
+func dataURI(mimeType string, size int) string {
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(make([]byte, size))
+}
+
+func TestValidateContentParts(t *testing.T) {
+	const maxImageBytes = 16
+
+	tests := []struct {
+		name         string
+		parts        []models.ContentPart
+		wantErr      string
+		wantTooLarge bool
+	}{
+		{
+			name:  "文本与图片链接",
+			parts: []models.ContentPart{{Type: models.ContentPartText, Text: "图里是什么"}, imagePart("https://example.com/cat.png")},
+		},
+		{
+			name:  "限制内的PNG",
+			parts: []models.ContentPart{imagePart(dataURI("image/png", maxImageBytes))},
+		},
+		{
+			name:         "超过大小限制",
+			parts:        []models.ContentPart{imagePart(dataURI("image/jpeg", maxImageBytes+1))},
+			wantTooLarge: true,
+		},
+		{
+			name:    "不允许的图片类型",
+			parts:   []models.ContentPart{imagePart(dataURI("image/svg+xml", 4))},
+			wantErr: "不支持的图片类型",
+		},
+		{
+			name:    "非base64编码",
+			parts:   []models.ContentPart{imagePart("data:image/png,raw")},
+			wantErr: "base64",
+		},
+		{
+			name:    "base64内容无效",
+			parts:   []models.ContentPart{imagePart("data:image/png;base64,@@@")},
+			wantErr: "base64 解码失败",
+		},
+		{
+			name:    "不支持的地址协议",
+			parts:   []models.ContentPart{imagePart("ftp://example.com/cat.png")},
+			wantErr: "仅支持",
+		},
+		{
+			name:    "缺少图片地址",
+			parts:   []models.ContentPart{{Type: models.ContentPartImageURL}},
+			wantErr: "缺少图片地址",
+		},
+		{
+			name:    "未知内容块类型",
+			parts:   []models.ContentPart{{Type: "audio"}},
+			wantErr: "不受支持",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateContentParts(tt.parts, maxImageBytes)
+
+			var tooLarge *PayloadTooLargeError
+			switch {
+			case tt.wantTooLarge:
+				if !errors.As(err, &tooLarge) || tooLarge.Limit != "image_bytes" {
+					t.Fatalf("错误 = %v，期望 image_bytes 的 PayloadTooLargeError", err)
+				}
+			case tt.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误 = %v，期望包含 %q", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("validateContentParts 返回错误: %v", err)
+			}
+		})
+	}
+}
+
+func TestBuildUserMessageMixedTextAndImage(t *testing.T) {
+	req := &WorkflowRequest{
+		Message: "图里是什么",
+		ContentParts: []models.ContentPart{
+			{Type: models.ContentPartText, Text: "图里是什么"},
+			{Type: models.ContentPartImageURL, ImageURL: &models.ImageURL{URL: "https://example.com/cat.png", Detail: "low"}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		provider    string
+		wantMulti   bool
+		wantIgnored bool
+	}{
+		{name: "支持视觉的供应商", provider: "openai", wantMulti: true},
+		{name: "方舟", provider: "ark", wantMulti: true},
+		{name: "不支持视觉的供应商", provider: "deepseek", wantIgnored: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := buildUserMessage(req, tt.provider)
+			if message.Role != schema.User {
+				t.Fatalf("role = %s，期望 user", message.Role)
+			}
+
+			if tt.wantMulti {
+				if message.Content != "" || len(message.MultiContent) != 2 {
+					t.Fatalf("消息 = %+v，期望两个多模态内容块且不含纯文本", message)
+				}
+				text, image := message.MultiContent[0], message.MultiContent[1]
+				if text.Type != schema.ChatMessagePartTypeText || text.Text != "图里是什么" {
+					t.Fatalf("文本块 = %+v", text)
+				}
+				if image.Type != schema.ChatMessagePartTypeImageURL || image.ImageURL == nil ||
+					image.ImageURL.URL != "https://example.com/cat.png" || image.ImageURL.Detail != schema.ImageURLDetailLow {
+					t.Fatalf("图片块 = %+v", image)
+				}
+			} else if message.Content != req.Message || len(message.MultiContent) != 0 {
+				t.Fatalf("消息 = %+v，期望退化为纯文本", message)
+			}
+
+			metadata := map[string]interface{}{}
+			applyContentPartsMetadata(metadata, tt.provider, req.ContentParts)
+			if ignored, _ := metadata["images_ignored"].(bool); ignored != tt.wantIgnored {
+				t.Fatalf("images_ignored = %v，期望 %v", ignored, tt.wantIgnored)
+			}
+		})
+	}
+}
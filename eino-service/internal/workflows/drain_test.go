@@ -0,0 +1,76 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDrainCompletesRunningAndCancelsSlowExecutions(t *testing.T) {
+	tests := []struct {
+		name          string
+		fast          int
+		slow          int
+		wantDrained   int
+		wantCancelled int
+	}{
+		{name: "运行中的执行在排空期间完成", fast: 1, wantDrained: 1},
+		{name: "慢执行在截止时间被取消", slow: 1, wantCancelled: 1},
+		{name: "完成与取消混合", fast: 2, slow: 1, wantDrained: 2, wantCancelled: 1},
+		{name: "没有运行中的执行"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor, slow := newTestExecutor(t, 10)
+			fast := &blockingWorkflow{release: make(chan struct{})}
+			if err := executor.registry.RegisterWorkflow("fast", fast); err != nil {
+				t.Fatalf("RegisterWorkflow: %v", err)
+			}
+
+			start := func(workflowType string, count int) []<-chan *WorkflowStreamResponse {
+				var streams []<-chan *WorkflowStreamResponse
+				for i := 0; i < count; i++ {
+					req := streamRequest(fmt.Sprintf("%s-%d", workflowType, i))
+					req.WorkflowType = workflowType
+					ch, err := executor.ExecuteStream(context.Background(), req)
+					if err != nil {
+						t.Fatalf("启动 %s 执行: %v", workflowType, err)
+					}
+					streams = append(streams, ch)
+				}
+				return streams
+			}
+			fastStreams := start("fast", tt.fast)
+			slowStreams := start("blocking", tt.slow)
+			defer close(slow.release)
+
+			// 排空开始后快执行才完成，慢执行一直运行到宽限期结束
+			time.AfterFunc(20*time.Millisecond, func() { close(fast.release) })
+
+			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+			defer cancel()
+			drained, cancelled := executor.Drain(ctx)
+			if drained != tt.wantDrained || cancelled != tt.wantCancelled {
+				t.Fatalf("Drain = (%d, %d)，期望 (%d, %d)", drained, cancelled, tt.wantDrained, tt.wantCancelled)
+			}
+
+			for _, ch := range fastStreams {
+				if last := drain(ch); last == nil || last.Type != StreamEventEnd {
+					t.Fatalf("排空期间完成的执行应以 end 结束，实际 %+v", last)
+				}
+			}
+			for _, ch := range slowStreams {
+				if last := drain(ch); last == nil || last.Type != StreamEventError {
+					t.Fatalf("被取消的执行应以 error 结束，实际 %+v", last)
+				}
+			}
+
+			if _, err := executor.ExecuteStream(context.Background(), streamRequest("after-drain")); !errors.Is(err, ErrShuttingDown) {
+				t.Fatalf("排空后应拒绝新的执行，实际 %v", err)
+			}
+		})
+	}
+}
@@ -4,32 +4,76 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
+	"strings"
 	"time"
 
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
 	"github.com/cloudwego/eino-ext/components/model/openai"
 	"github.com/cloudwego/eino-ext/components/model/deepseek"
 	"github.com/cloudwego/eino-ext/components/model/ark"
+	"github.com/cloudwego/eino-ext/components/model/gemini"
 	"github.com/sirupsen/logrus"
 
+	"lyss-ai-platform/eino-service/internal/client"
 	"lyss-ai-platform/eino-service/internal/models"
 	"lyss-ai-platform/eino-service/pkg/credential"
+	"lyss-ai-platform/eino-service/pkg/jsonmode"
 )
 
 // EINOStandardChatWorkflow 基于EINO官方标准的聊天工作流
 type EINOStandardChatWorkflow struct {
 	credentialManager *credential.Manager
+	maxFallbacks      int
+	maxStreamResumes  int
+	streamTimeouts    client.StreamTimeouts
+	httpClient        *http.Client
 	logger            *logrus.Logger
 }
 
+// einoProviders 标准EINO聊天工作流支持的供应商
+var einoProviders = []string{"openai", "deepseek", "ark", "google"}
+
+// streamResumeInstruction 流式输出中断后请求模型续写的指令
+const streamResumeInstruction = "上一条回答因连接中断未完成，请紧接着已输出的内容继续回答，不要重复已输出的部分。"
+
 // NewEINOStandardChatWorkflow 创建标准EINO聊天工作流
-func NewEINOStandardChatWorkflow(credentialManager *credential.Manager, logger *logrus.Logger) *EINOStandardChatWorkflow {
+// maxFallbacks 为模型调用出现可重试错误时换用备用凭证的最大次数
+// maxStreamResumes 为流式输出中途出现可重试错误时续写的最大次数
+func NewEINOStandardChatWorkflow(credentialManager *credential.Manager, maxFallbacks, maxStreamResumes int, logger *logrus.Logger) *EINOStandardChatWorkflow {
 	return &EINOStandardChatWorkflow{
 		credentialManager: credentialManager,
+		maxFallbacks:      maxFallbacks,
+		maxStreamResumes:  maxStreamResumes,
 		logger:            logger,
 	}
 }
 
+// SetStreamTimeouts 设置流式调用的首字节与空闲超时，超时视为可重试错误并按续写处理
+func (w *EINOStandardChatWorkflow) SetStreamTimeouts(timeouts client.StreamTimeouts) {
+	w.streamTimeouts = timeouts
+}
+
+// SetHTTPClient 设置调用供应商使用的HTTP客户端，为nil时由模型组件使用默认客户端
+// 使用共享传输层时复用连接池，并为出站请求附加请求ID与追踪头
+func (w *EINOStandardChatWorkflow) SetHTTPClient(httpClient *http.Client) {
+	w.httpClient = httpClient
+}
+
+// startStream 在超时看门狗的约束下发起流式调用，调用方负责停止返回的看门狗
+func (w *EINOStandardChatWorkflow) startStream(ctx context.Context, chatModel model.ChatModel, messages []*schema.Message) (*schema.StreamReader[*schema.Message], *client.StreamWatchdog, error) {
+	streamCtx, watchdog := client.NewStreamWatchdog(ctx, w.streamTimeouts)
+	streamResult, err := chatModel.Stream(streamCtx, messages)
+	if err != nil {
+		watchdog.Stop()
+		return nil, nil, watchdog.Err(err)
+	}
+	watchdog.Received()
+	return streamResult, watchdog, nil
+}
+
 // Execute 执行标准EINO聊天工作流
 func (w *EINOStandardChatWorkflow) Execute(ctx context.Context, req *WorkflowRequest) (*WorkflowResponse, error) {
 	startTime := time.Now()
@@ -51,35 +95,83 @@ func (w *EINOStandardChatWorkflow) Execute(ctx context.Context, req *WorkflowReq
 		}
 	}
 	
-	credential, err := w.credentialManager.GetBestCredentialForModel(req.TenantID, provider, "")
+	credential, err := w.credentialManager.SelectCredential(req.TenantID, provider, "", req.UserID, req.CredentialStrategy)
 	if err != nil {
 		return w.buildErrorResponse(startTime, fmt.Sprintf("获取凭证失败: %v", err), err)
 	}
 
-	// 2. 根据供应商创建ChatModel
-	chatModel, err := w.createChatModel(ctx, credential)
-	if err != nil {
-		return w.buildErrorResponse(startTime, fmt.Sprintf("创建聊天模型失败: %v", err), err)
-	}
+	// 2-4. 创建ChatModel并执行模型调用，可重试错误时换用备用凭证
+	var result *schema.Message
+	var chatModel model.ChatModel
+	var fallbacks []map[string]interface{}
+	failed := make(map[string]bool)
 
-	// 3. 构建输入消息
-	messages := w.buildMessages(req)
+	for {
+		chatModel, err = w.createChatModel(ctx, credential, req)
+		if err != nil {
+			return w.buildErrorResponse(startTime, fmt.Sprintf("创建聊天模型失败: %v", err), err)
+		}
 
-	// 4. 执行模型调用
-	result, err := chatModel.Generate(ctx, messages)
-	
+		var release func()
+		release, err = w.credentialManager.AcquireCall(ctx, credential)
+		if err == nil {
+			result, err = chatModel.Generate(ctx, w.buildMessages(req, credential.Provider))
+			release()
+			err = client.AsProviderError(credential.Provider, err)
+		}
+		if err == nil {
+			err = checkModelMessage(credential.Provider, result)
+		}
+		if err == nil {
+			break
+		}
+		w.credentialManager.RecordFailure(ctx, credential.ID.String(), err)
+
+		if len(fallbacks) >= w.maxFallbacks || !client.IsRetriableError(err) || ctx.Err() != nil {
+			return w.buildErrorResponse(startTime, fmt.Sprintf("模型调用失败: %v", err), err)
+		}
+
+		failed[credential.ID.String()] = true
+		next, selectErr := w.credentialManager.SelectFallbackCredential(req.TenantID, "", einoProviders, failed)
+		if selectErr != nil {
+			return w.buildErrorResponse(startTime, fmt.Sprintf("模型调用失败: %v", err), err)
+		}
+
+		w.logger.WithFields(logrus.Fields{
+			"request_id":    req.RequestID,
+			"execution_id":  req.ExecutionID,
+			"tenant_id":     req.TenantID,
+			"from_provider": credential.Provider,
+			"to_provider":   next.Provider,
+			"operation":     "provider_fallback",
+			"error":         err.Error(),
+		}).Warn("模型调用失败，换用备用凭证重试")
+
+		fallbacks = append(fallbacks, map[string]interface{}{
+			"from_provider":      credential.Provider,
+			"from_credential_id": credential.ID.String(),
+			"to_provider":        next.Provider,
+			"to_credential_id":   next.ID.String(),
+			"error":              err.Error(),
+		})
+		credential = next
+	}
+
+	// JSON模式下校验输出，无效时重试一次
+	result, jsonRetried, err := w.ensureJSONOutput(ctx, chatModel, w.buildMessages(req, credential.Provider), result, req, credential.Provider)
 	if err != nil {
-		return w.buildErrorResponse(startTime, fmt.Sprintf("模型调用失败: %v", err), err)
+		return w.buildErrorResponse(startTime, fmt.Sprintf("模型输出校验失败: %v", err), err)
 	}
 
 	// 5. 记录凭证使用
 	w.credentialManager.RecordUsage(credential.ID.String())
+	w.credentialManager.RecordSuccess(credential.ID.String())
 
 	// 6. 构建成功响应
 	response := &WorkflowResponse{
 		Success:         true,
 		Content:         result.Content,
-		Model:           w.getModelName(credential),
+		Model:           w.getModelName(credential, req),
 		WorkflowType:    "eino_standard_chat",
 		ExecutionTimeMs: time.Since(startTime).Milliseconds(),
 		Usage: &TokenUsage{
@@ -87,14 +179,24 @@ func (w *EINOStandardChatWorkflow) Execute(ctx context.Context, req *WorkflowReq
 			CompletionTokens: w.getCompletionTokens(result),
 			TotalTokens:      w.getTotalTokens(result),
 		},
+		FinishReason: w.getFinishReason(result),
 		Metadata: map[string]interface{}{
 			"provider":       credential.Provider,
 			"credential_id":  credential.ID.String(),
-			"model_used":     w.getModelName(credential),
+			"model_used":     w.getModelName(credential, req),
 			"eino_framework": "cloudwego/eino",
 			"workflow_type":  "standard_chat",
 		},
 	}
+	applySeedMetadata(response.Metadata, credential.Provider, resolveSeed(req))
+	applyContentPartsMetadata(response.Metadata, credential.Provider, req.ContentParts)
+	if len(fallbacks) > 0 {
+		response.Metadata["fallbacks"] = fallbacks
+	}
+	if req.ResponseFormat.JSONMode() {
+		response.Metadata["response_format"] = req.ResponseFormat.Type
+		response.Metadata["json_retried"] = jsonRetried
+	}
 
 	w.logger.WithFields(logrus.Fields{
 		"request_id":       req.RequestID,
@@ -104,7 +206,7 @@ func (w *EINOStandardChatWorkflow) Execute(ctx context.Context, req *WorkflowReq
 		"workflow_type":    "eino_standard_chat",
 		"operation":        "workflow_success",
 		"provider":         credential.Provider,
-		"model":            w.getModelName(credential),
+		"model":            w.getModelName(credential, req),
 		"execution_time_ms": response.ExecutionTimeMs,
 		"total_tokens":     response.Usage.TotalTokens,
 	}).Info("标准EINO聊天工作流执行成功")
@@ -135,106 +237,122 @@ func (w *EINOStandardChatWorkflow) ExecuteStream(ctx context.Context, req *Workf
 			}
 		}
 		
-		credential, err := w.credentialManager.GetBestCredentialForModel(req.TenantID, provider, "")
+		credential, err := w.credentialManager.SelectCredential(req.TenantID, provider, "", req.UserID, req.CredentialStrategy)
 		if err != nil {
 			responseChan <- &WorkflowStreamResponse{
-				Type:  "error",
+				Type:  StreamEventError,
 				Error: fmt.Sprintf("获取凭证失败: %v", err),
 			}
 			return
 		}
 
 		// 2. 根据供应商创建ChatModel
-		chatModel, err := w.createChatModel(ctx, credential)
+		chatModel, err := w.createChatModel(ctx, credential, req)
 		if err != nil {
 			responseChan <- &WorkflowStreamResponse{
-				Type:  "error",
+				Type:  StreamEventError,
 				Error: fmt.Sprintf("创建模型失败: %v", err),
 			}
 			return
 		}
 
 		// 3. 构建消息
-		messages := w.buildMessages(req)
+		messages := w.buildMessages(req, credential.Provider)
 
 		// 4. 发送开始事件
 		responseChan <- &WorkflowStreamResponse{
-			Type:        "start",
+			Type:        StreamEventStart,
 			ExecutionID: req.ExecutionID,
 			Data: map[string]any{
 				"provider": credential.Provider,
-				"model":    w.getModelName(credential),
+				"model":    w.getModelName(credential, req),
 			},
 		}
 
-		// 5. 执行流式调用
-		streamResult, err := chatModel.Stream(ctx, messages)
+		// 5. 执行流式调用，输出结束前一直占用凭证的并发名额
+		release, err := w.credentialManager.AcquireCall(ctx, credential)
 		if err != nil {
 			responseChan <- &WorkflowStreamResponse{
-				Type:  "error",
+				Type:  StreamEventError,
 				Error: fmt.Sprintf("流式调用失败: %v", err),
 			}
 			return
 		}
+		defer release()
 
-		// 6. 处理流式响应
-		var fullContent string
-		var chunks []*schema.Message
-		
-		for {
-			chunk, err := streamResult.Recv()
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				responseChan <- &WorkflowStreamResponse{
-					Type:  "error",
-					Error: fmt.Sprintf("接收流式数据失败: %v", err),
-				}
-				return
+		streamResult, watchdog, err := w.startStream(ctx, chatModel, messages)
+		if err != nil {
+			err = client.AsProviderError(credential.Provider, err)
+			w.credentialManager.RecordFailure(ctx, credential.ID.String(), err)
+			responseChan <- &WorkflowStreamResponse{
+				Type:  StreamEventError,
+				Error: fmt.Sprintf("流式调用失败: %v", err),
 			}
+			return
+		}
 
-			chunks = append(chunks, chunk)
-			fullContent += chunk.Content
-			
+		// 6. 处理流式响应，中途出现可重试错误时续写
+		chunks, usage, resumes, err := w.receiveStream(ctx, chatModel, streamResult, watchdog, messages, req, responseChan)
+		if err != nil {
+			err = client.AsProviderError(credential.Provider, err)
+			w.credentialManager.RecordFailure(ctx, credential.ID.String(), err)
 			responseChan <- &WorkflowStreamResponse{
-				Type:        "chunk",
-				ExecutionID: req.ExecutionID,
-				Content:     fullContent,
-				Data: map[string]any{
-					"delta": chunk.Content,
-				},
+				Type:  StreamEventError,
+				Error: fmt.Sprintf("接收流式数据失败: %v", err),
 			}
+			return
 		}
 
 		// 7. 合并最终消息
 		finalMessage, err := schema.ConcatMessages(chunks)
 		if err != nil {
 			responseChan <- &WorkflowStreamResponse{
-				Type:  "error",
+				Type:  StreamEventError,
 				Error: fmt.Sprintf("合并消息失败: %v", err),
 			}
 			return
 		}
+		// ConcatMessages 按字段取各分块用量的最大值，续写后会丢失被中断请求的用量，改用各次请求的合计
+		if usage != nil {
+			if finalMessage.ResponseMeta == nil {
+				finalMessage.ResponseMeta = &schema.ResponseMeta{}
+			}
+			finalMessage.ResponseMeta.Usage = usage
+		}
+
+		// JSON模式下校验完整输出，无效时以非流式调用重试一次，结束事件的 final_content 为校验后的JSON
+		finalMessage, jsonRetried, err := w.ensureJSONOutput(ctx, chatModel, messages, finalMessage, req, credential.Provider)
+		if err != nil {
+			responseChan <- &WorkflowStreamResponse{
+				Type:  StreamEventError,
+				Error: fmt.Sprintf("模型输出校验失败: %v", err),
+			}
+			return
+		}
 
 		// 8. 发送结束事件
 		responseChan <- &WorkflowStreamResponse{
-			Type:        "end",
+			Type:        StreamEventEnd,
 			ExecutionID: req.ExecutionID,
 			Data: map[string]any{
 				"final_content": finalMessage.Content,
 				"provider":      credential.Provider,
-				"model":         w.getModelName(credential),
+				"credential_id": credential.ID.String(),
+				"model":         w.getModelName(credential, req),
+				"finish_reason": w.getFinishReason(finalMessage),
 				"usage": map[string]int{
 					"prompt_tokens":     w.getPromptTokensFromMessage(finalMessage),
 					"completion_tokens": w.getCompletionTokensFromMessage(finalMessage),
 					"total_tokens":      w.getTotalTokensFromMessage(finalMessage),
 				},
+				"stream_resumes": resumes,
+				"json_retried":   jsonRetried,
 			},
 		}
 
 		// 9. 记录凭证使用
 		w.credentialManager.RecordUsage(credential.ID.String())
+		w.credentialManager.RecordSuccess(credential.ID.String())
 
 		w.logger.WithFields(logrus.Fields{
 			"execution_id":  req.ExecutionID,
@@ -243,13 +361,131 @@ func (w *EINOStandardChatWorkflow) ExecuteStream(ctx context.Context, req *Workf
 			"workflow_type": "eino_standard_chat",
 			"operation":     "workflow_stream_success",
 			"provider":      credential.Provider,
-			"model":         w.getModelName(credential),
+			"model":         w.getModelName(credential, req),
 		}).Info("标准EINO流式聊天工作流执行成功")
 	}()
 
 	return responseChan, nil
 }
 
+// receiveStream 接收流式输出并转发增量内容
+// 中途出现可重试错误时，将已输出内容作为助手消息并附加续写指令重新发起请求，续写内容接在已输出内容之后
+// 返回全部分块、各次请求的令牌用量合计（供应商未返回用量时为nil）以及每次续写的记录（续写起点与中断原因）
+func (w *EINOStandardChatWorkflow) receiveStream(
+	ctx context.Context,
+	chatModel model.ChatModel,
+	streamResult *schema.StreamReader[*schema.Message],
+	watchdog *client.StreamWatchdog,
+	messages []*schema.Message,
+	req *WorkflowRequest,
+	responseChan chan<- *WorkflowStreamResponse,
+) ([]*schema.Message, *schema.TokenUsage, []map[string]any, error) {
+	var fullContent strings.Builder
+	var chunks []*schema.Message
+	resumes := make([]map[string]any, 0)
+
+	// 被中断的请求同样已经计费：同一次请求内按字段取最大值（与 ConcatMessages 一致），各次请求之间累加
+	var usage, attemptUsage *schema.TokenUsage
+	finishAttempt := func() {
+		if attemptUsage == nil {
+			return
+		}
+		if usage == nil {
+			usage = &schema.TokenUsage{}
+		}
+		usage.PromptTokens += attemptUsage.PromptTokens
+		usage.CompletionTokens += attemptUsage.CompletionTokens
+		usage.TotalTokens += attemptUsage.TotalTokens
+		attemptUsage = nil
+	}
+
+	for {
+		chunk, err := streamResult.Recv()
+		if err == io.EOF {
+			streamResult.Close()
+			watchdog.Stop()
+			finishAttempt()
+			return chunks, usage, resumes, nil
+		}
+		if err != nil {
+			streamResult.Close()
+			err = watchdog.Err(err)
+			watchdog.Stop()
+			if len(resumes) >= w.maxStreamResumes || !client.IsRetriableError(err) {
+				return nil, nil, resumes, err
+			}
+			finishAttempt()
+
+			w.logger.WithFields(logrus.Fields{
+				"execution_id": req.ExecutionID,
+				"tenant_id":    req.TenantID,
+				"attempt":      len(resumes) + 1,
+				"offset":       fullContent.Len(),
+				"operation":    "stream_resume",
+				"error":        err.Error(),
+			}).Warn("流式输出中断，携带已输出内容续写")
+
+			resumes = append(resumes, map[string]any{
+				"offset": fullContent.Len(),
+				"error":  err.Error(),
+			})
+			streamResult, watchdog, err = w.startStream(ctx, chatModel, buildResumeMessages(messages, fullContent.String()))
+			if err != nil {
+				return nil, nil, resumes, fmt.Errorf("续写请求失败: %w", err)
+			}
+			continue
+		}
+
+		watchdog.Received()
+		if chunk == nil {
+			continue
+		}
+		chunks = append(chunks, chunk)
+		fullContent.WriteString(chunk.Content)
+		if chunk.ResponseMeta != nil && chunk.ResponseMeta.Usage != nil {
+			attemptUsage = maxTokenUsage(attemptUsage, chunk.ResponseMeta.Usage)
+		}
+
+		data := map[string]any{
+			"delta": chunk.Content,
+		}
+		if len(resumes) > 0 {
+			data["resumed"] = true
+		}
+		responseChan <- &WorkflowStreamResponse{
+			Type:        StreamEventChunk,
+			ExecutionID: req.ExecutionID,
+			Content:     fullContent.String(),
+			Data:        data,
+		}
+	}
+}
+
+// maxTokenUsage 按字段取两份用量的最大值，current 为nil时视为0
+func maxTokenUsage(current, usage *schema.TokenUsage) *schema.TokenUsage {
+	merged := &schema.TokenUsage{}
+	if current != nil {
+		*merged = *current
+	}
+	merged.PromptTokens = max(merged.PromptTokens, usage.PromptTokens)
+	merged.CompletionTokens = max(merged.CompletionTokens, usage.CompletionTokens)
+	merged.TotalTokens = max(merged.TotalTokens, usage.TotalTokens)
+	return merged
+}
+
+// buildResumeMessages 构建续写请求的消息：原始消息、已输出的助手内容以及续写指令
+func buildResumeMessages(messages []*schema.Message, partial string) []*schema.Message {
+	resumed := make([]*schema.Message, 0, len(messages)+2)
+	resumed = append(resumed, messages...)
+	if partial != "" {
+		resumed = append(resumed,
+			schema.AssistantMessage(partial, nil),
+			schema.UserMessage(streamResumeInstruction),
+		)
+	}
+	return resumed
+}
+
 // GetInfo 获取工作流信息
 func (w *EINOStandardChatWorkflow) GetInfo() *WorkflowInfo {
 	return &WorkflowInfo{
@@ -269,15 +505,23 @@ func (w *EINOStandardChatWorkflow) GetInfo() *WorkflowInfo {
 				Name:        "provider",
 				Type:        "string",
 				Required:    false,
-				Description: "AI供应商（openai、deepseek、ark等）",
+				Description: "AI供应商（openai、deepseek、ark、google等）",
 				Default:     "openai",
 			},
+			{
+				Name:        "conversation_history",
+				Type:        "array",
+				Required:    false,
+				Description: "历史对话消息，元素为包含 role（system、user、assistant）和 content 的对象",
+			},
 		},
 		SupportedFeatures: []string{
 			"basic_chat",
+			"multi_turn",
 			"streaming",
 			"multi_provider",
 			"official_eino",
+			"json_mode",
 		},
 		Nodes: []WorkflowNodeInfo{
 			{
@@ -305,15 +549,15 @@ func (w *EINOStandardChatWorkflow) GetInfo() *WorkflowInfo {
 }
 
 // buildEINOChain 使用EINO官方API构建聊天链
-func (w *EINOStandardChatWorkflow) buildEINOChain(ctx context.Context, credential *models.SupplierCredential) (eino.CompiledChain, error) {
+func (w *EINOStandardChatWorkflow) buildEINOChain(ctx context.Context, credential *models.SupplierCredential, req *WorkflowRequest) (compose.Runnable[map[string]any, *schema.Message], error) {
 	// 根据供应商创建对应的ChatModel
-	chatModel, err := w.createChatModel(ctx, credential)
+	chatModel, err := w.createChatModel(ctx, credential, req)
 	if err != nil {
 		return nil, fmt.Errorf("创建聊天模型失败: %w", err)
 	}
 
 	// 使用EINO官方Chain API构建工作流
-	chain, err := eino.NewChain[map[string]any, *schema.Message]().
+	chain, err := compose.NewChain[map[string]any, *schema.Message]().
 		AppendChatModel(chatModel).
 		Compile(ctx)
 
@@ -325,31 +569,113 @@ func (w *EINOStandardChatWorkflow) buildEINOChain(ctx context.Context, credentia
 }
 
 // createChatModel 根据供应商创建对应的ChatModel
-func (w *EINOStandardChatWorkflow) createChatModel(ctx context.Context, credential *models.SupplierCredential) (eino.ChatModel, error) {
+func (w *EINOStandardChatWorkflow) createChatModel(ctx context.Context, credential *models.SupplierCredential, req *WorkflowRequest) (model.ChatModel, error) {
+	params := resolveGenerationParams(req)
+	modelName := w.getModelName(credential, req)
+
+	// 凭证配置了额外请求头时在工作流客户端上附加请求头
+	httpClient := client.WithExtraHeaders(w.httpClient, client.ExtraHeaders(credential))
+
 	switch credential.Provider {
 	case "openai":
-		return openai.NewChatModel(ctx, &openai.ChatModelConfig{
-			APIKey:  credential.APIKey,
-			Model:   w.getModelName(credential),
-			BaseURL: credential.BaseURL,
-		})
+		config := w.buildOpenAIConfig(credential, modelName, params)
+		config.HTTPClient = httpClient
+		responseFormat, err := buildOpenAIResponseFormat(req.ResponseFormat)
+		if err != nil {
+			return nil, err
+		}
+		config.ResponseFormat = responseFormat
+		return openai.NewChatModel(ctx, config)
 	case "deepseek":
-		return deepseek.NewChatModel(ctx, &deepseek.ChatModelConfig{
-			APIKey: credential.APIKey,
-			Model:  w.getModelName(credential),
-		})
+		config := w.buildDeepSeekConfig(credential, modelName, params)
+		config.HTTPClient = httpClient
+		if jsonmode.NativeType(credential.Provider, req.ResponseFormat) != "" {
+			config.ResponseFormatType = deepseek.ResponseFormatTypeJSONObject
+		}
+		return deepseek.NewChatModel(ctx, config)
 	case "ark":
-		return ark.NewChatModel(ctx, &ark.ChatModelConfig{
-			APIKey: credential.APIKey,
-			Model:  w.getModelName(credential),
-		})
+		config := w.buildArkConfig(credential, modelName, params)
+		config.HTTPClient = httpClient
+		return ark.NewChatModel(ctx, config)
+	case "google":
+		geminiClient, err := client.NewGeminiClient(ctx, credential.APIKey, credential.BaseURL, httpClient, w.logger)
+		if err != nil {
+			return nil, err
+		}
+		return gemini.NewChatModel(ctx, w.buildGeminiConfig(geminiClient, modelName, params))
 	default:
 		return nil, fmt.Errorf("不支持的供应商: %s", credential.Provider)
 	}
 }
 
+// buildOpenAIConfig 构建OpenAI模型配置，仅设置请求显式提供的参数
+func (w *EINOStandardChatWorkflow) buildOpenAIConfig(credential *models.SupplierCredential, modelName string, params *generationParams) *openai.ChatModelConfig {
+	return &openai.ChatModelConfig{
+		APIKey:      credential.APIKey,
+		Model:       modelName,
+		BaseURL:     credential.BaseURL,
+		Temperature: params.Temperature,
+		MaxTokens:   params.MaxTokens,
+		TopP:        params.TopP,
+		Stop:        params.Stop,
+		Seed:        params.Seed,
+	}
+}
+
+// buildDeepSeekConfig 构建DeepSeek模型配置，零值字段由供应商使用默认值
+func (w *EINOStandardChatWorkflow) buildDeepSeekConfig(credential *models.SupplierCredential, modelName string, params *generationParams) *deepseek.ChatModelConfig {
+	config := &deepseek.ChatModelConfig{
+		APIKey:  credential.APIKey,
+		Model:   modelName,
+		BaseURL: credential.BaseURL,
+		Stop:    params.Stop,
+	}
+	if params.Temperature != nil {
+		config.Temperature = *params.Temperature
+	}
+	if params.MaxTokens != nil {
+		config.MaxTokens = *params.MaxTokens
+	}
+	if params.TopP != nil {
+		config.TopP = *params.TopP
+	}
+	return config
+}
+
+// buildArkConfig 构建火山方舟模型配置，仅设置请求显式提供的参数
+func (w *EINOStandardChatWorkflow) buildArkConfig(credential *models.SupplierCredential, modelName string, params *generationParams) *ark.ChatModelConfig {
+	return &ark.ChatModelConfig{
+		APIKey:      credential.APIKey,
+		Model:       modelName,
+		BaseURL:     credential.BaseURL,
+		Temperature: params.Temperature,
+		MaxTokens:   params.MaxTokens,
+		TopP:        params.TopP,
+		Stop:        params.Stop,
+	}
+}
+
+// buildGeminiConfig 构建Gemini模型配置，仅设置请求显式提供的参数
+// EINO 的 Gemini 模型配置未暴露 stop 与 seed，两者会被忽略
+func (w *EINOStandardChatWorkflow) buildGeminiConfig(geminiClient *client.GeminiClient, modelName string, params *generationParams) *gemini.Config {
+	return &gemini.Config{
+		Client:      geminiClient.GenAI(),
+		Model:       modelName,
+		Temperature: params.Temperature,
+		MaxTokens:   params.MaxTokens,
+		TopP:        params.TopP,
+	}
+}
+
+// historyRoles 对话历史中允许的角色
+var historyRoles = map[string]schema.RoleType{
+	"system":    schema.System,
+	"user":      schema.User,
+	"assistant": schema.Assistant,
+}
+
 // buildMessages 构建EINO schema消息
-func (w *EINOStandardChatWorkflow) buildMessages(req *WorkflowRequest) []*schema.Message {
+func (w *EINOStandardChatWorkflow) buildMessages(req *WorkflowRequest, provider string) []*schema.Message {
 	var messages []*schema.Message
 
 	// 添加系统提示（如果存在）
@@ -360,11 +686,65 @@ func (w *EINOStandardChatWorkflow) buildMessages(req *WorkflowRequest) []*schema
 		})
 	}
 
+	// JSON模式下附加只输出JSON的系统指令
+	if instruction := jsonmode.Instruction(req.ResponseFormat); instruction != "" {
+		messages = append(messages, schema.SystemMessage(instruction))
+	}
+
+	// 添加对话历史（如果存在）
+	messages = append(messages, w.buildHistoryMessages(req)...)
+
 	// 添加用户消息
-	messages = append(messages, &schema.Message{
-		Role:    schema.User,
-		Content: req.Message,
-	})
+	messages = append(messages, buildUserMessage(req, provider))
+
+	return messages
+}
+
+// buildHistoryMessages 将 conversation_history 转换为EINO schema消息
+// 角色无效或内容缺失的条目会被跳过并记录警告
+func (w *EINOStandardChatWorkflow) buildHistoryMessages(req *WorkflowRequest) []*schema.Message {
+	var items []interface{}
+	switch history := req.Configuration["conversation_history"].(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		items = history
+	case []map[string]interface{}:
+		for _, item := range history {
+			items = append(items, item)
+		}
+	default:
+		w.logger.WithFields(logrus.Fields{
+			"request_id":   req.RequestID,
+			"execution_id": req.ExecutionID,
+			"operation":    "build_history",
+		}).Warn("conversation_history 格式无效，已忽略")
+		return nil
+	}
+
+	messages := make([]*schema.Message, 0, len(items))
+	for index, item := range items {
+		entry, _ := item.(map[string]interface{})
+		role, _ := entry["role"].(string)
+		content, contentOk := entry["content"].(string)
+
+		schemaRole, roleOk := historyRoles[role]
+		if !roleOk || !contentOk {
+			w.logger.WithFields(logrus.Fields{
+				"request_id":   req.RequestID,
+				"execution_id": req.ExecutionID,
+				"index":        index,
+				"role":         role,
+				"operation":    "build_history",
+			}).Warn("跳过无效的历史消息")
+			continue
+		}
+
+		messages = append(messages, &schema.Message{
+			Role:    schemaRole,
+			Content: content,
+		})
+	}
 
 	return messages
 }
@@ -382,7 +762,13 @@ func (w *EINOStandardChatWorkflow) buildErrorResponse(startTime time.Time, messa
 }
 
 // getModelName 获取模型名称
-func (w *EINOStandardChatWorkflow) getModelName(credential *models.SupplierCredential) string {
+// 请求指定的模型（已由别名解析为具体模型ID）属于凭证的供应商时优先使用，否则使用凭证配置的模型
+func (w *EINOStandardChatWorkflow) getModelName(credential *models.SupplierCredential, req *WorkflowRequest) string {
+	if provider, _ := req.ModelConfig["provider"].(string); provider == credential.Provider {
+		if model, ok := req.ModelConfig["model"].(string); ok && model != "" {
+			return model
+		}
+	}
 	if model, exists := credential.ModelConfigs["model"]; exists {
 		return model.(string)
 	}
@@ -395,11 +781,33 @@ func (w *EINOStandardChatWorkflow) getModelName(credential *models.SupplierCrede
 		return "deepseek-chat"
 	case "ark":
 		return "default-ark-model"
+	case "google":
+		return "gemini-1.5-flash"
 	default:
 		return "unknown"
 	}
 }
 
+// checkModelMessage 校验模型组件返回的消息，为 nil 时返回 EmptyResponseError 而不是继续访问
+// 供应商返回 "message": null 时模型组件给出空消息，既无内容也无工具调用同样按空响应处理
+func checkModelMessage(provider string, message *schema.Message) error {
+	if message == nil {
+		return &client.EmptyResponseError{Provider: provider, Reason: "message 为空"}
+	}
+	if message.Content == "" && len(message.ToolCalls) == 0 {
+		return &client.EmptyResponseError{Provider: provider, Reason: "content 为空"}
+	}
+	return nil
+}
+
+// getFinishReason 获取模型输出的结束原因
+func (w *EINOStandardChatWorkflow) getFinishReason(result *schema.Message) string {
+	if result.ResponseMeta != nil {
+		return result.ResponseMeta.FinishReason
+	}
+	return ""
+}
+
 // Token统计辅助方法
 func (w *EINOStandardChatWorkflow) getPromptTokens(result *schema.Message) int {
 	if result.ResponseMeta != nil && result.ResponseMeta.Usage != nil {
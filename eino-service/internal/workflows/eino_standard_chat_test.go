@@ -0,0 +1,98 @@
+package workflows
+
+import (
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+// roleContent 消息的角色与内容，便于比较
+type roleContent struct {
+	role    schema.RoleType
+	content string
+}
+
+func TestBuildMessagesConversationHistory(t *testing.T) {
+	threeTurns := []interface{}{
+		map[string]interface{}{"role": "user", "content": "你好"},
+		map[string]interface{}{"role": "assistant", "content": "你好，有什么可以帮你？"},
+		map[string]interface{}{"role": "user", "content": "介绍一下上海"},
+	}
+
+	tests := []struct {
+		name          string
+		configuration map[string]interface{}
+		want          []roleContent
+	}{
+		{
+			name:          "三轮历史生成四条消息",
+			configuration: map[string]interface{}{"conversation_history": threeTurns},
+			want: []roleContent{
+				{schema.User, "你好"},
+				{schema.Assistant, "你好，有什么可以帮你？"},
+				{schema.User, "介绍一下上海"},
+				{schema.User, "天气怎么样？"},
+			},
+		},
+		{
+			name: "系统提示在历史之前",
+			configuration: map[string]interface{}{
+				"system_prompt":        "简洁回答",
+				"conversation_history": threeTurns[:1],
+			},
+			want: []roleContent{
+				{schema.System, "简洁回答"},
+				{schema.User, "你好"},
+				{schema.User, "天气怎么样？"},
+			},
+		},
+		{
+			name: "跳过角色无效或内容缺失的条目",
+			configuration: map[string]interface{}{"conversation_history": []interface{}{
+				map[string]interface{}{"role": "tool", "content": "无效角色"},
+				map[string]interface{}{"role": "assistant"},
+				"不是对象",
+				map[string]interface{}{"role": "assistant", "content": "有效"},
+			}},
+			want: []roleContent{
+				{schema.Assistant, "有效"},
+				{schema.User, "天气怎么样？"},
+			},
+		},
+		{
+			name:          "支持 []map[string]interface{} 格式",
+			configuration: map[string]interface{}{"conversation_history": []map[string]interface{}{{"role": "system", "content": "历史系统消息"}}},
+			want: []roleContent{
+				{schema.System, "历史系统消息"},
+				{schema.User, "天气怎么样？"},
+			},
+		},
+		{
+			name:          "格式无效的历史被忽略",
+			configuration: map[string]interface{}{"conversation_history": "你好"},
+			want:          []roleContent{{schema.User, "天气怎么样？"}},
+		},
+	}
+
+	w := NewEINOStandardChatWorkflow(nil, 0, 0, testutil.Logger())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := w.buildMessages(&WorkflowRequest{Message: "天气怎么样？", Configuration: tt.configuration}, "openai")
+
+			got := make([]roleContent, len(messages))
+			for i, message := range messages {
+				got[i] = roleContent{message.Role, message.Content}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("消息 = %v，期望 %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("第 %d 条消息 = %v，期望 %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
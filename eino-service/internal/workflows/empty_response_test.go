@@ -0,0 +1,95 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/client"
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/internal/testutil/credentialtest"
+)
+
+func TestEINOStandardChatEmptyProviderResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream bool
+		body   string
+		// wantTyped 为 false 时空 choices 由模型组件自身拒绝，只要求调用失败且不 panic
+		wantTyped bool
+	}{
+		{name: "choices为空", body: `{"id":"1","object":"chat.completion","choices":[]}`},
+		{name: "message为null", body: `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":null}]}`, wantTyped: true},
+		{
+			name:   "流式分块choices为空",
+			stream: true,
+			body: "data: {\"id\":\"1\",\"object\":\"chat.completion.chunk\",\"choices\":[]}\n\n" +
+				"data: {\"id\":\"1\",\"object\":\"chat.completion.chunk\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"你好\"}}]}\n\n" +
+				"data: {\"id\":\"1\",\"object\":\"chat.completion.chunk\",\"choices\":[]}\n\n" +
+				"data: [DONE]\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.stream {
+					w.Header().Set("Content-Type", "text/event-stream")
+				} else {
+					w.Header().Set("Content-Type", "application/json")
+				}
+				io.WriteString(w, tt.body)
+			}))
+			t.Cleanup(provider.Close)
+
+			tenantService := testutil.NewTenantService(t)
+			tenantService.SetCredentials("tenant-1", testutil.Credential("openai", provider.URL))
+			workflow := NewEINOStandardChatWorkflow(credentialtest.NewManager(t, tenantService), 0, 0, testutil.Logger())
+
+			req := &WorkflowRequest{
+				RequestID:   "req-1",
+				ExecutionID: "exec-1",
+				TenantID:    "tenant-1",
+				UserID:      "user-1",
+				Message:     "你好",
+				Stream:      tt.stream,
+				ModelConfig: map[string]interface{}{"provider": "openai", "model": "gpt-4o-mini"},
+			}
+
+			if !tt.stream {
+				resp, err := workflow.Execute(context.Background(), req)
+				if err == nil {
+					t.Fatal("期望空响应返回错误")
+				}
+				if tt.wantTyped && !errors.Is(err, client.ErrEmptyProviderResponse) {
+					t.Fatalf("错误 = %v，期望 ErrEmptyProviderResponse", err)
+				}
+				if resp != nil && resp.Success {
+					t.Fatalf("响应 = %+v，期望空响应返回失败", resp)
+				}
+				return
+			}
+
+			stream, err := workflow.ExecuteStream(context.Background(), req)
+			if err != nil {
+				t.Fatalf("ExecuteStream: %v", err)
+			}
+			var content strings.Builder
+			for event := range stream {
+				if event.Type == StreamEventError {
+					t.Fatalf("流式错误: %s", event.Error)
+				}
+				if delta, ok := event.Data["delta"].(string); ok {
+					content.WriteString(delta)
+				}
+			}
+			if content.String() != "你好" {
+				t.Fatalf("流式内容 = %q，期望跳过空分块后为 你好", content.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// 取消执行的广播频道
+const executionCancelChannel = "workflow:execution:cancel"
+
+// 单次Redis操作的超时时间，避免Redis不可用时阻塞执行
+const executionStoreTimeout = 2 * time.Second
+
+// ErrExecutionNotFound 执行记录不存在
+var ErrExecutionNotFound = errors.New("执行记录不存在")
+
+// executionRecord 跨副本共享的执行记录
+// 不包含 State 与 Configuration，避免将请求内容写入Redis
+type executionRecord struct {
+	ExecutionID  string         `json:"execution_id"`
+	RequestID    string         `json:"request_id"`
+	TenantID     string         `json:"tenant_id"`
+	UserID       string         `json:"user_id"`
+	WorkflowType string         `json:"workflow_type"`
+	Steps        []WorkflowStep `json:"steps"`
+	TotalSteps   int            `json:"total_steps"`
+	StartTime    int64          `json:"start_time"`
+	EndTime      int64          `json:"end_time"`
+	Status       string         `json:"status"`
+	CancelReason string         `json:"cancel_reason,omitempty"`
+}
+
+// ExecutionStore 基于Redis的执行记录存储
+// 各副本写入自身执行的状态，并通过频道广播取消请求，使状态查询与取消可以跨副本生效
+type ExecutionStore struct {
+	redisClient *redis.Client
+	ttl         time.Duration
+	logger      *logrus.Logger
+}
+
+// NewExecutionStore 创建执行记录存储
+// ttl 为执行记录在Redis中的保留时间
+func NewExecutionStore(redisClient *redis.Client, ttl time.Duration, logger *logrus.Logger) *ExecutionStore {
+	return &ExecutionStore{
+		redisClient: redisClient,
+		ttl:         ttl,
+		logger:      logger,
+	}
+}
+
+// Save 保存执行记录
+func (s *ExecutionStore) Save(ctx context.Context, execCtx *WorkflowExecutionContext) error {
+	payload, err := json.Marshal(&executionRecord{
+		ExecutionID:  execCtx.ExecutionID,
+		RequestID:    execCtx.RequestID,
+		TenantID:     execCtx.TenantID,
+		UserID:       execCtx.UserID,
+		WorkflowType: execCtx.WorkflowType,
+		Steps:        execCtx.Steps,
+		TotalSteps:   execCtx.TotalSteps,
+		StartTime:    execCtx.StartTime,
+		EndTime:      execCtx.EndTime,
+		Status:       execCtx.Status,
+		CancelReason: execCtx.CancelReason,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化执行记录失败: %w", err)
+	}
+
+	if err := s.redisClient.Set(ctx, s.buildKey(execCtx.ExecutionID), payload, s.ttl).Err(); err != nil {
+		return fmt.Errorf("保存执行记录失败: %w", err)
+	}
+	return nil
+}
+
+// Load 读取执行记录
+func (s *ExecutionStore) Load(ctx context.Context, executionID string) (*WorkflowExecutionContext, error) {
+	payload, err := s.redisClient.Get(ctx, s.buildKey(executionID)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrExecutionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取执行记录失败: %w", err)
+	}
+
+	var record executionRecord
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return nil, fmt.Errorf("解析执行记录失败: %w", err)
+	}
+
+	return &WorkflowExecutionContext{
+		ExecutionID:  record.ExecutionID,
+		RequestID:    record.RequestID,
+		TenantID:     record.TenantID,
+		UserID:       record.UserID,
+		WorkflowType: record.WorkflowType,
+		Steps:        record.Steps,
+		TotalSteps:   record.TotalSteps,
+		StartTime:    record.StartTime,
+		EndTime:      record.EndTime,
+		Status:       record.Status,
+		CancelReason: record.CancelReason,
+	}, nil
+}
+
+// PublishCancel 广播取消请求，由运行该执行的副本处理
+func (s *ExecutionStore) PublishCancel(ctx context.Context, executionID string) error {
+	if err := s.redisClient.Publish(ctx, executionCancelChannel, executionID).Err(); err != nil {
+		return fmt.Errorf("广播取消请求失败: %w", err)
+	}
+	return nil
+}
+
+// SubscribeCancel 订阅取消请求，直到 ctx 结束
+func (s *ExecutionStore) SubscribeCancel(ctx context.Context, handler func(executionID string)) {
+	pubsub := s.redisClient.Subscribe(ctx, executionCancelChannel)
+
+	go func() {
+		defer pubsub.Close()
+
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				handler(msg.Payload)
+			}
+		}
+	}()
+
+	s.logger.WithFields(logrus.Fields{
+		"channel":   executionCancelChannel,
+		"operation": "execution_cancel_subscribe",
+	}).Info("已订阅工作流取消频道")
+}
+
+// buildKey 构建Redis键
+func (s *ExecutionStore) buildKey(executionID string) string {
+	return fmt.Sprintf("workflow:execution:%s", executionID)
+}
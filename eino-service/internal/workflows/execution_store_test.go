@@ -0,0 +1,128 @@
+package workflows
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+// newReplicas 创建共享同一个 miniredis 的两个执行器，模拟两个副本
+func newReplicas(t *testing.T) (owner *DefaultWorkflowExecutor, workflow *blockingWorkflow, other *DefaultWorkflowExecutor) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+
+	newReplica := func() (*DefaultWorkflowExecutor, *blockingWorkflow) {
+		redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { redisClient.Close() })
+
+		executor, workflow := newTestExecutor(t, 10)
+		executor.SetExecutionStore(NewExecutionStore(redisClient, time.Hour, testutil.Logger()))
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		executor.StartCancelListener(ctx)
+		return executor, workflow
+	}
+
+	owner, workflow = newReplica()
+	other, _ = newReplica()
+	return owner, workflow, other
+}
+
+// waitForStatus 轮询副本上的执行状态，直到达到期望状态或超时
+func waitForStatus(t *testing.T, executor *DefaultWorkflowExecutor, executionID, want string) *WorkflowExecutionStatus {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		status, err := executor.GetExecutionStatus(executionID)
+		if err == nil && status.Status == want {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("执行状态未变为 %s，最后一次结果 %+v，错误 %v", want, status, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestExecutionStatusSharedAcrossReplicas(t *testing.T) {
+	tests := []struct {
+		name       string
+		finish     func(t *testing.T, workflow *blockingWorkflow, other *DefaultWorkflowExecutor)
+		wantStatus string
+		wantEvent  string
+		wantReason string
+	}{
+		{
+			name:       "运行中",
+			wantStatus: "running",
+		},
+		{
+			name: "在第一个副本完成",
+			finish: func(t *testing.T, workflow *blockingWorkflow, other *DefaultWorkflowExecutor) {
+				close(workflow.release)
+			},
+			wantStatus: "completed",
+			wantEvent:  StreamEventEnd,
+		},
+		{
+			name: "从第二个副本取消",
+			finish: func(t *testing.T, workflow *blockingWorkflow, other *DefaultWorkflowExecutor) {
+				if err := other.CancelExecution("exec-1"); err != nil {
+					t.Fatalf("第二个副本取消执行: %v", err)
+				}
+			},
+			wantStatus: "cancelled",
+			wantEvent:  StreamEventError,
+			wantReason: CancelReasonUser,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner, workflow, other := newReplicas(t)
+
+			ch, err := owner.ExecuteStream(context.Background(), streamRequest("exec-1"))
+			if err != nil {
+				t.Fatalf("ExecuteStream: %v", err)
+			}
+			status := waitForStatus(t, other, "exec-1", "running")
+			if status.ExecutionID != "exec-1" || status.StartTime == 0 {
+				t.Fatalf("第二个副本读到的状态 = %+v，期望包含执行ID与开始时间", status)
+			}
+
+			if tt.finish == nil {
+				close(workflow.release)
+				drain(ch)
+				return
+			}
+			tt.finish(t, workflow, other)
+
+			if last := drain(ch); last == nil || last.Type != tt.wantEvent {
+				t.Fatalf("流的最后一个事件 = %+v，期望 %s", last, tt.wantEvent)
+			}
+			status = waitForStatus(t, other, "exec-1", tt.wantStatus)
+			if status.EndTime == 0 {
+				t.Fatalf("结束后的状态应包含结束时间，实际 %+v", status)
+			}
+			if status.CancelReason != tt.wantReason {
+				t.Fatalf("cancel_reason = %q，期望 %q", status.CancelReason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestExecutionStatusUnknownOnAllReplicas(t *testing.T) {
+	_, _, other := newReplicas(t)
+
+	if _, err := other.GetExecutionStatus("missing"); err == nil {
+		t.Fatal("两个副本都没有的执行应返回错误")
+	}
+	if err := other.CancelExecution("missing"); err == nil {
+		t.Fatal("取消两个副本都没有的执行应返回错误")
+	}
+}
@@ -2,7 +2,9 @@ package workflows
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,40 +12,280 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// ErrConcurrencyLimit 已达到最大并发执行数
+var ErrConcurrencyLimit = errors.New("已达到最大并发执行数限制")
+
+// ErrShuttingDown 服务正在关闭，不再接受新的执行
+var ErrShuttingDown = errors.New("服务正在关闭，不再接受新的工作流执行")
+
+// ErrExecutionTimeout 工作流执行超过超时时间
+var ErrExecutionTimeout = errors.New("工作流执行超时")
+
+// ErrClientCanceled 客户端在执行完成前取消了请求
+var ErrClientCanceled = errors.New("客户端已取消请求")
+
+// 排空期间检查执行是否完成的间隔
+const drainPollInterval = 100 * time.Millisecond
+
 // DefaultWorkflowExecutor 默认工作流执行器实现
 type DefaultWorkflowExecutor struct {
 	registry     WorkflowRegistry
 	executions   map[string]*WorkflowExecutionContext
+	cancels      map[string]context.CancelCauseFunc
 	mutex        sync.RWMutex
 	logger       *logrus.Logger
 	maxExecutions int
 	executionTimeout time.Duration
+	metrics      *MetricsCollector
+	draining     bool
+
+	// store 跨副本共享的执行记录，为nil时仅使用本地记录
+	store *ExecutionStore
 }
 
 // NewDefaultWorkflowExecutor 创建默认工作流执行器
-func NewDefaultWorkflowExecutor(registry WorkflowRegistry, logger *logrus.Logger, maxExecutions int, executionTimeout time.Duration) *DefaultWorkflowExecutor {
+func NewDefaultWorkflowExecutor(registry WorkflowRegistry, logger *logrus.Logger, maxExecutions int, executionTimeout time.Duration, metrics *MetricsCollector) *DefaultWorkflowExecutor {
 	return &DefaultWorkflowExecutor{
 		registry:         registry,
 		executions:       make(map[string]*WorkflowExecutionContext),
+		cancels:          make(map[string]context.CancelCauseFunc),
 		logger:           logger,
 		maxExecutions:    maxExecutions,
 		executionTimeout: executionTimeout,
+		metrics:          metrics,
 	}
 }
 
+// SetExecutionStore 设置跨副本共享的执行记录存储
+// 本地记录仍作为快速缓存，Redis中的记录用于其他副本查询状态和取消执行
+func (e *DefaultWorkflowExecutor) SetExecutionStore(store *ExecutionStore) {
+	e.store = store
+}
+
+// StartCancelListener 监听其他副本广播的取消请求，直到 ctx 结束
+func (e *DefaultWorkflowExecutor) StartCancelListener(ctx context.Context) {
+	if e.store == nil {
+		return
+	}
+
+	e.store.SubscribeCancel(ctx, func(executionID string) {
+		e.mutex.Lock()
+		execCtx, exists := e.executions[executionID]
+		cancelled := exists && execCtx.Status == "running"
+		if cancelled {
+			e.cancelLocked(executionID, execCtx, CancelReasonUser)
+		}
+		e.mutex.Unlock()
+
+		if cancelled {
+			e.persist(execCtx)
+		}
+	})
+}
+
 // Execute 执行工作流
 func (e *DefaultWorkflowExecutor) Execute(ctx context.Context, req *WorkflowRequest) (*WorkflowResponse, error) {
-	// 验证并发限制
-	if err := e.checkConcurrencyLimit(); err != nil {
+	// 获取工作流
+	workflow, err := e.registry.GetWorkflow(WorkflowRef(req.WorkflowType, req.WorkflowVersion))
+	if err != nil {
+		return nil, fmt.Errorf("获取工作流失败: %w", err)
+	}
+
+	// 创建可按原因取消、带超时的上下文
+	timeoutCtx, cancel, release := e.newExecutionContext(ctx, req)
+	defer release()
+
+	// 占用并发名额并注册执行上下文
+	execCtx, err := e.startExecution(req, cancel)
+	if err != nil {
 		return nil, err
 	}
+	defer e.unregisterExecution(req.ExecutionID)
+	timeoutCtx = withStepTracker(timeoutCtx, &executionStepTracker{executor: e, execCtx: execCtx})
+
+	return e.runExecute(ctx, timeoutCtx, workflow, req, execCtx)
+}
 
+// runExecute 执行阻塞式工作流并记录结果，调用方需已注册执行上下文
+func (e *DefaultWorkflowExecutor) runExecute(parent, ctx context.Context, workflow WorkflowEngine, req *WorkflowRequest, execCtx *WorkflowExecutionContext) (*WorkflowResponse, error) {
+	// 执行工作流
+	response, err := workflow.Execute(ctx, req)
+	if err != nil {
+		err = classifyContextError(parent, ctx, err)
+	}
+	if response != nil && req.WorkflowVersion != "" {
+		if response.Metadata == nil {
+			response.Metadata = make(map[string]interface{})
+		}
+		response.Metadata["workflow_version"] = req.WorkflowVersion
+	}
+
+	e.finishExecution(req, execCtx, response, err)
+	return response, err
+}
+
+// timeoutFor 获取请求的执行超时时间
+// 请求可通过 timeout_ms 缩短超时，但不能超过配置的 execution_timeout
+func (e *DefaultWorkflowExecutor) timeoutFor(req *WorkflowRequest) time.Duration {
+	if req.TimeoutMs <= 0 {
+		return e.executionTimeout
+	}
+
+	timeout := time.Duration(req.TimeoutMs) * time.Millisecond
+	if timeout > e.executionTimeout {
+		e.logger.WithFields(logrus.Fields{
+			"request_id":     req.RequestID,
+			"execution_id":   req.ExecutionID,
+			"timeout_ms":     req.TimeoutMs,
+			"max_timeout_ms": e.executionTimeout.Milliseconds(),
+			"operation":      "execution_timeout_clamped",
+		}).Debug("请求超时时间超过上限，已按上限执行")
+		return e.executionTimeout
+	}
+	return timeout
+}
+
+// classifyContextError 区分执行超时、客户端取消与主动取消
+// parent 为请求上下文，ctx 为执行上下文；主动取消时返回携带原因的 CancellationError，其他情况返回原错误
+func classifyContextError(parent, ctx context.Context, err error) error {
+	switch {
+	case parent.Err() == context.Canceled:
+		return fmt.Errorf("%w: %v", ErrClientCanceled, err)
+	case ctx.Err() == context.DeadlineExceeded:
+		return fmt.Errorf("%w: %v", ErrExecutionTimeout, err)
+	}
+	if cancelErr := cancellationCause(ctx); cancelErr != nil {
+		return fmt.Errorf("%w: %v", cancelErr, err)
+	}
+	return err
+}
+
+// ExecuteStream 流式执行工作流
+// 并发名额在返回通道前同步占用，超限时直接返回 ErrConcurrencyLimit
+func (e *DefaultWorkflowExecutor) ExecuteStream(ctx context.Context, req *WorkflowRequest) (<-chan *WorkflowStreamResponse, error) {
 	// 获取工作流
-	workflow, err := e.registry.GetWorkflow(req.WorkflowType)
+	workflow, err := e.registry.GetWorkflow(WorkflowRef(req.WorkflowType, req.WorkflowVersion))
 	if err != nil {
 		return nil, fmt.Errorf("获取工作流失败: %w", err)
 	}
 
+	// 创建可按原因取消、带超时的上下文
+	timeoutCtx, cancel, release := e.newExecutionContext(ctx, req)
+
+	// 占用并发名额并注册执行上下文
+	execCtx, err := e.startExecution(req, cancel)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	timeoutCtx = withStepTracker(timeoutCtx, &executionStepTracker{executor: e, execCtx: execCtx})
+
+	// 创建响应通道
+	responseCh := make(chan *WorkflowStreamResponse, 100)
+
+	// 异步执行工作流
+	go func() {
+		defer close(responseCh)
+		defer e.unregisterExecution(req.ExecutionID)
+		defer release()
+
+		e.forwardNativeStream(ctx, timeoutCtx, workflow, req, execCtx, responseCh)
+	}()
+	
+	return responseCh, nil
+}
+
+// forwardNativeStream 转发工作流的流式事件
+// chunk 事件统一补齐 delta 与累计的 content，end 与 error 事件结束执行并记录指标
+func (e *DefaultWorkflowExecutor) forwardNativeStream(parent, ctx context.Context, workflow WorkflowEngine, req *WorkflowRequest, execCtx *WorkflowExecutionContext, responseCh chan<- *WorkflowStreamResponse) {
+	streamCh, err := workflow.ExecuteStream(ctx, req)
+	if err != nil {
+		err = classifyContextError(parent, ctx, err)
+		e.finishExecution(req, execCtx, nil, err)
+		responseCh <- &WorkflowStreamResponse{
+			Type:  StreamEventError,
+			Error: err.Error(),
+		}
+		return
+	}
+
+	var accumulated string
+	for event := range streamCh {
+		switch event.Type {
+		case StreamEventChunk:
+			delta, ok := event.Data["delta"].(string)
+			if !ok {
+				delta = strings.TrimPrefix(event.Content, accumulated)
+			}
+			accumulated += delta
+			responseCh <- &WorkflowStreamResponse{
+				Type:        StreamEventChunk,
+				ExecutionID: req.ExecutionID,
+				Content:     delta,
+				Data: map[string]any{
+					"delta":   delta,
+					"content": accumulated,
+				},
+			}
+		case StreamEventEnd:
+			e.finishExecution(req, execCtx, streamEndResponse(event), nil)
+			event.ExecutionID = req.ExecutionID
+			if req.WorkflowVersion != "" {
+				if event.Data == nil {
+					event.Data = make(map[string]any)
+				}
+				event.Data["workflow_version"] = req.WorkflowVersion
+			}
+			responseCh <- event
+			return
+		case StreamEventError:
+			err := classifyContextError(parent, ctx, errors.New(event.Error))
+			e.finishExecution(req, execCtx, nil, err)
+			event.Error = err.Error()
+			responseCh <- event
+			return
+		default:
+			responseCh <- event
+		}
+	}
+
+	// 通道关闭但未收到结束事件，通常是超时或被取消
+	err = ctx.Err()
+	if err == nil {
+		err = errors.New("流式响应意外结束")
+	}
+	err = classifyContextError(parent, ctx, err)
+	e.finishExecution(req, execCtx, nil, err)
+	responseCh <- &WorkflowStreamResponse{
+		Type:  StreamEventError,
+		Error: err.Error(),
+	}
+}
+
+// streamEndResponse 从流式结束事件中提取供应商和用量，用于记录执行指标
+func streamEndResponse(event *WorkflowStreamResponse) *WorkflowResponse {
+	response := &WorkflowResponse{
+		Metadata: map[string]interface{}{
+			"provider":      event.Data["provider"],
+			"credential_id": event.Data["credential_id"],
+		},
+	}
+	response.Model, _ = event.Data["model"].(string)
+
+	if usage, ok := event.Data["usage"].(map[string]int); ok {
+		response.Usage = &TokenUsage{
+			PromptTokens:     usage["prompt_tokens"],
+			CompletionTokens: usage["completion_tokens"],
+			TotalTokens:      usage["total_tokens"],
+		}
+	}
+	response.FinishReason, _ = event.Data["finish_reason"].(string)
+
+	return response
+}
+
+// startExecution 占用并发名额并注册执行上下文
+func (e *DefaultWorkflowExecutor) startExecution(req *WorkflowRequest, cancel context.CancelCauseFunc) (*WorkflowExecutionContext, error) {
 	// 生成执行ID（如果未提供）
 	if req.ExecutionID == "" {
 		req.ExecutionID = uuid.New().String()
@@ -63,13 +305,11 @@ func (e *DefaultWorkflowExecutor) Execute(ctx context.Context, req *WorkflowRequ
 		Status:        "running",
 	}
 
-	// 注册执行上下文
-	e.registerExecution(execCtx)
-	defer e.unregisterExecution(req.ExecutionID)
-
-	// 创建带超时的上下文
-	timeoutCtx, cancel := context.WithTimeout(ctx, e.executionTimeout)
-	defer cancel()
+	// 验证并发限制并注册执行上下文
+	if err := e.registerExecution(execCtx, cancel); err != nil {
+		return nil, err
+	}
+	e.persist(execCtx)
 
 	// 记录开始执行
 	e.logger.WithFields(logrus.Fields{
@@ -78,16 +318,39 @@ func (e *DefaultWorkflowExecutor) Execute(ctx context.Context, req *WorkflowRequ
 		"tenant_id":      req.TenantID,
 		"user_id":        req.UserID,
 		"workflow_type":  req.WorkflowType,
+		"stream":         req.Stream,
 		"operation":      "execution_start",
 	}).Info("开始执行工作流")
 
-	// 执行工作流
-	response, err := workflow.Execute(timeoutCtx, req)
-	
+	return execCtx, nil
+}
+
+// finishExecution 更新执行状态并记录指标
+func (e *DefaultWorkflowExecutor) finishExecution(req *WorkflowRequest, execCtx *WorkflowExecutionContext, response *WorkflowResponse, err error) {
 	// 更新执行状态
+	e.mutex.Lock()
 	execCtx.EndTime = time.Now().UnixMilli()
+	if execCtx.Status == "running" {
+		execCtx.Status, execCtx.CancelReason = statusForError(err)
+	}
+	e.mutex.Unlock()
+	e.persist(execCtx)
+
+	// 记录执行指标
+	if e.metrics != nil {
+		var usage *TokenUsage
+		if response != nil {
+			usage = response.Usage
+		}
+		e.metrics.RecordExecution(
+			req.WorkflowType,
+			resolveProvider(req, response),
+			time.Duration(execCtx.EndTime-execCtx.StartTime)*time.Millisecond,
+			usage,
+			err == nil,
+		)
+	}
 	if err != nil {
-		execCtx.Status = "failed"
 		e.logger.WithFields(logrus.Fields{
 			"request_id":     req.RequestID,
 			"execution_id":   req.ExecutionID,
@@ -99,7 +362,6 @@ func (e *DefaultWorkflowExecutor) Execute(ctx context.Context, req *WorkflowRequ
 			"execution_time": execCtx.EndTime - execCtx.StartTime,
 		}).Error("工作流执行失败")
 	} else {
-		execCtx.Status = "completed"
 		e.logger.WithFields(logrus.Fields{
 			"request_id":     req.RequestID,
 			"execution_id":   req.ExecutionID,
@@ -110,69 +372,40 @@ func (e *DefaultWorkflowExecutor) Execute(ctx context.Context, req *WorkflowRequ
 			"execution_time": execCtx.EndTime - execCtx.StartTime,
 		}).Info("工作流执行成功")
 	}
-
-	return response, err
-}
-
-// ExecuteStream 流式执行工作流
-func (e *DefaultWorkflowExecutor) ExecuteStream(ctx context.Context, req *WorkflowRequest) (<-chan *WorkflowStreamResponse, error) {
-	// 创建响应通道
-	responseCh := make(chan *WorkflowStreamResponse, 100)
-	
-	// 异步执行工作流
-	go func() {
-		defer close(responseCh)
-		
-		// 执行工作流
-		response, err := e.Execute(ctx, req)
-		
-		if err != nil {
-			// 发送错误
-			responseCh <- &WorkflowStreamResponse{
-				Type:  "error",
-				Error: err.Error(),
-			}
-			return
-		}
-		
-		// 发送成功响应
-		responseCh <- &WorkflowStreamResponse{
-			Type:    "data",
-			Content: response.Content,
-			Data: map[string]any{
-				"content":           response.Content,
-				"model":            response.Model,
-				"workflow_type":    response.WorkflowType,
-				"execution_time_ms": response.ExecutionTimeMs,
-				"usage":            response.Usage,
-			},
-		}
-		
-		// 发送完成信号
-		responseCh <- &WorkflowStreamResponse{
-			Type: "done",
-		}
-	}()
-	
-	return responseCh, nil
 }
 
 // GetExecutionStatus 获取执行状态
+// 本地不存在时从共享存储读取，支持查询其他副本上的执行
 func (e *DefaultWorkflowExecutor) GetExecutionStatus(executionID string) (*WorkflowExecutionStatus, error) {
 	e.mutex.RLock()
-	defer e.mutex.RUnlock()
-
 	execCtx, exists := e.executions[executionID]
-	if !exists {
-		return nil, fmt.Errorf("执行ID %s 不存在", executionID)
+	if exists {
+		status := buildExecutionStatus(execCtx)
+		e.mutex.RUnlock()
+		return status, nil
+	}
+	e.mutex.RUnlock()
+
+	execCtx, err := e.loadRemote(executionID)
+	if err != nil {
+		return nil, err
 	}
+	return buildExecutionStatus(execCtx), nil
+}
 
-	// 计算进度
+// buildExecutionStatus 根据执行上下文构建执行状态
+func buildExecutionStatus(execCtx *WorkflowExecutionContext) *WorkflowExecutionStatus {
+	// 计算进度：工作流声明了步骤数时按已完成步骤计算，运行中最多为 99
 	progress := 0
 	if execCtx.Status == "completed" {
 		progress = 100
+	} else if execCtx.Status == "running" && execCtx.TotalSteps > 0 {
+		progress = completedStepCount(execCtx.Steps) * 100 / execCtx.TotalSteps
+		if progress > 99 {
+			progress = 99
+		}
 	} else if execCtx.Status == "running" {
-		progress = 50 // 简化的进度计算
+		progress = 50 // 未声明步骤数的工作流
 	}
 
 	// 当前步骤
@@ -188,7 +421,7 @@ func (e *DefaultWorkflowExecutor) GetExecutionStatus(executionID string) (*Workf
 	}
 
 	return &WorkflowExecutionStatus{
-		ExecutionID:     executionID,
+		ExecutionID:     execCtx.ExecutionID,
 		Status:          execCtx.Status,
 		Progress:        progress,
 		CurrentStep:     currentStep,
@@ -196,71 +429,227 @@ func (e *DefaultWorkflowExecutor) GetExecutionStatus(executionID string) (*Workf
 		StartTime:       execCtx.StartTime,
 		EndTime:         execCtx.EndTime,
 		ExecutionTimeMs: executionTime,
-	}, nil
+		CancelReason:    execCtx.CancelReason,
+	}
 }
 
 // CancelExecution 取消执行
+// 执行不在本副本时，通过共享存储广播取消请求，由运行该执行的副本中止
 func (e *DefaultWorkflowExecutor) CancelExecution(executionID string) error {
 	e.mutex.Lock()
-	defer e.mutex.Unlock()
-
 	execCtx, exists := e.executions[executionID]
 	if !exists {
-		return fmt.Errorf("执行ID %s 不存在", executionID)
+		e.mutex.Unlock()
+		return e.cancelRemote(executionID)
 	}
 
 	if execCtx.Status != "running" {
+		e.mutex.Unlock()
 		return fmt.Errorf("执行ID %s 状态为 %s，无法取消", executionID, execCtx.Status)
 	}
 
-	// 更新状态
-	execCtx.Status = "cancelled"
-	execCtx.EndTime = time.Now().UnixMilli()
+	e.cancelLocked(executionID, execCtx, CancelReasonUser)
+	e.mutex.Unlock()
+	e.persist(execCtx)
 
 	e.logger.WithFields(logrus.Fields{
 		"execution_id": executionID,
 		"tenant_id":    execCtx.TenantID,
 		"user_id":      execCtx.UserID,
 		"workflow_type": execCtx.WorkflowType,
+		"cancel_reason": CancelReasonUser,
 		"operation":    "execution_cancelled",
 	}).Info("工作流执行已取消")
 
 	return nil
 }
 
-// checkConcurrencyLimit 检查并发限制
-func (e *DefaultWorkflowExecutor) checkConcurrencyLimit() error {
+// cancelLocked 标记执行为已取消并以携带原因的 CancellationError 中止其上下文，调用方需持有写锁
+// 工作流的供应商调用使用该上下文，取消后立即中止
+func (e *DefaultWorkflowExecutor) cancelLocked(executionID string, execCtx *WorkflowExecutionContext, reason string) {
+	execCtx.Status = "cancelled"
+	execCtx.CancelReason = reason
+	execCtx.EndTime = time.Now().UnixMilli()
+	if cancel, ok := e.cancels[executionID]; ok {
+		cancel(&CancellationError{Reason: reason})
+	}
+}
+
+// cancelRemote 广播取消其他副本上运行的执行
+func (e *DefaultWorkflowExecutor) cancelRemote(executionID string) error {
+	execCtx, err := e.loadRemote(executionID)
+	if err != nil {
+		return err
+	}
+	if execCtx.Status != "running" {
+		return fmt.Errorf("执行ID %s 状态为 %s，无法取消", executionID, execCtx.Status)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), executionStoreTimeout)
+	defer cancel()
+	if err := e.store.PublishCancel(ctx, executionID); err != nil {
+		return err
+	}
+
+	e.logger.WithFields(logrus.Fields{
+		"execution_id":  executionID,
+		"tenant_id":     execCtx.TenantID,
+		"workflow_type": execCtx.WorkflowType,
+		"operation":     "execution_cancel_published",
+	}).Info("已广播工作流取消请求")
+
+	return nil
+}
+
+// loadRemote 从共享存储读取执行记录
+func (e *DefaultWorkflowExecutor) loadRemote(executionID string) (*WorkflowExecutionContext, error) {
+	if e.store == nil {
+		return nil, fmt.Errorf("执行ID %s 不存在", executionID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), executionStoreTimeout)
+	defer cancel()
+
+	execCtx, err := e.store.Load(ctx, executionID)
+	if errors.Is(err, ErrExecutionNotFound) {
+		return nil, fmt.Errorf("执行ID %s 不存在", executionID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return execCtx, nil
+}
+
+// persist 将执行记录写入共享存储，失败时仅记录日志
+func (e *DefaultWorkflowExecutor) persist(execCtx *WorkflowExecutionContext) {
+	if e.store == nil {
+		return
+	}
+
 	e.mutex.RLock()
-	defer e.mutex.RUnlock()
+	snapshot := *execCtx
+	snapshot.Steps = append([]WorkflowStep(nil), execCtx.Steps...)
+	e.mutex.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), executionStoreTimeout)
+	defer cancel()
+
+	if err := e.store.Save(ctx, &snapshot); err != nil {
+		e.logger.WithFields(logrus.Fields{
+			"execution_id": execCtx.ExecutionID,
+			"operation":    "execution_persist_failed",
+			"error":        err.Error(),
+		}).Warn("保存共享执行记录失败")
+	}
+}
+
+// registerExecution 在并发限制内注册执行上下文
+// 检查与注册在同一把锁内完成，避免并发请求同时越过限制
+func (e *DefaultWorkflowExecutor) registerExecution(execCtx *WorkflowExecutionContext, cancel context.CancelCauseFunc) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.draining {
+		return ErrShuttingDown
+	}
 
 	activeCount := 0
-	for _, execCtx := range e.executions {
-		if execCtx.Status == "running" {
+	for _, existing := range e.executions {
+		if existing.Status == "running" {
 			activeCount++
 		}
 	}
 
 	if activeCount >= e.maxExecutions {
-		return fmt.Errorf("已达到最大并发执行数限制: %d", e.maxExecutions)
+		return fmt.Errorf("%w: %d", ErrConcurrencyLimit, e.maxExecutions)
 	}
 
+	e.executions[execCtx.ExecutionID] = execCtx
+	e.cancels[execCtx.ExecutionID] = cancel
 	return nil
 }
 
-// registerExecution 注册执行上下文
-func (e *DefaultWorkflowExecutor) registerExecution(execCtx *WorkflowExecutionContext) {
+// unregisterExecution 取消注册执行上下文
+func (e *DefaultWorkflowExecutor) unregisterExecution(executionID string) {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 	
-	e.executions[execCtx.ExecutionID] = execCtx
+	delete(e.executions, executionID)
+	delete(e.cancels, executionID)
 }
 
-// unregisterExecution 取消注册执行上下文
-func (e *DefaultWorkflowExecutor) unregisterExecution(executionID string) {
+// Drain 停止接受新的执行，等待运行中的执行完成
+// ctx 到期后仍未完成的执行通过其上下文取消，返回正常完成与被取消的执行数
+func (e *DefaultWorkflowExecutor) Drain(ctx context.Context) (drained int, cancelled int) {
+	e.mutex.Lock()
+	e.draining = true
+	pending := make(map[string]bool)
+	for id, execCtx := range e.executions {
+		if execCtx.Status == "running" {
+			pending[id] = true
+		}
+	}
+	e.mutex.Unlock()
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for len(pending) > 0 {
+		drained += e.removeFinished(pending)
+		if len(pending) == 0 {
+			break
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			// 宽限期结束，最后检查一次后取消剩余执行
+			drained += e.removeFinished(pending)
+			return drained, e.cancelPending(pending)
+		}
+	}
+
+	return drained, 0
+}
+
+// removeFinished 从待完成集合中移除已结束的执行，返回移除数量
+func (e *DefaultWorkflowExecutor) removeFinished(pending map[string]bool) int {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	finished := 0
+	for id := range pending {
+		if execCtx, exists := e.executions[id]; !exists || execCtx.Status != "running" {
+			delete(pending, id)
+			finished++
+		}
+	}
+	return finished
+}
+
+// cancelPending 取消仍在运行的执行，返回取消数量
+func (e *DefaultWorkflowExecutor) cancelPending(pending map[string]bool) int {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
-	
-	delete(e.executions, executionID)
+
+	cancelled := 0
+	for id := range pending {
+		execCtx, exists := e.executions[id]
+		if !exists || execCtx.Status != "running" {
+			continue
+		}
+
+		e.cancelLocked(id, execCtx, CancelReasonShutdown)
+		cancelled++
+
+		e.logger.WithFields(logrus.Fields{
+			"execution_id":  id,
+			"tenant_id":     execCtx.TenantID,
+			"workflow_type": execCtx.WorkflowType,
+			"operation":     "execution_cancelled_on_shutdown",
+		}).Warn("关闭宽限期已到，取消未完成的工作流执行")
+	}
+	return cancelled
 }
 
 // GetActiveExecutions 获取活跃执行数
@@ -297,6 +686,7 @@ func (e *DefaultWorkflowExecutor) CleanupCompletedExecutions(maxAge time.Duratio
 	for id, execCtx := range e.executions {
 		if execCtx.Status != "running" && execCtx.EndTime > 0 && execCtx.EndTime < cutoff {
 			delete(e.executions, id)
+			delete(e.cancels, id)
 		}
 	}
 }
\ No newline at end of file
@@ -0,0 +1,140 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+// blockingWorkflow 流式执行直到 release 关闭或上下文结束的工作流替身
+type blockingWorkflow struct {
+	release chan struct{}
+}
+
+func (w *blockingWorkflow) Execute(ctx context.Context, req *WorkflowRequest) (*WorkflowResponse, error) {
+	select {
+	case <-w.release:
+		return &WorkflowResponse{Success: true, Content: "完成"}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (w *blockingWorkflow) ExecuteStream(ctx context.Context, req *WorkflowRequest) (<-chan *WorkflowStreamResponse, error) {
+	ch := make(chan *WorkflowStreamResponse, 1)
+	go func() {
+		defer close(ch)
+		select {
+		case <-w.release:
+			ch <- &WorkflowStreamResponse{Type: StreamEventEnd, ExecutionID: req.ExecutionID}
+		case <-ctx.Done():
+			ch <- &WorkflowStreamResponse{Type: StreamEventError, ExecutionID: req.ExecutionID, Error: ctx.Err().Error()}
+		}
+	}()
+	return ch, nil
+}
+
+func (w *blockingWorkflow) GetInfo() *WorkflowInfo {
+	return &WorkflowInfo{Name: "blocking", Version: "1.0.0"}
+}
+
+// newTestExecutor 创建注册了 blockingWorkflow 的执行器
+func newTestExecutor(t *testing.T, maxExecutions int) (*DefaultWorkflowExecutor, *blockingWorkflow) {
+	t.Helper()
+	workflow := &blockingWorkflow{release: make(chan struct{})}
+	registry := NewDefaultWorkflowRegistry(testutil.Logger())
+	if err := registry.RegisterWorkflow("blocking", workflow); err != nil {
+		t.Fatalf("RegisterWorkflow: %v", err)
+	}
+	return NewDefaultWorkflowExecutor(registry, testutil.Logger(), maxExecutions, time.Minute, NewMetricsCollector()), workflow
+}
+
+// streamRequest 创建指定执行ID的流式请求
+func streamRequest(executionID string) *WorkflowRequest {
+	return &WorkflowRequest{
+		RequestID:    "req-" + executionID,
+		ExecutionID:  executionID,
+		TenantID:     "tenant-1",
+		UserID:       "user-1",
+		WorkflowType: "blocking",
+		Message:      "你好",
+		Stream:       true,
+	}
+}
+
+// drain 读取流直到结束，返回最后一个事件
+func drain(ch <-chan *WorkflowStreamResponse) *WorkflowStreamResponse {
+	var last *WorkflowStreamResponse
+	for event := range ch {
+		last = event
+	}
+	return last
+}
+
+func TestExecuteStreamEnforcesConcurrencyLimit(t *testing.T) {
+	executor, workflow := newTestExecutor(t, 2)
+	ctx := context.Background()
+
+	var streams []<-chan *WorkflowStreamResponse
+	for i := 0; i < 2; i++ {
+		ch, err := executor.ExecuteStream(ctx, streamRequest(fmt.Sprintf("exec-%d", i)))
+		if err != nil {
+			t.Fatalf("第 %d 个流式执行: %v", i+1, err)
+		}
+		streams = append(streams, ch)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := executor.ExecuteStream(ctx, streamRequest(fmt.Sprintf("over-%d", i))); !errors.Is(err, ErrConcurrencyLimit) {
+			t.Fatalf("超出并发上限时应返回 ErrConcurrencyLimit，实际 %v", err)
+		}
+	}
+	if _, err := executor.Execute(ctx, streamRequest("blocking-call")); !errors.Is(err, ErrConcurrencyLimit) {
+		t.Fatalf("流式执行应与普通执行共用并发名额，实际 %v", err)
+	}
+
+	close(workflow.release)
+	for _, ch := range streams {
+		if last := drain(ch); last == nil || last.Type != StreamEventEnd {
+			t.Fatalf("流应以 end 事件结束，实际 %+v", last)
+		}
+	}
+
+	ch, err := executor.ExecuteStream(ctx, streamRequest("after-release"))
+	if err != nil {
+		t.Fatalf("名额释放后应允许新的流式执行: %v", err)
+	}
+	drain(ch)
+}
+
+func TestExecuteStreamIsTrackedAndCancellable(t *testing.T) {
+	executor, _ := newTestExecutor(t, 2)
+
+	ch, err := executor.ExecuteStream(context.Background(), streamRequest("exec-1"))
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+
+	status, err := executor.GetExecutionStatus("exec-1")
+	if err != nil {
+		t.Fatalf("GetExecutionStatus: %v", err)
+	}
+	if status.Status != "running" {
+		t.Fatalf("status = %s，期望 running", status.Status)
+	}
+
+	if err := executor.CancelExecution("exec-1"); err != nil {
+		t.Fatalf("CancelExecution: %v", err)
+	}
+	if last := drain(ch); last == nil || last.Type != StreamEventError {
+		t.Fatalf("取消后流应以 error 事件结束，实际 %+v", last)
+	}
+
+	if _, err := executor.ExecuteStream(context.Background(), streamRequest("exec-2")); err != nil {
+		t.Fatalf("取消后应释放并发名额: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package workflows
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/internal/testutil/credentialtest"
+)
+
+func TestEINOStandardChatSendsExtraHeaders(t *testing.T) {
+	var received []chatMessage
+	provider := newOpenAIStub(t, "你好", &received)
+
+	var mutex sync.Mutex
+	var headers http.Header
+	respond := provider.Config.Handler
+	provider.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		headers = r.Header.Clone()
+		mutex.Unlock()
+		respond.ServeHTTP(w, r)
+	})
+
+	cred := testutil.Credential("openai", provider.URL)
+	cred.ModelConfigs["extra_headers"] = map[string]interface{}{
+		"OpenAI-Organization": "org-1",
+		"OpenAI-Beta":         "assistants=v2",
+		"Authorization":       "Bearer evil",
+	}
+	tenantService := testutil.NewTenantService(t)
+	tenantService.SetCredentials("tenant-1", cred)
+	workflow := NewEINOStandardChatWorkflow(credentialtest.NewManager(t, tenantService), 0, 0, testutil.Logger())
+
+	resp, err := workflow.Execute(context.Background(), &WorkflowRequest{
+		RequestID:   "req-1",
+		ExecutionID: "exec-1",
+		TenantID:    "tenant-1",
+		UserID:      "user-1",
+		Message:     "你好",
+		ModelConfig: map[string]interface{}{"provider": "openai", "model": "gpt-4o-mini"},
+	})
+	if err != nil || !resp.Success {
+		t.Fatalf("Execute = %+v, %v", resp, err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "OpenAI-Organization", want: "org-1"},
+		{header: "OpenAI-Beta", want: "assistants=v2"},
+		{header: "Authorization", want: "Bearer " + cred.APIKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := headers.Get(tt.header); got != tt.want {
+				t.Fatalf("%s = %q，期望 %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package workflows
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/pkg/featureflag"
+)
+
+// workflowFeatureFlags 需要租户开启功能开关才能使用的工作流
+var workflowFeatureFlags = map[string]string{
+	"optimized_rag": featureflag.FlagOptimizedRAG,
+}
+
+// SetFeatureFlags 设置租户级功能开关，为nil时不做限制
+func (wm *WorkflowManager) SetFeatureFlags(flags *featureflag.Service) {
+	wm.featureFlags = flags
+}
+
+// checkFeatureFlags 检查请求使用的工作流与功能是否对租户开启
+func (wm *WorkflowManager) checkFeatureFlags(ctx context.Context, req *WorkflowRequest) error {
+	if wm.featureFlags == nil {
+		return nil
+	}
+
+	var flags []string
+	if flag, ok := workflowFeatureFlags[req.WorkflowType]; ok {
+		flags = append(flags, flag)
+	}
+	if req.ResponseFormat.JSONMode() {
+		flags = append(flags, featureflag.FlagJSONMode)
+	}
+
+	for _, flag := range flags {
+		if err := wm.featureFlags.Require(ctx, req.TenantID, flag); err != nil {
+			wm.logger.WithFields(logrus.Fields{
+				"request_id":    req.RequestID,
+				"tenant_id":     req.TenantID,
+				"workflow_type": req.WorkflowType,
+				"feature":       flag,
+				"operation":     "feature_disabled",
+			}).Info("租户未开启请求使用的功能")
+			return err
+		}
+	}
+	return nil
+}
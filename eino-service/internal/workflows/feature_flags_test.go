@@ -0,0 +1,41 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/pkg/featureflag"
+)
+
+func TestCheckFeatureFlags(t *testing.T) {
+	jsonMode := &models.ResponseFormat{Type: models.ResponseFormatJSONObject}
+
+	tests := []struct {
+		name     string
+		defaults map[string]bool
+		req      *WorkflowRequest
+		wantErr  bool
+	}{
+		{name: "普通聊天不受开关限制", defaults: nil, req: &WorkflowRequest{WorkflowType: "simple_chat"}},
+		{name: "optimized_rag 开启", defaults: map[string]bool{featureflag.FlagOptimizedRAG: true}, req: &WorkflowRequest{WorkflowType: "optimized_rag"}},
+		{name: "optimized_rag 关闭", defaults: map[string]bool{featureflag.FlagOptimizedRAG: false}, req: &WorkflowRequest{WorkflowType: "optimized_rag"}, wantErr: true},
+		{name: "JSON模式关闭", defaults: map[string]bool{}, req: &WorkflowRequest{WorkflowType: "simple_chat", ResponseFormat: jsonMode}, wantErr: true},
+		{name: "text 格式不需要JSON模式", defaults: map[string]bool{}, req: &WorkflowRequest{WorkflowType: "simple_chat", ResponseFormat: &models.ResponseFormat{Type: models.ResponseFormatText}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wm := &WorkflowManager{logger: testutil.Logger()}
+			wm.SetFeatureFlags(featureflag.NewService(nil, nil, tt.defaults, 0, testutil.Logger()))
+			tt.req.TenantID = "tenant-1"
+
+			err := wm.checkFeatureFlags(context.Background(), tt.req)
+			if got := errors.Is(err, featureflag.ErrFeatureDisabled); got != tt.wantErr {
+				t.Fatalf("checkFeatureFlags = %v，期望功能未开启 %v", err, tt.wantErr)
+			}
+		})
+	}
+}
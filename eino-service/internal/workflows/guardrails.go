@@ -0,0 +1,91 @@
+package workflows
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"lyss-ai-platform/eino-service/pkg/tokenizer"
+)
+
+// ErrPayloadTooLarge 请求内容超出长度限制
+var ErrPayloadTooLarge = errors.New("请求内容超出长度限制")
+
+// PayloadTooLargeError 请求内容超限详情
+type PayloadTooLargeError struct {
+	Limit  string `json:"limit"`  // 超出的限制类型：bytes 或 tokens
+	Actual int    `json:"actual"` // 请求的实际大小
+	Max    int    `json:"max"`    // 允许的最大值
+	Model  string `json:"model,omitempty"`
+}
+
+// Error 实现 error 接口
+func (e *PayloadTooLargeError) Error() string {
+	return fmt.Sprintf("%s: %s %d 超过上限 %d", ErrPayloadTooLarge.Error(), e.Limit, e.Actual, e.Max)
+}
+
+// Unwrap 支持 errors.Is(err, ErrPayloadTooLarge)
+func (e *PayloadTooLargeError) Unwrap() error {
+	return ErrPayloadTooLarge
+}
+
+// PayloadLimits 请求内容长度限制，取值为0表示不限制
+type PayloadLimits struct {
+	MaxBytes  int
+	MaxTokens int
+	// ModelMaxTokens 按模型覆盖的令牌上限
+	ModelMaxTokens map[string]int
+	// MaxImageBytes 单张 base64 图片解码后的最大字节数
+	MaxImageBytes int
+}
+
+// Check 在调用供应商前检查消息及对话历史的大小
+func (l *PayloadLimits) Check(req *WorkflowRequest) error {
+	var builder strings.Builder
+	builder.WriteString(req.Message)
+	for _, content := range historyContents(req) {
+		builder.WriteString(content)
+	}
+	text := builder.String()
+
+	if size := len(text); l.MaxBytes > 0 && size > l.MaxBytes {
+		return &PayloadTooLargeError{Limit: "bytes", Actual: size, Max: l.MaxBytes}
+	}
+
+	model := requestModel(req)
+	maxTokens := l.MaxTokens
+	if limit, ok := l.ModelMaxTokens[model]; ok && limit > 0 {
+		maxTokens = limit
+	}
+	if tokens := tokenizer.CountTokens(model, text); maxTokens > 0 && tokens > maxTokens {
+		return &PayloadTooLargeError{Limit: "tokens", Actual: tokens, Max: maxTokens, Model: model}
+	}
+
+	return nil
+}
+
+// historyContents 提取对话历史中的消息内容
+func historyContents(req *WorkflowRequest) []string {
+	history, ok := req.Configuration["conversation_history"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	contents := make([]string, 0, len(history))
+	for _, item := range history {
+		if msg, ok := item.(map[string]interface{}); ok {
+			if content, ok := msg["content"].(string); ok {
+				contents = append(contents, content)
+			}
+		}
+	}
+	return contents
+}
+
+// requestModel 获取请求指定的模型名称
+func requestModel(req *WorkflowRequest) string {
+	if model, ok := req.ModelConfig["model"].(string); ok && model != "" {
+		return model
+	}
+	return req.Model
+}
@@ -0,0 +1,76 @@
+package workflows
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"lyss-ai-platform/eino-service/pkg/tokenizer"
+)
+
+func TestPayloadLimitsBoundary(t *testing.T) {
+	message := strings.Repeat("a", 100)
+	history := map[string]interface{}{
+		"conversation_history": []interface{}{
+			map[string]interface{}{"role": "user", "content": strings.Repeat("b", 20)},
+			map[string]interface{}{"role": "assistant", "content": strings.Repeat("c", 30)},
+		},
+	}
+	tokens := tokenizer.CountTokens("deepseek-chat", message)
+
+	tests := []struct {
+		name       string
+		limits     PayloadLimits
+		req        *WorkflowRequest
+		wantLimit  string
+		wantActual int
+	}{
+		{name: "不限制", limits: PayloadLimits{}, req: &WorkflowRequest{Message: message}},
+		{name: "字节数等于上限", limits: PayloadLimits{MaxBytes: 100}, req: &WorkflowRequest{Message: message}},
+		{name: "字节数超过上限", limits: PayloadLimits{MaxBytes: 99}, req: &WorkflowRequest{Message: message}, wantLimit: "bytes", wantActual: 100},
+		{
+			name:      "对话历史计入字节数",
+			limits:    PayloadLimits{MaxBytes: 149},
+			req:       &WorkflowRequest{Message: message, Configuration: history},
+			wantLimit: "bytes", wantActual: 150,
+		},
+		{name: "令牌数等于上限", limits: PayloadLimits{MaxTokens: tokens}, req: &WorkflowRequest{Message: message, Model: "deepseek-chat"}},
+		{
+			name:      "令牌数超过上限",
+			limits:    PayloadLimits{MaxTokens: tokens - 1},
+			req:       &WorkflowRequest{Message: message, Model: "deepseek-chat"},
+			wantLimit: "tokens", wantActual: tokens,
+		},
+		{
+			name:      "按模型覆盖令牌上限",
+			limits:    PayloadLimits{MaxTokens: tokens, ModelMaxTokens: map[string]int{"deepseek-chat": tokens - 1}},
+			req:       &WorkflowRequest{Message: message, ModelConfig: map[string]interface{}{"model": "deepseek-chat"}},
+			wantLimit: "tokens", wantActual: tokens,
+		},
+		{
+			name:   "其他模型不受覆盖影响",
+			limits: PayloadLimits{MaxTokens: tokens, ModelMaxTokens: map[string]int{"gpt-4": 1}},
+			req:    &WorkflowRequest{Message: message, Model: "deepseek-chat"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limits.Check(tt.req)
+			if tt.wantLimit == "" {
+				if err != nil {
+					t.Fatalf("Check = %v，期望通过", err)
+				}
+				return
+			}
+
+			var tooLarge *PayloadTooLargeError
+			if !errors.As(err, &tooLarge) || !errors.Is(err, ErrPayloadTooLarge) {
+				t.Fatalf("Check = %v，期望 PayloadTooLargeError", err)
+			}
+			if tooLarge.Limit != tt.wantLimit || tooLarge.Actual != tt.wantActual {
+				t.Fatalf("超限详情 = %+v，期望 %s %d", tooLarge, tt.wantLimit, tt.wantActual)
+			}
+		})
+	}
+}
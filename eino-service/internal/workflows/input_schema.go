@@ -0,0 +1,163 @@
+package workflows
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidParameters 请求参数不符合工作流定义
+var ErrInvalidParameters = errors.New("请求参数不符合工作流定义")
+
+// InvalidParametersError 参数校验失败详情
+type InvalidParametersError struct {
+	WorkflowType string            `json:"workflow_type"`
+	Missing      []string          `json:"missing,omitempty"` // 缺失的必需字段
+	Invalid      map[string]string `json:"invalid,omitempty"` // 类型不符的字段及期望类型
+}
+
+// Error 实现 error 接口
+func (e *InvalidParametersError) Error() string {
+	details := make([]string, 0, len(e.Invalid)+1)
+	if len(e.Missing) > 0 {
+		details = append(details, fmt.Sprintf("缺少 %s", strings.Join(e.Missing, ", ")))
+	}
+	invalid := make([]string, 0, len(e.Invalid))
+	for name := range e.Invalid {
+		invalid = append(invalid, name)
+	}
+	sort.Strings(invalid)
+	for _, name := range invalid {
+		details = append(details, fmt.Sprintf("%s 应为 %s", name, e.Invalid[name]))
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalidParameters.Error(), strings.Join(details, "; "))
+}
+
+// Unwrap 支持 errors.Is(err, ErrInvalidParameters)
+func (e *InvalidParametersError) Unwrap() error {
+	return ErrInvalidParameters
+}
+
+// modelConfigParameters 从 ModelConfig 读取的参数，其余非顶层参数从 Configuration 读取
+var modelConfigParameters = map[string]bool{
+	"provider":    true,
+	"model":       true,
+	"temperature": true,
+	"max_tokens":  true,
+}
+
+// applyInputSchema 按工作流声明的 RequiredInputs 与 Parameters 校验请求，并为缺省的可选参数填充默认值
+func applyInputSchema(req *WorkflowRequest, info *WorkflowInfo) error {
+	schemaErr := &InvalidParametersError{
+		WorkflowType: info.Name,
+		Invalid:      make(map[string]string),
+	}
+	missing := make(map[string]bool)
+	addMissing := func(name string) {
+		if !missing[name] {
+			missing[name] = true
+			schemaErr.Missing = append(schemaErr.Missing, name)
+		}
+	}
+
+	for _, name := range info.RequiredInputs {
+		if _, found := parameterValue(req, name); !found {
+			addMissing(name)
+		}
+	}
+
+	for _, param := range info.Parameters {
+		value, found := parameterValue(req, param.Name)
+		switch {
+		case !found && param.Required:
+			addMissing(param.Name)
+		case !found && param.Default != nil:
+			setParameterDefault(req, param.Name, param.Default)
+		case found && !matchesParameterType(value, param.Type):
+			schemaErr.Invalid[param.Name] = param.Type
+		}
+	}
+
+	if len(schemaErr.Missing) > 0 || len(schemaErr.Invalid) > 0 {
+		return schemaErr
+	}
+	return nil
+}
+
+// parameterValue 获取请求中的参数值，空字符串与 nil 视为未提供
+func parameterValue(req *WorkflowRequest, name string) (interface{}, bool) {
+	var value interface{}
+	switch name {
+	case "message":
+		value = req.Message
+	case "tenant_id":
+		value = req.TenantID
+	case "user_id":
+		value = req.UserID
+	case "request_id":
+		value = req.RequestID
+	case "execution_id":
+		value = req.ExecutionID
+	default:
+		if modelConfigParameters[name] {
+			value = req.ModelConfig[name]
+		} else {
+			value = req.Configuration[name]
+		}
+	}
+
+	if value == nil || value == "" {
+		return nil, false
+	}
+	return value, true
+}
+
+// setParameterDefault 填充参数默认值
+func setParameterDefault(req *WorkflowRequest, name string, value interface{}) {
+	if modelConfigParameters[name] {
+		if req.ModelConfig == nil {
+			req.ModelConfig = make(map[string]interface{})
+		}
+		req.ModelConfig[name] = value
+		return
+	}
+	if req.Configuration == nil {
+		req.Configuration = make(map[string]interface{})
+	}
+	req.Configuration[name] = value
+}
+
+// matchesParameterType 检查参数值是否符合声明的类型，JSON 数字解码为 float64
+func matchesParameterType(value interface{}, paramType string) bool {
+	switch paramType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "integer":
+		switch v := value.(type) {
+		case int, int32, int64:
+			return true
+		case float64:
+			return v == math.Trunc(v)
+		}
+		return false
+	case "number":
+		switch value.(type) {
+		case int, int32, int64, float32, float64:
+			return true
+		}
+		return false
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		return reflect.ValueOf(value).Kind() == reflect.Slice
+	case "object":
+		return reflect.ValueOf(value).Kind() == reflect.Map
+	default:
+		return true
+	}
+}
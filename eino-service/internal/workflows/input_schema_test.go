@@ -0,0 +1,113 @@
+package workflows
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// schemaInfo 测试使用的工作流定义
+func schemaInfo() *WorkflowInfo {
+	return &WorkflowInfo{
+		Name:           "schema_test",
+		RequiredInputs: []string{"message", "tenant_id"},
+		Parameters: []WorkflowParameter{
+			{Name: "temperature", Type: "number", Default: 0.7},
+			{Name: "max_tokens", Type: "integer", Default: 2048},
+			{Name: "top_k", Type: "integer", Required: true},
+			{Name: "tags", Type: "array"},
+			{Name: "verbose", Type: "boolean", Default: false},
+		},
+	}
+}
+
+func TestApplyInputSchemaRejectsMissingAndInvalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         *WorkflowRequest
+		wantMissing []string
+		wantInvalid map[string]string
+	}{
+		{
+			name:        "缺少必需输入与必需参数",
+			req:         &WorkflowRequest{TenantID: "tenant-1"},
+			wantMissing: []string{"message", "top_k"},
+		},
+		{
+			name:        "空字符串视为未提供",
+			req:         &WorkflowRequest{Message: "", TenantID: "tenant-1", Configuration: map[string]interface{}{"top_k": 3.0}},
+			wantMissing: []string{"message"},
+		},
+		{
+			name: "参数类型不符",
+			req: &WorkflowRequest{
+				Message:       "你好",
+				TenantID:      "tenant-1",
+				ModelConfig:   map[string]interface{}{"temperature": "hot"},
+				Configuration: map[string]interface{}{"top_k": 2.5, "tags": "a,b"},
+			},
+			wantInvalid: map[string]string{"temperature": "number", "top_k": "integer", "tags": "array"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := applyInputSchema(tt.req, schemaInfo())
+
+			var invalid *InvalidParametersError
+			if !errors.As(err, &invalid) || !errors.Is(err, ErrInvalidParameters) {
+				t.Fatalf("applyInputSchema = %v，期望 InvalidParametersError", err)
+			}
+			if !reflect.DeepEqual(invalid.Missing, tt.wantMissing) {
+				t.Fatalf("Missing = %v，期望 %v", invalid.Missing, tt.wantMissing)
+			}
+			if tt.wantInvalid == nil {
+				tt.wantInvalid = map[string]string{}
+			}
+			if !reflect.DeepEqual(invalid.Invalid, tt.wantInvalid) {
+				t.Fatalf("Invalid = %v，期望 %v", invalid.Invalid, tt.wantInvalid)
+			}
+		})
+	}
+}
+
+func TestApplyInputSchemaAppliesDefaults(t *testing.T) {
+	tests := []struct {
+		name              string
+		req               *WorkflowRequest
+		wantModelConfig   map[string]interface{}
+		wantConfiguration map[string]interface{}
+	}{
+		{
+			name:              "未提供的可选参数使用默认值",
+			req:               &WorkflowRequest{Message: "你好", TenantID: "tenant-1", Configuration: map[string]interface{}{"top_k": 3.0}},
+			wantModelConfig:   map[string]interface{}{"temperature": 0.7, "max_tokens": 2048},
+			wantConfiguration: map[string]interface{}{"top_k": 3.0, "verbose": false},
+		},
+		{
+			name: "请求提供的值不被默认值覆盖",
+			req: &WorkflowRequest{
+				Message:       "你好",
+				TenantID:      "tenant-1",
+				ModelConfig:   map[string]interface{}{"temperature": 0.0},
+				Configuration: map[string]interface{}{"top_k": 3.0, "verbose": true},
+			},
+			wantModelConfig:   map[string]interface{}{"temperature": 0.0, "max_tokens": 2048},
+			wantConfiguration: map[string]interface{}{"top_k": 3.0, "verbose": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := applyInputSchema(tt.req, schemaInfo()); err != nil {
+				t.Fatalf("applyInputSchema: %v", err)
+			}
+			if !reflect.DeepEqual(tt.req.ModelConfig, tt.wantModelConfig) {
+				t.Fatalf("ModelConfig = %v，期望 %v", tt.req.ModelConfig, tt.wantModelConfig)
+			}
+			if !reflect.DeepEqual(tt.req.Configuration, tt.wantConfiguration) {
+				t.Fatalf("Configuration = %v，期望 %v", tt.req.Configuration, tt.wantConfiguration)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/internal/testutil/credentialtest"
+	"lyss-ai-platform/eino-service/pkg/jsonmode"
+)
+
+// jsonModeRequest 供应商收到的请求中与JSON模式相关的字段
+type jsonModeRequest struct {
+	Stream         bool `json:"stream"`
+	ResponseFormat *struct {
+		Type string `json:"type"`
+	} `json:"response_format"`
+}
+
+// newOpenAISequenceStub 依次返回 replies 中的回答，超出后重复最后一个；流式请求以单个分块返回
+func newOpenAISequenceStub(t *testing.T, replies []string, requests *[]jsonModeRequest) *httptest.Server {
+	t.Helper()
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req jsonModeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mutex.Lock()
+		*requests = append(*requests, req)
+		reply := replies[min(len(*requests), len(replies))-1]
+		mutex.Unlock()
+
+		if req.Stream {
+			content, _ := json.Marshal(reply)
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: {\"id\":\"1\",\"object\":\"chat.completion.chunk\",\"choices\":[{\"index\":0,\"delta\":{\"content\":%s}}]}\n\n", content)
+			fmt.Fprint(w, "data: {\"id\":\"1\",\"object\":\"chat.completion.chunk\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":     "chatcmpl-1",
+			"object": "chat.completion",
+			"model":  "gpt-4o-mini",
+			"choices": []map[string]interface{}{{
+				"index":         0,
+				"message":       map[string]string{"role": "assistant", "content": reply},
+				"finish_reason": "stop",
+			}},
+			"usage": map[string]int{"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEINOStandardChatJSONMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		stream      bool
+		replies     []string
+		wantCalls   int
+		wantContent string
+		wantRetried bool
+		wantErr     bool
+	}{
+		{name: "首次即为合法JSON", replies: []string{`{"city":"北京"}`}, wantCalls: 1, wantContent: `{"city":"北京"}`},
+		{name: "无效后重试一次", replies: []string{"城市是北京", `{"city":"北京"}`}, wantCalls: 2, wantContent: `{"city":"北京"}`, wantRetried: true},
+		{name: "重试后仍无效", replies: []string{"城市是北京", "还是北京"}, wantCalls: 2, wantErr: true},
+		{name: "流式合法JSON", stream: true, replies: []string{`{"city":"北京"}`}, wantCalls: 1, wantContent: `{"city":"北京"}`},
+		{name: "流式无效后重试一次", stream: true, replies: []string{"城市是北京", `{"city":"北京"}`}, wantCalls: 2, wantContent: `{"city":"北京"}`, wantRetried: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []jsonModeRequest
+			provider := newOpenAISequenceStub(t, tt.replies, &requests)
+			tenantService := testutil.NewTenantService(t)
+			tenantService.SetCredentials("tenant-1", testutil.Credential("openai", provider.URL))
+			workflow := NewEINOStandardChatWorkflow(credentialtest.NewManager(t, tenantService), 0, 0, testutil.Logger())
+
+			req := &WorkflowRequest{
+				RequestID:      "req-1",
+				ExecutionID:    "exec-1",
+				TenantID:       "tenant-1",
+				UserID:         "user-1",
+				Message:        "北京在哪个城市？",
+				Stream:         tt.stream,
+				ModelConfig:    map[string]interface{}{"provider": "openai", "model": "gpt-4o-mini"},
+				ResponseFormat: &models.ResponseFormat{Type: models.ResponseFormatJSONObject},
+			}
+
+			var content string
+			var retried interface{}
+			if tt.stream {
+				stream, err := workflow.ExecuteStream(context.Background(), req)
+				if err != nil {
+					t.Fatalf("ExecuteStream: %v", err)
+				}
+				for event := range stream {
+					if event.Type == StreamEventError {
+						t.Fatalf("流式错误: %s", event.Error)
+					}
+					if event.Type == StreamEventEnd {
+						content, _ = event.Data["final_content"].(string)
+						retried = event.Data["json_retried"]
+					}
+				}
+			} else {
+				resp, err := workflow.Execute(context.Background(), req)
+				if tt.wantErr {
+					if !errors.Is(err, jsonmode.ErrInvalidJSON) || resp.Success {
+						t.Fatalf("结果 = %+v, %v，期望 ErrInvalidJSON", resp, err)
+					}
+				} else if err != nil || !resp.Success {
+					t.Fatalf("Execute = %+v, %v", resp, err)
+				} else {
+					content, retried = resp.Content, resp.Metadata["json_retried"]
+					if want := 15 * tt.wantCalls; resp.Usage.TotalTokens != want {
+						t.Fatalf("令牌用量 = %d，期望 %d", resp.Usage.TotalTokens, want)
+					}
+				}
+			}
+
+			if len(requests) != tt.wantCalls {
+				t.Fatalf("供应商调用次数 = %d，期望 %d", len(requests), tt.wantCalls)
+			}
+			for i, received := range requests {
+				if received.ResponseFormat == nil || received.ResponseFormat.Type != models.ResponseFormatJSONObject {
+					t.Fatalf("第 %d 次请求 response_format = %+v，期望 json_object", i+1, received.ResponseFormat)
+				}
+			}
+			if tt.wantErr {
+				return
+			}
+			if content != tt.wantContent || retried != tt.wantRetried {
+				t.Fatalf("内容 = %q，json_retried = %v，期望 %q、%v", content, retried, tt.wantContent, tt.wantRetried)
+			}
+		})
+	}
+}
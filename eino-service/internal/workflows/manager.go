@@ -2,32 +2,89 @@ package workflows
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 
+	"lyss-ai-platform/eino-service/internal/client"
 	"lyss-ai-platform/eino-service/internal/config"
+	"lyss-ai-platform/eino-service/internal/workflows/nodes"
+	"lyss-ai-platform/eino-service/pkg/audit"
 	"lyss-ai-platform/eino-service/pkg/credential"
+	"lyss-ai-platform/eino-service/pkg/featureflag"
+	"lyss-ai-platform/eino-service/pkg/health"
+	"lyss-ai-platform/eino-service/pkg/modelalias"
+	"lyss-ai-platform/eino-service/pkg/prompts"
+	"lyss-ai-platform/eino-service/pkg/prompttrace"
+	"lyss-ai-platform/eino-service/pkg/quota"
+	"lyss-ai-platform/eino-service/pkg/requestid"
+	"lyss-ai-platform/eino-service/pkg/responsecache"
+	"lyss-ai-platform/eino-service/pkg/tracing"
 )
 
+// 写入凭证使用审计记录的超时时间
+const usageAuditTimeout = 3 * time.Second
+
+// 累计租户配额用量的超时时间
+const quotaRecordTimeout = 2 * time.Second
+
 // WorkflowManager 工作流管理器
 type WorkflowManager struct {
 	registry         WorkflowRegistry
 	executor         WorkflowExecutor
+	metrics          *MetricsCollector
 	credentialManager *credential.Manager
 	logger           *logrus.Logger
 	config           *config.Config
+
+	transport      http.RoundTripper
+	providerClient *http.Client
+	payloadLimits  *PayloadLimits
+	parameterLimits *ParameterLimits
+	quotaStore     *quota.Store
+	modelAliases   *modelalias.Registry
+
+	// usageAudit 凭证使用审计，为nil时不写审计记录
+	usageAudit *audit.Store
+
+	// promptStore 提示词预设库，为nil时不支持 prompt_id
+	promptStore *prompts.Store
+
+	// responseCache 确定性请求的响应缓存，为nil时不缓存
+	responseCache *responsecache.Store
+
+	// pricing 模型单价表，按成本选择模型、计算审计费用与费用配额时使用
+	pricing audit.Pricing
+
+	// promptTraces 采样记录完整提示词与回答，为nil时不记录
+	promptTraces *prompttrace.Recorder
+
+	// featureFlags 租户级功能开关，为nil时不做限制
+	featureFlags *featureflag.Service
+
+	// stopCancelListener 停止监听跨副本取消请求
+	stopCancelListener context.CancelFunc
 }
 
 // NewWorkflowManager 创建工作流管理器
 func NewWorkflowManager(
 	credentialManager *credential.Manager,
+	redisClient *redis.Client,
+	transport http.RoundTripper,
 	logger *logrus.Logger,
 	config *config.Config,
 ) *WorkflowManager {
 	// 创建注册表
 	registry := NewDefaultWorkflowRegistry(logger)
+
+	// 创建指标收集器
+	metrics := NewMetricsCollector()
 	
 	// 创建执行器
 	executor := NewDefaultWorkflowExecutor(
@@ -35,17 +92,44 @@ func NewWorkflowManager(
 		logger,
 		config.Workflows.MaxConcurrentExecutions,
 		config.Workflows.ExecutionTimeout,
+		metrics,
 	)
+	executor.SetExecutionStore(NewExecutionStore(redisClient, config.Workflows.ExecutionRecordTTL, logger))
 
 	return &WorkflowManager{
 		registry:         registry,
 		executor:         executor,
+		metrics:          metrics,
 		credentialManager: credentialManager,
 		logger:           logger,
 		config:           config,
+		transport:        transport,
+		payloadLimits: &PayloadLimits{
+			MaxBytes:       config.Workflows.MaxMessageBytes,
+			MaxTokens:      config.Workflows.MaxMessageTokens,
+			ModelMaxTokens: config.Workflows.ModelTokenLimits,
+			MaxImageBytes:  config.Workflows.MaxImageBytes,
+		},
+		parameterLimits: &ParameterLimits{
+			Policy:               config.Workflows.ParameterPolicy,
+			MaxOutputTokens:      config.Workflows.MaxOutputTokens,
+			ModelMaxOutputTokens: config.Workflows.ModelMaxOutputTokens,
+		},
+		quotaStore:   quota.NewStore(redisClient, &config.Quota, logger),
+		modelAliases: modelalias.NewRegistry(config.Models.Aliases),
+		pricing:      audit.NewPricing(config.Models.Pricing),
+		providerClient: &http.Client{
+			Transport: transport,
+			Timeout:   config.Services.HTTPClient.ProviderTimeout,
+		},
 	}
 }
 
+// SetUsageAuditStore 设置凭证使用审计存储，每次成功的模型调用写入一条记录
+func (wm *WorkflowManager) SetUsageAuditStore(store *audit.Store) {
+	wm.usageAudit = store
+}
+
 // Initialize 初始化工作流管理器
 func (wm *WorkflowManager) Initialize() error {
 	wm.logger.Info("正在初始化工作流管理器...")
@@ -55,6 +139,13 @@ func (wm *WorkflowManager) Initialize() error {
 		return fmt.Errorf("注册内置工作流失败: %w", err)
 	}
 
+	// 监听其他副本广播的取消请求
+	if executor, ok := wm.executor.(*DefaultWorkflowExecutor); ok {
+		listenerCtx, cancel := context.WithCancel(context.Background())
+		wm.stopCancelListener = cancel
+		executor.StartCancelListener(listenerCtx)
+	}
+
 	wm.logger.WithFields(logrus.Fields{
 		"operation":        "workflow_manager_initialized",
 		"workflow_count":   wm.registry.GetWorkflowCount(),
@@ -64,29 +155,57 @@ func (wm *WorkflowManager) Initialize() error {
 	return nil
 }
 
+// streamingClient 创建EINO模型组件使用的共享传输层客户端
+// 不设置整体超时，流式输出的时长由执行超时与流式看门狗约束
+func (wm *WorkflowManager) streamingClient() *http.Client {
+	return &http.Client{Transport: wm.transport}
+}
+
 // registerBuiltinWorkflows 注册内置工作流
 func (wm *WorkflowManager) registerBuiltinWorkflows() error {
 	// 注册标准EINO聊天工作流（主要工作流）
-	einoChatWorkflow := NewEINOStandardChatWorkflow(wm.credentialManager, wm.logger)
+	einoChatWorkflow := NewEINOStandardChatWorkflow(wm.credentialManager, wm.config.Workflows.MaxProviderFallbacks, wm.config.Workflows.MaxStreamResumes, wm.logger)
+	einoChatWorkflow.SetStreamTimeouts(client.StreamTimeouts{
+		FirstByte: wm.config.Services.HTTPClient.ProviderFirstByteTimeout,
+		Idle:      wm.config.Services.HTTPClient.ProviderStreamIdleTimeout,
+	})
+	einoChatWorkflow.SetHTTPClient(wm.streamingClient())
 	if err := wm.registry.RegisterWorkflow("eino_standard_chat", einoChatWorkflow); err != nil {
 		return fmt.Errorf("注册标准EINO聊天工作流失败: %w", err)
 	}
 
 	// 注册简单聊天工作流（兼容性）
-	simpleChatWorkflow := NewSimpleChatWorkflow(wm.credentialManager, wm.logger)
+	simpleChatWorkflow := NewSimpleChatWorkflow(wm.credentialManager, wm.providerClient, wm.config.Workflows.MaxProviderFallbacks, wm.logger)
+	simpleChatWorkflow.SetModelDefaults(nodes.NewModelDefaultsTable(wm.config.Models.DefaultModel, wm.config.Models.Defaults))
+	simpleChatWorkflow.SetMaxSSELineSize(wm.config.Services.HTTPClient.ProviderMaxSSELineBytes)
 	if err := wm.registry.RegisterWorkflow("simple_chat", simpleChatWorkflow); err != nil {
 		return fmt.Errorf("注册简单聊天工作流失败: %w", err)
 	}
 
 	// 注册标准EINO聊天工作流（旧版本兼容）
 	standardEinoChatWorkflow := NewStandardEINOChatWorkflow(wm.credentialManager, wm.logger)
+	standardEinoChatWorkflow.SetHTTPClient(wm.streamingClient())
 	if err := wm.registry.RegisterWorkflow("standard_eino_chat", standardEinoChatWorkflow); err != nil {
 		return fmt.Errorf("注册标准EINO聊天工作流失败: %w", err)
 	}
 
+	// 注册检索增强生成工作流
+	memoryClient := client.NewMemoryClient(&wm.config.Services.MemoryService, wm.transport, wm.logger)
+	ragWorkflow := NewOptimizedRAGWorkflow(wm.credentialManager, memoryClient, wm.logger)
+	ragWorkflow.SetHTTPClient(wm.streamingClient())
+	if err := wm.registry.RegisterWorkflow("optimized_rag", ragWorkflow); err != nil {
+		return fmt.Errorf("注册检索增强生成工作流失败: %w", err)
+	}
+
+	// 注册工具调用工作流
+	tenantClient := client.NewTenantClient(&wm.config.Services.TenantService, wm.transport, wm.logger)
+	toolCallingWorkflow := NewToolCallingWorkflow(wm.credentialManager, tenantClient, wm.logger)
+	toolCallingWorkflow.SetHTTPClient(wm.streamingClient())
+	if err := wm.registry.RegisterWorkflow("tool_calling", toolCallingWorkflow); err != nil {
+		return fmt.Errorf("注册工具调用工作流失败: %w", err)
+	}
+
 	// TODO: 注册其他EINO工作流
-	// - RAG工作流（基于EINO Graph）
-	// - Tool调用工作流（基于EINO Tools）
 	// - 多步对话工作流
 
 	return nil
@@ -99,6 +218,36 @@ func (wm *WorkflowManager) ExecuteWorkflow(ctx context.Context, req *WorkflowReq
 		return nil, fmt.Errorf("请求验证失败: %w", err)
 	}
 
+	// 出站调用携带请求ID
+	ctx = requestid.WithRequestID(ctx, req.RequestID)
+
+	// 检查租户是否开启了请求使用的功能
+	if err := wm.checkFeatureFlags(ctx, req); err != nil {
+		return nil, err
+	}
+
+	// 工作流执行 span，模型供应商和租户服务的出站调用成为其子 span
+	ctx, span := wm.startWorkflowSpan(ctx, "workflow.execute", req)
+	defer span.End()
+
+	// 解析引用的提示词预设
+	if err := wm.applyPromptPreset(ctx, req); err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	// 确定性请求命中缓存时直接返回，不消耗配额
+	if cached := wm.lookupResponseCache(ctx, req); cached != nil {
+		span.SetAttributes(tracing.CacheHitKey.Bool(true))
+		return cached, nil
+	}
+
+	// 检查租户月度配额
+	if _, err := wm.quotaStore.Check(ctx, req.TenantID); err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
 	// 记录请求
 	wm.logger.WithFields(logrus.Fields{
 		"request_id":     req.RequestID,
@@ -111,8 +260,17 @@ func (wm *WorkflowManager) ExecuteWorkflow(ctx context.Context, req *WorkflowReq
 	}).Info("收到工作流执行请求")
 
 	// 执行工作流
+	traceReason := wm.promptTraceReason(req)
 	response, err := wm.executor.Execute(ctx, req)
+	if traceReason != "" {
+		if err != nil {
+			wm.recordPromptTrace(req, traceReason, "", "", err)
+		} else {
+			wm.recordPromptTrace(req, traceReason, response.Model, response.Content, nil)
+		}
+	}
 	if err != nil {
+		tracing.RecordError(span, err)
 		wm.logger.WithFields(logrus.Fields{
 			"request_id":    req.RequestID,
 			"execution_id":  req.ExecutionID,
@@ -137,6 +295,20 @@ func (wm *WorkflowManager) ExecuteWorkflow(ctx context.Context, req *WorkflowReq
 		"total_tokens":     response.Usage.TotalTokens,
 	}).Info("工作流执行成功")
 
+	// 缓存确定性请求的响应，在附加配额信息之前写入
+	wm.storeResponseCache(ctx, req, response)
+
+	// 累计租户用量，接近上限时在元数据中提示
+	if usage := wm.recordQuotaUsage(ctx, req, response); usage != nil {
+		if response.Metadata == nil {
+			response.Metadata = make(map[string]interface{})
+		}
+		applyQuotaMetadata(response.Metadata, usage)
+	}
+
+	// 写入凭证使用审计
+	wm.recordUsageAudit(req, response)
+
 	return response, nil
 }
 
@@ -147,6 +319,38 @@ func (wm *WorkflowManager) ExecuteWorkflowStream(ctx context.Context, req *Workf
 		return nil, fmt.Errorf("请求验证失败: %w", err)
 	}
 
+	// 出站调用携带请求ID
+	ctx = requestid.WithRequestID(ctx, req.RequestID)
+
+	// 检查租户是否开启了请求使用的功能
+	if err := wm.checkFeatureFlags(ctx, req); err != nil {
+		return nil, err
+	}
+
+	// 流式执行 span 在流结束后结束
+	ctx, span := wm.startWorkflowSpan(ctx, "workflow.execute_stream", req)
+
+	// 解析引用的提示词预设
+	if err := wm.applyPromptPreset(ctx, req); err != nil {
+		tracing.RecordError(span, err)
+		span.End()
+		return nil, err
+	}
+
+	// 命中缓存时将完整回答作为单个增量事件回放；未命中时流式输出不写入缓存
+	if cached := wm.lookupResponseCache(ctx, req); cached != nil {
+		span.SetAttributes(tracing.CacheHitKey.Bool(true))
+		span.End()
+		return replayCachedStream(req, cached), nil
+	}
+
+	// 检查租户月度配额
+	if _, err := wm.quotaStore.Check(ctx, req.TenantID); err != nil {
+		tracing.RecordError(span, err)
+		span.End()
+		return nil, err
+	}
+
 	// 记录流式请求
 	wm.logger.WithFields(logrus.Fields{
 		"request_id":     req.RequestID,
@@ -158,7 +362,144 @@ func (wm *WorkflowManager) ExecuteWorkflowStream(ctx context.Context, req *Workf
 	}).Info("收到工作流流式执行请求")
 
 	// 执行流式工作流
-	return wm.executor.ExecuteStream(ctx, req)
+	traceReason := wm.promptTraceReason(req)
+	responseCh, err := wm.executor.ExecuteStream(ctx, req)
+	if err != nil {
+		if traceReason != "" {
+			wm.recordPromptTrace(req, traceReason, "", "", err)
+		}
+		tracing.RecordError(span, err)
+		span.End()
+		return nil, err
+	}
+	if traceReason != "" {
+		responseCh = wm.tracePromptStream(req, traceReason, responseCh)
+	}
+
+	return wm.recordStreamUsage(ctx, span, req, responseCh), nil
+}
+
+// startWorkflowSpan 创建工作流执行 span，记录租户、用户与工作流标识
+func (wm *WorkflowManager) startWorkflowSpan(ctx context.Context, name string, req *WorkflowRequest) (context.Context, trace.Span) {
+	attrs := append(
+		tracing.IdentityAttributes(req.TenantID, req.UserID),
+		tracing.WorkflowTypeKey.String(req.WorkflowType),
+		tracing.RequestIDKey.String(req.RequestID),
+		tracing.ExecutionIDKey.String(req.ExecutionID),
+	)
+	return tracing.Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// recordStreamUsage 转发流式事件，在结束事件中累计租户用量、写入凭证使用审计并附带配额提示
+// 流结束后结束工作流执行 span，错误事件记录到 span
+func (wm *WorkflowManager) recordStreamUsage(ctx context.Context, span trace.Span, req *WorkflowRequest, responseCh <-chan *WorkflowStreamResponse) <-chan *WorkflowStreamResponse {
+	forwardCh := make(chan *WorkflowStreamResponse, cap(responseCh))
+
+	go func() {
+		defer close(forwardCh)
+		defer span.End()
+
+		for event := range responseCh {
+			if event.Type == StreamEventError {
+				tracing.RecordError(span, errors.New(event.Error))
+			}
+			if event.Type == StreamEventEnd {
+				response := streamEndResponse(event)
+				if usage := wm.recordQuotaUsage(ctx, req, response); usage != nil {
+					if event.Data == nil {
+						event.Data = make(map[string]any)
+					}
+					applyQuotaMetadata(event.Data, usage)
+				}
+				wm.recordUsageAudit(req, response)
+			}
+			forwardCh <- event
+		}
+	}()
+
+	return forwardCh
+}
+
+// recordQuotaUsage 累计租户令牌用量与费用，失败时仅记录日志
+// 模型调用已经计费，使用独立的超时上下文，客户端断开后仍能完成累计
+func (wm *WorkflowManager) recordQuotaUsage(ctx context.Context, req *WorkflowRequest, response *WorkflowResponse) *quota.Usage {
+	if response.Usage == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), quotaRecordTimeout)
+	defer cancel()
+
+	quotaUsage, err := wm.quotaStore.Record(ctx, req.TenantID, response.Usage.TotalTokens, wm.ResponseCost(response))
+	if err != nil {
+		wm.logger.WithFields(logrus.Fields{
+			"request_id": req.RequestID,
+			"tenant_id":  req.TenantID,
+			"operation":  "quota_record_failed",
+			"error":      err.Error(),
+		}).Warn("记录租户用量失败")
+		return nil
+	}
+	return quotaUsage
+}
+
+// recordUsageAudit 写入凭证使用审计记录，失败时仅记录日志
+// 使用独立的超时上下文，客户端断开后仍能完成写入
+func (wm *WorkflowManager) recordUsageAudit(req *WorkflowRequest, response *WorkflowResponse) {
+	if wm.usageAudit == nil {
+		return
+	}
+
+	credentialID, _ := response.Metadata["credential_id"].(string)
+	if credentialID == "" {
+		return
+	}
+	provider, _ := response.Metadata["provider"].(string)
+
+	record := &audit.UsageRecord{
+		TenantID:     req.TenantID,
+		CredentialID: credentialID,
+		Provider:     provider,
+		Model:        response.Model,
+		WorkflowType: req.WorkflowType,
+		RequestID:    req.RequestID,
+		ExecutionID:  req.ExecutionID,
+	}
+	if response.Usage != nil {
+		record.PromptTokens = response.Usage.PromptTokens
+		record.CompletionTokens = response.Usage.CompletionTokens
+		record.TotalTokens = response.Usage.TotalTokens
+		record.Cost = wm.ResponseCost(response)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), usageAuditTimeout)
+	defer cancel()
+
+	if err := wm.usageAudit.Record(ctx, record); err != nil {
+		wm.logger.WithFields(logrus.Fields{
+			"request_id":    req.RequestID,
+			"execution_id":  req.ExecutionID,
+			"credential_id": credentialID,
+			"operation":     "usage_audit_failed",
+			"error":         err.Error(),
+		}).Warn("写入凭证使用审计记录失败")
+	}
+}
+
+// ResponseCost 按模型单价计算一次调用的费用，未配置单价或无用量时为0
+func (wm *WorkflowManager) ResponseCost(response *WorkflowResponse) float64 {
+	if response.Usage == nil {
+		return 0
+	}
+	return wm.pricing.Cost(response.Model, int64(response.Usage.PromptTokens), int64(response.Usage.CompletionTokens))
+}
+
+// applyQuotaMetadata 在响应元数据中附带配额用量，达到软上限时给出提示
+func applyQuotaMetadata(metadata map[string]interface{}, usage *quota.Usage) {
+	metadata["quota"] = usage
+	if usage.SoftExceeded {
+		metadata["quota_warning"] = fmt.Sprintf("本月用量已达上限的 %d%%", usage.Percent)
+	}
 }
 
 // GetWorkflowInfo 获取工作流信息
@@ -183,14 +524,15 @@ func (wm *WorkflowManager) CancelExecution(executionID string) error {
 
 // GetMetrics 获取工作流指标
 func (wm *WorkflowManager) GetMetrics() *WorkflowMetrics {
-	// TODO: 实现指标收集
-	return &WorkflowMetrics{
-		TotalExecutions:      0,
-		SuccessfulExecutions: 0,
-		FailedExecutions:     0,
-		AverageExecutionTime: 0,
-		TotalTokensUsed:      0,
-	}
+	metrics := wm.metrics.Snapshot()
+	metrics.StartedAt = health.StartTime().Format(time.RFC3339)
+	metrics.UptimeSeconds = int64(health.Uptime().Seconds())
+	return metrics
+}
+
+// MetricsHandler 获取Prometheus指标处理器
+func (wm *WorkflowManager) MetricsHandler() http.Handler {
+	return wm.metrics.Handler()
 }
 
 // validateRequest 验证请求
@@ -219,27 +561,214 @@ func (wm *WorkflowManager) validateRequest(req *WorkflowRequest) error {
 		return fmt.Errorf("消息不能为空")
 	}
 
-	// 检查工作流是否存在
-	if !wm.registry.IsWorkflowRegistered(req.WorkflowType) {
-		return fmt.Errorf("工作流类型 %s 不存在", req.WorkflowType)
+	if req.CredentialStrategy != "" && !credential.ValidStrategy(req.CredentialStrategy) {
+		return &InvalidParametersError{
+			WorkflowType: req.WorkflowType,
+			Invalid:      map[string]string{"credential_strategy": "first_available、least_used、round_robin、weighted 或 sticky_by_user"},
+		}
+	}
+
+	// 检查工作流及版本是否存在，未指定版本时使用最新版本
+	info, err := wm.resolveWorkflowVersion(req)
+	if err != nil {
+		return err
+	}
+
+	// 按能力与优化目标选择模型，没有匹配时保留请求的模型
+	if err := wm.applyModelSelection(req); err != nil {
+		return err
+	}
+
+	// 将模型别名解析为供应商与具体模型ID
+	if err := wm.resolveModel(req); err != nil {
+		return err
+	}
+
+	// 按工作流声明的输入定义校验参数并填充默认值
+	if err := applyInputSchema(req, info); err != nil {
+		return err
+	}
+
+	// 校验生成参数的取值范围，按配置策略拒绝或修正越界参数
+	clamped, err := wm.parameterLimits.Apply(req)
+	if err != nil {
+		return err
+	}
+	logClampedParameters(wm.logger, req, clamped)
+
+	// 检查消息大小，避免超出供应商上下文限制
+	if err := wm.payloadLimits.Check(req); err != nil {
+		return err
+	}
+
+	// 检查多模态内容块
+	if err := validateContentParts(req.ContentParts, wm.payloadLimits.MaxImageBytes); err != nil {
+		return err
+	}
+
+	// 检查结构化输出格式
+	if err := validateResponseFormat(req); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// resolveWorkflowVersion 解析请求的工作流版本，workflow_type 支持 name@version 形式
+// 版本未注册时返回列出可用版本的 InvalidParametersError；解析后 WorkflowVersion 固定为实际执行的版本
+func (wm *WorkflowManager) resolveWorkflowVersion(req *WorkflowRequest) (*WorkflowInfo, error) {
+	if name, version := ParseWorkflowRef(req.WorkflowType); version != "" {
+		if req.WorkflowVersion != "" && compareVersions(req.WorkflowVersion, version) != 0 {
+			return nil, &InvalidParametersError{
+				WorkflowType: name,
+				Invalid:      map[string]string{"workflow_version": version},
+			}
+		}
+		req.WorkflowType, req.WorkflowVersion = name, version
+	}
+
+	info, err := wm.registry.GetWorkflowInfo(WorkflowRef(req.WorkflowType, req.WorkflowVersion))
+	if errors.Is(err, ErrUnknownWorkflowVersion) {
+		expected := "已注册的版本"
+		if latest, err := wm.registry.GetWorkflowInfo(req.WorkflowType); err == nil {
+			expected = strings.Join(latest.Versions, "、") + " 之一"
+		}
+		return nil, &InvalidParametersError{
+			WorkflowType: req.WorkflowType,
+			Invalid:      map[string]string{"workflow_version": expected},
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("工作流类型 %s 不存在", req.WorkflowType)
+	}
+
+	req.WorkflowVersion = info.Version
+	return info, nil
+}
+
+// resolveModel 解析请求的模型名称，写入具体模型ID并在未指定供应商时使用别名对应的供应商
+func (wm *WorkflowManager) resolveModel(req *WorkflowRequest) error {
+	name := requestModel(req)
+	if name == "" {
+		return nil
+	}
+
+	resolved, err := wm.modelAliases.Resolve(name)
+	if err != nil {
+		return err
+	}
+
+	if req.ModelConfig == nil {
+		req.ModelConfig = make(map[string]interface{})
+	}
+	if provider, ok := req.ModelConfig["provider"].(string); ok && provider != "" && provider != resolved.Provider {
+		return &InvalidParametersError{
+			WorkflowType: req.WorkflowType,
+			Invalid:      map[string]string{"provider": resolved.Provider},
+		}
 	}
 
+	req.Model = resolved.Model
+	req.ModelConfig["model"] = resolved.Model
+	req.ModelConfig["provider"] = resolved.Provider
 	return nil
 }
 
+// applyModelSelection 请求指定 requires 或 optimize 时，从具备所需能力的模型中选择租户有可用凭证的模型
+// 未指定 optimize 且请求的模型已具备所需能力时保留该模型；没有匹配的模型时使用请求的模型或默认模型
+func (wm *WorkflowManager) applyModelSelection(req *WorkflowRequest) error {
+	if len(req.Requires) == 0 && req.Optimize == "" {
+		return nil
+	}
+	if req.Optimize != "" && req.Optimize != credential.OptimizeCost {
+		return &InvalidParametersError{
+			WorkflowType: req.WorkflowType,
+			Invalid:      map[string]string{"optimize": credential.OptimizeCost},
+		}
+	}
+
+	requested := requestModel(req)
+	if requested != "" && req.Optimize == "" {
+		if resolved, err := wm.modelAliases.Resolve(requested); err == nil && resolved.HasCapabilities(req.Requires) {
+			return nil
+		}
+	}
+
+	provider, _ := req.ModelConfig["provider"].(string)
+	var candidates []credential.ModelCandidate
+	for _, model := range wm.modelAliases.WithCapabilities(req.Requires) {
+		if provider != "" && model.Provider != provider {
+			continue
+		}
+		candidate := credential.ModelCandidate{
+			Alias:    model.Alias,
+			Provider: model.Provider,
+			Model:    model.Model,
+		}
+		if price, ok := wm.modelPrice(model); ok {
+			candidate.Cost = price.PromptPer1K + price.CompletionPer1K
+			candidate.Priced = true
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	logger := wm.logger.WithFields(logrus.Fields{
+		"request_id": req.RequestID,
+		"tenant_id":  req.TenantID,
+		"requires":   req.Requires,
+		"optimize":   req.Optimize,
+	})
+
+	selected, err := wm.credentialManager.SelectModel(req.TenantID, candidates, req.Optimize)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"model":     requested,
+			"operation": "model_selection_fallback",
+			"error":     err.Error(),
+		}).Info("没有满足能力要求的可用模型，使用请求的模型")
+		return nil
+	}
+
+	if req.ModelConfig == nil {
+		req.ModelConfig = make(map[string]interface{})
+	}
+	req.Model = selected.Alias
+	req.ModelConfig["model"] = selected.Alias
+	delete(req.ModelConfig, "provider")
+
+	logger.WithFields(logrus.Fields{
+		"requested_model": requested,
+		"model":           selected.Alias,
+		"provider":        selected.Provider,
+		"operation":       "model_selected",
+	}).Info("已按能力选择模型")
+	return nil
+}
+
+// modelPrice 查找模型单价，先按具体模型ID再按别名查找
+func (wm *WorkflowManager) modelPrice(model modelalias.Model) (config.ModelPricingConfig, bool) {
+	if price, ok := wm.pricing[model.Model]; ok {
+		return price, true
+	}
+	price, ok := wm.pricing[model.Alias]
+	return price, ok
+}
+
 // RegisterWorkflow 注册工作流
 func (wm *WorkflowManager) RegisterWorkflow(name string, workflow WorkflowEngine) error {
 	return wm.registry.RegisterWorkflow(name, workflow)
 }
 
-// UnregisterWorkflow 取消注册工作流
-func (wm *WorkflowManager) UnregisterWorkflow(name string) error {
+// UnregisterWorkflow 取消注册工作流，ref 为 name@version 时只取消该版本
+// 内置工作流的内置版本不能取消注册，同名的其他版本可以
+func (wm *WorkflowManager) UnregisterWorkflow(ref string) error {
 	// 检查是否为内置工作流
-	if wm.isBuiltinWorkflow(name) {
-		return fmt.Errorf("不能取消注册内置工作流: %s", name)
+	name, version := ParseWorkflowRef(ref)
+	if wm.isBuiltinWorkflow(name) && (version == "" || compareVersions(version, builtinWorkflowVersion) == 0) {
+		return fmt.Errorf("不能取消注册内置工作流: %s", ref)
 	}
 
-	return wm.registry.UnregisterWorkflow(name)
+	return wm.registry.UnregisterWorkflow(ref)
 }
 
 // isBuiltinWorkflow 检查是否为内置工作流
@@ -289,13 +818,32 @@ func (wm *WorkflowManager) StartCleanupService() {
 }
 
 // Shutdown 关闭工作流管理器
+// 停止接受新的执行，在宽限期内等待运行中的执行完成，超时后取消剩余执行
 func (wm *WorkflowManager) Shutdown() {
-	wm.logger.Info("正在关闭工作流管理器...")
-	
-	// TODO: 实现优雅关闭
-	// - 等待当前执行完成
-	// - 取消未完成的执行
-	// - 清理资源
-	
-	wm.logger.Info("工作流管理器已关闭")
+	gracePeriod := wm.config.Workflows.ShutdownGracePeriod
+
+	wm.logger.WithFields(logrus.Fields{
+		"grace_period": gracePeriod.String(),
+		"operation":    "shutdown_start",
+	}).Info("正在关闭工作流管理器...")
+
+	executor, ok := wm.executor.(*DefaultWorkflowExecutor)
+	if !ok {
+		wm.logger.Info("工作流管理器已关闭")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+
+	drained, cancelled := executor.Drain(ctx)
+	if wm.stopCancelListener != nil {
+		wm.stopCancelListener()
+	}
+
+	wm.logger.WithFields(logrus.Fields{
+		"drained":   drained,
+		"cancelled": cancelled,
+		"operation": "shutdown_complete",
+	}).Info("工作流管理器已关闭")
 }
\ No newline at end of file
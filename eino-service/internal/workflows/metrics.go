@@ -0,0 +1,143 @@
+package workflows
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsCollector 工作流指标收集器
+// 同时维护JSON接口使用的累计计数和Prometheus指标，两者读取同一份数据源
+type MetricsCollector struct {
+	totalExecutions      atomic.Int64
+	successfulExecutions atomic.Int64
+	failedExecutions     atomic.Int64
+	totalExecutionTimeMs atomic.Int64
+	totalTokensUsed      atomic.Int64
+
+	registry          *prometheus.Registry
+	executionsTotal   *prometheus.CounterVec
+	tokensTotal       *prometheus.CounterVec
+	executionDuration *prometheus.HistogramVec
+}
+
+// NewMetricsCollector 创建工作流指标收集器
+func NewMetricsCollector() *MetricsCollector {
+	registry := prometheus.NewRegistry()
+
+	executionsTotal := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eino",
+		Subsystem: "workflow",
+		Name:      "executions_total",
+		Help:      "工作流执行总次数",
+	}, []string{"workflow_type", "provider", "status"})
+
+	tokensTotal := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eino",
+		Subsystem: "workflow",
+		Name:      "tokens_total",
+		Help:      "工作流消耗的Token总数",
+	}, []string{"workflow_type", "provider", "kind"})
+
+	executionDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "eino",
+		Subsystem: "workflow",
+		Name:      "execution_duration_seconds",
+		Help:      "工作流执行耗时分布",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 30, 60, 120, 300},
+	}, []string{"workflow_type", "provider"})
+
+	registry.MustRegister(
+		executionsTotal,
+		tokensTotal,
+		executionDuration,
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+
+	return &MetricsCollector{
+		registry:          registry,
+		executionsTotal:   executionsTotal,
+		tokensTotal:       tokensTotal,
+		executionDuration: executionDuration,
+	}
+}
+
+// RecordExecution 记录一次工作流执行
+func (m *MetricsCollector) RecordExecution(workflowType, provider string, duration time.Duration, usage *TokenUsage, success bool) {
+	if provider == "" {
+		provider = "unknown"
+	}
+
+	status := "success"
+	m.totalExecutions.Add(1)
+	if success {
+		m.successfulExecutions.Add(1)
+	} else {
+		status = "failed"
+		m.failedExecutions.Add(1)
+	}
+	m.totalExecutionTimeMs.Add(duration.Milliseconds())
+
+	m.executionsTotal.WithLabelValues(workflowType, provider, status).Inc()
+	m.executionDuration.WithLabelValues(workflowType, provider).Observe(duration.Seconds())
+
+	if usage != nil {
+		m.totalTokensUsed.Add(int64(usage.TotalTokens))
+		m.tokensTotal.WithLabelValues(workflowType, provider, "prompt").Add(float64(usage.PromptTokens))
+		m.tokensTotal.WithLabelValues(workflowType, provider, "completion").Add(float64(usage.CompletionTokens))
+	}
+}
+
+// Snapshot 获取当前累计指标
+func (m *MetricsCollector) Snapshot() *WorkflowMetrics {
+	total := m.totalExecutions.Load()
+
+	var average int64
+	if total > 0 {
+		average = m.totalExecutionTimeMs.Load() / total
+	}
+
+	return &WorkflowMetrics{
+		TotalExecutions:      total,
+		SuccessfulExecutions: m.successfulExecutions.Load(),
+		FailedExecutions:     m.failedExecutions.Load(),
+		AverageExecutionTime: average,
+		TotalTokensUsed:      m.totalTokensUsed.Load(),
+	}
+}
+
+// Handler 返回Prometheus文本格式的指标处理器
+func (m *MetricsCollector) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// metricsProviders provider 标签允许的取值
+// 请求中的 provider 由客户端传入，不在列表中的取值记为 unknown，避免任意字符串扩大标签基数
+var metricsProviders = map[string]bool{
+	"openai":    true,
+	"deepseek":  true,
+	"anthropic": true,
+	"google":    true,
+	"azure":     true,
+	"ark":       true,
+}
+
+// resolveProvider 从响应元数据或请求配置中解析供应商，未知供应商返回 unknown
+func resolveProvider(req *WorkflowRequest, response *WorkflowResponse) string {
+	var provider string
+	if response != nil && response.Metadata != nil {
+		provider, _ = response.Metadata["provider"].(string)
+	}
+	if provider == "" && req.ModelConfig != nil {
+		provider, _ = req.ModelConfig["provider"].(string)
+	}
+
+	if !metricsProviders[provider] {
+		return "unknown"
+	}
+	return provider
+}
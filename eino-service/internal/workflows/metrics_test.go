@@ -0,0 +1,30 @@
+package workflows
+
+import (
+	"testing"
+)
+
+func TestResolveProviderBoundsLabel(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		config   map[string]interface{}
+		want     string
+	}{
+		{name: "响应中的供应商", metadata: map[string]interface{}{"provider": "google"}, config: map[string]interface{}{"provider": "deepseek"}, want: "google"},
+		{name: "请求中的已知供应商", config: map[string]interface{}{"provider": "deepseek"}, want: "deepseek"},
+		{name: "请求中的任意字符串", config: map[string]interface{}{"provider": "attacker-controlled-123"}, want: "unknown"},
+		{name: "响应中的未知供应商", metadata: map[string]interface{}{"provider": "custom"}, want: "unknown"},
+		{name: "未指定供应商", want: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &WorkflowRequest{ModelConfig: tt.config}
+			response := &WorkflowResponse{Metadata: tt.metadata}
+			if got := resolveProvider(req, response); got != tt.want {
+				t.Fatalf("resolveProvider = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,24 +3,36 @@ package nodes
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
+	"github.com/cloudwego/eino-ext/components/model/gemini"
+	"github.com/cloudwego/eino/schema"
 	"github.com/sirupsen/logrus"
 
 	"lyss-ai-platform/eino-service/internal/client"
 	"lyss-ai-platform/eino-service/internal/models"
 	"lyss-ai-platform/eino-service/pkg/credential"
+	"lyss-ai-platform/eino-service/pkg/jsonmode"
 )
 
 // ChatModelNode 聊天模型节点
 type ChatModelNode struct {
 	*BaseNode
 	credentialManager *credential.Manager
+	httpClient        *http.Client
+	maxFallbacks      int
+	modelDefaults     *ModelDefaultsTable
+	maxSSELineSize    int
 }
 
+// chatModelNodeProviders 聊天模型节点支持的供应商
+var chatModelNodeProviders = []string{"deepseek", "google"}
+
 // NewChatModelNode 创建聊天模型节点
-func NewChatModelNode(name string, credentialManager *credential.Manager, logger *logrus.Logger) *ChatModelNode {
+// maxFallbacks 为模型调用出现可重试错误时换用备用凭证的最大次数
+func NewChatModelNode(name string, credentialManager *credential.Manager, httpClient *http.Client, maxFallbacks int, logger *logrus.Logger) *ChatModelNode {
 	return &ChatModelNode{
 		BaseNode: NewBaseNode(
 			name,
@@ -29,9 +41,25 @@ func NewChatModelNode(name string, credentialManager *credential.Manager, logger
 			logger,
 		),
 		credentialManager: credentialManager,
+		httpClient:        httpClient,
+		maxFallbacks:      maxFallbacks,
+		modelDefaults:     builtinModelDefaults,
 	}
 }
 
+// SetModelDefaults 设置模型默认参数表，为nil时使用内置默认值
+func (n *ChatModelNode) SetModelDefaults(table *ModelDefaultsTable) {
+	if table == nil {
+		table = builtinModelDefaults
+	}
+	n.modelDefaults = table
+}
+
+// SetMaxSSELineSize 设置供应商流式响应的单行上限，0表示使用默认值
+func (n *ChatModelNode) SetMaxSSELineSize(size int) {
+	n.maxSSELineSize = size
+}
+
 // Execute 执行聊天模型节点
 func (n *ChatModelNode) Execute(ctx context.Context, nodeCtx *NodeContext) (*NodeResult, error) {
 	startTime := time.Now()
@@ -60,7 +88,15 @@ func (n *ChatModelNode) Execute(ctx context.Context, nodeCtx *NodeContext) (*Nod
 	}
 
 	// 获取模型配置
-	modelConfig := n.getModelConfig(nodeCtx.State)
+	modelConfig, err := n.getModelConfig(nodeCtx.State)
+	if err != nil {
+		n.LogNodeError(ctx, nodeCtx, err)
+		return &NodeResult{
+			Success:    false,
+			Error:      fmt.Sprintf("模型参数错误: %s", err.Error()),
+			DurationMs: int(time.Since(startTime).Milliseconds()),
+		}, err
+	}
 	
 	// 获取对话历史（如果存在）
 	var conversationHistory []client.DeepSeekMessage
@@ -84,11 +120,14 @@ func (n *ChatModelNode) Execute(ctx context.Context, nodeCtx *NodeContext) (*Nod
 	// 构建消息序列
 	messages := n.buildMessages(conversationHistory, message, nodeCtx.State)
 
-	// 获取供应商凭证
-	credential, err := n.credentialManager.GetBestCredentialForModel(
+	// 获取供应商凭证，请求未指定策略时使用默认策略
+	strategy, _ := nodeCtx.State["credential_strategy"].(string)
+	credential, err := n.credentialManager.SelectCredential(
 		nodeCtx.TenantID,
 		modelConfig.Provider,
 		modelConfig.ModelName,
+		nodeCtx.UserID,
+		strategy,
 	)
 	if err != nil {
 		n.LogNodeError(ctx, nodeCtx, err)
@@ -102,44 +141,231 @@ func (n *ChatModelNode) Execute(ctx context.Context, nodeCtx *NodeContext) (*Nod
 	// 记录凭证使用
 	n.credentialManager.RecordUsage(credential.ID.String())
 
-	// 调用AI模型
-	result, err := n.callAIModel(ctx, nodeCtx, credential, messages, modelConfig)
+	// 调用AI模型，可重试错误时换用备用凭证
+	var fallbacks []map[string]interface{}
+	failed := make(map[string]bool)
+	var result *NodeResult
+	callConfig := modelConfig
+	for {
+		var release func()
+		release, err = n.credentialManager.AcquireCall(ctx, credential)
+		if err == nil {
+			result, err = n.callAIModel(ctx, nodeCtx, credential, messages, callConfig)
+			release()
+		}
+		if err == nil {
+			break
+		}
+		n.credentialManager.RecordFailure(ctx, credential.ID.String(), err)
+
+		next := n.selectFallback(ctx, nodeCtx, credential, modelConfig, failed, len(fallbacks), err)
+		if next == nil {
+			n.LogNodeError(ctx, nodeCtx, err)
+			return &NodeResult{
+				Success:    false,
+				Error:      fmt.Sprintf("AI模型调用失败: %s", err.Error()),
+				DurationMs: int(time.Since(startTime).Milliseconds()),
+			}, err
+		}
+
+		fallbacks = append(fallbacks, map[string]interface{}{
+			"from_provider":      credential.Provider,
+			"from_credential_id": credential.ID.String(),
+			"to_provider":        next.Provider,
+			"to_credential_id":   next.ID.String(),
+			"error":              err.Error(),
+		})
+		credential = next
+		callConfig = fallbackModelConfig(modelConfig, credential)
+		n.credentialManager.RecordUsage(credential.ID.String())
+	}
+	if len(fallbacks) > 0 {
+		result.NodeMetadata["fallbacks"] = fallbacks
+	}
+
+	// JSON模式下校验输出，无效时重试一次
+	result, err = n.ensureJSONOutput(ctx, nodeCtx, credential, messages, callConfig, result)
 	if err != nil {
 		n.LogNodeError(ctx, nodeCtx, err)
 		return &NodeResult{
 			Success:    false,
-			Error:      fmt.Sprintf("AI模型调用失败: %s", err.Error()),
+			Error:      fmt.Sprintf("模型输出校验失败: %s", err.Error()),
 			DurationMs: int(time.Since(startTime).Milliseconds()),
 		}, err
 	}
 
 	// 处理成功结果
+	n.credentialManager.RecordSuccess(credential.ID.String())
 	result.DurationMs = int(time.Since(startTime).Milliseconds())
 	n.LogNodeComplete(ctx, nodeCtx, result)
 
 	return result, nil
 }
 
-// getModelConfig 获取模型配置
-func (n *ChatModelNode) getModelConfig(state map[string]interface{}) *ModelConfig {
-	config := &ModelConfig{
-		Provider:    "deepseek",
-		ModelName:   "deepseek-chat",
-		Temperature: 0.7,
-		MaxTokens:   2048,
-		Stream:      false,
+// selectFallback 为可重试的调用错误选择备用凭证，无需或无法重试时返回nil
+func (n *ChatModelNode) selectFallback(
+	ctx context.Context,
+	nodeCtx *NodeContext,
+	failedCredential *models.SupplierCredential,
+	config *ModelConfig,
+	failed map[string]bool,
+	attempts int,
+	callErr error,
+) *models.SupplierCredential {
+	if attempts >= n.maxFallbacks || !client.IsRetriableError(callErr) || ctx.Err() != nil {
+		return nil
+	}
+
+	failed[failedCredential.ID.String()] = true
+	next, err := n.credentialManager.SelectFallbackCredential(nodeCtx.TenantID, config.ModelName, chatModelNodeProviders, failed)
+	if err != nil {
+		n.Logger.WithFields(logrus.Fields{
+			"request_id": nodeCtx.RequestID,
+			"tenant_id":  nodeCtx.TenantID,
+			"node_name":  n.Name,
+			"operation":  "provider_fallback_unavailable",
+			"error":      err.Error(),
+		}).Warn("没有可用的备用凭证")
+		return nil
+	}
+
+	n.Logger.WithFields(logrus.Fields{
+		"request_id":    nodeCtx.RequestID,
+		"execution_id":  nodeCtx.ExecutionID,
+		"tenant_id":     nodeCtx.TenantID,
+		"node_name":     n.Name,
+		"from_provider": failedCredential.Provider,
+		"to_provider":   next.Provider,
+		"operation":     "provider_fallback",
+		"error":         callErr.Error(),
+	}).Warn("模型调用失败，换用备用凭证重试")
+
+	return next
+}
+
+// fallbackModelConfig 备用凭证属于其他供应商时，原模型名对该供应商无效，
+// 改用凭证配置的模型，未配置时使用供应商的默认模型，其余生成参数保持不变
+func fallbackModelConfig(config *ModelConfig, credential *models.SupplierCredential) *ModelConfig {
+	if credential.Provider == config.Provider {
+		return config
+	}
+	fallback := *config
+	fallback.Provider = credential.Provider
+	fallback.ModelName = providerDefaultModels[credential.Provider]
+	if model, ok := credential.ModelConfigs["model"].(string); ok && model != "" {
+		fallback.ModelName = model
 	}
+	return &fallback
+}
+
+// ensureJSONOutput JSON模式下校验模型输出，不是合法JSON时使用同一凭证附加纠正提示重试一次
+// 成功时回答内容替换为去除代码块标记后的JSON，重试的令牌用量计入结果
+func (n *ChatModelNode) ensureJSONOutput(
+	ctx context.Context,
+	nodeCtx *NodeContext,
+	credential *models.SupplierCredential,
+	messages []client.DeepSeekMessage,
+	config *ModelConfig,
+	result *NodeResult,
+) (*NodeResult, error) {
+	if !config.ResponseFormat.JSONMode() {
+		return result, nil
+	}
+	result.NodeMetadata["response_format"] = config.ResponseFormat.Type
 
-	// 从状态中获取配置参数
-	if modelName, exists := state["model"]; exists {
-		if name, ok := modelName.(string); ok {
-			config.ModelName = name
+	response, _ := result.Data["response"].(string)
+	content, err := jsonmode.Extract(response)
+	if err != nil {
+		n.Logger.WithFields(logrus.Fields{
+			"request_id":   nodeCtx.RequestID,
+			"execution_id": nodeCtx.ExecutionID,
+			"tenant_id":    nodeCtx.TenantID,
+			"node_name":    n.Name,
+			"provider":     credential.Provider,
+			"operation":    "json_output_retry",
+			"error":        err.Error(),
+		}).Warn("模型输出不是合法的JSON，重试一次")
+
+		retryMessages := make([]client.DeepSeekMessage, 0, len(messages)+2)
+		retryMessages = append(retryMessages, messages...)
+		retryMessages = append(retryMessages,
+			client.DeepSeekMessage{Role: "assistant", Content: response},
+			client.DeepSeekMessage{Role: "user", Content: jsonmode.RetryPrompt(err)},
+		)
+
+		release, err := n.credentialManager.AcquireCall(ctx, credential)
+		if err != nil {
+			return nil, err
+		}
+		retried, err := n.callAIModel(ctx, nodeCtx, credential, retryMessages, config)
+		release()
+		if err != nil {
+			return nil, err
+		}
+
+		response, _ = retried.Data["response"].(string)
+		content, err = jsonmode.Extract(response)
+		if err != nil {
+			return nil, err
+		}
+
+		if result.TokenUsage != nil && retried.TokenUsage != nil {
+			retried.TokenUsage.PromptTokens += result.TokenUsage.PromptTokens
+			retried.TokenUsage.CompletionTokens += result.TokenUsage.CompletionTokens
+			retried.TokenUsage.TotalTokens += result.TokenUsage.TotalTokens
+		}
+		for key, value := range result.NodeMetadata {
+			if _, exists := retried.NodeMetadata[key]; !exists {
+				retried.NodeMetadata[key] = value
+			}
 		}
+		result = retried
+		result.NodeMetadata["json_retried"] = true
+	} else {
+		result.NodeMetadata["json_retried"] = false
+	}
+
+	for _, key := range []string{"response", "assistant_message", "model_response"} {
+		result.Data[key] = content
+	}
+	return result, nil
+}
+
+// getModelConfig 获取模型配置
+// 未指定模型时使用供应商的默认模型，未指定供应商时使用配置的默认模型；
+// 供应商、temperature 与 max_tokens 未指定时取自该模型的默认参数，请求中的值优先
+func (n *ChatModelNode) getModelConfig(state map[string]interface{}) (*ModelConfig, error) {
+	provider, _ := state["provider"].(string)
+	modelName, _ := state["model"].(string)
+	if modelName == "" && provider != "" {
+		modelName = providerDefaultModels[provider]
+	}
+	if modelName == "" {
+		modelName = n.modelDefaults.DefaultModel()
+	}
+
+	defaults := n.modelDefaults.Lookup(modelName)
+	if provider == "" {
+		provider = defaults.Provider
+	}
+	if provider == "" {
+		provider = n.modelDefaults.Lookup(n.modelDefaults.DefaultModel()).Provider
+	}
+
+	config := &ModelConfig{
+		Provider:    provider,
+		ModelName:   modelName,
+		Temperature: defaults.Temperature,
+		MaxTokens:   defaults.MaxTokens,
+		Stream:      false,
 	}
 
 	if temperature, exists := state["temperature"]; exists {
-		if temp, ok := temperature.(float64); ok {
+		switch temp := temperature.(type) {
+		case float64:
 			config.Temperature = temp
+		case int:
+			config.Temperature = float64(temp)
 		}
 	}
 
@@ -155,7 +381,68 @@ func (n *ChatModelNode) getModelConfig(state map[string]interface{}) *ModelConfi
 		}
 	}
 
-	return config
+	if stop, exists := state["stop"]; exists {
+		config.Stop = ToStringSlice(stop)
+	}
+
+	if penalty, exists := state["frequency_penalty"]; exists {
+		value, err := parsePenalty("frequency_penalty", penalty)
+		if err != nil {
+			return nil, err
+		}
+		config.FrequencyPenalty = value
+	}
+
+	if penalty, exists := state["presence_penalty"]; exists {
+		value, err := parsePenalty("presence_penalty", penalty)
+		if err != nil {
+			return nil, err
+		}
+		config.PresencePenalty = value
+	}
+
+	if seed, exists := state["seed"]; exists {
+		switch v := seed.(type) {
+		case int:
+			config.Seed = &v
+		case float64:
+			value := int(v)
+			config.Seed = &value
+		default:
+			return nil, fmt.Errorf("seed必须是整数类型")
+		}
+	}
+
+	if format, ok := state["response_format"].(*models.ResponseFormat); ok {
+		config.ResponseFormat = format
+	}
+
+	return config, nil
+}
+
+// providerDefaultModels 各供应商未指定模型时使用的默认模型
+var providerDefaultModels = map[string]string{
+	"deepseek": "deepseek-chat",
+	"google":   "gemini-1.5-flash",
+}
+
+// parsePenalty 解析并校验惩罚参数，取值范围为[-2, 2]
+func parsePenalty(name string, value interface{}) (float64, error) {
+	var penalty float64
+	switch v := value.(type) {
+	case float64:
+		penalty = v
+	case int:
+		penalty = float64(v)
+	default:
+		return 0, fmt.Errorf("%s必须是数值类型", name)
+	}
+
+	if penalty < -2 || penalty > 2 {
+		return 0, fmt.Errorf("%s取值必须在[-2, 2]范围内，实际为%v", name, penalty)
+	}
+
+	return penalty, nil
 }
 
 // buildMessages 构建消息序列
@@ -172,6 +459,16 @@ func (n *ChatModelNode) buildMessages(history []client.DeepSeekMessage, currentM
 		}
 	}
 
+	// JSON模式下附加只输出JSON的系统指令
+	if format, ok := state["response_format"].(*models.ResponseFormat); ok {
+		if instruction := jsonmode.Instruction(format); instruction != "" {
+			messages = append(messages, client.DeepSeekMessage{
+				Role:    "system",
+				Content: instruction,
+			})
+		}
+	}
+
 	// 添加对话历史
 	messages = append(messages, history...)
 
@@ -192,10 +489,11 @@ func (n *ChatModelNode) callAIModel(
 	messages []client.DeepSeekMessage,
 	config *ModelConfig,
 ) (*NodeResult, error) {
-	// 目前只支持DeepSeek，后续可扩展其他供应商
 	switch credential.Provider {
 	case "deepseek":
 		return n.callDeepSeekModel(ctx, nodeCtx, credential, messages, config)
+	case "google":
+		return n.callGeminiModel(ctx, nodeCtx, credential, messages, config)
 	default:
 		return nil, fmt.Errorf("不支持的供应商: %s", credential.Provider)
 	}
@@ -213,16 +511,28 @@ func (n *ChatModelNode) callDeepSeekModel(
 	deepSeekClient := client.NewDeepSeekClient(
 		credential.APIKey,
 		credential.BaseURL,
+		n.httpClient,
 		n.Logger,
 	)
+	deepSeekClient.SetExtraHeaders(client.ExtraHeaders(credential))
+	deepSeekClient.SetMaxSSELineSize(n.maxSSELineSize)
 
-	// 构建请求
+	// 构建请求，temperature 以指针传递，保证显式的0不会因omitempty被丢弃
+	temperature := config.Temperature
 	req := &client.DeepSeekRequest{
 		Model:       config.ModelName,
 		Messages:    messages,
-		Temperature: config.Temperature,
+		Temperature: &temperature,
 		MaxTokens:   config.MaxTokens,
 		Stream:      config.Stream,
+
+		Stop:             config.Stop,
+		FrequencyPenalty: config.FrequencyPenalty,
+		PresencePenalty:  config.PresencePenalty,
+		Seed:             config.Seed,
+	}
+	if responseType := jsonmode.NativeType(credential.Provider, config.ResponseFormat); responseType != "" {
+		req.ResponseFormat = &client.DeepSeekResponseFormat{Type: responseType}
 	}
 
 	// 发送请求
@@ -233,12 +543,17 @@ func (n *ChatModelNode) callDeepSeekModel(
 
 	// 检查响应
 	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("DeepSeek响应无选择项")
+		return nil, &client.EmptyResponseError{Provider: credential.Provider, Reason: "choices 为空"}
 	}
 
 	choice := resp.Choices[0]
 	if choice.Message == nil {
-		return nil, fmt.Errorf("DeepSeek响应消息为空")
+		return nil, &client.EmptyResponseError{Provider: credential.Provider, Reason: "message 为空"}
+	}
+
+	var finishReason string
+	if choice.FinishReason != nil {
+		finishReason = *choice.FinishReason
 	}
 
 	// 构建结果
@@ -248,7 +563,7 @@ func (n *ChatModelNode) callDeepSeekModel(
 			"response":           choice.Message.Content,
 			"assistant_message":  choice.Message.Content,
 			"model_response":     choice.Message.Content,
-			"finish_reason":      choice.FinishReason,
+			"finish_reason":      finishReason,
 			"response_id":        resp.ID,
 			"model_used":         resp.Model,
 		},
@@ -261,14 +576,103 @@ func (n *ChatModelNode) callDeepSeekModel(
 			"provider":       credential.Provider,
 			"model":          resp.Model,
 			"credential_id":  credential.ID.String(),
-			"finish_reason":  choice.FinishReason,
+			"finish_reason":  finishReason,
 			"messages_count": len(messages),
 		},
 	}
+	if config.Seed != nil {
+		result.NodeMetadata["seed"] = *config.Seed
+	}
 
 	return result, nil
 }
 
+// callGeminiModel 通过EINO Gemini模型组件调用Gemini
+// Gemini 不支持的 stop、penalty 与 seed 参数会被忽略
+func (n *ChatModelNode) callGeminiModel(
+	ctx context.Context,
+	nodeCtx *NodeContext,
+	credential *models.SupplierCredential,
+	messages []client.DeepSeekMessage,
+	config *ModelConfig,
+) (*NodeResult, error) {
+	httpClient := client.WithExtraHeaders(n.httpClient, client.ExtraHeaders(credential))
+	geminiClient, err := client.NewGeminiClient(ctx, credential.APIKey, credential.BaseURL, httpClient, n.Logger)
+	if err != nil {
+		return nil, err
+	}
+
+	temperature := float32(config.Temperature)
+	maxTokens := config.MaxTokens
+	chatModel, err := gemini.NewChatModel(ctx, &gemini.Config{
+		Client:      geminiClient.GenAI(),
+		Model:       config.ModelName,
+		Temperature: &temperature,
+		MaxTokens:   &maxTokens,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建Gemini模型失败: %w", err)
+	}
+
+	resp, err := chatModel.Generate(ctx, toSchemaMessages(messages))
+	if err != nil {
+		return nil, fmt.Errorf("Gemini API调用失败: %w", client.AsProviderError(credential.Provider, err))
+	}
+	if resp == nil {
+		return nil, &client.EmptyResponseError{Provider: credential.Provider, Reason: "message 为空"}
+	}
+
+	var finishReason string
+	usage := &models.TokenUsage{}
+	if resp.ResponseMeta != nil {
+		finishReason = resp.ResponseMeta.FinishReason
+		if resp.ResponseMeta.Usage != nil {
+			usage.PromptTokens = resp.ResponseMeta.Usage.PromptTokens
+			usage.CompletionTokens = resp.ResponseMeta.Usage.CompletionTokens
+			usage.TotalTokens = resp.ResponseMeta.Usage.TotalTokens
+		}
+	}
+
+	return &NodeResult{
+		Success: true,
+		Data: map[string]interface{}{
+			"response":          resp.Content,
+			"assistant_message": resp.Content,
+			"model_response":    resp.Content,
+			"finish_reason":     finishReason,
+			"model_used":        config.ModelName,
+		},
+		TokenUsage: usage,
+		NodeMetadata: map[string]interface{}{
+			"provider":       credential.Provider,
+			"model":          config.ModelName,
+			"credential_id":  credential.ID.String(),
+			"finish_reason":  finishReason,
+			"messages_count": len(messages),
+		},
+	}, nil
+}
+
+// toSchemaMessages 将节点消息转换为EINO消息，未知角色按用户消息处理
+// assistant 到 Gemini model 角色、system 到系统指令的映射由EINO Gemini组件完成
+func toSchemaMessages(messages []client.DeepSeekMessage) []*schema.Message {
+	result := make([]*schema.Message, 0, len(messages))
+	for _, message := range messages {
+		role := schema.User
+		switch message.Role {
+		case "system":
+			role = schema.System
+		case "assistant":
+			role = schema.Assistant
+		}
+		result = append(result, &schema.Message{
+			Role:    role,
+			Content: message.Content,
+		})
+	}
+	return result
+}
+
 // ValidateInput 验证输入数据
 func (n *ChatModelNode) ValidateInput(input map[string]interface{}) error {
 	if err := n.BaseNode.ValidateInput(input); err != nil {
@@ -309,4 +713,11 @@ type ModelConfig struct {
 	Temperature float64 `json:"temperature"`
 	MaxTokens   int     `json:"max_tokens"`
 	Stream      bool    `json:"stream"`
+
+	Stop             []string `json:"stop,omitempty"`
+	FrequencyPenalty float64  `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float64  `json:"presence_penalty,omitempty"`
+	Seed             *int     `json:"seed,omitempty"`
+
+	ResponseFormat *models.ResponseFormat `json:"response_format,omitempty"`
 }
\ No newline at end of file
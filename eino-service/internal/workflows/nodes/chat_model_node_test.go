@@ -0,0 +1,105 @@
+package nodes
+
+import (
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestChatModelNode() *ChatModelNode {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewChatModelNode("chat_model", nil, nil, 0, logger)
+}
+
+func TestToStringSlice(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []string
+	}{
+		{name: "nil", value: nil, want: nil},
+		{name: "empty string", value: "", want: nil},
+		{name: "single string", value: "END", want: []string{"END"}},
+		{name: "string slice", value: []string{"a", "b"}, want: []string{"a", "b"}},
+		{name: "interface slice skips empty and non-string", value: []interface{}{"a", "", 1, "b"}, want: []string{"a", "b"}},
+		{name: "unsupported type", value: 42, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToStringSlice(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ToStringSlice(%#v) = %#v，期望 %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetModelConfigStopAndPenalties(t *testing.T) {
+	tests := []struct {
+		name          string
+		state         map[string]interface{}
+		wantStop      []string
+		wantFrequency float64
+		wantPresence  float64
+		wantErr       bool
+	}{
+		{
+			name:  "omitted",
+			state: map[string]interface{}{},
+		},
+		{
+			name:     "single stop",
+			state:    map[string]interface{}{"stop": "END"},
+			wantStop: []string{"END"},
+		},
+		{
+			name:     "stop list",
+			state:    map[string]interface{}{"stop": []interface{}{"a", ""}},
+			wantStop: []string{"a"},
+		},
+		{
+			name:          "explicit penalties",
+			state:         map[string]interface{}{"frequency_penalty": 1.5, "presence_penalty": -2},
+			wantFrequency: 1.5,
+			wantPresence:  -2,
+		},
+		{
+			name:    "frequency penalty out of range",
+			state:   map[string]interface{}{"frequency_penalty": 2.5},
+			wantErr: true,
+		},
+		{
+			name:    "presence penalty not numeric",
+			state:   map[string]interface{}{"presence_penalty": "high"},
+			wantErr: true,
+		},
+	}
+
+	node := newTestChatModelNode()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := node.getModelConfig(tt.state)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("期望返回错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getModelConfig 返回错误: %v", err)
+			}
+			if !reflect.DeepEqual(config.Stop, tt.wantStop) {
+				t.Fatalf("Stop = %#v，期望 %#v", config.Stop, tt.wantStop)
+			}
+			if config.FrequencyPenalty != tt.wantFrequency {
+				t.Fatalf("FrequencyPenalty = %v，期望 %v", config.FrequencyPenalty, tt.wantFrequency)
+			}
+			if config.PresencePenalty != tt.wantPresence {
+				t.Fatalf("PresencePenalty = %v，期望 %v", config.PresencePenalty, tt.wantPresence)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package nodes
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/internal/testutil/credentialtest"
+)
+
+func TestChatModelNodeConcurrencyLimitFallsBack(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxFallbacks  int
+		wantSecondary int32
+		wantFailures  int
+	}{
+		{name: "超出上限的调用换用备用凭证", maxFallbacks: 1, wantSecondary: 1},
+		{name: "未开启备用时超出上限的调用失败", maxFallbacks: 0, wantFailures: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var primaryCalls, secondaryCalls int32
+			// 主凭证的供应商响应较慢，保证两次调用重叠
+			primary := newProviderServer(t, http.StatusOK, "来自主凭证", &primaryCalls)
+			handler := primary.Config.Handler
+			primary.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(100 * time.Millisecond)
+				handler.ServeHTTP(w, r)
+			})
+			secondary := newProviderServer(t, http.StatusOK, "来自备用凭证", &secondaryCalls)
+
+			tenantService := testutil.NewTenantService(t)
+			primaryCred := testutil.Credential("deepseek", primary.URL)
+			secondaryCred := testutil.Credential("deepseek", secondary.URL)
+			primaryCred.ID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+			secondaryCred.ID = uuid.MustParse("00000000-0000-0000-0000-000000000002")
+			primaryCred.ModelConfigs["max_concurrent_calls"] = float64(1)
+			// 主凭证配置了所用模型，评分高于备用凭证，两次调用都优先选中主凭证
+			primaryCred.ModelConfigs["deepseek-chat"] = map[string]interface{}{}
+			tenantService.SetCredentials(testTenantID, primaryCred, secondaryCred)
+
+			node := NewChatModelNode("chat_model", credentialtest.NewManager(t, tenantService), http.DefaultClient, tt.maxFallbacks, testutil.Logger())
+
+			var wg sync.WaitGroup
+			var failures int32
+			for i := 0; i < 2; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					// 第二次调用在第一次占用名额后发起
+					time.Sleep(time.Duration(i) * 30 * time.Millisecond)
+					_, err := node.Execute(context.Background(), &NodeContext{
+						RequestID: "req-1",
+						TenantID:  testTenantID,
+						UserID:    "user-1",
+						State:     map[string]interface{}{"message": "你好", "provider": "deepseek"},
+					})
+					if err != nil {
+						atomic.AddInt32(&failures, 1)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			if got := atomic.LoadInt32(&primaryCalls); got != 1 {
+				t.Fatalf("主凭证调用次数 = %d，期望不超过上限 1", got)
+			}
+			if got := atomic.LoadInt32(&secondaryCalls); got != tt.wantSecondary {
+				t.Fatalf("备用凭证调用次数 = %d，期望 %d", got, tt.wantSecondary)
+			}
+			if got := int(atomic.LoadInt32(&failures)); got != tt.wantFailures {
+				t.Fatalf("失败次数 = %d，期望 %d", got, tt.wantFailures)
+			}
+		})
+	}
+}
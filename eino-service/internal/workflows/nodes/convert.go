@@ -0,0 +1,25 @@
+package nodes
+
+// ToStringSlice 将单个字符串或字符串数组统一转换为[]string，空字符串被忽略
+// 工作流参数解析与节点配置共用，如 stop 停止序列
+func ToStringSlice(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				result = append(result, s)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
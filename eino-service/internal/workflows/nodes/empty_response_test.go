@@ -0,0 +1,52 @@
+package nodes
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/client"
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/internal/testutil/credentialtest"
+)
+
+func TestChatModelNodeEmptyProviderResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "choices为空", body: `{"id":"1","choices":[]}`},
+		{name: "message为null", body: `{"id":"1","choices":[{"index":0,"message":null}]}`},
+		{name: "空对象", body: `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, tt.body)
+			}))
+			t.Cleanup(server.Close)
+
+			tenantService := testutil.NewTenantService(t)
+			tenantService.SetCredentials(testTenantID, testutil.Credential("deepseek", server.URL))
+			node := NewChatModelNode("chat_model", credentialtest.NewManager(t, tenantService), http.DefaultClient, 0, testutil.Logger())
+
+			result, err := node.Execute(context.Background(), &NodeContext{
+				RequestID: "req-1",
+				TenantID:  testTenantID,
+				UserID:    "user-1",
+				State:     map[string]interface{}{"message": "你好", "provider": "deepseek"},
+			})
+			if !errors.Is(err, client.ErrEmptyProviderResponse) {
+				t.Fatalf("错误 = %v，期望 ErrEmptyProviderResponse", err)
+			}
+			if result == nil || result.Success || result.Error == "" {
+				t.Fatalf("节点结果 = %+v，期望失败并包含错误信息", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"lyss-ai-platform/eino-service/internal/client"
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/internal/testutil/credentialtest"
+)
+
+const testTenantID = "7b0c3c9e-5f44-4c3a-9d59-2f0a4a4c1e01"
+
+// newProviderServer 启动DeepSeek兼容的供应商替身，status 非200时返回错误响应
+func newProviderServer(t *testing.T, status int, content string, calls *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		if status != http.StatusOK {
+			http.Error(w, `{"error":{"message":"upstream failure"}}`, status)
+			return
+		}
+		finish := "stop"
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(client.DeepSeekResponse{
+			ID:    "resp-1",
+			Model: "deepseek-chat",
+			Choices: []client.DeepSeekChoice{{
+				Message:      &client.DeepSeekMessage{Role: "assistant", Content: content},
+				FinishReason: &finish,
+			}},
+			Usage: client.DeepSeekUsage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestChatModelNodeProviderFallback(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxFallbacks  int
+		primaryStatus int
+		wantSuccess   bool
+		wantFallbacks int
+		wantSecondary int32
+	}{
+		{name: "500 falls back to second provider", maxFallbacks: 1, primaryStatus: http.StatusInternalServerError, wantSuccess: true, wantFallbacks: 1, wantSecondary: 1},
+		{name: "fallback disabled", maxFallbacks: 0, primaryStatus: http.StatusInternalServerError, wantSuccess: false},
+		{name: "400 is not retried", maxFallbacks: 1, primaryStatus: http.StatusBadRequest, wantSuccess: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var primaryCalls, secondaryCalls int32
+			primary := newProviderServer(t, tt.primaryStatus, "", &primaryCalls)
+			secondary := newProviderServer(t, http.StatusOK, "来自备用凭证", &secondaryCalls)
+
+			tenantService := testutil.NewTenantService(t)
+			primaryCred := testutil.Credential("deepseek", primary.URL)
+			secondaryCred := testutil.Credential("deepseek", secondary.URL)
+			// 候选凭证按ID排序，固定ID保证主凭证先被选中
+			primaryCred.ID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+			secondaryCred.ID = uuid.MustParse("00000000-0000-0000-0000-000000000002")
+			tenantService.SetCredentials(testTenantID, primaryCred, secondaryCred)
+
+			node := NewChatModelNode("chat_model", credentialtest.NewManager(t, tenantService), http.DefaultClient, tt.maxFallbacks, testutil.Logger())
+			result, err := node.Execute(context.Background(), &NodeContext{
+				RequestID: "req-1",
+				TenantID:  testTenantID,
+				UserID:    "user-1",
+				State:     map[string]interface{}{"message": "你好", "provider": "deepseek"},
+			})
+
+			if atomic.LoadInt32(&primaryCalls) == 0 {
+				t.Fatalf("主凭证的供应商未被调用: %v", err)
+			}
+			if got := atomic.LoadInt32(&secondaryCalls); got != tt.wantSecondary {
+				t.Fatalf("备用供应商调用次数 = %d，期望 %d", got, tt.wantSecondary)
+			}
+			if !tt.wantSuccess {
+				if err == nil {
+					t.Fatal("期望返回错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute 返回错误: %v", err)
+			}
+			if got := result.Data["response"]; got != "来自备用凭证" {
+				t.Fatalf("response = %v，期望来自备用凭证", got)
+			}
+			if got := result.NodeMetadata["credential_id"]; got != secondaryCred.ID.String() {
+				t.Fatalf("credential_id = %v，期望 %s", got, secondaryCred.ID)
+			}
+			fallbacks, _ := result.NodeMetadata["fallbacks"].([]map[string]interface{})
+			if len(fallbacks) != tt.wantFallbacks {
+				t.Fatalf("fallbacks = %v，期望 %d 条", result.NodeMetadata["fallbacks"], tt.wantFallbacks)
+			}
+			if fallbacks[0]["from_credential_id"] != primaryCred.ID.String() {
+				t.Fatalf("from_credential_id = %v，期望 %s", fallbacks[0]["from_credential_id"], primaryCred.ID)
+			}
+		})
+	}
+}
+
+func TestChatModelNodeCrossProviderFallbackModel(t *testing.T) {
+	tests := []struct {
+		name        string
+		modelConfig map[string]interface{}
+		wantModel   string
+	}{
+		{name: "使用供应商默认模型", wantModel: "gemini-1.5-flash"},
+		{name: "使用凭证配置的模型", modelConfig: map[string]interface{}{"model": "gemini-2.0-flash"}, wantModel: "gemini-2.0-flash"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var primaryCalls int32
+			primary := newProviderServer(t, http.StatusInternalServerError, "", &primaryCalls)
+			geminiServer, geminiRequests := newGeminiServer(t, "来自Gemini")
+
+			tenantService := testutil.NewTenantService(t)
+			primaryCred := testutil.Credential("deepseek", primary.URL)
+			geminiCred := testutil.Credential("google", geminiServer.URL)
+			geminiCred.ModelConfigs = tt.modelConfig
+			tenantService.SetCredentials(testTenantID, primaryCred, geminiCred)
+
+			node := NewChatModelNode("chat_model", credentialtest.NewManager(t, tenantService), http.DefaultClient, 1, testutil.Logger())
+			result, err := node.Execute(context.Background(), &NodeContext{
+				RequestID: "req-1",
+				TenantID:  testTenantID,
+				UserID:    "user-1",
+				State:     map[string]interface{}{"message": "你好", "model": "deepseek-chat"},
+			})
+			if err != nil {
+				t.Fatalf("Execute 返回错误: %v", err)
+			}
+			if atomic.LoadInt32(&primaryCalls) == 0 {
+				t.Fatal("主凭证的供应商未被调用")
+			}
+
+			requests := geminiRequests()
+			if len(requests) != 1 {
+				t.Fatalf("Gemini 请求数 = %d，期望 1", len(requests))
+			}
+			if want := "/models/" + tt.wantModel + ":generateContent"; !strings.HasSuffix(requests[0].Path, want) {
+				t.Fatalf("Gemini 请求路径 = %s，期望以 %s 结尾", requests[0].Path, want)
+			}
+			if got := result.NodeMetadata["model"]; got != tt.wantModel {
+				t.Fatalf("model = %v，期望 %s", got, tt.wantModel)
+			}
+			if got := result.NodeMetadata["provider"]; got != "google" {
+				t.Fatalf("provider = %v，期望 google", got)
+			}
+		})
+	}
+}
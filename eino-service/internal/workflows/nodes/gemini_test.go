@@ -0,0 +1,154 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/internal/testutil/credentialtest"
+)
+
+// geminiRequest 供应商替身记录的 generateContent 请求
+type geminiRequest struct {
+	Path   string
+	APIKey string
+	Body   struct {
+		Contents []struct {
+			Role  string `json:"role"`
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"contents"`
+		SystemInstruction *struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"systemInstruction"`
+	}
+}
+
+// newGeminiServer 启动Gemini API替身，记录收到的请求并返回固定回复与用量
+func newGeminiServer(t *testing.T, content string) (*httptest.Server, func() []geminiRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []geminiRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := geminiRequest{Path: r.URL.Path, APIKey: r.Header.Get("x-goog-api-key")}
+		json.NewDecoder(r.Body).Decode(&req.Body)
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"candidates": []map[string]interface{}{{
+				"content":      map[string]interface{}{"role": "model", "parts": []map[string]string{{"text": content}}},
+				"finishReason": "STOP",
+			}},
+			"usageMetadata": map[string]int{"promptTokenCount": 4, "candidatesTokenCount": 2, "totalTokenCount": 6},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []geminiRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]geminiRequest(nil), requests...)
+	}
+}
+
+func TestChatModelNodeGeminiRouting(t *testing.T) {
+	tests := []struct {
+		name         string
+		provider     string
+		wantResponse string
+		wantGemini   bool
+	}{
+		{name: "google路由到Gemini", provider: "google", wantResponse: "来自Gemini", wantGemini: true},
+		{name: "deepseek不经过Gemini", provider: "deepseek", wantResponse: "来自DeepSeek"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deepseekCalls int32
+			geminiServer, geminiRequests := newGeminiServer(t, "来自Gemini")
+			deepseekServer := newProviderServer(t, http.StatusOK, "来自DeepSeek", &deepseekCalls)
+
+			tenantService := testutil.NewTenantService(t)
+			geminiCred := testutil.Credential("google", geminiServer.URL)
+			tenantService.SetCredentials(testTenantID, geminiCred, testutil.Credential("deepseek", deepseekServer.URL))
+
+			node := NewChatModelNode("chat_model", credentialtest.NewManager(t, tenantService), http.DefaultClient, 0, testutil.Logger())
+			result, err := node.Execute(context.Background(), &NodeContext{
+				RequestID: "req-1",
+				TenantID:  testTenantID,
+				UserID:    "user-1",
+				State: map[string]interface{}{
+					"message":       "你好",
+					"provider":      tt.provider,
+					"system_prompt": "你是助手",
+					"conversation_history": []interface{}{
+						map[string]interface{}{"role": "user", "content": "早上好"},
+						map[string]interface{}{"role": "assistant", "content": "早上好！"},
+					},
+				},
+			})
+			if err != nil {
+				t.Fatalf("Execute 返回错误: %v", err)
+			}
+			if got := result.Data["response"]; got != tt.wantResponse {
+				t.Fatalf("response = %v，期望 %s", got, tt.wantResponse)
+			}
+			if got := result.NodeMetadata["provider"]; got != tt.provider {
+				t.Fatalf("provider = %v，期望 %s", got, tt.provider)
+			}
+
+			requests := geminiRequests()
+			if !tt.wantGemini {
+				if len(requests) != 0 {
+					t.Fatalf("Gemini 替身收到 %d 次请求，期望 0", len(requests))
+				}
+				if atomic.LoadInt32(&deepseekCalls) != 1 {
+					t.Fatalf("DeepSeek 调用次数 = %d，期望 1", deepseekCalls)
+				}
+				return
+			}
+
+			if atomic.LoadInt32(&deepseekCalls) != 0 {
+				t.Fatalf("DeepSeek 调用次数 = %d，期望 0", deepseekCalls)
+			}
+			if len(requests) != 1 {
+				t.Fatalf("Gemini 请求数 = %d，期望 1", len(requests))
+			}
+			req := requests[0]
+			if !strings.HasSuffix(req.Path, "/models/gemini-1.5-flash:generateContent") {
+				t.Fatalf("请求路径 = %s，期望调用默认模型 gemini-1.5-flash", req.Path)
+			}
+			if req.APIKey != geminiCred.APIKey {
+				t.Fatalf("x-goog-api-key = %q，期望凭证的 API Key", req.APIKey)
+			}
+			if req.Body.SystemInstruction == nil || len(req.Body.SystemInstruction.Parts) == 0 || req.Body.SystemInstruction.Parts[0].Text != "你是助手" {
+				t.Fatalf("systemInstruction = %+v，期望系统提示词", req.Body.SystemInstruction)
+			}
+			var roles []string
+			for _, content := range req.Body.Contents {
+				roles = append(roles, content.Role)
+			}
+			if got := strings.Join(roles, ","); got != "user,model,user" {
+				t.Fatalf("contents 角色 = %s，期望 user,model,user", got)
+			}
+
+			if result.TokenUsage == nil || result.TokenUsage.PromptTokens != 4 || result.TokenUsage.CompletionTokens != 2 || result.TokenUsage.TotalTokens != 6 {
+				t.Fatalf("用量 = %+v，期望 4/2/6", result.TokenUsage)
+			}
+			if got := result.Data["finish_reason"]; got != "STOP" {
+				t.Fatalf("finish_reason = %v，期望 STOP", got)
+			}
+		})
+	}
+}
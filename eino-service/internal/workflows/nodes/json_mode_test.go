@@ -0,0 +1,117 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/client"
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/internal/testutil/credentialtest"
+	"lyss-ai-platform/eino-service/pkg/jsonmode"
+)
+
+// newSequenceServer 依次返回 replies 中的回答，超出后重复最后一个，并记录收到的请求
+func newSequenceServer(t *testing.T, replies []string, requests *[]client.DeepSeekRequest) *httptest.Server {
+	t.Helper()
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req client.DeepSeekRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mutex.Lock()
+		*requests = append(*requests, req)
+		reply := replies[min(len(*requests), len(replies))-1]
+		mutex.Unlock()
+
+		finish := "stop"
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(client.DeepSeekResponse{
+			ID:    "resp-1",
+			Model: "deepseek-chat",
+			Choices: []client.DeepSeekChoice{{
+				Message:      &client.DeepSeekMessage{Role: "assistant", Content: reply},
+				FinishReason: &finish,
+			}},
+			Usage: client.DeepSeekUsage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestChatModelNodeJSONMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		replies     []string
+		wantCalls   int
+		wantContent string
+		wantRetried bool
+		wantTokens  int
+		wantErr     bool
+	}{
+		{name: "首次即为合法JSON", replies: []string{`{"city":"北京"}`}, wantCalls: 1, wantContent: `{"city":"北京"}`, wantTokens: 5},
+		{name: "代码块包裹", replies: []string{"```json\n{\"city\":\"北京\"}\n```"}, wantCalls: 1, wantContent: `{"city":"北京"}`, wantTokens: 5},
+		{name: "无效后重试一次", replies: []string{"城市是北京", `{"city":"北京"}`}, wantCalls: 2, wantContent: `{"city":"北京"}`, wantRetried: true, wantTokens: 10},
+		{name: "重试后仍无效", replies: []string{"城市是北京", "还是北京"}, wantCalls: 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []client.DeepSeekRequest
+			server := newSequenceServer(t, tt.replies, &requests)
+			tenantService := testutil.NewTenantService(t)
+			tenantService.SetCredentials(testTenantID, testutil.Credential("deepseek", server.URL))
+			node := NewChatModelNode("chat_model", credentialtest.NewManager(t, tenantService), http.DefaultClient, 0, testutil.Logger())
+
+			result, err := node.Execute(context.Background(), &NodeContext{
+				RequestID: "req-1",
+				TenantID:  testTenantID,
+				UserID:    "user-1",
+				State: map[string]interface{}{
+					"message":         "北京在哪个城市？",
+					"provider":        "deepseek",
+					"response_format": &models.ResponseFormat{Type: models.ResponseFormatJSONObject},
+				},
+			})
+			if len(requests) != tt.wantCalls {
+				t.Fatalf("供应商调用次数 = %d，期望 %d", len(requests), tt.wantCalls)
+			}
+			if requests[0].ResponseFormat == nil || requests[0].ResponseFormat.Type != models.ResponseFormatJSONObject {
+				t.Fatalf("response_format = %+v，期望 json_object", requests[0].ResponseFormat)
+			}
+			if first := requests[0].Messages[0]; first.Role != "system" || first.Content != jsonmode.Instruction(&models.ResponseFormat{Type: models.ResponseFormatJSONObject}) {
+				t.Fatalf("首条消息 = %+v，期望JSON系统指令", first)
+			}
+
+			if tt.wantErr {
+				if !errors.Is(err, jsonmode.ErrInvalidJSON) || result.Success {
+					t.Fatalf("结果 = %+v, %v，期望 ErrInvalidJSON", result, err)
+				}
+				return
+			}
+			if err != nil || !result.Success {
+				t.Fatalf("结果 = %+v, %v", result, err)
+			}
+			if got := result.Data["response"]; got != tt.wantContent {
+				t.Fatalf("response = %v，期望 %s", got, tt.wantContent)
+			}
+			if got := result.NodeMetadata["json_retried"]; got != tt.wantRetried {
+				t.Fatalf("json_retried = %v，期望 %v", got, tt.wantRetried)
+			}
+			if result.TokenUsage.TotalTokens != tt.wantTokens {
+				t.Fatalf("令牌用量 = %d，期望 %d", result.TokenUsage.TotalTokens, tt.wantTokens)
+			}
+			if tt.wantRetried {
+				retry := requests[1].Messages
+				if last := retry[len(retry)-1]; last.Role != "user" || retry[len(retry)-2].Content != tt.replies[0] {
+					t.Fatalf("重试消息 = %+v，期望附带上次回答与纠正提示", retry[len(retry)-2:])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package nodes
+
+import (
+	"lyss-ai-platform/eino-service/internal/config"
+)
+
+// 模型默认参数表未配置或未声明对应值时使用的内置默认值
+const (
+	builtinDefaultModel       = "deepseek-chat"
+	builtinDefaultTemperature = 0.7
+	builtinDefaultMaxTokens   = 2048
+)
+
+// ModelDefaults 模型的默认生成参数，请求未指定时使用
+type ModelDefaults struct {
+	Provider    string
+	Temperature float64
+	MaxTokens   int
+}
+
+// ModelDefaultsTable 按模型查找默认生成参数
+type ModelDefaultsTable struct {
+	defaultModel string
+	models       map[string]ModelDefaults
+}
+
+// NewModelDefaultsTable 根据配置创建模型默认参数表，配置的条目覆盖内置供应商默认模型的条目
+// defaultModel 为请求既未指定模型也未指定供应商时使用的模型，为空时使用 deepseek-chat
+func NewModelDefaultsTable(defaultModel string, entries []config.ModelDefaultsConfig) *ModelDefaultsTable {
+	if defaultModel == "" {
+		defaultModel = builtinDefaultModel
+	}
+
+	table := &ModelDefaultsTable{
+		defaultModel: defaultModel,
+		models:       make(map[string]ModelDefaults, len(builtinModelDefaultEntries)+len(entries)),
+	}
+	// 显式复制，避免 append 写入内置条目切片的底层数组
+	merged := make([]config.ModelDefaultsConfig, 0, len(builtinModelDefaultEntries)+len(entries))
+	merged = append(merged, builtinModelDefaultEntries...)
+	merged = append(merged, entries...)
+	for _, entry := range merged {
+		defaults := ModelDefaults{
+			Provider:    entry.Provider,
+			Temperature: builtinDefaultTemperature,
+			MaxTokens:   builtinDefaultMaxTokens,
+		}
+		if entry.Temperature != nil {
+			defaults.Temperature = *entry.Temperature
+		}
+		if entry.MaxTokens > 0 {
+			defaults.MaxTokens = entry.MaxTokens
+		}
+		table.models[entry.Model] = defaults
+	}
+	return table
+}
+
+// DefaultModel 请求未指定模型和供应商时使用的模型
+func (t *ModelDefaultsTable) DefaultModel() string {
+	return t.defaultModel
+}
+
+// Lookup 获取模型的默认参数，未配置的模型使用内置默认值，供应商为空
+func (t *ModelDefaultsTable) Lookup(model string) ModelDefaults {
+	if defaults, ok := t.models[model]; ok {
+		return defaults
+	}
+	return ModelDefaults{
+		Temperature: builtinDefaultTemperature,
+		MaxTokens:   builtinDefaultMaxTokens,
+	}
+}
+
+// builtinModelDefaultEntries 内置供应商默认模型的条目，保证默认模型总能确定供应商
+var builtinModelDefaultEntries = []config.ModelDefaultsConfig{
+	{Model: "deepseek-chat", Provider: "deepseek"},
+	{Model: "gemini-1.5-flash", Provider: "google"},
+}
+
+// builtinModelDefaults 未设置模型默认参数表时使用
+var builtinModelDefaults = NewModelDefaultsTable("", nil)
@@ -0,0 +1,114 @@
+package nodes
+
+import (
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/config"
+)
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+func TestGetModelConfigUsesModelDefaults(t *testing.T) {
+	table := NewModelDefaultsTable("custom-model", []config.ModelDefaultsConfig{
+		{Model: "custom-model", Provider: "deepseek", Temperature: float64Ptr(0.2), MaxTokens: 512},
+		{Model: "zero-model", Provider: "deepseek", Temperature: float64Ptr(0)},
+		{Model: "gemini-1.5-flash", Provider: "google", MaxTokens: 4096},
+	})
+
+	tests := []struct {
+		name            string
+		state           map[string]interface{}
+		wantProvider    string
+		wantModel       string
+		wantTemperature float64
+		wantMaxTokens   int
+	}{
+		{
+			name:            "omitted model uses configured default",
+			state:           map[string]interface{}{},
+			wantProvider:    "deepseek",
+			wantModel:       "custom-model",
+			wantTemperature: 0.2,
+			wantMaxTokens:   512,
+		},
+		{
+			name:            "configured entry overrides builtin",
+			state:           map[string]interface{}{"provider": "google"},
+			wantProvider:    "google",
+			wantModel:       "gemini-1.5-flash",
+			wantTemperature: builtinDefaultTemperature,
+			wantMaxTokens:   4096,
+		},
+		{
+			name:            "configured zero temperature",
+			state:           map[string]interface{}{"model": "zero-model"},
+			wantProvider:    "deepseek",
+			wantModel:       "zero-model",
+			wantTemperature: 0,
+			wantMaxTokens:   builtinDefaultMaxTokens,
+		},
+		{
+			name:            "unknown model falls back to default provider",
+			state:           map[string]interface{}{"model": "other-model"},
+			wantProvider:    "deepseek",
+			wantModel:       "other-model",
+			wantTemperature: builtinDefaultTemperature,
+			wantMaxTokens:   builtinDefaultMaxTokens,
+		},
+		{
+			name:            "explicit zero temperature wins",
+			state:           map[string]interface{}{"temperature": 0.0},
+			wantProvider:    "deepseek",
+			wantModel:       "custom-model",
+			wantTemperature: 0,
+			wantMaxTokens:   512,
+		},
+		{
+			name:            "explicit values win",
+			state:           map[string]interface{}{"temperature": 1, "max_tokens": 100},
+			wantProvider:    "deepseek",
+			wantModel:       "custom-model",
+			wantTemperature: 1,
+			wantMaxTokens:   100,
+		},
+	}
+
+	node := newTestChatModelNode()
+	node.SetModelDefaults(table)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := node.getModelConfig(tt.state)
+			if err != nil {
+				t.Fatalf("getModelConfig 返回错误: %v", err)
+			}
+			if config.Provider != tt.wantProvider || config.ModelName != tt.wantModel {
+				t.Fatalf("供应商/模型 = %s/%s，期望 %s/%s", config.Provider, config.ModelName, tt.wantProvider, tt.wantModel)
+			}
+			if config.Temperature != tt.wantTemperature {
+				t.Fatalf("Temperature = %v，期望 %v", config.Temperature, tt.wantTemperature)
+			}
+			if config.MaxTokens != tt.wantMaxTokens {
+				t.Fatalf("MaxTokens = %d，期望 %d", config.MaxTokens, tt.wantMaxTokens)
+			}
+		})
+	}
+}
+
+func TestNewModelDefaultsTableKeepsBuiltinEntries(t *testing.T) {
+	builtin := make([]config.ModelDefaultsConfig, len(builtinModelDefaultEntries), cap(builtinModelDefaultEntries))
+	copy(builtin, builtinModelDefaultEntries)
+
+	NewModelDefaultsTable("", []config.ModelDefaultsConfig{{Model: "deepseek-chat", Provider: "other"}})
+
+	full := builtinModelDefaultEntries[:cap(builtinModelDefaultEntries)]
+	for i := range builtin {
+		if full[i] != builtin[i] {
+			t.Fatalf("内置条目 %d 被修改: %+v", i, full[i])
+		}
+	}
+	if got := builtinModelDefaults.Lookup("deepseek-chat").Provider; got != "deepseek" {
+		t.Fatalf("内置默认表供应商 = %s，期望 deepseek", got)
+	}
+}
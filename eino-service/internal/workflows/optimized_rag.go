@@ -0,0 +1,457 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/internal/client"
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/pkg/credential"
+)
+
+// 默认检索的记忆条数
+const defaultMemoryLimit = 5
+
+// OptimizedRAGWorkflow 检索增强生成工作流
+// 依次执行：提示词优化 -> 记忆检索 -> 核心应答 -> 最终整合
+type OptimizedRAGWorkflow struct {
+	credentialManager *credential.Manager
+	memoryClient      *client.MemoryClient
+	chatWorkflow      *EINOStandardChatWorkflow
+	logger            *logrus.Logger
+}
+
+// NewOptimizedRAGWorkflow 创建检索增强生成工作流
+func NewOptimizedRAGWorkflow(credentialManager *credential.Manager, memoryClient *client.MemoryClient, logger *logrus.Logger) *OptimizedRAGWorkflow {
+	return &OptimizedRAGWorkflow{
+		credentialManager: credentialManager,
+		memoryClient:      memoryClient,
+		chatWorkflow:      NewEINOStandardChatWorkflow(credentialManager, 0, 0, logger), // 仅复用模型创建与消息构建
+		logger:            logger,
+	}
+}
+
+// SetHTTPClient 设置调用供应商使用的HTTP客户端
+func (w *OptimizedRAGWorkflow) SetHTTPClient(httpClient *http.Client) {
+	w.chatWorkflow.SetHTTPClient(httpClient)
+}
+
+// ragState RAG工作流在各步骤之间传递的状态
+type ragState struct {
+	query    string
+	memories []*models.Memory
+	steps    []string
+}
+
+// Execute 执行检索增强生成工作流
+func (w *OptimizedRAGWorkflow) Execute(ctx context.Context, req *WorkflowRequest) (*WorkflowResponse, error) {
+	startTime := time.Now()
+
+	w.logger.WithFields(logrus.Fields{
+		"request_id":    req.RequestID,
+		"execution_id":  req.ExecutionID,
+		"tenant_id":     req.TenantID,
+		"user_id":       req.UserID,
+		"workflow_type": "optimized_rag",
+		"operation":     "workflow_start",
+	}).Info("开始执行检索增强生成工作流")
+
+	// 1. 提示词优化与记忆检索
+	state := w.prepare(ctx, req)
+
+	// 2. 核心应答：获取凭证并调用模型
+	credential, err := w.credentialManager.SelectCredential(req.TenantID, w.getProvider(req), "", req.UserID, req.CredentialStrategy)
+	if err != nil {
+		return w.buildErrorResponse(startTime, state, fmt.Sprintf("获取凭证失败: %v", err), err)
+	}
+
+	chatModel, err := w.chatWorkflow.createChatModel(ctx, credential, req)
+	if err != nil {
+		return w.buildErrorResponse(startTime, state, fmt.Sprintf("创建聊天模型失败: %v", err), err)
+	}
+
+	release, err := w.credentialManager.AcquireCall(ctx, credential)
+	if err != nil {
+		return w.buildErrorResponse(startTime, state, fmt.Sprintf("模型调用失败: %v", err), err)
+	}
+	result, err := chatModel.Generate(ctx, w.buildMessages(req, state))
+	release()
+	err = client.AsProviderError(credential.Provider, err)
+	if err == nil {
+		err = checkModelMessage(credential.Provider, result)
+	}
+	if err != nil {
+		w.credentialManager.RecordFailure(ctx, credential.ID.String(), err)
+		return w.buildErrorResponse(startTime, state, fmt.Sprintf("模型调用失败: %v", err), err)
+	}
+	state.steps = append(state.steps, "core_responder")
+
+	w.credentialManager.RecordUsage(credential.ID.String())
+	w.credentialManager.RecordSuccess(credential.ID.String())
+
+	// 3. 最终整合
+	content := w.synthesize(result.Content, state)
+
+	response := &WorkflowResponse{
+		ID:              req.ExecutionID,
+		Success:         true,
+		Content:         content,
+		Model:           w.chatWorkflow.getModelName(credential, req),
+		WorkflowType:    "optimized_rag",
+		Status:          "completed",
+		ExecutionTimeMs: time.Since(startTime).Milliseconds(),
+		Usage: &TokenUsage{
+			PromptTokens:     w.chatWorkflow.getPromptTokens(result),
+			CompletionTokens: w.chatWorkflow.getCompletionTokens(result),
+			TotalTokens:      w.chatWorkflow.getTotalTokens(result),
+		},
+		FinishReason: w.chatWorkflow.getFinishReason(result),
+		Metadata: map[string]interface{}{
+			"provider":        credential.Provider,
+			"credential_id":   credential.ID.String(),
+			"model_used":      w.chatWorkflow.getModelName(credential, req),
+			"workflow_steps":  state.steps,
+			"memories_used":   len(state.memories),
+			"optimized_query": state.query,
+		},
+	}
+	applySeedMetadata(response.Metadata, credential.Provider, resolveSeed(req))
+
+	w.logger.WithFields(logrus.Fields{
+		"request_id":        req.RequestID,
+		"execution_id":      req.ExecutionID,
+		"tenant_id":         req.TenantID,
+		"user_id":           req.UserID,
+		"workflow_type":     "optimized_rag",
+		"operation":         "workflow_success",
+		"provider":          credential.Provider,
+		"memories_used":     len(state.memories),
+		"execution_time_ms": response.ExecutionTimeMs,
+		"total_tokens":      response.Usage.TotalTokens,
+	}).Info("检索增强生成工作流执行成功")
+
+	return response, nil
+}
+
+// ExecuteStream 流式执行检索增强生成工作流
+func (w *OptimizedRAGWorkflow) ExecuteStream(ctx context.Context, req *WorkflowRequest) (<-chan *WorkflowStreamResponse, error) {
+	responseChan := make(chan *WorkflowStreamResponse, 100)
+
+	go func() {
+		defer close(responseChan)
+
+		w.logger.WithFields(logrus.Fields{
+			"execution_id":  req.ExecutionID,
+			"tenant_id":     req.TenantID,
+			"user_id":       req.UserID,
+			"workflow_type": "optimized_rag",
+			"operation":     "workflow_stream_start",
+		}).Info("开始流式执行检索增强生成工作流")
+
+		state := w.prepare(ctx, req)
+
+		credential, err := w.credentialManager.SelectCredential(req.TenantID, w.getProvider(req), "", req.UserID, req.CredentialStrategy)
+		if err != nil {
+			responseChan <- &WorkflowStreamResponse{
+				Type:  StreamEventError,
+				Error: fmt.Sprintf("获取凭证失败: %v", err),
+			}
+			return
+		}
+
+		chatModel, err := w.chatWorkflow.createChatModel(ctx, credential, req)
+		if err != nil {
+			responseChan <- &WorkflowStreamResponse{
+				Type:  StreamEventError,
+				Error: fmt.Sprintf("创建模型失败: %v", err),
+			}
+			return
+		}
+
+		responseChan <- &WorkflowStreamResponse{
+			Type:        StreamEventStart,
+			ExecutionID: req.ExecutionID,
+			Data: map[string]any{
+				"provider":      credential.Provider,
+				"model":         w.chatWorkflow.getModelName(credential, req),
+				"memories_used": len(state.memories),
+			},
+		}
+
+		release, err := w.credentialManager.AcquireCall(ctx, credential)
+		if err != nil {
+			responseChan <- &WorkflowStreamResponse{
+				Type:  StreamEventError,
+				Error: fmt.Sprintf("流式调用失败: %v", err),
+			}
+			return
+		}
+		defer release()
+
+		streamResult, err := chatModel.Stream(ctx, w.buildMessages(req, state))
+		if err != nil {
+			err = client.AsProviderError(credential.Provider, err)
+			w.credentialManager.RecordFailure(ctx, credential.ID.String(), err)
+			responseChan <- &WorkflowStreamResponse{
+				Type:  StreamEventError,
+				Error: fmt.Sprintf("流式调用失败: %v", err),
+			}
+			return
+		}
+		defer streamResult.Close()
+
+		var fullContent string
+		var chunks []*schema.Message
+
+		for {
+			chunk, err := streamResult.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				w.credentialManager.RecordFailure(ctx, credential.ID.String(), err)
+				responseChan <- &WorkflowStreamResponse{
+					Type:  StreamEventError,
+					Error: fmt.Sprintf("接收流式数据失败: %v", err),
+				}
+				return
+			}
+
+			if chunk == nil {
+				continue
+			}
+			chunks = append(chunks, chunk)
+			fullContent += chunk.Content
+
+			responseChan <- &WorkflowStreamResponse{
+				Type:        StreamEventChunk,
+				ExecutionID: req.ExecutionID,
+				Content:     fullContent,
+				Data: map[string]any{
+					"delta": chunk.Content,
+				},
+			}
+		}
+		state.steps = append(state.steps, "core_responder")
+
+		finalMessage, err := schema.ConcatMessages(chunks)
+		if err != nil {
+			responseChan <- &WorkflowStreamResponse{
+				Type:  StreamEventError,
+				Error: fmt.Sprintf("合并消息失败: %v", err),
+			}
+			return
+		}
+
+		responseChan <- &WorkflowStreamResponse{
+			Type:        StreamEventEnd,
+			ExecutionID: req.ExecutionID,
+			Data: map[string]any{
+				"final_content":  w.synthesize(finalMessage.Content, state),
+				"provider":       credential.Provider,
+				"credential_id":  credential.ID.String(),
+				"model":          w.chatWorkflow.getModelName(credential, req),
+				"workflow_steps": state.steps,
+				"finish_reason":  w.chatWorkflow.getFinishReason(finalMessage),
+				"usage": map[string]int{
+					"prompt_tokens":     w.chatWorkflow.getPromptTokens(finalMessage),
+					"completion_tokens": w.chatWorkflow.getCompletionTokens(finalMessage),
+					"total_tokens":      w.chatWorkflow.getTotalTokens(finalMessage),
+				},
+			},
+		}
+
+		w.credentialManager.RecordUsage(credential.ID.String())
+		w.credentialManager.RecordSuccess(credential.ID.String())
+	}()
+
+	return responseChan, nil
+}
+
+// GetInfo 获取工作流信息
+func (w *OptimizedRAGWorkflow) GetInfo() *WorkflowInfo {
+	return &WorkflowInfo{
+		Name:        "optimized_rag",
+		DisplayName: "检索增强对话",
+		Description: "结合用户记忆检索的增强对话工作流，先检索相关记忆再由模型生成回答",
+		Version:     "1.0.0",
+		Type:        "rag",
+		Parameters: []WorkflowParameter{
+			{
+				Name:        "message",
+				Type:        "string",
+				Required:    true,
+				Description: "用户输入的消息",
+			},
+			{
+				Name:        "provider",
+				Type:        "string",
+				Required:    false,
+				Description: "AI供应商（openai、deepseek、ark等）",
+				Default:     "openai",
+			},
+			{
+				Name:        "memory_limit",
+				Type:        "integer",
+				Required:    false,
+				Description: "检索的记忆条数",
+				Default:     defaultMemoryLimit,
+			},
+		},
+		SupportedFeatures: []string{
+			"memory_retrieval",
+			"streaming",
+			"multi_provider",
+		},
+		Nodes: []WorkflowNodeInfo{
+			{
+				Name:        "prompt_optimizer",
+				Type:        "transform",
+				Description: "规范化用户输入，生成检索查询",
+				Required:    true,
+			},
+			{
+				Name:        "memory_retrieval",
+				Type:        "retriever",
+				Description: "从记忆服务检索相关上下文",
+				Required:    false,
+			},
+			{
+				Name:        "core_responder",
+				Type:        "chat_model",
+				Description: "基于凭证选择的模型生成回答",
+				Required:    true,
+			},
+			{
+				Name:        "final_synthesizer",
+				Type:        "transform",
+				Description: "整合模型输出形成最终回答",
+				Required:    true,
+			},
+		},
+		RequiredInputs: []string{"message", "tenant_id", "user_id", "request_id", "execution_id"},
+		OutputSchema: map[string]interface{}{
+			"success":           "boolean",
+			"content":           "string",
+			"model":             "string",
+			"workflow_type":     "string",
+			"execution_time_ms": "integer",
+			"usage": map[string]interface{}{
+				"prompt_tokens":     "integer",
+				"completion_tokens": "integer",
+				"total_tokens":      "integer",
+			},
+			"metadata": "object",
+		},
+	}
+}
+
+// prepare 执行提示词优化和记忆检索步骤
+func (w *OptimizedRAGWorkflow) prepare(ctx context.Context, req *WorkflowRequest) *ragState {
+	state := &ragState{
+		query: w.optimizePrompt(req.Message),
+		steps: []string{"prompt_optimizer"},
+	}
+
+	memories, err := w.memoryClient.SearchMemories(ctx, req.TenantID, req.UserID, req.RequestID, &models.MemorySearchRequest{
+		Query:           state.query,
+		Limit:           w.getMemoryLimit(req),
+		IncludeMetadata: true,
+	})
+	if err != nil {
+		// 记忆检索失败不影响主流程，降级为无上下文回答
+		w.logger.WithFields(logrus.Fields{
+			"request_id":    req.RequestID,
+			"execution_id":  req.ExecutionID,
+			"tenant_id":     req.TenantID,
+			"user_id":       req.UserID,
+			"workflow_type": "optimized_rag",
+			"operation":     "memory_retrieval_failed",
+			"error":         err.Error(),
+		}).Warn("记忆检索失败，继续执行")
+		return state
+	}
+
+	state.memories = memories
+	state.steps = append(state.steps, "memory_retrieval")
+	return state
+}
+
+// optimizePrompt 规范化用户输入，去除多余空白
+func (w *OptimizedRAGWorkflow) optimizePrompt(message string) string {
+	return strings.Join(strings.Fields(message), " ")
+}
+
+// buildMessages 构建包含检索上下文的消息序列
+func (w *OptimizedRAGWorkflow) buildMessages(req *WorkflowRequest, state *ragState) []*schema.Message {
+	var messages []*schema.Message
+
+	if systemPrompt, ok := req.Configuration["system_prompt"].(string); ok && systemPrompt != "" {
+		messages = append(messages, schema.SystemMessage(systemPrompt))
+	}
+
+	if len(state.memories) > 0 {
+		var builder strings.Builder
+		builder.WriteString("以下是与用户相关的已知信息，请在回答时参考：\n")
+		for i, memory := range state.memories {
+			builder.WriteString(fmt.Sprintf("%d. %s\n", i+1, memory.Content))
+		}
+		messages = append(messages, schema.SystemMessage(builder.String()))
+	}
+
+	messages = append(messages, schema.UserMessage(state.query))
+	return messages
+}
+
+// synthesize 整合模型输出形成最终回答
+func (w *OptimizedRAGWorkflow) synthesize(content string, state *ragState) string {
+	state.steps = append(state.steps, "final_synthesizer")
+	return strings.TrimSpace(content)
+}
+
+// getProvider 获取请求指定的供应商
+func (w *OptimizedRAGWorkflow) getProvider(req *WorkflowRequest) string {
+	if req.ModelConfig != nil {
+		if provider, ok := req.ModelConfig["provider"].(string); ok && provider != "" {
+			return provider
+		}
+	}
+	return "openai"
+}
+
+// getMemoryLimit 获取记忆检索条数
+func (w *OptimizedRAGWorkflow) getMemoryLimit(req *WorkflowRequest) int {
+	switch limit := req.Configuration["memory_limit"].(type) {
+	case int:
+		if limit > 0 {
+			return limit
+		}
+	case float64:
+		if limit > 0 {
+			return int(limit)
+		}
+	}
+	return defaultMemoryLimit
+}
+
+// buildErrorResponse 构建错误响应
+func (w *OptimizedRAGWorkflow) buildErrorResponse(startTime time.Time, state *ragState, message string, err error) (*WorkflowResponse, error) {
+	w.logger.WithError(err).Error(message)
+
+	return &WorkflowResponse{
+		Success:         false,
+		ErrorMessage:    message,
+		ExecutionTimeMs: time.Since(startTime).Milliseconds(),
+		WorkflowType:    "optimized_rag",
+		Metadata: map[string]interface{}{
+			"workflow_steps": state.steps,
+		},
+	}, err
+}
@@ -0,0 +1,176 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"lyss-ai-platform/eino-service/internal/client"
+	"lyss-ai-platform/eino-service/internal/config"
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/internal/testutil/credentialtest"
+)
+
+// memoryServiceStub 记忆服务替身，记录收到的检索请求
+type memoryServiceStub struct {
+	mutex    sync.Mutex
+	status   int
+	memories []*models.Memory
+	queries  []string
+	tenants  []string
+}
+
+func (s *memoryServiceStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var searchRequest models.MemorySearchRequest
+	json.NewDecoder(r.Body).Decode(&searchRequest)
+
+	s.mutex.Lock()
+	s.queries = append(s.queries, searchRequest.Query)
+	s.tenants = append(s.tenants, r.Header.Get("X-Tenant-ID"))
+	s.mutex.Unlock()
+
+	if r.URL.Path != "/api/v1/memory/search" {
+		http.NotFound(w, r)
+		return
+	}
+	if s.status != http.StatusOK {
+		w.WriteHeader(s.status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.ApiResponse[models.MemorySearchResult]{
+		Success: true,
+		Data:    models.MemorySearchResult{Memories: s.memories, TotalFound: len(s.memories)},
+	})
+}
+
+// chatMessage OpenAI兼容请求中的消息
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// newOpenAIStub 启动OpenAI兼容的供应商替身，返回固定回答并记录请求消息
+func newOpenAIStub(t *testing.T, answer string, received *[]chatMessage) *httptest.Server {
+	t.Helper()
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []chatMessage `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mutex.Lock()
+		*received = body.Messages
+		mutex.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":     "chatcmpl-1",
+			"object": "chat.completion",
+			"model":  "gpt-4o-mini",
+			"choices": []map[string]interface{}{{
+				"index":         0,
+				"message":       map[string]string{"role": "assistant", "content": answer},
+				"finish_reason": "stop",
+			}},
+			"usage": map[string]int{"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOptimizedRAGWorkflowExecute(t *testing.T) {
+	tests := []struct {
+		name         string
+		memoryStatus int
+		memories     []*models.Memory
+		wantSteps    []string
+		wantContext  bool
+	}{
+		{
+			name:         "memories injected as system message",
+			memoryStatus: http.StatusOK,
+			memories: []*models.Memory{
+				{ID: "m1", Content: "用户喜欢简洁的回答"},
+				{ID: "m2", Content: "用户在上海工作"},
+			},
+			wantSteps:   []string{"prompt_optimizer", "memory_retrieval", "core_responder", "final_synthesizer"},
+			wantContext: true,
+		},
+		{
+			name:         "memory service failure degrades without context",
+			memoryStatus: http.StatusInternalServerError,
+			wantSteps:    []string{"prompt_optimizer", "core_responder", "final_synthesizer"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memoryService := &memoryServiceStub{status: tt.memoryStatus, memories: tt.memories}
+			memoryServer := httptest.NewServer(memoryService)
+			t.Cleanup(memoryServer.Close)
+
+			var received []chatMessage
+			provider := newOpenAIStub(t, "  你好，上海的朋友  ", &received)
+
+			tenantService := testutil.NewTenantService(t)
+			tenantService.SetCredentials("tenant-1", testutil.Credential("openai", provider.URL))
+
+			credentialManager := credentialtest.NewManager(t, tenantService)
+
+			memoryClient := client.NewMemoryClient(&config.MemoryServiceConfig{BaseURL: memoryServer.URL, Timeout: 5 * time.Second}, http.DefaultTransport, testutil.Logger())
+			workflow := NewOptimizedRAGWorkflow(credentialManager, memoryClient, testutil.Logger())
+
+			resp, err := workflow.Execute(context.Background(), &WorkflowRequest{
+				RequestID:   "req-1",
+				ExecutionID: "exec-1",
+				TenantID:    "tenant-1",
+				UserID:      "user-1",
+				Message:     "  你好，\n 我在哪里工作？ ",
+				ModelConfig: map[string]interface{}{"provider": "openai"},
+			})
+			if err != nil {
+				t.Fatalf("Execute 返回错误: %v", err)
+			}
+			if !resp.Success || resp.Content != "你好，上海的朋友" {
+				t.Fatalf("响应 = %+v，期望整合后的回答", resp)
+			}
+			if steps := resp.Metadata["workflow_steps"]; !reflect.DeepEqual(steps, tt.wantSteps) {
+				t.Fatalf("workflow_steps = %v，期望 %v", steps, tt.wantSteps)
+			}
+			if got := resp.Metadata["memories_used"]; got != len(tt.memories) {
+				t.Fatalf("memories_used = %v，期望 %d", got, len(tt.memories))
+			}
+
+			// 记忆检索使用优化后的查询并携带租户信息
+			if len(memoryService.queries) != 1 || memoryService.queries[0] != "你好， 我在哪里工作？" {
+				t.Fatalf("记忆检索查询 = %q", memoryService.queries)
+			}
+			if memoryService.tenants[0] != "tenant-1" {
+				t.Fatalf("X-Tenant-ID = %q，期望 tenant-1", memoryService.tenants[0])
+			}
+
+			// 检索到的记忆在模型调用前作为系统消息注入
+			if len(received) == 0 || received[len(received)-1].Role != "user" {
+				t.Fatalf("模型请求消息 = %+v", received)
+			}
+			hasContext := false
+			for _, message := range received {
+				if message.Role == "system" && strings.Contains(message.Content, "用户在上海工作") {
+					hasContext = true
+				}
+			}
+			if hasContext != tt.wantContext {
+				t.Fatalf("系统消息包含记忆 = %v，期望 %v，消息 %+v", hasContext, tt.wantContext, received)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package workflows
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 生成参数越界时的处理策略
+const (
+	ParameterPolicyReject = "reject" // 返回400并列出越界参数
+	ParameterPolicyClamp  = "clamp"  // 将越界参数修正到允许范围内继续执行
+)
+
+// parameterRange 生成参数的取值范围
+type parameterRange struct {
+	min, max float64
+}
+
+// modelParameterRanges ModelConfig 中有固定取值范围的生成参数
+var modelParameterRanges = map[string]parameterRange{
+	"temperature":       {0, 2},
+	"top_p":             {0, 1},
+	"frequency_penalty": {-2, 2},
+	"presence_penalty":  {-2, 2},
+}
+
+// ParameterLimits 请求生成参数的取值限制
+type ParameterLimits struct {
+	// Policy 越界时的处理策略，未识别的取值按 reject 处理
+	Policy string
+	// MaxOutputTokens max_tokens 的默认上限，0表示不限制
+	MaxOutputTokens int
+	// ModelMaxOutputTokens 按模型覆盖的 max_tokens 上限
+	ModelMaxOutputTokens map[string]int
+}
+
+// Apply 校验请求的生成参数（顶层 temperature、max_tokens 与 ModelConfig 中的同名参数）
+// reject 策略下返回列出全部越界参数的 InvalidParametersError；clamp 策略下就地修正并返回被修正的参数
+func (l *ParameterLimits) Apply(req *WorkflowRequest) (map[string]string, error) {
+	invalid := make(map[string]string)
+	clamped := make(map[string]string)
+	clamp := l.Policy == ParameterPolicyClamp
+
+	// check 检查单个参数，越界时按策略记录或修正，返回修正后的值
+	check := func(name string, value, min, max float64, expected string) float64 {
+		if value >= min && value <= max {
+			return value
+		}
+		if !clamp {
+			invalid[name] = expected
+			return value
+		}
+		fixed := value
+		if fixed < min {
+			fixed = min
+		}
+		if fixed > max {
+			fixed = max
+		}
+		clamped[name] = fmt.Sprintf("%v -> %v", value, fixed)
+		return fixed
+	}
+
+	if req.Temperature != nil {
+		temperature := check("temperature", *req.Temperature, 0, 2, "0 到 2 之间的数值")
+		req.Temperature = &temperature
+	}
+
+	maxOutput := l.maxOutputTokens(requestModel(req))
+	upper := float64(math.MaxInt32)
+	expectedTokens := "正整数"
+	if maxOutput > 0 {
+		upper = float64(maxOutput)
+		expectedTokens = fmt.Sprintf("1 到 %d 之间的整数", maxOutput)
+	}
+	if req.MaxTokens != 0 {
+		req.MaxTokens = int(check("max_tokens", float64(req.MaxTokens), 1, upper, expectedTokens))
+	}
+
+	for name, value := range req.ModelConfig {
+		if name == "max_tokens" {
+			tokens, ok := toFloat64(value)
+			if !ok {
+				invalid[name] = expectedTokens
+				continue
+			}
+			if fixed := check(name, tokens, 1, upper, expectedTokens); fixed != tokens {
+				req.ModelConfig[name] = int(fixed)
+			}
+			continue
+		}
+
+		limits, ok := modelParameterRanges[name]
+		if !ok {
+			continue
+		}
+		expected := fmt.Sprintf("%v 到 %v 之间的数值", limits.min, limits.max)
+		number, ok := toFloat64(value)
+		if !ok {
+			invalid[name] = expected
+			continue
+		}
+		if fixed := check(name, number, limits.min, limits.max, expected); fixed != number {
+			req.ModelConfig[name] = fixed
+		}
+	}
+
+	if len(invalid) > 0 {
+		return nil, &InvalidParametersError{
+			WorkflowType: req.WorkflowType,
+			Invalid:      invalid,
+		}
+	}
+	return clamped, nil
+}
+
+// maxOutputTokens 获取模型的 max_tokens 上限，模型单独配置时优先
+func (l *ParameterLimits) maxOutputTokens(model string) int {
+	if limit, ok := l.ModelMaxOutputTokens[model]; ok && limit > 0 {
+		return limit
+	}
+	return l.MaxOutputTokens
+}
+
+// logClampedParameters 记录 clamp 策略下被修正的参数
+func logClampedParameters(logger *logrus.Logger, req *WorkflowRequest, clamped map[string]string) {
+	if len(clamped) == 0 {
+		return
+	}
+	logger.WithFields(logrus.Fields{
+		"request_id":    req.RequestID,
+		"tenant_id":     req.TenantID,
+		"workflow_type": req.WorkflowType,
+		"clamped":       clamped,
+		"operation":     "parameters_clamped",
+	}).Warn("请求生成参数超出允许范围，已修正")
+}
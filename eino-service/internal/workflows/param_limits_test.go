@@ -0,0 +1,118 @@
+package workflows
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParameterLimitsBoundaries(t *testing.T) {
+	limits := &ParameterLimits{
+		MaxOutputTokens:      4096,
+		ModelMaxOutputTokens: map[string]int{"deepseek-reasoner": 8192},
+	}
+
+	tests := []struct {
+		name        string
+		req         *WorkflowRequest
+		wantInvalid string
+	}{
+		{name: "temperature 下限", req: &WorkflowRequest{Temperature: float64Ptr(0)}},
+		{name: "temperature 上限", req: &WorkflowRequest{Temperature: float64Ptr(2)}},
+		{name: "temperature 低于下限", req: &WorkflowRequest{Temperature: float64Ptr(-0.1)}, wantInvalid: "temperature"},
+		{name: "temperature 超过上限", req: &WorkflowRequest{Temperature: float64Ptr(2.1)}, wantInvalid: "temperature"},
+		{name: "model_config.temperature 超过上限", req: &WorkflowRequest{ModelConfig: map[string]interface{}{"temperature": 2.01}}, wantInvalid: "temperature"},
+		{name: "top_p 下限", req: &WorkflowRequest{ModelConfig: map[string]interface{}{"top_p": 0}}},
+		{name: "top_p 上限", req: &WorkflowRequest{ModelConfig: map[string]interface{}{"top_p": 1}}},
+		{name: "top_p 低于下限", req: &WorkflowRequest{ModelConfig: map[string]interface{}{"top_p": -0.01}}, wantInvalid: "top_p"},
+		{name: "top_p 超过上限", req: &WorkflowRequest{ModelConfig: map[string]interface{}{"top_p": 1.01}}, wantInvalid: "top_p"},
+		{name: "frequency_penalty 下限", req: &WorkflowRequest{ModelConfig: map[string]interface{}{"frequency_penalty": -2}}},
+		{name: "frequency_penalty 超过上限", req: &WorkflowRequest{ModelConfig: map[string]interface{}{"frequency_penalty": 2.5}}, wantInvalid: "frequency_penalty"},
+		{name: "presence_penalty 上限", req: &WorkflowRequest{ModelConfig: map[string]interface{}{"presence_penalty": 2}}},
+		{name: "presence_penalty 低于下限", req: &WorkflowRequest{ModelConfig: map[string]interface{}{"presence_penalty": -2.5}}, wantInvalid: "presence_penalty"},
+		{name: "top_p 非数值", req: &WorkflowRequest{ModelConfig: map[string]interface{}{"top_p": "high"}}, wantInvalid: "top_p"},
+		{name: "max_tokens 下限", req: &WorkflowRequest{MaxTokens: 1}},
+		{name: "max_tokens 默认上限", req: &WorkflowRequest{MaxTokens: 4096}},
+		{name: "max_tokens 超过默认上限", req: &WorkflowRequest{MaxTokens: 4097}, wantInvalid: "max_tokens"},
+		{name: "max_tokens 为负数", req: &WorkflowRequest{MaxTokens: -1}, wantInvalid: "max_tokens"},
+		{name: "max_tokens 模型上限", req: &WorkflowRequest{Model: "deepseek-reasoner", MaxTokens: 8192}},
+		{name: "max_tokens 超过模型上限", req: &WorkflowRequest{ModelConfig: map[string]interface{}{"model": "deepseek-reasoner", "max_tokens": 8193}}, wantInvalid: "max_tokens"},
+		{name: "model_config.max_tokens 为0", req: &WorkflowRequest{ModelConfig: map[string]interface{}{"max_tokens": 0}}, wantInvalid: "max_tokens"},
+		{name: "未识别参数不校验", req: &WorkflowRequest{ModelConfig: map[string]interface{}{"seed": -5}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clamped, err := limits.Apply(tt.req)
+			if tt.wantInvalid == "" {
+				if err != nil || len(clamped) != 0 {
+					t.Fatalf("Apply = %v, %v，期望通过", clamped, err)
+				}
+				return
+			}
+			var invalid *InvalidParametersError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("错误 = %v，期望 InvalidParametersError", err)
+			}
+			if _, ok := invalid.Invalid[tt.wantInvalid]; !ok || len(invalid.Invalid) != 1 {
+				t.Fatalf("越界参数 = %v，期望只有 %s", invalid.Invalid, tt.wantInvalid)
+			}
+		})
+	}
+}
+
+func TestParameterLimitsListsAllInvalid(t *testing.T) {
+	limits := &ParameterLimits{Policy: ParameterPolicyReject, MaxOutputTokens: 4096}
+	_, err := limits.Apply(&WorkflowRequest{
+		Temperature: float64Ptr(3),
+		MaxTokens:   100000,
+		ModelConfig: map[string]interface{}{"top_p": 2.0},
+	})
+
+	var invalid *InvalidParametersError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("错误 = %v，期望 InvalidParametersError", err)
+	}
+	for _, name := range []string{"temperature", "max_tokens", "top_p"} {
+		if invalid.Invalid[name] == "" {
+			t.Fatalf("越界参数 = %v，期望包含 %s 及允许范围", invalid.Invalid, name)
+		}
+	}
+}
+
+func TestParameterLimitsClamp(t *testing.T) {
+	limits := &ParameterLimits{Policy: ParameterPolicyClamp, MaxOutputTokens: 4096}
+
+	tests := []struct {
+		name  string
+		req   *WorkflowRequest
+		check func(req *WorkflowRequest) bool
+	}{
+		{name: "temperature 修正到上限", req: &WorkflowRequest{Temperature: float64Ptr(5)}, check: func(req *WorkflowRequest) bool { return *req.Temperature == 2 }},
+		{name: "temperature 修正到下限", req: &WorkflowRequest{Temperature: float64Ptr(-1)}, check: func(req *WorkflowRequest) bool { return *req.Temperature == 0 }},
+		{name: "max_tokens 修正到上限", req: &WorkflowRequest{MaxTokens: 100000}, check: func(req *WorkflowRequest) bool { return req.MaxTokens == 4096 }},
+		{name: "max_tokens 修正到下限", req: &WorkflowRequest{MaxTokens: -3}, check: func(req *WorkflowRequest) bool { return req.MaxTokens == 1 }},
+		{name: "top_p 修正到上限", req: &WorkflowRequest{ModelConfig: map[string]interface{}{"top_p": 1.5}}, check: func(req *WorkflowRequest) bool { return req.ModelConfig["top_p"] == 1.0 }},
+		{name: "model_config.max_tokens 修正为整数", req: &WorkflowRequest{ModelConfig: map[string]interface{}{"max_tokens": 5000.0}}, check: func(req *WorkflowRequest) bool { return req.ModelConfig["max_tokens"] == 4096 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clamped, err := limits.Apply(tt.req)
+			if err != nil {
+				t.Fatalf("clamp 策略不应返回错误: %v", err)
+			}
+			if len(clamped) != 1 {
+				t.Fatalf("修正记录 = %v，期望一项", clamped)
+			}
+			if !tt.check(tt.req) {
+				t.Fatalf("修正后请求 = %+v，temperature = %v", tt.req, tt.req.Temperature)
+			}
+		})
+	}
+
+	t.Run("非数值仍然拒绝", func(t *testing.T) {
+		if _, err := limits.Apply(&WorkflowRequest{ModelConfig: map[string]interface{}{"top_p": "high"}}); err == nil {
+			t.Fatal("无法修正的参数应返回错误")
+		}
+	})
+}
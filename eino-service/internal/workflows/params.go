@@ -0,0 +1,104 @@
+package workflows
+
+import (
+	"fmt"
+
+	"lyss-ai-platform/eino-service/internal/workflows/nodes"
+)
+
+// generationParams 模型生成参数
+// 仅在请求显式提供时设置对应字段，未设置的字段使用供应商默认值
+type generationParams struct {
+	Temperature *float32
+	MaxTokens   *int
+	TopP        *float32
+	Stop        []string
+	Seed        *int
+}
+
+// seedSupportedProviders 支持 seed 参数的供应商
+// EINO 的 DeepSeek 与方舟模型配置未暴露 seed，请求中的 seed 会被忽略并在元数据中说明
+var seedSupportedProviders = map[string]bool{
+	"openai": true,
+}
+
+// resolveGenerationParams 从工作流请求中解析生成参数
+// ModelConfig 中的显式配置优先于请求顶层字段
+func resolveGenerationParams(req *WorkflowRequest) *generationParams {
+	params := &generationParams{}
+
+	if req.Temperature != nil {
+		temperature := float32(*req.Temperature)
+		params.Temperature = &temperature
+	}
+	if req.MaxTokens > 0 {
+		maxTokens := req.MaxTokens
+		params.MaxTokens = &maxTokens
+	}
+	params.Seed = resolveSeed(req)
+
+	if req.ModelConfig == nil {
+		return params
+	}
+
+	if value, ok := toFloat64(req.ModelConfig["temperature"]); ok {
+		temperature := float32(value)
+		params.Temperature = &temperature
+	}
+	if value, ok := toFloat64(req.ModelConfig["max_tokens"]); ok && value > 0 {
+		maxTokens := int(value)
+		params.MaxTokens = &maxTokens
+	}
+	if value, ok := toFloat64(req.ModelConfig["top_p"]); ok {
+		topP := float32(value)
+		params.TopP = &topP
+	}
+	params.Stop = nodes.ToStringSlice(req.ModelConfig["stop"])
+
+	return params
+}
+
+// resolveSeed 解析请求的随机种子，请求顶层字段优先于 ModelConfig 中的 seed
+func resolveSeed(req *WorkflowRequest) *int {
+	if req.Seed != nil {
+		seed := *req.Seed
+		return &seed
+	}
+	if req.ModelConfig != nil {
+		if value, ok := toFloat64(req.ModelConfig["seed"]); ok {
+			seed := int(value)
+			return &seed
+		}
+	}
+	return nil
+}
+
+// applySeedMetadata 在响应元数据中记录生效的随机种子
+// 供应商不支持 seed 时记录为已忽略，而不是返回错误
+func applySeedMetadata(metadata map[string]interface{}, provider string, seed *int) {
+	if seed == nil {
+		return
+	}
+	if !seedSupportedProviders[provider] {
+		metadata["seed_ignored"] = true
+		metadata["seed_note"] = fmt.Sprintf("供应商 %s 不支持 seed 参数，已忽略", provider)
+		return
+	}
+	metadata["seed"] = *seed
+}
+
+// toFloat64 将JSON解码或代码传入的数值统一转换为float64
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
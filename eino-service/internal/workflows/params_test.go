@@ -0,0 +1,262 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/client"
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+func float64Ptr(v float64) *float64 { return &v }
+
+func intPtr(v int) *int { return &v }
+
+func TestResolveGenerationParamsTemperature(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         *WorkflowRequest
+		temperature *float32
+	}{
+		{
+			name: "未设置时使用供应商默认值",
+			req:  &WorkflowRequest{},
+		},
+		{
+			name:        "顶层显式0",
+			req:         &WorkflowRequest{Temperature: float64Ptr(0)},
+			temperature: new(float32),
+		},
+		{
+			name:        "model_config 显式0",
+			req:         &WorkflowRequest{ModelConfig: map[string]interface{}{"temperature": float64(0)}},
+			temperature: new(float32),
+		},
+		{
+			name: "model_config 优先于顶层字段",
+			req: &WorkflowRequest{
+				Temperature: float64Ptr(0.3),
+				ModelConfig: map[string]interface{}{"temperature": float64(0)},
+			},
+			temperature: new(float32),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := resolveGenerationParams(tt.req)
+			switch {
+			case tt.temperature == nil && params.Temperature != nil:
+				t.Fatalf("Temperature = %v，期望未设置", *params.Temperature)
+			case tt.temperature != nil && params.Temperature == nil:
+				t.Fatalf("Temperature 未设置，期望 %v", *tt.temperature)
+			case tt.temperature != nil && *params.Temperature != *tt.temperature:
+				t.Fatalf("Temperature = %v，期望 %v", *params.Temperature, *tt.temperature)
+			}
+		})
+	}
+}
+
+func TestExplicitZeroTemperatureReachesModelConfig(t *testing.T) {
+	w := &EINOStandardChatWorkflow{}
+	credential := &models.SupplierCredential{Provider: "openai", APIKey: "sk-test"}
+	params := resolveGenerationParams(&WorkflowRequest{Temperature: float64Ptr(0), Seed: intPtr(42)})
+
+	openAIConfig := w.buildOpenAIConfig(credential, "gpt-4o-mini", params)
+	if openAIConfig.Temperature == nil || *openAIConfig.Temperature != 0 {
+		t.Fatalf("OpenAI Temperature = %v，期望显式的0", openAIConfig.Temperature)
+	}
+	if openAIConfig.Seed == nil || *openAIConfig.Seed != 42 {
+		t.Fatalf("OpenAI Seed = %v，期望 42", openAIConfig.Seed)
+	}
+
+	arkConfig := w.buildArkConfig(credential, "doubao", params)
+	if arkConfig.Temperature == nil || *arkConfig.Temperature != 0 {
+		t.Fatalf("方舟 Temperature = %v，期望显式的0", arkConfig.Temperature)
+	}
+}
+
+// builtConfig 各供应商模型配置中与生成参数相关的字段，未设置的指针字段为nil
+type builtConfig struct {
+	temperature *float32
+	maxTokens   *int
+	topP        *float32
+	stop        []string
+}
+
+func float32Ptr(v float32) *float32 { return &v }
+
+// optionalFloat32 将零值视为未设置，用于非指针字段的供应商配置
+func optionalFloat32(v float32) *float32 {
+	if v == 0 {
+		return nil
+	}
+	return &v
+}
+
+// optionalInt 将零值视为未设置，用于非指针字段的供应商配置
+func optionalInt(v int) *int {
+	if v == 0 {
+		return nil
+	}
+	return &v
+}
+
+func TestProviderConfigsReflectRequest(t *testing.T) {
+	w := &EINOStandardChatWorkflow{}
+	credential := &models.SupplierCredential{APIKey: "sk-test", BaseURL: "http://localhost"}
+	geminiClient, err := client.NewGeminiClient(context.Background(), "test-key", "", nil, testutil.Logger())
+	if err != nil {
+		t.Fatalf("创建Gemini客户端失败: %v", err)
+	}
+
+	build := map[string]func(params *generationParams) builtConfig{
+		"openai": func(params *generationParams) builtConfig {
+			config := w.buildOpenAIConfig(credential, "gpt-4o-mini", params)
+			return builtConfig{config.Temperature, config.MaxTokens, config.TopP, config.Stop}
+		},
+		"deepseek": func(params *generationParams) builtConfig {
+			config := w.buildDeepSeekConfig(credential, "deepseek-chat", params)
+			return builtConfig{optionalFloat32(config.Temperature), optionalInt(config.MaxTokens), optionalFloat32(config.TopP), config.Stop}
+		},
+		"ark": func(params *generationParams) builtConfig {
+			config := w.buildArkConfig(credential, "doubao", params)
+			return builtConfig{config.Temperature, config.MaxTokens, config.TopP, config.Stop}
+		},
+		"google": func(params *generationParams) builtConfig {
+			config := w.buildGeminiConfig(geminiClient, "gemini-1.5-flash", params)
+			return builtConfig{config.Temperature, config.MaxTokens, config.TopP, nil}
+		},
+	}
+
+	tests := []struct {
+		name string
+		req  *WorkflowRequest
+		want builtConfig
+	}{
+		{
+			name: "未设置的参数使用供应商默认值",
+			req:  &WorkflowRequest{},
+		},
+		{
+			name: "顶层字段",
+			req:  &WorkflowRequest{Temperature: float64Ptr(0.5), MaxTokens: 256},
+			want: builtConfig{temperature: float32Ptr(0.5), maxTokens: intPtr(256)},
+		},
+		{
+			name: "model_config 中的全部参数",
+			req: &WorkflowRequest{ModelConfig: map[string]interface{}{
+				"temperature": 0.25,
+				"max_tokens":  float64(512),
+				"top_p":       0.9,
+				"stop":        []interface{}{"END"},
+			}},
+			want: builtConfig{temperature: float32Ptr(0.25), maxTokens: intPtr(512), topP: float32Ptr(0.9), stop: []string{"END"}},
+		},
+	}
+
+	for _, tt := range tests {
+		for provider, buildConfig := range build {
+			t.Run(tt.name+"/"+provider, func(t *testing.T) {
+				want := tt.want
+				if provider == "google" {
+					want.stop = nil // Gemini 配置不支持 stop
+				}
+				got := buildConfig(resolveGenerationParams(tt.req))
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("配置 = %s，期望 %s", formatBuiltConfig(got), formatBuiltConfig(want))
+				}
+			})
+		}
+	}
+}
+
+// formatBuiltConfig 输出配置字段的值，便于比较失败时定位
+func formatBuiltConfig(c builtConfig) string {
+	format := func(v interface{}) interface{} {
+		switch p := v.(type) {
+		case *float32:
+			if p != nil {
+				return *p
+			}
+		case *int:
+			if p != nil {
+				return *p
+			}
+		default:
+			return v
+		}
+		return nil
+	}
+	return fmt.Sprintf("{temperature:%v max_tokens:%v top_p:%v stop:%v}", format(c.temperature), format(c.maxTokens), format(c.topP), c.stop)
+}
+
+func TestResolveSeed(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *WorkflowRequest
+		want *int
+	}{
+		{name: "未设置", req: &WorkflowRequest{}},
+		{name: "顶层字段", req: &WorkflowRequest{Seed: intPtr(7)}, want: intPtr(7)},
+		{name: "model_config", req: &WorkflowRequest{ModelConfig: map[string]interface{}{"seed": float64(9)}}, want: intPtr(9)},
+		{
+			name: "顶层字段优先",
+			req:  &WorkflowRequest{Seed: intPtr(7), ModelConfig: map[string]interface{}{"seed": float64(9)}},
+			want: intPtr(7),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolveSeed(tt.req)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Fatalf("resolveSeed = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplySeedMetadata(t *testing.T) {
+	tests := []struct {
+		provider    string
+		wantSeed    bool
+		wantIgnored bool
+	}{
+		{provider: "openai", wantSeed: true},
+		{provider: "deepseek", wantIgnored: true},
+		{provider: "ark", wantIgnored: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			metadata := make(map[string]interface{})
+			applySeedMetadata(metadata, tt.provider, intPtr(42))
+			if _, ok := metadata["seed"]; ok != tt.wantSeed {
+				t.Fatalf("metadata.seed 存在 = %v，期望 %v", ok, tt.wantSeed)
+			}
+			if _, ok := metadata["seed_ignored"]; ok != tt.wantIgnored {
+				t.Fatalf("metadata.seed_ignored 存在 = %v，期望 %v", ok, tt.wantIgnored)
+			}
+		})
+	}
+}
+
+func TestParameterLimitsKeepsExplicitZeroTemperature(t *testing.T) {
+	limits := &ParameterLimits{Policy: ParameterPolicyReject}
+
+	req := &WorkflowRequest{Temperature: float64Ptr(0)}
+	if _, err := limits.Apply(req); err != nil {
+		t.Fatalf("temperature=0 不应越界: %v", err)
+	}
+	if req.Temperature == nil || *req.Temperature != 0 {
+		t.Fatalf("Temperature = %v，期望保留显式的0", req.Temperature)
+	}
+
+	if _, err := limits.Apply(&WorkflowRequest{Temperature: float64Ptr(2.5)}); err == nil {
+		t.Fatal("temperature=2.5 应被拒绝")
+	}
+}
@@ -0,0 +1,89 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/schema"
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/pkg/prompts"
+)
+
+// SetPromptStore 设置提示词预设存储，请求 configuration.prompt_id 引用的预设渲染后作为系统提示词
+func (wm *WorkflowManager) SetPromptStore(store *prompts.Store) {
+	wm.promptStore = store
+}
+
+// applyPromptPreset 解析请求引用的提示词预设
+// 预设模板按 Jinja2 渲染，变量来自 Configuration 与 message，渲染结果覆盖 system_prompt
+func (wm *WorkflowManager) applyPromptPreset(ctx context.Context, req *WorkflowRequest) error {
+	promptID, _ := req.Configuration["prompt_id"].(string)
+	if promptID == "" {
+		return nil
+	}
+
+	if wm.promptStore == nil {
+		return &InvalidParametersError{
+			WorkflowType: req.WorkflowType,
+			Invalid:      map[string]string{"prompt_id": "已启用的提示词预设ID"},
+		}
+	}
+
+	preset, err := wm.promptStore.Get(ctx, req.TenantID, promptID)
+	if errors.Is(err, prompts.ErrPromptNotFound) {
+		return &InvalidParametersError{
+			WorkflowType: req.WorkflowType,
+			Invalid:      map[string]string{"prompt_id": "当前租户的提示词预设ID"},
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("加载提示词预设失败: %w", err)
+	}
+
+	if err := prompts.CheckTemplateTags(preset.Template); err != nil {
+		return &InvalidParametersError{
+			WorkflowType: req.WorkflowType,
+			Invalid:      map[string]string{"prompt_id": "模板可正常渲染的提示词预设ID"},
+		}
+	}
+	if missing := missingTemplateVariables(preset.Template, req.Configuration); len(missing) > 0 {
+		return &InvalidParametersError{
+			WorkflowType: req.WorkflowType,
+			Missing:      missing,
+		}
+	}
+
+	variables := make(map[string]any, len(req.Configuration)+1)
+	for key, value := range req.Configuration {
+		variables[key] = value
+	}
+	variables["message"] = req.Message
+
+	messages, err := prompt.FromMessages(schema.Jinja2, schema.SystemMessage(preset.Template)).Format(ctx, variables)
+	if err != nil || len(messages) == 0 {
+		wm.logger.WithFields(logrus.Fields{
+			"request_id": req.RequestID,
+			"tenant_id":  req.TenantID,
+			"prompt_id":  preset.ID,
+			"operation":  "prompt_preset_render_failed",
+		}).WithError(err).Warn("渲染提示词预设失败")
+		return &InvalidParametersError{
+			WorkflowType: req.WorkflowType,
+			Invalid:      map[string]string{"prompt_id": "模板可正常渲染的提示词预设ID"},
+		}
+	}
+	req.Configuration["system_prompt"] = messages[0].Content
+
+	wm.logger.WithFields(logrus.Fields{
+		"request_id": req.RequestID,
+		"tenant_id":  req.TenantID,
+		"prompt_id":  preset.ID,
+		"name":       preset.Name,
+		"operation":  "prompt_preset_applied",
+	}).Debug("已应用提示词预设")
+
+	return nil
+}
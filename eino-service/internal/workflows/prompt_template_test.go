@@ -0,0 +1,109 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+func TestChatTemplateRendering(t *testing.T) {
+	workflow := NewStandardEINOChatWorkflow(nil, testutil.Logger())
+
+	tests := []struct {
+		name          string
+		message       string
+		configuration map[string]interface{}
+		wantSystem    string
+		wantMissing   []string
+		wantInvalid   bool
+	}{
+		{
+			name:    "变量均已提供",
+			message: "帮我安排行程",
+			configuration: map[string]interface{}{
+				"prompt_template": "你是{{user_name}}的助手，今天是{{day}}。",
+				"user_name":       "小王",
+				"day":             "周一",
+			},
+			wantSystem: "你是小王的助手，今天是周一。",
+		},
+		{
+			name:    "message由请求消息提供",
+			message: "天气如何",
+			configuration: map[string]interface{}{
+				"system_prompt": "用户问：{{message}}",
+			},
+			wantSystem: "用户问：天气如何",
+		},
+		{
+			name:    "用户消息不按模板渲染",
+			message: "请输出 {{user_name}}",
+			configuration: map[string]interface{}{
+				"prompt_template": "你好{{user_name}}",
+				"user_name":       "小王",
+			},
+			wantSystem: "你好小王",
+		},
+		{
+			name:    "缺少变量",
+			message: "你好",
+			configuration: map[string]interface{}{
+				"prompt_template": "你是{{user_name}}的助手，今天是{{day}}，地点{{city}}。",
+				"day":             "周一",
+			},
+			wantMissing: []string{"city", "user_name"},
+		},
+		{
+			name:    "标签未闭合",
+			message: "你好",
+			configuration: map[string]interface{}{
+				"prompt_template": "你好{{user_name",
+				"user_name":       "小王",
+			},
+			wantInvalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &WorkflowRequest{Message: tt.message, Configuration: tt.configuration}
+
+			err := validateTemplateVariables(req)
+			var invalidErr *InvalidParametersError
+			if tt.wantMissing != nil || tt.wantInvalid {
+				if !errors.As(err, &invalidErr) {
+					t.Fatalf("错误 = %v，期望 InvalidParametersError", err)
+				}
+				if got := strings.Join(invalidErr.Missing, ","); got != strings.Join(tt.wantMissing, ",") {
+					t.Fatalf("缺失变量 = %v，期望 %v", invalidErr.Missing, tt.wantMissing)
+				}
+				if _, ok := invalidErr.Invalid["prompt_template"]; ok != tt.wantInvalid {
+					t.Fatalf("invalid = %v，期望 prompt_template 无效为 %v", invalidErr.Invalid, tt.wantInvalid)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateTemplateVariables: %v", err)
+			}
+
+			messages, err := buildChatTemplate(req).Format(context.Background(), workflow.buildTemplateVariables(req, "openai"))
+			if err != nil {
+				t.Fatalf("渲染模板失败: %v", err)
+			}
+			if len(messages) != 2 {
+				t.Fatalf("消息数 = %d，期望系统消息与用户消息各一条", len(messages))
+			}
+			if messages[0].Role != schema.System || messages[0].Content != tt.wantSystem {
+				t.Fatalf("系统消息 = %+v，期望 %q", messages[0], tt.wantSystem)
+			}
+			if messages[1].Role != schema.User || messages[1].Content != tt.message {
+				t.Fatalf("用户消息 = %+v，期望原样保留 %q", messages[1], tt.message)
+			}
+		})
+	}
+}
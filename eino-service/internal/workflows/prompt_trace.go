@@ -0,0 +1,84 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"lyss-ai-platform/eino-service/pkg/prompttrace"
+)
+
+// promptTraceTimeout 写入提示词记录的超时时间
+const promptTraceTimeout = time.Second
+
+// SetPromptTraceRecorder 设置提示词记录器，为nil时不记录
+func (wm *WorkflowManager) SetPromptTraceRecorder(recorder *prompttrace.Recorder) {
+	wm.promptTraces = recorder
+}
+
+// promptTraceReason 判断本次执行是否需要记录完整的提示词与回答，不需要时返回空字符串
+func (wm *WorkflowManager) promptTraceReason(req *WorkflowRequest) string {
+	if wm.promptTraces == nil {
+		return ""
+	}
+	return wm.promptTraces.Reason(req.DebugTrace)
+}
+
+// recordPromptTrace 记录请求的提示词与模型回答
+// 使用独立的超时上下文，客户端断开后仍能完成写入
+func (wm *WorkflowManager) recordPromptTrace(req *WorkflowRequest, reason, model, content string, err error) {
+	trace := &prompttrace.Trace{
+		RequestID:     req.RequestID,
+		ExecutionID:   req.ExecutionID,
+		TenantID:      req.TenantID,
+		UserID:        req.UserID,
+		WorkflowType:  req.WorkflowType,
+		Model:         model,
+		Reason:        reason,
+		Message:       req.Message,
+		Configuration: req.Configuration,
+		ModelConfig:   req.ModelConfig,
+		Response:      content,
+		CreatedAt:     time.Now().UTC(),
+	}
+	if trace.Model == "" {
+		trace.Model = req.Model
+	}
+	if err != nil {
+		trace.Error = err.Error()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), promptTraceTimeout)
+	defer cancel()
+	wm.promptTraces.Record(ctx, trace)
+}
+
+// tracePromptStream 转发流式事件，拼接增量内容，在流结束或出错时记录提示词与完整回答
+func (wm *WorkflowManager) tracePromptStream(req *WorkflowRequest, reason string, responseCh <-chan *WorkflowStreamResponse) <-chan *WorkflowStreamResponse {
+	forwardCh := make(chan *WorkflowStreamResponse, cap(responseCh))
+
+	go func() {
+		defer close(forwardCh)
+
+		var content strings.Builder
+		for event := range responseCh {
+			switch event.Type {
+			case StreamEventChunk:
+				content.WriteString(event.Content)
+			case StreamEventEnd:
+				model, _ := event.Data["model"].(string)
+				finalContent, ok := event.Data["final_content"].(string)
+				if !ok {
+					finalContent = content.String()
+				}
+				wm.recordPromptTrace(req, reason, model, finalContent, nil)
+			case StreamEventError:
+				wm.recordPromptTrace(req, reason, "", content.String(), errors.New(event.Error))
+			}
+			forwardCh <- event
+		}
+	}()
+
+	return forwardCh
+}
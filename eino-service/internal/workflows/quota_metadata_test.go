@@ -0,0 +1,59 @@
+package workflows
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/config"
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/pkg/audit"
+	"lyss-ai-platform/eino-service/pkg/quota"
+)
+
+func TestApplyQuotaMetadata(t *testing.T) {
+	tests := []struct {
+		name        string
+		usage       *quota.Usage
+		wantWarning bool
+	}{
+		{name: "低于软上限", usage: &quota.Usage{UsedTokens: 700, LimitTokens: 1000, Percent: 70}},
+		{name: "超过软上限", usage: &quota.Usage{UsedTokens: 850, LimitTokens: 1000, Percent: 85, SoftExceeded: true}, wantWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := make(map[string]interface{})
+			applyQuotaMetadata(metadata, tt.usage)
+			if metadata["quota"] != tt.usage {
+				t.Fatal("metadata.quota 应为当前用量")
+			}
+			if _, ok := metadata["quota_warning"]; ok != tt.wantWarning {
+				t.Fatalf("quota_warning 存在 = %v，期望 %v", ok, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestRecordQuotaUsageAfterClientCancel(t *testing.T) {
+	redisClient, _ := newTestRedis(t)
+	wm := &WorkflowManager{
+		quotaStore: quota.NewStore(redisClient, &config.QuotaConfig{MonthlyTokenLimit: 1000, MonthlyCostLimit: 1}, testutil.Logger()),
+		pricing: audit.NewPricing([]config.ModelPricingConfig{
+			{Model: "deepseek-chat", PromptPer1K: 0.001, CompletionPer1K: 0.002},
+		}),
+		logger: testutil.Logger(),
+	}
+
+	// 模型调用已完成但客户端已断开，仍应计入用量与费用
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	response := &WorkflowResponse{
+		Model: "deepseek-chat",
+		Usage: &TokenUsage{PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150},
+	}
+	usage := wm.recordQuotaUsage(ctx, &WorkflowRequest{TenantID: "tenant-1"}, response)
+	if usage == nil || usage.UsedTokens != 150 || math.Abs(usage.UsedCost-0.0002) > 1e-12 {
+		t.Fatalf("usage = %+v，期望 150 个令牌、费用 0.0002", usage)
+	}
+}
@@ -2,14 +2,16 @@ package workflows
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/sirupsen/logrus"
 )
 
 // DefaultWorkflowRegistry 默认工作流注册表实现
+// 同名工作流可以注册多个版本（取自 WorkflowInfo.Version），按 name@version 引用指定版本，只给名称时使用最新版本
 type DefaultWorkflowRegistry struct {
-	workflows map[string]WorkflowEngine
+	workflows map[string]map[string]WorkflowEngine // 名称 -> 版本 -> 工作流
 	mutex     sync.RWMutex
 	logger    *logrus.Logger
 }
@@ -17,65 +19,81 @@ type DefaultWorkflowRegistry struct {
 // NewDefaultWorkflowRegistry 创建默认工作流注册表
 func NewDefaultWorkflowRegistry(logger *logrus.Logger) *DefaultWorkflowRegistry {
 	return &DefaultWorkflowRegistry{
-		workflows: make(map[string]WorkflowEngine),
+		workflows: make(map[string]map[string]WorkflowEngine),
 		logger:    logger,
 	}
 }
 
-// RegisterWorkflow 注册工作流
+// RegisterWorkflow 注册工作流，版本取自工作流信息，同名同版本不能重复注册
 func (r *DefaultWorkflowRegistry) RegisterWorkflow(name string, workflow WorkflowEngine) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.workflows[name]; exists {
-		return fmt.Errorf("工作流 %s 已经注册", name)
+	version := workflowVersion(workflow)
+	versions, exists := r.workflows[name]
+	if !exists {
+		versions = make(map[string]WorkflowEngine)
+		r.workflows[name] = versions
+	}
+	if _, exists := matchVersion(versions, version); exists {
+		return fmt.Errorf("工作流 %s 已经注册", WorkflowRef(name, version))
 	}
 
-	r.workflows[name] = workflow
+	versions[version] = workflow
 	r.logger.WithFields(logrus.Fields{
-		"workflow_name": name,
-		"operation":     "register_workflow",
+		"workflow_name":    name,
+		"workflow_version": version,
+		"operation":        "register_workflow",
 	}).Info("工作流注册成功")
 
 	return nil
 }
 
-// GetWorkflow 获取工作流
-func (r *DefaultWorkflowRegistry) GetWorkflow(name string) (WorkflowEngine, error) {
+// GetWorkflow 获取工作流，ref 为名称或 name@version，只给名称时返回最新版本
+// 名称已注册但版本不存在时返回 ErrUnknownWorkflowVersion
+func (r *DefaultWorkflowRegistry) GetWorkflow(ref string) (WorkflowEngine, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	workflow, exists := r.workflows[name]
+	name, version := ParseWorkflowRef(ref)
+	versions, exists := r.workflows[name]
 	if !exists {
 		return nil, fmt.Errorf("工作流 %s 未注册", name)
 	}
 
+	if version == "" {
+		return versions[latestVersion(versions)], nil
+	}
+	workflow, exists := matchVersion(versions, version)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownWorkflowVersion, ref)
+	}
 	return workflow, nil
 }
 
-// ListWorkflows 列出所有工作流
+// ListWorkflows 列出所有工作流，每个名称一条，信息取自最新版本，Versions 列出全部已注册版本
 func (r *DefaultWorkflowRegistry) ListWorkflows() []WorkflowInfo {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
 	var workflows []WorkflowInfo
-	for _, workflow := range r.workflows {
-		workflows = append(workflows, *workflow.GetInfo())
+	for _, versions := range r.workflows {
+		workflows = append(workflows, versionedInfo(versions, latestVersion(versions)))
 	}
+	sort.Slice(workflows, func(i, j int) bool {
+		return workflows[i].Name < workflows[j].Name
+	})
 
 	return workflows
 }
 
-// IsWorkflowRegistered 检查工作流是否已注册
-func (r *DefaultWorkflowRegistry) IsWorkflowRegistered(name string) bool {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
-
-	_, exists := r.workflows[name]
-	return exists
+// IsWorkflowRegistered 检查工作流是否已注册，ref 为名称或 name@version
+func (r *DefaultWorkflowRegistry) IsWorkflowRegistered(ref string) bool {
+	_, err := r.GetWorkflow(ref)
+	return err == nil
 }
 
-// GetWorkflowNames 获取所有工作流名称
+// GetWorkflowNames 获取所有工作流名称，多个版本只返回一次
 func (r *DefaultWorkflowRegistry) GetWorkflowNames() []string {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
@@ -88,7 +106,7 @@ func (r *DefaultWorkflowRegistry) GetWorkflowNames() []string {
 	return names
 }
 
-// GetWorkflowCount 获取工作流数量
+// GetWorkflowCount 获取工作流数量，多个版本计为一个
 func (r *DefaultWorkflowRegistry) GetWorkflowCount() int {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
@@ -96,24 +114,81 @@ func (r *DefaultWorkflowRegistry) GetWorkflowCount() int {
 	return len(r.workflows)
 }
 
-// UnregisterWorkflow 取消注册工作流
-func (r *DefaultWorkflowRegistry) UnregisterWorkflow(name string) error {
+// UnregisterWorkflow 取消注册工作流，ref 为 name@version 时只取消该版本，只给名称时取消全部版本
+func (r *DefaultWorkflowRegistry) UnregisterWorkflow(ref string) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.workflows[name]; !exists {
+	name, version := ParseWorkflowRef(ref)
+	versions, exists := r.workflows[name]
+	if !exists {
 		return fmt.Errorf("工作流 %s 未注册", name)
 	}
 
-	delete(r.workflows, name)
+	if version == "" {
+		delete(r.workflows, name)
+	} else {
+		for registered := range versions {
+			if compareVersions(registered, version) == 0 {
+				version = registered
+			}
+		}
+		if _, exists := versions[version]; !exists {
+			return fmt.Errorf("%w: %s", ErrUnknownWorkflowVersion, ref)
+		}
+		delete(versions, version)
+		if len(versions) == 0 {
+			delete(r.workflows, name)
+		}
+	}
+
 	r.logger.WithFields(logrus.Fields{
-		"workflow_name": name,
-		"operation":     "unregister_workflow",
+		"workflow_name":    name,
+		"workflow_version": version,
+		"operation":        "unregister_workflow",
 	}).Info("工作流取消注册成功")
 
 	return nil
 }
 
+// workflowVersion 工作流的版本号，未声明时为空字符串
+func workflowVersion(workflow WorkflowEngine) string {
+	if info := workflow.GetInfo(); info != nil {
+		return info.Version
+	}
+	return ""
+}
+
+// latestVersion 已注册版本中最大的版本号
+func latestVersion(versions map[string]WorkflowEngine) string {
+	latest := ""
+	first := true
+	for version := range versions {
+		if first || compareVersions(version, latest) > 0 {
+			latest = version
+			first = false
+		}
+	}
+	return latest
+}
+
+// registeredVersions 已注册版本号，按升序排列
+func registeredVersions(versions map[string]WorkflowEngine) []string {
+	list := make([]string, 0, len(versions))
+	for version := range versions {
+		list = append(list, version)
+	}
+	sortVersions(list)
+	return list
+}
+
+// versionedInfo 指定版本的工作流信息，Versions 填充为全部已注册版本
+func versionedInfo(versions map[string]WorkflowEngine, version string) WorkflowInfo {
+	info := *versions[version].GetInfo()
+	info.Versions = registeredVersions(versions)
+	return info
+}
+
 // ValidateWorkflow 验证工作流
 func (r *DefaultWorkflowRegistry) ValidateWorkflow(name string, workflow WorkflowEngine) error {
 	if name == "" {
@@ -149,24 +224,33 @@ func (r *DefaultWorkflowRegistry) RegisterWorkflowSafely(name string, workflow W
 	return r.RegisterWorkflow(name, workflow)
 }
 
-// GetWorkflowInfo 获取工作流信息
-func (r *DefaultWorkflowRegistry) GetWorkflowInfo(name string) (*WorkflowInfo, error) {
-	workflow, err := r.GetWorkflow(name)
+// GetWorkflowInfo 获取工作流信息，ref 为名称或 name@version，只给名称时返回最新版本的信息
+func (r *DefaultWorkflowRegistry) GetWorkflowInfo(ref string) (*WorkflowInfo, error) {
+	workflow, err := r.GetWorkflow(ref)
 	if err != nil {
 		return nil, err
 	}
 
-	return workflow.GetInfo(), nil
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	name, _ := ParseWorkflowRef(ref)
+	info := *workflow.GetInfo()
+	if versions, exists := r.workflows[name]; exists {
+		info.Versions = registeredVersions(versions)
+	}
+	return &info, nil
 }
 
-// GetWorkflowInfos 获取所有工作流信息
+// GetWorkflowInfos 获取所有工作流最新版本的信息
 func (r *DefaultWorkflowRegistry) GetWorkflowInfos() map[string]*WorkflowInfo {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
 	infos := make(map[string]*WorkflowInfo)
-	for name, workflow := range r.workflows {
-		infos[name] = workflow.GetInfo()
+	for name, versions := range r.workflows {
+		info := versionedInfo(versions, latestVersion(versions))
+		infos[name] = &info
 	}
 
 	return infos
@@ -0,0 +1,190 @@
+package workflows
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/pkg/responsecache"
+)
+
+// responseCacheTimeout 读写响应缓存的超时时间，缓存不可用时不拖慢请求
+const responseCacheTimeout = 500 * time.Millisecond
+
+// cacheableWorkflows 可缓存响应的工作流
+// 工具调用和检索增强的结果依赖外部数据，即使 temperature 为0也不缓存
+var cacheableWorkflows = map[string]bool{
+	"eino_standard_chat": true,
+	"simple_chat":        true,
+	"standard_eino_chat": true,
+}
+
+// SetResponseCache 设置确定性请求的响应缓存，为nil时不缓存
+func (wm *WorkflowManager) SetResponseCache(store *responsecache.Store) {
+	wm.responseCache = store
+}
+
+// isCacheableRequest 判断请求是否可以使用响应缓存
+// 只有显式设置 temperature（顶层字段或 model_config.temperature）为0且不使用工具的请求才可缓存
+func isCacheableRequest(req *WorkflowRequest) bool {
+	if !cacheableWorkflows[req.WorkflowType] {
+		return false
+	}
+	params := resolveGenerationParams(req)
+	return params.Temperature != nil && *params.Temperature == 0
+}
+
+// responseCacheKey 按影响模型输出的请求内容计算缓存键
+// 租户在 Redis 键中单独区分；map 序列化时按键排序，相同内容得到相同的键
+func responseCacheKey(req *WorkflowRequest) (string, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"workflow_type": req.WorkflowType,
+		"model":         req.Model,
+		"message":       req.Message,
+		"content_parts": req.ContentParts,
+		"temperature":   req.Temperature,
+		"max_tokens":    req.MaxTokens,
+		"seed":          req.Seed,
+		"model_config":  req.ModelConfig,
+		"configuration": req.Configuration,
+
+		"response_format":  req.ResponseFormat,
+		"workflow_version": req.WorkflowVersion,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// lookupResponseCache 读取请求的缓存响应，未启用、不可缓存、未命中或读取失败时返回nil
+func (wm *WorkflowManager) lookupResponseCache(ctx context.Context, req *WorkflowRequest) *WorkflowResponse {
+	if wm.responseCache == nil || !isCacheableRequest(req) {
+		return nil
+	}
+
+	key, err := responseCacheKey(req)
+	if err != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, responseCacheTimeout)
+	defer cancel()
+
+	payload, found, err := wm.responseCache.Get(ctx, req.TenantID, key)
+	if err != nil {
+		wm.logger.WithFields(logrus.Fields{
+			"request_id": req.RequestID,
+			"tenant_id":  req.TenantID,
+			"operation":  "response_cache_read_failed",
+			"error":      err.Error(),
+		}).Warn("读取响应缓存失败")
+		return nil
+	}
+	if !found {
+		return nil
+	}
+
+	var response WorkflowResponse
+	if err := json.Unmarshal(payload, &response); err != nil {
+		return nil
+	}
+
+	if req.ExecutionID == "" {
+		req.ExecutionID = uuid.New().String()
+	}
+	response.ID = req.ExecutionID
+	response.ExecutionTimeMs = 0
+	if response.Metadata == nil {
+		response.Metadata = make(map[string]interface{})
+	}
+	response.Metadata["cache_hit"] = true
+
+	wm.logger.WithFields(logrus.Fields{
+		"request_id":    req.RequestID,
+		"execution_id":  req.ExecutionID,
+		"tenant_id":     req.TenantID,
+		"workflow_type": req.WorkflowType,
+		"operation":     "response_cache_hit",
+	}).Info("命中响应缓存")
+
+	return &response
+}
+
+// storeResponseCache 缓存成功的响应，失败时仅记录日志
+func (wm *WorkflowManager) storeResponseCache(ctx context.Context, req *WorkflowRequest, response *WorkflowResponse) {
+	if wm.responseCache == nil || !response.Success || !isCacheableRequest(req) {
+		return
+	}
+
+	key, err := responseCacheKey(req)
+	if err != nil {
+		return
+	}
+	payload, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), responseCacheTimeout)
+	defer cancel()
+
+	if err := wm.responseCache.Set(ctx, req.TenantID, key, payload); err != nil {
+		wm.logger.WithFields(logrus.Fields{
+			"request_id": req.RequestID,
+			"tenant_id":  req.TenantID,
+			"operation":  "response_cache_write_failed",
+			"error":      err.Error(),
+		}).Warn("写入响应缓存失败")
+	}
+}
+
+// replayCachedStream 将缓存的完整回答作为单个增量事件回放
+func replayCachedStream(req *WorkflowRequest, response *WorkflowResponse) <-chan *WorkflowStreamResponse {
+	responseChan := make(chan *WorkflowStreamResponse, 3)
+
+	responseChan <- &WorkflowStreamResponse{
+		Type:        StreamEventStart,
+		ExecutionID: req.ExecutionID,
+		Data: map[string]any{
+			"model":     response.Model,
+			"cache_hit": true,
+		},
+	}
+	responseChan <- &WorkflowStreamResponse{
+		Type:        StreamEventChunk,
+		ExecutionID: req.ExecutionID,
+		Content:     response.Content,
+		Data: map[string]any{
+			"delta": response.Content,
+		},
+	}
+
+	end := &WorkflowStreamResponse{
+		Type:        StreamEventEnd,
+		ExecutionID: req.ExecutionID,
+		Data: map[string]any{
+			"final_content": response.Content,
+			"model":         response.Model,
+			"finish_reason": response.FinishReason,
+			"cache_hit":     true,
+		},
+	}
+	if response.Usage != nil {
+		end.Data["usage"] = map[string]int{
+			"prompt_tokens":     response.Usage.PromptTokens,
+			"completion_tokens": response.Usage.CompletionTokens,
+			"total_tokens":      response.Usage.TotalTokens,
+		}
+	}
+	responseChan <- end
+	close(responseChan)
+
+	return responseChan
+}
@@ -0,0 +1,120 @@
+package workflows
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/pkg/responsecache"
+)
+
+// newTestRedis 创建使用 miniredis 的Redis客户端
+func newTestRedis(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client, mr
+}
+
+func TestIsCacheableRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *WorkflowRequest
+		want bool
+	}{
+		{name: "未设置 temperature", req: &WorkflowRequest{WorkflowType: "eino_standard_chat"}},
+		{name: "顶层 temperature 为0", req: &WorkflowRequest{WorkflowType: "eino_standard_chat", Temperature: float64Ptr(0)}, want: true},
+		{
+			name: "model_config.temperature 为0",
+			req:  &WorkflowRequest{WorkflowType: "simple_chat", ModelConfig: map[string]interface{}{"temperature": float64(0)}},
+			want: true,
+		},
+		{name: "temperature 非0", req: &WorkflowRequest{WorkflowType: "eino_standard_chat", Temperature: float64Ptr(0.7)}},
+		{
+			name: "model_config 覆盖顶层的0",
+			req: &WorkflowRequest{
+				WorkflowType: "eino_standard_chat",
+				Temperature:  float64Ptr(0),
+				ModelConfig:  map[string]interface{}{"temperature": 0.5},
+			},
+		},
+		{name: "工具调用工作流不缓存", req: &WorkflowRequest{WorkflowType: "tool_calling", Temperature: float64Ptr(0)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isCacheableRequest(tt.req); got != tt.want {
+				t.Fatalf("isCacheableRequest = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseCacheHitAndMiss(t *testing.T) {
+	redisClient, _ := newTestRedis(t)
+	wm := &WorkflowManager{
+		responseCache: responsecache.NewStore(redisClient, time.Hour),
+		logger:        testutil.Logger(),
+	}
+	ctx := context.Background()
+
+	newRequest := func(message string) *WorkflowRequest {
+		return &WorkflowRequest{
+			TenantID:     "tenant-1",
+			WorkflowType: "eino_standard_chat",
+			Message:      message,
+			Temperature:  float64Ptr(0),
+		}
+	}
+
+	if cached := wm.lookupResponseCache(ctx, newRequest("你好")); cached != nil {
+		t.Fatal("写入前不应命中缓存")
+	}
+
+	wm.storeResponseCache(ctx, newRequest("你好"), &WorkflowResponse{Success: true, Content: "你好！", Model: "deepseek-chat"})
+
+	cached := wm.lookupResponseCache(ctx, newRequest("你好"))
+	if cached == nil {
+		t.Fatal("相同请求应命中缓存")
+	}
+	if cached.Content != "你好！" || cached.Metadata["cache_hit"] != true {
+		t.Fatalf("缓存响应 = %+v", cached)
+	}
+
+	if wm.lookupResponseCache(ctx, newRequest("再见")) != nil {
+		t.Fatal("不同消息不应命中缓存")
+	}
+
+	otherTenant := newRequest("你好")
+	otherTenant.TenantID = "tenant-2"
+	if wm.lookupResponseCache(ctx, otherTenant) != nil {
+		t.Fatal("其他租户不应命中缓存")
+	}
+
+	nonDeterministic := newRequest("你好")
+	nonDeterministic.Temperature = float64Ptr(0.7)
+	if wm.lookupResponseCache(ctx, nonDeterministic) != nil {
+		t.Fatal("temperature 非0的请求不应读取缓存")
+	}
+}
+
+func TestResponseCacheSkipsFailedAndNonDeterministicResponses(t *testing.T) {
+	redisClient, mr := newTestRedis(t)
+	wm := &WorkflowManager{
+		responseCache: responsecache.NewStore(redisClient, time.Hour),
+		logger:        testutil.Logger(),
+	}
+	ctx := context.Background()
+
+	wm.storeResponseCache(ctx, &WorkflowRequest{WorkflowType: "eino_standard_chat", Temperature: float64Ptr(0)}, &WorkflowResponse{Success: false})
+	wm.storeResponseCache(ctx, &WorkflowRequest{WorkflowType: "eino_standard_chat", Temperature: float64Ptr(1)}, &WorkflowResponse{Success: true})
+
+	if keys := mr.Keys(); len(keys) != 0 {
+		t.Fatalf("不应写入缓存，实际写入 %v", keys)
+	}
+}
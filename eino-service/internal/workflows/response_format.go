@@ -0,0 +1,129 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	acl "github.com/cloudwego/eino-ext/libs/acl/openai"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/internal/client"
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/pkg/jsonmode"
+)
+
+// jsonModeWorkflows 支持 response_format JSON模式的工作流
+var jsonModeWorkflows = map[string]bool{
+	"eino_standard_chat": true,
+	"simple_chat":        true,
+}
+
+// validateResponseFormat 校验请求的响应格式，不支持JSON模式的工作流拒绝JSON模式请求
+func validateResponseFormat(req *WorkflowRequest) error {
+	if req.ResponseFormat.Validate() != nil {
+		return &InvalidParametersError{
+			WorkflowType: req.WorkflowType,
+			Invalid:      map[string]string{"response_format": "text、json_object 或 json_schema（json_schema 需提供 json_schema.schema）"},
+		}
+	}
+	if req.ResponseFormat.JSONMode() && !jsonModeWorkflows[req.WorkflowType] {
+		return &InvalidParametersError{
+			WorkflowType: req.WorkflowType,
+			Invalid:      map[string]string{"response_format": "text（该工作流不支持JSON模式）"},
+		}
+	}
+	return nil
+}
+
+// buildOpenAIResponseFormat 转换为OpenAI模型组件的 response_format，非JSON模式时返回nil
+func buildOpenAIResponseFormat(format *models.ResponseFormat) (*acl.ChatCompletionResponseFormat, error) {
+	switch jsonmode.NativeType("openai", format) {
+	case models.ResponseFormatJSONObject:
+		return &acl.ChatCompletionResponseFormat{Type: acl.ChatCompletionResponseFormatTypeJSONObject}, nil
+	case models.ResponseFormatJSONSchema:
+		payload, err := json.Marshal(format.JSONSchema.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("序列化 json_schema 失败: %w", err)
+		}
+		schema := &openapi3.Schema{}
+		if err := json.Unmarshal(payload, schema); err != nil {
+			return nil, fmt.Errorf("解析 json_schema 失败: %w", err)
+		}
+		name := format.JSONSchema.Name
+		if name == "" {
+			name = "response"
+		}
+		return &acl.ChatCompletionResponseFormat{
+			Type: acl.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &acl.ChatCompletionResponseFormatJSONSchema{
+				Name:        name,
+				Description: format.JSONSchema.Description,
+				Schema:      schema,
+				Strict:      format.JSONSchema.Strict,
+			},
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// ensureJSONOutput JSON模式下校验模型输出，不是合法JSON时附加纠正提示重试一次
+// 返回内容为去除代码块标记后的JSON，重试时令牌用量为两次调用之和
+func (w *EINOStandardChatWorkflow) ensureJSONOutput(
+	ctx context.Context,
+	chatModel model.ChatModel,
+	messages []*schema.Message,
+	result *schema.Message,
+	req *WorkflowRequest,
+	provider string,
+) (*schema.Message, bool, error) {
+	if !req.ResponseFormat.JSONMode() {
+		return result, false, nil
+	}
+
+	content, err := jsonmode.Extract(result.Content)
+	if err == nil {
+		result.Content = content
+		return result, false, nil
+	}
+
+	w.logger.WithFields(logrus.Fields{
+		"request_id":   req.RequestID,
+		"execution_id": req.ExecutionID,
+		"tenant_id":    req.TenantID,
+		"provider":     provider,
+		"operation":    "json_output_retry",
+		"error":        err.Error(),
+	}).Warn("模型输出不是合法的JSON，重试一次")
+
+	retryMessages := make([]*schema.Message, 0, len(messages)+2)
+	retryMessages = append(retryMessages, messages...)
+	retryMessages = append(retryMessages,
+		schema.AssistantMessage(result.Content, nil),
+		schema.UserMessage(jsonmode.RetryPrompt(err)),
+	)
+
+	retried, err := chatModel.Generate(ctx, retryMessages)
+	if err != nil {
+		return nil, true, client.AsProviderError(provider, err)
+	}
+	if err := checkModelMessage(provider, retried); err != nil {
+		return nil, true, err
+	}
+	content, err = jsonmode.Extract(retried.Content)
+	if err != nil {
+		return nil, true, err
+	}
+
+	retried.Content = content
+	if retried.ResponseMeta != nil && retried.ResponseMeta.Usage != nil && result.ResponseMeta != nil && result.ResponseMeta.Usage != nil {
+		retried.ResponseMeta.Usage.PromptTokens += result.ResponseMeta.Usage.PromptTokens
+		retried.ResponseMeta.Usage.CompletionTokens += result.ResponseMeta.Usage.CompletionTokens
+		retried.ResponseMeta.Usage.TotalTokens += result.ResponseMeta.Usage.TotalTokens
+	}
+	return retried, true, nil
+}
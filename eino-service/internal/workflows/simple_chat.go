@@ -3,6 +3,7 @@ package workflows
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -14,17 +15,33 @@ import (
 // SimpleChatWorkflow 简单聊天工作流
 type SimpleChatWorkflow struct {
 	credentialManager *credential.Manager
+	httpClient        *http.Client
+	maxFallbacks      int
+	modelDefaults     *nodes.ModelDefaultsTable
+	maxSSELineSize    int
 	logger            *logrus.Logger
 }
 
 // NewSimpleChatWorkflow 创建简单聊天工作流
-func NewSimpleChatWorkflow(credentialManager *credential.Manager, logger *logrus.Logger) *SimpleChatWorkflow {
+func NewSimpleChatWorkflow(credentialManager *credential.Manager, httpClient *http.Client, maxFallbacks int, logger *logrus.Logger) *SimpleChatWorkflow {
 	return &SimpleChatWorkflow{
 		credentialManager: credentialManager,
+		httpClient:        httpClient,
+		maxFallbacks:      maxFallbacks,
 		logger:            logger,
 	}
 }
 
+// SetModelDefaults 设置模型默认参数表，请求未指定的生成参数从中获取
+func (w *SimpleChatWorkflow) SetModelDefaults(table *nodes.ModelDefaultsTable) {
+	w.modelDefaults = table
+}
+
+// SetMaxSSELineSize 设置供应商流式响应的单行上限，0表示使用默认值
+func (w *SimpleChatWorkflow) SetMaxSSELineSize(size int) {
+	w.maxSSELineSize = size
+}
+
 // Execute 执行简单聊天工作流
 func (w *SimpleChatWorkflow) Execute(ctx context.Context, req *WorkflowRequest) (*WorkflowResponse, error) {
 	startTime := time.Now()
@@ -66,6 +83,9 @@ func (w *SimpleChatWorkflow) Execute(ctx context.Context, req *WorkflowRequest)
 	
 	// 提取模型配置
 	if req.ModelConfig != nil {
+		if provider, exists := req.ModelConfig["provider"]; exists {
+			nodeCtx.State["provider"] = provider
+		}
 		if model, exists := req.ModelConfig["model"]; exists {
 			nodeCtx.State["model"] = model
 		}
@@ -78,6 +98,23 @@ func (w *SimpleChatWorkflow) Execute(ctx context.Context, req *WorkflowRequest)
 		if stream, exists := req.ModelConfig["stream"]; exists {
 			nodeCtx.State["stream"] = stream
 		}
+		for _, key := range []string{"stop", "frequency_penalty", "presence_penalty", "seed"} {
+			if value, exists := req.ModelConfig[key]; exists {
+				nodeCtx.State[key] = value
+			}
+		}
+	}
+
+	if req.Seed != nil {
+		nodeCtx.State["seed"] = *req.Seed
+	}
+
+	if req.ResponseFormat.JSONMode() {
+		nodeCtx.State["response_format"] = req.ResponseFormat
+	}
+
+	if req.CredentialStrategy != "" {
+		nodeCtx.State["credential_strategy"] = req.CredentialStrategy
 	}
 
 	// 添加系统提示（如果存在）
@@ -102,10 +139,16 @@ func (w *SimpleChatWorkflow) Execute(ctx context.Context, req *WorkflowRequest)
 	}).Info("简单聊天工作流开始执行")
 
 	// 创建聊天模型节点
-	chatNode := nodes.NewChatModelNode("chat_model", w.credentialManager, w.logger)
+	chatNode := nodes.NewChatModelNode("chat_model", w.credentialManager, w.httpClient, w.maxFallbacks, w.logger)
+	chatNode.SetModelDefaults(w.modelDefaults)
+	chatNode.SetMaxSSELineSize(w.maxSSELineSize)
+	stepTracker := stepTrackerFrom(ctx)
+	stepTracker.SetTotalSteps(1)
 
 	// 执行聊天模型节点
+	nodeStartTime := time.Now()
 	result, err := chatNode.Execute(ctx, nodeCtx)
+	stepTracker.RecordStep(buildNodeStep(chatNode.BaseNode, nodeCtx, result, err, nodeStartTime))
 	if err != nil {
 		w.logger.WithFields(logrus.Fields{
 			"request_id":     req.RequestID,
@@ -128,10 +171,16 @@ func (w *SimpleChatWorkflow) Execute(ctx context.Context, req *WorkflowRequest)
 	chatNode.UpdateNodeContext(nodeCtx, result)
 
 	// 构建响应
+	finishReason, _ := result.Data["finish_reason"].(string)
+	// 请求未指定模型时使用节点实际调用的模型
+	model, _ := nodeCtx.State["model"].(string)
+	if model == "" {
+		model, _ = result.Data["model_used"].(string)
+	}
 	response := &WorkflowResponse{
 		Success:         true,
 		Content:         result.Data["response"].(string),
-		Model:           nodeCtx.State["model"].(string),
+		Model:           model,
 		WorkflowType:    "simple_chat",
 		ExecutionTimeMs: time.Since(startTime).Milliseconds(),
 		Usage: &TokenUsage{
@@ -139,6 +188,7 @@ func (w *SimpleChatWorkflow) Execute(ctx context.Context, req *WorkflowRequest)
 			CompletionTokens: result.TokenUsage.CompletionTokens,
 			TotalTokens:      result.TokenUsage.TotalTokens,
 		},
+		FinishReason: finishReason,
 		Metadata: map[string]interface{}{
 			"workflow_type":    "simple_chat",
 			"nodes_executed":   []string{"chat_model"},
@@ -148,6 +198,11 @@ func (w *SimpleChatWorkflow) Execute(ctx context.Context, req *WorkflowRequest)
 			"node_metadata":    result.NodeMetadata,
 		},
 	}
+	for _, key := range []string{"provider", "credential_id", "seed", "fallbacks", "response_format", "json_retried"} {
+		if value, exists := result.NodeMetadata[key]; exists {
+			response.Metadata[key] = value
+		}
+	}
 
 	// 记录工作流完成
 	w.logger.WithFields(logrus.Fields{
@@ -233,6 +288,7 @@ func (w *SimpleChatWorkflow) GetInfo() *WorkflowInfo {
 		SupportedFeatures: []string{
 			"basic_chat",
 			"streaming",
+			"json_mode",
 		},
 		Nodes: []WorkflowNodeInfo{
 			{
@@ -264,7 +320,7 @@ func (w *SimpleChatWorkflow) ExecuteStream(ctx context.Context, req *WorkflowReq
 
 		// 发送开始事件
 		responseChan <- &WorkflowStreamResponse{
-			Type:        "start",
+			Type:        StreamEventStart,
 			ExecutionID: req.ExecutionID,
 			Data:        map[string]any{"message": "简单聊天工作流开始执行"},
 		}
@@ -273,39 +329,34 @@ func (w *SimpleChatWorkflow) ExecuteStream(ctx context.Context, req *WorkflowReq
 		response, err := w.Execute(ctx, req)
 		if err != nil {
 			responseChan <- &WorkflowStreamResponse{
-				Type:        "error",
+				Type:        StreamEventError,
 				ExecutionID: req.ExecutionID,
 				Error:       err.Error(),
 			}
 			return
 		}
 
-		// 模拟流式输出
-		words := []string{"这是", "简单", "聊天", "工作流", "的", "响应"}
-		var fullContent string
-		
-		for _, word := range words {
-			fullContent += word
-			
-			responseChan <- &WorkflowStreamResponse{
-				Type:        "chunk",
-				ExecutionID: req.ExecutionID,
-				Content:     fullContent,
-				Data: map[string]any{
-					"content": fullContent,
-					"delta":   word,
-				},
-			}
-			
-			time.Sleep(200 * time.Millisecond)
+		// 聊天节点以非流式方式调用模型，完整内容作为单个块推送
+		responseChan <- &WorkflowStreamResponse{
+			Type:        StreamEventChunk,
+			ExecutionID: req.ExecutionID,
+			Content:     response.Content,
+			Data: map[string]any{
+				"content": response.Content,
+				"delta":   response.Content,
+			},
 		}
 
 		// 发送结束事件
 		responseChan <- &WorkflowStreamResponse{
-			Type:        "end",
+			Type:        StreamEventEnd,
 			ExecutionID: req.ExecutionID,
 			Data: map[string]any{
-				"message": "简单聊天工作流执行完成",
+				"message":       "简单聊天工作流执行完成",
+				"provider":      response.Metadata["provider"],
+				"credential_id": response.Metadata["credential_id"],
+				"model":         response.Model,
+				"finish_reason": response.FinishReason,
 				"usage": map[string]int{
 					"prompt_tokens":     response.Usage.PromptTokens,
 					"completion_tokens": response.Usage.CompletionTokens,
@@ -3,17 +3,38 @@ package workflows
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
 	"time"
 
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
 	"github.com/sirupsen/logrus"
 
+	"lyss-ai-platform/eino-service/internal/client"
+	"lyss-ai-platform/eino-service/internal/models"
 	"lyss-ai-platform/eino-service/pkg/credential"
+	"lyss-ai-platform/eino-service/pkg/prompts"
 )
 
+// 提示词模板中对话历史与用户消息占位符使用的变量名
+const (
+	templateHistoryKey = "history_messages"
+	templateUserKey    = "user_messages"
+)
+
+// templateVariablePattern 匹配模板表达式 {{ name ... }} 中引用的变量名
+var templateVariablePattern = regexp.MustCompile(`\{\{-?\s*([A-Za-z_][A-Za-z0-9_]*)`)
+
 // StandardEINOChatWorkflow 标准EINO聊天工作流，严格按照官方规范实现
+// 使用 ChatTemplate + ChatModel 组成的EINO链，系统提示词支持从 Configuration 替换 {{变量}}
 type StandardEINOChatWorkflow struct {
 	credentialManager *credential.Manager
-	logger           *logrus.Logger
+	chatWorkflow      *EINOStandardChatWorkflow
+	logger            *logrus.Logger
 }
 
 // NewStandardEINOChatWorkflow 创建标准EINO聊天工作流
@@ -23,15 +44,22 @@ func NewStandardEINOChatWorkflow(
 ) *StandardEINOChatWorkflow {
 	return &StandardEINOChatWorkflow{
 		credentialManager: credentialManager,
-		logger:           logger,
+		chatWorkflow:      NewEINOStandardChatWorkflow(credentialManager, 0, 0, logger), // 仅复用模型创建与消息构建
+		logger:            logger,
 	}
 }
 
+// SetHTTPClient 设置调用供应商使用的HTTP客户端
+func (w *StandardEINOChatWorkflow) SetHTTPClient(httpClient *http.Client) {
+	w.chatWorkflow.SetHTTPClient(httpClient)
+}
+
 // Execute 执行工作流
 func (w *StandardEINOChatWorkflow) Execute(ctx context.Context, req *WorkflowRequest) (*WorkflowResponse, error) {
 	startTime := time.Now()
 
 	w.logger.WithFields(logrus.Fields{
+		"request_id":    req.RequestID,
 		"execution_id":  req.ExecutionID,
 		"tenant_id":     req.TenantID,
 		"user_id":       req.UserID,
@@ -39,47 +67,77 @@ func (w *StandardEINOChatWorkflow) Execute(ctx context.Context, req *WorkflowReq
 		"operation":     "workflow_start",
 	}).Info("开始执行标准EINO聊天工作流")
 
-	// TODO: 实现真正的EINO Chain
-	// 根据官方示例：
-	// chain, _ := eino.NewChain[map[string]any, *schema.Message]().
-	//            AppendChatTemplate(prompt).
-	//            AppendChatModel(model).
-	//            Compile(ctx)
-	
-	// 暂时使用模拟响应
-	content := fmt.Sprintf("标准EINO工作流响应。您的消息：%s", req.Message)
-	
-	// 计算执行时间
-	executionTime := time.Since(startTime).Milliseconds()
-
-	// 构建响应
+	// 1. 校验模板引用的变量
+	if err := validateTemplateVariables(req); err != nil {
+		return w.buildErrorResponse(startTime, err.Error(), err)
+	}
+
+	// 2. 获取凭证
+	credential, err := w.credentialManager.SelectCredential(req.TenantID, w.getProvider(req), "", req.UserID, req.CredentialStrategy)
+	if err != nil {
+		return w.buildErrorResponse(startTime, fmt.Sprintf("获取凭证失败: %v", err), err)
+	}
+
+	// 3. 编译EINO链并调用
+	chain, err := w.buildEINOChain(ctx, credential, req)
+	if err != nil {
+		return w.buildErrorResponse(startTime, fmt.Sprintf("构建EINO链失败: %v", err), err)
+	}
+
+	release, err := w.credentialManager.AcquireCall(ctx, credential)
+	if err != nil {
+		return w.buildErrorResponse(startTime, fmt.Sprintf("EINO链调用失败: %v", err), err)
+	}
+	result, err := chain.Invoke(ctx, w.buildTemplateVariables(req, credential.Provider))
+	release()
+	err = client.AsProviderError(credential.Provider, err)
+	if err == nil {
+		err = checkModelMessage(credential.Provider, result)
+	}
+	if err != nil {
+		w.credentialManager.RecordFailure(ctx, credential.ID.String(), err)
+		return w.buildErrorResponse(startTime, fmt.Sprintf("EINO链调用失败: %v", err), err)
+	}
+
+	w.credentialManager.RecordUsage(credential.ID.String())
+	w.credentialManager.RecordSuccess(credential.ID.String())
+
+	// 4. 构建响应
+	modelName := w.chatWorkflow.getModelName(credential, req)
 	response := &WorkflowResponse{
 		ID:              req.ExecutionID,
 		Success:         true,
-		Content:         content,
-		Model:           req.Model,
+		Content:         result.Content,
+		Model:           modelName,
 		WorkflowType:    "standard_eino_chat",
 		Status:          "completed",
-		ExecutionTimeMs: executionTime,
+		ExecutionTimeMs: time.Since(startTime).Milliseconds(),
 		Usage: &TokenUsage{
-			PromptTokens:     len(req.Message) / 4,
-			CompletionTokens: len(content) / 4,
-			TotalTokens:      (len(req.Message) + len(content)) / 4,
+			PromptTokens:     w.chatWorkflow.getPromptTokens(result),
+			CompletionTokens: w.chatWorkflow.getCompletionTokens(result),
+			TotalTokens:      w.chatWorkflow.getTotalTokens(result),
 		},
+		FinishReason: w.chatWorkflow.getFinishReason(result),
 		Metadata: map[string]interface{}{
-			"framework": "cloudwego/eino",
-			"version":   "v0.3.52",
+			"provider":      credential.Provider,
+			"credential_id": credential.ID.String(),
+			"model_used":    modelName,
+			"framework":     "cloudwego/eino",
+			"version":       "v0.3.52",
 		},
 	}
+	applySeedMetadata(response.Metadata, credential.Provider, resolveSeed(req))
 
 	w.logger.WithFields(logrus.Fields{
-		"execution_id":       req.ExecutionID,
-		"tenant_id":          req.TenantID,
-		"user_id":            req.UserID,
-		"workflow_type":      "standard_eino_chat",
-		"operation":          "workflow_success",
-		"execution_time_ms":  response.ExecutionTimeMs,
-		"total_tokens":       response.Usage.TotalTokens,
+		"request_id":        req.RequestID,
+		"execution_id":      req.ExecutionID,
+		"tenant_id":         req.TenantID,
+		"user_id":           req.UserID,
+		"workflow_type":     "standard_eino_chat",
+		"operation":         "workflow_success",
+		"provider":          credential.Provider,
+		"execution_time_ms": response.ExecutionTimeMs,
+		"total_tokens":      response.Usage.TotalTokens,
 	}).Info("标准EINO聊天工作流执行成功")
 
 	return response, nil
@@ -87,12 +145,17 @@ func (w *StandardEINOChatWorkflow) Execute(ctx context.Context, req *WorkflowReq
 
 // ExecuteStream 流式执行工作流
 func (w *StandardEINOChatWorkflow) ExecuteStream(ctx context.Context, req *WorkflowRequest) (<-chan *WorkflowStreamResponse, error) {
-	responseChan := make(chan *WorkflowStreamResponse, 10)
+	if err := validateTemplateVariables(req); err != nil {
+		return nil, err
+	}
+
+	responseChan := make(chan *WorkflowStreamResponse, 100)
 
 	go func() {
 		defer close(responseChan)
 
 		w.logger.WithFields(logrus.Fields{
+			"request_id":    req.RequestID,
 			"execution_id":  req.ExecutionID,
 			"tenant_id":     req.TenantID,
 			"user_id":       req.UserID,
@@ -100,54 +163,128 @@ func (w *StandardEINOChatWorkflow) ExecuteStream(ctx context.Context, req *Workf
 			"operation":     "workflow_stream_start",
 		}).Info("开始流式执行标准EINO聊天工作流")
 
-		// 发送开始事件
+		// 1. 获取凭证并编译EINO链
+		credential, err := w.credentialManager.SelectCredential(req.TenantID, w.getProvider(req), "", req.UserID, req.CredentialStrategy)
+		if err != nil {
+			responseChan <- &WorkflowStreamResponse{
+				Type:  StreamEventError,
+				Error: fmt.Sprintf("获取凭证失败: %v", err),
+			}
+			return
+		}
+
+		chain, err := w.buildEINOChain(ctx, credential, req)
+		if err != nil {
+			responseChan <- &WorkflowStreamResponse{
+				Type:  StreamEventError,
+				Error: fmt.Sprintf("构建EINO链失败: %v", err),
+			}
+			return
+		}
+
+		modelName := w.chatWorkflow.getModelName(credential, req)
 		responseChan <- &WorkflowStreamResponse{
-			Type:        "start",
+			Type:        StreamEventStart,
 			ExecutionID: req.ExecutionID,
-			Data:        map[string]any{"message": "标准EINO工作流开始执行"},
+			Data: map[string]any{
+				"provider": credential.Provider,
+				"model":    modelName,
+			},
+		}
+
+		// 2. 流式调用EINO链，输出结束前一直占用凭证的并发名额
+		release, err := w.credentialManager.AcquireCall(ctx, credential)
+		if err != nil {
+			responseChan <- &WorkflowStreamResponse{
+				Type:  StreamEventError,
+				Error: fmt.Sprintf("流式调用失败: %v", err),
+			}
+			return
+		}
+		defer release()
+
+		streamResult, err := chain.Stream(ctx, w.buildTemplateVariables(req, credential.Provider))
+		if err != nil {
+			err = client.AsProviderError(credential.Provider, err)
+			w.credentialManager.RecordFailure(ctx, credential.ID.String(), err)
+			responseChan <- &WorkflowStreamResponse{
+				Type:  StreamEventError,
+				Error: fmt.Sprintf("流式调用失败: %v", err),
+			}
+			return
 		}
+		defer streamResult.Close()
 
-		// 模拟流式响应
-		words := []string{"标准", "EINO", "工作流", "流式", "响应"}
 		var fullContent string
-		
-		for _, word := range words {
-			fullContent += word
-			
+		var chunks []*schema.Message
+		for {
+			chunk, err := streamResult.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				w.credentialManager.RecordFailure(ctx, credential.ID.String(), err)
+				responseChan <- &WorkflowStreamResponse{
+					Type:  StreamEventError,
+					Error: fmt.Sprintf("接收流式数据失败: %v", err),
+				}
+				return
+			}
+
+			if chunk == nil {
+				continue
+			}
+			chunks = append(chunks, chunk)
+			fullContent += chunk.Content
+
 			responseChan <- &WorkflowStreamResponse{
-				Type:        "chunk",
+				Type:        StreamEventChunk,
 				ExecutionID: req.ExecutionID,
 				Content:     fullContent,
 				Data: map[string]any{
-					"content": fullContent,
-					"delta":   word,
+					"delta": chunk.Content,
 				},
 			}
-			
-			time.Sleep(300 * time.Millisecond)
 		}
 
-		// 发送结束事件
+		// 3. 合并最终消息并发送结束事件
+		finalMessage, err := schema.ConcatMessages(chunks)
+		if err != nil {
+			responseChan <- &WorkflowStreamResponse{
+				Type:  StreamEventError,
+				Error: fmt.Sprintf("合并消息失败: %v", err),
+			}
+			return
+		}
+
 		responseChan <- &WorkflowStreamResponse{
-			Type:        "end",
+			Type:        StreamEventEnd,
 			ExecutionID: req.ExecutionID,
 			Data: map[string]any{
-				"message": "标准EINO工作流执行完成",
+				"final_content": finalMessage.Content,
+				"provider":      credential.Provider,
+				"credential_id": credential.ID.String(),
+				"model":         modelName,
+				"finish_reason": w.chatWorkflow.getFinishReason(finalMessage),
 				"usage": map[string]int{
-					"prompt_tokens":     len(req.Message) / 4,
-					"completion_tokens": len(fullContent) / 4,
-					"total_tokens":      (len(req.Message) + len(fullContent)) / 4,
+					"prompt_tokens":     w.chatWorkflow.getPromptTokensFromMessage(finalMessage),
+					"completion_tokens": w.chatWorkflow.getCompletionTokensFromMessage(finalMessage),
+					"total_tokens":      w.chatWorkflow.getTotalTokensFromMessage(finalMessage),
 				},
-				"execution_time_ms": 1500,
 			},
 		}
 
+		w.credentialManager.RecordUsage(credential.ID.String())
+		w.credentialManager.RecordSuccess(credential.ID.String())
+
 		w.logger.WithFields(logrus.Fields{
+			"request_id":    req.RequestID,
 			"execution_id":  req.ExecutionID,
 			"tenant_id":     req.TenantID,
 			"user_id":       req.UserID,
 			"workflow_type": "standard_eino_chat",
 			"operation":     "workflow_stream_success",
+			"provider":      credential.Provider,
 		}).Info("标准EINO流式聊天工作流执行成功")
 	}()
 
@@ -190,11 +327,17 @@ func (w *StandardEINOChatWorkflow) GetInfo() *WorkflowInfo {
 				Description: "最大Token数",
 				Default:     2048,
 			},
+			{
+				Name:        "prompt_template",
+				Type:        "string",
+				Required:    false,
+				Description: "系统提示词模板，使用 {{变量名}} 引用 configuration 中的字段",
+			},
 		},
 		SupportedFeatures: []string{
 			"streaming",
 			"eino_chain",
-			"eino_graph", 
+			"eino_graph",
 			"official_standard",
 		},
 		RequiredInputs: []string{"message"},
@@ -205,17 +348,92 @@ func (w *StandardEINOChatWorkflow) GetInfo() *WorkflowInfo {
 	}
 }
 
-// buildEINOChain 构建标准EINO链（待完整实现）
-func (w *StandardEINOChatWorkflow) buildEINOChain(ctx context.Context) (interface{}, error) {
-	// TODO: 根据官方示例实现标准EINO链构建
-	// 示例代码结构：
-	// chain, err := eino.NewChain[map[string]any, *schema.Message]().
-	//     AppendChatTemplate(prompt).
-	//     AppendChatModel(model).
-	//     Compile(ctx)
-	
-	w.logger.Info("构建标准EINO链（当前为占位实现）")
-	return nil, fmt.Errorf("标准EINO链构建待实现")
+// buildEINOChain 构建并编译标准EINO链：ChatTemplate 渲染提示词后交给 ChatModel
+func (w *StandardEINOChatWorkflow) buildEINOChain(ctx context.Context, credential *models.SupplierCredential, req *WorkflowRequest) (compose.Runnable[map[string]any, *schema.Message], error) {
+	chatModel, err := w.chatWorkflow.createChatModel(ctx, credential, req)
+	if err != nil {
+		return nil, fmt.Errorf("创建聊天模型失败: %w", err)
+	}
+
+	chain, err := compose.NewChain[map[string]any, *schema.Message]().
+		AppendChatTemplate(buildChatTemplate(req)).
+		AppendChatModel(chatModel).
+		Compile(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("编译EINO链失败: %w", err)
+	}
+
+	return chain, nil
+}
+
+// buildChatTemplate 构建聊天模板
+// 仅系统提示词按 Jinja2 渲染，对话历史与用户消息通过占位符原样传入，避免用户输入被当作模板解析
+func buildChatTemplate(req *WorkflowRequest) prompt.ChatTemplate {
+	var templates []schema.MessagesTemplate
+	if promptTemplate := templateSource(req); promptTemplate != "" {
+		templates = append(templates, schema.SystemMessage(promptTemplate))
+	}
+	templates = append(templates,
+		schema.MessagesPlaceholder(templateHistoryKey, true),
+		schema.MessagesPlaceholder(templateUserKey, false),
+	)
+	return prompt.FromMessages(schema.Jinja2, templates...)
+}
+
+// buildTemplateVariables 构建模板变量：Configuration 中的全部字段、message，以及历史与用户消息占位符
+func (w *StandardEINOChatWorkflow) buildTemplateVariables(req *WorkflowRequest, provider string) map[string]any {
+	variables := make(map[string]any, len(req.Configuration)+3)
+	for key, value := range req.Configuration {
+		variables[key] = value
+	}
+	variables["message"] = req.Message
+	variables[templateHistoryKey] = w.chatWorkflow.buildHistoryMessages(req)
+	variables[templateUserKey] = []*schema.Message{buildUserMessage(req, provider)}
+	return variables
+}
+
+// templateSource 获取系统提示词模板，prompt_template 优先于 system_prompt
+func templateSource(req *WorkflowRequest) string {
+	if promptTemplate, ok := req.Configuration["prompt_template"].(string); ok && promptTemplate != "" {
+		return promptTemplate
+	}
+	systemPrompt, _ := req.Configuration["system_prompt"].(string)
+	return systemPrompt
+}
+
+// validateTemplateVariables 检查模板引用的变量均已在 Configuration 中提供
+func validateTemplateVariables(req *WorkflowRequest) error {
+	if err := prompts.CheckTemplateTags(templateSource(req)); err != nil {
+		return &InvalidParametersError{
+			WorkflowType: "standard_eino_chat",
+			Invalid:      map[string]string{"prompt_template": "标签均已闭合的 Jinja2 模板"},
+		}
+	}
+	if missing := missingTemplateVariables(templateSource(req), req.Configuration); len(missing) > 0 {
+		return &InvalidParametersError{
+			WorkflowType: "standard_eino_chat",
+			Missing:      missing,
+		}
+	}
+	return nil
+}
+
+// missingTemplateVariables 列出模板引用但 variables 中未提供的变量，message 由请求消息提供
+func missingTemplateVariables(template string, variables map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	var missing []string
+	for _, match := range templateVariablePattern.FindAllStringSubmatch(template, -1) {
+		name := match[1]
+		if seen[name] || name == "message" {
+			continue
+		}
+		seen[name] = true
+		if _, exists := variables[name]; !exists {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
 }
 
 // buildEINOGraph 构建标准EINO图（待完整实现）
@@ -226,39 +444,29 @@ func (w *StandardEINOChatWorkflow) buildEINOGraph(ctx context.Context) (interfac
 	// _ = graph.AddChatTemplateNode("node_template", chatTpl)
 	// _ = graph.AddChatModelNode("node_model", chatModel)
 	// compiledGraph, err := graph.Compile(ctx)
-	
+
 	w.logger.Info("构建标准EINO图（当前为占位实现）")
 	return nil, fmt.Errorf("标准EINO图构建待实现")
 }
 
-// createChatModel 创建聊天模型（待集成eino-ext组件）
-func (w *StandardEINOChatWorkflow) createChatModel(ctx context.Context, provider, modelName string) (interface{}, error) {
-	// TODO: 集成eino-ext组件实现真正的模型创建
-	// 根据调研的releases，应该支持：
-	// - OpenAI: github.com/cloudwego/eino-ext/components/model/openai
-	// - Claude: github.com/cloudwego/eino-ext/components/model/claude
-	// - Gemini: github.com/cloudwego/eino-ext/components/model/gemini
-	
-	switch provider {
-	case "openai":
-		// TODO: 实现 openai.NewChatModel(ctx, config)
-		return nil, fmt.Errorf("OpenAI模型创建待实现")
-	case "claude":
-		// TODO: 实现 claude.NewChatModel(ctx, config)
-		return nil, fmt.Errorf("Claude模型创建待实现")
-	case "gemini":
-		// TODO: 实现 gemini.NewChatModel(ctx, config)
-		return nil, fmt.Errorf("Gemini模型创建待实现")
-	default:
-		return nil, fmt.Errorf("不支持的供应商: %s", provider)
+// getProvider 获取请求指定的供应商，默认使用 openai
+func (w *StandardEINOChatWorkflow) getProvider(req *WorkflowRequest) string {
+	if req.ModelConfig != nil {
+		if provider, ok := req.ModelConfig["provider"].(string); ok && provider != "" {
+			return provider
+		}
 	}
+	return "openai"
 }
 
-// invokeEINOChain 调用EINO链（待完整实现）
-func (w *StandardEINOChatWorkflow) invokeEINOChain(ctx context.Context, chain interface{}, input map[string]any) (interface{}, error) {
-	// TODO: 实现标准EINO链调用
-	// 示例代码：
-	// output, err := chain.Invoke(ctx, input)
-	
-	return nil, fmt.Errorf("EINO链调用待实现")
-}
\ No newline at end of file
+// buildErrorResponse 构建错误响应
+func (w *StandardEINOChatWorkflow) buildErrorResponse(startTime time.Time, message string, err error) (*WorkflowResponse, error) {
+	w.logger.WithError(err).Error(message)
+
+	return &WorkflowResponse{
+		Success:         false,
+		ErrorMessage:    message,
+		ExecutionTimeMs: time.Since(startTime).Milliseconds(),
+		WorkflowType:    "standard_eino_chat",
+	}, err
+}
@@ -0,0 +1,96 @@
+package workflows
+
+import (
+	"context"
+	"time"
+
+	"lyss-ai-platform/eino-service/internal/workflows/nodes"
+)
+
+// StepTracker 记录工作流执行步骤，由执行器通过 context 传递给工作流
+type StepTracker interface {
+	// SetTotalSteps 声明本次执行计划执行的步骤数，用于计算进度
+	SetTotalSteps(total int)
+
+	// RecordStep 记录一个已结束的步骤
+	RecordStep(step WorkflowStep)
+}
+
+// stepTrackerKey 步骤记录器在 context 中的键
+type stepTrackerKey struct{}
+
+// withStepTracker 将步骤记录器写入 context
+func withStepTracker(ctx context.Context, tracker StepTracker) context.Context {
+	return context.WithValue(ctx, stepTrackerKey{}, tracker)
+}
+
+// stepTrackerFrom 从 context 读取步骤记录器，不存在时返回不做任何记录的实现
+func stepTrackerFrom(ctx context.Context) StepTracker {
+	if tracker, ok := ctx.Value(stepTrackerKey{}).(StepTracker); ok {
+		return tracker
+	}
+	return noopStepTracker{}
+}
+
+// noopStepTracker 未经执行器调用工作流时使用的空记录器
+type noopStepTracker struct{}
+
+func (noopStepTracker) SetTotalSteps(int)       {}
+func (noopStepTracker) RecordStep(WorkflowStep) {}
+
+// executionStepTracker 将步骤写入执行上下文并同步到共享存储
+type executionStepTracker struct {
+	executor *DefaultWorkflowExecutor
+	execCtx  *WorkflowExecutionContext
+}
+
+// SetTotalSteps 实现 StepTracker 接口
+func (t *executionStepTracker) SetTotalSteps(total int) {
+	t.executor.mutex.Lock()
+	t.execCtx.TotalSteps = total
+	t.executor.mutex.Unlock()
+}
+
+// RecordStep 实现 StepTracker 接口
+func (t *executionStepTracker) RecordStep(step WorkflowStep) {
+	t.executor.mutex.Lock()
+	t.execCtx.Steps = append(t.execCtx.Steps, step)
+	t.executor.mutex.Unlock()
+	t.executor.persist(t.execCtx)
+}
+
+// buildNodeStep 根据节点执行结果构建执行步骤
+// 不记录节点的输入输出数据，避免请求内容随执行记录写入共享存储
+func buildNodeStep(node *nodes.BaseNode, nodeCtx *nodes.NodeContext, result *nodes.NodeResult, err error, startTime time.Time) WorkflowStep {
+	if result == nil {
+		result = &nodes.NodeResult{
+			Success:    false,
+			DurationMs: int(time.Since(startTime).Milliseconds()),
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+	}
+	executionStep := node.CreateExecutionStep(nodeCtx, result)
+
+	return WorkflowStep{
+		Name:       executionStep.Node,
+		Type:       node.Type,
+		Status:     executionStep.Status,
+		StartTime:  startTime.UnixMilli(),
+		EndTime:    time.Now().UnixMilli(),
+		DurationMs: executionStep.DurationMs,
+		Error:      executionStep.Error,
+	}
+}
+
+// completedStepCount 统计已成功完成的步骤数
+func completedStepCount(steps []WorkflowStep) int {
+	completed := 0
+	for _, step := range steps {
+		if step.Status == "completed" {
+			completed++
+		}
+	}
+	return completed
+}
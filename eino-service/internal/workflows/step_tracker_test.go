@@ -0,0 +1,118 @@
+package workflows
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/internal/testutil/credentialtest"
+)
+
+func TestSimpleChatRecordsStepsAndProgress(t *testing.T) {
+	tests := []struct {
+		name         string
+		failing      bool
+		wantStatus   string
+		wantProgress int
+		wantStep     string
+	}{
+		{name: "执行完成", wantStatus: "completed", wantProgress: 100, wantStep: "completed"},
+		{name: "执行失败", failing: true, wantStatus: "failed", wantProgress: 0, wantStep: "failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received []chatMessage
+			provider := newOpenAIStub(t, "你好！", &received)
+			if tt.failing {
+				provider = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					http.Error(w, `{"error":{"message":"invalid request"}}`, http.StatusBadRequest)
+				}))
+				t.Cleanup(provider.Close)
+			}
+			tenantService := testutil.NewTenantService(t)
+			tenantService.SetCredentials("tenant-1", testutil.Credential("deepseek", provider.URL))
+
+			registry := NewDefaultWorkflowRegistry(testutil.Logger())
+			workflow := NewSimpleChatWorkflow(credentialtest.NewManager(t, tenantService), http.DefaultClient, 0, testutil.Logger())
+			if err := registry.RegisterWorkflow("simple_chat", workflow); err != nil {
+				t.Fatalf("RegisterWorkflow: %v", err)
+			}
+
+			// 执行结束后本地记录即被移除，状态从共享存储读取
+			mr := miniredis.RunT(t)
+			redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			t.Cleanup(func() { redisClient.Close() })
+			executor := NewDefaultWorkflowExecutor(registry, testutil.Logger(), 10, time.Minute, NewMetricsCollector())
+			executor.SetExecutionStore(NewExecutionStore(redisClient, time.Hour, testutil.Logger()))
+
+			req := streamRequest("exec-1")
+			req.WorkflowType = "simple_chat"
+			req.Stream = false
+			req.ModelConfig = map[string]interface{}{"model": "deepseek-chat"}
+			executor.Execute(context.Background(), req)
+
+			status, err := executor.GetExecutionStatus("exec-1")
+			if err != nil {
+				t.Fatalf("GetExecutionStatus: %v", err)
+			}
+			if status.Status != tt.wantStatus || status.Progress != tt.wantProgress {
+				t.Fatalf("status/progress = %s/%d，期望 %s/%d", status.Status, status.Progress, tt.wantStatus, tt.wantProgress)
+			}
+			if len(status.Steps) == 0 {
+				t.Fatal("执行状态中没有步骤")
+			}
+			step := status.Steps[0]
+			if step.Name != "chat_model" || step.Status != tt.wantStep || step.EndTime < step.StartTime || step.StartTime == 0 {
+				t.Fatalf("步骤 = %+v，期望 chat_model 节点状态 %s 且包含起止时间", step, tt.wantStep)
+			}
+			if tt.failing && step.Error == "" {
+				t.Fatalf("失败的步骤应包含错误信息，实际 %+v", step)
+			}
+			if status.CurrentStep != "chat_model" {
+				t.Fatalf("current_step = %q，期望 chat_model", status.CurrentStep)
+			}
+		})
+	}
+}
+
+func TestBuildExecutionStatusProgress(t *testing.T) {
+	completed := WorkflowStep{Name: "a", Status: "completed"}
+	failed := WorkflowStep{Name: "b", Status: "failed"}
+
+	tests := []struct {
+		name       string
+		status     string
+		totalSteps int
+		steps      []WorkflowStep
+		want       int
+	}{
+		{name: "完成", status: "completed", totalSteps: 2, steps: []WorkflowStep{completed, completed}, want: 100},
+		{name: "运行中尚无步骤", status: "running", totalSteps: 2, want: 0},
+		{name: "运行中完成一半", status: "running", totalSteps: 2, steps: []WorkflowStep{completed}, want: 50},
+		{name: "运行中步骤全部完成时不超过99", status: "running", totalSteps: 1, steps: []WorkflowStep{completed}, want: 99},
+		{name: "失败的步骤不计入", status: "running", totalSteps: 2, steps: []WorkflowStep{failed}, want: 0},
+		{name: "未声明步骤数", status: "running", want: 50},
+		{name: "失败", status: "failed", totalSteps: 1, steps: []WorkflowStep{failed}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := buildExecutionStatus(&WorkflowExecutionContext{
+				Status:     tt.status,
+				TotalSteps: tt.totalSteps,
+				Steps:      tt.steps,
+				StartTime:  time.Now().UnixMilli(),
+			})
+			if status.Progress != tt.want {
+				t.Fatalf("progress = %d，期望 %d", status.Progress, tt.want)
+			}
+		})
+	}
+}
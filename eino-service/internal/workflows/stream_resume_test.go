@@ -0,0 +1,180 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"lyss-ai-platform/eino-service/internal/client"
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+// streamStep 一次流式调用的输出：依次发送的分块，以及分块之后返回的错误（为nil时正常结束）
+// usage 不为nil时附加在最后一个分块上
+type streamStep struct {
+	chunks []string
+	usage  *schema.TokenUsage
+	err    error
+}
+
+// fakeStreamModel 按调用顺序返回预设流式输出的模型替身，并记录每次调用的输入消息
+type fakeStreamModel struct {
+	mutex  sync.Mutex
+	steps  []streamStep
+	inputs [][]*schema.Message
+}
+
+func (m *fakeStreamModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *fakeStreamModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	call := len(m.inputs)
+	m.inputs = append(m.inputs, input)
+	if call >= len(m.steps) {
+		return nil, errors.New("unexpected stream call")
+	}
+	step := m.steps[call]
+
+	reader, writer := schema.Pipe[*schema.Message](len(step.chunks) + 1)
+	for i, content := range step.chunks {
+		chunk := schema.AssistantMessage(content, nil)
+		if i == len(step.chunks)-1 && step.usage != nil {
+			chunk.ResponseMeta = &schema.ResponseMeta{Usage: step.usage}
+		}
+		writer.Send(chunk, nil)
+	}
+	if step.err != nil {
+		writer.Send(nil, step.err)
+	}
+	writer.Close()
+	return reader, nil
+}
+
+func (m *fakeStreamModel) BindTools(tools []*schema.ToolInfo) error {
+	return nil
+}
+
+func TestReceiveStreamResume(t *testing.T) {
+	dropped := client.NewProviderError("deepseek", http.StatusBadGateway, "", "connection reset")
+	invalid := client.NewProviderError("deepseek", http.StatusBadRequest, "", "bad request")
+
+	tests := []struct {
+		name        string
+		maxResumes  int
+		steps       []streamStep
+		wantContent string
+		wantResumes int
+		wantUsage   schema.TokenUsage
+		wantErr     bool
+	}{
+		{
+			name:       "resume completes after two chunks",
+			maxResumes: 1,
+			steps: []streamStep{
+				{chunks: []string{"你好", "，世界"}, usage: &schema.TokenUsage{PromptTokens: 10, CompletionTokens: 4, TotalTokens: 14}, err: dropped},
+				{chunks: []string{"！"}, usage: &schema.TokenUsage{PromptTokens: 20, CompletionTokens: 1, TotalTokens: 21}},
+			},
+			wantContent: "你好，世界！",
+			wantResumes: 1,
+			// 被中断的请求与续写请求都已计费，用量为两次之和
+			wantUsage: schema.TokenUsage{PromptTokens: 30, CompletionTokens: 5, TotalTokens: 35},
+		},
+		{
+			name:       "resume disabled",
+			maxResumes: 0,
+			steps: []streamStep{
+				{chunks: []string{"你好", "，世界"}, err: dropped},
+			},
+			wantErr: true,
+		},
+		{
+			name:       "non-retriable error",
+			maxResumes: 1,
+			steps: []streamStep{
+				{chunks: []string{"你好"}, err: invalid},
+			},
+			wantErr: true,
+		},
+		{
+			name:       "resume limit reached",
+			maxResumes: 1,
+			steps: []streamStep{
+				{chunks: []string{"你好"}, err: dropped},
+				{chunks: []string{"，世界"}, err: dropped},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewEINOStandardChatWorkflow(nil, 0, tt.maxResumes, testutil.Logger())
+			chatModel := &fakeStreamModel{steps: tt.steps}
+			messages := []*schema.Message{schema.UserMessage("打个招呼")}
+			req := &WorkflowRequest{ExecutionID: "exec-1", TenantID: "tenant-1"}
+
+			streamResult, watchdog, err := w.startStream(context.Background(), chatModel, messages)
+			if err != nil {
+				t.Fatalf("startStream 返回错误: %v", err)
+			}
+
+			responseChan := make(chan *WorkflowStreamResponse, 16)
+			chunks, usage, resumes, err := w.receiveStream(context.Background(), chatModel, streamResult, watchdog, messages, req, responseChan)
+			close(responseChan)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("期望返回错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("receiveStream 返回错误: %v", err)
+			}
+
+			final, err := schema.ConcatMessages(chunks)
+			if err != nil {
+				t.Fatalf("合并消息失败: %v", err)
+			}
+			if final.Content != tt.wantContent {
+				t.Fatalf("最终内容 = %q，期望 %q", final.Content, tt.wantContent)
+			}
+			if usage == nil || *usage != tt.wantUsage {
+				t.Fatalf("令牌用量 = %+v，期望各次请求之和 %+v", usage, tt.wantUsage)
+			}
+			if len(resumes) != tt.wantResumes {
+				t.Fatalf("续写次数 = %d，期望 %d", len(resumes), tt.wantResumes)
+			}
+			if offset := resumes[0]["offset"]; offset != len("你好，世界") {
+				t.Fatalf("续写起点 = %v，期望 %d", offset, len("你好，世界"))
+			}
+
+			// 续写请求携带已输出内容与续写指令
+			resumeInput := chatModel.inputs[1]
+			if len(resumeInput) != 3 || resumeInput[1].Role != schema.Assistant || resumeInput[1].Content != "你好，世界" {
+				t.Fatalf("续写请求消息 = %v", resumeInput)
+			}
+			if resumeInput[2].Content != streamResumeInstruction {
+				t.Fatalf("续写指令 = %q", resumeInput[2].Content)
+			}
+
+			// 增量事件的累计内容连续，续写部分标记 resumed
+			var last *WorkflowStreamResponse
+			for event := range responseChan {
+				last = event
+			}
+			if last == nil || last.Content != tt.wantContent || last.Data["resumed"] != true {
+				t.Fatalf("最后一个增量事件 = %+v", last)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+// newTimeoutExecutor 创建超时上限为 executionTimeout、注册了 blockingWorkflow 的执行器
+func newTimeoutExecutor(t *testing.T, executionTimeout time.Duration) *DefaultWorkflowExecutor {
+	t.Helper()
+	registry := NewDefaultWorkflowRegistry(testutil.Logger())
+	if err := registry.RegisterWorkflow("blocking", &blockingWorkflow{release: make(chan struct{})}); err != nil {
+		t.Fatalf("RegisterWorkflow: %v", err)
+	}
+	return NewDefaultWorkflowExecutor(registry, testutil.Logger(), 10, executionTimeout, NewMetricsCollector())
+}
+
+func TestTimeoutFor(t *testing.T) {
+	executor := newTimeoutExecutor(t, time.Minute)
+
+	tests := []struct {
+		name      string
+		timeoutMs int
+		want      time.Duration
+	}{
+		{name: "未指定时使用上限", want: time.Minute},
+		{name: "负数按未指定处理", timeoutMs: -1, want: time.Minute},
+		{name: "缩短超时", timeoutMs: 1500, want: 1500 * time.Millisecond},
+		{name: "等于上限", timeoutMs: 60000, want: time.Minute},
+		{name: "超过上限按上限执行", timeoutMs: 600000, want: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := executor.timeoutFor(&WorkflowRequest{TimeoutMs: tt.timeoutMs}); got != tt.want {
+				t.Fatalf("timeoutFor(%d) = %v，期望 %v", tt.timeoutMs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteTimeoutOverride(t *testing.T) {
+	tests := []struct {
+		name             string
+		executionTimeout time.Duration
+		timeoutMs        int
+		cancelClient     bool
+		wantErr          error
+		wantStatus       string
+	}{
+		{
+			name:             "较短的超时生效",
+			executionTimeout: time.Minute,
+			timeoutMs:        30,
+			wantErr:          ErrExecutionTimeout,
+			wantStatus:       "timeout",
+		},
+		{
+			name:             "超过上限的超时被截断",
+			executionTimeout: 30 * time.Millisecond,
+			timeoutMs:        600000,
+			wantErr:          ErrExecutionTimeout,
+			wantStatus:       "timeout",
+		},
+		{
+			name:             "客户端取消与超时区分",
+			executionTimeout: time.Minute,
+			cancelClient:     true,
+			wantErr:          ErrClientCanceled,
+			wantStatus:       "cancelled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := newTimeoutExecutor(t, tt.executionTimeout)
+			req := streamRequest("exec-1")
+			req.Stream = false
+			req.TimeoutMs = tt.timeoutMs
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelClient {
+				time.AfterFunc(30*time.Millisecond, cancel)
+			}
+
+			start := time.Now()
+			_, err := executor.Execute(ctx, req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Execute 错误 = %v，期望 %v", err, tt.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("执行耗时 %v，超时未按预期生效", elapsed)
+			}
+
+			if status, _ := statusForError(err); status != tt.wantStatus {
+				t.Fatalf("执行状态 = %s，期望 %s", status, tt.wantStatus)
+			}
+		})
+	}
+}
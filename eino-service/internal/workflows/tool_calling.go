@@ -0,0 +1,365 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/internal/client"
+	"lyss-ai-platform/eino-service/internal/workflows/tools"
+	"lyss-ai-platform/eino-service/pkg/credential"
+)
+
+// 单次请求最多进行的工具调用轮数
+const maxToolRounds = 5
+
+// ToolCallingWorkflow 工具调用工作流
+// 根据租户工具配置向模型暴露可用工具，执行模型发起的工具调用并将结果回传，直到模型给出最终回答
+type ToolCallingWorkflow struct {
+	credentialManager *credential.Manager
+	tenantClient      *client.TenantClient
+	chatWorkflow      *EINOStandardChatWorkflow
+	tools             map[string]tools.Tool
+	logger            *logrus.Logger
+}
+
+// NewToolCallingWorkflow 创建工具调用工作流
+func NewToolCallingWorkflow(credentialManager *credential.Manager, tenantClient *client.TenantClient, logger *logrus.Logger) *ToolCallingWorkflow {
+	return &ToolCallingWorkflow{
+		credentialManager: credentialManager,
+		tenantClient:      tenantClient,
+		chatWorkflow:      NewEINOStandardChatWorkflow(credentialManager, 0, 0, logger), // 仅复用模型创建与消息构建
+		tools:             tools.Builtin(),
+		logger:            logger,
+	}
+}
+
+// SetHTTPClient 设置调用供应商使用的HTTP客户端
+func (w *ToolCallingWorkflow) SetHTTPClient(httpClient *http.Client) {
+	w.chatWorkflow.SetHTTPClient(httpClient)
+}
+
+// enabledTool 租户已启用的工具及其配置参数
+type enabledTool struct {
+	tool   tools.Tool
+	params map[string]interface{}
+}
+
+// Execute 执行工具调用工作流
+func (w *ToolCallingWorkflow) Execute(ctx context.Context, req *WorkflowRequest) (*WorkflowResponse, error) {
+	startTime := time.Now()
+
+	w.logger.WithFields(logrus.Fields{
+		"request_id":    req.RequestID,
+		"execution_id":  req.ExecutionID,
+		"tenant_id":     req.TenantID,
+		"user_id":       req.UserID,
+		"workflow_type": "tool_calling",
+		"operation":     "workflow_start",
+	}).Info("开始执行工具调用工作流")
+
+	// 1. 加载租户启用的工具
+	enabled := w.loadEnabledTools(ctx, req)
+
+	// 2. 获取凭证并创建模型
+	credential, err := w.credentialManager.SelectCredential(req.TenantID, w.getProvider(req), "", req.UserID, req.CredentialStrategy)
+	if err != nil {
+		return w.buildErrorResponse(startTime, fmt.Sprintf("获取凭证失败: %v", err), err)
+	}
+
+	chatModel, err := w.chatWorkflow.createChatModel(ctx, credential, req)
+	if err != nil {
+		return w.buildErrorResponse(startTime, fmt.Sprintf("创建聊天模型失败: %v", err), err)
+	}
+
+	if len(enabled) > 0 {
+		toolInfos := make([]*schema.ToolInfo, 0, len(enabled))
+		for _, item := range enabled {
+			toolInfos = append(toolInfos, item.tool.Info())
+		}
+		if err := chatModel.BindTools(toolInfos); err != nil {
+			return w.buildErrorResponse(startTime, fmt.Sprintf("绑定工具失败: %v", err), err)
+		}
+	}
+
+	// 3. 循环调用模型，执行工具调用直到得到最终回答
+	messages := w.chatWorkflow.buildMessages(req, credential.Provider)
+	usage := &TokenUsage{}
+	var toolCallsMade []string
+	var result *schema.Message
+
+	for round := 0; ; round++ {
+		var release func()
+		release, err = w.credentialManager.AcquireCall(ctx, credential)
+		if err == nil {
+			result, err = chatModel.Generate(ctx, messages)
+			release()
+			err = client.AsProviderError(credential.Provider, err)
+		}
+		if err == nil {
+			err = checkModelMessage(credential.Provider, result)
+		}
+		if err != nil {
+			w.credentialManager.RecordFailure(ctx, credential.ID.String(), err)
+			return w.buildErrorResponse(startTime, fmt.Sprintf("模型调用失败: %v", err), err)
+		}
+		usage.PromptTokens += w.chatWorkflow.getPromptTokens(result)
+		usage.CompletionTokens += w.chatWorkflow.getCompletionTokens(result)
+		usage.TotalTokens += w.chatWorkflow.getTotalTokens(result)
+
+		if len(result.ToolCalls) == 0 {
+			break
+		}
+		if round >= maxToolRounds {
+			err := fmt.Errorf("工具调用超过最大轮数 %d", maxToolRounds)
+			return w.buildErrorResponse(startTime, err.Error(), err)
+		}
+
+		messages = append(messages, result)
+		for _, call := range result.ToolCalls {
+			toolCallsMade = append(toolCallsMade, call.Function.Name)
+			messages = append(messages, schema.ToolMessage(w.invokeTool(ctx, req, enabled, call), call.ID))
+		}
+	}
+
+	w.credentialManager.RecordUsage(credential.ID.String())
+	w.credentialManager.RecordSuccess(credential.ID.String())
+
+	response := &WorkflowResponse{
+		ID:              req.ExecutionID,
+		Success:         true,
+		Content:         result.Content,
+		Model:           w.chatWorkflow.getModelName(credential, req),
+		WorkflowType:    "tool_calling",
+		Status:          "completed",
+		ExecutionTimeMs: time.Since(startTime).Milliseconds(),
+		Usage:           usage,
+		FinishReason:    w.chatWorkflow.getFinishReason(result),
+		Metadata: map[string]interface{}{
+			"provider":      credential.Provider,
+			"credential_id": credential.ID.String(),
+			"model_used":    w.chatWorkflow.getModelName(credential, req),
+			"tools_enabled": w.enabledToolNames(enabled),
+			"tool_calls":    toolCallsMade,
+		},
+	}
+	applySeedMetadata(response.Metadata, credential.Provider, resolveSeed(req))
+
+	w.logger.WithFields(logrus.Fields{
+		"request_id":        req.RequestID,
+		"execution_id":      req.ExecutionID,
+		"tenant_id":         req.TenantID,
+		"user_id":           req.UserID,
+		"workflow_type":     "tool_calling",
+		"operation":         "workflow_success",
+		"provider":          credential.Provider,
+		"tool_calls":        len(toolCallsMade),
+		"execution_time_ms": response.ExecutionTimeMs,
+		"total_tokens":      usage.TotalTokens,
+	}).Info("工具调用工作流执行成功")
+
+	return response, nil
+}
+
+// ExecuteStream 流式执行工具调用工作流
+// 工具调用需要完整的模型输出才能继续，因此在得到最终回答后一次性推送
+func (w *ToolCallingWorkflow) ExecuteStream(ctx context.Context, req *WorkflowRequest) (<-chan *WorkflowStreamResponse, error) {
+	responseChan := make(chan *WorkflowStreamResponse, 10)
+
+	go func() {
+		defer close(responseChan)
+
+		response, err := w.Execute(ctx, req)
+		if err != nil {
+			responseChan <- &WorkflowStreamResponse{
+				Type:  StreamEventError,
+				Error: err.Error(),
+			}
+			return
+		}
+
+		responseChan <- &WorkflowStreamResponse{
+			Type:        StreamEventChunk,
+			ExecutionID: req.ExecutionID,
+			Content:     response.Content,
+			Data: map[string]any{
+				"delta": response.Content,
+			},
+		}
+
+		responseChan <- &WorkflowStreamResponse{
+			Type:        StreamEventEnd,
+			ExecutionID: req.ExecutionID,
+			Data: map[string]any{
+				"final_content": response.Content,
+				"provider":      response.Metadata["provider"],
+				"credential_id": response.Metadata["credential_id"],
+				"model":         response.Model,
+				"tool_calls":    response.Metadata["tool_calls"],
+				"finish_reason": response.FinishReason,
+				"usage": map[string]int{
+					"prompt_tokens":     response.Usage.PromptTokens,
+					"completion_tokens": response.Usage.CompletionTokens,
+					"total_tokens":      response.Usage.TotalTokens,
+				},
+			},
+		}
+	}()
+
+	return responseChan, nil
+}
+
+// GetInfo 获取工作流信息
+func (w *ToolCallingWorkflow) GetInfo() *WorkflowInfo {
+	return &WorkflowInfo{
+		Name:        "tool_calling",
+		DisplayName: "工具调用对话",
+		Description: "根据租户工具配置向模型暴露计算器、网页抓取等工具，由模型按需调用后给出最终回答",
+		Version:     "1.0.0",
+		Type:        "tool",
+		Parameters: []WorkflowParameter{
+			{
+				Name:        "message",
+				Type:        "string",
+				Required:    true,
+				Description: "用户输入的消息",
+			},
+			{
+				Name:        "provider",
+				Type:        "string",
+				Required:    false,
+				Description: "AI供应商（openai、deepseek、ark等）",
+				Default:     "openai",
+			},
+		},
+		SupportedFeatures: []string{
+			"tool_calling",
+			"multi_provider",
+		},
+		Nodes: []WorkflowNodeInfo{
+			{
+				Name:        "tool_loader",
+				Type:        "config",
+				Description: "从租户服务加载已启用的工具配置",
+				Required:    true,
+			},
+			{
+				Name:        "chat_model",
+				Type:        "chat_model",
+				Description: "生成回答或发起工具调用",
+				Required:    true,
+			},
+			{
+				Name:        "tool_executor",
+				Type:        "tool",
+				Description: "执行模型发起的工具调用并回传结果",
+				Required:    false,
+			},
+		},
+		RequiredInputs: []string{"message", "tenant_id", "user_id", "request_id", "execution_id"},
+		OutputSchema: map[string]interface{}{
+			"success":           "boolean",
+			"content":           "string",
+			"model":             "string",
+			"workflow_type":     "string",
+			"execution_time_ms": "integer",
+			"usage": map[string]interface{}{
+				"prompt_tokens":     "integer",
+				"completion_tokens": "integer",
+				"total_tokens":      "integer",
+			},
+			"metadata": "object",
+		},
+	}
+}
+
+// loadEnabledTools 加载租户为该工作流启用的工具
+// 获取配置失败的工具视为未启用
+func (w *ToolCallingWorkflow) loadEnabledTools(ctx context.Context, req *WorkflowRequest) map[string]*enabledTool {
+	enabled := make(map[string]*enabledTool)
+
+	for name, tool := range w.tools {
+		toolConfig, err := w.tenantClient.GetToolConfig(ctx, req.TenantID, "tool_calling", name)
+		if err != nil {
+			w.logger.WithFields(logrus.Fields{
+				"request_id": req.RequestID,
+				"tenant_id":  req.TenantID,
+				"tool_name":  name,
+				"operation":  "tool_config_failed",
+				"error":      err.Error(),
+			}).Warn("获取工具配置失败，跳过该工具")
+			continue
+		}
+		if !toolConfig.IsEnabled {
+			continue
+		}
+
+		params := toolConfig.ConfigParams
+		if params == nil {
+			params = make(map[string]interface{})
+		}
+		enabled[name] = &enabledTool{tool: tool, params: params}
+	}
+
+	return enabled
+}
+
+// invokeTool 执行单个工具调用，错误信息作为工具结果回传给模型
+func (w *ToolCallingWorkflow) invokeTool(ctx context.Context, req *WorkflowRequest, enabled map[string]*enabledTool, call schema.ToolCall) string {
+	item, exists := enabled[call.Function.Name]
+	if !exists {
+		return fmt.Sprintf("工具 %s 不可用", call.Function.Name)
+	}
+
+	output, err := item.tool.Invoke(ctx, call.Function.Arguments, item.params)
+
+	fields := logrus.Fields{
+		"request_id":   req.RequestID,
+		"execution_id": req.ExecutionID,
+		"tenant_id":    req.TenantID,
+		"tool_name":    call.Function.Name,
+		"operation":    "tool_invoke",
+	}
+	if err != nil {
+		w.logger.WithFields(fields).WithError(err).Warn("工具调用失败")
+		return fmt.Sprintf("工具调用失败: %v", err)
+	}
+
+	w.logger.WithFields(fields).Debug("工具调用成功")
+	return output
+}
+
+// enabledToolNames 获取已启用的工具名称列表
+func (w *ToolCallingWorkflow) enabledToolNames(enabled map[string]*enabledTool) []string {
+	names := make([]string, 0, len(enabled))
+	for name := range enabled {
+		names = append(names, name)
+	}
+	return names
+}
+
+// getProvider 获取请求指定的供应商
+func (w *ToolCallingWorkflow) getProvider(req *WorkflowRequest) string {
+	if req.ModelConfig != nil {
+		if provider, ok := req.ModelConfig["provider"].(string); ok && provider != "" {
+			return provider
+		}
+	}
+	return "openai"
+}
+
+// buildErrorResponse 构建错误响应
+func (w *ToolCallingWorkflow) buildErrorResponse(startTime time.Time, message string, err error) (*WorkflowResponse, error) {
+	w.logger.WithError(err).Error(message)
+
+	return &WorkflowResponse{
+		Success:         false,
+		ErrorMessage:    message,
+		ExecutionTimeMs: time.Since(startTime).Milliseconds(),
+		WorkflowType:    "tool_calling",
+	}, err
+}
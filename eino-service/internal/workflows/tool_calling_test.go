@@ -0,0 +1,160 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/internal/testutil/credentialtest"
+	"lyss-ai-platform/eino-service/internal/workflows/tools"
+)
+
+// stubTool 记录调用参数并返回固定结果的工具替身
+type stubTool struct {
+	mutex     sync.Mutex
+	arguments []string
+	params    []map[string]interface{}
+}
+
+func (t *stubTool) Info() *schema.ToolInfo {
+	return &schema.ToolInfo{
+		Name: "lookup_weather",
+		Desc: "查询城市天气",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"city": {Type: schema.String, Desc: "城市名称", Required: true},
+		}),
+	}
+}
+
+func (t *stubTool) Invoke(ctx context.Context, arguments string, params map[string]interface{}) (string, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.arguments = append(t.arguments, arguments)
+	t.params = append(t.params, params)
+	return "晴，25度", nil
+}
+
+// toolModelRequest OpenAI兼容请求中与工具调用相关的字段
+type toolModelRequest struct {
+	Messages []struct {
+		Role       string `json:"role"`
+		Content    string `json:"content"`
+		ToolCallID string `json:"tool_call_id"`
+	} `json:"messages"`
+	Tools []struct {
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	} `json:"tools"`
+}
+
+// newToolModelStub 启动OpenAI兼容的供应商替身：首次请求要求调用 lookup_weather，收到工具结果后给出最终回答
+func newToolModelStub(t *testing.T, received *[]toolModelRequest) *httptest.Server {
+	t.Helper()
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body toolModelRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		mutex.Lock()
+		*received = append(*received, body)
+		mutex.Unlock()
+
+		message := map[string]interface{}{"role": "assistant", "content": "上海今天晴，25度。"}
+		finishReason := "stop"
+		if last := body.Messages[len(body.Messages)-1]; last.Role != "tool" {
+			message = map[string]interface{}{
+				"role":    "assistant",
+				"content": "",
+				"tool_calls": []map[string]interface{}{{
+					"id":       "call-1",
+					"type":     "function",
+					"function": map[string]string{"name": "lookup_weather", "arguments": `{"city":"上海"}`},
+				}},
+			}
+			finishReason = "tool_calls"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":      "chatcmpl-1",
+			"object":  "chat.completion",
+			"model":   "gpt-4o-mini",
+			"choices": []map[string]interface{}{{"index": 0, "message": message, "finish_reason": finishReason}},
+			"usage":   map[string]int{"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestToolCallingWorkflowDrivesStubTool(t *testing.T) {
+	var received []toolModelRequest
+	provider := newToolModelStub(t, &received)
+
+	tenantService := testutil.NewTenantService(t)
+	tenantService.SetCredentials("tenant-1", testutil.Credential("openai", provider.URL))
+	tenantService.SetToolConfig("tenant-1", "tool_calling", "lookup_weather", &models.ToolConfig{
+		IsEnabled:    true,
+		ConfigParams: map[string]interface{}{"unit": "celsius"},
+	})
+	tenantService.SetToolConfig("tenant-1", "tool_calling", "calculator", &models.ToolConfig{IsEnabled: false})
+
+	tool := &stubTool{}
+	workflow := NewToolCallingWorkflow(credentialtest.NewManager(t, tenantService), tenantService.Client(), testutil.Logger())
+	workflow.tools = map[string]tools.Tool{
+		"lookup_weather": tool,
+		"calculator":     tools.NewCalculatorTool(),
+	}
+
+	resp, err := workflow.Execute(context.Background(), &WorkflowRequest{
+		RequestID:   "req-1",
+		ExecutionID: "exec-1",
+		TenantID:    "tenant-1",
+		UserID:      "user-1",
+		Message:     "上海天气怎么样？",
+		ModelConfig: map[string]interface{}{"provider": "openai"},
+	})
+	if err != nil {
+		t.Fatalf("Execute 返回错误: %v", err)
+	}
+	if !resp.Success || resp.Content != "上海今天晴，25度。" {
+		t.Fatalf("响应 = %+v，期望最终回答", resp)
+	}
+	if got := resp.Metadata["tool_calls"]; !reflect.DeepEqual(got, []string{"lookup_weather"}) {
+		t.Fatalf("tool_calls = %v，期望 [lookup_weather]", got)
+	}
+	if got := resp.Metadata["tools_enabled"]; !reflect.DeepEqual(got, []string{"lookup_weather"}) {
+		t.Fatalf("tools_enabled = %v，期望只包含已启用的工具", got)
+	}
+	if resp.Usage.TotalTokens != 30 {
+		t.Fatalf("TotalTokens = %d，期望两轮合计 30", resp.Usage.TotalTokens)
+	}
+
+	// 工具收到模型生成的参数与租户配置参数
+	if !reflect.DeepEqual(tool.arguments, []string{`{"city":"上海"}`}) {
+		t.Fatalf("工具参数 = %v", tool.arguments)
+	}
+	if len(tool.params) != 1 || tool.params[0]["unit"] != "celsius" {
+		t.Fatalf("工具配置参数 = %v，期望 unit=celsius", tool.params)
+	}
+
+	// 首轮只暴露已启用的工具，第二轮携带工具结果
+	if len(received) != 2 {
+		t.Fatalf("模型调用次数 = %d，期望 2", len(received))
+	}
+	if len(received[0].Tools) != 1 || received[0].Tools[0].Function.Name != "lookup_weather" {
+		t.Fatalf("首轮工具 = %+v，期望仅 lookup_weather", received[0].Tools)
+	}
+	last := received[1].Messages[len(received[1].Messages)-1]
+	if last.Role != "tool" || last.Content != "晴，25度" || last.ToolCallID != "call-1" {
+		t.Fatalf("第二轮最后一条消息 = %+v，期望工具结果", last)
+	}
+}
@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"strconv"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// CalculatorTool 四则运算计算器工具
+type CalculatorTool struct{}
+
+// NewCalculatorTool 创建计算器工具
+func NewCalculatorTool() *CalculatorTool {
+	return &CalculatorTool{}
+}
+
+// calculatorArguments 计算器调用参数
+type calculatorArguments struct {
+	Expression string `json:"expression"`
+}
+
+// Info 返回计算器的函数定义
+func (t *CalculatorTool) Info() *schema.ToolInfo {
+	return &schema.ToolInfo{
+		Name: "calculator",
+		Desc: "计算数学表达式，支持 + - * / % 和括号，例如 (1 + 2) * 3.5",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"expression": {
+				Type:     schema.String,
+				Desc:     "需要计算的数学表达式",
+				Required: true,
+			},
+		}),
+	}
+}
+
+// Invoke 计算表达式
+// 支持的配置参数：precision（结果保留的小数位数，默认6）
+func (t *CalculatorTool) Invoke(ctx context.Context, arguments string, params map[string]interface{}) (string, error) {
+	var args calculatorArguments
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", fmt.Errorf("解析计算器参数失败: %w", err)
+	}
+	if args.Expression == "" {
+		return "", fmt.Errorf("表达式不能为空")
+	}
+
+	expr, err := parser.ParseExpr(args.Expression)
+	if err != nil {
+		return "", fmt.Errorf("表达式格式错误: %w", err)
+	}
+
+	result, err := evaluate(expr)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.FormatFloat(result, 'f', intParam(params, "precision", 6), 64), nil
+}
+
+// evaluate 递归计算表达式语法树
+func evaluate(expr ast.Expr) (float64, error) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.INT && e.Kind != token.FLOAT {
+			return 0, fmt.Errorf("不支持的字面量: %s", e.Value)
+		}
+		return strconv.ParseFloat(e.Value, 64)
+	case *ast.ParenExpr:
+		return evaluate(e.X)
+	case *ast.UnaryExpr:
+		value, err := evaluate(e.X)
+		if err != nil {
+			return 0, err
+		}
+		switch e.Op {
+		case token.SUB:
+			return -value, nil
+		case token.ADD:
+			return value, nil
+		}
+		return 0, fmt.Errorf("不支持的运算符: %s", e.Op)
+	case *ast.BinaryExpr:
+		left, err := evaluate(e.X)
+		if err != nil {
+			return 0, err
+		}
+		right, err := evaluate(e.Y)
+		if err != nil {
+			return 0, err
+		}
+		switch e.Op {
+		case token.ADD:
+			return left + right, nil
+		case token.SUB:
+			return left - right, nil
+		case token.MUL:
+			return left * right, nil
+		case token.QUO:
+			if right == 0 {
+				return 0, fmt.Errorf("除数不能为零")
+			}
+			return left / right, nil
+		case token.REM:
+			if right == 0 {
+				return 0, fmt.Errorf("除数不能为零")
+			}
+			return math.Mod(left, right), nil
+		}
+		return 0, fmt.Errorf("不支持的运算符: %s", e.Op)
+	default:
+		return 0, fmt.Errorf("不支持的表达式")
+	}
+}
@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCalculatorInvoke(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments string
+		params    map[string]interface{}
+		want      string
+		wantErr   bool
+	}{
+		{name: "四则运算与括号", arguments: `{"expression":"(1 + 2) * 3.5"}`, params: map[string]interface{}{"precision": 1}, want: "10.5"},
+		{name: "默认保留6位小数", arguments: `{"expression":"10 / 4"}`, want: "2.500000"},
+		{name: "precision为0时使用默认值", arguments: `{"expression":"-7 % 3"}`, params: map[string]interface{}{"precision": float64(0)}, want: "-1.000000"},
+		{name: "除数为零", arguments: `{"expression":"1 / 0"}`, wantErr: true},
+		{name: "不支持的表达式", arguments: `{"expression":"os.Exit(1)"}`, wantErr: true},
+		{name: "表达式为空", arguments: `{}`, wantErr: true},
+		{name: "参数不是JSON", arguments: `1+1`, wantErr: true},
+	}
+
+	tool := NewCalculatorTool()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tool.Invoke(context.Background(), tt.arguments, tt.params)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("期望返回错误，结果 %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Invoke 返回错误: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("结果 = %s，期望 %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// HTTP抓取工具默认配置
+const (
+	defaultFetchTimeoutSeconds = 10
+	defaultFetchMaxBytes       = 8192
+	maxFetchRedirects          = 5
+)
+
+// HTTPFetchTool 抓取网页内容的工具
+// 仅允许访问租户配置的域名，且拒绝连接回环、链路本地和内网地址，防止SSRF
+type HTTPFetchTool struct {
+	transport *http.Transport
+
+	// ipAllowed 判断拨号目标IP是否允许访问，测试中可替换
+	ipAllowed func(ip netip.Addr) bool
+}
+
+// NewHTTPFetchTool 创建HTTP抓取工具
+func NewHTTPFetchTool() *HTTPFetchTool {
+	return newHTTPFetchTool(isPublicIP)
+}
+
+// newHTTPFetchTool 使用指定的IP校验函数创建HTTP抓取工具
+func newHTTPFetchTool(ipAllowed func(ip netip.Addr) bool) *HTTPFetchTool {
+	t := &HTTPFetchTool{ipAllowed: ipAllowed}
+
+	// 在拨号阶段校验解析后的IP，避免DNS重绑定绕过检查
+	dialer := &net.Dialer{
+		Timeout: defaultFetchTimeoutSeconds * time.Second,
+		Control: t.dialControl,
+	}
+	// 不使用环境变量中的代理，否则实际连接的是代理地址，IP校验将失效
+	t.transport = &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: defaultFetchTimeoutSeconds * time.Second,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return t
+}
+
+// httpFetchArguments HTTP抓取调用参数
+type httpFetchArguments struct {
+	URL string `json:"url"`
+}
+
+// Info 返回HTTP抓取的函数定义
+func (t *HTTPFetchTool) Info() *schema.ToolInfo {
+	return &schema.ToolInfo{
+		Name: "http_fetch",
+		Desc: "通过HTTP GET获取指定URL的文本内容",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"url": {
+				Type:     schema.String,
+				Desc:     "需要获取的完整URL，仅支持 http 和 https",
+				Required: true,
+			},
+		}),
+	}
+}
+
+// Invoke 抓取URL内容
+// 支持的配置参数：allowed_domains（允许访问的域名列表，必填）、timeout_seconds、max_bytes
+func (t *HTTPFetchTool) Invoke(ctx context.Context, arguments string, params map[string]interface{}) (string, error) {
+	var args httpFetchArguments
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", fmt.Errorf("解析抓取参数失败: %w", err)
+	}
+
+	// 未配置允许访问的域名时拒绝抓取
+	allowed := stringSliceParam(params, "allowed_domains")
+	if len(allowed) == 0 {
+		return "", fmt.Errorf("未配置 allowed_domains，拒绝抓取")
+	}
+
+	target, err := url.Parse(args.URL)
+	if err != nil {
+		return "", fmt.Errorf("无效的URL: %s", args.URL)
+	}
+	if err := checkFetchURL(target, allowed); err != nil {
+		return "", err
+	}
+
+	timeout := time.Duration(intParam(params, "timeout_seconds", defaultFetchTimeoutSeconds)) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", target.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	// 每次重定向都重新校验域名，IP由拨号阶段校验
+	client := &http.Client{
+		Transport: t.transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchRedirects {
+				return fmt.Errorf("重定向次数超过 %d 次", maxFetchRedirects)
+			}
+			return checkFetchURL(req.URL, allowed)
+		},
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("HTTP请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(intParam(params, "max_bytes", defaultFetchMaxBytes))))
+	if err != nil {
+		return "", fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	return fmt.Sprintf("HTTP %d\n%s", resp.StatusCode, string(body)), nil
+}
+
+// dialControl 在建立连接前校验目标IP
+func (t *HTTPFetchTool) dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("无效的连接地址: %s", address)
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil || !t.ipAllowed(ip) {
+		return fmt.Errorf("禁止访问地址 %s", host)
+	}
+	return nil
+}
+
+// checkFetchURL 校验URL的协议和域名
+func checkFetchURL(target *url.URL, allowed []string) error {
+	if (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("无效的URL: %s", target.String())
+	}
+	if !isDomainAllowed(target.Hostname(), allowed) {
+		return fmt.Errorf("域名 %s 不在允许访问的列表中", target.Hostname())
+	}
+	return nil
+}
+
+// deniedPrefixes 禁止访问的网段：本机、内网、运营商级NAT、链路本地（含云厂商元数据地址）、
+// 协议保留、基准测试、文档、组播及保留地址
+var deniedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// nat64Prefix NAT64 知名前缀，低32位为内嵌的IPv4地址
+var nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+
+// isPublicIP 判断IP是否为可公开访问的地址
+// IPv4映射地址和NAT64地址按内嵌的IPv4地址判断
+func isPublicIP(ip netip.Addr) bool {
+	ip = ip.WithZone("").Unmap()
+	if nat64Prefix.Contains(ip) {
+		b := ip.As16()
+		ip = netip.AddrFrom4([4]byte{b[12], b[13], b[14], b[15]})
+	}
+	if !ip.IsValid() {
+		return false
+	}
+	for _, prefix := range deniedPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// isDomainAllowed 判断域名是否在允许列表中，支持子域名匹配
+func isDomainAllowed(host string, allowed []string) bool {
+	host = strings.ToLower(host)
+	for _, domain := range allowed {
+		domain = strings.ToLower(domain)
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// allowAllIPs 测试中允许连接本地 httptest 服务
+func allowAllIPs(netip.Addr) bool { return true }
+
+func TestHTTPFetchInvoke(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		switch r.URL.Path {
+		case "/redirect":
+			// 重定向到 localhost，与允许列表中的 127.0.0.1 不是同一域名
+			target, _ := url.Parse("http://" + r.Host)
+			http.Redirect(w, r, "http://localhost:"+target.Port()+"/ok", http.StatusFound)
+		default:
+			w.Write([]byte("hello world"))
+		}
+	}))
+	defer server.Close()
+
+	allowed := map[string]interface{}{"allowed_domains": []interface{}{"127.0.0.1"}}
+	tests := []struct {
+		name     string
+		path     string
+		params   map[string]interface{}
+		want     string
+		wantErr  string
+		wantHits int
+	}{
+		{name: "正常抓取", path: "/ok", params: allowed, want: "HTTP 200\nhello world", wantHits: 1},
+		{
+			name:     "max_bytes截断响应",
+			path:     "/ok",
+			params:   map[string]interface{}{"allowed_domains": []interface{}{"127.0.0.1"}, "max_bytes": float64(5)},
+			want:     "HTTP 200\nhello",
+			wantHits: 1,
+		},
+		{name: "未配置allowed_domains时拒绝", path: "/ok", wantErr: "allowed_domains"},
+		{
+			name:    "域名不在允许列表中",
+			path:    "/ok",
+			params:  map[string]interface{}{"allowed_domains": []interface{}{"example.com"}},
+			wantErr: "不在允许访问的列表中",
+		},
+		{name: "重定向到允许列表之外", path: "/redirect", params: allowed, wantErr: "不在允许访问的列表中", wantHits: 1},
+	}
+
+	tool := newHTTPFetchTool(allowAllIPs)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits = 0
+			got, err := tool.Invoke(context.Background(), `{"url":"`+server.URL+tt.path+`"}`, tt.params)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误 = %v，期望包含 %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Invoke 返回错误: %v", err)
+			} else if got != tt.want {
+				t.Fatalf("结果 = %q，期望 %q", got, tt.want)
+			}
+			if hits != tt.wantHits {
+				t.Fatalf("服务端收到 %d 次请求，期望 %d 次", hits, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestHTTPFetchRejectsPrivateIP(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer server.Close()
+
+	// 即使域名在允许列表中，回环地址也应在拨号阶段被拒绝
+	params := map[string]interface{}{"allowed_domains": []interface{}{"127.0.0.1"}}
+	_, err := NewHTTPFetchTool().Invoke(context.Background(), `{"url":"`+server.URL+`"}`, params)
+	if err == nil || !strings.Contains(err.Error(), "禁止访问地址") {
+		t.Fatalf("错误 = %v，期望拒绝回环地址", err)
+	}
+	if hits != 0 {
+		t.Fatalf("服务端收到 %d 次请求，期望 0 次", hits)
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "93.184.216.34", want: true},
+		{ip: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{ip: "127.0.0.1", want: false},
+		{ip: "169.254.169.254", want: false},
+		{ip: "10.0.0.1", want: false},
+		{ip: "172.16.5.4", want: false},
+		{ip: "192.168.1.1", want: false},
+		{ip: "0.0.0.0", want: false},
+		{ip: "0.1.2.3", want: false},
+		{ip: "100.64.0.1", want: false},
+		{ip: "100.100.100.200", want: false},
+		{ip: "100.127.255.255", want: false},
+		{ip: "100.128.0.1", want: true},
+		{ip: "198.18.0.1", want: false},
+		{ip: "198.19.255.255", want: false},
+		{ip: "192.0.0.8", want: false},
+		{ip: "224.0.0.1", want: false},
+		{ip: "255.255.255.255", want: false},
+		{ip: "::", want: false},
+		{ip: "::1", want: false},
+		{ip: "fd00::1", want: false},
+		{ip: "fe80::1", want: false},
+		{ip: "fe80::1%eth0", want: false},
+		{ip: "::ffff:127.0.0.1", want: false},
+		{ip: "::ffff:169.254.169.254", want: false},
+		{ip: "::ffff:100.100.100.200", want: false},
+		{ip: "::ffff:93.184.216.34", want: true},
+		{ip: "64:ff9b::a9fe:a9fe", want: false},
+		{ip: "64:ff9b::7f00:1", want: false},
+		{ip: "64:ff9b::5db8:d822", want: true},
+		{ip: "64:ff9b:1::1", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isPublicIP(netip.MustParseAddr(tt.ip)); got != tt.want {
+			t.Errorf("isPublicIP(%s) = %v，期望 %v", tt.ip, got, tt.want)
+		}
+	}
+}
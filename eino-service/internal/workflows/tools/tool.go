@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// Tool 可供模型调用的内置工具
+type Tool interface {
+	// Info 返回暴露给模型的函数定义
+	Info() *schema.ToolInfo
+
+	// Invoke 执行工具调用
+	// arguments 为模型生成的JSON参数，params 为租户工具配置中的 config_params
+	Invoke(ctx context.Context, arguments string, params map[string]interface{}) (string, error)
+}
+
+// Builtin 返回所有内置工具，按工具名称索引
+func Builtin() map[string]Tool {
+	builtin := []Tool{
+		NewCalculatorTool(),
+		NewHTTPFetchTool(),
+	}
+
+	tools := make(map[string]Tool, len(builtin))
+	for _, tool := range builtin {
+		tools[tool.Info().Name] = tool
+	}
+	return tools
+}
+
+// intParam 读取整数类型的配置参数
+func intParam(params map[string]interface{}, key string, defaultValue int) int {
+	switch value := params[key].(type) {
+	case int:
+		if value > 0 {
+			return value
+		}
+	case float64:
+		if value > 0 {
+			return int(value)
+		}
+	}
+	return defaultValue
+}
+
+// stringSliceParam 读取字符串数组类型的配置参数
+func stringSliceParam(params map[string]interface{}, key string) []string {
+	switch value := params[key].(type) {
+	case []string:
+		return value
+	case []interface{}:
+		result := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok && s != "" {
+				result = append(result, s)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
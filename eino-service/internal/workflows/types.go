@@ -2,6 +2,8 @@ package workflows
 
 import (
 	"context"
+
+	"lyss-ai-platform/eino-service/internal/models"
 )
 
 // WorkflowEngine 工作流引擎接口
@@ -25,11 +27,34 @@ type WorkflowRequest struct {
 	WorkflowType  string                 `json:"workflow_type"`
 	Message       string                 `json:"message"`
 	Model         string                 `json:"model"`
-	Temperature   float64                `json:"temperature"`
+	Temperature   *float64               `json:"temperature,omitempty"` // 未设置时为nil，显式的0表示确定性输出
 	MaxTokens     int                    `json:"max_tokens"`
 	ModelConfig   map[string]interface{} `json:"model_config"`
 	Configuration map[string]interface{} `json:"configuration"`
 	Stream        bool                   `json:"stream"`
+	Seed          *int                   `json:"seed,omitempty"`
+	TimeoutMs     int                    `json:"timeout_ms,omitempty"` // 缩短本次执行的超时时间，不超过配置的 execution_timeout
+
+	// ContentParts 当前用户消息的多模态内容，支持视觉的供应商使用，其他供应商退化为 Message 文本
+	ContentParts []models.ContentPart `json:"content_parts,omitempty"`
+
+	// ResponseFormat 结构化输出格式，JSON模式下校验模型输出并在无效时重试一次
+	ResponseFormat *models.ResponseFormat `json:"response_format,omitempty"`
+
+	// WorkflowVersion 目标工作流版本，为空时使用最新版本；校验通过后写入实际执行的版本
+	WorkflowVersion string `json:"workflow_version,omitempty"`
+
+	// Requires 按能力选择模型时要求具备的能力，如 vision、tools、json_mode
+	Requires []string `json:"requires,omitempty"`
+
+	// Optimize 按能力选择模型时的优化目标，cost 表示选择单价最低的模型
+	Optimize string `json:"optimize,omitempty"`
+
+	// CredentialStrategy 本次请求的凭证选择策略，为空时使用 workflows.default_strategy
+	CredentialStrategy string `json:"credential_strategy,omitempty"`
+
+	// DebugTrace 请求携带调试头，开启调试头记录时记录本次的完整提示词与回答
+	DebugTrace bool `json:"-"`
 }
 
 // WorkflowResponse 工作流响应
@@ -42,6 +67,7 @@ type WorkflowResponse struct {
 	Status          string                 `json:"status"`
 	ExecutionTimeMs int64                  `json:"execution_time_ms"`
 	Usage           *TokenUsage            `json:"usage"`
+	FinishReason    string                 `json:"finish_reason,omitempty"` // stop、length 等，length 表示输出被 max_tokens 截断
 	Metadata        map[string]interface{} `json:"metadata"`
 	ErrorMessage    string                 `json:"error_message,omitempty"`
 }
@@ -65,6 +91,9 @@ type WorkflowInfo struct {
 	Nodes             []WorkflowNodeInfo   `json:"nodes"`
 	RequiredInputs    []string             `json:"required_inputs"`
 	OutputSchema      map[string]interface{} `json:"output_schema"`
+
+	// Versions 同名工作流已注册的全部版本，按版本号升序
+	Versions []string `json:"versions,omitempty"`
 }
 
 // WorkflowParameter 工作流参数
@@ -94,9 +123,11 @@ type WorkflowExecutionContext struct {
 	State         map[string]interface{} `json:"state"`
 	Configuration map[string]interface{} `json:"configuration"`
 	Steps         []WorkflowStep         `json:"steps"`
+	TotalSteps    int                    `json:"total_steps"` // 工作流声明的计划步骤数，0 表示未声明
 	StartTime     int64                  `json:"start_time"`
 	EndTime       int64                  `json:"end_time"`
 	Status        string                 `json:"status"`
+	CancelReason  string                 `json:"cancel_reason,omitempty"` // 状态为 cancelled 时的取消原因
 }
 
 // WorkflowStep 工作流步骤
@@ -118,10 +149,10 @@ type WorkflowRegistry interface {
 	// RegisterWorkflow 注册工作流
 	RegisterWorkflow(name string, workflow WorkflowEngine) error
 	
-	// GetWorkflow 获取工作流
+	// GetWorkflow 获取工作流，name 为名称或 name@version，只给名称时返回最新版本
 	GetWorkflow(name string) (WorkflowEngine, error)
 	
-	// ListWorkflows 列出所有工作流
+	// ListWorkflows 列出所有工作流，同名的多个版本合并为一条
 	ListWorkflows() []WorkflowInfo
 	
 	// IsWorkflowRegistered 检查工作流是否已注册
@@ -133,10 +164,10 @@ type WorkflowRegistry interface {
 	// GetWorkflowNames 获取所有工作流名称
 	GetWorkflowNames() []string
 	
-	// GetWorkflowInfo 获取工作流信息
+	// GetWorkflowInfo 获取工作流信息，name 为名称或 name@version
 	GetWorkflowInfo(name string) (*WorkflowInfo, error)
 	
-	// UnregisterWorkflow 取消注册工作流
+	// UnregisterWorkflow 取消注册工作流，name 为 name@version 时只取消该版本
 	UnregisterWorkflow(name string) error
 }
 
@@ -155,9 +186,17 @@ type WorkflowExecutor interface {
 	CancelExecution(executionID string) error
 }
 
+// 流式事件类型，工作流、执行器与处理器共用
+const (
+	StreamEventStart = "start" // 开始执行
+	StreamEventChunk = "chunk" // 增量内容，Data 中携带 delta 与累计的 content
+	StreamEventEnd   = "end"   // 执行完成，Data 中携带 usage 与 finish_reason
+	StreamEventError = "error" // 执行失败
+)
+
 // WorkflowStreamResponse 工作流流式响应
 type WorkflowStreamResponse struct {
-	Type        string         `json:"type"`        // "start", "chunk", "end", "error"
+	Type        string         `json:"type"`        // StreamEventStart、StreamEventChunk、StreamEventEnd、StreamEventError
 	ExecutionID string         `json:"execution_id"`
 	Content     string         `json:"content"` 
 	Data        map[string]any `json:"data"`
@@ -175,6 +214,7 @@ type WorkflowExecutionStatus struct {
 	EndTime         int64          `json:"end_time"`
 	ExecutionTimeMs int64          `json:"execution_time_ms"`
 	Error           string         `json:"error,omitempty"`
+	CancelReason    string         `json:"cancel_reason,omitempty"` // user、shutdown 或 client_disconnected
 }
 
 // WorkflowMetrics 工作流指标
@@ -184,6 +224,9 @@ type WorkflowMetrics struct {
 	FailedExecutions    int64 `json:"failed_executions"`
 	AverageExecutionTime int64 `json:"average_execution_time"`
 	TotalTokensUsed     int64 `json:"total_tokens_used"`
+
+	StartedAt     string `json:"started_at"`     // 服务进程启动时间
+	UptimeSeconds int64  `json:"uptime_seconds"` // 服务已运行秒数
 }
 
 // WorkflowEvent 工作流事件
@@ -0,0 +1,81 @@
+package workflows
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// builtinWorkflowVersion 内置工作流的版本
+const builtinWorkflowVersion = "1.0.0"
+
+// ErrUnknownWorkflowVersion 请求的工作流版本未注册
+var ErrUnknownWorkflowVersion = errors.New("工作流版本未注册")
+
+// ParseWorkflowRef 解析 name@version 形式的工作流引用，不带版本时 version 为空，表示最新版本
+func ParseWorkflowRef(ref string) (name, version string) {
+	if index := strings.LastIndex(ref, "@"); index >= 0 {
+		return ref[:index], ref[index+1:]
+	}
+	return ref, ""
+}
+
+// WorkflowRef 生成工作流引用，version 为空时只返回名称
+func WorkflowRef(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + "@" + version
+}
+
+// compareVersions 比较两个版本号，按 "." 分段逐段比较，数字段按数值比较，其他按字符串比较
+// 缺少的段视为0，因此 "2" 与 "2.0.0" 相等
+func compareVersions(a, b string) int {
+	left := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	right := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(left) || i < len(right); i++ {
+		x, y := "0", "0"
+		if i < len(left) {
+			x = left[i]
+		}
+		if i < len(right) {
+			y = right[i]
+		}
+
+		xn, xErr := strconv.Atoi(x)
+		yn, yErr := strconv.Atoi(y)
+		switch {
+		case xErr == nil && yErr == nil:
+			if xn != yn {
+				if xn < yn {
+					return -1
+				}
+				return 1
+			}
+		case x != y:
+			return strings.Compare(x, y)
+		}
+	}
+	return 0
+}
+
+// sortVersions 按版本号升序排列
+func sortVersions(versions []string) {
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) < 0
+	})
+}
+
+// matchVersion 在已注册版本中查找与请求版本相等的版本，"2" 可以匹配 "2.0.0"
+func matchVersion(versions map[string]WorkflowEngine, version string) (WorkflowEngine, bool) {
+	if workflow, ok := versions[version]; ok {
+		return workflow, true
+	}
+	for registered, workflow := range versions {
+		if compareVersions(registered, version) == 0 {
+			return workflow, true
+		}
+	}
+	return nil, false
+}
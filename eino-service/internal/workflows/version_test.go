@@ -0,0 +1,186 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+// versionedWorkflow 以版本号作为回答内容的工作流替身
+type versionedWorkflow struct {
+	name    string
+	version string
+}
+
+func (w *versionedWorkflow) Execute(ctx context.Context, req *WorkflowRequest) (*WorkflowResponse, error) {
+	return &WorkflowResponse{Success: true, Content: w.version}, nil
+}
+
+func (w *versionedWorkflow) ExecuteStream(ctx context.Context, req *WorkflowRequest) (<-chan *WorkflowStreamResponse, error) {
+	ch := make(chan *WorkflowStreamResponse, 1)
+	ch <- &WorkflowStreamResponse{Type: StreamEventEnd, Data: map[string]any{"final_content": w.version}}
+	close(ch)
+	return ch, nil
+}
+
+func (w *versionedWorkflow) GetInfo() *WorkflowInfo {
+	return &WorkflowInfo{Name: w.name, Version: w.version}
+}
+
+// newVersionedRegistry 创建注册了 echo@1.0.0 与 echo@2.0.0 的注册表
+func newVersionedRegistry(t *testing.T) *DefaultWorkflowRegistry {
+	t.Helper()
+	registry := NewDefaultWorkflowRegistry(testutil.Logger())
+	for _, version := range []string{"1.0.0", "2.0.0"} {
+		if err := registry.RegisterWorkflow("echo", &versionedWorkflow{name: "echo", version: version}); err != nil {
+			t.Fatalf("RegisterWorkflow(%s): %v", version, err)
+		}
+	}
+	return registry
+}
+
+func TestRegistryGetWorkflowByVersion(t *testing.T) {
+	registry := newVersionedRegistry(t)
+
+	tests := []struct {
+		ref            string
+		wantVersion    string
+		wantUnknownVer bool
+		wantErr        bool
+	}{
+		{ref: "echo", wantVersion: "2.0.0"},
+		{ref: "echo@1.0.0", wantVersion: "1.0.0"},
+		{ref: "echo@1", wantVersion: "1.0.0"},
+		{ref: "echo@v2.0", wantVersion: "2.0.0"},
+		{ref: "echo@3", wantUnknownVer: true},
+		{ref: "missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			workflow, err := registry.GetWorkflow(tt.ref)
+			switch {
+			case tt.wantUnknownVer:
+				if !errors.Is(err, ErrUnknownWorkflowVersion) {
+					t.Fatalf("错误 = %v，期望 ErrUnknownWorkflowVersion", err)
+				}
+			case tt.wantErr:
+				if err == nil || errors.Is(err, ErrUnknownWorkflowVersion) {
+					t.Fatalf("错误 = %v，期望工作流未注册", err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("GetWorkflow: %v", err)
+				}
+				if got := workflow.GetInfo().Version; got != tt.wantVersion {
+					t.Fatalf("版本 = %s，期望 %s", got, tt.wantVersion)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistryVersionLifecycle(t *testing.T) {
+	registry := newVersionedRegistry(t)
+
+	if err := registry.RegisterWorkflow("echo", &versionedWorkflow{name: "echo", version: "1"}); err == nil {
+		t.Fatal("同名同版本（1 与 1.0.0）不应重复注册")
+	}
+
+	workflows := registry.ListWorkflows()
+	if len(workflows) != 1 || workflows[0].Version != "2.0.0" {
+		t.Fatalf("ListWorkflows = %+v，期望一条最新版本的记录", workflows)
+	}
+	if want := []string{"1.0.0", "2.0.0"}; !reflect.DeepEqual(workflows[0].Versions, want) {
+		t.Fatalf("Versions = %v，期望 %v", workflows[0].Versions, want)
+	}
+	if registry.GetWorkflowCount() != 1 {
+		t.Fatalf("工作流数量 = %d，多个版本应计为一个", registry.GetWorkflowCount())
+	}
+
+	info, err := registry.GetWorkflowInfo("echo@1")
+	if err != nil || info.Version != "1.0.0" || len(info.Versions) != 2 {
+		t.Fatalf("GetWorkflowInfo(echo@1) = %+v, %v", info, err)
+	}
+
+	if err := registry.UnregisterWorkflow("echo@2"); err != nil {
+		t.Fatalf("UnregisterWorkflow(echo@2): %v", err)
+	}
+	if workflow, _ := registry.GetWorkflow("echo"); workflow.GetInfo().Version != "1.0.0" {
+		t.Fatalf("取消 2.0.0 后最新版本 = %s，期望 1.0.0", workflow.GetInfo().Version)
+	}
+	if err := registry.UnregisterWorkflow("echo@2"); !errors.Is(err, ErrUnknownWorkflowVersion) {
+		t.Fatalf("重复取消错误 = %v，期望 ErrUnknownWorkflowVersion", err)
+	}
+	if err := registry.UnregisterWorkflow("echo@1"); err != nil || registry.IsWorkflowRegistered("echo") {
+		t.Fatalf("取消最后一个版本后工作流仍注册: %v", err)
+	}
+}
+
+func TestExecutorRoutesByVersion(t *testing.T) {
+	executor := NewDefaultWorkflowExecutor(newVersionedRegistry(t), testutil.Logger(), 10, time.Minute, NewMetricsCollector())
+
+	tests := []struct {
+		name        string
+		version     string
+		wantContent string
+	}{
+		{name: "默认最新版本", wantContent: "2.0.0"},
+		{name: "指定旧版本", version: "1.0.0", wantContent: "1.0.0"},
+		{name: "指定新版本", version: "2.0.0", wantContent: "2.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &WorkflowRequest{
+				RequestID:       "req-1",
+				ExecutionID:     "exec-" + tt.wantContent + tt.version,
+				TenantID:        "tenant-1",
+				WorkflowType:    "echo",
+				WorkflowVersion: tt.version,
+				Message:         "你好",
+			}
+			resp, err := executor.Execute(context.Background(), req)
+			if err != nil || resp.Content != tt.wantContent {
+				t.Fatalf("Execute = %+v, %v，期望由 %s 处理", resp, err, tt.wantContent)
+			}
+			if tt.version != "" && resp.Metadata["workflow_version"] != tt.version {
+				t.Fatalf("metadata.workflow_version = %v，期望 %s", resp.Metadata["workflow_version"], tt.version)
+			}
+
+			stream, err := executor.ExecuteStream(context.Background(), req)
+			if err != nil {
+				t.Fatalf("ExecuteStream: %v", err)
+			}
+			if end := drain(stream); end.Data["final_content"] != tt.wantContent {
+				t.Fatalf("流式结束事件 = %+v，期望由 %s 处理", end, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.0.0", b: "1.0.0", want: 0},
+		{a: "2", b: "2.0.0", want: 0},
+		{a: "v1.2", b: "1.2.0", want: 0},
+		{a: "1.10.0", b: "1.9.0", want: 1},
+		{a: "1.0.0", b: "2.0.0", want: -1},
+		{a: "1.0.0-beta", b: "1.0.0-alpha", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			if got := compareVersions(tt.a, tt.b); got != tt.want {
+				t.Fatalf("compareVersions(%s, %s) = %d，期望 %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
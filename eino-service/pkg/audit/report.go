@@ -0,0 +1,152 @@
+package audit
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"lyss-ai-platform/eino-service/internal/config"
+)
+
+// UsageGroup 租户按供应商/模型（可选按天）汇总的用量
+type UsageGroup struct {
+	Day              string  `json:"day,omitempty"` // 按天分组时为 UTC 日期，格式 2006-01-02
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// UsageReport 租户用量报表
+type UsageReport struct {
+	TenantID         string        `json:"tenant_id"`
+	From             time.Time     `json:"from"`
+	To               time.Time     `json:"to"`
+	GroupByDay       bool          `json:"group_by_day"`
+	Groups           []*UsageGroup `json:"groups"`
+	Requests         int64         `json:"requests"`
+	PromptTokens     int64         `json:"prompt_tokens"`
+	CompletionTokens int64         `json:"completion_tokens"`
+	TotalTokens      int64         `json:"total_tokens"`
+	Cost             float64       `json:"cost"`
+}
+
+// Pricing 模型单价表，未配置单价的模型费用为0
+type Pricing map[string]config.ModelPricingConfig
+
+// NewPricing 根据配置创建模型单价表
+func NewPricing(prices []config.ModelPricingConfig) Pricing {
+	pricing := make(Pricing, len(prices))
+	for _, price := range prices {
+		pricing[price.Model] = price
+	}
+	return pricing
+}
+
+// Cost 计算模型调用的费用
+func (p Pricing) Cost(model string, promptTokens, completionTokens int64) float64 {
+	price, exists := p[model]
+	if !exists {
+		return 0
+	}
+	return float64(promptTokens)/1000*price.PromptPer1K + float64(completionTokens)/1000*price.CompletionPer1K
+}
+
+// TenantUsage 统计租户在 [from, to) 范围内按供应商与模型分组的用量，groupByDay 为 true 时再按 UTC 日期分组
+func (s *Store) TenantUsage(ctx context.Context, tenantID string, from, to time.Time, groupByDay bool) ([]*UsageGroup, error) {
+	columns := "provider, model"
+	if groupByDay {
+		columns = "TO_CHAR(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day, " + columns
+	}
+
+	var groups []*UsageGroup
+	err := s.db.WithContext(ctx).
+		Model(&UsageRecord{}).
+		Select(columns+", COUNT(*) AS requests, "+
+			"COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens, "+
+			"COALESCE(SUM(completion_tokens), 0) AS completion_tokens, "+
+			"COALESCE(SUM(total_tokens), 0) AS total_tokens").
+		Where("tenant_id = ? AND created_at >= ? AND created_at < ?", tenantID, from, to).
+		Group(groupColumns(groupByDay)).
+		Order(groupColumns(groupByDay)).
+		Scan(&groups).Error
+	if err != nil {
+		return nil, fmt.Errorf("统计租户用量失败: %w", err)
+	}
+
+	return groups, nil
+}
+
+// groupColumns 分组与排序字段
+func groupColumns(groupByDay bool) string {
+	if groupByDay {
+		return "day, provider, model"
+	}
+	return "provider, model"
+}
+
+// BuildUsageReport 根据分组用量计算费用并汇总合计
+func BuildUsageReport(tenantID string, from, to time.Time, groupByDay bool, groups []*UsageGroup, pricing Pricing) *UsageReport {
+	report := &UsageReport{
+		TenantID:   tenantID,
+		From:       from,
+		To:         to,
+		GroupByDay: groupByDay,
+		Groups:     make([]*UsageGroup, 0, len(groups)),
+	}
+
+	for _, group := range groups {
+		group.Cost = pricing.Cost(group.Model, group.PromptTokens, group.CompletionTokens)
+		report.Groups = append(report.Groups, group)
+		report.Requests += group.Requests
+		report.PromptTokens += group.PromptTokens
+		report.CompletionTokens += group.CompletionTokens
+		report.TotalTokens += group.TotalTokens
+		report.Cost += group.Cost
+	}
+
+	return report
+}
+
+// WriteCSV 以 CSV 格式输出报表，每个分组一行
+func (r *UsageReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"provider", "model", "requests", "prompt_tokens", "completion_tokens", "total_tokens", "cost"}
+	if r.GroupByDay {
+		header = append([]string{"day"}, header...)
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("写入用量报表表头失败: %w", err)
+	}
+
+	for _, group := range r.Groups {
+		row := []string{
+			group.Provider,
+			group.Model,
+			strconv.FormatInt(group.Requests, 10),
+			strconv.FormatInt(group.PromptTokens, 10),
+			strconv.FormatInt(group.CompletionTokens, 10),
+			strconv.FormatInt(group.TotalTokens, 10),
+			strconv.FormatFloat(group.Cost, 'f', 6, 64),
+		}
+		if r.GroupByDay {
+			row = append([]string{group.Day}, row...)
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("写入用量报表失败: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("写入用量报表失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"lyss-ai-platform/eino-service/internal/config"
+)
+
+// newTestStore 创建基于内存 SQLite 的审计存储
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开内存数据库失败: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	store := NewStore(db, logger)
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("迁移审计表失败: %v", err)
+	}
+	return store
+}
+
+var testPricing = NewPricing([]config.ModelPricingConfig{
+	{Model: "deepseek-chat", PromptPer1K: 0.001, CompletionPer1K: 0.002},
+	{Model: "gpt-4o", PromptPer1K: 0.005, CompletionPer1K: 0.015},
+})
+
+func TestTenantUsageAggregation(t *testing.T) {
+	store := newTestStore(t)
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 2)
+
+	records := []*UsageRecord{
+		{TenantID: "tenant-1", Provider: "deepseek", Model: "deepseek-chat", PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150, CreatedAt: from.Add(time.Hour)},
+		{TenantID: "tenant-1", Provider: "deepseek", Model: "deepseek-chat", PromptTokens: 200, CompletionTokens: 100, TotalTokens: 300, CreatedAt: from.Add(26 * time.Hour)},
+		{TenantID: "tenant-1", Provider: "openai", Model: "gpt-4o", PromptTokens: 1000, CompletionTokens: 1000, TotalTokens: 2000, CreatedAt: from.Add(2 * time.Hour)},
+		// 范围外与其他租户的记录不计入
+		{TenantID: "tenant-1", Provider: "openai", Model: "gpt-4o", PromptTokens: 9, CompletionTokens: 9, TotalTokens: 18, CreatedAt: to},
+		{TenantID: "tenant-1", Provider: "openai", Model: "gpt-4o", PromptTokens: 9, CompletionTokens: 9, TotalTokens: 18, CreatedAt: from.Add(-time.Second)},
+		{TenantID: "tenant-2", Provider: "deepseek", Model: "deepseek-chat", PromptTokens: 9, CompletionTokens: 9, TotalTokens: 18, CreatedAt: from.Add(time.Hour)},
+	}
+	for _, record := range records {
+		record.CredentialID = "credential-1"
+		if err := store.Record(context.Background(), record); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	groups, err := store.TenantUsage(context.Background(), "tenant-1", from, to, false)
+	if err != nil {
+		t.Fatalf("TenantUsage: %v", err)
+	}
+	report := BuildUsageReport("tenant-1", from, to, false, groups, testPricing)
+
+	want := []UsageGroup{
+		{Provider: "deepseek", Model: "deepseek-chat", Requests: 2, PromptTokens: 300, CompletionTokens: 150, TotalTokens: 450, Cost: 0.0006},
+		{Provider: "openai", Model: "gpt-4o", Requests: 1, PromptTokens: 1000, CompletionTokens: 1000, TotalTokens: 2000, Cost: 0.02},
+	}
+	if len(report.Groups) != len(want) {
+		t.Fatalf("分组数 = %d，期望 %d", len(report.Groups), len(want))
+	}
+	for i, group := range report.Groups {
+		got := *group
+		got.Cost = roundCost(got.Cost)
+		if got != want[i] {
+			t.Fatalf("第 %d 个分组 = %+v，期望 %+v", i, got, want[i])
+		}
+	}
+	if report.Requests != 3 || report.PromptTokens != 1300 || report.CompletionTokens != 1150 || report.TotalTokens != 2450 || roundCost(report.Cost) != 0.0206 {
+		t.Fatalf("合计 = %+v，期望 3 次请求、1300/1150/2450 令牌、费用 0.0206", report)
+	}
+}
+
+func TestBuildUsageReportPricing(t *testing.T) {
+	tests := []struct {
+		name     string
+		group    UsageGroup
+		wantCost float64
+	}{
+		{name: "按千令牌计价", group: UsageGroup{Model: "deepseek-chat", PromptTokens: 2000, CompletionTokens: 500}, wantCost: 0.003},
+		{name: "未配置单价的模型费用为0", group: UsageGroup{Model: "unknown-model", PromptTokens: 2000, CompletionTokens: 500}},
+		{name: "无用量", group: UsageGroup{Model: "gpt-4o"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := tt.group
+			report := BuildUsageReport("tenant-1", time.Time{}, time.Time{}, false, []*UsageGroup{&group}, testPricing)
+			if got := roundCost(report.Groups[0].Cost); got != tt.wantCost {
+				t.Fatalf("费用 = %v，期望 %v", got, tt.wantCost)
+			}
+			if roundCost(report.Cost) != tt.wantCost {
+				t.Fatalf("合计费用 = %v，期望 %v", report.Cost, tt.wantCost)
+			}
+		})
+	}
+}
+
+func TestUsageReportWriteCSV(t *testing.T) {
+	groups := func() []*UsageGroup {
+		return []*UsageGroup{
+			{Day: "2026-03-01", Provider: "deepseek", Model: "deepseek-chat", Requests: 2, PromptTokens: 300, CompletionTokens: 150, TotalTokens: 450},
+			{Day: "2026-03-02", Provider: "openai", Model: "gpt-4o, vision", Requests: 1, PromptTokens: 1000, CompletionTokens: 1000, TotalTokens: 2000},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		groupByDay bool
+		groups     []*UsageGroup
+		want       string
+	}{
+		{
+			name:   "按供应商与模型",
+			groups: groups(),
+			want: "provider,model,requests,prompt_tokens,completion_tokens,total_tokens,cost\n" +
+				"deepseek,deepseek-chat,2,300,150,450,0.000600\n" +
+				"openai,\"gpt-4o, vision\",1,1000,1000,2000,0.000000\n",
+		},
+		{
+			name:       "按天分组",
+			groupByDay: true,
+			groups:     groups(),
+			want: "day,provider,model,requests,prompt_tokens,completion_tokens,total_tokens,cost\n" +
+				"2026-03-01,deepseek,deepseek-chat,2,300,150,450,0.000600\n" +
+				"2026-03-02,openai,\"gpt-4o, vision\",1,1000,1000,2000,0.000000\n",
+		},
+		{
+			name: "无数据时只有表头",
+			want: "provider,model,requests,prompt_tokens,completion_tokens,total_tokens,cost\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := BuildUsageReport("tenant-1", time.Time{}, time.Time{}, tt.groupByDay, tt.groups, testPricing)
+			var buf bytes.Buffer
+			if err := report.WriteCSV(&buf); err != nil {
+				t.Fatalf("WriteCSV: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Fatalf("CSV =\n%s\n期望\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+// roundCost 消除浮点误差，保留6位小数
+func roundCost(cost float64) float64 {
+	return float64(int64(cost*1e6+0.5)) / 1e6
+}
@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// UsageRecord 凭证使用审计记录，每次成功的模型调用写入一行
+type UsageRecord struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	TenantID         string    `gorm:"size:64;not null;index" json:"tenant_id"`
+	CredentialID     string    `gorm:"size:64;not null;index:idx_credential_usage_credential_time,priority:1" json:"credential_id"`
+	Provider         string    `gorm:"size:32" json:"provider"`
+	Model            string    `gorm:"size:128" json:"model"`
+	WorkflowType     string    `gorm:"size:64" json:"workflow_type"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	Cost             float64   `json:"cost"` // 按写入时的模型单价计算
+	RequestID        string    `gorm:"size:64;index" json:"request_id"`
+	ExecutionID      string    `gorm:"size:64" json:"execution_id"`
+	CreatedAt        time.Time `gorm:"not null;index:idx_credential_usage_credential_time,priority:2" json:"created_at"`
+}
+
+// TableName 审计表名
+func (UsageRecord) TableName() string {
+	return "credential_usage_audit"
+}
+
+// CredentialTotals 凭证在时间范围内的用量合计
+type CredentialTotals struct {
+	CredentialID     string    `json:"credential_id"`
+	From             time.Time `json:"from"`
+	To               time.Time `json:"to"`
+	Requests         int64     `json:"requests"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	TotalTokens      int64     `json:"total_tokens"`
+	Cost             float64   `json:"cost"`
+}
+
+// Store 基于数据库的凭证使用审计
+type Store struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewStore 创建凭证使用审计存储
+func NewStore(db *gorm.DB, logger *logrus.Logger) *Store {
+	return &Store{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Migrate 创建或更新审计表结构
+func (s *Store) Migrate() error {
+	if err := s.db.AutoMigrate(&UsageRecord{}); err != nil {
+		return fmt.Errorf("迁移凭证使用审计表失败: %w", err)
+	}
+	return nil
+}
+
+// Record 写入一条审计记录
+func (s *Store) Record(ctx context.Context, record *UsageRecord) error {
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now().UTC()
+	}
+	if err := s.db.WithContext(ctx).Create(record).Error; err != nil {
+		return fmt.Errorf("写入凭证使用审计记录失败: %w", err)
+	}
+	return nil
+}
+
+// CredentialTotals 统计凭证在 [from, to) 范围内的请求数、令牌用量与费用
+func (s *Store) CredentialTotals(ctx context.Context, credentialID string, from, to time.Time) (*CredentialTotals, error) {
+	var row struct {
+		Requests         int64
+		PromptTokens     int64
+		CompletionTokens int64
+		TotalTokens      int64
+		Cost             float64
+	}
+
+	err := s.db.WithContext(ctx).
+		Model(&UsageRecord{}).
+		Select("COUNT(*) AS requests, "+
+			"COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens, "+
+			"COALESCE(SUM(completion_tokens), 0) AS completion_tokens, "+
+			"COALESCE(SUM(total_tokens), 0) AS total_tokens, "+
+			"COALESCE(SUM(cost), 0) AS cost").
+		Where("credential_id = ? AND created_at >= ? AND created_at < ?", credentialID, from, to).
+		Scan(&row).Error
+	if err != nil {
+		return nil, fmt.Errorf("统计凭证用量失败: %w", err)
+	}
+
+	return &CredentialTotals{
+		CredentialID:     credentialID,
+		From:             from,
+		To:               to,
+		Requests:         row.Requests,
+		PromptTokens:     row.PromptTokens,
+		CompletionTokens: row.CompletionTokens,
+		TotalTokens:      row.TotalTokens,
+		Cost:             row.Cost,
+	}, nil
+}
@@ -0,0 +1,81 @@
+package bodylimit
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/internal/models"
+)
+
+// Middleware 限制请求体大小，超过 maxBytes 时返回 413，maxBytes 不大于0时不限制
+// 声明的 Content-Length 超限时不读取请求体直接拒绝；分块上传最多读取 maxBytes+1 字节即可判定，
+// 不会把超大请求体整体读入内存。未超限的请求体缓存后交给后续处理器做 JSON 绑定
+func Middleware(maxBytes int64, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			reject(c, maxBytes, logger)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBytes+1))
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("读取请求体失败: %v", err))
+			return
+		}
+		if int64(len(body)) > maxBytes {
+			reject(c, maxBytes, logger)
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Request.ContentLength = int64(len(body))
+		c.Next()
+	}
+}
+
+// reject 返回请求体超限的 413 响应
+func reject(c *gin.Context, maxBytes int64, logger *logrus.Logger) {
+	logger.WithFields(logrus.Fields{
+		"request_id":     c.GetHeader("X-Request-ID"),
+		"path":           c.Request.URL.Path,
+		"method":         c.Request.Method,
+		"content_length": c.Request.ContentLength,
+		"limit_bytes":    maxBytes,
+		"operation":      "request_body_too_large",
+	}).Warn("请求体超过大小限制")
+
+	// 超限后不再读取剩余请求体，响应后关闭连接
+	c.Header("Connection", "close")
+	abortWithError(c, http.StatusRequestEntityTooLarge, "invalid_request_error", fmt.Sprintf("请求体超过大小限制（%d 字节）", maxBytes))
+}
+
+// abortWithError 按接口格式返回错误：OpenAI 兼容接口（/v1/*）使用 OpenAI 错误格式，其他接口使用通用响应格式
+func abortWithError(c *gin.Context, statusCode int, errorType, message string) {
+	if strings.HasPrefix(c.Request.URL.Path, "/v1/") {
+		c.AbortWithStatusJSON(statusCode, models.OpenAIErrorResponse{
+			Error: models.OpenAIError{
+				Message: message,
+				Type:    errorType,
+			},
+		})
+		return
+	}
+
+	c.AbortWithStatusJSON(statusCode, models.ApiResponse[interface{}]{
+		Success:   false,
+		Message:   message,
+		RequestID: c.GetHeader("X-Request-ID"),
+		Timestamp: fmt.Sprintf("%d", c.GetInt64("timestamp")),
+	})
+}
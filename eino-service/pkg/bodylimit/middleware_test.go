@@ -0,0 +1,104 @@
+package bodylimit
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// countingReader 记录被读取的字节数，用于确认超大请求体没有被整体读取
+type countingReader struct {
+	reader io.Reader
+	read   int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	return n, err
+}
+
+func TestMiddleware(t *testing.T) {
+	const limit = 16
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		path       string
+		limit      int64
+		size       int
+		chunked    bool
+		wantStatus int
+		wantOpenAI bool
+	}{
+		{name: "等于上限", path: "/api/v1/chat", limit: limit, size: limit, wantStatus: http.StatusOK},
+		{name: "超过上限", path: "/api/v1/chat", limit: limit, size: limit + 1, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "分块上传等于上限", path: "/api/v1/chat", limit: limit, size: limit, chunked: true, wantStatus: http.StatusOK},
+		{name: "分块上传超过上限", path: "/api/v1/chat", limit: limit, size: 10 * limit, chunked: true, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "OpenAI兼容接口超过上限", path: "/v1/chat/completions", limit: limit, size: limit + 1, wantStatus: http.StatusRequestEntityTooLarge, wantOpenAI: true},
+		{name: "未配置上限", path: "/api/v1/chat", limit: 0, size: 10 * limit, wantStatus: http.StatusOK},
+		{name: "空请求体", path: "/api/v1/chat", limit: limit, size: 0, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received string
+			router := gin.New()
+			router.Use(Middleware(tt.limit, logger))
+			router.Any("/*path", func(c *gin.Context) {
+				body, _ := io.ReadAll(c.Request.Body)
+				received = string(body)
+				c.Status(http.StatusOK)
+			})
+
+			payload := strings.Repeat("a", tt.size)
+			body := &countingReader{reader: strings.NewReader(payload)}
+			req := httptest.NewRequest(http.MethodPost, tt.path, body)
+			req.ContentLength = int64(tt.size)
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d，期望 %d", recorder.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if received != payload {
+					t.Fatalf("处理器收到 %d 字节，期望完整的 %d 字节", len(received), tt.size)
+				}
+				return
+			}
+
+			if received != "" {
+				t.Fatal("超限请求不应到达处理器")
+			}
+			if !tt.chunked && body.read != 0 {
+				t.Fatalf("声明长度超限时读取了 %d 字节，期望不读取请求体", body.read)
+			}
+			if body.read > tt.limit+1 {
+				t.Fatalf("读取了 %d 字节，期望最多 %d 字节", body.read, tt.limit+1)
+			}
+
+			var response map[string]interface{}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			if _, isOpenAI := response["error"]; isOpenAI != tt.wantOpenAI {
+				t.Fatalf("响应 = %v，OpenAI 错误格式 = %v，期望 %v", response, isOpenAI, tt.wantOpenAI)
+			}
+			if recorder.Header().Get("Connection") != "close" {
+				t.Fatal("超限响应后应关闭连接")
+			}
+		})
+	}
+}
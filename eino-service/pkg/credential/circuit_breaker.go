@@ -0,0 +1,164 @@
+package credential
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitState 熔断器状态
+type CircuitState string
+
+const (
+	// CircuitClosed 关闭状态，凭证正常参与选择
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen 打开状态，凭证暂时从选择中移除
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen 半开状态，冷却结束后允许一次试探调用
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// circuit 单个凭证的熔断状态
+type circuit struct {
+	state               CircuitState
+	consecutiveFailures int
+	openedAt            time.Time
+
+	// probeStartedAt 半开状态下试探调用的开始时间，为零值表示没有进行中的试探
+	probeStartedAt time.Time
+}
+
+// circuitBreakers 按凭证维护的熔断器集合
+type circuitBreakers struct {
+	circuits         map[string]*circuit
+	failureThreshold int
+	cooldown         time.Duration
+	mutex            sync.Mutex
+}
+
+// newCircuitBreakers 创建熔断器集合
+func newCircuitBreakers(failureThreshold int, cooldown time.Duration) *circuitBreakers {
+	return &circuitBreakers{
+		circuits:         make(map[string]*circuit),
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+	}
+}
+
+// allow 判断凭证当前是否可以参与选择，只读取状态
+// 打开状态在冷却结束后、半开状态在没有进行中的试探时可以参与选择
+func (b *circuitBreakers) allow(credentialID string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	c, exists := b.circuits[credentialID]
+	if !exists {
+		return true
+	}
+
+	switch c.state {
+	case CircuitOpen:
+		return time.Since(c.openedAt) >= b.cooldown
+	case CircuitHalfOpen:
+		return !b.probeInFlight(c)
+	default:
+		return true
+	}
+}
+
+// tryAcquireProbe 由实际发起调用的一方在调用前占用凭证
+// 关闭状态直接放行；冷却结束的打开状态转为半开并占用唯一的试探名额，其他调用方在试探结束前被拒绝
+func (b *circuitBreakers) tryAcquireProbe(credentialID string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	c, exists := b.circuits[credentialID]
+	if !exists || c.state == CircuitClosed {
+		return true
+	}
+
+	switch c.state {
+	case CircuitOpen:
+		if time.Since(c.openedAt) < b.cooldown {
+			return false
+		}
+		c.state = CircuitHalfOpen
+	case CircuitHalfOpen:
+		if b.probeInFlight(c) {
+			return false
+		}
+	}
+
+	c.probeStartedAt = time.Now()
+	return true
+}
+
+// releaseProbe 试探调用未得出结果（如调用方取消）时释放试探名额，保持半开状态
+func (b *circuitBreakers) releaseProbe(credentialID string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if c, exists := b.circuits[credentialID]; exists {
+		c.probeStartedAt = time.Time{}
+	}
+}
+
+// probeInFlight 是否有进行中的试探，试探超过冷却时间未报告结果时视为已结束，调用方需持有锁
+func (b *circuitBreakers) probeInFlight(c *circuit) bool {
+	return !c.probeStartedAt.IsZero() && time.Since(c.probeStartedAt) < b.cooldown
+}
+
+// recordFailure 记录一次调用失败，返回记录后的状态
+func (b *circuitBreakers) recordFailure(credentialID string) CircuitState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	c, exists := b.circuits[credentialID]
+	if !exists {
+		c = &circuit{state: CircuitClosed}
+		b.circuits[credentialID] = c
+	}
+
+	c.consecutiveFailures++
+	c.probeStartedAt = time.Time{}
+
+	// 半开状态下试探失败立即重新打开
+	if c.state == CircuitHalfOpen || c.consecutiveFailures >= b.failureThreshold {
+		c.state = CircuitOpen
+		c.openedAt = time.Now()
+	}
+
+	return c.state
+}
+
+// recordSuccess 记录一次调用成功，关闭熔断器
+func (b *circuitBreakers) recordSuccess(credentialID string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	delete(b.circuits, credentialID)
+}
+
+// state 获取凭证的熔断状态
+func (b *circuitBreakers) state(credentialID string) CircuitState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if c, exists := b.circuits[credentialID]; exists {
+		return c.state
+	}
+	return CircuitClosed
+}
+
+// openCount 获取处于打开状态的熔断器数量
+func (b *circuitBreakers) openCount() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	count := 0
+	for _, c := range b.circuits {
+		if c.state == CircuitOpen {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,191 @@
+package credential
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// openCircuit 连续记录失败直到熔断器打开
+func openCircuit(b *circuitBreakers, credentialID string) {
+	for i := 0; i < b.failureThreshold; i++ {
+		b.recordFailure(credentialID)
+	}
+}
+
+// expireCooldown 将熔断器的打开时间提前到冷却结束之后
+func expireCooldown(b *circuitBreakers, credentialID string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.circuits[credentialID].openedAt = time.Now().Add(-2 * b.cooldown)
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps func(b *circuitBreakers)
+		want  CircuitState
+		allow bool
+	}{
+		{
+			name:  "未记录失败时关闭",
+			steps: func(b *circuitBreakers) {},
+			want:  CircuitClosed,
+			allow: true,
+		},
+		{
+			name:  "失败未达阈值保持关闭",
+			steps: func(b *circuitBreakers) { b.recordFailure("cred") },
+			want:  CircuitClosed,
+			allow: true,
+		},
+		{
+			name:  "连续失败达到阈值后打开",
+			steps: func(b *circuitBreakers) { openCircuit(b, "cred") },
+			want:  CircuitOpen,
+		},
+		{
+			name: "冷却结束后试探调用转为半开",
+			steps: func(b *circuitBreakers) {
+				openCircuit(b, "cred")
+				expireCooldown(b, "cred")
+				b.tryAcquireProbe("cred")
+			},
+			want: CircuitHalfOpen,
+		},
+		{
+			name: "半开试探成功后关闭",
+			steps: func(b *circuitBreakers) {
+				openCircuit(b, "cred")
+				expireCooldown(b, "cred")
+				b.tryAcquireProbe("cred")
+				b.recordSuccess("cred")
+			},
+			want:  CircuitClosed,
+			allow: true,
+		},
+		{
+			name: "半开试探失败后重新打开",
+			steps: func(b *circuitBreakers) {
+				openCircuit(b, "cred")
+				expireCooldown(b, "cred")
+				b.tryAcquireProbe("cred")
+				b.recordFailure("cred")
+			},
+			want: CircuitOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newCircuitBreakers(3, time.Minute)
+			tt.steps(b)
+			if got := b.state("cred"); got != tt.want {
+				t.Fatalf("state = %s，期望 %s", got, tt.want)
+			}
+			if got := b.allow("cred"); got != tt.allow {
+				t.Fatalf("allow = %v，期望 %v", got, tt.allow)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerAllowIsReadOnly(t *testing.T) {
+	b := newCircuitBreakers(1, time.Minute)
+	openCircuit(b, "cred")
+	expireCooldown(b, "cred")
+
+	for i := 0; i < 3; i++ {
+		if !b.allow("cred") {
+			t.Fatal("冷却结束后应允许参与选择")
+		}
+	}
+	if got := b.state("cred"); got != CircuitOpen {
+		t.Fatalf("仅参与选择不应改变状态，state = %s", got)
+	}
+}
+
+func TestCircuitBreakerSingleProbe(t *testing.T) {
+	b := newCircuitBreakers(1, time.Minute)
+	openCircuit(b, "cred")
+	expireCooldown(b, "cred")
+
+	if !b.tryAcquireProbe("cred") {
+		t.Fatal("第一个调用方应获得试探名额")
+	}
+	if b.tryAcquireProbe("cred") {
+		t.Fatal("试探进行中时不应放行第二个调用方")
+	}
+	if b.allow("cred") {
+		t.Fatal("试探进行中时凭证不应参与选择")
+	}
+
+	b.releaseProbe("cred")
+	if !b.tryAcquireProbe("cred") {
+		t.Fatal("释放试探名额后应允许新的试探")
+	}
+}
+
+func TestCircuitBreakerStaleProbeExpires(t *testing.T) {
+	b := newCircuitBreakers(1, time.Minute)
+	openCircuit(b, "cred")
+	expireCooldown(b, "cred")
+	b.tryAcquireProbe("cred")
+
+	b.mutex.Lock()
+	b.circuits["cred"].probeStartedAt = time.Now().Add(-2 * time.Minute)
+	b.mutex.Unlock()
+
+	if !b.tryAcquireProbe("cred") {
+		t.Fatal("超过冷却时间未报告结果的试探应视为结束")
+	}
+}
+
+func TestRecordFailureIgnoresCallerCancellation(t *testing.T) {
+	manager, _ := newTestManager(t, StrategyFirstAvailable)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for i := 0; i < 5; i++ {
+		manager.RecordFailure(ctx, "cred", context.Canceled)
+	}
+	if got := manager.GetCircuitState("cred"); got != CircuitClosed {
+		t.Fatalf("调用方取消不应计为失败，state = %s", got)
+	}
+
+	for i := 0; i < 3; i++ {
+		manager.RecordFailure(context.Background(), "cred", errors.New("供应商返回500"))
+	}
+	if got := manager.GetCircuitState("cred"); got != CircuitOpen {
+		t.Fatalf("连续失败后应熔断，state = %s", got)
+	}
+}
+
+func TestListAvailableCredentialsDoesNotHalfOpen(t *testing.T) {
+	manager, tenantService := newTestManager(t, StrategyFirstAvailable)
+	credentials := seedCredentials(tenantService, "tenant-1", "deepseek", 1)
+	id := credentials[0].ID.String()
+
+	openCircuit(manager.breakers, id)
+	expireCooldown(manager.breakers, id)
+
+	for i := 0; i < 3; i++ {
+		if _, err := manager.ListAvailableCredentials("tenant-1"); err != nil {
+			t.Fatalf("ListAvailableCredentials: %v", err)
+		}
+	}
+	if got := manager.GetCircuitState(id); got != CircuitOpen {
+		t.Fatalf("列出凭证不应改变熔断状态，state = %s", got)
+	}
+
+	if _, err := manager.SelectCredential("tenant-1", "deepseek", "", "", ""); err != nil {
+		t.Fatalf("冷却结束后应可选择凭证进行试探: %v", err)
+	}
+	if got := manager.GetCircuitState(id); got != CircuitHalfOpen {
+		t.Fatalf("选择凭证发起调用后应转为半开，state = %s", got)
+	}
+	if _, err := manager.SelectCredential("tenant-1", "deepseek", "", "", ""); err == nil {
+		t.Fatal("试探进行中时不应再次选中该凭证")
+	}
+}
@@ -0,0 +1,121 @@
+package credential
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/internal/models"
+)
+
+// ErrCredentialBusy 凭证的并发调用数已达上限且等待超时
+var ErrCredentialBusy = errors.New("凭证并发调用数已达上限")
+
+// CredentialBusyError 凭证繁忙详情
+// 实现 net.Error 且 Timeout 为 true，调用方按可重试错误换用备用凭证
+type CredentialBusyError struct {
+	CredentialID string
+	Limit        int
+	Wait         time.Duration
+}
+
+// Error 实现 error 接口
+func (e *CredentialBusyError) Error() string {
+	return fmt.Sprintf("%s: 凭证 %s 上限 %d，等待 %s 后仍无空位", ErrCredentialBusy.Error(), e.CredentialID, e.Limit, e.Wait)
+}
+
+// Unwrap 支持 errors.Is(err, ErrCredentialBusy)
+func (e *CredentialBusyError) Unwrap() error {
+	return ErrCredentialBusy
+}
+
+// Timeout 实现 net.Error
+func (e *CredentialBusyError) Timeout() bool {
+	return true
+}
+
+// Temporary 实现 net.Error
+func (e *CredentialBusyError) Temporary() bool {
+	return true
+}
+
+// callSlots 按凭证维护的并发调用信号量
+type callSlots struct {
+	slots map[string]chan struct{}
+	mutex sync.Mutex
+}
+
+// newCallSlots 创建并发调用信号量集合
+func newCallSlots() *callSlots {
+	return &callSlots{slots: make(map[string]chan struct{})}
+}
+
+// get 获取凭证的信号量，上限变化时新建，已占用旧信号量的调用仍释放到旧信号量
+func (s *callSlots) get(credentialID string, limit int) chan struct{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	slot, exists := s.slots[credentialID]
+	if !exists || cap(slot) != limit {
+		slot = make(chan struct{}, limit)
+		s.slots[credentialID] = slot
+	}
+	return slot
+}
+
+// AcquireCall 在调用供应商前占用凭证的并发调用名额，调用结束后必须调用返回的 release
+// 名额已满时最多等待 concurrency_wait_timeout，超时返回 CredentialBusyError
+func (m *Manager) AcquireCall(ctx context.Context, cred *models.SupplierCredential) (func(), error) {
+	limit := m.callLimit(cred)
+	if limit <= 0 {
+		return func() {}, nil
+	}
+
+	credentialID := cred.ID.String()
+	slot := m.callSlots.get(credentialID, limit)
+	release := func() { <-slot }
+
+	select {
+	case slot <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	wait := m.config.ConcurrencyWaitTimeout
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case slot <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		m.logger.WithFields(logrus.Fields{
+			"credential_id": credentialID,
+			"limit":         limit,
+			"wait":          wait.String(),
+			"operation":     "credential_busy",
+		}).Warn("凭证并发调用数已达上限")
+		return nil, &CredentialBusyError{CredentialID: credentialID, Limit: limit, Wait: wait}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// callLimit 凭证的并发调用上限，model_configs.max_concurrent_calls 优先于全局配置
+func (m *Manager) callLimit(cred *models.SupplierCredential) int {
+	switch limit := cred.ModelConfigs["max_concurrent_calls"].(type) {
+	case float64:
+		if limit > 0 {
+			return int(limit)
+		}
+	case int:
+		if limit > 0 {
+			return limit
+		}
+	}
+	return m.config.MaxConcurrentCalls
+}
@@ -0,0 +1,94 @@
+package credential
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+func TestAcquireCallQueuesOrTimesOut(t *testing.T) {
+	tests := []struct {
+		name        string
+		configLimit int
+		credLimit   interface{}
+		holders     int           // 先占用名额的调用数
+		releaseIn   time.Duration // 占用者释放名额的时间，0 表示测试期间不释放
+		wantBusy    bool
+	}{
+		{name: "未设置上限不限制", holders: 5},
+		{name: "未达上限立即获得", configLimit: 2, holders: 1},
+		{name: "名额在等待期间释放则排队获得", configLimit: 1, holders: 1, releaseIn: 20 * time.Millisecond},
+		{name: "等待超时返回繁忙", configLimit: 1, holders: 1, wantBusy: true},
+		{name: "凭证配置优先于全局配置", configLimit: 5, credLimit: float64(1), holders: 1, wantBusy: true},
+		{name: "凭证配置放宽全局上限", configLimit: 1, credLimit: 2, holders: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, _ := newTestManager(t, StrategyFirstAvailable)
+			manager.config.MaxConcurrentCalls = tt.configLimit
+			manager.config.ConcurrencyWaitTimeout = 100 * time.Millisecond
+
+			cred := testutil.Credential("deepseek", "")
+			if tt.credLimit != nil {
+				cred.ModelConfigs["max_concurrent_calls"] = tt.credLimit
+			}
+
+			for i := 0; i < tt.holders; i++ {
+				release, err := manager.AcquireCall(context.Background(), cred)
+				if err != nil {
+					t.Fatalf("第 %d 个占用者获取名额失败: %v", i, err)
+				}
+				if tt.releaseIn > 0 {
+					time.AfterFunc(tt.releaseIn, release)
+				} else {
+					t.Cleanup(release)
+				}
+			}
+
+			start := time.Now()
+			release, err := manager.AcquireCall(context.Background(), cred)
+			if !tt.wantBusy {
+				if err != nil {
+					t.Fatalf("AcquireCall: %v", err)
+				}
+				release()
+				return
+			}
+
+			if !errors.Is(err, ErrCredentialBusy) {
+				t.Fatalf("错误 = %v，期望 ErrCredentialBusy", err)
+			}
+			var netErr net.Error
+			if !errors.As(err, &netErr) || !netErr.Timeout() {
+				t.Fatalf("错误 = %v，期望按超时处理以触发备用凭证", err)
+			}
+			if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+				t.Fatalf("等待 %v 即返回，期望等待 concurrency_wait_timeout", elapsed)
+			}
+		})
+	}
+}
+
+func TestAcquireCallCanceledWhileWaiting(t *testing.T) {
+	manager, _ := newTestManager(t, StrategyFirstAvailable)
+	manager.config.MaxConcurrentCalls = 1
+	manager.config.ConcurrencyWaitTimeout = time.Minute
+
+	cred := testutil.Credential("deepseek", "")
+	release, err := manager.AcquireCall(context.Background(), cred)
+	if err != nil {
+		t.Fatalf("AcquireCall: %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := manager.AcquireCall(ctx, cred); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("错误 = %v，期望调用方取消", err)
+	}
+}
@@ -0,0 +1,29 @@
+package credential
+
+// 凭证健康检查模式
+const (
+	// HealthCheckModeConnection 通过租户服务测试凭证连接
+	HealthCheckModeConnection = "connection"
+	// HealthCheckModeLive 直接向供应商发起最小补全请求
+	HealthCheckModeLive = "live"
+)
+
+// providerBaseURLs 凭证未配置 base_url 时使用的OpenAI兼容供应商地址，google 由 Gemini 客户端使用默认端点
+var providerBaseURLs = map[string]string{
+	"openai":   "https://api.openai.com/v1",
+	"deepseek": "https://api.deepseek.com",
+}
+
+// providerDefaultModels 实时检查使用的默认模型
+var providerDefaultModels = map[string]string{
+	"openai":   "gpt-3.5-turbo",
+	"deepseek": "deepseek-chat",
+	"google":   "gemini-1.5-flash",
+}
+
+// liveCheckProviders 支持实时检查的供应商，其余供应商在实时模式下仍通过租户服务测试连接
+var liveCheckProviders = map[string]bool{
+	"openai":   true,
+	"deepseek": true,
+	"google":   true,
+}
@@ -0,0 +1,143 @@
+package credential
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+// gaugeProvider 记录同时处理中的健康检查请求数峰值的供应商替身
+type gaugeProvider struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	inFlight int
+	peak     int
+	total    int
+	started  chan struct{}
+}
+
+func newGaugeProvider(t *testing.T, delay time.Duration) *gaugeProvider {
+	t.Helper()
+	p := &gaugeProvider{started: make(chan struct{}, 100)}
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		p.mutex.Lock()
+		p.inFlight++
+		p.total++
+		if p.inFlight > p.peak {
+			p.peak = p.inFlight
+		}
+		p.mutex.Unlock()
+		p.started <- struct{}{}
+
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+		}
+
+		p.mutex.Lock()
+		p.inFlight--
+		p.mutex.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1","model":"deepseek-chat","choices":[{"index":0,"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	t.Cleanup(p.Server.Close)
+	return p
+}
+
+func (p *gaugeProvider) Stats() (peak, total int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.peak, p.total
+}
+
+// newGaugeCredentials 创建 n 个指向同一供应商替身的凭证
+func newGaugeCredentials(provider *gaugeProvider, n int) []*models.SupplierCredential {
+	credentials := make([]*models.SupplierCredential, n)
+	for i := range credentials {
+		credentials[i] = testutil.Credential("deepseek", provider.URL)
+	}
+	return credentials
+}
+
+func TestHealthCheckRespectsConcurrencyLimit(t *testing.T) {
+	const credentialCount = 12
+
+	tests := []struct {
+		name      string
+		limit     int
+		wantLimit int
+	}{
+		{name: "并发上限1", limit: 1, wantLimit: 1},
+		{name: "并发上限3", limit: 3, wantLimit: 3},
+		{name: "未配置时按1处理", limit: 0, wantLimit: 1},
+		{name: "上限大于凭证数", limit: 50, wantLimit: credentialCount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newGaugeProvider(t, 20*time.Millisecond)
+			manager, _ := newHealthCheckManager(t, HealthCheckModeLive, newGaugeCredentials(provider, credentialCount)...)
+			manager.config.MaxConcurrentTests = tt.limit
+
+			manager.performHealthCheck()
+
+			peak, total := provider.Stats()
+			if total != credentialCount {
+				t.Fatalf("健康检查请求数 = %d，期望 %d", total, credentialCount)
+			}
+			if peak > tt.wantLimit {
+				t.Fatalf("并发峰值 = %d，超过上限 %d", peak, tt.wantLimit)
+			}
+			if tt.wantLimit > 1 && peak < 2 {
+				t.Fatalf("并发峰值 = %d，期望检查并行执行", peak)
+			}
+
+			manager.mutex.RLock()
+			checked := len(manager.healthStatus)
+			manager.mutex.RUnlock()
+			if checked != credentialCount {
+				t.Fatalf("已记录 %d 个凭证的健康状态，期望 %d 个", checked, credentialCount)
+			}
+		})
+	}
+}
+
+func TestHealthCheckStopsOnShutdown(t *testing.T) {
+	const credentialCount = 12
+	const limit = 2
+
+	provider := newGaugeProvider(t, 5*time.Second)
+	manager, _ := newHealthCheckManager(t, HealthCheckModeLive, newGaugeCredentials(provider, credentialCount)...)
+	manager.config.MaxConcurrentTests = limit
+
+	done := make(chan struct{})
+	go func() {
+		manager.performHealthCheck()
+		close(done)
+	}()
+
+	for i := 0; i < limit; i++ {
+		select {
+		case <-provider.started:
+		case <-time.After(2 * time.Second):
+			t.Fatal("健康检查未开始")
+		}
+	}
+	manager.Stop()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("停止后健康检查未结束")
+	}
+	if _, total := provider.Stats(); total != limit {
+		t.Fatalf("停止后共发出 %d 个检查请求，期望仅 %d 个在途请求", total, limit)
+	}
+}
@@ -0,0 +1,211 @@
+package credential
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"lyss-ai-platform/eino-service/internal/config"
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+// stubProvider 供应商替身，按状态码响应并记录请求体
+type stubProvider struct {
+	server   *httptest.Server
+	mutex    sync.Mutex
+	requests []map[string]interface{}
+}
+
+func newStubProvider(t *testing.T, status int) *stubProvider {
+	t.Helper()
+	p := &stubProvider{}
+	p.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		p.mutex.Lock()
+		p.requests = append(p.requests, body)
+		p.mutex.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			w.Write([]byte(`{"error":{"message":"invalid api key","type":"authentication_error"}}`))
+			return
+		}
+		w.Write([]byte(`{"id":"1","model":"deepseek-chat","choices":[{"index":0,"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func (p *stubProvider) Requests() []map[string]interface{} {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]map[string]interface{}(nil), p.requests...)
+}
+
+// newHealthCheckManager 创建指定健康检查模式的凭证管理器，并将凭证放入缓存
+func newHealthCheckManager(t *testing.T, mode string, credentials ...*models.SupplierCredential) (*Manager, *testutil.TenantService) {
+	t.Helper()
+	tenantService := testutil.NewTenantService(t)
+	manager := NewManager(tenantService.Client(), nil, &config.CredentialConfig{
+		CacheTTL:                time.Minute,
+		CircuitFailureThreshold: 3,
+		CircuitCooldown:         time.Minute,
+		MaxConcurrentTests:      2,
+		HealthCheckMode:         mode,
+		LiveCheckTimeout:        5 * time.Second,
+	}, StrategyFirstAvailable, testutil.Logger())
+	t.Cleanup(manager.Stop)
+
+	for _, cred := range credentials {
+		manager.cache[cred.ID.String()] = cred
+	}
+	return manager, tenantService
+}
+
+func TestLiveHealthCheck(t *testing.T) {
+	healthy := newStubProvider(t, http.StatusOK)
+	revoked := newStubProvider(t, http.StatusUnauthorized)
+
+	healthyCred := testutil.Credential("deepseek", healthy.server.URL)
+	healthyCred.ModelConfigs["model"] = "deepseek-reasoner"
+	revokedCred := testutil.Credential("deepseek", revoked.server.URL)
+
+	manager, tenantService := newHealthCheckManager(t, HealthCheckModeLive, healthyCred, revokedCred)
+	manager.performHealthCheck()
+
+	tests := []struct {
+		name      string
+		cred      *models.SupplierCredential
+		provider  *stubProvider
+		wantModel string
+		want      bool
+	}{
+		{name: "补全成功标记为健康", cred: healthyCred, provider: healthy, wantModel: "deepseek-reasoner", want: true},
+		{name: "鉴权失败标记为不健康", cred: revokedCred, provider: revoked, wantModel: "deepseek-chat", want: false},
+	}
+
+	latencies, _ := manager.GetCredentialStats()["last_check_latency_ms"].(map[string]int64)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager.mutex.RLock()
+			got, checked := manager.healthStatus[tt.cred.ID.String()]
+			manager.mutex.RUnlock()
+			if !checked || got != tt.want {
+				t.Fatalf("healthStatus = %v（已检查 %v），期望 %v", got, checked, tt.want)
+			}
+
+			requests := tt.provider.Requests()
+			if len(requests) != 1 {
+				t.Fatalf("供应商收到 %d 个请求，期望 1 个实时补全请求", len(requests))
+			}
+			if requests[0]["model"] != tt.wantModel || requests[0]["max_tokens"] != float64(10) {
+				t.Fatalf("补全请求 = %v，期望模型 %s 且 max_tokens 为 10", requests[0], tt.wantModel)
+			}
+			if _, ok := latencies[tt.cred.ID.String()]; !ok {
+				t.Fatalf("last_check_latency_ms = %v，缺少该凭证的检查延迟", latencies)
+			}
+		})
+	}
+
+	if got := tenantService.Requests("/test"); got != 0 {
+		t.Fatalf("实时模式调用了租户服务连接测试 %d 次", got)
+	}
+}
+
+func TestConnectionHealthCheckUsesTenantService(t *testing.T) {
+	provider := newStubProvider(t, http.StatusOK)
+	cred := testutil.Credential("deepseek", provider.server.URL)
+
+	manager, tenantService := newHealthCheckManager(t, HealthCheckModeConnection, cred)
+	manager.performHealthCheck()
+
+	manager.mutex.RLock()
+	healthy := manager.healthStatus[cred.ID.String()]
+	manager.mutex.RUnlock()
+	if !healthy {
+		t.Fatal("连接测试成功后应标记为健康")
+	}
+	if got := tenantService.Requests("/test"); got != 1 {
+		t.Fatalf("租户服务连接测试调用 %d 次，期望 1 次", got)
+	}
+	if got := len(provider.Requests()); got != 0 {
+		t.Fatalf("连接模式不应直接请求供应商，实际 %d 次", got)
+	}
+}
+
+// countingTransport 统计经过的请求数
+type countingTransport struct {
+	mutex sync.Mutex
+	count int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mutex.Lock()
+	t.count++
+	t.mutex.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (t *countingTransport) Count() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.count
+}
+
+func TestLiveHealthCheckDispatchesByProvider(t *testing.T) {
+	var geminiMutex sync.Mutex
+	var geminiPaths []string
+	gemini := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		geminiMutex.Lock()
+		geminiPaths = append(geminiPaths, r.URL.Path)
+		geminiMutex.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]},"finishReason":"STOP"}]}`))
+	}))
+	t.Cleanup(gemini.Close)
+	// 没有实时检查的供应商不应收到请求，收到时返回鉴权失败
+	unsupported := newStubProvider(t, http.StatusUnauthorized)
+
+	geminiCred := testutil.Credential("google", gemini.URL)
+	anthropicCred := testutil.Credential("anthropic", unsupported.server.URL)
+
+	manager, tenantService := newHealthCheckManager(t, HealthCheckModeLive, geminiCred, anthropicCred)
+	transport := &countingTransport{}
+	manager.SetHTTPClient(&http.Client{Transport: transport})
+	manager.performHealthCheck()
+
+	manager.mutex.RLock()
+	geminiHealthy := manager.healthStatus[geminiCred.ID.String()]
+	anthropicHealthy := manager.healthStatus[anthropicCred.ID.String()]
+	manager.mutex.RUnlock()
+
+	if !geminiHealthy {
+		t.Fatal("google 凭证的 Gemini 实时检查成功后应标记为健康")
+	}
+	geminiMutex.Lock()
+	paths := append([]string(nil), geminiPaths...)
+	geminiMutex.Unlock()
+	if len(paths) != 1 || !strings.HasSuffix(paths[0], "/models/gemini-1.5-flash:generateContent") {
+		t.Fatalf("Gemini 请求路径 = %v，期望一次 gemini-1.5-flash 的 generateContent", paths)
+	}
+	if got := transport.Count(); got != 1 {
+		t.Fatalf("共享客户端发出 %d 个请求，期望实时检查经过共享客户端", got)
+	}
+
+	if !anthropicHealthy {
+		t.Fatal("不支持实时检查的供应商应回退为连接测试并标记为健康")
+	}
+	if got := len(unsupported.Requests()); got != 0 {
+		t.Fatalf("不支持实时检查的供应商收到 %d 个请求", got)
+	}
+	if got := tenantService.Requests("/test"); got != 1 {
+		t.Fatalf("租户服务连接测试调用 %d 次，期望 1 次", got)
+	}
+}
@@ -0,0 +1,92 @@
+package credential
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"lyss-ai-platform/eino-service/internal/client"
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+// callProvider 向返回指定状态码的供应商替身发起调用，返回客户端错误
+func callProvider(t *testing.T, status int) error {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"provider error"}}`, status)
+	}))
+	defer server.Close()
+
+	deepSeekClient := client.NewDeepSeekClient("sk-test", server.URL, http.DefaultClient, testutil.Logger())
+	_, err := deepSeekClient.ChatCompletion(context.Background(), &client.DeepSeekRequest{
+		Model:    "deepseek-chat",
+		Messages: []client.DeepSeekMessage{{Role: "user", Content: "hi"}},
+	})
+	if err == nil {
+		t.Fatalf("状态码 %d 应返回错误", status)
+	}
+	return err
+}
+
+func TestRecordFailureInvalidatesOnAuthError(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		wantHealthy bool
+	}{
+		{name: "401使凭证失效", status: http.StatusUnauthorized, wantHealthy: false},
+		{name: "403使凭证失效", status: http.StatusForbidden, wantHealthy: false},
+		{name: "500不使凭证失效", status: http.StatusInternalServerError, wantHealthy: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, tenantService := newTestManager(t, StrategyFirstAvailable)
+			credentials := seedCredentials(tenantService, "tenant-1", "deepseek", 2)
+			for _, cred := range credentials {
+				manager.healthStatus[cred.ID.String()] = true
+			}
+
+			failed, err := manager.SelectCredential("tenant-1", "deepseek", "", "user-1", "")
+			if err != nil {
+				t.Fatalf("选择凭证失败: %v", err)
+			}
+			manager.RecordFailure(context.Background(), failed.ID.String(), callProvider(t, tt.status))
+
+			manager.mutex.RLock()
+			healthy := manager.healthStatus[failed.ID.String()]
+			manager.mutex.RUnlock()
+			if healthy != tt.wantHealthy {
+				t.Fatalf("healthStatus = %v，期望 %v", healthy, tt.wantHealthy)
+			}
+			if tt.wantHealthy {
+				return
+			}
+
+			// 失效的凭证上报租户服务
+			deadline := time.Now().Add(5 * time.Second)
+			for len(tenantService.Invalidated()) == 0 {
+				if time.Now().After(deadline) {
+					t.Fatal("失效凭证未上报租户服务")
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			if got := tenantService.Invalidated()[0]; got != failed.ID.String() {
+				t.Fatalf("上报的凭证 = %s，期望 %s", got, failed.ID)
+			}
+
+			// 后续选择跳过失效的凭证
+			for i := 0; i < 5; i++ {
+				cred, err := manager.SelectCredential("tenant-1", "deepseek", "", "user-1", "")
+				if err != nil {
+					t.Fatalf("选择凭证失败: %v", err)
+				}
+				if cred.ID == failed.ID {
+					t.Fatal("失效的凭证不应再被选中")
+				}
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package credential
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
@@ -12,16 +14,29 @@ import (
 	"lyss-ai-platform/eino-service/internal/client"
 	"lyss-ai-platform/eino-service/internal/config"
 	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/pkg/redact"
 )
 
+// invalidationReportTimeout 通知租户服务凭证失效的超时时间
+const invalidationReportTimeout = 5 * time.Second
+
 // Manager 凭证管理器
 type Manager struct {
 	tenantClient   *client.TenantClient
 	redisClient    *redis.Client
+	httpClient     *http.Client // 实时检查访问供应商使用的共享连接池客户端
 	cache          map[string]*models.SupplierCredential
 	lastUsed       map[string]time.Time
+	lastSuccess    map[string]time.Time
 	usage          map[string]int64
 	healthStatus   map[string]bool
+	invalidated    map[string]string // 因鉴权失败被标记失效的凭证及原因，健康检查通过后恢复
+	checkLatency   map[string]time.Duration
+	breakers       *circuitBreakers
+	callSlots      *callSlots
+	redisGuard     redisGuard
+	roundRobin     map[string]uint64
+	strategy       string
 	mutex          sync.RWMutex
 	config         *config.CredentialConfig
 	logger         *logrus.Logger
@@ -30,7 +45,8 @@ type Manager struct {
 }
 
 // NewManager 创建新的凭证管理器
-func NewManager(tenantClient *client.TenantClient, redisClient *redis.Client, config *config.CredentialConfig, logger *logrus.Logger) *Manager {
+// defaultStrategy 为未显式指定策略时使用的凭证选择策略
+func NewManager(tenantClient *client.TenantClient, redisClient *redis.Client, config *config.CredentialConfig, defaultStrategy string, logger *logrus.Logger) *Manager {
 	ctx, cancel := context.WithCancel(context.Background())
 	
 	return &Manager{
@@ -38,8 +54,15 @@ func NewManager(tenantClient *client.TenantClient, redisClient *redis.Client, co
 		redisClient:  redisClient,
 		cache:        make(map[string]*models.SupplierCredential),
 		lastUsed:     make(map[string]time.Time),
+		lastSuccess:  make(map[string]time.Time),
 		usage:        make(map[string]int64),
 		healthStatus: make(map[string]bool),
+		invalidated:  make(map[string]string),
+		checkLatency: make(map[string]time.Duration),
+		breakers:     newCircuitBreakers(config.CircuitFailureThreshold, config.CircuitCooldown),
+		callSlots:    newCallSlots(),
+		roundRobin:   make(map[string]uint64),
+		strategy:     defaultStrategy,
 		config:       config,
 		logger:       logger,
 		ctx:          ctx,
@@ -70,22 +93,27 @@ func (m *Manager) Stop() {
 	m.logger.Info("凭证管理器已停止")
 }
 
-// GetBestCredentialForModel 获取最佳凭证
+// GetBestCredentialForModel 使用默认策略获取最佳凭证
 func (m *Manager) GetBestCredentialForModel(tenantID, provider, modelName string) (*models.SupplierCredential, error) {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
-	
+	return m.SelectCredential(tenantID, provider, modelName, "", "")
+}
+
+// SelectCredential 按指定策略选择凭证
+// strategy 为空时使用默认策略，userID 仅用于 sticky_by_user 策略
+func (m *Manager) SelectCredential(tenantID, provider, modelName, userID, strategy string) (*models.SupplierCredential, error) {
+	if strategy == "" {
+		strategy = m.strategy
+	}
+
 	// 1. 检查缓存
 	cacheKey := fmt.Sprintf("%s:%s", tenantID, provider)
-	if cached, exists := m.cache[cacheKey]; exists {
-		if time.Since(cached.UpdatedAt) < m.config.CacheTTL && m.healthStatus[cached.ID.String()] {
-			return cached, nil
-		}
+	if cached := m.cachedCredential(cacheKey, strategy); cached != nil && m.breakers.tryAcquireProbe(cached.ID.String()) {
+		return cached, nil
 	}
 	
-	// 2. 从租户服务获取凭证
+	// 2. 从租户服务获取凭证，HTTP调用期间不持有锁，避免其他选择与用量记录排队等待租户服务
 	credentials, err := m.tenantClient.GetAvailableCredentials(tenantID, &models.CredentialSelector{
-		Strategy: "least_used",
+		Strategy: strategy,
 		Filters: struct {
 			OnlyActive bool     `json:"only_active"`
 			Providers  []string `json:"providers"`
@@ -103,15 +131,108 @@ func (m *Manager) GetBestCredentialForModel(tenantID, provider, modelName string
 		return nil, fmt.Errorf("没有找到可用的 %s 凭证", provider)
 	}
 	
-	// 3. 选择最佳凭证
-	best := m.selectBestCredential(credentials, modelName)
-	
-	// 4. 更新缓存
+	// 3. 选择最佳凭证并更新缓存
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	best := m.selectByStrategy(strategy, cacheKey, userID, credentials, modelName)
+	if best == nil {
+		return nil, fmt.Errorf("%s 凭证均处于熔断或失效状态，请稍后重试", provider)
+	}
 	m.cache[cacheKey] = best
 	
 	return best, nil
 }
 
+// cachedCredential 读取仍然有效的缓存凭证，策略不可缓存或缓存失效时返回nil
+func (m *Manager) cachedCredential(cacheKey, strategy string) *models.SupplierCredential {
+	if !isCacheableStrategy(strategy) {
+		return nil
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	cached, exists := m.cache[cacheKey]
+	if !exists || time.Since(cached.UpdatedAt) >= m.config.CacheTTL {
+		return nil
+	}
+	if !m.healthStatus[cached.ID.String()] || !m.breakers.allow(cached.ID.String()) {
+		return nil
+	}
+	return cached
+}
+
+// ListAvailableCredentials 获取租户所有可用的凭证
+// 已确认不健康或处于熔断状态的凭证会被过滤，尚未完成健康检查的凭证视为可用
+func (m *Manager) ListAvailableCredentials(tenantID string) ([]*models.SupplierCredential, error) {
+	credentials, err := m.tenantClient.GetAvailableCredentials(tenantID, &models.CredentialSelector{
+		Strategy: m.strategy,
+		Filters: struct {
+			OnlyActive bool     `json:"only_active"`
+			Providers  []string `json:"providers"`
+		}{
+			OnlyActive: true,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取凭证失败: %w", err)
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	available := make([]*models.SupplierCredential, 0, len(credentials))
+	for _, cred := range credentials {
+		if healthy, checked := m.healthStatus[cred.ID.String()]; checked && !healthy {
+			continue
+		}
+		if !m.breakers.allow(cred.ID.String()) {
+			continue
+		}
+		available = append(available, cred)
+	}
+
+	return available, nil
+}
+
+// SelectFallbackCredential 选择备用凭证
+// 从租户可用凭证中排除已失败的凭证，并限定为调用方支持的供应商，按评分选择最佳凭证
+func (m *Manager) SelectFallbackCredential(tenantID, modelName string, providers []string, exclude map[string]bool) (*models.SupplierCredential, error) {
+	available, err := m.ListAvailableCredentials(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	supported := make(map[string]bool, len(providers))
+	for _, provider := range providers {
+		supported[provider] = true
+	}
+
+	candidates := make([]*models.SupplierCredential, 0, len(available))
+	for _, cred := range available {
+		if exclude[cred.ID.String()] || !supported[cred.Provider] {
+			continue
+		}
+		candidates = append(candidates, cred)
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("没有可用的备用凭证")
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	best := m.acquireCandidate(candidates, func(candidates []*models.SupplierCredential) *models.SupplierCredential {
+		return m.selectBestCredential(candidates, modelName)
+	})
+	if best == nil {
+		return nil, fmt.Errorf("没有可用的备用凭证")
+	}
+	return best, nil
+}
+
 // selectBestCredential 选择最佳凭证
 func (m *Manager) selectBestCredential(credentials []*models.SupplierCredential, modelName string) *models.SupplierCredential {
 	var best *models.SupplierCredential
@@ -169,38 +290,134 @@ func (m *Manager) RecordUsage(credentialID string) {
 	m.usage[credentialID]++
 	m.lastUsed[credentialID] = time.Now()
 	
-	// 异步更新Redis统计
+	// 异步更新Redis统计，Redis不可用时只保留内存计数
+	go m.recordUsageToRedis(credentialID)
+}
+
+// RecordFailure 记录凭证调用失败，连续失败达到阈值后熔断该凭证
+// 鉴权失败（401/403）说明密钥已吊销或过期，立即将凭证标记为失效；
+// 并发名额已满或调用方自身取消（ctx 已结束）不计为凭证失败，只释放熔断器的试探名额
+func (m *Manager) RecordFailure(ctx context.Context, credentialID string, err error) {
+	if errors.Is(err, ErrCredentialBusy) || ctx.Err() != nil {
+		m.breakers.releaseProbe(credentialID)
+		return
+	}
+	if client.IsAuthError(err) {
+		m.invalidateCredential(credentialID, err)
+	}
+
+	state := m.breakers.recordFailure(credentialID)
+	if state == CircuitOpen {
+		m.logger.WithFields(logrus.Fields{
+			"credential_id": credentialID,
+			"cooldown":      m.config.CircuitCooldown.String(),
+			"operation":     "circuit_open",
+		}).Warning("凭证连续调用失败，已熔断")
+	}
+}
+
+// invalidateCredential 将凭证标记为不健康并通知租户服务
+// 失效的凭证在后续选择中被跳过，直到健康检查重新验证通过
+func (m *Manager) invalidateCredential(credentialID string, err error) {
+	reason := redact.String(err.Error())
+
+	m.mutex.Lock()
+	_, already := m.invalidated[credentialID]
+	m.healthStatus[credentialID] = false
+	m.invalidated[credentialID] = reason
+	m.mutex.Unlock()
+
+	if already {
+		return
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"credential_id": credentialID,
+		"operation":     "credential_invalidated",
+		"error":         reason,
+	}).Warning("供应商鉴权失败，凭证已标记为失效")
+
 	go func() {
-		key := fmt.Sprintf("credential_usage:%s", credentialID)
-		m.redisClient.Incr(m.ctx, key)
-		m.redisClient.Expire(m.ctx, key, 24*time.Hour)
+		ctx, cancel := context.WithTimeout(m.ctx, invalidationReportTimeout)
+		defer cancel()
+
+		if err := m.tenantClient.ReportInvalidCredential(ctx, credentialID, reason); err != nil {
+			m.logger.WithFields(logrus.Fields{
+				"credential_id": credentialID,
+				"operation":     "credential_invalidation_report_failed",
+				"error":         err.Error(),
+			}).Warning("通知租户服务凭证失效失败")
+		}
 	}()
 }
 
+// RecordSuccess 记录凭证调用成功，关闭熔断器
+func (m *Manager) RecordSuccess(credentialID string) {
+	m.breakers.recordSuccess(credentialID)
+
+	m.mutex.Lock()
+	m.lastSuccess[credentialID] = time.Now()
+	m.mutex.Unlock()
+}
+
+// GetCircuitState 获取凭证的熔断状态
+func (m *Manager) GetCircuitState(credentialID string) CircuitState {
+	return m.breakers.state(credentialID)
+}
+
 // WarmUpCredentials 预热凭证
 func (m *Manager) WarmUpCredentials() error {
+	_, err := m.RefreshCredentials()
+	return err
+}
+
+// RefreshCredentials 从租户服务重新加载所有活跃租户的凭证，返回加载的凭证数
+// 用于租户服务新增凭证后立即生效，无需等待下一次预热
+func (m *Manager) RefreshCredentials() (int, error) {
 	m.logger.Info("开始凭证预热...")
 	
 	// 获取活跃租户列表
 	tenantIDs, err := m.tenantClient.GetActiveTenants()
 	if err != nil {
-		return fmt.Errorf("获取活跃租户列表失败: %w", err)
+		return 0, fmt.Errorf("获取活跃租户列表失败: %w", err)
 	}
 	
 	// 为每个租户预热凭证
+	total := 0
 	for _, tenantID := range tenantIDs {
-		if err := m.warmUpTenantCredentials(tenantID); err != nil {
+		count, err := m.warmUpTenantCredentials(tenantID)
+		if err != nil {
 			m.logger.WithError(err).WithField("tenant_id", tenantID).Error("租户凭证预热失败")
 		}
+		total += count
 	}
 	
-	m.logger.WithField("tenant_count", len(tenantIDs)).Info("凭证预热完成")
-	return nil
+	m.logger.WithFields(logrus.Fields{
+		"tenant_count":     len(tenantIDs),
+		"credential_count": total,
+	}).Info("凭证预热完成")
+	return total, nil
+}
+
+// RefreshTenantCredentials 从租户服务重新加载单个租户的凭证，返回加载的凭证数
+func (m *Manager) RefreshTenantCredentials(tenantID string) (int, error) {
+	count, err := m.warmUpTenantCredentials(tenantID)
+	if err != nil {
+		return count, err
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"tenant_id":        tenantID,
+		"credential_count": count,
+		"operation":        "credential_refresh",
+	}).Info("租户凭证已重新加载")
+	return count, nil
 }
 
-// warmUpTenantCredentials 预热单个租户的凭证
-func (m *Manager) warmUpTenantCredentials(tenantID string) error {
+// warmUpTenantCredentials 预热单个租户的凭证，返回加载的凭证数
+func (m *Manager) warmUpTenantCredentials(tenantID string) (int, error) {
 	providers := []string{"openai", "anthropic", "deepseek", "google", "azure"}
+	count := 0
 	
 	for _, provider := range providers {
 		credentials, err := m.tenantClient.GetAvailableCredentials(tenantID, &models.CredentialSelector{
@@ -229,19 +446,30 @@ func (m *Manager) warmUpTenantCredentials(tenantID string) error {
 			
 			// 异步健康检查
 			go m.testCredentialHealth(cred)
+			count++
 		}
 	}
 	
-	return nil
+	return count, nil
 }
 
 // testCredentialHealth 测试凭证健康状态
 func (m *Manager) testCredentialHealth(cred *models.SupplierCredential) {
-	healthy, err := m.tenantClient.TestCredential(cred.ID.String(), &models.CredentialTestRequest{
-		TenantID:  cred.TenantID.String(),
-		TestType:  "connection",
-		ModelName: "default",
-	})
+	startTime := time.Now()
+
+	var healthy bool
+	var err error
+	if m.config.HealthCheckMode == HealthCheckModeLive && liveCheckProviders[cred.Provider] {
+		err = m.testLiveCredential(cred)
+		healthy = err == nil
+	} else {
+		healthy, err = m.tenantClient.TestCredential(cred.ID.String(), &models.CredentialTestRequest{
+			TenantID:  cred.TenantID.String(),
+			TestType:  "connection",
+			ModelName: "default",
+		})
+	}
+	latency := time.Since(startTime)
 	
 	if err != nil {
 		m.logger.WithError(err).WithField("credential_id", cred.ID.String()).Error("凭证健康检查失败")
@@ -250,6 +478,10 @@ func (m *Manager) testCredentialHealth(cred *models.SupplierCredential) {
 	
 	m.mutex.Lock()
 	m.healthStatus[cred.ID.String()] = healthy
+	m.checkLatency[cred.ID.String()] = latency
+	if healthy {
+		delete(m.invalidated, cred.ID.String())
+	}
 	m.mutex.Unlock()
 	
 	if healthy {
@@ -257,6 +489,8 @@ func (m *Manager) testCredentialHealth(cred *models.SupplierCredential) {
 			"credential_id": cred.ID.String(),
 			"provider":      cred.Provider,
 			"display_name":  cred.DisplayName,
+			"mode":          m.config.HealthCheckMode,
+			"latency_ms":    latency.Milliseconds(),
 		}).Info("凭证健康检查通过")
 	} else {
 		m.logger.WithFields(logrus.Fields{
@@ -267,6 +501,43 @@ func (m *Manager) testCredentialHealth(cred *models.SupplierCredential) {
 	}
 }
 
+// SetHTTPClient 设置实时检查访问供应商使用的HTTP客户端，应在 Start 之前调用
+func (m *Manager) SetHTTPClient(httpClient *http.Client) {
+	m.httpClient = httpClient
+}
+
+// testLiveCredential 直接向供应商发起最小补全请求验证凭证
+// google 使用 Gemini 客户端，其余支持实时检查的供应商使用OpenAI兼容接口
+func (m *Manager) testLiveCredential(cred *models.SupplierCredential) error {
+
+	model, _ := cred.ModelConfigs["model"].(string)
+	if model == "" {
+		model = providerDefaultModels[cred.Provider]
+	}
+	if model == "" {
+		return fmt.Errorf("供应商 %s 未配置可用于实时检查的模型", cred.Provider)
+	}
+
+	ctx, cancel := context.WithTimeout(m.ctx, m.config.LiveCheckTimeout)
+	defer cancel()
+
+	if cred.Provider == "google" {
+		geminiClient, err := client.NewGeminiClient(ctx, cred.APIKey, cred.BaseURL, client.WithExtraHeaders(m.httpClient, client.ExtraHeaders(cred)), m.logger)
+		if err != nil {
+			return err
+		}
+		return geminiClient.TestModel(ctx, model)
+	}
+
+	baseURL := cred.BaseURL
+	if baseURL == "" {
+		baseURL = providerBaseURLs[cred.Provider]
+	}
+	deepSeekClient := client.NewDeepSeekClient(cred.APIKey, baseURL, m.httpClient, m.logger)
+	deepSeekClient.SetExtraHeaders(client.ExtraHeaders(cred))
+	return deepSeekClient.TestModel(ctx, model)
+}
+
 // startHealthCheck 启动健康检查
 func (m *Manager) startHealthCheck() {
 	ticker := time.NewTicker(m.config.HealthCheckInterval)
@@ -283,6 +554,8 @@ func (m *Manager) startHealthCheck() {
 }
 
 // performHealthCheck 执行健康检查
+// 同时进行的检查数不超过 max_concurrent_tests，避免凭证较多时集中请求租户服务和供应商；
+// 所有检查完成后返回，管理器停止后不再发起新的检查
 func (m *Manager) performHealthCheck() {
 	m.mutex.RLock()
 	credentials := make([]*models.SupplierCredential, 0, len(m.cache))
@@ -290,9 +563,32 @@ func (m *Manager) performHealthCheck() {
 		credentials = append(credentials, cred)
 	}
 	m.mutex.RUnlock()
-	
-	for _, cred := range credentials {
-		go m.testCredentialHealth(cred)
+
+	concurrency := m.config.MaxConcurrentTests
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	semaphore := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for i, cred := range credentials {
+		select {
+		case semaphore <- struct{}{}:
+		case <-m.ctx.Done():
+			m.logger.WithFields(logrus.Fields{
+				"skipped":   len(credentials) - i,
+				"operation": "health_check_aborted",
+			}).Info("凭证管理器已停止，跳过剩余的健康检查")
+			return
+		}
+
+		wg.Add(1)
+		go func(cred *models.SupplierCredential) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			m.testCredentialHealth(cred)
+		}(cred)
 	}
 }
 
@@ -319,7 +615,17 @@ func (m *Manager) GetCredentialStats() map[string]interface{} {
 			}
 			return total
 		}(),
-		"cache_size": len(m.cache),
+		"cache_size":        len(m.cache),
+		"open_circuits":     m.breakers.openCount(),
+		"default_strategy":  m.strategy,
+		"health_check_mode": m.config.HealthCheckMode,
+		"last_check_latency_ms": func() map[string]int64 {
+			latencies := make(map[string]int64, len(m.checkLatency))
+			for id, latency := range m.checkLatency {
+				latencies[id] = latency.Milliseconds()
+			}
+			return latencies
+		}(),
 	}
 	
 	return stats
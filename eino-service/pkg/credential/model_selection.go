@@ -0,0 +1,59 @@
+package credential
+
+import (
+	"errors"
+	"sort"
+)
+
+// OptimizeCost 按能力选择模型时优先选择单价最低的模型
+const OptimizeCost = "cost"
+
+// ErrNoMatchingModel 没有满足能力要求且租户有可用凭证的模型
+var ErrNoMatchingModel = errors.New("没有满足能力要求的可用模型")
+
+// ModelCandidate 按能力选择模型时的候选模型
+type ModelCandidate struct {
+	Alias    string  // 客户端使用的模型名称
+	Provider string  // 供应商
+	Model    string  // 发送给供应商的具体模型ID
+	Cost     float64 // 每1000个输入与输出令牌的单价之和
+	Priced   bool    // 是否配置了单价
+}
+
+// SelectModel 从满足能力要求的候选模型中选择租户有可用凭证的模型
+// optimize 为 cost 时选择单价最低的模型，未配置单价的模型排在最后；否则按候选顺序选择第一个
+func (m *Manager) SelectModel(tenantID string, candidates []ModelCandidate, optimize string) (*ModelCandidate, error) {
+	if len(candidates) == 0 {
+		return nil, ErrNoMatchingModel
+	}
+
+	available, err := m.ListAvailableCredentials(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	providers := make(map[string]bool, len(available))
+	for _, cred := range available {
+		providers[cred.Provider] = true
+	}
+
+	usable := make([]ModelCandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		if providers[candidate.Provider] {
+			usable = append(usable, candidate)
+		}
+	}
+	if len(usable) == 0 {
+		return nil, ErrNoMatchingModel
+	}
+
+	if optimize == OptimizeCost {
+		sort.SliceStable(usable, func(i, j int) bool {
+			if usable[i].Priced != usable[j].Priced {
+				return usable[i].Priced
+			}
+			return usable[i].Cost < usable[j].Cost
+		})
+	}
+
+	return &usable[0], nil
+}
@@ -0,0 +1,54 @@
+package credential
+
+import (
+	"errors"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+func TestSelectModel(t *testing.T) {
+	manager, tenantService := newTestManager(t, StrategyFirstAvailable)
+	tenantService.SetCredentials("tenant-1",
+		testutil.Credential("openai", ""),
+		testutil.Credential("deepseek", ""),
+	)
+
+	gpt4o := ModelCandidate{Alias: "gpt-4o", Provider: "openai", Model: "gpt-4o", Cost: 0.0125, Priced: true}
+	deepseek := ModelCandidate{Alias: "deepseek-chat", Provider: "deepseek", Model: "deepseek-chat", Cost: 0.00137, Priced: true}
+	unpriced := ModelCandidate{Alias: "gpt-4", Provider: "openai", Model: "gpt-4"}
+	gemini := ModelCandidate{Alias: "gemini-1.5-flash", Provider: "google", Model: "gemini-1.5-flash", Cost: 0.0001, Priced: true}
+
+	tests := []struct {
+		name       string
+		candidates []ModelCandidate
+		optimize   string
+		want       string
+		wantErr    error
+	}{
+		{name: "按成本选择最便宜的模型", candidates: []ModelCandidate{gpt4o, deepseek}, optimize: OptimizeCost, want: "deepseek-chat"},
+		{name: "未配置单价的模型排在最后", candidates: []ModelCandidate{unpriced, gpt4o}, optimize: OptimizeCost, want: "gpt-4o"},
+		{name: "未指定优化目标时按候选顺序", candidates: []ModelCandidate{gpt4o, deepseek}, want: "gpt-4o"},
+		{name: "跳过没有凭证的供应商", candidates: []ModelCandidate{gemini, gpt4o}, optimize: OptimizeCost, want: "gpt-4o"},
+		{name: "候选均无凭证", candidates: []ModelCandidate{gemini}, wantErr: ErrNoMatchingModel},
+		{name: "没有候选模型", wantErr: ErrNoMatchingModel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := manager.SelectModel("tenant-1", tt.candidates, tt.optimize)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("SelectModel 错误 = %v，期望 %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SelectModel: %v", err)
+			}
+			if selected.Alias != tt.want {
+				t.Fatalf("选择的模型 = %s，期望 %s", selected.Alias, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package credential
+
+import (
+	"time"
+)
+
+// 供应商可达状态
+const (
+	// ProviderAvailable 至少有一个健康且未熔断的凭证
+	ProviderAvailable = "available"
+	// ProviderUnavailable 所有已知凭证均不健康或处于熔断状态
+	ProviderUnavailable = "unavailable"
+)
+
+// ProviderStatus 供应商可达性，根据已缓存凭证的健康检查、熔断状态与最近一次成功调用汇总
+type ProviderStatus struct {
+	Provider           string       `json:"provider"`
+	Status             string       `json:"status"`
+	Credentials        int          `json:"credentials"`
+	HealthyCredentials int          `json:"healthy_credentials"`
+	OpenCircuits       int          `json:"open_circuits"`
+	CircuitState       CircuitState `json:"circuit_state"` // 供应商内最乐观的熔断状态：任一凭证关闭即为关闭
+	LastSuccessAt      *time.Time   `json:"last_success_at,omitempty"`
+}
+
+// circuitStateRank 熔断状态的可用程度，数值越小越可用
+var circuitStateRank = map[CircuitState]int{
+	CircuitClosed:   0,
+	CircuitHalfOpen: 1,
+	CircuitOpen:     2,
+}
+
+// GetProviderStatuses 按供应商汇总已缓存凭证的可达性
+func (m *Manager) GetProviderStatuses() map[string]*ProviderStatus {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	statuses := make(map[string]*ProviderStatus)
+	seen := make(map[string]bool, len(m.cache))
+	for _, cred := range m.cache {
+		credentialID := cred.ID.String()
+		if seen[credentialID] {
+			continue
+		}
+		seen[credentialID] = true
+
+		status, exists := statuses[cred.Provider]
+		if !exists {
+			status = &ProviderStatus{
+				Provider:     cred.Provider,
+				Status:       ProviderUnavailable,
+				CircuitState: CircuitOpen,
+			}
+			statuses[cred.Provider] = status
+		}
+		status.Credentials++
+
+		healthy, checked := m.healthStatus[credentialID]
+		healthy = healthy || !checked
+		if healthy {
+			status.HealthyCredentials++
+		}
+
+		circuitState := m.breakers.state(credentialID)
+		if circuitState == CircuitOpen {
+			status.OpenCircuits++
+		}
+		if circuitStateRank[circuitState] < circuitStateRank[status.CircuitState] {
+			status.CircuitState = circuitState
+		}
+		if healthy && circuitState != CircuitOpen {
+			status.Status = ProviderAvailable
+		}
+
+		if lastSuccess, exists := m.lastSuccess[credentialID]; exists {
+			if status.LastSuccessAt == nil || lastSuccess.After(*status.LastSuccessAt) {
+				lastSuccess := lastSuccess
+				status.LastSuccessAt = &lastSuccess
+			}
+		}
+	}
+
+	return statuses
+}
@@ -0,0 +1,80 @@
+package credential
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+func TestGetProviderStatusesMixedStates(t *testing.T) {
+	manager, _ := newTestManager(t, StrategyFirstAvailable)
+
+	succeeded := testutil.Credential("deepseek", "")
+	tripped := testutil.Credential("deepseek", "")
+	openaiTripped := testutil.Credential("openai", "")
+	googleUnhealthy := testutil.Credential("google", "")
+	arkUnchecked := testutil.Credential("ark", "")
+
+	manager.mutex.Lock()
+	manager.cache["tenant-1:deepseek"] = succeeded
+	manager.cache["tenant-2:deepseek"] = tripped
+	manager.cache["tenant-1:openai"] = openaiTripped
+	manager.cache["tenant-1:google"] = googleUnhealthy
+	manager.cache["tenant-1:ark"] = arkUnchecked
+	// 同一凭证被多个缓存键引用时只统计一次
+	manager.cache["tenant-3:deepseek"] = succeeded
+	manager.healthStatus[succeeded.ID.String()] = true
+	manager.healthStatus[tripped.ID.String()] = true
+	manager.healthStatus[openaiTripped.ID.String()] = true
+	manager.healthStatus[googleUnhealthy.ID.String()] = false
+	manager.mutex.Unlock()
+
+	manager.RecordSuccess(succeeded.ID.String())
+	for i := 0; i < 3; i++ {
+		manager.RecordFailure(context.Background(), tripped.ID.String(), errors.New("upstream failure"))
+		manager.RecordFailure(context.Background(), openaiTripped.ID.String(), errors.New("upstream failure"))
+	}
+
+	tests := []struct {
+		provider        string
+		wantStatus      string
+		wantCredentials int
+		wantHealthy     int
+		wantOpen        int
+		wantCircuit     CircuitState
+		wantLastSuccess bool
+	}{
+		{provider: "deepseek", wantStatus: ProviderAvailable, wantCredentials: 2, wantHealthy: 2, wantOpen: 1, wantCircuit: CircuitClosed, wantLastSuccess: true},
+		{provider: "openai", wantStatus: ProviderUnavailable, wantCredentials: 1, wantHealthy: 1, wantOpen: 1, wantCircuit: CircuitOpen},
+		{provider: "google", wantStatus: ProviderUnavailable, wantCredentials: 1, wantHealthy: 0, wantOpen: 0, wantCircuit: CircuitClosed},
+		{provider: "ark", wantStatus: ProviderAvailable, wantCredentials: 1, wantHealthy: 1, wantOpen: 0, wantCircuit: CircuitClosed},
+	}
+
+	statuses := manager.GetProviderStatuses()
+	if len(statuses) != len(tests) {
+		t.Fatalf("供应商数 = %d，期望 %d", len(statuses), len(tests))
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			status, exists := statuses[tt.provider]
+			if !exists {
+				t.Fatalf("缺少供应商 %s 的状态", tt.provider)
+			}
+			if status.Status != tt.wantStatus {
+				t.Fatalf("status = %s，期望 %s", status.Status, tt.wantStatus)
+			}
+			if status.Credentials != tt.wantCredentials || status.HealthyCredentials != tt.wantHealthy || status.OpenCircuits != tt.wantOpen {
+				t.Fatalf("凭证数/健康/熔断 = %d/%d/%d，期望 %d/%d/%d",
+					status.Credentials, status.HealthyCredentials, status.OpenCircuits, tt.wantCredentials, tt.wantHealthy, tt.wantOpen)
+			}
+			if status.CircuitState != tt.wantCircuit {
+				t.Fatalf("circuit_state = %s，期望 %s", status.CircuitState, tt.wantCircuit)
+			}
+			if (status.LastSuccessAt != nil) != tt.wantLastSuccess {
+				t.Fatalf("last_success_at = %v，期望存在为 %v", status.LastSuccessAt, tt.wantLastSuccess)
+			}
+		})
+	}
+}
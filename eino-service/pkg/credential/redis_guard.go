@@ -0,0 +1,115 @@
+package credential
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// usageWriteTimeout 单次写入Redis使用统计的超时时间，避免Redis无响应时 goroutine 长时间滞留
+const usageWriteTimeout = 2 * time.Second
+
+// redisRetryInterval Redis写入失败后暂停写入的时间，期间只使用内存计数
+const redisRetryInterval = 30 * time.Second
+
+// redisGuard Redis写入熔断
+// 写入失败后在 redisRetryInterval 内跳过Redis，只保留内存计数；状态变化时各记录一次日志
+type redisGuard struct {
+	mutex      sync.Mutex
+	pausedTill time.Time
+	degraded   bool
+	lastError  string
+}
+
+// allow 判断当前是否尝试写入Redis
+func (g *redisGuard) allow() bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return time.Now().After(g.pausedTill)
+}
+
+// failure 记录写入失败，返回是否为新进入降级状态
+func (g *redisGuard) failure(err error) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.pausedTill = time.Now().Add(redisRetryInterval)
+	g.lastError = err.Error()
+	if g.degraded {
+		return false
+	}
+	g.degraded = true
+	return true
+}
+
+// success 记录写入成功，返回是否从降级状态恢复
+func (g *redisGuard) success() bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	recovered := g.degraded
+	g.degraded = false
+	g.lastError = ""
+	return recovered
+}
+
+// status 当前是否降级及最近一次错误
+func (g *redisGuard) status() (bool, string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.degraded, g.lastError
+}
+
+// RedisDegraded Redis写入是否处于降级状态，降级期间使用统计只保存在内存中
+func (m *Manager) RedisDegraded() (bool, string) {
+	return m.redisGuard.status()
+}
+
+// MarkRedisUnavailable 标记Redis不可用，暂停写入并使用内存计数
+// 用于启动时Redis连接失败的场景，服务继续运行，暂停结束后自动重试写入
+func (m *Manager) MarkRedisUnavailable(err error) {
+	m.markRedisFailure("", err)
+}
+
+// markRedisFailure 记录Redis写入失败，首次进入降级状态时记录告警
+func (m *Manager) markRedisFailure(credentialID string, err error) {
+	if !m.redisGuard.failure(err) {
+		return
+	}
+	m.logger.WithFields(logrus.Fields{
+		"credential_id": credentialID,
+		"retry_after":   redisRetryInterval.String(),
+		"operation":     "redis_degraded",
+		"error":         err.Error(),
+	}).Warn("Redis不可用，暂时只使用内存计数")
+}
+
+// recordUsageToRedis 将凭证使用次数写入Redis
+// Redis不可用时跳过并记录告警，不阻塞调用方；每次写入有独立超时，不会无限期占用 goroutine
+func (m *Manager) recordUsageToRedis(credentialID string) {
+	if m.redisClient == nil || !m.redisGuard.allow() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(m.ctx, usageWriteTimeout)
+	defer cancel()
+
+	key := fmt.Sprintf("credential_usage:%s", credentialID)
+	pipe := m.redisClient.Pipeline()
+	pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, 24*time.Hour)
+	if _, err := pipe.Exec(ctx); err != nil {
+		if m.ctx.Err() != nil {
+			return
+		}
+		m.markRedisFailure(credentialID, err)
+		return
+	}
+
+	if m.redisGuard.success() {
+		m.logger.WithField("operation", "redis_recovered").Info("Redis已恢复，继续写入使用统计")
+	}
+}
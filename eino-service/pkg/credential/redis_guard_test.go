@@ -0,0 +1,145 @@
+package credential
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// newClosedRedisClient 创建连接已关闭的Redis客户端
+func newClosedRedisClient(t *testing.T) *redis.Client {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	mr.Close()
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// waitUsageWriters 等待所有写入使用统计的 goroutine 退出
+// 只统计本包的写入 goroutine，连接池自身的重连 goroutine 不计入
+func waitUsageWriters(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		buf := make([]byte, 1<<20)
+		stacks := string(buf[:runtime.Stack(buf, true)])
+		if !strings.Contains(stacks, "recordUsageToRedis") {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("写入使用统计的 goroutine 未退出:\n%s", stacks)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRecordUsageWithClosedRedis(t *testing.T) {
+	manager, _ := newTestManager(t, StrategyFirstAvailable)
+	manager.redisClient = newClosedRedisClient(t)
+
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		manager.RecordUsage("cred")
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("RecordUsage 耗时 %s，不应等待Redis", elapsed)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for degraded, _ := manager.RedisDegraded(); !degraded; degraded, _ = manager.RedisDegraded() {
+		if time.Now().After(deadline) {
+			t.Fatal("Redis写入失败后应进入降级状态")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	waitUsageWriters(t)
+
+	manager.mutex.RLock()
+	usage := manager.usage["cred"]
+	manager.mutex.RUnlock()
+	if usage != 20 {
+		t.Fatalf("内存计数 = %d，期望 20", usage)
+	}
+
+	degraded, lastError := manager.RedisDegraded()
+	if !degraded || lastError == "" {
+		t.Fatalf("RedisDegraded = %v, %q，期望降级并记录错误", degraded, lastError)
+	}
+	if manager.redisGuard.allow() {
+		t.Fatal("写入失败后应暂停Redis写入")
+	}
+}
+
+func TestRedisGuardTransitions(t *testing.T) {
+	tests := []struct {
+		name         string
+		steps        func(g *redisGuard) bool
+		wantReturn   bool
+		wantDegraded bool
+		wantAllow    bool
+	}{
+		{
+			name:      "初始状态允许写入",
+			steps:     func(g *redisGuard) bool { return false },
+			wantAllow: true,
+		},
+		{
+			name:         "首次失败进入降级",
+			steps:        func(g *redisGuard) bool { return g.failure(errors.New("连接被拒绝")) },
+			wantReturn:   true,
+			wantDegraded: true,
+		},
+		{
+			name: "重复失败不重复告警",
+			steps: func(g *redisGuard) bool {
+				g.failure(errors.New("连接被拒绝"))
+				return g.failure(errors.New("连接被拒绝"))
+			},
+			wantDegraded: true,
+		},
+		{
+			name: "暂停结束后成功写入则恢复",
+			steps: func(g *redisGuard) bool {
+				g.failure(errors.New("连接被拒绝"))
+				g.pausedTill = time.Now().Add(-time.Second)
+				return g.success()
+			},
+			wantReturn: true,
+			wantAllow:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &redisGuard{}
+			if got := tt.steps(g); got != tt.wantReturn {
+				t.Fatalf("返回值 = %v，期望 %v", got, tt.wantReturn)
+			}
+			if degraded, _ := g.status(); degraded != tt.wantDegraded {
+				t.Fatalf("degraded = %v，期望 %v", degraded, tt.wantDegraded)
+			}
+			if got := g.allow(); got != tt.wantAllow {
+				t.Fatalf("allow = %v，期望 %v", got, tt.wantAllow)
+			}
+		})
+	}
+}
+
+func TestMarkRedisUnavailable(t *testing.T) {
+	manager, _ := newTestManager(t, StrategyFirstAvailable)
+	manager.MarkRedisUnavailable(errors.New("dial tcp: connection refused"))
+
+	degraded, lastError := manager.RedisDegraded()
+	if !degraded || lastError != "dial tcp: connection refused" {
+		t.Fatalf("RedisDegraded = %v, %q，期望启动失败后处于降级状态", degraded, lastError)
+	}
+	if manager.redisGuard.allow() {
+		t.Fatal("启动失败后应暂停Redis写入")
+	}
+}
@@ -0,0 +1,172 @@
+package credential
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sort"
+
+	"lyss-ai-platform/eino-service/internal/models"
+)
+
+// 凭证选择策略
+const (
+	// StrategyLeastUsed 选择使用次数最少的凭证
+	StrategyLeastUsed = "least_used"
+	// StrategyRoundRobin 按顺序轮询凭证
+	StrategyRoundRobin = "round_robin"
+	// StrategyWeighted 按 model_configs.weight 加权随机选择凭证
+	StrategyWeighted = "weighted"
+	// StrategyStickyByUser 按用户ID哈希固定到同一凭证
+	StrategyStickyByUser = "sticky_by_user"
+	// StrategyFirstAvailable 综合评分选择凭证（默认行为）
+	StrategyFirstAvailable = "first_available"
+)
+
+// ValidStrategy 判断是否为支持的凭证选择策略
+func ValidStrategy(strategy string) bool {
+	switch strategy {
+	case StrategyFirstAvailable, StrategyLeastUsed, StrategyRoundRobin, StrategyWeighted, StrategyStickyByUser:
+		return true
+	default:
+		return false
+	}
+}
+
+// 未配置权重时的默认权重
+const defaultCredentialWeight = 1.0
+
+// isCacheableStrategy 判断策略的选择结果是否可以按租户缓存
+// 轮询、加权和用户粘滞策略每次选择结果可能不同，不能复用单一缓存
+func isCacheableStrategy(strategy string) bool {
+	switch strategy {
+	case StrategyRoundRobin, StrategyWeighted, StrategyStickyByUser:
+		return false
+	default:
+		return true
+	}
+}
+
+// selectByStrategy 按策略从候选凭证中选择并占用熔断器的试探名额，调用方需持有写锁
+// 选中的凭证处于半开状态且试探名额已被占用时，从候选中移除后重新选择
+func (m *Manager) selectByStrategy(strategy, roundRobinKey, userID string, credentials []*models.SupplierCredential, modelName string) *models.SupplierCredential {
+	// 过滤熔断中及已失效的凭证，并按ID排序保证轮询与哈希结果稳定
+	candidates := make([]*models.SupplierCredential, 0, len(credentials))
+	for _, cred := range credentials {
+		if _, invalid := m.invalidated[cred.ID.String()]; invalid {
+			continue
+		}
+		if m.breakers.allow(cred.ID.String()) {
+			candidates = append(candidates, cred)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].ID.String() < candidates[j].ID.String()
+	})
+
+	return m.acquireCandidate(candidates, func(candidates []*models.SupplierCredential) *models.SupplierCredential {
+		return m.pickByStrategy(strategy, roundRobinKey, userID, candidates, modelName)
+	})
+}
+
+// acquireCandidate 按 pick 依次选择候选凭证，返回第一个成功占用熔断器试探名额的凭证
+func (m *Manager) acquireCandidate(candidates []*models.SupplierCredential, pick func([]*models.SupplierCredential) *models.SupplierCredential) *models.SupplierCredential {
+	for len(candidates) > 0 {
+		chosen := pick(candidates)
+		if m.breakers.tryAcquireProbe(chosen.ID.String()) {
+			return chosen
+		}
+
+		remaining := make([]*models.SupplierCredential, 0, len(candidates)-1)
+		for _, cred := range candidates {
+			if cred != chosen {
+				remaining = append(remaining, cred)
+			}
+		}
+		candidates = remaining
+	}
+	return nil
+}
+
+// pickByStrategy 按策略从非空候选中选择一个凭证
+func (m *Manager) pickByStrategy(strategy, roundRobinKey, userID string, candidates []*models.SupplierCredential, modelName string) *models.SupplierCredential {
+	switch strategy {
+	case StrategyLeastUsed:
+		return m.selectLeastUsed(candidates)
+	case StrategyRoundRobin:
+		return m.selectRoundRobin(roundRobinKey, candidates)
+	case StrategyWeighted:
+		return selectWeighted(candidates)
+	case StrategyStickyByUser:
+		if userID == "" {
+			return m.selectBestCredential(candidates, modelName)
+		}
+		return selectStickyByUser(userID, candidates)
+	default:
+		return m.selectBestCredential(candidates, modelName)
+	}
+}
+
+// selectLeastUsed 选择使用次数最少的凭证
+func (m *Manager) selectLeastUsed(candidates []*models.SupplierCredential) *models.SupplierCredential {
+	best := candidates[0]
+	for _, cred := range candidates[1:] {
+		if m.usage[cred.ID.String()] < m.usage[best.ID.String()] {
+			best = cred
+		}
+	}
+	return best
+}
+
+// selectRoundRobin 按租户和供应商维度轮询凭证
+func (m *Manager) selectRoundRobin(key string, candidates []*models.SupplierCredential) *models.SupplierCredential {
+	index := m.roundRobin[key] % uint64(len(candidates))
+	m.roundRobin[key]++
+	return candidates[index]
+}
+
+// selectWeighted 按凭证权重加权随机选择
+func selectWeighted(candidates []*models.SupplierCredential) *models.SupplierCredential {
+	var total float64
+	weights := make([]float64, len(candidates))
+	for i, cred := range candidates {
+		weights[i] = credentialWeight(cred)
+		total += weights[i]
+	}
+	if total <= 0 {
+		return candidates[rand.Intn(len(candidates))]
+	}
+
+	target := rand.Float64() * total
+	for i, weight := range weights {
+		target -= weight
+		if target < 0 {
+			return candidates[i]
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+// selectStickyByUser 按用户ID哈希选择固定凭证
+func selectStickyByUser(userID string, candidates []*models.SupplierCredential) *models.SupplierCredential {
+	hasher := fnv.New32a()
+	hasher.Write([]byte(userID))
+	return candidates[hasher.Sum32()%uint32(len(candidates))]
+}
+
+// credentialWeight 读取凭证 model_configs 中的 weight 配置
+func credentialWeight(cred *models.SupplierCredential) float64 {
+	switch weight := cred.ModelConfigs["weight"].(type) {
+	case float64:
+		if weight >= 0 {
+			return weight
+		}
+	case int:
+		if weight >= 0 {
+			return float64(weight)
+		}
+	}
+	return defaultCredentialWeight
+}
@@ -0,0 +1,173 @@
+package credential
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"lyss-ai-platform/eino-service/internal/config"
+	"lyss-ai-platform/eino-service/internal/models"
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+// newTestManager 创建连接租户服务替身的凭证管理器
+func newTestManager(t *testing.T, defaultStrategy string) (*Manager, *testutil.TenantService) {
+	t.Helper()
+
+	tenantService := testutil.NewTenantService(t)
+	manager := NewManager(tenantService.Client(), nil, &config.CredentialConfig{
+		CacheTTL:                time.Minute,
+		CircuitFailureThreshold: 3,
+		CircuitCooldown:         time.Minute,
+	}, defaultStrategy, testutil.Logger())
+	t.Cleanup(manager.Stop)
+	return manager, tenantService
+}
+
+// seedCredentials 为租户配置 n 个同一供应商的凭证
+func seedCredentials(tenantService *testutil.TenantService, tenantID, provider string, n int) []*models.SupplierCredential {
+	credentials := make([]*models.SupplierCredential, n)
+	for i := range credentials {
+		credentials[i] = testutil.Credential(provider, "")
+	}
+	tenantService.SetCredentials(tenantID, credentials...)
+	return credentials
+}
+
+func TestSelectCredentialRoundRobinDistribution(t *testing.T) {
+	manager, tenantService := newTestManager(t, StrategyRoundRobin)
+	seedCredentials(tenantService, "tenant-1", "deepseek", 3)
+
+	counts := make(map[string]int)
+	for i := 0; i < 30; i++ {
+		cred, err := manager.SelectCredential("tenant-1", "deepseek", "", "user-1", "")
+		if err != nil {
+			t.Fatalf("SelectCredential: %v", err)
+		}
+		counts[cred.ID.String()]++
+	}
+
+	if len(counts) != 3 {
+		t.Fatalf("轮询应覆盖全部3个凭证，实际 %d 个", len(counts))
+	}
+	for id, count := range counts {
+		if count != 10 {
+			t.Fatalf("凭证 %s 被选择 %d 次，期望均匀分布为10次", id, count)
+		}
+	}
+}
+
+func TestSelectCredentialStickyByUser(t *testing.T) {
+	manager, tenantService := newTestManager(t, StrategyStickyByUser)
+	seedCredentials(tenantService, "tenant-1", "deepseek", 5)
+
+	users := []string{"alice", "bob", "carol", "dave"}
+	for _, userID := range users {
+		first, err := manager.SelectCredential("tenant-1", "deepseek", "", userID, "")
+		if err != nil {
+			t.Fatalf("SelectCredential: %v", err)
+		}
+		for i := 0; i < 10; i++ {
+			cred, _ := manager.SelectCredential("tenant-1", "deepseek", "", userID, "")
+			if cred.ID != first.ID {
+				t.Fatalf("用户 %s 第 %d 次选择到不同的凭证", userID, i+2)
+			}
+		}
+	}
+}
+
+func TestSelectCredentialWeighted(t *testing.T) {
+	manager, tenantService := newTestManager(t, StrategyWeighted)
+	credentials := seedCredentials(tenantService, "tenant-1", "deepseek", 2)
+	credentials[0].ModelConfigs["weight"] = 0.0
+	credentials[1].ModelConfigs["weight"] = 5.0
+	tenantService.SetCredentials("tenant-1", credentials...)
+
+	for i := 0; i < 20; i++ {
+		cred, err := manager.SelectCredential("tenant-1", "deepseek", "", "", "")
+		if err != nil {
+			t.Fatalf("SelectCredential: %v", err)
+		}
+		if cred.ID != credentials[1].ID {
+			t.Fatal("权重为0的凭证不应被选择")
+		}
+	}
+}
+
+func TestSelectCredentialLeastUsed(t *testing.T) {
+	manager, tenantService := newTestManager(t, StrategyLeastUsed)
+	credentials := seedCredentials(tenantService, "tenant-1", "deepseek", 2)
+
+	manager.mutex.Lock()
+	manager.usage[credentials[0].ID.String()] = 10
+	manager.usage[credentials[1].ID.String()] = 2
+	manager.mutex.Unlock()
+
+	cred, err := manager.SelectCredential("tenant-1", "deepseek", "", "", "")
+	if err != nil {
+		t.Fatalf("SelectCredential: %v", err)
+	}
+	if cred.ID != credentials[1].ID {
+		t.Fatal("应选择使用次数最少的凭证")
+	}
+}
+
+func TestSelectCredentialRequestStrategyOverridesDefault(t *testing.T) {
+	manager, tenantService := newTestManager(t, StrategyFirstAvailable)
+	seedCredentials(tenantService, "tenant-1", "deepseek", 2)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		cred, err := manager.SelectCredential("tenant-1", "deepseek", "", "", StrategyRoundRobin)
+		if err != nil {
+			t.Fatalf("SelectCredential: %v", err)
+		}
+		seen[cred.ID.String()] = true
+	}
+	if len(seen) != 2 {
+		t.Fatalf("请求指定 round_robin 时应轮询两个凭证，实际选择了 %d 个", len(seen))
+	}
+}
+
+func TestValidStrategy(t *testing.T) {
+	tests := []struct {
+		strategy string
+		want     bool
+	}{
+		{StrategyFirstAvailable, true},
+		{StrategyLeastUsed, true},
+		{StrategyRoundRobin, true},
+		{StrategyWeighted, true},
+		{StrategyStickyByUser, true},
+		{"", false},
+		{"random", false},
+	}
+	for _, tt := range tests {
+		if got := ValidStrategy(tt.strategy); got != tt.want {
+			t.Errorf("ValidStrategy(%q) = %v，期望 %v", tt.strategy, got, tt.want)
+		}
+	}
+}
+
+func TestSelectCredentialDoesNotHoldLockDuringFetch(t *testing.T) {
+	manager, tenantService := newTestManager(t, StrategyRoundRobin)
+	credentials := seedCredentials(tenantService, "tenant-1", "deepseek", 1)
+	tenantService.SetDelay(300 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		manager.SelectCredential("tenant-1", "deepseek", "", "", "")
+	}()
+
+	// 等待选择请求进入租户服务调用
+	time.Sleep(50 * time.Millisecond)
+	start := time.Now()
+	manager.RecordUsage(credentials[0].ID.String())
+	manager.RecordSuccess(credentials[0].ID.String())
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("租户服务调用期间记录用量耗时 %s，不应等待选择完成", elapsed)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,95 @@
+package featureflag
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+)
+
+// fakeSource 返回固定覆盖的租户服务替身
+type fakeSource struct {
+	overrides map[string]map[string]bool
+	err       error
+	calls     int
+}
+
+func (s *fakeSource) GetFeatureFlags(ctx context.Context, tenantID string) (map[string]bool, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.overrides[tenantID], nil
+}
+
+func TestIsEnabledPrecedence(t *testing.T) {
+	defaults := map[string]bool{"default_on": true, "default_off": false}
+	source := &fakeSource{overrides: map[string]map[string]bool{
+		"tenant-1": {"default_on": false, "default_off": true},
+	}}
+
+	tests := []struct {
+		name     string
+		source   *fakeSource
+		tenantID string
+		flag     string
+		want     bool
+	}{
+		{name: "默认开启", source: source, tenantID: "tenant-2", flag: "default_on", want: true},
+		{name: "默认关闭", source: source, tenantID: "tenant-2", flag: "default_off"},
+		{name: "未声明的开关视为关闭", source: source, tenantID: "tenant-2", flag: "unknown"},
+		{name: "租户覆盖关闭默认开启的开关", source: source, tenantID: "tenant-1", flag: "default_on"},
+		{name: "租户覆盖开启默认关闭的开关", source: source, tenantID: "tenant-1", flag: "default_off", want: true},
+		{name: "租户服务不可用时使用默认值", source: &fakeSource{err: errors.New("连接被拒绝")}, tenantID: "tenant-1", flag: "default_on", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(tt.source, nil, defaults, 0, testutil.Logger())
+			if got := service.IsEnabled(context.Background(), tt.tenantID, tt.flag); got != tt.want {
+				t.Fatalf("IsEnabled = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequire(t *testing.T) {
+	service := NewService(nil, nil, map[string]bool{FlagJSONMode: true}, 0, testutil.Logger())
+
+	if err := service.Require(context.Background(), "tenant-1", FlagJSONMode); err != nil {
+		t.Fatalf("开启的功能不应返回错误: %v", err)
+	}
+	if err := service.Require(context.Background(), "tenant-1", FlagOptimizedRAG); !errors.Is(err, ErrFeatureDisabled) {
+		t.Fatalf("未开启的功能应返回 ErrFeatureDisabled，实际 %v", err)
+	}
+}
+
+func TestOverridesAreCached(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	source := &fakeSource{overrides: map[string]map[string]bool{"tenant-1": {"beta": true}}}
+	service := NewService(source, client, nil, time.Minute, testutil.Logger())
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if !service.IsEnabled(ctx, "tenant-1", "beta") {
+			t.Fatal("租户覆盖应开启 beta")
+		}
+	}
+	if source.calls != 1 {
+		t.Fatalf("租户服务调用 %d 次，缓存有效期内应只调用1次", source.calls)
+	}
+
+	mr.FastForward(2 * time.Minute)
+	service.IsEnabled(ctx, "tenant-1", "beta")
+	if source.calls != 2 {
+		t.Fatalf("缓存过期后应重新查询租户服务，调用 %d 次", source.calls)
+	}
+}
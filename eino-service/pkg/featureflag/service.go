@@ -0,0 +1,142 @@
+package featureflag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// 服务内使用的功能开关
+const (
+	FlagOptimizedRAG = "optimized_rag" // optimized_rag 工作流
+	FlagJSONMode     = "json_mode"     // response_format 结构化输出
+)
+
+// ErrFeatureDisabled 租户未开启请求使用的功能
+var ErrFeatureDisabled = errors.New("租户未开启该功能")
+
+// OverrideSource 租户级功能开关覆盖的来源，通常为租户服务
+type OverrideSource interface {
+	GetFeatureFlags(ctx context.Context, tenantID string) (map[string]bool, error)
+}
+
+// Service 租户级功能开关
+// 优先使用租户服务下发的租户覆盖，其次使用配置中的默认值，两者都未声明的开关视为关闭
+// 租户覆盖缓存在Redis中，租户服务不可用时使用默认值
+type Service struct {
+	source      OverrideSource
+	redisClient *redis.Client
+	defaults    map[string]bool
+	cacheTTL    time.Duration
+	logger      *logrus.Logger
+}
+
+// NewService 创建功能开关服务，cacheTTL 为租户覆盖的缓存时间，0表示每次都查询租户服务
+func NewService(source OverrideSource, redisClient *redis.Client, defaults map[string]bool, cacheTTL time.Duration, logger *logrus.Logger) *Service {
+	if defaults == nil {
+		defaults = make(map[string]bool)
+	}
+	return &Service{
+		source:      source,
+		redisClient: redisClient,
+		defaults:    defaults,
+		cacheTTL:    cacheTTL,
+		logger:      logger,
+	}
+}
+
+// IsEnabled 判断租户是否开启了功能
+func (s *Service) IsEnabled(ctx context.Context, tenantID, flag string) bool {
+	if enabled, ok := s.overrides(ctx, tenantID)[flag]; ok {
+		return enabled
+	}
+	return s.defaults[flag]
+}
+
+// Require 租户未开启功能时返回 ErrFeatureDisabled
+func (s *Service) Require(ctx context.Context, tenantID, flag string) error {
+	if s.IsEnabled(ctx, tenantID, flag) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrFeatureDisabled, flag)
+}
+
+// overrides 获取租户覆盖，优先读取缓存，获取失败时返回空覆盖
+func (s *Service) overrides(ctx context.Context, tenantID string) map[string]bool {
+	if cached, ok := s.cachedOverrides(ctx, tenantID); ok {
+		return cached
+	}
+	if s.source == nil {
+		return nil
+	}
+
+	overrides, err := s.source.GetFeatureFlags(ctx, tenantID)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"tenant_id": tenantID,
+			"operation": "feature_flags_fetch_failed",
+			"error":     err.Error(),
+		}).Warn("获取租户功能开关失败，使用默认值")
+		return nil
+	}
+
+	s.cacheOverrides(ctx, tenantID, overrides)
+	return overrides
+}
+
+// cachedOverrides 读取缓存的租户覆盖
+func (s *Service) cachedOverrides(ctx context.Context, tenantID string) (map[string]bool, bool) {
+	if s.redisClient == nil || s.cacheTTL <= 0 {
+		return nil, false
+	}
+
+	payload, err := s.redisClient.Get(ctx, s.buildKey(tenantID)).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			s.logger.WithFields(logrus.Fields{
+				"tenant_id": tenantID,
+				"operation": "feature_flags_cache_read_failed",
+				"error":     err.Error(),
+			}).Warn("读取功能开关缓存失败")
+		}
+		return nil, false
+	}
+
+	var overrides map[string]bool
+	if err := json.Unmarshal(payload, &overrides); err != nil {
+		return nil, false
+	}
+	return overrides, true
+}
+
+// cacheOverrides 缓存租户覆盖，失败时仅记录日志
+func (s *Service) cacheOverrides(ctx context.Context, tenantID string, overrides map[string]bool) {
+	if s.redisClient == nil || s.cacheTTL <= 0 {
+		return
+	}
+	if overrides == nil {
+		overrides = make(map[string]bool)
+	}
+
+	payload, err := json.Marshal(overrides)
+	if err != nil {
+		return
+	}
+	if err := s.redisClient.Set(ctx, s.buildKey(tenantID), payload, s.cacheTTL).Err(); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"tenant_id": tenantID,
+			"operation": "feature_flags_cache_write_failed",
+			"error":     err.Error(),
+		}).Warn("缓存功能开关失败")
+	}
+}
+
+// buildKey 构建Redis键
+func (s *Service) buildKey(tenantID string) string {
+	return fmt.Sprintf("feature_flags:%s", tenantID)
+}
@@ -37,6 +37,8 @@ type HealthResult struct {
 	Dependencies  map[string]string `json:"dependencies"`
 	ResponseTimes map[string]int64  `json:"response_times"`
 	Metrics       map[string]int    `json:"metrics"`
+	// Providers 各供应商的可达性，单个供应商不可用只会使整体状态降级为 degraded
+	Providers map[string]*credential.ProviderStatus `json:"providers"`
 }
 
 // Check 执行健康检查
@@ -59,12 +61,23 @@ func (c *Checker) Check(ctx context.Context) *HealthResult {
 	}
 	result.ResponseTimes["tenant_service"] = time.Since(start).Milliseconds()
 	
-	// 检查Redis
+	// 检查Redis，Redis只用于统计、配额和缓存，不可用时服务降级运行而非不可用
 	start = time.Now()
 	if err := c.checkRedis(ctx); err != nil {
-		result.Dependencies["redis"] = "unhealthy"
-		result.Status = "unhealthy"
-		c.logger.WithError(err).Error("Redis健康检查失败")
+		result.Dependencies["redis"] = "degraded"
+		if result.Status == "healthy" {
+			result.Status = "degraded"
+		}
+		c.logger.WithError(err).Warn("Redis健康检查失败，服务降级运行")
+	} else if degraded, lastError := c.credentialManager.RedisDegraded(); degraded {
+		result.Dependencies["redis"] = "degraded"
+		if result.Status == "healthy" {
+			result.Status = "degraded"
+		}
+		c.logger.WithFields(logrus.Fields{
+			"last_error": lastError,
+			"operation":  "redis_health_check",
+		}).Warn("Redis写入仍处于降级状态")
 	} else {
 		result.Dependencies["redis"] = "healthy"
 	}
@@ -81,6 +94,21 @@ func (c *Checker) Check(ctx context.Context) *HealthResult {
 	}
 	result.ResponseTimes["database"] = time.Since(start).Milliseconds()
 	
+	// 检查供应商可达性
+	result.Providers = c.credentialManager.GetProviderStatuses()
+	for provider, status := range result.Providers {
+		if status.Status != credential.ProviderAvailable {
+			if result.Status == "healthy" {
+				result.Status = "degraded"
+			}
+			c.logger.WithFields(logrus.Fields{
+				"provider":      provider,
+				"open_circuits": status.OpenCircuits,
+				"operation":     "provider_health_check",
+			}).Warning("供应商当前无可用凭证")
+		}
+	}
+	
 	// 获取系统指标
 	result.Metrics["goroutines"] = runtime.NumGoroutine()
 	var m runtime.MemStats
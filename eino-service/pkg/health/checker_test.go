@@ -0,0 +1,117 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"lyss-ai-platform/eino-service/internal/config"
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/pkg/credential"
+)
+
+func TestCheckReportsRedisDegraded(t *testing.T) {
+	tests := []struct {
+		name        string
+		closeRedis  bool
+		markFailure bool
+		wantRedis   string
+		wantStatus  string
+	}{
+		{name: "Redis正常", wantRedis: "healthy", wantStatus: "healthy"},
+		{name: "Redis连接已关闭", closeRedis: true, wantRedis: "degraded", wantStatus: "degraded"},
+		{name: "Redis写入仍在暂停", markFailure: true, wantRedis: "degraded", wantStatus: "degraded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenantService := testutil.NewTenantService(t)
+			mr := miniredis.RunT(t)
+			redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+			t.Cleanup(func() { redisClient.Close() })
+			if tt.closeRedis {
+				mr.Close()
+			}
+
+			manager := credential.NewManager(tenantService.Client(), redisClient, &config.CredentialConfig{
+				CacheTTL:                time.Minute,
+				CircuitFailureThreshold: 3,
+				CircuitCooldown:         time.Minute,
+			}, credential.StrategyFirstAvailable, testutil.Logger())
+			t.Cleanup(manager.Stop)
+			if tt.markFailure {
+				manager.MarkRedisUnavailable(errors.New("connection refused"))
+			}
+
+			checker := NewChecker(tenantService.Client(), redisClient, manager, testutil.Logger())
+			result := checker.Check(context.Background())
+			if got := result.Dependencies["redis"]; got != tt.wantRedis {
+				t.Fatalf("redis = %s，期望 %s", got, tt.wantRedis)
+			}
+			if result.Status != tt.wantStatus {
+				t.Fatalf("status = %s，期望 %s", result.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCheckProviderOutageIsDegraded(t *testing.T) {
+	tests := []struct {
+		name       string
+		tripOpenAI bool
+		wantStatus string
+		wantOpenAI string
+	}{
+		{name: "供应商均可用", wantStatus: "healthy", wantOpenAI: credential.ProviderAvailable},
+		{name: "单个供应商熔断", tripOpenAI: true, wantStatus: "degraded", wantOpenAI: credential.ProviderUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenantService := testutil.NewTenantService(t)
+			mr := miniredis.RunT(t)
+			redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			t.Cleanup(func() { redisClient.Close() })
+
+			manager := credential.NewManager(tenantService.Client(), redisClient, &config.CredentialConfig{
+				CacheTTL:                time.Minute,
+				CircuitFailureThreshold: 3,
+				CircuitCooldown:         time.Minute,
+			}, credential.StrategyFirstAvailable, testutil.Logger())
+			t.Cleanup(manager.Stop)
+
+			tenantService.SetCredentials("tenant-1", testutil.Credential("deepseek", ""), testutil.Credential("openai", ""))
+			deepseek, err := manager.SelectCredential("tenant-1", "deepseek", "", "user-1", "")
+			if err != nil {
+				t.Fatalf("SelectCredential(deepseek): %v", err)
+			}
+			openai, err := manager.SelectCredential("tenant-1", "openai", "", "user-1", "")
+			if err != nil {
+				t.Fatalf("SelectCredential(openai): %v", err)
+			}
+			manager.RecordSuccess(deepseek.ID.String())
+			if tt.tripOpenAI {
+				for i := 0; i < 3; i++ {
+					manager.RecordFailure(context.Background(), openai.ID.String(), errors.New("upstream failure"))
+				}
+			}
+
+			checker := NewChecker(tenantService.Client(), redisClient, manager, testutil.Logger())
+			result := checker.Check(context.Background())
+			if result.Status != tt.wantStatus {
+				t.Fatalf("status = %s，期望 %s", result.Status, tt.wantStatus)
+			}
+			if got := result.Providers["openai"]; got == nil || got.Status != tt.wantOpenAI {
+				t.Fatalf("openai = %+v，期望 %s", got, tt.wantOpenAI)
+			}
+			// 其他供应商不受影响
+			if got := result.Providers["deepseek"]; got == nil || got.Status != credential.ProviderAvailable || got.LastSuccessAt == nil {
+				t.Fatalf("deepseek = %+v，期望可用且包含最近成功时间", got)
+			}
+		})
+	}
+}
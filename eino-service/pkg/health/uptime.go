@@ -0,0 +1,28 @@
+package health
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// startedAt 进程启动时间（UnixNano），包初始化时设置，main 中通过 MarkStarted 校准
+var startedAt atomic.Int64
+
+func init() {
+	startedAt.Store(time.Now().UnixNano())
+}
+
+// MarkStarted 记录进程启动时间，应在 main 开始时调用
+func MarkStarted(t time.Time) {
+	startedAt.Store(t.UnixNano())
+}
+
+// StartTime 进程启动时间
+func StartTime() time.Time {
+	return time.Unix(0, startedAt.Load())
+}
+
+// Uptime 进程已运行时长
+func Uptime() time.Duration {
+	return time.Since(StartTime())
+}
@@ -0,0 +1,39 @@
+package health
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUptime(t *testing.T) {
+	previous := StartTime()
+	t.Cleanup(func() { MarkStarted(previous) })
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+	}{
+		{name: "刚启动", elapsed: 0},
+		{name: "运行一小时", elapsed: time.Hour},
+		{name: "运行三天", elapsed: 72 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := time.Now().Add(-tt.elapsed)
+			MarkStarted(started)
+			if !StartTime().Equal(started) {
+				t.Fatalf("StartTime = %v，期望 %v", StartTime(), started)
+			}
+
+			first := Uptime()
+			if first < tt.elapsed || first > tt.elapsed+time.Second {
+				t.Fatalf("Uptime = %v，期望约 %v", first, tt.elapsed)
+			}
+			time.Sleep(10 * time.Millisecond)
+			if second := Uptime(); second <= first {
+				t.Fatalf("两次调用的运行时长 %v -> %v，期望递增", first, second)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// 执行中占位值
+const pendingMarker = "__pending__"
+
+// 轮询等待首个请求完成的间隔
+const pollInterval = 100 * time.Millisecond
+
+// ErrInFlightTimeout 等待首个请求完成超时
+var ErrInFlightTimeout = errors.New("等待相同幂等键的请求完成超时")
+
+// Store 基于Redis的幂等键存储
+type Store struct {
+	redisClient *redis.Client
+	ttl         time.Duration
+	lockTTL     time.Duration
+	logger      *logrus.Logger
+}
+
+// NewStore 创建幂等键存储
+// ttl 为已完成响应的保留时间，lockTTL 为执行中占位的最长保留时间
+func NewStore(redisClient *redis.Client, ttl, lockTTL time.Duration, logger *logrus.Logger) *Store {
+	return &Store{
+		redisClient: redisClient,
+		ttl:         ttl,
+		lockTTL:     lockTTL,
+		logger:      logger,
+	}
+}
+
+// Acquire 尝试占用幂等键
+// 返回 claimed=true 表示当前请求负责执行；否则阻塞等待首个请求完成并返回其缓存的响应
+func (s *Store) Acquire(ctx context.Context, tenantID, key string) (cached []byte, claimed bool, err error) {
+	redisKey := s.buildKey(tenantID, key)
+
+	claimed, err = s.redisClient.SetNX(ctx, redisKey, pendingMarker, s.lockTTL).Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("占用幂等键失败: %w", err)
+	}
+	if claimed {
+		return nil, true, nil
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"tenant_id":       tenantID,
+		"idempotency_key": key,
+		"operation":       "idempotency_replay",
+	}).Info("检测到重复的幂等请求")
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		value, err := s.redisClient.Get(ctx, redisKey).Result()
+		switch {
+		case err == redis.Nil:
+			// 首个请求失败后释放了幂等键，由当前请求重新执行
+			return s.Acquire(ctx, tenantID, key)
+		case err != nil && ctx.Err() != nil:
+			return nil, false, ErrInFlightTimeout
+		case err != nil:
+			return nil, false, fmt.Errorf("读取幂等键失败: %w", err)
+		case value != pendingMarker:
+			return []byte(value), false, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, false, ErrInFlightTimeout
+		case <-ticker.C:
+		}
+	}
+}
+
+// Complete 保存执行完成的响应
+func (s *Store) Complete(ctx context.Context, tenantID, key string, payload []byte) error {
+	if err := s.redisClient.Set(ctx, s.buildKey(tenantID, key), payload, s.ttl).Err(); err != nil {
+		return fmt.Errorf("保存幂等响应失败: %w", err)
+	}
+	return nil
+}
+
+// Release 释放幂等键，允许后续请求重新执行
+func (s *Store) Release(ctx context.Context, tenantID, key string) {
+	if err := s.redisClient.Del(ctx, s.buildKey(tenantID, key)).Err(); err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"tenant_id":       tenantID,
+			"idempotency_key": key,
+		}).Warn("释放幂等键失败")
+	}
+}
+
+// buildKey 构建Redis键
+func (s *Store) buildKey(tenantID, key string) string {
+	return fmt.Sprintf("idempotency:%s:%s", tenantID, key)
+}
@@ -0,0 +1,132 @@
+package idempotency
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// newTestStore 创建使用 miniredis 的幂等键存储
+func newTestStore(t *testing.T) (*Store, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewStore(client, time.Hour, time.Minute, logger), mr
+}
+
+func TestAcquireReplaysCompletedResponse(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+
+	cached, claimed, err := store.Acquire(ctx, "tenant-1", "key-1")
+	if err != nil || !claimed || cached != nil {
+		t.Fatalf("首次占用: cached=%q claimed=%v err=%v", cached, claimed, err)
+	}
+	if err := store.Complete(ctx, "tenant-1", "key-1", []byte(`{"content":"ok"}`)); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	cached, claimed, err = store.Acquire(ctx, "tenant-1", "key-1")
+	if err != nil || claimed {
+		t.Fatalf("重放: claimed=%v err=%v", claimed, err)
+	}
+	if string(cached) != `{"content":"ok"}` {
+		t.Fatalf("重放响应 = %q", cached)
+	}
+
+	// 幂等键按租户隔离
+	if _, claimed, err := store.Acquire(ctx, "tenant-2", "key-1"); err != nil || !claimed {
+		t.Fatalf("其他租户: claimed=%v err=%v", claimed, err)
+	}
+}
+
+func TestAcquireDeduplicatesConcurrentRequests(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+
+	const requests = 5
+	var (
+		wg       sync.WaitGroup
+		mutex    sync.Mutex
+		claimers int
+		replayed []string
+	)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cached, claimed, err := store.Acquire(ctx, "tenant-1", "key-1")
+			if err != nil {
+				t.Errorf("Acquire: %v", err)
+				return
+			}
+			if claimed {
+				mutex.Lock()
+				claimers++
+				mutex.Unlock()
+				time.Sleep(3 * pollInterval)
+				if err := store.Complete(ctx, "tenant-1", "key-1", []byte("result")); err != nil {
+					t.Errorf("Complete: %v", err)
+				}
+				return
+			}
+			mutex.Lock()
+			replayed = append(replayed, string(cached))
+			mutex.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if claimers != 1 {
+		t.Fatalf("执行请求数 = %d，期望 1", claimers)
+	}
+	if len(replayed) != requests-1 {
+		t.Fatalf("重放请求数 = %d，期望 %d", len(replayed), requests-1)
+	}
+	for _, payload := range replayed {
+		if payload != "result" {
+			t.Fatalf("重放响应 = %q", payload)
+		}
+	}
+}
+
+func TestAcquireAfterRelease(t *testing.T) {
+	store, mr := newTestStore(t)
+	ctx := context.Background()
+
+	if _, claimed, _ := store.Acquire(ctx, "tenant-1", "key-1"); !claimed {
+		t.Fatal("首次请求应占用幂等键")
+	}
+	store.Release(ctx, "tenant-1", "key-1")
+	if mr.Exists("idempotency:tenant-1:key-1") {
+		t.Fatal("Release 后幂等键仍存在")
+	}
+	if _, claimed, _ := store.Acquire(ctx, "tenant-1", "key-1"); !claimed {
+		t.Fatal("释放后的幂等键应可重新占用")
+	}
+}
+
+func TestAcquireInFlightTimeout(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	if _, claimed, _ := store.Acquire(context.Background(), "tenant-1", "key-1"); !claimed {
+		t.Fatal("首次请求应占用幂等键")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*pollInterval)
+	defer cancel()
+	if _, _, err := store.Acquire(ctx, "tenant-1", "key-1"); err != ErrInFlightTimeout {
+		t.Fatalf("err = %v，期望 ErrInFlightTimeout", err)
+	}
+}
@@ -0,0 +1,85 @@
+package jsonmode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"lyss-ai-platform/eino-service/internal/models"
+)
+
+// ErrInvalidJSON 模型输出不是合法的JSON
+var ErrInvalidJSON = errors.New("模型输出不是合法的JSON")
+
+// nativeProviders 支持通过 response_format 参数约束输出的供应商及支持的格式
+// DeepSeek 只支持 json_object，json_schema 请求以 json_object 发送并在系统指令中给出 schema
+var nativeProviders = map[string]map[string]bool{
+	"openai": {
+		models.ResponseFormatJSONObject: true,
+		models.ResponseFormatJSONSchema: true,
+	},
+	"deepseek": {
+		models.ResponseFormatJSONObject: true,
+	},
+}
+
+// NativeType 返回发送给供应商的 response_format 类型，供应商不支持原生JSON模式时返回空字符串
+func NativeType(provider string, format *models.ResponseFormat) string {
+	if !format.JSONMode() {
+		return ""
+	}
+	supported := nativeProviders[provider]
+	if supported[format.Type] {
+		return format.Type
+	}
+	if supported[models.ResponseFormatJSONObject] {
+		return models.ResponseFormatJSONObject
+	}
+	return ""
+}
+
+// Instruction 要求模型只输出JSON的系统指令
+// 所有供应商都附加该指令：OpenAI 与 DeepSeek 的 json_object 模式要求提示词中出现 JSON，
+// 不支持原生JSON模式的供应商只能依靠指令约束输出
+func Instruction(format *models.ResponseFormat) string {
+	if !format.JSONMode() {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString("你必须只输出一个合法的 JSON 值，不要输出任何解释、前后缀文字或 Markdown 代码块标记。")
+	if format.Type == models.ResponseFormatJSONSchema && format.JSONSchema != nil {
+		schema, err := json.Marshal(format.JSONSchema.Schema)
+		if err == nil {
+			builder.WriteString("输出必须符合以下 JSON Schema：\n")
+			builder.Write(schema)
+		}
+	}
+	return builder.String()
+}
+
+// RetryPrompt 输出不是合法JSON时要求模型重新输出的提示
+func RetryPrompt(err error) string {
+	return fmt.Sprintf("上一条回答无法解析为 JSON（%v）。请重新输出，只包含一个合法的 JSON 值，不要包含其他任何内容。", err)
+}
+
+// Extract 校验模型输出是否为合法的JSON，返回去除首尾空白和 Markdown 代码块标记后的内容
+func Extract(content string) (string, error) {
+	trimmed := strings.TrimSpace(content)
+	if strings.HasPrefix(trimmed, "```") {
+		trimmed = strings.TrimPrefix(trimmed, "```")
+		trimmed = strings.TrimPrefix(trimmed, "json")
+		trimmed = strings.TrimSuffix(strings.TrimSpace(trimmed), "```")
+		trimmed = strings.TrimSpace(trimmed)
+	}
+
+	if trimmed == "" {
+		return "", fmt.Errorf("%w: 内容为空", ErrInvalidJSON)
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(trimmed), &value); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+	}
+	return trimmed, nil
+}
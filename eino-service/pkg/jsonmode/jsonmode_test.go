@@ -0,0 +1,100 @@
+package jsonmode
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/models"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{name: "对象", content: `{"city":"北京"}`, want: `{"city":"北京"}`},
+		{name: "首尾空白", content: "\n  [1, 2]  \n", want: "[1, 2]"},
+		{name: "json代码块", content: "```json\n{\"ok\":true}\n```", want: `{"ok":true}`},
+		{name: "无语言代码块", content: "```\n{\"ok\":true}\n```", want: `{"ok":true}`},
+		{name: "前缀说明文字", content: `结果如下：{"ok":true}`, wantErr: true},
+		{name: "截断", content: `{"ok":`, wantErr: true},
+		{name: "空内容", content: "  ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Extract(tt.content)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidJSON) {
+					t.Fatalf("错误 = %v，期望 ErrInvalidJSON", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("Extract = %q, %v，期望 %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNativeType(t *testing.T) {
+	jsonObject := &models.ResponseFormat{Type: models.ResponseFormatJSONObject}
+	jsonSchema := &models.ResponseFormat{Type: models.ResponseFormatJSONSchema}
+
+	tests := []struct {
+		name     string
+		provider string
+		format   *models.ResponseFormat
+		want     string
+	}{
+		{name: "未设置", provider: "openai", format: nil, want: ""},
+		{name: "text", provider: "openai", format: &models.ResponseFormat{Type: models.ResponseFormatText}, want: ""},
+		{name: "OpenAI json_schema", provider: "openai", format: jsonSchema, want: models.ResponseFormatJSONSchema},
+		{name: "DeepSeek json_object", provider: "deepseek", format: jsonObject, want: models.ResponseFormatJSONObject},
+		{name: "DeepSeek 降级为json_object", provider: "deepseek", format: jsonSchema, want: models.ResponseFormatJSONObject},
+		{name: "不支持的供应商", provider: "google", format: jsonObject, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NativeType(tt.provider, tt.format); got != tt.want {
+				t.Fatalf("NativeType = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstruction(t *testing.T) {
+	tests := []struct {
+		name       string
+		format     *models.ResponseFormat
+		wantEmpty  bool
+		wantSchema bool
+	}{
+		{name: "未设置", format: nil, wantEmpty: true},
+		{name: "json_object", format: &models.ResponseFormat{Type: models.ResponseFormatJSONObject}},
+		{
+			name: "json_schema附带schema",
+			format: &models.ResponseFormat{
+				Type:       models.ResponseFormatJSONSchema,
+				JSONSchema: &models.JSONSchemaFormat{Schema: map[string]interface{}{"type": "object"}},
+			},
+			wantSchema: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Instruction(tt.format)
+			if (got == "") != tt.wantEmpty {
+				t.Fatalf("Instruction = %q", got)
+			}
+			if strings.Contains(got, `{"type":"object"}`) != tt.wantSchema {
+				t.Fatalf("Instruction = %q，schema 出现与期望不符", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package modelalias
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"lyss-ai-platform/eino-service/internal/config"
+)
+
+// ErrUnknownModel 请求的模型既不是已配置的别名也不是已知的模型ID
+var ErrUnknownModel = errors.New("未知的模型")
+
+// UnknownModelError 未知模型详情
+type UnknownModelError struct {
+	Name  string   `json:"name"`
+	Known []string `json:"known"`
+}
+
+// Error 实现 error 接口
+func (e *UnknownModelError) Error() string {
+	return fmt.Sprintf("%s %s，可用模型: %s", ErrUnknownModel.Error(), e.Name, strings.Join(e.Known, ", "))
+}
+
+// Unwrap 支持 errors.Is(err, ErrUnknownModel)
+func (e *UnknownModelError) Unwrap() error {
+	return ErrUnknownModel
+}
+
+// Model 解析后的模型
+type Model struct {
+	Alias    string `json:"alias"`    // 请求中使用的名称
+	Provider string `json:"provider"` // 供应商
+	Model    string `json:"model"`    // 发送给供应商的具体模型ID
+
+	Capabilities []string `json:"capabilities,omitempty"` // 模型支持的能力，如 vision、tools、json_mode
+}
+
+// Registry 模型别名注册表
+// 将客户端使用的友好名称映射到供应商与具体模型ID，具体模型ID本身也可直接使用
+type Registry struct {
+	aliases map[string]Model
+	models  map[string]Model
+	ordered []Model // 按配置顺序排列的别名，用于按能力选择
+}
+
+// NewRegistry 根据配置创建模型别名注册表
+func NewRegistry(aliases []config.ModelAliasConfig) *Registry {
+	r := &Registry{
+		aliases: make(map[string]Model, len(aliases)),
+		models:  make(map[string]Model, len(aliases)),
+	}
+
+	for _, alias := range aliases {
+		model := Model{
+			Alias:        alias.Alias,
+			Provider:     alias.Provider,
+			Model:        alias.Model,
+			Capabilities: alias.Capabilities,
+		}
+		if model.Model == "" {
+			model.Model = alias.Alias
+		}
+		r.aliases[alias.Alias] = model
+		r.ordered = append(r.ordered, model)
+		if _, exists := r.models[model.Model]; !exists {
+			r.models[model.Model] = Model{
+				Alias:        model.Model,
+				Provider:     model.Provider,
+				Model:        model.Model,
+				Capabilities: model.Capabilities,
+			}
+		}
+	}
+
+	return r
+}
+
+// Resolve 将模型名称解析为供应商与具体模型ID，别名优先于具体模型ID
+func (r *Registry) Resolve(name string) (*Model, error) {
+	name = strings.TrimSpace(name)
+	if model, exists := r.aliases[name]; exists {
+		return &model, nil
+	}
+	if model, exists := r.models[name]; exists {
+		return &model, nil
+	}
+	return nil, &UnknownModelError{Name: name, Known: r.Known()}
+}
+
+// Known 列出所有可用的模型名称，包括别名和具体模型ID
+func (r *Registry) Known() []string {
+	seen := make(map[string]bool, len(r.aliases)+len(r.models))
+	names := make([]string, 0, len(r.aliases)+len(r.models))
+	for name := range r.aliases {
+		seen[name] = true
+		names = append(names, name)
+	}
+	for name := range r.models {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithCapabilities 按配置顺序列出具备全部指定能力的别名，指向同一供应商模型的别名只保留第一个
+func (r *Registry) WithCapabilities(requires []string) []Model {
+	matched := make([]Model, 0, len(r.ordered))
+	seen := make(map[string]bool, len(r.ordered))
+	for _, model := range r.ordered {
+		key := model.Provider + "/" + model.Model
+		if seen[key] || !model.HasCapabilities(requires) {
+			continue
+		}
+		seen[key] = true
+		matched = append(matched, model)
+	}
+	return matched
+}
+
+// HasCapabilities 模型是否具备全部指定能力
+func (m Model) HasCapabilities(requires []string) bool {
+	for _, required := range requires {
+		found := false
+		for _, capability := range m.Capabilities {
+			if capability == required {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package modelalias
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"lyss-ai-platform/eino-service/internal/config"
+)
+
+// testRegistry 测试使用的别名配置
+func testRegistry() *Registry {
+	return NewRegistry([]config.ModelAliasConfig{
+		{Alias: "gpt-4", Provider: "openai", Model: "gpt-4-0613"},
+		{Alias: "gpt-4o", Provider: "openai", Model: "gpt-4o", Capabilities: []string{"vision", "tools", "json_mode"}},
+		{Alias: "vision", Provider: "openai", Model: "gpt-4o", Capabilities: []string{"vision", "tools", "json_mode"}},
+		{Alias: "deepseek-chat", Provider: "deepseek", Capabilities: []string{"tools"}},
+	})
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantProvider string
+		wantModel    string
+	}{
+		{name: "别名映射到具体模型ID", input: "gpt-4", wantProvider: "openai", wantModel: "gpt-4-0613"},
+		{name: "具体模型ID可直接使用", input: "gpt-4-0613", wantProvider: "openai", wantModel: "gpt-4-0613"},
+		{name: "未配置模型ID时使用别名", input: "deepseek-chat", wantProvider: "deepseek", wantModel: "deepseek-chat"},
+		{name: "忽略首尾空白", input: "  gpt-4o ", wantProvider: "openai", wantModel: "gpt-4o"},
+	}
+
+	registry := testRegistry()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model, err := registry.Resolve(tt.input)
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if model.Provider != tt.wantProvider || model.Model != tt.wantModel {
+				t.Fatalf("Resolve = %s/%s，期望 %s/%s", model.Provider, model.Model, tt.wantProvider, tt.wantModel)
+			}
+		})
+	}
+}
+
+func TestResolveUnknownModel(t *testing.T) {
+	_, err := testRegistry().Resolve("gpt-5")
+
+	var unknown *UnknownModelError
+	if !errors.As(err, &unknown) || !errors.Is(err, ErrUnknownModel) {
+		t.Fatalf("Resolve = %v，期望 UnknownModelError", err)
+	}
+	want := []string{"deepseek-chat", "gpt-4", "gpt-4-0613", "gpt-4o", "vision"}
+	if unknown.Name != "gpt-5" || !reflect.DeepEqual(unknown.Known, want) {
+		t.Fatalf("UnknownModelError = %+v，期望列出 %v", unknown, want)
+	}
+}
+
+func TestWithCapabilities(t *testing.T) {
+	tests := []struct {
+		name     string
+		requires []string
+		want     []string
+	}{
+		{name: "不要求能力时返回全部模型", requires: nil, want: []string{"gpt-4", "gpt-4o", "deepseek-chat"}},
+		{name: "同一供应商模型只保留第一个别名", requires: []string{"vision"}, want: []string{"gpt-4o"}},
+		{name: "需要同时具备全部能力", requires: []string{"tools"}, want: []string{"gpt-4o", "deepseek-chat"}},
+		{name: "没有匹配的模型", requires: []string{"audio"}, want: []string{}},
+	}
+
+	registry := testRegistry()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, model := range registry.WithCapabilities(tt.requires) {
+				got = append(got, model.Alias)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("WithCapabilities = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package prompts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ErrPromptNotFound 提示词预设不存在或不属于该租户
+var ErrPromptNotFound = errors.New("提示词预设不存在")
+
+// ErrPromptNameExists 租户内已存在同名提示词预设
+var ErrPromptNameExists = errors.New("提示词预设名称已存在")
+
+// Prompt 租户的系统提示词预设，模板按 Jinja2 语法渲染，变量来自请求的 configuration
+type Prompt struct {
+	ID        string    `gorm:"size:36;primaryKey" json:"id"`
+	TenantID  string    `gorm:"size:64;not null;uniqueIndex:idx_prompt_presets_tenant_name,priority:1" json:"tenant_id"`
+	Name      string    `gorm:"size:128;not null;uniqueIndex:idx_prompt_presets_tenant_name,priority:2" json:"name"`
+	Template  string    `gorm:"type:text;not null" json:"template"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 提示词预设表名
+func (Prompt) TableName() string {
+	return "prompt_presets"
+}
+
+// Store 基于数据库的提示词预设存储，所有操作都限定在租户范围内
+type Store struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewStore 创建提示词预设存储
+func NewStore(db *gorm.DB, logger *logrus.Logger) *Store {
+	return &Store{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Migrate 创建或更新提示词预设表结构
+func (s *Store) Migrate() error {
+	if err := s.db.AutoMigrate(&Prompt{}); err != nil {
+		return fmt.Errorf("迁移提示词预设表失败: %w", err)
+	}
+	return nil
+}
+
+// Create 创建提示词预设
+func (s *Store) Create(ctx context.Context, tenantID, name, template string) (*Prompt, error) {
+	if err := s.checkNameAvailable(ctx, tenantID, name, ""); err != nil {
+		return nil, err
+	}
+
+	preset := &Prompt{
+		ID:       uuid.New().String(),
+		TenantID: tenantID,
+		Name:     name,
+		Template: template,
+	}
+	if err := s.db.WithContext(ctx).Create(preset).Error; err != nil {
+		return nil, fmt.Errorf("创建提示词预设失败: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"tenant_id": tenantID,
+		"prompt_id": preset.ID,
+		"name":      name,
+		"operation": "prompt_created",
+	}).Info("提示词预设已创建")
+
+	return preset, nil
+}
+
+// Get 获取租户的提示词预设
+func (s *Store) Get(ctx context.Context, tenantID, id string) (*Prompt, error) {
+	var preset Prompt
+	err := s.db.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(&preset).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPromptNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询提示词预设失败: %w", err)
+	}
+	return &preset, nil
+}
+
+// List 列出租户的全部提示词预设，按名称排序
+func (s *Store) List(ctx context.Context, tenantID string) ([]*Prompt, error) {
+	prompts := make([]*Prompt, 0)
+	err := s.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("name").
+		Find(&prompts).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询提示词预设列表失败: %w", err)
+	}
+	return prompts, nil
+}
+
+// Update 更新提示词预设的名称和模板
+func (s *Store) Update(ctx context.Context, tenantID, id, name, template string) (*Prompt, error) {
+	preset, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if name != preset.Name {
+		if err := s.checkNameAvailable(ctx, tenantID, name, id); err != nil {
+			return nil, err
+		}
+	}
+
+	preset.Name = name
+	preset.Template = template
+	if err := s.db.WithContext(ctx).Save(preset).Error; err != nil {
+		return nil, fmt.Errorf("更新提示词预设失败: %w", err)
+	}
+	return preset, nil
+}
+
+// Delete 删除提示词预设
+func (s *Store) Delete(ctx context.Context, tenantID, id string) error {
+	result := s.db.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		Delete(&Prompt{})
+	if result.Error != nil {
+		return fmt.Errorf("删除提示词预设失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrPromptNotFound
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"tenant_id": tenantID,
+		"prompt_id": id,
+		"operation": "prompt_deleted",
+	}).Info("提示词预设已删除")
+
+	return nil
+}
+
+// checkNameAvailable 检查名称在租户内未被其他预设使用
+func (s *Store) checkNameAvailable(ctx context.Context, tenantID, name, excludeID string) error {
+	query := s.db.WithContext(ctx).
+		Model(&Prompt{}).
+		Where("tenant_id = ? AND name = ?", tenantID, name)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return fmt.Errorf("检查提示词预设名称失败: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s", ErrPromptNameExists, name)
+	}
+	return nil
+}
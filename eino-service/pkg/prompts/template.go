@@ -0,0 +1,69 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/schema"
+)
+
+// templateTags Jinja2 标签的起止符
+var templateTags = map[string]string{
+	"{{": "}}",
+	"{%": "%}",
+	"{#": "#}",
+}
+
+// ValidateTemplate 检查模板能否按 Jinja2 语法解析，未提供的变量渲染为空
+func ValidateTemplate(ctx context.Context, template string) error {
+	if err := CheckTemplateTags(template); err != nil {
+		return err
+	}
+	_, err := prompt.FromMessages(schema.Jinja2, schema.SystemMessage(template)).Format(ctx, map[string]any{})
+	if err != nil {
+		return fmt.Errorf("提示词模板无效: %w", err)
+	}
+	return nil
+}
+
+// CheckTemplateTags 检查模板中的每个标签都已闭合
+// Jinja2 解析器在表达式或语句未闭合就到达结尾时会陷入死循环，渲染用户提供的模板前必须先检查
+func CheckTemplateTags(template string) error {
+	for i := 0; i < len(template)-1; i++ {
+		closer, isTag := templateTags[template[i:i+2]]
+		if !isTag {
+			continue
+		}
+
+		end := tagEnd(template, i+2, closer)
+		if end < 0 {
+			return fmt.Errorf("提示词模板无效: 第 %d 字节处的 %s 未闭合", i, template[i:i+2])
+		}
+		i = end - 1
+	}
+	return nil
+}
+
+// tagEnd 从 start 开始查找标签结束符，返回结束符之后的位置，未找到返回 -1
+// 表达式与语句中引号内的内容不参与匹配，注释中的引号按普通字符处理
+func tagEnd(template string, start int, closer string) int {
+	var quote byte
+	for i := start; i < len(template); i++ {
+		c := template[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case closer != "#}" && (c == '\'' || c == '"'):
+			quote = c
+		case strings.HasPrefix(template[i:], closer):
+			return i + len(closer)
+		}
+	}
+	return -1
+}
@@ -0,0 +1,218 @@
+package prompttrace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/pkg/redact"
+)
+
+// 记录提示词的原因
+const (
+	ReasonSampled   = "sampled"   // 按采样比例选中
+	ReasonRequested = "requested" // 请求携带调试头
+)
+
+// Trace 一次模型调用的完整提示词与回答，记录前已屏蔽敏感信息
+type Trace struct {
+	RequestID     string                 `json:"request_id"`
+	ExecutionID   string                 `json:"execution_id"`
+	TenantID      string                 `json:"tenant_id"`
+	UserID        string                 `json:"user_id"`
+	WorkflowType  string                 `json:"workflow_type"`
+	Model         string                 `json:"model"`
+	Reason        string                 `json:"reason"`
+	Message       string                 `json:"message"`
+	Configuration map[string]interface{} `json:"configuration,omitempty"` // 包含系统提示与对话历史
+	ModelConfig   map[string]interface{} `json:"model_config,omitempty"`
+	Response      string                 `json:"response"`
+	Error         string                 `json:"error,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
+
+// Sampler 按固定间隔采样，每 rate 个请求选中一个
+type Sampler struct {
+	rate    uint64
+	counter atomic.Uint64
+}
+
+// NewSampler 创建采样器，rate 不大于0时不采样
+func NewSampler(rate int) *Sampler {
+	if rate < 0 {
+		rate = 0
+	}
+	return &Sampler{rate: uint64(rate)}
+}
+
+// Sample 判断本次请求是否被选中
+func (s *Sampler) Sample() bool {
+	if s.rate == 0 {
+		return false
+	}
+	return s.counter.Add(1)%s.rate == 0
+}
+
+// Store 基于Redis的提示词记录，每个租户保留最近的记录，最后一次写入后按 ttl 过期
+type Store struct {
+	redisClient *redis.Client
+	ttl         time.Duration
+	maxTraces   int64
+}
+
+// NewStore 创建提示词记录存储，maxTraces 为每个租户保留的最大记录数
+func NewStore(redisClient *redis.Client, ttl time.Duration, maxTraces int) *Store {
+	return &Store{
+		redisClient: redisClient,
+		ttl:         ttl,
+		maxTraces:   int64(maxTraces),
+	}
+}
+
+// Append 写入一条记录，超过保留数量时淘汰最旧的记录
+func (s *Store) Append(ctx context.Context, trace *Trace) error {
+	payload, err := json.Marshal(trace)
+	if err != nil {
+		return fmt.Errorf("序列化提示词记录失败: %w", err)
+	}
+
+	key := s.buildKey(trace.TenantID)
+	pipe := s.redisClient.TxPipeline()
+	pipe.LPush(ctx, key, payload)
+	if s.maxTraces > 0 {
+		pipe.LTrim(ctx, key, 0, s.maxTraces-1)
+	}
+	if s.ttl > 0 {
+		pipe.Expire(ctx, key, s.ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("保存提示词记录失败: %w", err)
+	}
+	return nil
+}
+
+// List 按时间倒序列出租户最近的记录，limit 不大于0时返回全部
+func (s *Store) List(ctx context.Context, tenantID string, limit int) ([]*Trace, error) {
+	stop := int64(-1)
+	if limit > 0 {
+		stop = int64(limit) - 1
+	}
+
+	payloads, err := s.redisClient.LRange(ctx, s.buildKey(tenantID), 0, stop).Result()
+	if err != nil {
+		return nil, fmt.Errorf("读取提示词记录失败: %w", err)
+	}
+
+	traces := make([]*Trace, 0, len(payloads))
+	for _, payload := range payloads {
+		var trace Trace
+		if err := json.Unmarshal([]byte(payload), &trace); err != nil {
+			continue
+		}
+		traces = append(traces, &trace)
+	}
+	return traces, nil
+}
+
+// buildKey 构建Redis键
+func (s *Store) buildKey(tenantID string) string {
+	return fmt.Sprintf("prompt_trace:%s", tenantID)
+}
+
+// Recorder 按采样比例或调试头记录完整的提示词与回答，写入日志与存储前屏蔽敏感信息
+type Recorder struct {
+	sampler      *Sampler
+	store        *Store
+	allowRequest bool
+	logger       *logrus.Logger
+}
+
+// NewRecorder 创建提示词记录器
+// allowRequest 为 true 时携带调试头的请求总会被记录；store 为nil时只写日志
+func NewRecorder(sampler *Sampler, store *Store, allowRequest bool, logger *logrus.Logger) *Recorder {
+	return &Recorder{
+		sampler:      sampler,
+		store:        store,
+		allowRequest: allowRequest,
+		logger:       logger,
+	}
+}
+
+// Reason 判断请求是否需要记录，不需要时返回空字符串
+// requested 表示请求携带了调试头，未开启调试头时忽略
+func (r *Recorder) Reason(requested bool) string {
+	if requested && r.allowRequest {
+		return ReasonRequested
+	}
+	if r.sampler != nil && r.sampler.Sample() {
+		return ReasonSampled
+	}
+	return ""
+}
+
+// Record 屏蔽敏感信息后写入日志和存储，存储失败时仅记录日志
+func (r *Recorder) Record(ctx context.Context, trace *Trace) {
+	trace = Redact(trace)
+
+	r.logger.WithFields(logrus.Fields{
+		"request_id":    trace.RequestID,
+		"execution_id":  trace.ExecutionID,
+		"tenant_id":     trace.TenantID,
+		"workflow_type": trace.WorkflowType,
+		"model":         trace.Model,
+		"reason":        trace.Reason,
+		"message":       trace.Message,
+		"configuration": trace.Configuration,
+		"response":      trace.Response,
+		"error":         trace.Error,
+		"operation":     "prompt_trace",
+	}).Info("记录完整提示词与回答")
+
+	if r.store == nil {
+		return
+	}
+	if err := r.store.Append(ctx, trace); err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"request_id": trace.RequestID,
+			"tenant_id":  trace.TenantID,
+			"operation":  "prompt_trace_store_failed",
+			"error":      err.Error(),
+		}).Warn("保存提示词记录失败")
+	}
+}
+
+// List 列出租户最近的记录，未配置存储时返回空列表
+func (r *Recorder) List(ctx context.Context, tenantID string, limit int) ([]*Trace, error) {
+	if r.store == nil {
+		return []*Trace{}, nil
+	}
+	return r.store.List(ctx, tenantID, limit)
+}
+
+// Redact 返回屏蔽了密钥、令牌等敏感信息的记录副本
+func Redact(trace *Trace) *Trace {
+	redacted := *trace
+	redacted.Message = redact.String(trace.Message)
+	redacted.Response = redact.String(trace.Response)
+	redacted.Error = redact.String(trace.Error)
+	redacted.Configuration = redactMap(trace.Configuration)
+	redacted.ModelConfig = redactMap(trace.ModelConfig)
+	return &redacted
+}
+
+// redactMap 逐个字段屏蔽敏感信息，嵌套的复合值序列化后屏蔽
+func redactMap(values map[string]interface{}) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	redacted := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		redacted[key] = redact.Value(key, value)
+	}
+	return redacted
+}
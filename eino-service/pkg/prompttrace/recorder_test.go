@@ -0,0 +1,202 @@
+package prompttrace
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"lyss-ai-platform/eino-service/internal/testutil"
+	"lyss-ai-platform/eino-service/pkg/redact"
+)
+
+const testAPIKey = "sk-abcdefghijklmnopqrstuvwxyz"
+
+// newTestStore 创建使用 miniredis 的提示词记录存储
+func newTestStore(t *testing.T, maxTraces int) (*Store, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewStore(client, time.Hour, maxTraces), mr
+}
+
+func TestSamplerHonorsRate(t *testing.T) {
+	const requests = 100
+
+	tests := []struct {
+		name string
+		rate int
+		want int
+	}{
+		{name: "不采样", rate: 0, want: 0},
+		{name: "负数按不采样处理", rate: -5, want: 0},
+		{name: "全部记录", rate: 1, want: requests},
+		{name: "每4个记录1个", rate: 4, want: requests / 4},
+		{name: "每10个记录1个", rate: 10, want: requests / 10},
+		{name: "采样间隔大于请求数", rate: 1000, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sampler := NewSampler(tt.rate)
+
+			var mutex sync.Mutex
+			var wg sync.WaitGroup
+			sampled := 0
+			for i := 0; i < requests; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if sampler.Sample() {
+						mutex.Lock()
+						sampled++
+						mutex.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+
+			if sampled != tt.want {
+				t.Fatalf("%d 个请求中采样 %d 个，期望 %d 个", requests, sampled, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecorderReason(t *testing.T) {
+	tests := []struct {
+		name         string
+		rate         int
+		allowRequest bool
+		requested    bool
+		want         []string
+	}{
+		{name: "未开启", want: []string{"", "", ""}},
+		{name: "按比例采样", rate: 2, want: []string{"", ReasonSampled, "", ReasonSampled}},
+		{name: "调试头", allowRequest: true, requested: true, want: []string{ReasonRequested, ReasonRequested}},
+		{name: "未开启调试头时忽略", requested: true, want: []string{"", ""}},
+		{name: "开启调试头但请求未携带", allowRequest: true, want: []string{"", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := NewRecorder(NewSampler(tt.rate), nil, tt.allowRequest, testutil.Logger())
+			for i, want := range tt.want {
+				if got := recorder.Reason(tt.requested); got != want {
+					t.Fatalf("第 %d 个请求 Reason = %q，期望 %q", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	trace := &Trace{
+		TenantID: "tenant-1",
+		Message:  "我的密钥是 " + testAPIKey,
+		Configuration: map[string]interface{}{
+			"system_prompt": "Authorization: Bearer secret-token",
+			"history":       []map[string]string{{"role": "user", "content": "key " + testAPIKey}},
+		},
+		ModelConfig: map[string]interface{}{
+			"model":   "deepseek-chat",
+			"api_key": "plain-key",
+		},
+		Response: "收到 " + testAPIKey,
+		Error:    `upstream: {"api_key": "plain-key"}`,
+	}
+
+	redacted := Redact(trace)
+	rendered := fmt.Sprintf("%+v", *redacted)
+
+	tests := []struct {
+		name string
+		leak string
+	}{
+		{name: "sk-格式密钥", leak: testAPIKey},
+		{name: "Bearer令牌", leak: "secret-token"},
+		{name: "密钥字段", leak: "plain-key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if strings.Contains(rendered, tt.leak) {
+				t.Fatalf("屏蔽后的记录仍包含 %q: %s", tt.leak, rendered)
+			}
+		})
+	}
+
+	if redacted.ModelConfig["model"] != "deepseek-chat" || !strings.Contains(redacted.Message, redact.Mask) {
+		t.Fatalf("屏蔽后的记录 = %+v，期望保留非敏感字段并替换密钥", redacted)
+	}
+	if !strings.Contains(trace.Message, testAPIKey) {
+		t.Fatal("Redact 不应修改原记录")
+	}
+}
+
+func TestRecorderStoresRedactedTraces(t *testing.T) {
+	store, mr := newTestStore(t, 3)
+	recorder := NewRecorder(NewSampler(1), store, false, testutil.Logger())
+	ctx := context.Background()
+
+	for i := 1; i <= 5; i++ {
+		recorder.Record(ctx, &Trace{
+			RequestID: fmt.Sprintf("req-%d", i),
+			TenantID:  "tenant-1",
+			Message:   "key " + testAPIKey,
+			Reason:    ReasonSampled,
+		})
+	}
+	recorder.Record(ctx, &Trace{RequestID: "req-other", TenantID: "tenant-2"})
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []string
+	}{
+		{name: "只保留最近的记录", want: []string{"req-5", "req-4", "req-3"}},
+		{name: "按条数返回", limit: 2, want: []string{"req-5", "req-4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traces, err := recorder.List(ctx, "tenant-1", tt.limit)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			got := make([]string, len(traces))
+			for i, trace := range traces {
+				got[i] = trace.RequestID
+				if strings.Contains(trace.Message, testAPIKey) {
+					t.Fatalf("存储的记录未屏蔽密钥: %q", trace.Message)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("记录 = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	if ttl := mr.TTL("prompt_trace:tenant-1"); ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("记录 TTL = %v，期望不超过1小时", ttl)
+	}
+	mr.FastForward(2 * time.Hour)
+	if traces, _ := recorder.List(ctx, "tenant-1", 0); len(traces) != 0 {
+		t.Fatalf("过期后仍有 %d 条记录", len(traces))
+	}
+}
+
+func TestRecorderWithoutStore(t *testing.T) {
+	recorder := NewRecorder(NewSampler(1), nil, false, testutil.Logger())
+	recorder.Record(context.Background(), &Trace{TenantID: "tenant-1"})
+
+	traces, err := recorder.List(context.Background(), "tenant-1", 10)
+	if err != nil || len(traces) != 0 {
+		t.Fatalf("List = %v, %v，未配置存储时期望空列表", traces, err)
+	}
+}
@@ -0,0 +1,189 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/internal/config"
+)
+
+// 月度用量键的保留时间，覆盖当月剩余天数
+const usageKeyTTL = 35 * 24 * time.Hour
+
+// checkTimeout 读取配额的超时时间，Redis无响应时尽快放行请求
+const checkTimeout = 500 * time.Millisecond
+
+// ErrQuotaExceeded 租户月度令牌或费用配额已用尽
+var ErrQuotaExceeded = errors.New("租户月度配额已用尽")
+
+// Usage 租户当月用量，未设置上限的维度上限为0
+type Usage struct {
+	TenantID     string  `json:"tenant_id"`
+	Month        string  `json:"month"`
+	UsedTokens   int64   `json:"used_tokens"`
+	LimitTokens  int64   `json:"limit_tokens"`
+	UsedCost     float64 `json:"used_cost"`
+	LimitCost    float64 `json:"limit_cost"`
+	Percent      int     `json:"percent"` // 令牌与费用中较高的用量百分比
+	SoftExceeded bool    `json:"soft_exceeded"`
+}
+
+// limits 租户的月度上限，为0表示该维度不限制
+type limits struct {
+	tokens int64
+	cost   float64
+}
+
+// unlimited 令牌与费用均不限制
+func (l limits) unlimited() bool {
+	return l.tokens <= 0 && l.cost <= 0
+}
+
+// Store 基于Redis的租户月度令牌与费用配额
+type Store struct {
+	redisClient *redis.Client
+	config      *config.QuotaConfig
+	logger      *logrus.Logger
+}
+
+// NewStore 创建配额存储
+// 默认上限与按租户覆盖的上限均来自配置，上限为0表示不限制
+func NewStore(redisClient *redis.Client, cfg *config.QuotaConfig, logger *logrus.Logger) *Store {
+	return &Store{
+		redisClient: redisClient,
+		config:      cfg,
+		logger:      logger,
+	}
+}
+
+// Check 检查租户当月配额，令牌或费用已用尽时返回 ErrQuotaExceeded
+// Redis不可用时放行请求，不因配额统计故障阻断对话
+func (s *Store) Check(ctx context.Context, tenantID string) (*Usage, error) {
+	limits := s.limitsFor(tenantID)
+	if limits.unlimited() {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	month := currentMonth()
+	pipe := s.redisClient.Pipeline()
+	tokensCmd := pipe.Get(ctx, s.tokensKey(tenantID, month))
+	costCmd := pipe.Get(ctx, s.costKey(tenantID, month))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"tenant_id": tenantID,
+			"operation": "quota_check_failed",
+		}).Warn("读取租户配额失败，跳过配额检查")
+		return nil, nil
+	}
+	// 当月尚无用量时键不存在，按0处理
+	usedTokens, _ := tokensCmd.Int64()
+	usedCost, _ := costCmd.Float64()
+
+	usage := s.buildUsage(tenantID, month, usedTokens, usedCost, limits)
+	if limits.tokens > 0 && usedTokens >= limits.tokens {
+		return usage, fmt.Errorf("%w: 已使用 %d / %d 个令牌", ErrQuotaExceeded, usedTokens, limits.tokens)
+	}
+	if limits.cost > 0 && usedCost >= limits.cost {
+		return usage, fmt.Errorf("%w: 已产生费用 %g / %g", ErrQuotaExceeded, usedCost, limits.cost)
+	}
+	return usage, nil
+}
+
+// Record 累加租户当月令牌用量与费用并返回最新用量，租户未设置上限时返回nil
+func (s *Store) Record(ctx context.Context, tenantID string, tokens int, cost float64) (*Usage, error) {
+	if tokens <= 0 && cost <= 0 {
+		return nil, nil
+	}
+
+	month := currentMonth()
+	tokensKey := s.tokensKey(tenantID, month)
+	costKey := s.costKey(tenantID, month)
+
+	pipe := s.redisClient.TxPipeline()
+	tokensCmd := pipe.IncrBy(ctx, tokensKey, int64(tokens))
+	costCmd := pipe.IncrByFloat(ctx, costKey, cost)
+	pipe.Expire(ctx, tokensKey, usageKeyTTL)
+	pipe.Expire(ctx, costKey, usageKeyTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("记录租户用量失败: %w", err)
+	}
+
+	// 未设置上限的租户同样累计用量，之后设置上限时按当月实际用量判断
+	limits := s.limitsFor(tenantID)
+	if limits.unlimited() {
+		return nil, nil
+	}
+	usage := s.buildUsage(tenantID, month, tokensCmd.Val(), costCmd.Val(), limits)
+	if usage.SoftExceeded {
+		s.logger.WithFields(logrus.Fields{
+			"tenant_id":    tenantID,
+			"used_tokens":  usage.UsedTokens,
+			"limit_tokens": usage.LimitTokens,
+			"used_cost":    usage.UsedCost,
+			"limit_cost":   usage.LimitCost,
+			"percent":      usage.Percent,
+			"operation":    "quota_soft_limit",
+		}).Warn("租户用量接近月度上限")
+	}
+	return usage, nil
+}
+
+// limitsFor 获取租户的月度上限
+func (s *Store) limitsFor(tenantID string) limits {
+	limits := limits{
+		tokens: s.config.MonthlyTokenLimit,
+		cost:   s.config.MonthlyCostLimit,
+	}
+	if limit, ok := s.config.TenantLimits[tenantID]; ok {
+		limits.tokens = limit
+	}
+	if limit, ok := s.config.TenantCostLimits[tenantID]; ok {
+		limits.cost = limit
+	}
+	return limits
+}
+
+// buildUsage 构建用量信息，百分比取令牌与费用中较高的一项
+func (s *Store) buildUsage(tenantID, month string, usedTokens int64, usedCost float64, limits limits) *Usage {
+	usage := &Usage{
+		TenantID:   tenantID,
+		Month:      month,
+		UsedTokens: usedTokens,
+		UsedCost:   usedCost,
+	}
+	if limits.tokens > 0 {
+		usage.LimitTokens = limits.tokens
+		usage.Percent = int(usedTokens * 100 / limits.tokens)
+	}
+	if limits.cost > 0 {
+		usage.LimitCost = limits.cost
+		if percent := int(usedCost * 100 / limits.cost); percent > usage.Percent {
+			usage.Percent = percent
+		}
+	}
+	usage.SoftExceeded = s.config.SoftLimitPercent > 0 && usage.Percent >= s.config.SoftLimitPercent
+	return usage
+}
+
+// tokensKey 构建令牌用量的Redis键
+func (s *Store) tokensKey(tenantID, month string) string {
+	return fmt.Sprintf("quota:tokens:%s:%s", tenantID, month)
+}
+
+// costKey 构建费用的Redis键
+func (s *Store) costKey(tenantID, month string) string {
+	return fmt.Sprintf("quota:cost:%s:%s", tenantID, month)
+}
+
+// currentMonth 当前自然月（UTC）
+func currentMonth() string {
+	return time.Now().UTC().Format("2006-01")
+}
@@ -0,0 +1,192 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/internal/config"
+)
+
+// newTestStore 创建使用 miniredis 的配额存储，软上限为80%
+func newTestStore(t *testing.T, cfg config.QuotaConfig) *Store {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	cfg.SoftLimitPercent = 80
+	return NewStore(client, &cfg, logger)
+}
+
+func TestQuotaSoftAndHardThresholds(t *testing.T) {
+	tests := []struct {
+		name         string
+		record       []int
+		wantPercent  int
+		wantSoft     bool
+		wantExceeded bool
+	}{
+		{name: "未使用", wantPercent: 0},
+		{name: "低于软上限", record: []int{500, 200}, wantPercent: 70},
+		{name: "刚好达到软上限", record: []int{800}, wantPercent: 80, wantSoft: true},
+		{name: "超过软上限未达硬上限", record: []int{600, 390}, wantPercent: 99, wantSoft: true},
+		{name: "刚好达到硬上限", record: []int{1000}, wantPercent: 100, wantSoft: true, wantExceeded: true},
+		{name: "超过硬上限", record: []int{900, 300}, wantPercent: 120, wantSoft: true, wantExceeded: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t, config.QuotaConfig{MonthlyTokenLimit: 1000})
+			ctx := context.Background()
+
+			for _, tokens := range tt.record {
+				if _, err := store.Record(ctx, "tenant-1", tokens, 0); err != nil {
+					t.Fatalf("Record: %v", err)
+				}
+			}
+
+			usage, err := store.Check(ctx, "tenant-1")
+			if got := errors.Is(err, ErrQuotaExceeded); got != tt.wantExceeded {
+				t.Fatalf("配额用尽 = %v，期望 %v（err = %v）", got, tt.wantExceeded, err)
+			}
+			if usage.Percent != tt.wantPercent || usage.SoftExceeded != tt.wantSoft {
+				t.Fatalf("usage = %+v，期望 percent %d soft %v", usage, tt.wantPercent, tt.wantSoft)
+			}
+		})
+	}
+}
+
+func TestQuotaCostThresholds(t *testing.T) {
+	tests := []struct {
+		name         string
+		record       []float64
+		wantPercent  int
+		wantSoft     bool
+		wantExceeded bool
+	}{
+		{name: "低于软上限", record: []float64{2.5, 4.5}, wantPercent: 70},
+		{name: "超过软上限未达硬上限", record: []float64{8.5}, wantPercent: 85, wantSoft: true},
+		{name: "超过硬上限", record: []float64{6, 5}, wantPercent: 110, wantSoft: true, wantExceeded: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 令牌上限足够高，只有费用会触发阈值
+			store := newTestStore(t, config.QuotaConfig{MonthlyTokenLimit: 1000000, MonthlyCostLimit: 10})
+			ctx := context.Background()
+
+			for _, cost := range tt.record {
+				if _, err := store.Record(ctx, "tenant-1", 100, cost); err != nil {
+					t.Fatalf("Record: %v", err)
+				}
+			}
+
+			usage, err := store.Check(ctx, "tenant-1")
+			if got := errors.Is(err, ErrQuotaExceeded); got != tt.wantExceeded {
+				t.Fatalf("配额用尽 = %v，期望 %v（err = %v）", got, tt.wantExceeded, err)
+			}
+			if usage.Percent != tt.wantPercent || usage.SoftExceeded != tt.wantSoft || usage.LimitCost != 10 {
+				t.Fatalf("usage = %+v，期望 percent %d soft %v", usage, tt.wantPercent, tt.wantSoft)
+			}
+		})
+	}
+}
+
+func TestQuotaTenantCostLimits(t *testing.T) {
+	store := newTestStore(t, config.QuotaConfig{
+		MonthlyCostLimit: 10,
+		TenantCostLimits: map[string]float64{"vip": 50},
+	})
+	ctx := context.Background()
+
+	for _, tenantID := range []string{"standard", "vip"} {
+		store.Record(ctx, tenantID, 100, 20)
+	}
+
+	if _, err := store.Check(ctx, "standard"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("standard: 期望费用配额用尽，实际 err = %v", err)
+	}
+	usage, err := store.Check(ctx, "vip")
+	if err != nil || usage.UsedCost != 20 || usage.LimitCost != 50 || usage.UsedTokens != 100 {
+		t.Fatalf("vip: usage = %+v, err = %v", usage, err)
+	}
+}
+
+func TestQuotaTenantLimits(t *testing.T) {
+	store := newTestStore(t, config.QuotaConfig{
+		MonthlyTokenLimit: 1000,
+		TenantLimits:      map[string]int64{"vip": 5000, "unlimited": 0},
+	})
+	ctx := context.Background()
+
+	for _, tenantID := range []string{"standard", "vip", "unlimited"} {
+		store.Record(ctx, tenantID, 2000, 0)
+	}
+
+	tests := []struct {
+		tenantID     string
+		wantUsage    bool
+		wantExceeded bool
+	}{
+		{tenantID: "standard", wantUsage: true, wantExceeded: true},
+		{tenantID: "vip", wantUsage: true},
+		{tenantID: "unlimited"},
+	}
+	for _, tt := range tests {
+		usage, err := store.Check(ctx, tt.tenantID)
+		if got := errors.Is(err, ErrQuotaExceeded); got != tt.wantExceeded {
+			t.Fatalf("%s: 配额用尽 = %v，期望 %v", tt.tenantID, got, tt.wantExceeded)
+		}
+		if (usage != nil) != tt.wantUsage {
+			t.Fatalf("%s: usage = %+v", tt.tenantID, usage)
+		}
+	}
+}
+
+func TestQuotaRecordsUsageWithoutLimit(t *testing.T) {
+	store := newTestStore(t, config.QuotaConfig{})
+	ctx := context.Background()
+
+	for _, tokens := range []int{600, 500} {
+		usage, err := store.Record(ctx, "tenant-1", tokens, 1.5)
+		if err != nil || usage != nil {
+			t.Fatalf("未设置上限时 Record = %+v, %v，期望不返回用量", usage, err)
+		}
+	}
+	if usage, err := store.Check(ctx, "tenant-1"); err != nil || usage != nil {
+		t.Fatalf("未设置上限时 Check = %+v, %v，期望放行", usage, err)
+	}
+
+	// 月中设置上限后按已累计的用量判断
+	store.config.MonthlyTokenLimit = 1000
+	usage, err := store.Check(ctx, "tenant-1")
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("设置上限后期望配额用尽，实际 err = %v", err)
+	}
+	if usage.UsedTokens != 1100 || usage.UsedCost != 3 {
+		t.Fatalf("usage = %+v，期望已使用 1100 个令牌、费用 3", usage)
+	}
+}
+
+func TestQuotaCheckFailsOpenWhenRedisDown(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	mr.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	store := NewStore(client, &config.QuotaConfig{MonthlyTokenLimit: 1000, SoftLimitPercent: 80}, logger)
+
+	if usage, err := store.Check(context.Background(), "tenant-1"); usage != nil || err != nil {
+		t.Fatalf("Redis不可用时应放行，实际 %+v, %v", usage, err)
+	}
+}
@@ -0,0 +1,128 @@
+package redact
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Mask 敏感信息的替换值
+const Mask = "***masked***"
+
+var (
+	// Authorization: Bearer xxx
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[^\s"',]+`)
+	// "api_key": "xxx"、"client_secret": "xxx" 等JSON字段
+	jsonFieldPattern = regexp.MustCompile(`(?i)("[a-z_\-]*(?:api[_\-]?key|secret|password|access_token|refresh_token)[a-z_\-]*"\s*:\s*")(?:[^"\\]|\\.)*(")`)
+	// URL查询参数中的密钥
+	queryParamPattern = regexp.MustCompile(`(?i)([?&](?:api[_\-]?key|key|token|access_token|secret)=)[^&\s"]+`)
+	// OpenAI/DeepSeek风格的密钥
+	secretKeyPattern = regexp.MustCompile(`\bsk-[A-Za-z0-9_\-]{16,}`)
+)
+
+// sensitiveKeys 需要整体屏蔽值的字段名片段
+var sensitiveKeys = []string{
+	"api_key", "apikey", "api-key",
+	"secret", "password", "authorization",
+	"access_token", "refresh_token",
+}
+
+// IsSensitiveKey 判断字段名是否表示敏感信息
+func IsSensitiveKey(key string) bool {
+	lowerKey := strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(lowerKey, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// String 屏蔽文本中的Bearer令牌、密钥类JSON字段、URL密钥参数和sk-格式密钥
+func String(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "${1}"+Mask)
+	s = jsonFieldPattern.ReplaceAllString(s, "${1}"+Mask+"${2}")
+	s = queryParamPattern.ReplaceAllString(s, "${1}"+Mask)
+	s = secretKeyPattern.ReplaceAllString(s, Mask)
+	return s
+}
+
+// Headers 将请求头转换为可记录的形式，屏蔽认证相关的值
+func Headers(header http.Header) map[string]string {
+	result := make(map[string]string, len(header))
+	for key, values := range header {
+		if IsSensitiveKey(key) || strings.EqualFold(key, "X-Api-Key") {
+			result[key] = Mask
+			continue
+		}
+		result[key] = String(strings.Join(values, ", "))
+	}
+	return result
+}
+
+// Value 屏蔽单个日志字段值
+// 结构体、map等复合值会序列化为JSON后再屏蔽，避免凭证对象中的 api_key 被输出
+func Value(key string, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		if IsSensitiveKey(key) {
+			return Mask
+		}
+		return String(v)
+	case error:
+		return errors.New(String(v.Error()))
+	case []byte:
+		return String(string(v))
+	case http.Header:
+		return Headers(v)
+	}
+
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return value
+		}
+		if masked := String(string(data)); masked != string(data) {
+			return masked
+		}
+	}
+
+	return value
+}
+
+// Fields 屏蔽日志字段中的敏感信息
+func Fields(fields logrus.Fields) logrus.Fields {
+	redacted := make(logrus.Fields, len(fields))
+	for key, value := range fields {
+		redacted[key] = Value(key, value)
+	}
+	return redacted
+}
+
+// Formatter 在输出前屏蔽敏感信息的日志格式化器
+type Formatter struct {
+	inner logrus.Formatter
+}
+
+// NewFormatter 创建屏蔽敏感信息的日志格式化器
+func NewFormatter(inner logrus.Formatter) *Formatter {
+	return &Formatter{inner: inner}
+}
+
+// Format 屏蔽日志消息和字段后交给内部格式化器输出
+func (f *Formatter) Format(entry *logrus.Entry) ([]byte, error) {
+	redacted := *entry
+	redacted.Data = Fields(entry.Data)
+	redacted.Message = String(entry.Message)
+	return f.inner.Format(&redacted)
+}
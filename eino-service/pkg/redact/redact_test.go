@@ -0,0 +1,107 @@
+package redact
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"lyss-ai-platform/eino-service/internal/models"
+)
+
+const testSecret = "sk-abcdef0123456789abcdef"
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "Bearer令牌", input: "Authorization: Bearer abc.def", want: "Authorization: Bearer " + Mask},
+		{name: "JSON api_key字段", input: `{"api_key":"plain-key","model":"x"}`, want: `{"api_key":"` + Mask + `","model":"x"}`},
+		{name: "JSON secret字段", input: `{"client_secret": "s3cr\"et"}`, want: `{"client_secret": "` + Mask + `"}`},
+		{name: "URL密钥参数", input: "https://example.com/v1?key=abc&model=x", want: "https://example.com/v1?key=" + Mask + "&model=x"},
+		{name: "sk-格式密钥", input: "invalid key " + testSecret, want: "invalid key " + Mask},
+		{name: "普通文本不变", input: "model=deepseek-chat tokens=10", want: "model=deepseek-chat tokens=10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := String(tt.input); got != tt.want {
+				t.Fatalf("String(%q) = %q，期望 %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+testSecret)
+	header.Set("X-Api-Key", "plain-key")
+	header.Set("Content-Type", "application/json")
+
+	got := Headers(header)
+	if got["Authorization"] != Mask || got["X-Api-Key"] != Mask {
+		t.Fatalf("认证请求头未屏蔽: %v", got)
+	}
+	if got["Content-Type"] != "application/json" {
+		t.Fatalf("Content-Type = %q，普通请求头不应屏蔽", got["Content-Type"])
+	}
+}
+
+func TestValue(t *testing.T) {
+	credential := &models.SupplierCredential{Provider: "deepseek", APIKey: testSecret}
+
+	tests := []struct {
+		name  string
+		key   string
+		value interface{}
+	}{
+		{name: "敏感字段名", key: "api_key", value: "plain-key"},
+		{name: "错误信息", key: "error", value: errors.New("401: " + testSecret)},
+		{name: "凭证结构体", key: "credential", value: credential},
+		{name: "map中的密钥", key: "body", value: map[string]string{"api_key": "plain-key"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var text string
+			switch got := Value(tt.key, tt.value).(type) {
+			case error:
+				text = got.Error()
+			case string:
+				text = got
+			default:
+				t.Fatalf("Value 返回 %T，期望屏蔽后的文本", got)
+			}
+			if strings.Contains(text, testSecret) || strings.Contains(text, "plain-key") {
+				t.Fatalf("屏蔽结果 %q 仍包含密钥", text)
+			}
+		})
+	}
+}
+
+func TestFormatterNeverPrintsAPIKey(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	logger.SetFormatter(NewFormatter(&logrus.JSONFormatter{}))
+
+	credential := &models.SupplierCredential{Provider: "deepseek", APIKey: testSecret}
+	logger.WithFields(logrus.Fields{
+		"credential": credential,
+		"api_key":    credential.APIKey,
+		"headers":    http.Header{"Authorization": []string{"Bearer " + testSecret}},
+	}).Errorf("调用失败，密钥 %s", credential.APIKey)
+
+	output := buf.String()
+	if strings.Contains(output, testSecret) {
+		t.Fatalf("日志输出包含密钥: %s", output)
+	}
+	if !strings.Contains(output, Mask) {
+		t.Fatalf("日志输出未包含屏蔽标记: %s", output)
+	}
+}
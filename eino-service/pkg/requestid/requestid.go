@@ -0,0 +1,62 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+)
+
+// Header 请求ID使用的HTTP头
+const Header = "X-Request-ID"
+
+// contextKey 请求ID在 context 中的键
+type contextKey struct{}
+
+// WithRequestID 将请求ID写入 context，空请求ID时原样返回
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	if requestID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, requestID)
+}
+
+// FromContext 从 context 读取请求ID，不存在时返回空字符串
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(contextKey{}).(string)
+	return requestID
+}
+
+// SetHeader 将请求 context 中的请求ID写入出站请求头，已显式设置时保持不变
+func SetHeader(req *http.Request) {
+	if req.Header.Get(Header) != "" {
+		return
+	}
+	if requestID := FromContext(req.Context()); requestID != "" {
+		req.Header.Set(Header, requestID)
+	}
+}
+
+// Transport 为所有出站请求附加请求ID的传输层
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// RoundTrip 实现 http.RoundTripper 接口
+// 按 RoundTripper 约定不修改原请求，需要附加请求头时复制后发送
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	requestID := FromContext(req.Context())
+	if requestID == "" || req.Header.Get(Header) != "" {
+		return base.RoundTrip(req)
+	}
+
+	outbound := req.Clone(req.Context())
+	outbound.Header.Set(Header, requestID)
+	return base.RoundTrip(outbound)
+}
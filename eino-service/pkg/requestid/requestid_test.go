@@ -0,0 +1,80 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTransportSetsRequestIDHeader(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		explicit  string
+		want      string
+	}{
+		{name: "从context附加请求ID", requestID: "req-1", want: "req-1"},
+		{name: "context中没有请求ID"},
+		{name: "保留显式设置的请求头", requestID: "req-1", explicit: "upstream-id", want: "upstream-id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r.Header.Get(Header)
+			}))
+			defer server.Close()
+
+			ctx := WithRequestID(context.Background(), tt.requestID)
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+			if tt.explicit != "" {
+				req.Header.Set(Header, tt.explicit)
+			}
+
+			httpClient := &http.Client{Transport: &Transport{}}
+			resp, err := httpClient.Do(req)
+			if err != nil {
+				t.Fatalf("发送请求失败: %v", err)
+			}
+			resp.Body.Close()
+
+			if received != tt.want {
+				t.Fatalf("下游收到的 %s = %q，期望 %q", Header, received, tt.want)
+			}
+			if tt.explicit == "" && req.Header.Get(Header) != "" {
+				t.Fatal("Transport 不应修改调用方的原始请求")
+			}
+		})
+	}
+}
+
+func TestSetHeader(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		explicit  string
+		want      string
+	}{
+		{name: "写入context中的请求ID", requestID: "req-1", want: "req-1"},
+		{name: "空请求ID不写入"},
+		{name: "不覆盖已有请求头", requestID: "req-1", explicit: "upstream-id", want: "upstream-id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequestWithContext(WithRequestID(context.Background(), tt.requestID), http.MethodGet, "http://example.com", nil)
+			if tt.explicit != "" {
+				req.Header.Set(Header, tt.explicit)
+			}
+			SetHeader(req)
+			if got := req.Header.Get(Header); got != tt.want {
+				t.Fatalf("%s = %q，期望 %q", Header, got, tt.want)
+			}
+			if got := FromContext(req.Context()); got != tt.requestID {
+				t.Fatalf("FromContext = %q，期望 %q", got, tt.requestID)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package responsecache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Store 基于Redis的确定性请求响应缓存，键限定在租户范围内
+type Store struct {
+	redisClient *redis.Client
+	ttl         time.Duration
+}
+
+// NewStore 创建响应缓存，ttl 为缓存响应的保留时间
+func NewStore(redisClient *redis.Client, ttl time.Duration) *Store {
+	return &Store{
+		redisClient: redisClient,
+		ttl:         ttl,
+	}
+}
+
+// Get 读取缓存的响应，未命中时返回 found=false
+func (s *Store) Get(ctx context.Context, tenantID, key string) (payload []byte, found bool, err error) {
+	payload, err = s.redisClient.Get(ctx, s.buildKey(tenantID, key)).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("读取响应缓存失败: %w", err)
+	}
+	return payload, true, nil
+}
+
+// Set 保存响应
+func (s *Store) Set(ctx context.Context, tenantID, key string, payload []byte) error {
+	if err := s.redisClient.Set(ctx, s.buildKey(tenantID, key), payload, s.ttl).Err(); err != nil {
+		return fmt.Errorf("保存响应缓存失败: %w", err)
+	}
+	return nil
+}
+
+// buildKey 构建Redis键
+func (s *Store) buildKey(tenantID, key string) string {
+	return fmt.Sprintf("response_cache:%s:%s", tenantID, key)
+}
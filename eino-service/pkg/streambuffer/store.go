@@ -0,0 +1,167 @@
+package streambuffer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrExpired 执行的事件缓冲已过期，或断点之后的事件已被淘汰，无法续传
+var ErrExpired = errors.New("流式事件缓冲已过期")
+
+// ErrInvalidID 无法解析的事件ID
+var ErrInvalidID = errors.New("无效的事件ID")
+
+// Event 缓冲的SSE事件
+type Event struct {
+	Seq   int64  `json:"seq"`             // 执行内从1开始单调递增的序号
+	Frame string `json:"frame"`           // 不含 id 行的SSE帧
+	Final bool   `json:"final,omitempty"` // 是否为流的最后一帧
+}
+
+// Store 基于Redis的流式事件缓冲，保留每次流式执行最近的事件用于断线续传
+type Store struct {
+	redisClient *redis.Client
+	ttl         time.Duration
+	maxEvents   int64
+}
+
+// NewStore 创建流式事件缓冲
+// ttl 为最后一次写入后缓冲的保留时间，maxEvents 为每次执行保留的最大事件数，0表示不限制
+func NewStore(redisClient *redis.Client, ttl time.Duration, maxEvents int) *Store {
+	return &Store{
+		redisClient: redisClient,
+		ttl:         ttl,
+		maxEvents:   int64(maxEvents),
+	}
+}
+
+// FormatID 生成SSE事件ID，格式为 <execution_id>:<seq>
+func FormatID(executionID string, seq int64) string {
+	return fmt.Sprintf("%s:%d", executionID, seq)
+}
+
+// ParseID 解析 Last-Event-ID
+func ParseID(id string) (executionID string, seq int64, err error) {
+	index := strings.LastIndex(id, ":")
+	if index <= 0 {
+		return "", 0, fmt.Errorf("%w: %s", ErrInvalidID, id)
+	}
+	seq, err = strconv.ParseInt(id[index+1:], 10, 64)
+	if err != nil || seq < 0 {
+		return "", 0, fmt.Errorf("%w: %s", ErrInvalidID, id)
+	}
+	return id[:index], seq, nil
+}
+
+// Append 批量追加事件并刷新缓冲的保留时间，超出 maxEvents 时淘汰最早的事件
+func (s *Store) Append(ctx context.Context, tenantID, executionID string, events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	payloads := make([]interface{}, 0, len(events))
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("序列化流式事件失败: %w", err)
+		}
+		payloads = append(payloads, payload)
+	}
+
+	key := s.buildKey(tenantID, executionID)
+	pipe := s.redisClient.TxPipeline()
+	pipe.RPush(ctx, key, payloads...)
+	if s.maxEvents > 0 {
+		pipe.LTrim(ctx, key, -s.maxEvents, -1)
+	}
+	pipe.Expire(ctx, key, s.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("写入流式事件缓冲失败: %w", err)
+	}
+	return nil
+}
+
+// After 读取序号大于 seq 的事件
+// 按最早保留事件的序号计算断点在列表中的偏移，只读取断点之后的部分
+// 缓冲不存在，或 seq 之后的部分事件已被淘汰时返回 ErrExpired
+func (s *Store) After(ctx context.Context, tenantID, executionID string, seq int64) ([]Event, error) {
+	key := s.buildKey(tenantID, executionID)
+
+	// 两次读取之间可能有写入淘汰了最早的事件，偏移失效时重新计算一次
+	for attempt := 0; attempt < 2; attempt++ {
+		first, err := s.redisClient.LIndex(ctx, key, 0).Result()
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrExpired
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取流式事件缓冲失败: %w", err)
+		}
+		firstEvent, err := decodeEvent(first)
+		if err != nil {
+			return nil, err
+		}
+		// 最早保留的事件晚于断点的下一条时说明中间有事件被淘汰
+		if firstEvent.Seq > seq+1 {
+			return nil, ErrExpired
+		}
+
+		values, err := s.redisClient.LRange(ctx, key, seq+1-firstEvent.Seq, -1).Result()
+		if err != nil {
+			return nil, fmt.Errorf("读取流式事件缓冲失败: %w", err)
+		}
+		events := make([]Event, 0, len(values))
+		for _, value := range values {
+			event, err := decodeEvent(value)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+		}
+		if len(events) == 0 || events[0].Seq == seq+1 {
+			return events, nil
+		}
+	}
+	return nil, ErrExpired
+}
+
+// Attach 标记有客户端正在续传该执行，标记在 ttl 后过期，续传期间需定期刷新
+func (s *Store) Attach(ctx context.Context, tenantID, executionID string, ttl time.Duration) error {
+	if err := s.redisClient.Set(ctx, s.buildAttachKey(tenantID, executionID), "1", ttl).Err(); err != nil {
+		return fmt.Errorf("标记流式续传失败: %w", err)
+	}
+	return nil
+}
+
+// Attached 是否有客户端正在续传该执行
+func (s *Store) Attached(ctx context.Context, tenantID, executionID string) (bool, error) {
+	count, err := s.redisClient.Exists(ctx, s.buildAttachKey(tenantID, executionID)).Result()
+	if err != nil {
+		return false, fmt.Errorf("读取流式续传标记失败: %w", err)
+	}
+	return count > 0, nil
+}
+
+// decodeEvent 解析缓冲中的事件
+func decodeEvent(value string) (Event, error) {
+	var event Event
+	if err := json.Unmarshal([]byte(value), &event); err != nil {
+		return Event{}, fmt.Errorf("解析流式事件失败: %w", err)
+	}
+	return event, nil
+}
+
+// buildKey 构建Redis键
+func (s *Store) buildKey(tenantID, executionID string) string {
+	return fmt.Sprintf("stream_buffer:%s:%s", tenantID, executionID)
+}
+
+// buildAttachKey 构建续传标记的Redis键
+func (s *Store) buildAttachKey(tenantID, executionID string) string {
+	return fmt.Sprintf("stream_buffer_attached:%s:%s", tenantID, executionID)
+}
@@ -0,0 +1,144 @@
+package streambuffer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// newTestStore 创建使用 miniredis 的流式事件缓冲
+func newTestStore(t *testing.T, maxEvents int) (*Store, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewStore(client, time.Minute, maxEvents), mr
+}
+
+// appendEvents 追加序号为 from 到 to 的事件
+func appendEvents(t *testing.T, store *Store, from, to int64) {
+	t.Helper()
+	var events []Event
+	for seq := from; seq <= to; seq++ {
+		events = append(events, Event{Seq: seq, Frame: "data: x\n\n"})
+	}
+	if err := store.Append(context.Background(), "tenant-1", "exec-1", events...); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+}
+
+func TestAfterReadsFromOffset(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxEvents int
+		appended  int64
+		after     int64
+		wantFirst int64
+		wantCount int
+		wantErr   error
+	}{
+		{name: "从头读取", maxEvents: 0, appended: 5, after: 0, wantFirst: 1, wantCount: 5},
+		{name: "从断点之后读取", maxEvents: 0, appended: 5, after: 3, wantFirst: 4, wantCount: 2},
+		{name: "没有新事件", maxEvents: 0, appended: 5, after: 5, wantCount: 0},
+		{name: "淘汰后断点仍在缓冲内", maxEvents: 3, appended: 5, after: 2, wantFirst: 3, wantCount: 3},
+		{name: "断点之后的事件已被淘汰", maxEvents: 3, appended: 5, after: 1, wantErr: ErrExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, _ := newTestStore(t, tt.maxEvents)
+			appendEvents(t, store, 1, tt.appended)
+
+			events, err := store.After(context.Background(), "tenant-1", "exec-1", tt.after)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("After 错误 = %v，期望 %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("After: %v", err)
+			}
+			if len(events) != tt.wantCount {
+				t.Fatalf("事件数 = %d，期望 %d", len(events), tt.wantCount)
+			}
+			for i, event := range events {
+				if want := tt.wantFirst + int64(i); event.Seq != want {
+					t.Fatalf("第 %d 个事件序号 = %d，期望 %d", i, event.Seq, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAfterExpiredBuffer(t *testing.T) {
+	store, mr := newTestStore(t, 0)
+	appendEvents(t, store, 1, 2)
+
+	// 其他租户读不到该执行的缓冲
+	if _, err := store.After(context.Background(), "tenant-2", "exec-1", 0); !errors.Is(err, ErrExpired) {
+		t.Fatalf("其他租户 After 错误 = %v，期望 ErrExpired", err)
+	}
+
+	mr.FastForward(2 * time.Minute)
+	if _, err := store.After(context.Background(), "tenant-1", "exec-1", 0); !errors.Is(err, ErrExpired) {
+		t.Fatalf("过期后 After 错误 = %v，期望 ErrExpired", err)
+	}
+}
+
+func TestAttachExpires(t *testing.T) {
+	store, mr := newTestStore(t, 0)
+	ctx := context.Background()
+
+	if attached, err := store.Attached(ctx, "tenant-1", "exec-1"); err != nil || attached {
+		t.Fatalf("未续传: attached=%v err=%v", attached, err)
+	}
+	if err := store.Attach(ctx, "tenant-1", "exec-1", time.Second); err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	if attached, err := store.Attached(ctx, "tenant-1", "exec-1"); err != nil || !attached {
+		t.Fatalf("续传中: attached=%v err=%v", attached, err)
+	}
+
+	mr.FastForward(2 * time.Second)
+	if attached, err := store.Attached(ctx, "tenant-1", "exec-1"); err != nil || attached {
+		t.Fatalf("标记过期后: attached=%v err=%v", attached, err)
+	}
+}
+
+func TestParseID(t *testing.T) {
+	tests := []struct {
+		name          string
+		id            string
+		wantExecution string
+		wantSeq       int64
+		wantErr       bool
+	}{
+		{name: "正常", id: FormatID("exec-1", 7), wantExecution: "exec-1", wantSeq: 7},
+		{name: "执行ID含冒号", id: "a:b:3", wantExecution: "a:b", wantSeq: 3},
+		{name: "缺少序号", id: "exec-1", wantErr: true},
+		{name: "序号不是数字", id: "exec-1:x", wantErr: true},
+		{name: "负序号", id: "exec-1:-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executionID, seq, err := ParseID(tt.id)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidID) {
+					t.Fatalf("ParseID(%q) 错误 = %v，期望 ErrInvalidID", tt.id, err)
+				}
+				return
+			}
+			if err != nil || executionID != tt.wantExecution || seq != tt.wantSeq {
+				t.Fatalf("ParseID(%q) = %q, %d, %v，期望 %q, %d", tt.id, executionID, seq, err, tt.wantExecution, tt.wantSeq)
+			}
+		})
+	}
+}
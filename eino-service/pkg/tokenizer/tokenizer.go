@@ -0,0 +1,113 @@
+package tokenizer
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Counter 令牌计数器
+type Counter interface {
+	// Count 统计文本的令牌数
+	Count(text string) int
+}
+
+// CounterFunc 将函数适配为 Counter
+type CounterFunc func(text string) int
+
+// Count 实现 Counter 接口
+func (f CounterFunc) Count(text string) int {
+	return f(text)
+}
+
+// registry 按模型覆盖的计数器
+type registry struct {
+	mutex    sync.RWMutex
+	counters map[string]Counter
+	prefixes []string // 按长度降序，最长前缀优先匹配
+}
+
+var defaultRegistry = &registry{counters: make(map[string]Counter)}
+
+// Default 默认计数器，按 BPE 分词的常见规律近似估算
+var Default Counter = CounterFunc(approximateCount)
+
+// Register 为模型注册专用计数器
+// model 以 * 结尾时按前缀匹配（如 "gpt-4*"），否则精确匹配；精确匹配优先于前缀匹配
+func Register(model string, counter Counter) {
+	defaultRegistry.mutex.Lock()
+	defer defaultRegistry.mutex.Unlock()
+
+	if _, exists := defaultRegistry.counters[model]; !exists && strings.HasSuffix(model, "*") {
+		defaultRegistry.prefixes = append(defaultRegistry.prefixes, model)
+		sort.Slice(defaultRegistry.prefixes, func(i, j int) bool {
+			return len(defaultRegistry.prefixes[i]) > len(defaultRegistry.prefixes[j])
+		})
+	}
+	defaultRegistry.counters[model] = counter
+}
+
+// CountTokens 使用模型对应的计数器统计文本令牌数，未注册的模型使用默认计数器
+func CountTokens(model, text string) int {
+	if text == "" {
+		return 0
+	}
+	return defaultRegistry.counter(model).Count(text)
+}
+
+// counter 查找模型对应的计数器
+func (r *registry) counter(model string) Counter {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if counter, exists := r.counters[model]; exists {
+		return counter
+	}
+	for _, prefix := range r.prefixes {
+		if strings.HasPrefix(model, strings.TrimSuffix(prefix, "*")) {
+			return r.counters[prefix]
+		}
+	}
+	return Default
+}
+
+// approximateCount 近似估算令牌数
+// 英文单词约每4个字母1个令牌，数字约每3位1个令牌，标点各占1个令牌，空白并入后一个词；
+// 中日韩文字等非ASCII字符通常每字至少1个令牌
+func approximateCount(text string) int {
+	tokens := 0
+	letters, digits := 0, 0
+	flush := func() {
+		tokens += (letters + 3) / 4
+		tokens += (digits + 2) / 3
+		letters, digits = 0, 0
+	}
+
+	for _, r := range text {
+		switch {
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || r == '_'):
+			if digits > 0 {
+				flush()
+			}
+			letters++
+		case r < utf8.RuneSelf && unicode.IsDigit(r):
+			if letters > 0 {
+				flush()
+			}
+			digits++
+		case unicode.IsSpace(r):
+			flush()
+		case r < utf8.RuneSelf:
+			flush()
+			tokens++
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+
+	return tokens
+}
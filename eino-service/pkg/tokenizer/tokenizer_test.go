@@ -0,0 +1,80 @@
+package tokenizer
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCountTokensRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantMin int
+		wantMax int
+	}{
+		{name: "空文本", text: "", wantMin: 0, wantMax: 0},
+		{name: "英文句子", text: "Hello, world! This is a test.", wantMin: 6, wantMax: 14},
+		{name: "英文长单词", text: "internationalization", wantMin: 3, wantMax: 6},
+		{name: "数字", text: "1234567890", wantMin: 2, wantMax: 5},
+		{name: "中文句子", text: "你好，世界！今天天气很好。", wantMin: 13, wantMax: 26},
+		{name: "日文", text: "こんにちは世界", wantMin: 7, wantMax: 14},
+		{name: "中英混合", text: "我在用 Go 写代码", wantMin: 6, wantMax: 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CountTokens("unregistered-model", tt.text)
+			if got < tt.wantMin || got > tt.wantMax {
+				t.Fatalf("CountTokens(%q) = %d，期望在 [%d, %d] 之间", tt.text, got, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestCountTokensCJKNotUnderestimated(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{name: "中文", text: "人工智能平台为租户提供统一的模型调用能力"},
+		{name: "韩文", text: "안녕하세요 세계"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CountTokens("unregistered-model", tt.text)
+			// 按字节数除以4的旧估算会把中日韩文本低估约一半以上
+			if legacy := len(tt.text) / 4; got <= legacy {
+				t.Fatalf("CountTokens = %d，不应低于按字节估算的 %d", got, legacy)
+			}
+			if runes := utf8.RuneCountInString(tt.text); got < runes/2 {
+				t.Fatalf("CountTokens = %d，期望接近字符数 %d", got, runes)
+			}
+		})
+	}
+}
+
+func TestRegisterOverridesPerModel(t *testing.T) {
+	Register("tokenizer-test-exact", CounterFunc(func(string) int { return 1 }))
+	Register("tokenizer-test-*", CounterFunc(func(string) int { return 2 }))
+	Register("tokenizer-test-long-*", CounterFunc(func(string) int { return 3 }))
+
+	tests := []struct {
+		name  string
+		model string
+		want  int
+	}{
+		{name: "精确匹配优先", model: "tokenizer-test-exact", want: 1},
+		{name: "前缀匹配", model: "tokenizer-test-other", want: 2},
+		{name: "最长前缀优先", model: "tokenizer-test-long-model", want: 3},
+		{name: "未注册使用默认计数器", model: "other-model", want: Default.Count("hello")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CountTokens(tt.model, "hello"); got != tt.want {
+				t.Fatalf("CountTokens(%q) = %d，期望 %d", tt.model, got, tt.want)
+			}
+		})
+	}
+}
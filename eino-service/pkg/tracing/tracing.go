@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"lyss-ai-platform/eino-service/internal/config"
+)
+
+// instrumentationName 本服务创建的 span 使用的 tracer 名称
+const instrumentationName = "lyss-ai-platform/eino-service"
+
+// span 属性键，只记录标识信息，不记录凭证和消息内容
+const (
+	TenantIDKey     = attribute.Key("tenant.id")
+	UserIDKey       = attribute.Key("user.id")
+	RequestIDKey    = attribute.Key("request.id")
+	ExecutionIDKey  = attribute.Key("workflow.execution_id")
+	WorkflowTypeKey = attribute.Key("workflow.type")
+	CacheHitKey     = attribute.Key("workflow.cache_hit")
+)
+
+// Init 初始化全局 TracerProvider 与 W3C Trace Context 传播器，返回关闭函数
+// 未配置 endpoint 时保持 OpenTelemetry 默认的 no-op 实现，只透传上游的追踪上下文
+func Init(ctx context.Context, config *config.TracingConfig, logger *logrus.Logger) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if config.Endpoint == "" {
+		logger.Info("未配置追踪导出地址，链路追踪已关闭")
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(config.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("创建追踪导出器失败: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(config.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("创建追踪资源失败: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	logger.WithFields(logrus.Fields{
+		"endpoint":     config.Endpoint,
+		"service_name": config.ServiceName,
+		"sample_ratio": config.SampleRatio,
+		"operation":    "tracing_init",
+	}).Info("链路追踪已启用")
+
+	return provider.Shutdown, nil
+}
+
+// Tracer 返回本服务的 tracer，每次从全局 TracerProvider 获取以便替换实现
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// IdentityAttributes 租户与用户标识属性，空值不记录
+func IdentityAttributes(tenantID, userID string) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, 2)
+	if tenantID != "" {
+		attrs = append(attrs, TenantIDKey.String(tenantID))
+	}
+	if userID != "" {
+		attrs = append(attrs, UserIDKey.String(userID))
+	}
+	return attrs
+}
+
+// RecordError 在 span 上记录错误并标记失败状态，err 为 nil 时不做处理
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
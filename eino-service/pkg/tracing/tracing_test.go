@@ -0,0 +1,133 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newSpanRecorder 将全局 TracerProvider 替换为内存记录器，测试结束后恢复
+func newSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previousProvider := otel.GetTracerProvider()
+	previousPropagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return recorder
+}
+
+// spanAttribute 获取 span 的属性值
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestMiddlewareAndTransportSpanChain(t *testing.T) {
+	const upstreamTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	tests := []struct {
+		name        string
+		traceparent string
+	}{
+		{name: "新建追踪"},
+		{name: "继续上游追踪", traceparent: "00-" + upstreamTraceID + "-00f067aa0ba902b7-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := newSpanRecorder(t)
+
+			var outboundTraceparent string
+			provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				outboundTraceparent = r.Header.Get("traceparent")
+				w.Write([]byte("ok"))
+			}))
+			t.Cleanup(provider.Close)
+			httpClient := &http.Client{Transport: &Transport{}}
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(Middleware())
+			router.POST("/api/v1/chat", func(c *gin.Context) {
+				req, _ := http.NewRequestWithContext(c.Request.Context(), http.MethodPost, provider.URL+"/chat/completions?key=sk-secret", nil)
+				resp, err := httpClient.Do(req)
+				if err != nil {
+					c.Status(http.StatusBadGateway)
+					return
+				}
+				resp.Body.Close()
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/chat", nil)
+			req.Header.Set("X-Tenant-ID", "tenant-1")
+			req.Header.Set("X-User-ID", "user-1")
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			if len(spans) != 2 {
+				t.Fatalf("span 数 = %d，期望服务端与客户端各一个", len(spans))
+			}
+			client, server := spans[0], spans[1]
+			if server.SpanKind() != trace.SpanKindServer || client.SpanKind() != trace.SpanKindClient {
+				t.Fatalf("span 类型 = %v/%v，期望 server/client", server.SpanKind(), client.SpanKind())
+			}
+			if server.Name() != "POST /api/v1/chat" || client.Name() != "HTTP POST" {
+				t.Fatalf("span 名称 = %q/%q", server.Name(), client.Name())
+			}
+
+			// 客户端 span 是服务端 span 的子 span
+			if client.Parent().SpanID() != server.SpanContext().SpanID() || client.SpanContext().TraceID() != server.SpanContext().TraceID() {
+				t.Fatalf("客户端 span 的父 span = %v，期望 %v", client.Parent().SpanID(), server.SpanContext().SpanID())
+			}
+			if tt.traceparent != "" {
+				if got := server.SpanContext().TraceID().String(); got != upstreamTraceID {
+					t.Fatalf("trace id = %s，期望继续上游 %s", got, upstreamTraceID)
+				}
+				if !server.Parent().IsRemote() {
+					t.Fatal("服务端 span 的父 span 应来自上游请求头")
+				}
+			}
+
+			// 出站请求携带客户端 span 的追踪上下文
+			want := "00-" + client.SpanContext().TraceID().String() + "-" + client.SpanContext().SpanID().String() + "-01"
+			if outboundTraceparent != want {
+				t.Fatalf("出站 traceparent = %q，期望 %q", outboundTraceparent, want)
+			}
+
+			if spanAttribute(server, TenantIDKey) != "tenant-1" || spanAttribute(server, UserIDKey) != "user-1" {
+				t.Fatalf("服务端 span 属性 = %v，期望包含租户与用户", server.Attributes())
+			}
+			for _, attr := range client.Attributes() {
+				if strings.Contains(attr.Value.Emit(), "sk-secret") {
+					t.Fatalf("客户端 span 属性 %s 包含查询参数中的密钥", attr.Key)
+				}
+			}
+		})
+	}
+}
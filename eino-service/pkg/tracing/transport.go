@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Transport 为出站请求创建客户端 span 并在请求头中传播追踪上下文
+// span 在响应体关闭时结束，流式响应的耗时包含读取全部数据的时间
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// RoundTrip 实现 http.RoundTripper 接口
+// 按 RoundTripper 约定不修改原请求，复制后写入追踪请求头；URL 只记录主机和路径，避免查询参数中的密钥进入追踪数据
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx, span := Tracer().Start(req.Context(), fmt.Sprintf("HTTP %s", req.Method),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.ServerAddress(req.URL.Hostname()),
+			semconv.URLPath(req.URL.Path),
+		),
+	)
+
+	outbound := req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(outbound.Header))
+
+	resp, err := base.RoundTrip(outbound)
+	if err != nil {
+		RecordError(span, err)
+		span.End()
+		return nil, err
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	resp.Body = &spanBody{body: resp.Body, span: span}
+	return resp, nil
+}
+
+// spanBody 关闭时结束客户端 span 的响应体
+type spanBody struct {
+	body io.ReadCloser
+	span trace.Span
+	once sync.Once
+}
+
+// Read 读取响应体，读取失败时记录到 span
+func (b *spanBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if err != nil && err != io.EOF {
+		RecordError(b.span, err)
+	}
+	return n, err
+}
+
+// Close 关闭响应体并结束 span
+func (b *spanBody) Close() error {
+	b.once.Do(func() { b.span.End() })
+	return b.body.Close()
+}